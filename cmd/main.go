@@ -1,14 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/mailer"
 	"vertice-backend/internal/repository"
 	"vertice-backend/internal/service"
+	"vertice-backend/internal/storage"
 	"vertice-backend/migrations"
+	"vertice-backend/pkg/password"
 	"vertice-backend/routes"
 
 	"github.com/joho/godotenv"
@@ -44,14 +53,179 @@ func main() {
 		log.Fatalf("Error in migration: %v", err)
 	}
 
+	tx := repository.NewGormTransactor()
+	outboxRepo := repository.NewOutboxGormRepository()
+	outbox := service.NewOutbox(outboxRepo)
+	eventBus := service.NewEventBus()
+	streamHub := service.NewStreamHub()
+	eventBus.Subscribe(service.AllEvents, streamHub.Handle)
+
 	userRepo := repository.NewUserGormRepository()
+
+	mail, err := mailer.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Error configuring mailer: %v", err)
+	}
+	var notificationOpts []service.NotificationServiceOption
+	if mail != nil {
+		notificationOpts = append(notificationOpts, service.WithNotificationMailer(mail, userRepo))
+	}
+	notificationService := service.NewNotificationService(repository.NewNotificationGormRepository(), notificationOpts...)
+	eventBus.Subscribe(domain.EventProductStockLow, notificationService.Handle)
+
+	webhookRepo := repository.NewWebhookGormRepository()
+	webhookService := service.NewWebhookService(webhookRepo, service.WithWebhookTransactor(tx))
+	go webhookService.Run(context.Background(), 5*time.Second)
+
+	blobStore, err := storage.NewBlobStoreFromEnv()
+	if err != nil {
+		log.Fatalf("Error configuring blob storage: %v", err)
+	}
+
 	productRepo := repository.NewProductGormRepository()
+	variantRepo := repository.NewVariantGormRepository()
+	categoryRepo := repository.NewCategoryGormRepository()
+	priceChangeRepo := repository.NewPriceChangeGormRepository()
 
-	userService := service.NewUserService(userRepo)
-	productService := service.NewProductService(productRepo)
+	productService := service.NewProductService(productRepo,
+		service.WithProductTransactor(tx),
+		service.WithProductEvents(outbox),
+		service.WithProductVariants(variantRepo),
+		service.WithProductCategories(categoryRepo),
+		service.WithProductBlobStore(blobStore),
+		service.WithProductPriceHistory(priceChangeRepo),
+	)
+	productImageService := service.NewProductImageService(repository.NewProductImageGormRepository(), productRepo, blobStore,
+		service.WithImageTransactor(tx),
+	)
+	importService := service.NewImportService(productRepo, repository.NewImportJobGormRepository(),
+		service.WithImportTransactor(tx),
+		service.WithImportEvents(outbox),
+		service.WithImportPriceHistory(priceChangeRepo),
+	)
+	go importService.Run(context.Background(), time.Minute)
+	categoryService := service.NewCategoryService(categoryRepo, productRepo)
+	tagService := service.NewTagService(repository.NewTagGormRepository(), productRepo)
+	variantService := service.NewVariantService(productRepo, variantRepo,
+		service.WithVariantTransactor(tx),
+		service.WithVariantEvents(outbox),
+	)
+
+	bundleService := service.NewBundleService(repository.NewBundleGormRepository(), productRepo,
+		service.WithBundleTransactor(tx),
+	)
+
+	lotRepo := repository.NewLotGormRepository()
+	serialRepo := repository.NewSerialGormRepository()
+	lotService := service.NewLotService(lotRepo, productRepo,
+		service.WithLotTransactor(tx),
+		service.WithLotEvents(outbox),
+		service.WithLotVariants(variantRepo),
+	)
+
+	stocktakeService := service.NewStocktakeService(repository.NewStocktakeGormRepository(), productRepo,
+		service.WithStocktakeTransactor(tx),
+		service.WithStocktakeEvents(outbox),
+		service.WithStocktakeVariants(variantRepo),
+		service.WithStocktakeCategories(categoryRepo),
+	)
+
+	priceChangeService := service.NewPriceChangeService(priceChangeRepo, productRepo,
+		service.WithPriceChangeTransactor(tx),
+		service.WithPriceChangeEvents(outbox),
+	)
+	go priceChangeService.Run(context.Background(), time.Minute)
+
+	customerRepo := repository.NewCustomerGormRepository()
+	priceListRepo := repository.NewPriceListGormRepository()
+	customerService := service.NewCustomerService(customerRepo)
+	priceListService := service.NewPriceListService(priceListRepo, customerRepo, productRepo,
+		service.WithPriceListTransactor(tx),
+	)
 
 	orderRepo := repository.NewOrderGormRepository()
-	orderService := service.NewOrderService(orderRepo, productRepo)
+	orderService := service.NewOrderService(orderRepo, productRepo,
+		service.WithOrderTransactor(tx),
+		service.WithOrderEvents(outbox),
+		service.WithOrderVariants(variantRepo),
+		service.WithOrderLots(lotRepo),
+		service.WithOrderSerials(serialRepo),
+		service.WithOrderPriceLists(priceListRepo, customerRepo),
+	)
+	serialService := service.NewSerialService(serialRepo, productRepo, orderRepo,
+		service.WithSerialTransactor(tx),
+		service.WithSerialEvents(outbox),
+		service.WithSerialVariants(variantRepo),
+	)
+
+	costingService := service.NewCostingService(repository.NewInventoryMovementGormRepository(), productRepo, orderRepo)
+	eventBus.Subscribe(domain.EventStockAdjusted, costingService.Handle)
+
+	analyticsRepo := repository.NewAnalyticsGormRepository()
+	analyticsService := service.NewAnalyticsService(analyticsRepo)
+
+	exportService := service.NewExportService(productRepo, orderRepo,
+		service.WithExportCategories(categoryRepo),
+	)
+
+	shipmentRepo := repository.NewShipmentGormRepository()
+	shipmentService := service.NewShipmentService(shipmentRepo, orderRepo,
+		service.WithShipmentTransactor(tx),
+		service.WithShipmentEvents(outbox),
+	)
+
+	supplierRepo := repository.NewSupplierGormRepository()
+	purchaseOrderRepo := repository.NewPurchaseOrderGormRepository()
+	supplierService := service.NewSupplierService(supplierRepo, productRepo, purchaseOrderRepo)
+	purchaseOrderService := service.NewPurchaseOrderService(purchaseOrderRepo, supplierRepo, productRepo,
+		service.WithPurchaseOrderTransactor(tx),
+		service.WithPurchaseOrderEvents(outbox),
+		service.WithPurchaseOrderVariants(variantRepo),
+		service.WithPurchaseOrderLots(lotRepo),
+		service.WithPurchaseOrderSerials(serialRepo),
+	)
+
+	emailOpts := []service.EmailServiceOption{service.WithEmailAppURL(os.Getenv("APP_URL"))}
+	if mail != nil {
+		emailOpts = append(emailOpts, service.WithEmailMailer(mail))
+	}
+	emailService := service.NewEmailService(repository.NewEmailLogGormRepository(), repository.NewNotificationPreferencesGormRepository(), userRepo, orderRepo, emailOpts...)
+	eventBus.Subscribe(domain.EventUserRegistered, emailService.Handle)
+	eventBus.Subscribe(domain.EventOrderCreated, emailService.Handle)
+	eventBus.Subscribe(domain.EventOrderStatusChanged, emailService.Handle)
+
+	passwordPolicy, err := password.PolicyFromEnv()
+	if err != nil {
+		log.Fatalf("Error configuring password policy: %v", err)
+	}
+	userOpts := []service.UserServiceOption{
+		service.WithPasswordPolicy(passwordPolicy),
+		service.WithUserTransactor(tx),
+		service.WithUserEvents(outbox),
+		service.WithUserTokens(repository.NewUserTokenGormRepository(), emailService),
+	}
+	if os.Getenv("REQUIRE_EMAIL_VERIFICATION") == "true" {
+		userOpts = append(userOpts, service.WithRequireVerifiedEmail())
+	}
+	userService := service.NewUserService(userRepo, userOpts...)
+
+	forecastService := service.NewForecastService(analyticsRepo, productRepo, supplierRepo, purchaseOrderRepo)
+
+	reportOpts := []service.ReportSubscriptionServiceOption{service.WithReportTransactor(tx)}
+	if mail != nil {
+		reportOpts = append(reportOpts, service.WithReportMailer(mail))
+	}
+	reportSubscriptionService := service.NewReportSubscriptionService(repository.NewReportSubscriptionGormRepository(), analyticsService, productRepo, userRepo, reportOpts...)
+	if mail != nil {
+		go reportSubscriptionService.Run(context.Background(), time.Minute)
+	}
+
+	// Relay only once every handler has subscribed, so none misses an event.
+	sinks := []service.EventSink{eventBus, webhookService}
+	if os.Getenv("OUTBOX_NDJSON_STDOUT") == "true" {
+		sinks = append(sinks, service.NewNDJSONSink(os.Stdout))
+	}
+	go service.NewOutboxRelay(outboxRepo, sinks...).Run(context.Background(), time.Second)
 
 	e := echo.New()
 	e.Use(middleware.Logger())
@@ -64,9 +238,34 @@ func main() {
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 
 	routesDependencies := routes.AppDependencies{
-		UserService:    userService,
-		ProductService: productService,
-		OrderService:   orderService,
+		UserService:               userService,
+		ProductService:            productService,
+		OrderService:              orderService,
+		ShipmentService:           shipmentService,
+		WebhookService:            webhookService,
+		StreamHub:                 streamHub,
+		NotificationService:       notificationService,
+		SupplierService:           supplierService,
+		PurchaseOrderService:      purchaseOrderService,
+		VariantService:            variantService,
+		CategoryService:           categoryService,
+		TagService:                tagService,
+		ProductImageService:       productImageService,
+		ImportService:             importService,
+		ExportService:             exportService,
+		StocktakeService:          stocktakeService,
+		LotService:                lotService,
+		SerialService:             serialService,
+		BundleService:             bundleService,
+		CustomerService:           customerService,
+		PriceListService:          priceListService,
+		PriceChangeService:        priceChangeService,
+		CostingService:            costingService,
+		AnalyticsService:          analyticsService,
+		ForecastService:           forecastService,
+		ReportSubscriptionService: reportSubscriptionService,
+		EmailService:              emailService,
+		BlobStore:                 blobStore,
 	}
 
 	routes.RegisterAllRoutes(e, routesDependencies)
@@ -75,6 +274,24 @@ func main() {
 	if port == "" {
 		port = "8080"
 	}
-	log.Printf("Server started on port %s", port)
-	e.Logger.Fatal(e.Start(":" + port))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		log.Printf("Server started on port %s", port)
+		if err := e.Start(":" + port); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			e.Logger.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Printf("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Shutting down the server failed: %v", err)
+	}
+	// Let background imports finish so they are not left half applied,
+	// and send the password reset and verification emails still queued.
+	importService.Wait()
+	userService.Wait()
 }
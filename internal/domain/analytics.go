@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// SalesBucket is the length of the periods sales are summed over.
+type SalesBucket string
+
+const (
+	SalesBucketDay   SalesBucket = "day"
+	SalesBucketWeek  SalesBucket = "week"
+	SalesBucketMonth SalesBucket = "month"
+)
+
+// SalesFilter narrows sales reports to the orders created from From up to,
+// but excluding, To. Location is the time zone periods start in; UTC when
+// nil.
+type SalesFilter struct {
+	From     *time.Time
+	To       *time.Time
+	Location *time.Location
+}
+
+// SalesPoint is the orders and revenue of the period starting at Period,
+// cancelled orders excluded. Period is a wall clock time in the report's
+// time zone, read as UTC.
+type SalesPoint struct {
+	Period  time.Time
+	Orders  int
+	Revenue float64
+}
+
+// ProductSales is how much of a product was sold, cancelled orders
+// excluded.
+type ProductSales struct {
+	ProductID uint
+	Code      string
+	Name      string
+	Quantity  int
+	Revenue   float64
+	Orders    int
+}
+
+// StatusCount is the number and value of the orders currently in a status.
+type StatusCount struct {
+	Status  OrderStatus
+	Orders  int
+	Revenue float64
+}
+
+// DailyProductSales is the quantity of a product, or of one of its variants,
+// sold on a UTC day, cancelled orders excluded. Units taken for bundles count
+// as sales of the components.
+type DailyProductSales struct {
+	ProductID uint
+	VariantID *uint
+	Day       time.Time
+	Quantity  int
+}
+
+type AnalyticsRepository interface {
+	SalesOverTime(ctx context.Context, userID uint, bucket SalesBucket, filter SalesFilter) ([]SalesPoint, error)
+	// TopProducts returns the limit best selling products, by revenue or,
+	// when byQuantity, by units sold.
+	TopProducts(ctx context.Context, userID uint, filter SalesFilter, byQuantity bool, limit int) ([]ProductSales, error)
+	StatusCounts(ctx context.Context, userID uint, filter SalesFilter) ([]StatusCount, error)
+	// DailyProductSales returns the daily sales from since on.
+	DailyProductSales(ctx context.Context, userID uint, since time.Time) ([]DailyProductSales, error)
+}
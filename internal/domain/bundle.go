@@ -0,0 +1,59 @@
+package domain
+
+import "context"
+
+// BundleComponent is a quantity of a component product, or of one of its
+// variants, in each unit of a bundle product.
+type BundleComponent struct {
+	ID          uint            `json:"id" gorm:"primaryKey"`
+	BundleID    uint            `json:"bundle_id" gorm:"not null;index"`
+	Bundle      *Product        `json:"-" gorm:"foreignKey:BundleID;constraint:OnDelete:CASCADE;"`
+	ComponentID uint            `json:"component_id" gorm:"not null;index"`
+	Component   Product         `json:"component" gorm:"foreignKey:ComponentID;constraint:OnDelete:RESTRICT;"`
+	VariantID   *uint           `json:"variant_id"`
+	Variant     *ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID;constraint:OnDelete:RESTRICT;"`
+	Quantity    int             `json:"quantity" gorm:"not null"`
+}
+
+// IsBundle reports whether the product is made of other products. A bundle
+// has no stock of its own.
+func (p *Product) IsBundle() bool {
+	return len(p.Components) > 0
+}
+
+// BundleStock is how many units of a bundle the stock of its components
+// makes up.
+func (p *Product) BundleStock() int {
+	stock := -1
+	for _, component := range p.Components {
+		available := component.Component.Stock
+		if component.Variant != nil {
+			available = component.Variant.Stock
+		}
+		if units := available / component.Quantity; stock < 0 || units < stock {
+			stock = units
+		}
+	}
+	return max(stock, 0)
+}
+
+// OrderItemComponent is the quantity of a component taken from stock for an
+// order item of a bundle.
+type OrderItemComponent struct {
+	ID          uint            `json:"id" gorm:"primaryKey"`
+	OrderItemID uint            `json:"order_item_id" gorm:"not null;index"`
+	ProductID   uint            `json:"product_id" gorm:"not null"`
+	Product     Product         `json:"product" gorm:"foreignKey:ProductID"`
+	VariantID   *uint           `json:"variant_id"`
+	Variant     *ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID;constraint:OnDelete:SET NULL;"`
+	Quantity    int             `json:"quantity" gorm:"not null"`
+}
+
+type BundleRepository interface {
+	// ReplaceComponents replaces the components of a bundle. No components
+	// make the product a regular one again.
+	ReplaceComponents(ctx context.Context, bundleID uint, components []BundleComponent) error
+	// FindBundleIDsByComponent returns the bundles the product is a
+	// component of.
+	FindBundleIDsByComponent(ctx context.Context, productID uint) ([]uint, error)
+}
@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Category groups products in a tree. Categories without a parent are roots;
+// siblings are ordered by Position, then name. Slug is unique per user.
+type Category struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_category_user_slug"`
+	User      *User     `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	ParentID  *uint     `json:"parent_id" gorm:"index"`
+	Parent    *Category `json:"-" gorm:"constraint:OnDelete:RESTRICT;"`
+	Name      string    `json:"name" gorm:"not null"`
+	Slug      string    `json:"slug" gorm:"not null;uniqueIndex:idx_category_user_slug"`
+	Position  int       `json:"position" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Tag is a free-form label of products. Names are stored lower-case and are
+// unique per user.
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_tag_user_name"`
+	User      *User     `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Name      string    `json:"name" gorm:"not null;uniqueIndex:idx_tag_user_name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TagUsage is a tag with the number of products carrying it.
+type TagUsage struct {
+	Tag
+	Products int64 `json:"products"`
+}
+
+// CategoryTree indexes the categories of a user by parent, for walking
+// subtrees and building breadcrumb paths.
+type CategoryTree struct {
+	byID     map[uint]*Category
+	children map[uint][]*Category
+	roots    []*Category
+}
+
+func NewCategoryTree(categories []*Category) *CategoryTree {
+	tree := &CategoryTree{byID: make(map[uint]*Category, len(categories)), children: make(map[uint][]*Category)}
+	for _, category := range categories {
+		tree.byID[category.ID] = category
+	}
+	for _, category := range categories {
+		if category.ParentID == nil || tree.byID[*category.ParentID] == nil {
+			tree.roots = append(tree.roots, category)
+			continue
+		}
+		tree.children[*category.ParentID] = append(tree.children[*category.ParentID], category)
+	}
+	sortCategories(tree.roots)
+	for _, children := range tree.children {
+		sortCategories(children)
+	}
+	return tree
+}
+
+func sortCategories(categories []*Category) {
+	sort.SliceStable(categories, func(i, j int) bool {
+		if categories[i].Position != categories[j].Position {
+			return categories[i].Position < categories[j].Position
+		}
+		return categories[i].Name < categories[j].Name
+	})
+}
+
+// Roots returns the top-level categories in display order.
+func (t *CategoryTree) Roots() []*Category {
+	return t.roots
+}
+
+// Children returns the direct children of a category in display order.
+func (t *CategoryTree) Children(id uint) []*Category {
+	return t.children[id]
+}
+
+// Get returns the category with the given ID.
+func (t *CategoryTree) Get(id uint) (*Category, bool) {
+	category, ok := t.byID[id]
+	return category, ok
+}
+
+// Subtree returns the ID of a category followed by the IDs of all its
+// descendants.
+func (t *CategoryTree) Subtree(id uint) []uint {
+	if _, ok := t.byID[id]; !ok {
+		return nil
+	}
+	ids := []uint{id}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range t.children[ids[i]] {
+			ids = append(ids, child.ID)
+		}
+	}
+	return ids
+}
+
+// Path returns the names from the root down to the category, joined by " / ".
+func (t *CategoryTree) Path(id uint) string {
+	var names []string
+	for category, ok := t.byID[id]; ok; {
+		names = append([]string{category.Name}, names...)
+		if category.ParentID == nil {
+			break
+		}
+		category, ok = t.byID[*category.ParentID]
+	}
+	return strings.Join(names, " / ")
+}
+
+type CategoryRepository interface {
+	Create(ctx context.Context, category *Category) error
+	FindByIDAndUserID(ctx context.Context, id, userID uint) (*Category, error)
+	FindBySlugAndUserID(ctx context.Context, slug string, userID uint) (*Category, error)
+	FindByUserID(ctx context.Context, userID uint) ([]*Category, error)
+	FindByIDs(ctx context.Context, ids []uint, userID uint) ([]*Category, error)
+	Update(ctx context.Context, category *Category) error
+	Delete(ctx context.Context, id, userID uint) error
+	// ReplaceProductCategories sets the categories of a product to exactly
+	// the given ones.
+	ReplaceProductCategories(ctx context.Context, product *Product, categories []*Category) error
+}
+
+type TagRepository interface {
+	FindByUserID(ctx context.Context, userID uint) ([]*TagUsage, error)
+	FindByIDAndUserID(ctx context.Context, id, userID uint) (*Tag, error)
+	// FindOrCreate returns the tags with the given names, creating the ones
+	// the user does not have yet.
+	FindOrCreate(ctx context.Context, userID uint, names []string) ([]*Tag, error)
+	Delete(ctx context.Context, id, userID uint) error
+	// ReplaceProductTags sets the tags of a product to exactly the given ones.
+	ReplaceProductTags(ctx context.Context, product *Product, tags []*Tag) error
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// CostMethod is how the cost of a product is kept. A standard cost is only
+// changed by hand; a moving average cost is recomputed from the unit cost of
+// every purchase receipt.
+type CostMethod string
+
+const (
+	CostMethodStandard      CostMethod = "standard"
+	CostMethodMovingAverage CostMethod = "moving_average"
+)
+
+// UnitCost is what a unit of the product costs. The cost of a bundle is that
+// of its components.
+func (p *Product) UnitCost() float64 {
+	if !p.IsBundle() {
+		return p.Cost
+	}
+	var cost float64
+	for _, component := range p.Components {
+		cost += component.Component.Cost * float64(component.Quantity)
+	}
+	return roundCost(cost)
+}
+
+// ReceiveCost returns the unit cost to book a receipt of quantity units bought
+// at unitCost. For moving average products it also folds the receipt into
+// the product cost, so it must be called before the stock is increased.
+// Standard cost products are received at their standard cost.
+func (p *Product) ReceiveCost(quantity int, unitCost float64) float64 {
+	if p.CostMethod == CostMethodStandard {
+		return p.Cost
+	}
+	if p.Stock <= 0 {
+		p.Cost = unitCost
+	} else {
+		p.Cost = roundCost((float64(p.Stock)*p.Cost + float64(quantity)*unitCost) / float64(p.Stock+quantity))
+	}
+	return unitCost
+}
+
+// roundCost rounds a unit cost to four decimals, so that averages do not
+// accumulate float noise.
+func roundCost(cost float64) float64 {
+	return math.Round(cost*10000) / 10000
+}
+
+// InventoryMovement is an entry of the inventory ledger: a change of the
+// stock of a product, or of one of its variants, valued at the unit cost at
+// the time. EventID is the stock event that produced it, so a redelivered
+// event is not booked twice. Opening balances, booked for the stock products
+// had when the ledger was introduced, have no event.
+type InventoryMovement struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     uint      `gorm:"not null;index" json:"user_id"`
+	EventID    *uint     `gorm:"uniqueIndex" json:"event_id"`
+	ProductID  uint      `gorm:"not null;index" json:"product_id"`
+	Product    *Product  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	VariantID  *uint     `json:"variant_id"`
+	Quantity   int       `gorm:"not null" json:"quantity"`
+	UnitCost   float64   `gorm:"not null" json:"unit_cost"`
+	Reason     string    `json:"reason"`
+	OccurredAt time.Time `gorm:"not null;index" json:"occurred_at"`
+}
+
+// StockReasonOpening is the reason of the opening balances of the ledger.
+const StockReasonOpening = "opening"
+
+// InventoryBalance is the stock of a product on hand at some time according
+// to the ledger. FIFOValue is what the most recent receipts making up the
+// quantity cost; AverageCost is the average unit cost of every receipt.
+type InventoryBalance struct {
+	ProductID   uint
+	Code        string
+	Name        string
+	Quantity    int
+	FIFOValue   float64 `gorm:"column:fifo_value"`
+	AverageCost float64
+}
+
+type InventoryMovementRepository interface {
+	// Create stores the movement unless one for its event exists.
+	Create(ctx context.Context, movement *InventoryMovement) error
+	// BalancesAt sums the movements of each product of a user up to and
+	// including t. Products with no stock on hand are left out.
+	BalancesAt(ctx context.Context, userID uint, t time.Time) ([]InventoryBalance, error)
+}
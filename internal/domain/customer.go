@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// CustomerGroup groups customers that share price lists, such as wholesale
+// customers.
+type CustomerGroup struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_customer_group_user_name"`
+	User      *User     `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Name      string    `json:"name" gorm:"not null;uniqueIndex:idx_customer_group_user_name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Customer is someone the user sells to. Orders may name a customer to get
+// the prices of the price lists assigned to it or to its group.
+type Customer struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	UserID    uint           `json:"user_id" gorm:"not null;index"`
+	User      *User          `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Name      string         `json:"name" gorm:"not null"`
+	Email     string         `json:"email"`
+	GroupID   *uint          `json:"group_id" gorm:"index"`
+	Group     *CustomerGroup `json:"group,omitempty" gorm:"foreignKey:GroupID;constraint:OnDelete:SET NULL;"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+type CustomerRepository interface {
+	Create(ctx context.Context, customer *Customer) error
+	FindByIDAndUserID(ctx context.Context, id, userID uint) (*Customer, error)
+	FindByUserID(ctx context.Context, userID uint) ([]*Customer, error)
+	Update(ctx context.Context, customer *Customer, userID uint) error
+	Delete(ctx context.Context, id, userID uint) error
+
+	CreateGroup(ctx context.Context, group *CustomerGroup) error
+	FindGroupByIDAndUserID(ctx context.Context, id, userID uint) (*CustomerGroup, error)
+	FindGroupByNameAndUserID(ctx context.Context, name string, userID uint) (*CustomerGroup, error)
+	FindGroupsByUserID(ctx context.Context, userID uint) ([]*CustomerGroup, error)
+	DeleteGroup(ctx context.Context, id, userID uint) error
+}
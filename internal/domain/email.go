@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type EmailKind string
+
+const (
+	EmailWelcome            EmailKind = "welcome"
+	EmailVerifyEmail        EmailKind = "verify_email"
+	EmailPasswordReset      EmailKind = "password_reset"
+	EmailOrderCreated       EmailKind = "order_created"
+	EmailOrderStatusChanged EmailKind = "order_status_changed"
+	EmailOrderCancelled     EmailKind = "order_cancelled"
+)
+
+type EmailStatus string
+
+const (
+	EmailSent   EmailStatus = "sent"
+	EmailFailed EmailStatus = "failed"
+	// EmailSkipped is an email the user's preferences opted out of.
+	EmailSkipped EmailStatus = "skipped"
+)
+
+// EmailLog records a transactional email, sent or not, for troubleshooting.
+// EventID is the outbox message that triggered it, so a redelivered event
+// does not email twice.
+type EmailLog struct {
+	ID         uint        `gorm:"primaryKey" json:"id"`
+	UserID     uint        `gorm:"not null;index:idx_email_log_user" json:"user_id"`
+	User       *User       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	EventID    *uint       `gorm:"uniqueIndex" json:"event_id"`
+	Kind       EmailKind   `gorm:"type:varchar(40);not null" json:"kind"`
+	Recipients string      `gorm:"type:text;not null;default:''" json:"recipients"`
+	Subject    string      `gorm:"not null;default:''" json:"subject"`
+	Status     EmailStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Error      string      `gorm:"type:text" json:"error"`
+	CreatedAt  time.Time   `gorm:"index:idx_email_log_user" json:"created_at"`
+}
+
+// NotificationPreferences are the optional emails a user receives. Account
+// emails, such as the welcome and password reset emails, are always sent. Users who never set
+// their preferences receive every email.
+type NotificationPreferences struct {
+	UserID             uint      `gorm:"primaryKey" json:"user_id"`
+	User               *User     `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	OrderCreated       bool      `gorm:"not null" json:"order_created"`
+	OrderStatusChanged bool      `gorm:"not null" json:"order_status_changed"`
+	OrderCancelled     bool      `gorm:"not null" json:"order_cancelled"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+func DefaultNotificationPreferences(userID uint) *NotificationPreferences {
+	return &NotificationPreferences{
+		UserID:             userID,
+		OrderCreated:       true,
+		OrderStatusChanged: true,
+		OrderCancelled:     true,
+	}
+}
+
+// Allows reports whether the user receives emails of the given kind.
+func (p *NotificationPreferences) Allows(kind EmailKind) bool {
+	switch kind {
+	case EmailOrderCreated:
+		return p.OrderCreated
+	case EmailOrderStatusChanged:
+		return p.OrderStatusChanged
+	case EmailOrderCancelled:
+		return p.OrderCancelled
+	default:
+		return true
+	}
+}
+
+type EmailLogRepository interface {
+	Create(ctx context.Context, entry *EmailLog) error
+	FindByEventID(ctx context.Context, eventID uint) (*EmailLog, error)
+	// FindByUserID returns the latest limit entries of a user, newest first.
+	FindByUserID(ctx context.Context, userID uint, limit int) ([]*EmailLog, error)
+}
+
+type NotificationPreferencesRepository interface {
+	FindByUserID(ctx context.Context, userID uint) (*NotificationPreferences, error)
+	// Save creates or replaces the preferences of a user.
+	Save(ctx context.Context, preferences *NotificationPreferences) error
+}
@@ -0,0 +1,209 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventOrderCreated        = "order.created"
+	EventOrderStatusChanged  = "order.status_changed"
+	EventStockAdjusted       = "product.stock_adjusted"
+	EventProductStockLow     = "product.stock_low"
+	EventProductDeleted      = "product.deleted"
+	EventProductPriceChanged = "product.price_changed"
+	EventUserRegistered      = "user.registered"
+)
+
+const (
+	AggregateOrder   = "order"
+	AggregateProduct = "product"
+	AggregateUser    = "user"
+)
+
+// Event is a state change that other parts of the system, or external
+// subscribers, may react to. Events of the same aggregate are delivered in the
+// order they were recorded.
+type Event interface {
+	EventType() string
+	AggregateType() string
+	AggregateID() uint
+}
+
+// Transactor runs fn inside a database transaction. Repositories called with
+// the context passed to fn take part in that transaction.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type OrderEventItem struct {
+	OrderItemID uint    `json:"order_item_id"`
+	ProductID   uint    `json:"product_id"`
+	VariantID   *uint   `json:"variant_id,omitempty"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	Subtotal    float64 `json:"subtotal"`
+}
+
+type OrderCreated struct {
+	OrderID     uint             `json:"order_id"`
+	Status      string           `json:"status"`
+	TotalAmount float64          `json:"total_amount"`
+	Items       []OrderEventItem `json:"items"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+func (OrderCreated) EventType() string     { return EventOrderCreated }
+func (OrderCreated) AggregateType() string { return AggregateOrder }
+func (e OrderCreated) AggregateID() uint   { return e.OrderID }
+
+func NewOrderCreated(order *Order) OrderCreated {
+	items := make([]OrderEventItem, len(order.Items))
+	for i, item := range order.Items {
+		items[i] = OrderEventItem{
+			OrderItemID: item.ID,
+			ProductID:   item.ProductID,
+			VariantID:   item.VariantID,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Subtotal:    item.Subtotal,
+		}
+	}
+	return OrderCreated{
+		OrderID:     order.ID,
+		Status:      string(order.Status),
+		TotalAmount: order.TotalAmount,
+		Items:       items,
+		CreatedAt:   order.CreatedAt,
+	}
+}
+
+type OrderStatusChanged struct {
+	OrderID uint   `json:"order_id"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+}
+
+func (OrderStatusChanged) EventType() string     { return EventOrderStatusChanged }
+func (OrderStatusChanged) AggregateType() string { return AggregateOrder }
+func (e OrderStatusChanged) AggregateID() uint   { return e.OrderID }
+
+// StockAdjusted reports a change of the product stock. When the change was
+// made to a variant, Previous and Stock are still the product totals and the
+// variant fields identify the variant and its new stock. UnitCost is what each
+// unit moved was valued at.
+type StockAdjusted struct {
+	ProductID    uint    `json:"product_id"`
+	Code         string  `json:"code"`
+	Previous     int     `json:"previous"`
+	Stock        int     `json:"stock"`
+	Delta        int     `json:"delta"`
+	Reason       string  `json:"reason"`
+	VariantID    uint    `json:"variant_id,omitempty"`
+	VariantCode  string  `json:"variant_code,omitempty"`
+	VariantStock *int    `json:"variant_stock,omitempty"`
+	UnitCost     float64 `json:"unit_cost"`
+}
+
+func (StockAdjusted) EventType() string     { return EventStockAdjusted }
+func (StockAdjusted) AggregateType() string { return AggregateProduct }
+func (e StockAdjusted) AggregateID() uint   { return e.ProductID }
+
+type ProductStockLow struct {
+	ProductID       uint   `json:"product_id"`
+	Code            string `json:"code"`
+	Name            string `json:"name"`
+	Stock           int    `json:"stock"`
+	ReorderPoint    int    `json:"reorder_point"`
+	ReorderQuantity int    `json:"reorder_quantity"`
+}
+
+func (ProductStockLow) EventType() string     { return EventProductStockLow }
+func (ProductStockLow) AggregateType() string { return AggregateProduct }
+func (e ProductStockLow) AggregateID() uint   { return e.ProductID }
+
+type ProductDeleted struct {
+	ProductID uint   `json:"product_id"`
+	Code      string `json:"code"`
+}
+
+func (ProductDeleted) EventType() string     { return EventProductDeleted }
+func (ProductDeleted) AggregateType() string { return AggregateProduct }
+func (e ProductDeleted) AggregateID() uint   { return e.ProductID }
+
+type ProductPriceChanged struct {
+	ProductID uint    `json:"product_id"`
+	Code      string  `json:"code"`
+	OldPrice  float64 `json:"old_price"`
+	NewPrice  float64 `json:"new_price"`
+	Source    string  `json:"source"`
+}
+
+func (ProductPriceChanged) EventType() string     { return EventProductPriceChanged }
+func (ProductPriceChanged) AggregateType() string { return AggregateProduct }
+func (e ProductPriceChanged) AggregateID() uint   { return e.ProductID }
+
+type UserRegistered struct {
+	UserID uint   `json:"user_id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+}
+
+func (UserRegistered) EventType() string     { return EventUserRegistered }
+func (UserRegistered) AggregateType() string { return AggregateUser }
+func (e UserRegistered) AggregateID() uint   { return e.UserID }
+
+// Reasons recorded on StockAdjusted events.
+const (
+	StockReasonOrderPlaced      = "order_placed"
+	StockReasonOrderCancelled   = "order_cancelled"
+	StockReasonManual           = "manual"
+	StockReasonPurchaseReceived = "purchase_received"
+	StockReasonImport           = "import"
+	StockReasonStocktake        = "stocktake"
+	StockReasonLotReceived      = "lot_received"
+	StockReasonLotWrittenOff    = "lot_written_off"
+	StockReasonSerialsReceived  = "serials_received"
+	StockReasonReturned         = "returned"
+	StockReasonInitial          = "initial"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxPending   OutboxStatus = "pending"
+	OutboxProcessed OutboxStatus = "processed"
+	OutboxFailed    OutboxStatus = "failed"
+)
+
+// OutboxMessage is an event stored in the same transaction as the state change
+// that produced it, waiting to be relayed to the event sinks.
+type OutboxMessage struct {
+	ID            uint         `json:"id" gorm:"primaryKey"`
+	UserID        uint         `json:"user_id" gorm:"not null;index"`
+	AggregateType string       `json:"aggregate_type" gorm:"type:varchar(30);not null"`
+	AggregateID   uint         `json:"aggregate_id" gorm:"not null"`
+	EventType     string       `json:"event_type" gorm:"type:varchar(50);not null"`
+	Payload       string       `json:"payload" gorm:"type:text;not null"`
+	Status        OutboxStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	Attempts      int          `json:"attempts" gorm:"not null;default:0"`
+	LastError     string       `json:"last_error" gorm:"type:text"`
+	OccurredAt    time.Time    `json:"occurred_at" gorm:"not null"`
+	ProcessedAt   *time.Time   `json:"processed_at"`
+}
+
+func (OutboxMessage) TableName() string {
+	return "outbox"
+}
+
+// Decode unmarshals the event payload into v.
+func (m *OutboxMessage) Decode(v any) error {
+	return json.Unmarshal([]byte(m.Payload), v)
+}
+
+type OutboxRepository interface {
+	Create(ctx context.Context, message *OutboxMessage) error
+	FindPending(ctx context.Context, limit int) ([]*OutboxMessage, error)
+	Update(ctx context.Context, message *OutboxMessage) error
+}
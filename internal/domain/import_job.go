@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type ImportJobStatus string
+
+const (
+	ImportJobPending   ImportJobStatus = "pending"
+	ImportJobRunning   ImportJobStatus = "running"
+	ImportJobCompleted ImportJobStatus = "completed"
+	ImportJobFailed    ImportJobStatus = "failed"
+)
+
+// ImportJob tracks a bulk product import. A dry run validates every row and
+// counts what would change without writing products.
+type ImportJob struct {
+	ID         uint            `json:"id" gorm:"primaryKey"`
+	UserID     uint            `json:"user_id" gorm:"not null;index"`
+	User       *User           `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Filename   string          `json:"filename"`
+	Format     string          `json:"format" gorm:"type:varchar(10)"`
+	DryRun     bool            `json:"dry_run"`
+	Status     ImportJobStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	TotalRows  int             `json:"total_rows"`
+	Created    int             `json:"created"`
+	Updated    int             `json:"updated"`
+	Failed     int             `json:"failed"`
+	Error      string          `json:"error"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	FinishedAt *time.Time      `json:"finished_at"`
+}
+
+// ImportJobError is a rejected row of an import. Row is the line number in
+// the file, counting the header as row 1.
+type ImportJobError struct {
+	ID      uint       `json:"id" gorm:"primaryKey"`
+	JobID   uint       `json:"job_id" gorm:"not null;index"`
+	Job     *ImportJob `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	Row     int        `json:"row"`
+	Code    string     `json:"code"`
+	Message string     `json:"message"`
+}
+
+type ImportJobRepository interface {
+	Create(ctx context.Context, job *ImportJob) error
+	FindByIDAndUserID(ctx context.Context, id, userID uint) (*ImportJob, error)
+	Update(ctx context.Context, job *ImportJob) error
+	CreateErrors(ctx context.Context, errors []ImportJobError) error
+	FindErrors(ctx context.Context, jobID uint) ([]ImportJobError, error)
+	// FailStale marks pending and running jobs last updated before the given
+	// time as failed with the message and returns how many it marked.
+	FailStale(ctx context.Context, before time.Time, message string) (int64, error)
+}
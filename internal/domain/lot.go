@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Lot is a batch of a batch-managed product, or of one of its variants,
+// received together. Received is the quantity first booked into the lot and
+// Quantity what is left of it. A lot cannot be allocated from ExpiresAt on.
+type Lot struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	UserID    uint            `json:"user_id" gorm:"not null;index"`
+	ProductID uint            `json:"product_id" gorm:"not null;index"`
+	Product   Product         `json:"product" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE;"`
+	VariantID *uint           `json:"variant_id"`
+	Variant   *ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID;constraint:OnDelete:CASCADE;"`
+	Number    string          `json:"number" gorm:"not null"`
+	ExpiresAt *time.Time      `json:"expires_at" gorm:"index"`
+	Received  int             `json:"received" gorm:"not null"`
+	Quantity  int             `json:"quantity" gorm:"not null"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Expired reports whether the lot has expired at now.
+func (l *Lot) Expired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// OrderItemLot records how much of an order item was taken from a lot.
+type OrderItemLot struct {
+	ID          uint `json:"id" gorm:"primaryKey"`
+	OrderItemID uint `json:"order_item_id" gorm:"not null;index"`
+	LotID       uint `json:"lot_id" gorm:"not null;index"`
+	Lot         *Lot `json:"lot,omitempty" gorm:"foreignKey:LotID"`
+	Quantity    int  `json:"quantity" gorm:"not null"`
+}
+
+type LotRepository interface {
+	Create(ctx context.Context, lot *Lot) error
+	FindByIDAndUserID(ctx context.Context, id, userID uint) (*Lot, error)
+	// FindByNumber finds the lot of a product, or of one of its variants,
+	// with the given number.
+	FindByNumber(ctx context.Context, productID uint, variantID *uint, number string) (*Lot, error)
+	// FindByProductID returns the lots of a product, the first to expire
+	// first.
+	FindByProductID(ctx context.Context, productID, userID uint) ([]*Lot, error)
+	// LockAllocatable returns the lots of a product, or of one of its
+	// variants, that have stock left and have not expired at now, in the
+	// order they should be allocated: the first to expire first, and lots
+	// without an expiry date last. The lots stay locked until the
+	// transaction in ctx ends.
+	LockAllocatable(ctx context.Context, productID uint, variantID *uint, now time.Time) ([]*Lot, error)
+	// FindExpiring returns the lots with stock left that expire before the
+	// given time, including those that have already expired, the first to
+	// expire first.
+	FindExpiring(ctx context.Context, userID uint, before time.Time) ([]*Lot, error)
+	Update(ctx context.Context, lot *Lot) error
+	// AddQuantity adds quantity to what is left of a lot.
+	AddQuantity(ctx context.Context, id uint, quantity int) error
+}
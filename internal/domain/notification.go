@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type NotificationType string
+
+const (
+	NotificationLowStock NotificationType = "low_stock"
+)
+
+// Notification is an entry of a user's in-app feed. EventID is the outbox
+// message that produced it, so a redelivered event does not notify twice.
+type Notification struct {
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	UserID    uint             `gorm:"not null;index" json:"user_id"`
+	User      *User            `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	EventID   uint             `gorm:"not null;uniqueIndex" json:"event_id"`
+	Type      NotificationType `gorm:"not null" json:"type"`
+	Title     string           `gorm:"not null" json:"title"`
+	Message   string           `json:"message"`
+	ProductID *uint            `json:"product_id"`
+	EmailedAt *time.Time       `json:"emailed_at"`
+	ReadAt    *time.Time       `json:"read_at"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *Notification) error
+	FindByEventID(ctx context.Context, eventID uint) (*Notification, error)
+	FindByIDAndUserID(ctx context.Context, id, userID uint) (*Notification, error)
+	FindByUserID(ctx context.Context, userID uint, unreadOnly bool, limit int) ([]*Notification, error)
+	Update(ctx context.Context, notification *Notification) error
+	MarkAllRead(ctx context.Context, userID uint, readAt time.Time) (int64, error)
+}
@@ -17,30 +17,59 @@ const (
 
 type Order struct {
 	ID          uint        `json:"id" gorm:"primaryKey"`
-	UserID      uint        `json:"user_id" gorm:"not null"`
+	UserID      uint        `json:"user_id" gorm:"not null;index:idx_orders_user_created"`
 	User        User        `json:"user" gorm:"foreignKey:UserID"`
+	CustomerID  *uint       `json:"customer_id" gorm:"index"`
+	Customer    *Customer   `json:"customer,omitempty" gorm:"foreignKey:CustomerID;constraint:OnDelete:SET NULL;"`
 	Status      OrderStatus `json:"status" gorm:"type:varchar(20);default:'pending'"`
 	TotalAmount float64     `json:"total_amount" gorm:"not null"`
 	Items       []OrderItem `json:"items" gorm:"foreignKey:OrderID"`
-	CreatedAt   time.Time   `json:"created_at"`
+	CreatedAt   time.Time   `json:"created_at" gorm:"index:idx_orders_user_created"`
 	UpdatedAt   time.Time   `json:"updated_at"`
 }
 
 type OrderItem struct {
-	ID        uint    `json:"id" gorm:"primaryKey"`
-	OrderID   uint    `json:"order_id" gorm:"not null"`
-	Order     Order   `json:"order" gorm:"foreignKey:OrderID"`
-	ProductID uint    `json:"product_id" gorm:"not null"`
-	Product   Product `json:"product" gorm:"foreignKey:ProductID"`
-	Quantity  int     `json:"quantity" gorm:"not null"`
-	UnitPrice float64 `json:"unit_price" gorm:"not null"`
-	Subtotal  float64 `json:"subtotal" gorm:"not null"`
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	OrderID   uint            `json:"order_id" gorm:"not null"`
+	Order     Order           `json:"order" gorm:"foreignKey:OrderID"`
+	ProductID uint            `json:"product_id" gorm:"not null"`
+	Product   Product         `json:"product" gorm:"foreignKey:ProductID"`
+	VariantID *uint           `json:"variant_id"`
+	Variant   *ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID;constraint:OnDelete:SET NULL;"`
+	Quantity  int             `json:"quantity" gorm:"not null"`
+	UnitPrice float64         `json:"unit_price" gorm:"not null"`
+	Subtotal  float64         `json:"subtotal" gorm:"not null"`
+	// UnitCost is the unit cost of the product when the item was sold.
+	UnitCost float64 `json:"unit_cost" gorm:"not null;default:0"`
+	// PriceListID is the price list the unit price came from, if any.
+	PriceListID *uint      `json:"price_list_id"`
+	PriceList   *PriceList `json:"price_list,omitempty" gorm:"foreignKey:PriceListID;constraint:OnDelete:SET NULL;"`
+	// Lots lists the lots the item was allocated from, for batch-managed
+	// products.
+	Lots []OrderItemLot `json:"lots,omitempty" gorm:"foreignKey:OrderItemID"`
+	// Serials lists the serials assigned to the item, for serialized
+	// products.
+	Serials []Serial `json:"serials,omitempty" gorm:"foreignKey:OrderItemID"`
+	// Components lists the component stock taken for an item of a bundle.
+	Components []OrderItemComponent `json:"components,omitempty" gorm:"foreignKey:OrderItemID"`
+}
+
+// OrderFilter narrows an order listing to a status and to orders created
+// from From up to, but excluding, To. Empty fields do not filter.
+type OrderFilter struct {
+	Status OrderStatus
+	From   *time.Time
+	To     *time.Time
 }
 
 type OrderRepository interface {
 	Create(ctx context.Context, order *Order) error
 	FindByIDAndUserID(ctx context.Context, id, userID uint) (*Order, error)
 	FindByUserID(ctx context.Context, userID uint) ([]*Order, error)
+	FindByFilter(ctx context.Context, userID uint, filter OrderFilter) ([]*Order, error)
+	// FindInBatches passes the matching orders with their items to fn in ID
+	// order, batchSize at a time.
+	FindInBatches(ctx context.Context, userID uint, filter OrderFilter, batchSize int, fn func([]*Order) error) error
 	Update(ctx context.Context, order *Order, userID uint) error
 	Delete(ctx context.Context, id, userID uint) error
 }
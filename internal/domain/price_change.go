@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Sources of price changes.
+const (
+	PriceChangeSourceManual    = "manual"
+	PriceChangeSourceImport    = "import"
+	PriceChangeSourceScheduled = "scheduled"
+)
+
+// PriceChange records a change of the price of a product. ChangedBy is the
+// user who made the change, or who scheduled it.
+type PriceChange struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	ProductID uint      `json:"product_id" gorm:"not null;index:idx_price_change_product"`
+	Product   *Product  `json:"-" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE;"`
+	OldPrice  float64   `json:"old_price" gorm:"not null"`
+	NewPrice  float64   `json:"new_price" gorm:"not null"`
+	Source    string    `json:"source" gorm:"type:varchar(20);not null"`
+	ChangedBy *uint     `json:"changed_by"`
+	ChangedAt time.Time `json:"changed_at" gorm:"not null;index:idx_price_change_product"`
+}
+
+type ScheduledPriceStatus string
+
+const (
+	ScheduledPricePending   ScheduledPriceStatus = "pending"
+	ScheduledPriceApplied   ScheduledPriceStatus = "applied"
+	ScheduledPriceCancelled ScheduledPriceStatus = "cancelled"
+)
+
+// ScheduledPriceChange sets the price of a product at EffectiveAt.
+type ScheduledPriceChange struct {
+	ID          uint                 `json:"id" gorm:"primaryKey"`
+	UserID      uint                 `json:"user_id" gorm:"not null;index"`
+	ProductID   uint                 `json:"product_id" gorm:"not null;index"`
+	Product     *Product             `json:"-" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE;"`
+	Price       float64              `json:"price" gorm:"not null"`
+	EffectiveAt time.Time            `json:"effective_at" gorm:"not null;index"`
+	Status      ScheduledPriceStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	CreatedBy   uint                 `json:"created_by" gorm:"not null"`
+	AppliedAt   *time.Time           `json:"applied_at"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+type PriceChangeRepository interface {
+	Create(ctx context.Context, change *PriceChange) error
+	// FindByProductID returns the price changes of a product, newest first.
+	FindByProductID(ctx context.Context, productID, userID uint) ([]*PriceChange, error)
+
+	CreateScheduled(ctx context.Context, change *ScheduledPriceChange) error
+	FindScheduledByIDAndUserID(ctx context.Context, id, userID uint) (*ScheduledPriceChange, error)
+	// FindScheduledByProductID returns the scheduled changes of a product
+	// with the given status, or all of them when status is empty, in the
+	// order they take effect.
+	FindScheduledByProductID(ctx context.Context, productID, userID uint, status ScheduledPriceStatus) ([]*ScheduledPriceChange, error)
+	// LockDueScheduled locks up to limit pending changes effective at or
+	// before t, skipping those locked by another scheduler.
+	LockDueScheduled(ctx context.Context, t time.Time, limit int) ([]*ScheduledPriceChange, error)
+	UpdateScheduled(ctx context.Context, change *ScheduledPriceChange) error
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// PriceList sets its own prices for products. It applies to orders placed
+// from ValidFrom up to, but excluding, ValidUntil, for the customers and
+// customer groups it is assigned to, or to every order when it is assigned to
+// none.
+type PriceList struct {
+	ID         uint             `json:"id" gorm:"primaryKey"`
+	UserID     uint             `json:"user_id" gorm:"not null;uniqueIndex:idx_price_list_user_name"`
+	User       *User            `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Name       string           `json:"name" gorm:"not null;uniqueIndex:idx_price_list_user_name"`
+	ValidFrom  *time.Time       `json:"valid_from"`
+	ValidUntil *time.Time       `json:"valid_until"`
+	Prices     []PriceListPrice `json:"prices" gorm:"foreignKey:PriceListID"`
+	Customers  []Customer       `json:"customers" gorm:"many2many:price_list_customers;constraint:OnDelete:CASCADE;"`
+	Groups     []CustomerGroup  `json:"groups" gorm:"many2many:price_list_customer_groups;constraint:OnDelete:CASCADE;"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+}
+
+// ValidAt reports whether the list applies at t.
+func (l *PriceList) ValidAt(t time.Time) bool {
+	if l.ValidFrom != nil && t.Before(*l.ValidFrom) {
+		return false
+	}
+	return l.ValidUntil == nil || t.Before(*l.ValidUntil)
+}
+
+// PriceListPrice prices a product, or one of its variants, from MinQuantity
+// units per order line. It either sets the unit price or takes
+// DiscountPercent off the regular price. Entries of the same product with
+// higher minimum quantities make quantity breaks.
+type PriceListPrice struct {
+	ID              uint            `json:"id" gorm:"primaryKey"`
+	PriceListID     uint            `json:"price_list_id" gorm:"not null;index"`
+	PriceList       *PriceList      `json:"-" gorm:"foreignKey:PriceListID;constraint:OnDelete:CASCADE;"`
+	ProductID       uint            `json:"product_id" gorm:"not null;index"`
+	Product         *Product        `json:"-" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE;"`
+	VariantID       *uint           `json:"variant_id"`
+	Variant         *ProductVariant `json:"-" gorm:"foreignKey:VariantID;constraint:OnDelete:CASCADE;"`
+	MinQuantity     int             `json:"min_quantity" gorm:"not null;default:1"`
+	Price           *float64        `json:"price"`
+	DiscountPercent *float64        `json:"discount_percent"`
+}
+
+// Applies reports whether the entry prices quantity units of the product or
+// variant. Entries without a variant apply to every variant of the product.
+func (p *PriceListPrice) Applies(productID uint, variantID *uint, quantity int) bool {
+	if p.ProductID != productID || quantity < p.MinQuantity {
+		return false
+	}
+	return p.VariantID == nil || (variantID != nil && *p.VariantID == *variantID)
+}
+
+// UnitPrice is the price the entry gives for a unit with the regular price.
+// Discounted prices are rounded to the cent.
+func (p *PriceListPrice) UnitPrice(regular float64) float64 {
+	if p.Price != nil {
+		return *p.Price
+	}
+	return math.Round(regular*(100-*p.DiscountPercent)) / 100
+}
+
+type PriceListRepository interface {
+	Create(ctx context.Context, list *PriceList) error
+	FindByIDAndUserID(ctx context.Context, id, userID uint) (*PriceList, error)
+	FindByNameAndUserID(ctx context.Context, name string, userID uint) (*PriceList, error)
+	FindByUserID(ctx context.Context, userID uint) ([]*PriceList, error)
+	// Update saves the list with its prices and assignments, replacing the
+	// ones it had.
+	Update(ctx context.Context, list *PriceList, userID uint) error
+	Delete(ctx context.Context, id, userID uint) error
+	// FindApplicable returns the lists valid at t that are assigned to the
+	// customer, to the group or to no one, with their prices for the product.
+	FindApplicable(ctx context.Context, userID uint, customerID, groupID *uint, productID uint, t time.Time) ([]*PriceList, error)
+}
@@ -5,24 +5,63 @@ import (
 	"time"
 )
 
+// Product is a stock item. ReorderPoint is the stock level at or below which it
+// needs restocking and ReorderQuantity is how much to order when it does.
+// Orders for a BatchManaged product are allocated from its lots. Each unit of
+// a Serialized product has a serial number. A product with Components is a
+// bundle. Cost is the unit cost, kept as CostMethod says.
 type Product struct {
-	ID          uint      `gorm:"primaryKey" json:"id"`
-	UserID      uint      `gorm:"not null;uniqueIndex:idx_user_code" json:"user_id"`
-	User        *User     `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
-	Code        string    `gorm:"not null;uniqueIndex:idx_user_code" json:"code"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price"`
-	Stock       int       `json:"stock"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID              uint              `gorm:"primaryKey" json:"id"`
+	UserID          uint              `gorm:"not null;uniqueIndex:idx_user_code" json:"user_id"`
+	User            *User             `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Code            string            `gorm:"not null;uniqueIndex:idx_user_code" json:"code"`
+	Name            string            `json:"name"`
+	Description     string            `json:"description"`
+	Price           float64           `json:"price"`
+	Cost            float64           `gorm:"not null;default:0" json:"cost"`
+	CostMethod      CostMethod        `gorm:"type:varchar(20);not null;default:'moving_average'" json:"cost_method"`
+	Stock           int               `json:"stock"`
+	ReorderPoint    int               `gorm:"not null;default:0" json:"reorder_point"`
+	ReorderQuantity int               `gorm:"not null;default:0" json:"reorder_quantity"`
+	BatchManaged    bool              `gorm:"not null;default:false" json:"batch_managed"`
+	Serialized      bool              `gorm:"not null;default:false" json:"serialized"`
+	Variants        []ProductVariant  `gorm:"foreignKey:ProductID" json:"variants,omitempty"`
+	Components      []BundleComponent `gorm:"foreignKey:BundleID" json:"components,omitempty"`
+	Categories      []Category        `gorm:"many2many:product_categories;constraint:OnDelete:CASCADE;" json:"categories,omitempty"`
+	Tags            []Tag             `gorm:"many2many:product_tags;constraint:OnDelete:CASCADE;" json:"tags,omitempty"`
+	Images          []ProductImage    `gorm:"foreignKey:ProductID" json:"images,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
+// LowStock reports whether the stock is at or below the reorder point.
+// Bundles never are; their components are reordered instead.
+func (p *Product) LowStock() bool {
+	return !p.IsBundle() && p.Stock <= p.ReorderPoint
+}
+
+// ProductFilter narrows a product listing. Products match when they are in
+// any of CategoryIDs and carry Tag; empty fields do not filter.
+type ProductFilter struct {
+	CategoryIDs []uint
+	Tag         string
 }
 
 type ProductRepository interface {
 	Create(ctx context.Context, product *Product) error
 	FindByIDAndUserID(ctx context.Context, id uint, userID uint) (*Product, error)
 	FindByUserID(ctx context.Context, userID uint) ([]*Product, error)
+	FindByFilter(ctx context.Context, userID uint, filter ProductFilter) ([]*Product, error)
+	// FindInBatches passes the matching products to fn in ID order, batchSize
+	// at a time, so that large catalogs are not loaded at once.
+	FindInBatches(ctx context.Context, userID uint, filter ProductFilter, batchSize int, fn func([]*Product) error) error
 	FindByCodeAndUserID(ctx context.Context, code string, userID uint) (*Product, error)
+	// FindLowStockByUserID returns the products whose stock is at or below
+	// their reorder point, the furthest below it first.
+	FindLowStockByUserID(ctx context.Context, userID uint) ([]*Product, error)
 	Update(ctx context.Context, product *Product, userID uint) error
 	Delete(ctx context.Context, id uint, userID uint) error
+	// LockByIDs locks the rows of the user's products with the given IDs
+	// until the transaction in ctx ends.
+	LockByIDs(ctx context.Context, ids []uint, userID uint) error
 }
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ProductImage is an uploaded picture of a product. Key and ThumbnailKey name
+// the blobs of the original and its thumbnail. Images are shown in Position
+// order and at most one of a product's images is primary.
+type ProductImage struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"not null;index"`
+	ProductID    uint      `json:"product_id" gorm:"not null;index"`
+	Product      *Product  `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	Key          string    `json:"key" gorm:"not null"`
+	ThumbnailKey string    `json:"thumbnail_key" gorm:"not null"`
+	Filename     string    `json:"filename"`
+	ContentType  string    `json:"content_type" gorm:"type:varchar(50);not null"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	Position     int       `json:"position" gorm:"not null;default:0"`
+	Primary      bool      `json:"primary" gorm:"not null;default:false"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type ProductImageRepository interface {
+	Create(ctx context.Context, image *ProductImage) error
+	FindByProductID(ctx context.Context, productID, userID uint) ([]*ProductImage, error)
+	// UpdateOrder saves the Position and Primary fields of the images.
+	UpdateOrder(ctx context.Context, images []*ProductImage) error
+	Delete(ctx context.Context, id, userID uint) error
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type PurchaseOrderStatus string
+
+const (
+	PurchaseOrderStatusDraft             PurchaseOrderStatus = "draft"
+	PurchaseOrderStatusSent              PurchaseOrderStatus = "sent"
+	PurchaseOrderStatusPartiallyReceived PurchaseOrderStatus = "partially_received"
+	PurchaseOrderStatusReceived          PurchaseOrderStatus = "received"
+	PurchaseOrderStatusClosed            PurchaseOrderStatus = "closed"
+)
+
+type PurchaseOrder struct {
+	ID         uint                   `json:"id" gorm:"primaryKey"`
+	UserID     uint                   `json:"user_id" gorm:"not null;index"`
+	User       *User                  `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	SupplierID uint                   `json:"supplier_id" gorm:"not null;index"`
+	Supplier   *Supplier              `json:"-" gorm:"constraint:OnDelete:RESTRICT;"`
+	Status     PurchaseOrderStatus    `json:"status" gorm:"type:varchar(20);default:'draft'"`
+	Notes      string                 `json:"notes"`
+	TotalCost  float64                `json:"total_cost" gorm:"not null"`
+	ExpectedAt *time.Time             `json:"expected_at"`
+	SentAt     *time.Time             `json:"sent_at"`
+	ReceivedAt *time.Time             `json:"received_at"`
+	ClosedAt   *time.Time             `json:"closed_at"`
+	Lines      []PurchaseOrderLine    `json:"lines" gorm:"foreignKey:PurchaseOrderID"`
+	Receipts   []PurchaseOrderReceipt `json:"receipts" gorm:"foreignKey:PurchaseOrderID"`
+	CreatedAt  time.Time              `json:"created_at"`
+	UpdatedAt  time.Time              `json:"updated_at"`
+}
+
+type PurchaseOrderLine struct {
+	ID               uint    `json:"id" gorm:"primaryKey"`
+	PurchaseOrderID  uint    `json:"purchase_order_id" gorm:"not null;index"`
+	ProductID        uint    `json:"product_id" gorm:"not null"`
+	Product          Product `json:"product" gorm:"foreignKey:ProductID"`
+	VariantID        *uint   `json:"variant_id"`
+	QuantityOrdered  int     `json:"quantity_ordered" gorm:"not null"`
+	QuantityReceived int     `json:"quantity_received" gorm:"not null;default:0"`
+	UnitCost         float64 `json:"unit_cost" gorm:"not null"`
+	Subtotal         float64 `json:"subtotal" gorm:"not null"`
+}
+
+// Outstanding is the quantity still to be received.
+func (l *PurchaseOrderLine) Outstanding() int {
+	return l.QuantityOrdered - l.QuantityReceived
+}
+
+// PurchaseOrderReceipt records one delivery received against a purchase order.
+type PurchaseOrderReceipt struct {
+	ID              uint                       `json:"id" gorm:"primaryKey"`
+	PurchaseOrderID uint                       `json:"purchase_order_id" gorm:"not null;index"`
+	UserID          uint                       `json:"user_id" gorm:"not null"`
+	Notes           string                     `json:"notes"`
+	ReceivedAt      time.Time                  `json:"received_at"`
+	Lines           []PurchaseOrderReceiptLine `json:"lines" gorm:"foreignKey:ReceiptID"`
+}
+
+type PurchaseOrderReceiptLine struct {
+	ID        uint `json:"id" gorm:"primaryKey"`
+	ReceiptID uint `json:"receipt_id" gorm:"not null;index"`
+	LineID    uint `json:"line_id" gorm:"not null"`
+	Quantity  int  `json:"quantity" gorm:"not null"`
+}
+
+type PurchaseOrderRepository interface {
+	Create(ctx context.Context, order *PurchaseOrder) error
+	FindByIDAndUserID(ctx context.Context, id, userID uint) (*PurchaseOrder, error)
+	FindByUserID(ctx context.Context, userID uint, status PurchaseOrderStatus) ([]*PurchaseOrder, error)
+	CountBySupplierID(ctx context.Context, supplierID, userID uint) (int64, error)
+	// Update saves the order and its lines. Lines missing from order.Lines
+	// are deleted.
+	Update(ctx context.Context, order *PurchaseOrder, userID uint) error
+	Delete(ctx context.Context, id, userID uint) error
+	CreateReceipt(ctx context.Context, receipt *PurchaseOrderReceipt) error
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+type ReportType string
+
+const (
+	// ReportDailySales summarises the sales of the previous day.
+	ReportDailySales ReportType = "daily_sales"
+	// ReportLowStock lists the products at or below their reorder point.
+	ReportLowStock ReportType = "low_stock"
+)
+
+// ReportSubscription emails a report whenever its cron expression fires in
+// Timezone. Reports go to Recipients, a comma separated list, or to the
+// account address when it is empty. NextRunAt is nil while the subscription
+// is disabled.
+type ReportSubscription struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	User       *User      `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Report     ReportType `json:"report" gorm:"type:varchar(20);not null"`
+	Cron       string     `json:"cron" gorm:"type:varchar(100);not null"`
+	Timezone   string     `json:"timezone" gorm:"type:varchar(64);not null;default:'UTC'"`
+	Recipients string     `json:"recipients" gorm:"type:text;not null;default:''"`
+	Enabled    bool       `json:"enabled" gorm:"not null;default:true"`
+	NextRunAt  *time.Time `json:"next_run_at" gorm:"index"`
+	LastRunAt  *time.Time `json:"last_run_at"`
+	LastError  string     `json:"last_error"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (s *ReportSubscription) RecipientList() []string {
+	if s.Recipients == "" {
+		return []string{}
+	}
+	return strings.Split(s.Recipients, ",")
+}
+
+func (s *ReportSubscription) SetRecipients(recipients []string) {
+	s.Recipients = strings.Join(recipients, ",")
+}
+
+type ReportSubscriptionRepository interface {
+	Create(ctx context.Context, subscription *ReportSubscription) error
+	FindByIDAndUserID(ctx context.Context, id, userID uint) (*ReportSubscription, error)
+	FindByUserID(ctx context.Context, userID uint) ([]*ReportSubscription, error)
+	Update(ctx context.Context, subscription *ReportSubscription) error
+	// UpdateLastError sets only the last error of a subscription, leaving
+	// changes made since it was read alone.
+	UpdateLastError(ctx context.Context, id uint, lastError string) error
+	Delete(ctx context.Context, id, userID uint) error
+	// LockDue locks up to limit enabled subscriptions due at or before t,
+	// skipping those locked by another scheduler.
+	LockDue(ctx context.Context, t time.Time, limit int) ([]*ReportSubscription, error)
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type SerialStatus string
+
+const (
+	SerialStatusInStock  SerialStatus = "in_stock"
+	SerialStatusAssigned SerialStatus = "assigned"
+)
+
+// Serial is one unit of a serialized product, or of one of its variants.
+// An assigned serial belongs to the order item in OrderItemID. A returned
+// serial is back in stock and keeps the order item it was returned from
+// until it is assigned again.
+type Serial struct {
+	ID          uint            `json:"id" gorm:"primaryKey"`
+	UserID      uint            `json:"user_id" gorm:"not null;uniqueIndex:idx_user_serial"`
+	Number      string          `json:"number" gorm:"not null;uniqueIndex:idx_user_serial"`
+	ProductID   uint            `json:"product_id" gorm:"not null;index"`
+	Product     Product         `json:"product" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE;"`
+	VariantID   *uint           `json:"variant_id"`
+	Variant     *ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID;constraint:OnDelete:CASCADE;"`
+	Status      SerialStatus    `json:"status" gorm:"type:varchar(20);not null;index"`
+	OrderItemID *uint           `json:"order_item_id" gorm:"index"`
+	OrderItem   *OrderItem      `json:"order_item,omitempty" gorm:"foreignKey:OrderItemID;constraint:OnDelete:SET NULL;"`
+	ReceivedAt  time.Time       `json:"received_at"`
+	ReturnedAt  *time.Time      `json:"returned_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+type SerialRepository interface {
+	// Create saves new serials.
+	Create(ctx context.Context, serials []*Serial) error
+	// FindByNumber finds a serial with its product and the order item and
+	// order it was last assigned to.
+	FindByNumber(ctx context.Context, number string, userID uint) (*Serial, error)
+	// LockByNumbers returns the user's serials with the given numbers, with
+	// the order item and order they were last assigned to, and locks them
+	// until the transaction in ctx ends.
+	LockByNumbers(ctx context.Context, numbers []string, userID uint) ([]*Serial, error)
+	// FindByProductID returns the serials of a product, optionally only
+	// those with the given status.
+	FindByProductID(ctx context.Context, productID, userID uint, status SerialStatus) ([]*Serial, error)
+	Update(ctx context.Context, serial *Serial) error
+	// Unassign puts the serials assigned to the order items back in stock.
+	Unassign(ctx context.Context, orderItemIDs []uint) error
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type ShipmentStatus string
+
+const (
+	ShipmentStatusPending   ShipmentStatus = "pending"
+	ShipmentStatusShipped   ShipmentStatus = "shipped"
+	ShipmentStatusDelivered ShipmentStatus = "delivered"
+)
+
+type Shipment struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	OrderID        uint           `json:"order_id" gorm:"not null;index"`
+	Order          *Order         `json:"-" gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE;"`
+	UserID         uint           `json:"user_id" gorm:"not null;index"`
+	Carrier        string         `json:"carrier" gorm:"type:varchar(50)"`
+	Service        string         `json:"service" gorm:"type:varchar(50)"`
+	TrackingNumber string         `json:"tracking_number" gorm:"type:varchar(100);index"`
+	Weight         float64        `json:"weight"`
+	Status         ShipmentStatus `json:"status" gorm:"type:varchar(20);default:'pending'"`
+	ShippedAt      *time.Time     `json:"shipped_at"`
+	DeliveredAt    *time.Time     `json:"delivered_at"`
+	Items          []ShipmentItem `json:"items" gorm:"foreignKey:ShipmentID"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+type ShipmentItem struct {
+	ID          uint `json:"id" gorm:"primaryKey"`
+	ShipmentID  uint `json:"shipment_id" gorm:"not null;index"`
+	OrderItemID uint `json:"order_item_id" gorm:"not null;index"`
+	Quantity    int  `json:"quantity" gorm:"not null"`
+}
+
+type ShipmentRepository interface {
+	Create(ctx context.Context, shipment *Shipment) error
+	FindByIDAndUserID(ctx context.Context, id, userID uint) (*Shipment, error)
+	FindByOrderID(ctx context.Context, orderID, userID uint) ([]*Shipment, error)
+	Update(ctx context.Context, shipment *Shipment, userID uint) error
+}
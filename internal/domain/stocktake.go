@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type StocktakeStatus string
+
+const (
+	StocktakeStatusOpen      StocktakeStatus = "open"
+	StocktakeStatusApproved  StocktakeStatus = "approved"
+	StocktakeStatusCancelled StocktakeStatus = "cancelled"
+)
+
+// Stocktake is a physical count of a set of products. While it is open, its
+// products cannot be part of another open stocktake.
+type Stocktake struct {
+	ID          uint            `json:"id" gorm:"primaryKey"`
+	UserID      uint            `json:"user_id" gorm:"not null;index"`
+	User        *User           `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Name        string          `json:"name"`
+	Notes       string          `json:"notes"`
+	Status      StocktakeStatus `json:"status" gorm:"type:varchar(20);not null;default:'open'"`
+	Lines       []StocktakeLine `json:"lines" gorm:"foreignKey:StocktakeID"`
+	ApprovedAt  *time.Time      `json:"approved_at"`
+	CancelledAt *time.Time      `json:"cancelled_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// StocktakeLine counts a product, or one variant of a product with variants.
+// Expected is the system stock when the line was first counted, so that
+// stock moved by orders before the count is not reported as variance.
+// Adjustment is the stock change posted when the stocktake was approved.
+type StocktakeLine struct {
+	ID          uint            `json:"id" gorm:"primaryKey"`
+	StocktakeID uint            `json:"stocktake_id" gorm:"not null;index"`
+	Stocktake   *Stocktake      `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	ProductID   uint            `json:"product_id" gorm:"not null;index"`
+	Product     Product         `json:"product" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE;"`
+	VariantID   *uint           `json:"variant_id"`
+	Variant     *ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID;constraint:OnDelete:CASCADE;"`
+	Expected    *int            `json:"expected"`
+	Counted     *int            `json:"counted"`
+	CountedAt   *time.Time      `json:"counted_at"`
+	Adjustment  int             `json:"adjustment" gorm:"not null;default:0"`
+}
+
+// SystemStock is the current stock of the counted product or variant.
+func (l *StocktakeLine) SystemStock() int {
+	if l.Variant != nil {
+		return l.Variant.Stock
+	}
+	return l.Product.Stock
+}
+
+// Code is the code a barcode scanner reads for the line.
+func (l *StocktakeLine) Code() string {
+	if l.Variant != nil {
+		return l.Variant.Code
+	}
+	return l.Product.Code
+}
+
+// Variance is the counted minus the expected stock, and nil until counted.
+func (l *StocktakeLine) Variance() *int {
+	if l.Counted == nil || l.Expected == nil {
+		return nil
+	}
+	variance := *l.Counted - *l.Expected
+	return &variance
+}
+
+type StocktakeRepository interface {
+	// Create saves the stocktake with its lines.
+	Create(ctx context.Context, stocktake *Stocktake) error
+	FindByIDAndUserID(ctx context.Context, id, userID uint) (*Stocktake, error)
+	// LockByIDAndUserID finds a stocktake like FindByIDAndUserID and locks it
+	// until the transaction in ctx ends, so that counts and approval of the
+	// same stocktake run one at a time.
+	LockByIDAndUserID(ctx context.Context, id, userID uint) (*Stocktake, error)
+	FindByUserID(ctx context.Context, userID uint, status StocktakeStatus) ([]*Stocktake, error)
+	// FindOpenProductIDs returns the products in the user's open stocktakes.
+	FindOpenProductIDs(ctx context.Context, userID uint) ([]uint, error)
+	// Update saves the stocktake and the given lines.
+	Update(ctx context.Context, stocktake *Stocktake, lines []*StocktakeLine) error
+}
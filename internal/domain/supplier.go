@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type Supplier struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_supplier_user_name"`
+	User      *User     `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Name      string    `json:"name" gorm:"not null;uniqueIndex:idx_supplier_user_name"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone" gorm:"type:varchar(50)"`
+	Notes     string    `json:"notes"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SupplierProduct links a product to a supplier that sells it, with the
+// supplier's own SKU, the usual unit cost and the lead time in days.
+type SupplierProduct struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"not null;index"`
+	SupplierID   uint      `json:"supplier_id" gorm:"not null;uniqueIndex:idx_supplier_product"`
+	Supplier     *Supplier `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	ProductID    uint      `json:"product_id" gorm:"not null;uniqueIndex:idx_supplier_product"`
+	Product      *Product  `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	SupplierSKU  string    `json:"supplier_sku" gorm:"type:varchar(100)"`
+	UnitCost     float64   `json:"unit_cost"`
+	LeadTimeDays int       `json:"lead_time_days"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type SupplierRepository interface {
+	Create(ctx context.Context, supplier *Supplier) error
+	FindByIDAndUserID(ctx context.Context, id, userID uint) (*Supplier, error)
+	FindByUserID(ctx context.Context, userID uint) ([]*Supplier, error)
+	FindByNameAndUserID(ctx context.Context, name string, userID uint) (*Supplier, error)
+	Update(ctx context.Context, supplier *Supplier, userID uint) error
+	Delete(ctx context.Context, id, userID uint) error
+
+	FindProducts(ctx context.Context, supplierID, userID uint) ([]*SupplierProduct, error)
+	FindProduct(ctx context.Context, supplierID, productID, userID uint) (*SupplierProduct, error)
+	SaveProduct(ctx context.Context, link *SupplierProduct) error
+	DeleteProduct(ctx context.Context, supplierID, productID, userID uint) error
+}
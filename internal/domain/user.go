@@ -7,18 +7,53 @@ import (
 	"gorm.io/gorm"
 )
 
+// User is an account. SessionVersion is carried by every token issued to the
+// user; raising it signs out every session.
 type User struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	Name      string         `json:"name"`
-	Email     string         `gorm:"uniqueIndex" json:"email"`
-	Password  string         `json:"-"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	Name            string         `json:"name"`
+	Email           string         `gorm:"uniqueIndex" json:"email"`
+	Password        string         `json:"-"`
+	EmailVerifiedAt *time.Time     `json:"email_verified_at"`
+	SessionVersion  int            `gorm:"not null;default:0" json:"-"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 type UserRepository interface {
 	Create(ctx context.Context, user *User) error
 	FindByEmail(ctx context.Context, email string) (*User, error)
 	FindByID(ctx context.Context, id uint) (*User, error)
+	Update(ctx context.Context, user *User) error
+}
+
+type UserTokenPurpose string
+
+const (
+	TokenVerifyEmail   UserTokenPurpose = "verify_email"
+	TokenResetPassword UserTokenPurpose = "reset_password"
+)
+
+// UserToken is a single use token emailed to a user to prove they own their
+// address. Only the SHA-256 hash of the token is stored.
+type UserToken struct {
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	UserID    uint             `gorm:"not null;index" json:"user_id"`
+	User      *User            `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Purpose   UserTokenPurpose `gorm:"type:varchar(20);not null" json:"purpose"`
+	TokenHash string           `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time        `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time       `json:"used_at"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+type UserTokenRepository interface {
+	Create(ctx context.Context, token *UserToken) error
+	// LockByHash finds and locks the token with the given hash.
+	LockByHash(ctx context.Context, hash string) (*UserToken, error)
+	Update(ctx context.Context, token *UserToken) error
+	// InvalidateByUserID marks the unused tokens of a user for purpose as
+	// used at t.
+	InvalidateByUserID(ctx context.Context, userID uint, purpose UserTokenPurpose, t time.Time) error
 }
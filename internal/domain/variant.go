@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// OptionType is a dimension products vary in, such as size or colour, with
+// the values it can take. Option types are shared by all products of a user.
+type OptionType struct {
+	ID        uint          `json:"id" gorm:"primaryKey"`
+	UserID    uint          `json:"user_id" gorm:"not null;uniqueIndex:idx_option_type_user_name"`
+	User      *User         `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Name      string        `json:"name" gorm:"not null;uniqueIndex:idx_option_type_user_name"`
+	Values    []OptionValue `json:"values" gorm:"foreignKey:OptionTypeID"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+type OptionValue struct {
+	ID           uint        `json:"id" gorm:"primaryKey"`
+	OptionTypeID uint        `json:"option_type_id" gorm:"not null;uniqueIndex:idx_option_value_type_value"`
+	OptionType   *OptionType `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	Value        string      `json:"value" gorm:"not null;uniqueIndex:idx_option_value_type_value"`
+	Position     int         `json:"position"`
+}
+
+// ProductVariant is one sellable combination of option values of a product,
+// with its own code and stock. Price overrides the product price when set.
+type ProductVariant struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	UserID    uint            `json:"user_id" gorm:"not null;uniqueIndex:idx_variant_user_code"`
+	ProductID uint            `json:"product_id" gorm:"not null;index"`
+	Product   *Product        `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	Code      string          `json:"code" gorm:"not null;uniqueIndex:idx_variant_user_code"`
+	Price     *float64        `json:"price"`
+	Stock     int             `json:"stock"`
+	Options   []VariantOption `json:"options" gorm:"foreignKey:VariantID"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// EffectivePrice is the variant price, or the product price without an override.
+func (v *ProductVariant) EffectivePrice(product *Product) float64 {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return product.Price
+}
+
+// VariantOption assigns a variant its value for one option type.
+type VariantOption struct {
+	ID            uint            `json:"id" gorm:"primaryKey"`
+	VariantID     uint            `json:"variant_id" gorm:"not null;uniqueIndex:idx_variant_option_type"`
+	Variant       *ProductVariant `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	OptionTypeID  uint            `json:"option_type_id" gorm:"not null;uniqueIndex:idx_variant_option_type"`
+	OptionType    OptionType      `json:"option_type"`
+	OptionValueID uint            `json:"option_value_id" gorm:"not null"`
+	OptionValue   OptionValue     `json:"option_value"`
+}
+
+// HasVariants reports whether the product is sold through variants. Its
+// Stock is then the sum of the variant stocks.
+func (p *Product) HasVariants() bool {
+	return len(p.Variants) > 0
+}
+
+// Variant returns the product variant with the given ID.
+func (p *Product) Variant(id uint) (*ProductVariant, bool) {
+	for i := range p.Variants {
+		if p.Variants[i].ID == id {
+			return &p.Variants[i], true
+		}
+	}
+	return nil, false
+}
+
+type VariantRepository interface {
+	CreateOptionType(ctx context.Context, optionType *OptionType) error
+	FindOptionTypeByIDAndUserID(ctx context.Context, id, userID uint) (*OptionType, error)
+	FindOptionTypeByNameAndUserID(ctx context.Context, name string, userID uint) (*OptionType, error)
+	FindOptionTypesByUserID(ctx context.Context, userID uint) ([]*OptionType, error)
+	DeleteOptionType(ctx context.Context, id, userID uint) error
+	CreateOptionValue(ctx context.Context, value *OptionValue) error
+	DeleteOptionValue(ctx context.Context, id uint) error
+	// FindOptionValues returns the values with the given IDs that belong to
+	// option types of the user, with their option type loaded.
+	FindOptionValues(ctx context.Context, ids []uint, userID uint) ([]*OptionValue, error)
+	CountVariantsByOptionType(ctx context.Context, optionTypeID uint) (int64, error)
+	CountVariantsByOptionValue(ctx context.Context, optionValueID uint) (int64, error)
+
+	CreateVariant(ctx context.Context, variant *ProductVariant) error
+	FindVariantByCodeAndUserID(ctx context.Context, code string, userID uint) (*ProductVariant, error)
+	UpdateVariant(ctx context.Context, variant *ProductVariant) error
+	DeleteVariant(ctx context.Context, id, userID uint) error
+}
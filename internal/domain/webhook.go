@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+)
+
+// WebhookEventTypes lists the event types a subscription can listen to.
+var WebhookEventTypes = []string{
+	EventOrderCreated,
+	EventOrderStatusChanged,
+	EventProductStockLow,
+	EventStockAdjusted,
+	EventProductDeleted,
+	EventProductPriceChanged,
+}
+
+type WebhookSubscription struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	User      *User     `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	URL       string    `json:"url" gorm:"not null"`
+	Secret    string    `json:"-" gorm:"not null"`
+	Events    string    `json:"events" gorm:"type:text;not null"`
+	Active    bool      `json:"active" gorm:"not null;default:true"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// EventTypes returns the subscribed event types stored as a comma separated list.
+func (s *WebhookSubscription) EventTypes() []string {
+	if s.Events == "" {
+		return []string{}
+	}
+	return strings.Split(s.Events, ",")
+}
+
+func (s *WebhookSubscription) SetEventTypes(events []string) {
+	s.Events = strings.Join(events, ",")
+}
+
+func (s *WebhookSubscription) Subscribes(eventType string) bool {
+	return slices.Contains(s.EventTypes(), eventType)
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryRetrying  WebhookDeliveryStatus = "retrying"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryDead      WebhookDeliveryStatus = "dead"
+)
+
+// WebhookDelivery is an event queued for one subscription. EventID is the
+// outbox message it came from; a subscription gets each event once.
+// Attempts counts every attempt ever made; the retry policy applies to the
+// attempts made since RetryFrom, which a redelivery moves up.
+type WebhookDelivery struct {
+	ID             uint                  `json:"id" gorm:"primaryKey"`
+	EventID        uint                  `json:"event_id" gorm:"uniqueIndex:idx_webhook_delivery_event"`
+	SubscriptionID uint                  `json:"subscription_id" gorm:"not null;index;uniqueIndex:idx_webhook_delivery_event"`
+	Subscription   *WebhookSubscription  `json:"-" gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE;"`
+	UserID         uint                  `json:"user_id" gorm:"not null;index"`
+	EventType      string                `json:"event_type" gorm:"type:varchar(50);not null"`
+	Payload        string                `json:"payload" gorm:"type:text;not null"`
+	Status         WebhookDeliveryStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	Attempts       int                   `json:"attempts" gorm:"not null;default:0"`
+	RetryFrom      int                   `json:"-" gorm:"not null;default:0"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at" gorm:"index"`
+	LastStatusCode int                   `json:"last_status_code"`
+	LastError      string                `json:"last_error" gorm:"type:text"`
+	DeliveredAt    *time.Time            `json:"delivered_at"`
+	AttemptLog     []WebhookAttempt      `json:"attempt_log" gorm:"foreignKey:DeliveryID"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+// WebhookAttempt is one HTTP call made for a delivery.
+type WebhookAttempt struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	DeliveryID   uint      `json:"delivery_id" gorm:"not null;index"`
+	Number       int       `json:"number" gorm:"not null"`
+	StatusCode   int       `json:"status_code"`
+	Error        string    `json:"error" gorm:"type:text"`
+	ResponseBody string    `json:"response_body" gorm:"type:text"`
+	DurationMs   int64     `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *WebhookSubscription) error
+	FindSubscriptionByIDAndUserID(ctx context.Context, id, userID uint) (*WebhookSubscription, error)
+	FindSubscriptionsByUserID(ctx context.Context, userID uint) ([]*WebhookSubscription, error)
+	FindActiveSubscriptions(ctx context.Context, userID uint) ([]*WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, subscription *WebhookSubscription, userID uint) error
+	DeleteSubscription(ctx context.Context, id, userID uint) error
+
+	CreateDelivery(ctx context.Context, delivery *WebhookDelivery) error
+	FindDeliveryByIDAndUserID(ctx context.Context, id, userID uint) (*WebhookDelivery, error)
+	FindDeliveriesBySubscriptionID(ctx context.Context, subscriptionID, userID uint) ([]*WebhookDelivery, error)
+	// LockDueDeliveries locks deliveries due at now, skipping those locked
+	// by another transaction. Call it inside a transaction.
+	LockDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *WebhookDelivery) error
+	CreateAttempt(ctx context.Context, attempt *WebhookAttempt) error
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SalesPointResponse struct {
+	Period  time.Time `json:"period" example:"2024-01-01T00:00:00+01:00"`
+	Orders  int       `json:"orders" example:"42"`
+	Revenue float64   `json:"revenue" example:"15420.50"`
+}
+
+type ProductSalesResponse struct {
+	ProductID uint    `json:"product_id" example:"1"`
+	Code      string  `json:"code" example:"PROD001"`
+	Name      string  `json:"name" example:"Laptop"`
+	Quantity  int     `json:"quantity" example:"12"`
+	Revenue   float64 `json:"revenue" example:"15599.88"`
+	Orders    int     `json:"orders" example:"10"`
+}
+
+type StatusCountResponse struct {
+	Status  string  `json:"status" example:"delivered"`
+	Orders  int     `json:"orders" example:"30"`
+	Revenue float64 `json:"revenue" example:"11020.00"`
+}
+
+type FunnelStageResponse struct {
+	Status  string  `json:"status" example:"shipped"`
+	Orders  int     `json:"orders" example:"35"`
+	Percent float64 `json:"percent" example:"83.33"`
+}
+
+type SalesSummaryResponse struct {
+	Orders            int                   `json:"orders" example:"42"`
+	Revenue           float64               `json:"revenue" example:"15420.50"`
+	AverageOrderValue float64               `json:"average_order_value" example:"385.51"`
+	Cancelled         int                   `json:"cancelled" example:"2"`
+	CancellationRate  float64               `json:"cancellation_rate" example:"4.76"`
+	Statuses          []StatusCountResponse `json:"statuses"`
+	Funnel            []FunnelStageResponse `json:"funnel"`
+}
+
+// parseSalesFilter reads the tz, from and to query parameters. The days are
+// read in tz, an IANA time zone name, which defaults to UTC. "Local" is
+// refused: it names the server's zone, which Postgres does not know.
+func parseSalesFilter(c echo.Context) (domain.SalesFilter, error) {
+	loc := time.UTC
+	if v := c.QueryParam("tz"); v != "" {
+		var err error
+		if loc, err = time.LoadLocation(v); err != nil || v == "Local" {
+			return domain.SalesFilter{}, echo.NewHTTPError(http.StatusBadRequest, "tz must be an IANA time zone such as Europe/Madrid")
+		}
+	}
+	from, to, err := parseDateRange(c, loc)
+	if err != nil {
+		return domain.SalesFilter{}, err
+	}
+	return domain.SalesFilter{From: from, To: to, Location: loc}, nil
+}
+
+type AnalyticsHandler struct {
+	service *service.AnalyticsService
+}
+
+func NewAnalyticsHandler(service *service.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{service: service}
+}
+
+// GetSalesOverTime godoc
+// @Summary Report sales over time
+// @Description Get the order count and revenue of each day, week or month, cancelled orders excluded. Periods start in the given time zone, weeks on Monday, and periods without orders are included
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param bucket query string false "Period length" Enums(day, week, month)
+// @Param tz query string false "IANA time zone, defaults to UTC" example(Europe/Madrid)
+// @Param from query string false "First day, as YYYY-MM-DD"
+// @Param to query string false "Last day, as YYYY-MM-DD"
+// @Success 200 {array} SalesPointResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /reports/sales [get]
+func (h *AnalyticsHandler) GetSalesOverTime(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	filter, err := parseSalesFilter(c)
+	if err != nil {
+		return err
+	}
+	points, err := h.service.SalesOverTime(c.Request().Context(), userID, domain.SalesBucket(c.QueryParam("bucket")), filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	resp := make([]SalesPointResponse, len(points))
+	for i, point := range points {
+		resp[i] = SalesPointResponse{Period: point.Period, Orders: point.Orders, Revenue: point.Revenue}
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetTopProducts godoc
+// @Summary Report top selling products
+// @Description Get the best selling products by revenue or units sold, cancelled orders excluded
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param by query string false "Ranking" Enums(revenue, quantity)
+// @Param limit query int false "How many products, 10 by default and at most 100"
+// @Param tz query string false "IANA time zone the days are in, defaults to UTC"
+// @Param from query string false "First day, as YYYY-MM-DD"
+// @Param to query string false "Last day, as YYYY-MM-DD"
+// @Success 200 {array} ProductSalesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /reports/top-products [get]
+func (h *AnalyticsHandler) GetTopProducts(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	filter, err := parseSalesFilter(c)
+	if err != nil {
+		return err
+	}
+	limit := 0
+	if v := c.QueryParam("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "limit must be a number")
+		}
+	}
+	products, err := h.service.TopProducts(c.Request().Context(), userID, filter, c.QueryParam("by"), limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	resp := make([]ProductSalesResponse, len(products))
+	for i, product := range products {
+		resp[i] = ProductSalesResponse{
+			ProductID: product.ProductID,
+			Code:      product.Code,
+			Name:      product.Name,
+			Quantity:  product.Quantity,
+			Revenue:   product.Revenue,
+			Orders:    product.Orders,
+		}
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetSalesSummary godoc
+// @Summary Report a sales summary
+// @Description Get the order count, revenue, average order value, cancellation rate, orders per status and the status funnel of a period
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param tz query string false "IANA time zone the days are in, defaults to UTC"
+// @Param from query string false "First day, as YYYY-MM-DD"
+// @Param to query string false "Last day, as YYYY-MM-DD"
+// @Success 200 {object} SalesSummaryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /reports/summary [get]
+func (h *AnalyticsHandler) GetSalesSummary(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	filter, err := parseSalesFilter(c)
+	if err != nil {
+		return err
+	}
+	summary, err := h.service.SalesSummary(c.Request().Context(), userID, filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	resp := SalesSummaryResponse{
+		Orders:            summary.Orders,
+		Revenue:           summary.Revenue,
+		AverageOrderValue: summary.AverageOrderValue,
+		Cancelled:         summary.Cancelled,
+		CancellationRate:  summary.CancellationRate,
+		Statuses:          make([]StatusCountResponse, len(summary.Statuses)),
+		Funnel:            make([]FunnelStageResponse, len(summary.Funnel)),
+	}
+	for i, count := range summary.Statuses {
+		resp.Statuses[i] = StatusCountResponse{Status: string(count.Status), Orders: count.Orders, Revenue: count.Revenue}
+	}
+	for i, stage := range summary.Funnel {
+		resp.Funnel[i] = FunnelStageResponse{Status: string(stage.Status), Orders: stage.Orders, Percent: stage.Percent}
+	}
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type BundleComponentResponse struct {
+	ProductID   uint   `json:"product_id" example:"2"`
+	Code        string `json:"code" example:"MOUSE-01"`
+	Name        string `json:"name" example:"Wireless Mouse"`
+	VariantID   *uint  `json:"variant_id,omitempty" example:"4"`
+	VariantCode string `json:"variant_code,omitempty" example:"MOUSE-01-BLACK"`
+	Quantity    int    `json:"quantity" example:"1"`
+	Stock       int    `json:"stock" example:"25"`
+}
+
+type bundleComponentsRequest struct {
+	Components []service.BundleComponentRequest `json:"components"`
+}
+
+func toBundleComponentResponses(p *domain.Product) []BundleComponentResponse {
+	if !p.IsBundle() {
+		return nil
+	}
+	resp := make([]BundleComponentResponse, len(p.Components))
+	for i, component := range p.Components {
+		resp[i] = BundleComponentResponse{
+			ProductID: component.ComponentID,
+			Code:      component.Component.Code,
+			Name:      component.Component.Name,
+			VariantID: component.VariantID,
+			Quantity:  component.Quantity,
+			Stock:     component.Component.Stock,
+		}
+		if component.Variant != nil {
+			resp[i].VariantCode = component.Variant.Code
+			resp[i].Stock = component.Variant.Stock
+		}
+	}
+	return resp
+}
+
+type BundleHandler struct {
+	service *service.BundleService
+}
+
+func NewBundleHandler(service *service.BundleService) *BundleHandler {
+	return &BundleHandler{service: service}
+}
+
+// SetComponents godoc
+// @Summary Set the components of a bundle
+// @Description Make a product a bundle of other products, replacing its components. The stock of a bundle is how many units its components make up; ordering a bundle takes its components from stock. An empty list makes it a regular product again.
+// @Tags bundles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param components body bundleComponentsRequest true "Components"
+// @Success 200 {object} ProductResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /products/{id}/components [put]
+func (h *BundleHandler) SetComponents(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	var body bundleComponentsRequest
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	product, err := h.service.SetComponents(c.Request().Context(), uint(id), userID, body.Components)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toProductResponse(product))
+}
@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type CategorySummary struct {
+	ID   uint   `json:"id" example:"2"`
+	Name string `json:"name" example:"T-Shirts"`
+	Slug string `json:"slug" example:"t-shirts"`
+}
+
+type CategoryResponse struct {
+	ID       uint   `json:"id" example:"2"`
+	ParentID *uint  `json:"parent_id" example:"1"`
+	Name     string `json:"name" example:"T-Shirts"`
+	Slug     string `json:"slug" example:"t-shirts"`
+	Position int    `json:"position" example:"0"`
+}
+
+type CategoryTreeResponse struct {
+	CategoryResponse
+	Path     string                 `json:"path" example:"Clothing / T-Shirts"`
+	Children []CategoryTreeResponse `json:"children"`
+}
+
+type CategoryReportResponse struct {
+	CategoryID uint    `json:"category_id" example:"2"`
+	Name       string  `json:"name" example:"T-Shirts"`
+	Slug       string  `json:"slug" example:"t-shirts"`
+	Path       string  `json:"path" example:"Clothing / T-Shirts"`
+	Products   int     `json:"products" example:"12"`
+	Units      int     `json:"units" example:"340"`
+	StockValue float64 `json:"stock_value" example:"4250.50"`
+	LowStock   int     `json:"low_stock" example:"2"`
+}
+
+type setProductCategoriesRequest struct {
+	CategoryIDs []uint `json:"category_ids" example:"1,2"`
+}
+
+func toCategoryResponse(category *domain.Category) CategoryResponse {
+	return CategoryResponse{
+		ID:       category.ID,
+		ParentID: category.ParentID,
+		Name:     category.Name,
+		Slug:     category.Slug,
+		Position: category.Position,
+	}
+}
+
+func toCategoryTreeResponses(nodes []*service.CategoryNode) []CategoryTreeResponse {
+	resp := make([]CategoryTreeResponse, len(nodes))
+	for i, node := range nodes {
+		resp[i] = CategoryTreeResponse{
+			CategoryResponse: toCategoryResponse(node.Category),
+			Path:             node.Path,
+			Children:         toCategoryTreeResponses(node.Children),
+		}
+	}
+	return resp
+}
+
+func toCategorySummaries(categories []domain.Category) []CategorySummary {
+	summaries := make([]CategorySummary, len(categories))
+	for i, category := range categories {
+		summaries[i] = CategorySummary{ID: category.ID, Name: category.Name, Slug: category.Slug}
+	}
+	return summaries
+}
+
+type CategoryHandler struct {
+	service *service.CategoryService
+}
+
+func NewCategoryHandler(service *service.CategoryService) *CategoryHandler {
+	return &CategoryHandler{service: service}
+}
+
+// CreateCategory godoc
+// @Summary Create a category
+// @Description Create a product category, optionally under a parent category. The slug defaults to one derived from the name.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param category body service.CategoryRequest true "Category data"
+// @Success 201 {object} CategoryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /categories [post]
+func (h *CategoryHandler) CreateCategory(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	var req service.CategoryRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	category, err := h.service.CreateCategory(c.Request().Context(), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, toCategoryResponse(category))
+}
+
+// ListCategories godoc
+// @Summary List categories
+// @Description Get the category tree of the authenticated user, siblings ordered by position and name
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} CategoryTreeResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /categories [get]
+func (h *CategoryHandler) ListCategories(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	nodes, err := h.service.GetCategoryTree(c.Request().Context(), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, toCategoryTreeResponses(nodes))
+}
+
+// CategoryReport godoc
+// @Summary Stock per category
+// @Description Get the number of products, units in stock, stock value and low-stock products of every category, including its subcategories
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} CategoryReportResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /categories/report [get]
+func (h *CategoryHandler) CategoryReport(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	reports, err := h.service.Report(c.Request().Context(), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	resp := make([]CategoryReportResponse, len(reports))
+	for i, report := range reports {
+		resp[i] = CategoryReportResponse{
+			CategoryID: report.Category.ID,
+			Name:       report.Category.Name,
+			Slug:       report.Category.Slug,
+			Path:       report.Path,
+			Products:   report.Products,
+			Units:      report.Units,
+			StockValue: report.StockValue,
+			LowStock:   report.LowStock,
+		}
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetCategory godoc
+// @Summary Get a category
+// @Description Get a category of the authenticated user
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Category ID"
+// @Success 200 {object} CategoryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /categories/{id} [get]
+func (h *CategoryHandler) GetCategory(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid category id")
+	}
+	category, err := h.service.GetCategory(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.JSON(http.StatusOK, toCategoryResponse(category))
+}
+
+// UpdateCategory godoc
+// @Summary Update a category
+// @Description Rename, reorder or move a category. A parent_id of 0 moves it to the top level.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Category ID"
+// @Param category body service.UpdateCategoryRequest true "Data to update"
+// @Success 200 {object} CategoryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /categories/{id} [patch]
+func (h *CategoryHandler) UpdateCategory(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid category id")
+	}
+	var req service.UpdateCategoryRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	category, err := h.service.UpdateCategory(c.Request().Context(), uint(id), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toCategoryResponse(category))
+}
+
+// DeleteCategory godoc
+// @Summary Delete a category
+// @Description Delete a category without subcategories; its products are kept
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Category ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /categories/{id} [delete]
+func (h *CategoryHandler) DeleteCategory(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid category id")
+	}
+	if err := h.service.DeleteCategory(c.Request().Context(), uint(id), userID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// SetProductCategories godoc
+// @Summary Set the categories of a product
+// @Description Replace the categories of a product; an empty list removes it from all categories
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param categories body setProductCategoriesRequest true "Category IDs"
+// @Success 200 {object} ProductResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /products/{id}/categories [put]
+func (h *CategoryHandler) SetProductCategories(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	var req setProductCategoriesRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	product, err := h.service.SetProductCategories(c.Request().Context(), uint(id), userID, req.CategoryIDs)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toProductResponse(product))
+}
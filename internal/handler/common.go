@@ -1,6 +1,37 @@
 package handler
 
+import (
+	"errors"
+	"net/http"
+
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
 // ErrorResponse representa una respuesta de error estándar
 type ErrorResponse struct {
 	Error string `json:"error" example:"error message"`
 }
+
+// EntryErrorsResponse lists the rejected entries of a batch request.
+type EntryErrorsResponse struct {
+	Error   string               `json:"error" example:"2 entries are invalid"`
+	Entries []service.EntryError `json:"entries"`
+}
+
+// FieldErrorsResponse lists the rejected fields of a request.
+type FieldErrorsResponse struct {
+	Error  string               `json:"error" example:"2 fields are invalid"`
+	Fields []service.FieldError `json:"fields"`
+}
+
+// entryErrorsResponse returns a 400 error that lists the rejected entries
+// when err is a *service.EntryErrors.
+func entryErrorsResponse(err error) error {
+	var entryErrs *service.EntryErrors
+	if errors.As(err, &entryErrs) {
+		return echo.NewHTTPError(http.StatusBadRequest, EntryErrorsResponse{Error: err.Error(), Entries: entryErrs.Entries})
+	}
+	return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+}
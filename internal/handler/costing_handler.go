@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type MarginLineResponse struct {
+	OrderID       uint    `json:"order_id,omitempty" example:"42"`
+	ProductID     uint    `json:"product_id,omitempty" example:"1"`
+	ProductCode   string  `json:"product_code,omitempty" example:"PROD001"`
+	ProductName   string  `json:"product_name,omitempty" example:"Laptop"`
+	Period        string  `json:"period,omitempty" example:"2024-01"`
+	Quantity      int     `json:"quantity" example:"12"`
+	Revenue       float64 `json:"revenue" example:"15599.88"`
+	Cost          float64 `json:"cost" example:"11400.00"`
+	Margin        float64 `json:"margin" example:"4199.88"`
+	MarginPercent float64 `json:"margin_percent" example:"26.92"`
+}
+
+type MarginReportResponse struct {
+	GroupBy string               `json:"group_by" example:"month"`
+	Lines   []MarginLineResponse `json:"lines"`
+	Total   MarginLineResponse   `json:"total"`
+}
+
+func toMarginLineResponse(line service.MarginLine) MarginLineResponse {
+	return MarginLineResponse{
+		OrderID:       line.OrderID,
+		ProductID:     line.ProductID,
+		ProductCode:   line.ProductCode,
+		ProductName:   line.ProductName,
+		Period:        line.Period,
+		Quantity:      line.Quantity,
+		Revenue:       line.Revenue,
+		Cost:          line.Cost,
+		Margin:        line.Margin(),
+		MarginPercent: line.MarginPercent(),
+	}
+}
+
+type ValuationLineResponse struct {
+	ProductID uint    `json:"product_id" example:"1"`
+	Code      string  `json:"code" example:"PROD001"`
+	Name      string  `json:"name" example:"Laptop"`
+	Quantity  int     `json:"quantity" example:"10"`
+	UnitCost  float64 `json:"unit_cost" example:"950.00"`
+	Value     float64 `json:"value" example:"9500.00"`
+}
+
+type InventoryValuationResponse struct {
+	AsOf   time.Time               `json:"as_of" example:"2024-01-31T23:59:59Z"`
+	Method string                  `json:"method" example:"fifo"`
+	Lines  []ValuationLineResponse `json:"lines"`
+	Total  float64                 `json:"total" example:"9500.00"`
+}
+
+type CostingHandler struct {
+	service *service.CostingService
+}
+
+func NewCostingHandler(service *service.CostingService) *CostingHandler {
+	return &CostingHandler{service: service}
+}
+
+// SetProductCost godoc
+// @Summary Set the cost of a product
+// @Description Set the unit cost of a product and whether it is a standard cost or a moving average recomputed on every purchase receipt
+// @Tags costing
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param cost body service.SetCostRequest true "Unit cost and cost method"
+// @Success 200 {object} ProductResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /products/{id}/cost [put]
+func (h *CostingHandler) SetProductCost(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	var req service.SetCostRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	product, err := h.service.SetCost(c.Request().Context(), uint(id), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toProductResponse(product))
+}
+
+// GetMarginReport godoc
+// @Summary Report gross margins
+// @Description Get the revenue, cost of sales and gross margin of the orders created in a period, cancelled orders excluded, per order, product, day or month
+// @Tags costing
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param group_by query string false "How to group the lines" Enums(order, product, day, month)
+// @Param from query string false "First day, as YYYY-MM-DD"
+// @Param to query string false "Last day, as YYYY-MM-DD"
+// @Success 200 {object} MarginReportResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /reports/margins [get]
+func (h *CostingHandler) GetMarginReport(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		return err
+	}
+	filter.Status = ""
+	report, err := h.service.MarginReport(c.Request().Context(), userID, service.MarginGroup(c.QueryParam("group_by")), filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	resp := MarginReportResponse{
+		GroupBy: string(report.GroupBy),
+		Lines:   make([]MarginLineResponse, len(report.Lines)),
+		Total:   toMarginLineResponse(report.Total),
+	}
+	for i, line := range report.Lines {
+		resp.Lines[i] = toMarginLineResponse(line)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetInventoryValuation godoc
+// @Summary Report inventory valuation
+// @Description Get the stock on hand at the end of a day and its value by FIFO or weighted average cost, replayed from the inventory ledger
+// @Tags costing
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param as_of query string false "Day to value the stock at the end of, as YYYY-MM-DD; defaults to now"
+// @Param method query string false "Valuation method" Enums(fifo, weighted_average)
+// @Success 200 {object} InventoryValuationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /reports/inventory-valuation [get]
+func (h *CostingHandler) GetInventoryValuation(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	asOf := time.Now()
+	if v := c.QueryParam("as_of"); v != "" {
+		day, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "as_of must be a date as YYYY-MM-DD")
+		}
+		asOf = day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	valuation, err := h.service.InventoryValuation(c.Request().Context(), userID, asOf, service.ValuationMethod(c.QueryParam("method")))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	resp := InventoryValuationResponse{
+		AsOf:   valuation.AsOf,
+		Method: string(valuation.Method),
+		Lines:  make([]ValuationLineResponse, len(valuation.Lines)),
+		Total:  valuation.Total,
+	}
+	for i, line := range valuation.Lines {
+		resp.Lines[i] = ValuationLineResponse{
+			ProductID: line.ProductID,
+			Code:      line.Code,
+			Name:      line.Name,
+			Quantity:  line.Quantity,
+			UnitCost:  line.UnitCost,
+			Value:     line.Value,
+		}
+	}
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type CustomerResponse struct {
+	ID        uint      `json:"id" example:"1"`
+	Name      string    `json:"name" example:"Acme Retail"`
+	Email     string    `json:"email" example:"buying@acme.example.com"`
+	GroupID   *uint     `json:"group_id" example:"1"`
+	GroupName string    `json:"group_name,omitempty" example:"Wholesale"`
+	CreatedAt time.Time `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt time.Time `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+}
+
+type CustomerGroupResponse struct {
+	ID        uint      `json:"id" example:"1"`
+	Name      string    `json:"name" example:"Wholesale"`
+	CreatedAt time.Time `json:"created_at" example:"2024-01-15T10:30:00Z"`
+}
+
+type customerGroupRequest struct {
+	Name string `json:"name" example:"Wholesale"`
+}
+
+func toCustomerResponse(customer *domain.Customer) CustomerResponse {
+	resp := CustomerResponse{
+		ID:        customer.ID,
+		Name:      customer.Name,
+		Email:     customer.Email,
+		GroupID:   customer.GroupID,
+		CreatedAt: customer.CreatedAt,
+		UpdatedAt: customer.UpdatedAt,
+	}
+	if customer.Group != nil {
+		resp.GroupName = customer.Group.Name
+	}
+	return resp
+}
+
+func toCustomerGroupResponse(group *domain.CustomerGroup) CustomerGroupResponse {
+	return CustomerGroupResponse{ID: group.ID, Name: group.Name, CreatedAt: group.CreatedAt}
+}
+
+type CustomerHandler struct {
+	service *service.CustomerService
+}
+
+func NewCustomerHandler(service *service.CustomerService) *CustomerHandler {
+	return &CustomerHandler{service: service}
+}
+
+// CreateCustomer godoc
+// @Summary Create a customer
+// @Description Create a customer, optionally in a customer group
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param customer body service.CustomerRequest true "Customer data"
+// @Success 201 {object} CustomerResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /customers [post]
+func (h *CustomerHandler) CreateCustomer(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	var req service.CustomerRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	customer, err := h.service.CreateCustomer(c.Request().Context(), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, toCustomerResponse(customer))
+}
+
+// ListCustomers godoc
+// @Summary List customers
+// @Description Get all customers of the authenticated user
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} CustomerResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /customers [get]
+func (h *CustomerHandler) ListCustomers(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	customers, err := h.service.GetCustomersByUser(c.Request().Context(), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	resp := make([]CustomerResponse, len(customers))
+	for i, customer := range customers {
+		resp[i] = toCustomerResponse(customer)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetCustomer godoc
+// @Summary Get a customer
+// @Description Get a customer of the authenticated user
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Customer ID"
+// @Success 200 {object} CustomerResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /customers/{id} [get]
+func (h *CustomerHandler) GetCustomer(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid customer id")
+	}
+	customer, err := h.service.GetCustomer(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.JSON(http.StatusOK, toCustomerResponse(customer))
+}
+
+// UpdateCustomer godoc
+// @Summary Update a customer
+// @Description Update the details or the group of a customer. A group_id of 0 takes the customer out of its group.
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Customer ID"
+// @Param customer body service.UpdateCustomerRequest true "Data to update"
+// @Success 200 {object} CustomerResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /customers/{id} [patch]
+func (h *CustomerHandler) UpdateCustomer(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid customer id")
+	}
+	var req service.UpdateCustomerRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	customer, err := h.service.UpdateCustomer(c.Request().Context(), uint(id), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toCustomerResponse(customer))
+}
+
+// DeleteCustomer godoc
+// @Summary Delete a customer
+// @Description Delete a customer. Its orders are kept without a customer.
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Customer ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /customers/{id} [delete]
+func (h *CustomerHandler) DeleteCustomer(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid customer id")
+	}
+	if err := h.service.DeleteCustomer(c.Request().Context(), uint(id), userID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// CreateGroup godoc
+// @Summary Create a customer group
+// @Description Create a group of customers that share price lists
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param group body customerGroupRequest true "Group data"
+// @Success 201 {object} CustomerGroupResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /customer-groups [post]
+func (h *CustomerHandler) CreateGroup(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	var req customerGroupRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	group, err := h.service.CreateGroup(c.Request().Context(), userID, req.Name)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, toCustomerGroupResponse(group))
+}
+
+// ListGroups godoc
+// @Summary List customer groups
+// @Description Get all customer groups of the authenticated user
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} CustomerGroupResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /customer-groups [get]
+func (h *CustomerHandler) ListGroups(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	groups, err := h.service.GetGroups(c.Request().Context(), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	resp := make([]CustomerGroupResponse, len(groups))
+	for i, group := range groups {
+		resp[i] = toCustomerGroupResponse(group)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// DeleteGroup godoc
+// @Summary Delete a customer group
+// @Description Delete a customer group. Its customers are kept without a group.
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Customer group ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /customer-groups/{id} [delete]
+func (h *CustomerHandler) DeleteGroup(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid customer group id")
+	}
+	if err := h.service.DeleteGroup(c.Request().Context(), uint(id), userID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
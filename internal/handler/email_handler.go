@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type NotificationPreferencesResponse struct {
+	OrderCreated       bool `json:"order_created" example:"true"`
+	OrderStatusChanged bool `json:"order_status_changed" example:"true"`
+	OrderCancelled     bool `json:"order_cancelled" example:"true"`
+}
+
+type EmailLogResponse struct {
+	ID         uint      `json:"id" example:"1"`
+	Kind       string    `json:"kind" example:"order_created"`
+	Recipients string    `json:"recipients" example:"john@example.com"`
+	Subject    string    `json:"subject" example:"Order #42 placed"`
+	Status     string    `json:"status" example:"failed"`
+	Error      string    `json:"error" example:"dial tcp: connection refused"`
+	EventID    *uint     `json:"event_id" example:"120"`
+	CreatedAt  time.Time `json:"created_at" example:"2024-01-15T10:30:00Z"`
+}
+
+func toNotificationPreferencesResponse(preferences *domain.NotificationPreferences) NotificationPreferencesResponse {
+	return NotificationPreferencesResponse{
+		OrderCreated:       preferences.OrderCreated,
+		OrderStatusChanged: preferences.OrderStatusChanged,
+		OrderCancelled:     preferences.OrderCancelled,
+	}
+}
+
+type EmailHandler struct {
+	service *service.EmailService
+}
+
+func NewEmailHandler(service *service.EmailService) *EmailHandler {
+	return &EmailHandler{service: service}
+}
+
+// GetNotificationPreferences godoc
+// @Summary Get notification preferences
+// @Description Get which optional emails the authenticated user receives. Account emails are always sent
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} NotificationPreferencesResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /users/notification-preferences [get]
+func (h *EmailHandler) GetNotificationPreferences(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	preferences, err := h.service.GetPreferences(c.Request().Context(), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, toNotificationPreferencesResponse(preferences))
+}
+
+// UpdateNotificationPreferences godoc
+// @Summary Update notification preferences
+// @Description Turn the order emails of the authenticated user on or off. Omitted fields are left as they are
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param preferences body service.UpdateNotificationPreferencesRequest true "Preferences to change"
+// @Success 200 {object} NotificationPreferencesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /users/notification-preferences [patch]
+func (h *EmailHandler) UpdateNotificationPreferences(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	var req service.UpdateNotificationPreferencesRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	preferences, err := h.service.UpdatePreferences(c.Request().Context(), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, toNotificationPreferencesResponse(preferences))
+}
+
+// ListEmailLog godoc
+// @Summary List sent emails
+// @Description Get the latest transactional emails of the authenticated user, newest first, with whether each was sent, failed or skipped by the notification preferences
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Maximum number of emails (default 50, max 200)"
+// @Success 200 {array} EmailLogResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /users/email-log [get]
+func (h *EmailHandler) ListEmailLog(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	var limit int
+	if v := c.QueryParam("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid limit")
+		}
+	}
+	entries, err := h.service.GetEmailLog(c.Request().Context(), userID, limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	resp := make([]EmailLogResponse, len(entries))
+	for i, entry := range entries {
+		resp[i] = EmailLogResponse{
+			ID:         entry.ID,
+			Kind:       string(entry.Kind),
+			Recipients: entry.Recipients,
+			Subject:    entry.Subject,
+			Status:     string(entry.Status),
+			Error:      entry.Error,
+			EventID:    entry.EventID,
+			CreatedAt:  entry.CreatedAt,
+		}
+	}
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ExportHandler struct {
+	service *service.ExportService
+}
+
+func NewExportHandler(service *service.ExportService) *ExportHandler {
+	return &ExportHandler{service: service}
+}
+
+// exportRequest reads the format and columns query parameters.
+func exportRequest(c echo.Context) service.ExportRequest {
+	req := service.ExportRequest{Format: strings.ToLower(c.QueryParam("format"))}
+	if req.Format == "" {
+		req.Format = "csv"
+	}
+	if v := c.QueryParam("columns"); v != "" {
+		req.Columns = strings.Split(v, ",")
+	}
+	return req
+}
+
+// stream sends an export as a download. Errors before the first byte are
+// returned as usual; once streaming has started, the connection is aborted
+// so that a truncated file is not mistaken for a complete one.
+func stream(c echo.Context, name string, req service.ExportRequest, write func(w io.Writer) error) error {
+	contentType, ok := service.ExportContentType(req.Format)
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "unsupported export format, expected csv, xlsx or ndjson")
+	}
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, contentType)
+	res.Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=\"%s-%s.%s\"", name, time.Now().UTC().Format("20060102"), req.Format))
+
+	err := write(res)
+	if err == nil {
+		if !res.Committed {
+			res.WriteHeader(http.StatusOK)
+		}
+		return nil
+	}
+	if !res.Committed {
+		res.Header().Del(echo.HeaderContentDisposition)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	log.Printf("exports: %s export failed while streaming: %v", name, err)
+	panic(http.ErrAbortHandler)
+}
+
+// ExportProducts godoc
+// @Summary Export products
+// @Description Download the products of the authenticated user as CSV, XLSX or NDJSON, streamed in batches. The columns are id, code, name, description, price, stock, reorder_point, reorder_quantity, variants, categories, tags, created_at and updated_at; the file can be edited and sent to the product import.
+// @Tags exports
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Param format query string false "csv (default), xlsx or ndjson"
+// @Param columns query string false "Comma-separated columns in the order to export"
+// @Param category query string false "Category ID or slug"
+// @Param tag query string false "Tag name"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /exports/products [get]
+func (h *ExportHandler) ExportProducts(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	req := exportRequest(c)
+	filter := service.ProductListFilter{Category: c.QueryParam("category"), Tag: c.QueryParam("tag")}
+	return stream(c, "products", req, func(w io.Writer) error {
+		return h.service.ExportProducts(c.Request().Context(), userID, w, req, filter)
+	})
+}
+
+// ExportOrders godoc
+// @Summary Export orders
+// @Description Download one record per order as CSV, XLSX or NDJSON, streamed in batches. The columns are id, status, total_amount, lines, units, created_at and updated_at.
+// @Tags exports
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Param format query string false "csv (default), xlsx or ndjson"
+// @Param columns query string false "Comma-separated columns in the order to export"
+// @Param status query string false "Order status"
+// @Param from query string false "First day, as YYYY-MM-DD in UTC"
+// @Param to query string false "Last day, as YYYY-MM-DD in UTC"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /exports/orders [get]
+func (h *ExportHandler) ExportOrders(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		return err
+	}
+	req := exportRequest(c)
+	return stream(c, "orders", req, func(w io.Writer) error {
+		return h.service.ExportOrders(c.Request().Context(), userID, w, req, filter)
+	})
+}
+
+// ExportOrderLines godoc
+// @Summary Export order lines
+// @Description Download one record per order item as CSV, XLSX or NDJSON, streamed in batches. The columns are order_id, order_status, order_created_at, line_id, product_id, product_code, product_name, variant_id, variant_code, quantity, unit_price and subtotal.
+// @Tags exports
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Param format query string false "csv (default), xlsx or ndjson"
+// @Param columns query string false "Comma-separated columns in the order to export"
+// @Param status query string false "Order status"
+// @Param from query string false "First day, as YYYY-MM-DD in UTC"
+// @Param to query string false "Last day, as YYYY-MM-DD in UTC"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /exports/order-lines [get]
+func (h *ExportHandler) ExportOrderLines(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		return err
+	}
+	req := exportRequest(c)
+	return stream(c, "order-lines", req, func(w io.Writer) error {
+		return h.service.ExportOrderLines(c.Request().Context(), userID, w, req, filter)
+	})
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type StockForecastResponse struct {
+	ProductID         uint     `json:"product_id" example:"1"`
+	Code              string   `json:"code" example:"PROD001"`
+	Name              string   `json:"name" example:"Laptop"`
+	VariantID         *uint    `json:"variant_id,omitempty" example:"3"`
+	VariantCode       string   `json:"variant_code,omitempty" example:"PROD001-RED-M"`
+	Stock             int      `json:"stock" example:"12"`
+	OnOrder           int      `json:"on_order" example:"10"`
+	AverageDailySales float64  `json:"average_daily_sales" example:"1.5"`
+	DailySales        float64  `json:"daily_sales" example:"1.8"`
+	DaysOfCover       *float64 `json:"days_of_cover" example:"6.6"`
+	StockOutDate      *string  `json:"stockout_date" example:"2024-01-21"`
+	LeadTimeDays      int      `json:"lead_time_days" example:"7"`
+	AtRisk            bool     `json:"at_risk" example:"true"`
+	SuggestedQuantity int      `json:"suggested_quantity" example:"57"`
+}
+
+type ForecastResponse struct {
+	WindowDays    int                           `json:"window_days" example:"28"`
+	Lines         []StockForecastResponse       `json:"lines"`
+	PurchaseOrder *service.PurchaseOrderRequest `json:"purchase_order,omitempty"`
+}
+
+type ForecastHandler struct {
+	service *service.ForecastService
+}
+
+func NewForecastHandler(service *service.ForecastService) *ForecastHandler {
+	return &ForecastHandler{service: service}
+}
+
+// parseForecastRequest reads the forecast query parameters.
+func parseForecastRequest(c echo.Context) (service.ForecastRequest, error) {
+	var req service.ForecastRequest
+	for name, target := range map[string]*int{
+		"window_days":    &req.WindowDays,
+		"lead_time_days": &req.LeadTimeDays,
+		"cover_days":     &req.CoverDays,
+	} {
+		if v := c.QueryParam(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return req, echo.NewHTTPError(http.StatusBadRequest, name+" must be a number")
+			}
+			*target = n
+		}
+	}
+	if v := c.QueryParam("alpha"); v != "" {
+		alpha, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return req, echo.NewHTTPError(http.StatusBadRequest, "alpha must be a number")
+		}
+		req.Alpha = &alpha
+	}
+	if v := c.QueryParam("supplier_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return req, echo.NewHTTPError(http.StatusBadRequest, "invalid supplier id")
+		}
+		supplierID := uint(id)
+		req.SupplierID = &supplierID
+	}
+	return req, nil
+}
+
+// GetForecast godoc
+// @Summary Forecast stock-outs
+// @Description Get the daily sales velocity of each product and variant, its days of cover, projected stock-out date and suggested reorder quantity, those running out soonest first. With supplier_id, only the supplier's products are forecast with the supplier's lead times, and purchase_order is a draft that can be posted to /purchase-orders as is
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param window_days query int false "Full days of sales to measure velocity over, 28 by default"
+// @Param alpha query number false "Exponential smoothing factor in (0, 1]; the plain average is used without it"
+// @Param lead_time_days query int false "Days until an order placed today arrives, 7 by default"
+// @Param cover_days query int false "Days of sales a reorder should cover after it arrives, 30 by default"
+// @Param supplier_id query int false "Forecast the products of this supplier"
+// @Success 200 {object} ForecastResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /products/forecast [get]
+func (h *ForecastHandler) GetForecast(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	req, err := parseForecastRequest(c)
+	if err != nil {
+		return err
+	}
+	forecast, err := h.service.Forecast(c.Request().Context(), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	resp := ForecastResponse{
+		WindowDays:    forecast.WindowDays,
+		Lines:         make([]StockForecastResponse, len(forecast.Lines)),
+		PurchaseOrder: forecast.PurchaseOrder,
+	}
+	for i, line := range forecast.Lines {
+		var stockOut *string
+		if line.StockOutDate != nil {
+			date := line.StockOutDate.Format(time.DateOnly)
+			stockOut = &date
+		}
+		resp.Lines[i] = StockForecastResponse{
+			ProductID:         line.ProductID,
+			Code:              line.Code,
+			Name:              line.Name,
+			VariantID:         line.VariantID,
+			VariantCode:       line.VariantCode,
+			Stock:             line.Stock,
+			OnOrder:           line.OnOrder,
+			AverageDailySales: line.AverageDailySales,
+			DailySales:        line.DailySales,
+			DaysOfCover:       line.DaysOfCover,
+			StockOutDate:      stockOut,
+			LeadTimeDays:      line.LeadTimeDays,
+			AtRisk:            line.AtRisk(),
+			SuggestedQuantity: line.SuggestedQuantity,
+		}
+	}
+	return c.JSON(http.StatusOK, resp)
+}
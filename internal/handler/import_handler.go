@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ImportJobResponse struct {
+	ID         uint       `json:"id" example:"1"`
+	Filename   string     `json:"filename" example:"catalog.csv"`
+	Format     string     `json:"format" example:"csv"`
+	DryRun     bool       `json:"dry_run" example:"false"`
+	Status     string     `json:"status" example:"completed"`
+	TotalRows  int        `json:"total_rows" example:"120"`
+	Created    int        `json:"created" example:"100"`
+	Updated    int        `json:"updated" example:"18"`
+	Failed     int        `json:"failed" example:"2"`
+	Error      string     `json:"error,omitempty" example:""`
+	CreatedAt  time.Time  `json:"created_at" example:"2024-01-01T00:00:00Z"`
+	FinishedAt *time.Time `json:"finished_at" example:"2024-01-01T00:00:05Z"`
+}
+
+func toImportJobResponse(job *domain.ImportJob) ImportJobResponse {
+	return ImportJobResponse{
+		ID:         job.ID,
+		Filename:   job.Filename,
+		Format:     job.Format,
+		DryRun:     job.DryRun,
+		Status:     string(job.Status),
+		TotalRows:  job.TotalRows,
+		Created:    job.Created,
+		Updated:    job.Updated,
+		Failed:     job.Failed,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		FinishedAt: job.FinishedAt,
+	}
+}
+
+type ImportHandler struct {
+	service *service.ImportService
+}
+
+func NewImportHandler(service *service.ImportService) *ImportHandler {
+	return &ImportHandler{service: service}
+}
+
+// ImportProducts godoc
+// @Summary Import products
+// @Description Create and update products from a CSV or XLSX file sent as the multipart field "file". Rows are matched to products by code; blank cells keep the current value. Recognised columns are code (or sku), name, description, price, stock, reorder_point and reorder_quantity; mapping assigns other headers to these fields. Large files are imported in the background and return 202 with a pending job.
+// @Tags products
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "CSV or XLSX file"
+// @Param dry_run query bool false "Validate the file without changing products"
+// @Param mapping formData string false "JSON object mapping column headers to product fields, e.g. {\"Artikelnummer\":\"code\"}"
+// @Success 200 {object} ImportJobResponse
+// @Success 202 {object} ImportJobResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Router /products/import [post]
+func (h *ImportHandler) ImportProducts(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	var dryRun bool
+	if v := c.QueryParam("dry_run"); v != "" {
+		dryRun, err = strconv.ParseBool(v)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid dry_run")
+		}
+	}
+
+	// Leave room for the multipart framing around the file.
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, h.service.MaxSize()+64<<10)
+	file, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "import file is too large")
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, "import file is required")
+	}
+	var mapping map[string]string
+	if v := c.FormValue("mapping"); v != "" {
+		if err := json.Unmarshal([]byte(v), &mapping); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "mapping must be a JSON object of column headers to product fields")
+		}
+	}
+	src, err := file.Open()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "import file could not be read")
+	}
+	defer src.Close()
+	body, err := io.ReadAll(src)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "import file could not be read")
+	}
+
+	job, err := h.service.Import(req.Context(), userID, service.ImportRequest{
+		Filename: file.Filename,
+		Body:     body,
+		DryRun:   dryRun,
+		Mapping:  mapping,
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	status := http.StatusOK
+	if job.Status == domain.ImportJobPending {
+		status = http.StatusAccepted
+	}
+	return c.JSON(status, toImportJobResponse(job))
+}
+
+// GetImportJob godoc
+// @Summary Get an import job
+// @Description Get the status and row counts of a product import
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Import job ID"
+// @Success 200 {object} ImportJobResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /products/import/{id} [get]
+func (h *ImportHandler) GetImportJob(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid import job id")
+	}
+	job, err := h.service.GetJob(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.JSON(http.StatusOK, toImportJobResponse(job))
+}
+
+// GetImportJobErrors godoc
+// @Summary Download the error report of an import
+// @Description Download the rejected rows of a product import as CSV with the columns row, code and error. Row numbers count the header as row 1.
+// @Tags products
+// @Produce text/csv
+// @Security BearerAuth
+// @Param id path int true "Import job ID"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /products/import/{id}/errors [get]
+func (h *ImportHandler) GetImportJobErrors(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid import job id")
+	}
+	rowErrors, err := h.service.GetJobErrors(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"import-%d-errors.csv\"", id))
+	res.WriteHeader(http.StatusOK)
+	w := csv.NewWriter(res)
+	w.Write([]string{"row", "code", "error"})
+	for _, rowError := range rowErrors {
+		w.Write([]string{strconv.Itoa(rowError.Row), rowError.Code, rowError.Message})
+	}
+	w.Flush()
+	return w.Error()
+}
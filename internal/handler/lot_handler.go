@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type LotResponse struct {
+	ID          uint       `json:"id" example:"3"`
+	ProductID   uint       `json:"product_id" example:"1"`
+	ProductCode string     `json:"product_code" example:"MILK-1L"`
+	ProductName string     `json:"product_name" example:"Milk 1L"`
+	VariantID   *uint      `json:"variant_id,omitempty" example:"4"`
+	VariantCode string     `json:"variant_code,omitempty" example:"MILK-1L-SKIM"`
+	Number      string     `json:"number" example:"L2024-031"`
+	ExpiresAt   *time.Time `json:"expires_at" example:"2024-06-30T00:00:00Z"`
+	Expired     bool       `json:"expired" example:"false"`
+	Received    int        `json:"received" example:"24"`
+	Quantity    int        `json:"quantity" example:"18"`
+	CreatedAt   time.Time  `json:"created_at" example:"2024-06-01T08:00:00Z"`
+}
+
+type batchManagedRequest struct {
+	BatchManaged bool `json:"batch_managed" example:"true"`
+}
+
+type writeOffLotRequest struct {
+	Quantity *int `json:"quantity,omitempty" example:"6"`
+}
+
+func toLotResponse(lot *domain.Lot, now time.Time) LotResponse {
+	resp := LotResponse{
+		ID:          lot.ID,
+		ProductID:   lot.ProductID,
+		ProductCode: lot.Product.Code,
+		ProductName: lot.Product.Name,
+		VariantID:   lot.VariantID,
+		Number:      lot.Number,
+		ExpiresAt:   lot.ExpiresAt,
+		Expired:     lot.Expired(now),
+		Received:    lot.Received,
+		Quantity:    lot.Quantity,
+		CreatedAt:   lot.CreatedAt,
+	}
+	if lot.Variant != nil {
+		resp.VariantCode = lot.Variant.Code
+	}
+	return resp
+}
+
+func toLotResponses(lots []*domain.Lot) []LotResponse {
+	now := time.Now()
+	resp := make([]LotResponse, len(lots))
+	for i, lot := range lots {
+		resp[i] = toLotResponse(lot, now)
+	}
+	return resp
+}
+
+type LotHandler struct {
+	service *service.LotService
+}
+
+func NewLotHandler(service *service.LotService) *LotHandler {
+	return &LotHandler{service: service}
+}
+
+// SetBatchManaged godoc
+// @Summary Turn lot tracking of a product on or off
+// @Description Orders for a batch-managed product are allocated from its lots, first expired first out. Lot tracking can only be turned on while the product has no stock.
+// @Tags lots
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param settings body batchManagedRequest true "Lot tracking"
+// @Success 200 {object} ProductResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /products/{id}/batch-managed [patch]
+func (h *LotHandler) SetBatchManaged(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	var body batchManagedRequest
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	product, err := h.service.SetBatchManaged(c.Request().Context(), uint(id), userID, body.BatchManaged)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toProductResponse(product))
+}
+
+// GetProductLots godoc
+// @Summary List the lots of a product
+// @Description Get the lots of a product, the first to expire first
+// @Tags lots
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Success 200 {array} LotResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /products/{id}/lots [get]
+func (h *LotHandler) GetProductLots(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	lots, err := h.service.GetProductLots(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.JSON(http.StatusOK, toLotResponses(lots))
+}
+
+// ReceiveLot godoc
+// @Summary Receive stock into a lot
+// @Description Add stock to a batch-managed product and book it into a lot. Receiving into an existing lot number adds to that lot.
+// @Tags lots
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param lot body service.ReceiveLotRequest true "Received stock"
+// @Success 201 {object} LotResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /lots [post]
+func (h *LotHandler) ReceiveLot(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	var req service.ReceiveLotRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	lot, err := h.service.ReceiveLot(c.Request().Context(), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, toLotResponse(lot, time.Now()))
+}
+
+// GetExpiringLots godoc
+// @Summary List lots expiring soon
+// @Description Get the lots with stock left that expire within the given number of days, including those that have already expired, the first to expire first
+// @Tags lots
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "Days ahead to look (default 30, at most 365)"
+// @Success 200 {array} LotResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /lots/expiring [get]
+func (h *LotHandler) GetExpiringLots(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	days := service.DefaultExpiringDays
+	if v := c.QueryParam("days"); v != "" {
+		if days, err = strconv.Atoi(v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid days")
+		}
+	}
+	lots, err := h.service.ExpiringLots(c.Request().Context(), userID, days)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toLotResponses(lots))
+}
+
+// GetLot godoc
+// @Summary Get a lot
+// @Description Get a lot by ID
+// @Tags lots
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Lot ID"
+// @Success 200 {object} LotResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /lots/{id} [get]
+func (h *LotHandler) GetLot(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid lot id")
+	}
+	lot, err := h.service.GetLot(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.JSON(http.StatusOK, toLotResponse(lot, time.Now()))
+}
+
+// WriteOffLot godoc
+// @Summary Write off stock of a lot
+// @Description Remove stock of an expired or damaged lot from the lot and from stock. Without a quantity, everything left in the lot is written off.
+// @Tags lots
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Lot ID"
+// @Param write_off body writeOffLotRequest false "Quantity to write off"
+// @Success 200 {object} LotResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /lots/{id}/write-off [post]
+func (h *LotHandler) WriteOffLot(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid lot id")
+	}
+	var body writeOffLotRequest
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	lot, err := h.service.WriteOffLot(c.Request().Context(), uint(id), userID, body.Quantity)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toLotResponse(lot, time.Now()))
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type NotificationResponse struct {
+	ID        uint       `json:"id" example:"1"`
+	Type      string     `json:"type" example:"low_stock"`
+	Title     string     `json:"title" example:"Low stock: Laptop (PROD001)"`
+	Message   string     `json:"message" example:"Laptop (PROD001) has 2 units left, at or below its reorder point of 5."`
+	ProductID *uint      `json:"product_id" example:"1"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at" example:"2024-01-15T10:30:00Z"`
+}
+
+type MarkAllReadResponse struct {
+	Updated int64 `json:"updated" example:"3"`
+}
+
+func toNotificationResponse(notification *domain.Notification) NotificationResponse {
+	return NotificationResponse{
+		ID:        notification.ID,
+		Type:      string(notification.Type),
+		Title:     notification.Title,
+		Message:   notification.Message,
+		ProductID: notification.ProductID,
+		ReadAt:    notification.ReadAt,
+		CreatedAt: notification.CreatedAt,
+	}
+}
+
+type NotificationHandler struct {
+	service *service.NotificationService
+}
+
+func NewNotificationHandler(service *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+// ListNotifications godoc
+// @Summary List notifications
+// @Description Get the notification feed of the authenticated user, newest first
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param unread query bool false "Only unread notifications"
+// @Param limit query int false "Maximum number of notifications (default 50, max 200)"
+// @Success 200 {array} NotificationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /notifications [get]
+func (h *NotificationHandler) ListNotifications(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	var unreadOnly bool
+	if v := c.QueryParam("unread"); v != "" {
+		unreadOnly, err = strconv.ParseBool(v)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid unread filter")
+		}
+	}
+	var limit int
+	if v := c.QueryParam("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid limit")
+		}
+	}
+	notifications, err := h.service.GetNotifications(c.Request().Context(), userID, unreadOnly, limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	resp := make([]NotificationResponse, len(notifications))
+	for i, notification := range notifications {
+		resp[i] = toNotificationResponse(notification)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// MarkNotificationRead godoc
+// @Summary Mark a notification as read
+// @Description Mark a notification of the authenticated user as read
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Notification ID"
+// @Success 200 {object} NotificationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /notifications/{id}/read [post]
+func (h *NotificationHandler) MarkNotificationRead(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid notification id")
+	}
+	notification, err := h.service.MarkRead(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.JSON(http.StatusOK, toNotificationResponse(notification))
+}
+
+// MarkAllNotificationsRead godoc
+// @Summary Mark all notifications as read
+// @Description Mark every unread notification of the authenticated user as read
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} MarkAllReadResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /notifications/read-all [post]
+func (h *NotificationHandler) MarkAllNotificationsRead(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	updated, err := h.service.MarkAllRead(c.Request().Context(), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, MarkAllReadResponse{Updated: updated})
+}
@@ -20,21 +20,47 @@ type ProductSummary struct {
 }
 
 type OrderItemResponse struct {
-	ID        uint           `json:"id" example:"1"`
-	ProductID uint           `json:"product_id" example:"1"`
-	Product   ProductSummary `json:"product"`
-	Quantity  int            `json:"quantity" example:"2"`
-	UnitPrice float64        `json:"unit_price" example:"1299.99"`
-	Subtotal  float64        `json:"subtotal" example:"2599.98"`
+	ID            uint                         `json:"id" example:"1"`
+	ProductID     uint                         `json:"product_id" example:"1"`
+	Product       ProductSummary               `json:"product"`
+	VariantID     *uint                        `json:"variant_id,omitempty" example:"3"`
+	VariantCode   string                       `json:"variant_code,omitempty" example:"PROD001-RED-M"`
+	Quantity      int                          `json:"quantity" example:"2"`
+	UnitPrice     float64                      `json:"unit_price" example:"1299.99"`
+	Subtotal      float64                      `json:"subtotal" example:"2599.98"`
+	UnitCost      float64                      `json:"unit_cost" example:"950.00"`
+	PriceListID   *uint                        `json:"price_list_id,omitempty" example:"2"`
+	PriceListName string                       `json:"price_list_name,omitempty" example:"Wholesale 2024"`
+	Lots          []OrderItemLotResponse       `json:"lots,omitempty"`
+	Serials       []string                     `json:"serials,omitempty" example:"SN-0001,SN-0002"`
+	Components    []OrderItemComponentResponse `json:"components,omitempty"`
+}
+
+type OrderItemComponentResponse struct {
+	ProductID   uint   `json:"product_id" example:"2"`
+	ProductCode string `json:"product_code" example:"MOUSE-01"`
+	ProductName string `json:"product_name" example:"Wireless Mouse"`
+	VariantID   *uint  `json:"variant_id,omitempty" example:"4"`
+	VariantCode string `json:"variant_code,omitempty" example:"MOUSE-01-BLACK"`
+	Quantity    int    `json:"quantity" example:"2"`
+}
+
+type OrderItemLotResponse struct {
+	LotID     uint       `json:"lot_id" example:"3"`
+	Number    string     `json:"number" example:"L2024-031"`
+	ExpiresAt *time.Time `json:"expires_at" example:"2024-06-30T00:00:00Z"`
+	Quantity  int        `json:"quantity" example:"2"`
 }
 
 type OrderResponse struct {
-	ID          uint                `json:"id" example:"1"`
-	Status      string              `json:"status" example:"pending"`
-	TotalAmount float64             `json:"total_amount" example:"2599.98"`
-	Items       []OrderItemResponse `json:"items"`
-	CreatedAt   time.Time           `json:"created_at" example:"2024-01-15T10:30:00Z"`
-	UpdatedAt   time.Time           `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+	ID           uint                `json:"id" example:"1"`
+	Status       string              `json:"status" example:"pending"`
+	TotalAmount  float64             `json:"total_amount" example:"2599.98"`
+	CustomerID   *uint               `json:"customer_id,omitempty" example:"4"`
+	CustomerName string              `json:"customer_name,omitempty" example:"Acme Retail"`
+	Items        []OrderItemResponse `json:"items"`
+	CreatedAt    time.Time           `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt    time.Time           `json:"updated_at" example:"2024-01-15T10:30:00Z"`
 }
 
 type updateOrderStatusRequest struct {
@@ -55,13 +81,55 @@ func toOrderItemResponse(item domain.OrderItem) OrderItemResponse {
 	if item.Product.ID != 0 {
 		prodSummary = toProductSummary(item.Product)
 	}
+	var variantCode string
+	if item.Variant != nil {
+		variantCode = item.Variant.Code
+	}
+	var lots []OrderItemLotResponse
+	for _, allocation := range item.Lots {
+		lot := OrderItemLotResponse{LotID: allocation.LotID, Quantity: allocation.Quantity}
+		if allocation.Lot != nil {
+			lot.Number, lot.ExpiresAt = allocation.Lot.Number, allocation.Lot.ExpiresAt
+		}
+		lots = append(lots, lot)
+	}
+	var serials []string
+	for _, serial := range item.Serials {
+		serials = append(serials, serial.Number)
+	}
+	var components []OrderItemComponentResponse
+	for _, taken := range item.Components {
+		component := OrderItemComponentResponse{
+			ProductID:   taken.ProductID,
+			ProductCode: taken.Product.Code,
+			ProductName: taken.Product.Name,
+			VariantID:   taken.VariantID,
+			Quantity:    taken.Quantity,
+		}
+		if taken.Variant != nil {
+			component.VariantCode = taken.Variant.Code
+		}
+		components = append(components, component)
+	}
+	var priceListName string
+	if item.PriceList != nil {
+		priceListName = item.PriceList.Name
+	}
 	return OrderItemResponse{
-		ID:        item.ID,
-		ProductID: item.ProductID,
-		Product:   prodSummary,
-		Quantity:  item.Quantity,
-		UnitPrice: item.UnitPrice,
-		Subtotal:  item.Subtotal,
+		ID:            item.ID,
+		ProductID:     item.ProductID,
+		Product:       prodSummary,
+		VariantID:     item.VariantID,
+		VariantCode:   variantCode,
+		Quantity:      item.Quantity,
+		UnitPrice:     item.UnitPrice,
+		Subtotal:      item.Subtotal,
+		UnitCost:      item.UnitCost,
+		PriceListID:   item.PriceListID,
+		PriceListName: priceListName,
+		Lots:          lots,
+		Serials:       serials,
+		Components:    components,
 	}
 }
 
@@ -70,16 +138,51 @@ func toOrderResponse(order *domain.Order) OrderResponse {
 	for i, item := range order.Items {
 		items[i] = toOrderItemResponse(item)
 	}
+	var customerName string
+	if order.Customer != nil {
+		customerName = order.Customer.Name
+	}
 	return OrderResponse{
-		ID:          order.ID,
-		Status:      string(order.Status),
-		TotalAmount: order.TotalAmount,
-		Items:       items,
-		CreatedAt:   order.CreatedAt,
-		UpdatedAt:   order.UpdatedAt,
+		ID:           order.ID,
+		Status:       string(order.Status),
+		TotalAmount:  order.TotalAmount,
+		CustomerID:   order.CustomerID,
+		CustomerName: customerName,
+		Items:        items,
+		CreatedAt:    order.CreatedAt,
+		UpdatedAt:    order.UpdatedAt,
 	}
 }
 
+// parseOrderFilter reads the status, from and to query parameters.
+func parseOrderFilter(c echo.Context) (domain.OrderFilter, error) {
+	filter := domain.OrderFilter{Status: domain.OrderStatus(c.QueryParam("status"))}
+	from, to, err := parseDateRange(c, time.UTC)
+	filter.From, filter.To = from, to
+	return filter, err
+}
+
+// parseDateRange reads the from and to query parameters as days in loc. Both
+// days are included, so the range ends at the start of the day after to.
+func parseDateRange(c echo.Context, loc *time.Location) (from, to *time.Time, err error) {
+	if v := c.QueryParam("from"); v != "" {
+		day, err := time.ParseInLocation(time.DateOnly, v, loc)
+		if err != nil {
+			return nil, nil, echo.NewHTTPError(http.StatusBadRequest, "from must be a date as YYYY-MM-DD")
+		}
+		from = &day
+	}
+	if v := c.QueryParam("to"); v != "" {
+		day, err := time.ParseInLocation(time.DateOnly, v, loc)
+		if err != nil {
+			return nil, nil, echo.NewHTTPError(http.StatusBadRequest, "to must be a date as YYYY-MM-DD")
+		}
+		day = day.AddDate(0, 0, 1)
+		to = &day
+	}
+	return from, to, nil
+}
+
 type OrderHandler struct {
 	service *service.OrderService
 }
@@ -118,23 +221,30 @@ func (h *OrderHandler) CreateOrder(c echo.Context) error {
 
 // ListOrders godoc
 // @Summary List orders of the authenticated user
-// @Description Get all orders of the authenticated user
+// @Description Get the orders of the authenticated user, newest first, optionally only those with a status or created within a date range
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param status query string false "Order status"
+// @Param from query string false "First day, as YYYY-MM-DD in UTC"
+// @Param to query string false "Last day, as YYYY-MM-DD in UTC"
 // @Success 200 {array} OrderResponse
+// @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
 // @Router /orders [get]
 func (h *OrderHandler) ListOrders(c echo.Context) error {
 	userID, err := pkg.GetUserIDFromJWTContext(c)
 	if err != nil {
 		return err
 	}
-	orders, err := h.service.GetOrdersByUser(c.Request().Context(), userID)
+	filter, err := parseOrderFilter(c)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
+	}
+	orders, err := h.service.ListOrders(c.Request().Context(), userID, filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 	resp := make([]OrderResponse, len(orders))
 	for i, order := range orders {
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type PriceChangeResponse struct {
+	ID        uint      `json:"id" example:"8"`
+	OldPrice  float64   `json:"old_price" example:"1299.99"`
+	NewPrice  float64   `json:"new_price" example:"1199.99"`
+	Source    string    `json:"source" example:"manual"`
+	ChangedBy *uint     `json:"changed_by" example:"1"`
+	ChangedAt time.Time `json:"changed_at" example:"2024-01-15T10:30:00Z"`
+}
+
+type ScheduledPriceChangeResponse struct {
+	ID          uint       `json:"id" example:"3"`
+	ProductID   uint       `json:"product_id" example:"1"`
+	Price       float64    `json:"price" example:"1199.99"`
+	EffectiveAt time.Time  `json:"effective_at" example:"2026-11-01T00:00:00Z"`
+	Status      string     `json:"status" example:"pending"`
+	CreatedBy   uint       `json:"created_by" example:"1"`
+	AppliedAt   *time.Time `json:"applied_at" example:"2026-11-01T00:00:04Z"`
+	CreatedAt   time.Time  `json:"created_at" example:"2026-10-15T09:00:00Z"`
+}
+
+func toScheduledPriceChangeResponse(change *domain.ScheduledPriceChange) ScheduledPriceChangeResponse {
+	return ScheduledPriceChangeResponse{
+		ID:          change.ID,
+		ProductID:   change.ProductID,
+		Price:       change.Price,
+		EffectiveAt: change.EffectiveAt,
+		Status:      string(change.Status),
+		CreatedBy:   change.CreatedBy,
+		AppliedAt:   change.AppliedAt,
+		CreatedAt:   change.CreatedAt,
+	}
+}
+
+type PriceChangeHandler struct {
+	service *service.PriceChangeService
+}
+
+func NewPriceChangeHandler(service *service.PriceChangeService) *PriceChangeHandler {
+	return &PriceChangeHandler{service: service}
+}
+
+// GetPriceHistory godoc
+// @Summary Get the price history of a product
+// @Description Get every change of the price of a product, newest first, with the old and new price, who made it, and whether it was manual, imported or scheduled
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Success 200 {array} PriceChangeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /products/{id}/price-history [get]
+func (h *PriceChangeHandler) GetPriceHistory(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	changes, err := h.service.GetPriceHistory(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	resp := make([]PriceChangeResponse, len(changes))
+	for i, change := range changes {
+		resp[i] = PriceChangeResponse{
+			ID:        change.ID,
+			OldPrice:  change.OldPrice,
+			NewPrice:  change.NewPrice,
+			Source:    change.Source,
+			ChangedBy: change.ChangedBy,
+			ChangedAt: change.ChangedAt,
+		}
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// SchedulePriceChange godoc
+// @Summary Schedule a price change
+// @Description Schedule a new price for a product, applied in the background once effective_at has passed
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param change body service.SchedulePriceRequest true "New price and when it takes effect"
+// @Success 201 {object} ScheduledPriceChangeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /products/{id}/scheduled-prices [post]
+func (h *PriceChangeHandler) SchedulePriceChange(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	var req service.SchedulePriceRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	change, err := h.service.SchedulePriceChange(c.Request().Context(), uint(id), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, toScheduledPriceChangeResponse(change))
+}
+
+// ListScheduledPriceChanges godoc
+// @Summary List the scheduled price changes of a product
+// @Description Get the scheduled price changes of a product in the order they take effect
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param status query string false "Filter by status" Enums(pending, applied, cancelled)
+// @Success 200 {array} ScheduledPriceChangeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /products/{id}/scheduled-prices [get]
+func (h *PriceChangeHandler) ListScheduledPriceChanges(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	status := domain.ScheduledPriceStatus(c.QueryParam("status"))
+	changes, err := h.service.GetScheduledChanges(c.Request().Context(), uint(id), userID, status)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	resp := make([]ScheduledPriceChangeResponse, len(changes))
+	for i, change := range changes {
+		resp[i] = toScheduledPriceChangeResponse(change)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// CancelScheduledPriceChange godoc
+// @Summary Cancel a scheduled price change
+// @Description Cancel a scheduled price change that has not been applied yet
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param changeId path int true "Scheduled price change ID"
+// @Success 200 {object} ScheduledPriceChangeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /products/{id}/scheduled-prices/{changeId}/cancel [post]
+func (h *PriceChangeHandler) CancelScheduledPriceChange(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	changeID, err := strconv.ParseUint(c.Param("changeId"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid scheduled price change id")
+	}
+	change, err := h.service.CancelScheduledChange(c.Request().Context(), uint(id), uint(changeID), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toScheduledPriceChangeResponse(change))
+}
@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type PriceListResponse struct {
+	ID         uint                     `json:"id" example:"1"`
+	Name       string                   `json:"name" example:"Wholesale 2024"`
+	ValidFrom  *time.Time               `json:"valid_from" example:"2024-01-01T00:00:00Z"`
+	ValidUntil *time.Time               `json:"valid_until" example:"2025-01-01T00:00:00Z"`
+	Active     bool                     `json:"active" example:"true"`
+	Prices     []PriceListPriceResponse `json:"prices"`
+	Customers  []PriceListMemberSummary `json:"customers"`
+	Groups     []PriceListMemberSummary `json:"groups"`
+	CreatedAt  time.Time                `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt  time.Time                `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+}
+
+type PriceListPriceResponse struct {
+	ProductID       uint     `json:"product_id" example:"1"`
+	VariantID       *uint    `json:"variant_id,omitempty" example:"3"`
+	MinQuantity     int      `json:"min_quantity" example:"10"`
+	Price           *float64 `json:"price,omitempty" example:"1199.99"`
+	DiscountPercent *float64 `json:"discount_percent,omitempty" example:"5"`
+}
+
+// PriceListMemberSummary is a customer or customer group a price list is
+// assigned to.
+type PriceListMemberSummary struct {
+	ID   uint   `json:"id" example:"1"`
+	Name string `json:"name" example:"Wholesale"`
+}
+
+func toPriceListResponse(list *domain.PriceList, now time.Time) PriceListResponse {
+	resp := PriceListResponse{
+		ID:         list.ID,
+		Name:       list.Name,
+		ValidFrom:  list.ValidFrom,
+		ValidUntil: list.ValidUntil,
+		Active:     list.ValidAt(now),
+		Prices:     make([]PriceListPriceResponse, len(list.Prices)),
+		Customers:  make([]PriceListMemberSummary, len(list.Customers)),
+		Groups:     make([]PriceListMemberSummary, len(list.Groups)),
+		CreatedAt:  list.CreatedAt,
+		UpdatedAt:  list.UpdatedAt,
+	}
+	for i, price := range list.Prices {
+		resp.Prices[i] = PriceListPriceResponse{
+			ProductID:       price.ProductID,
+			VariantID:       price.VariantID,
+			MinQuantity:     price.MinQuantity,
+			Price:           price.Price,
+			DiscountPercent: price.DiscountPercent,
+		}
+	}
+	for i, customer := range list.Customers {
+		resp.Customers[i] = PriceListMemberSummary{ID: customer.ID, Name: customer.Name}
+	}
+	for i, group := range list.Groups {
+		resp.Groups[i] = PriceListMemberSummary{ID: group.ID, Name: group.Name}
+	}
+	return resp
+}
+
+type PriceListHandler struct {
+	service *service.PriceListService
+}
+
+func NewPriceListHandler(service *service.PriceListService) *PriceListHandler {
+	return &PriceListHandler{service: service}
+}
+
+// CreatePriceList godoc
+// @Summary Create a price list
+// @Description Create a price list with its prices and the customers and customer groups it applies to. A list assigned to no one applies to every order. Orders get the lowest price of the lists that apply to them.
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param list body service.PriceListRequest true "Price list"
+// @Success 201 {object} PriceListResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /price-lists [post]
+func (h *PriceListHandler) CreatePriceList(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	var req service.PriceListRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	list, err := h.service.CreatePriceList(c.Request().Context(), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, toPriceListResponse(list, time.Now()))
+}
+
+// ListPriceLists godoc
+// @Summary List price lists
+// @Description Get all price lists of the authenticated user
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} PriceListResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /price-lists [get]
+func (h *PriceListHandler) ListPriceLists(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	lists, err := h.service.GetPriceListsByUser(c.Request().Context(), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	now := time.Now()
+	resp := make([]PriceListResponse, len(lists))
+	for i, list := range lists {
+		resp[i] = toPriceListResponse(list, now)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetPriceList godoc
+// @Summary Get a price list
+// @Description Get a price list with its prices and assignments
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Price list ID"
+// @Success 200 {object} PriceListResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /price-lists/{id} [get]
+func (h *PriceListHandler) GetPriceList(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid price list id")
+	}
+	list, err := h.service.GetPriceList(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.JSON(http.StatusOK, toPriceListResponse(list, time.Now()))
+}
+
+// UpdatePriceList godoc
+// @Summary Replace a price list
+// @Description Replace a price list with its prices and assignments. Orders already placed keep their prices.
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Price list ID"
+// @Param list body service.PriceListRequest true "Price list"
+// @Success 200 {object} PriceListResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /price-lists/{id} [put]
+func (h *PriceListHandler) UpdatePriceList(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid price list id")
+	}
+	var req service.PriceListRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	list, err := h.service.UpdatePriceList(c.Request().Context(), uint(id), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toPriceListResponse(list, time.Now()))
+}
+
+// DeletePriceList godoc
+// @Summary Delete a price list
+// @Description Delete a price list. Order items priced from it keep their prices.
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Price list ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /price-lists/{id} [delete]
+func (h *PriceListHandler) DeletePriceList(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid price list id")
+	}
+	if err := h.service.DeletePriceList(c.Request().Context(), uint(id), userID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
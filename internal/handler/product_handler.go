@@ -24,6 +24,8 @@ type createProductRequest struct {
 	Name        string  `json:"name" example:"Laptop Gaming"`
 	Description string  `json:"description" example:"Laptop para gaming de alta performance"`
 	Price       float64 `json:"price" example:"1299.99"`
+	Cost        float64 `json:"cost" example:"950.00"`
+	CostMethod  string  `json:"cost_method" example:"moving_average"`
 	Stock       int     `json:"stock" example:"10"`
 }
 
@@ -39,23 +41,72 @@ type updateStockRequest struct {
 	StockDelta int `json:"stockDelta" example:"5"`
 }
 
+type bulkStockRequest struct {
+	Entries []service.BulkStockEntry `json:"entries"`
+}
+
+type BulkStockResultResponse struct {
+	ProductID    uint   `json:"product_id" example:"1"`
+	Code         string `json:"code" example:"PROD001"`
+	VariantID    *uint  `json:"variant_id,omitempty" example:"3"`
+	VariantCode  string `json:"variant_code,omitempty" example:"PROD001-RED-M"`
+	Previous     int    `json:"previous" example:"12"`
+	Stock        int    `json:"stock" example:"10"`
+	ProductStock int    `json:"product_stock" example:"25"`
+}
+
+type updateReorderRequest struct {
+	ReorderPoint    *int `json:"reorder_point,omitempty" example:"5"`
+	ReorderQuantity *int `json:"reorder_quantity,omitempty" example:"20"`
+}
+
 type ProductResponse struct {
-	ID          uint    `json:"id" example:"1"`
-	Code        string  `json:"code" example:"PROD001"`
-	Name        string  `json:"name" example:"Laptop"`
-	Description string  `json:"description" example:"Laptop para gaming"`
-	Price       float64 `json:"price" example:"1299.99"`
-	Stock       int     `json:"stock" example:"10"`
+	ID              uint                      `json:"id" example:"1"`
+	Code            string                    `json:"code" example:"PROD001"`
+	Name            string                    `json:"name" example:"Laptop"`
+	Description     string                    `json:"description" example:"Laptop para gaming"`
+	Price           float64                   `json:"price" example:"1299.99"`
+	Cost            float64                   `json:"cost" example:"950.00"`
+	CostMethod      string                    `json:"cost_method" example:"moving_average"`
+	Stock           int                       `json:"stock" example:"10"`
+	ReorderPoint    int                       `json:"reorder_point" example:"5"`
+	ReorderQuantity int                       `json:"reorder_quantity" example:"20"`
+	LowStock        bool                      `json:"low_stock" example:"false"`
+	BatchManaged    bool                      `json:"batch_managed" example:"false"`
+	Serialized      bool                      `json:"serialized" example:"false"`
+	IsBundle        bool                      `json:"is_bundle" example:"false"`
+	Components      []BundleComponentResponse `json:"components,omitempty"`
+	Variants        []VariantResponse         `json:"variants,omitempty"`
+	Categories      []CategorySummary         `json:"categories"`
+	Tags            []string                  `json:"tags"`
+	Images          []ProductImageResponse    `json:"images"`
 }
 
 func toProductResponse(p *domain.Product) ProductResponse {
+	stock := p.Stock
+	if p.IsBundle() {
+		stock = p.BundleStock()
+	}
 	return ProductResponse{
-		ID:          p.ID,
-		Code:        p.Code,
-		Name:        p.Name,
-		Description: p.Description,
-		Price:       p.Price,
-		Stock:       p.Stock,
+		ID:              p.ID,
+		Code:            p.Code,
+		Name:            p.Name,
+		Description:     p.Description,
+		Price:           p.Price,
+		Cost:            p.UnitCost(),
+		CostMethod:      string(p.CostMethod),
+		Stock:           stock,
+		ReorderPoint:    p.ReorderPoint,
+		ReorderQuantity: p.ReorderQuantity,
+		LowStock:        p.LowStock(),
+		BatchManaged:    p.BatchManaged,
+		Serialized:      p.Serialized,
+		IsBundle:        p.IsBundle(),
+		Components:      toBundleComponentResponses(p),
+		Variants:        toVariantResponses(p),
+		Categories:      toCategorySummaries(p.Categories),
+		Tags:            tagNames(p.Tags),
+		Images:          toProductImageResponses(p.Images),
 	}
 }
 
@@ -80,7 +131,7 @@ func (h *ProductHandler) CreateProduct(c echo.Context) error {
 	if err := c.Bind(&body); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
 	}
-	product, err := h.service.CreateProduct(c.Request().Context(), userID, body.Code, body.Name, body.Description, body.Price, body.Stock)
+	product, err := h.service.CreateProduct(c.Request().Context(), userID, body.Code, body.Name, body.Description, body.Price, body.Cost, domain.CostMethod(body.CostMethod), body.Stock)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
@@ -89,21 +140,51 @@ func (h *ProductHandler) CreateProduct(c echo.Context) error {
 
 // ListProducts godoc
 // @Summary List products of the authenticated user
-// @Description Get all products of the authenticated user
+// @Description Get the products of the authenticated user, optionally only those in a category (including its subcategories) or with a tag
 // @Tags products
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param category query string false "Category ID or slug"
+// @Param tag query string false "Tag name"
 // @Success 200 {array} ProductResponse
+// @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
 // @Router /products [get]
 func (h *ProductHandler) ListProducts(c echo.Context) error {
 	userID, err := pkg.GetUserIDFromJWTContext(c)
 	if err != nil {
 		return err
 	}
-	products, err := h.service.GetProductsByUser(c.Request().Context(), userID)
+	filter := service.ProductListFilter{Category: c.QueryParam("category"), Tag: c.QueryParam("tag")}
+	products, err := h.service.ListProducts(c.Request().Context(), userID, filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	responses := make([]ProductResponse, len(products))
+	for i, p := range products {
+		responses[i] = toProductResponse(p)
+	}
+	return c.JSON(http.StatusOK, responses)
+}
+
+// ListLowStockProducts godoc
+// @Summary List products that need restocking
+// @Description Get the products of the authenticated user whose stock is at or below their reorder point, the furthest below first
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} ProductResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/low-stock [get]
+func (h *ProductHandler) ListLowStockProducts(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	products, err := h.service.GetLowStockProducts(c.Request().Context(), userID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
@@ -214,6 +295,81 @@ func (h *ProductHandler) UpdateProductStock(c echo.Context) error {
 	return c.JSON(http.StatusOK, toProductResponse(product))
 }
 
+// BulkUpdateStock godoc
+// @Summary Adjust the stock of many products
+// @Description Apply a list of stock adjustments in one transaction. Each entry names a product by product_id or code, and a variant_id for products with variants, and changes its stock by delta or to set_to. Either every entry is applied or, if any is invalid, none is and the errors of all invalid entries are returned.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param adjustments body bulkStockRequest true "Stock adjustments"
+// @Success 200 {array} BulkStockResultResponse
+// @Failure 400 {object} EntryErrorsResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /products/stock/bulk [post]
+func (h *ProductHandler) BulkUpdateStock(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	var body bulkStockRequest
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	results, err := h.service.BulkUpdateStock(c.Request().Context(), userID, body.Entries)
+	if err != nil {
+		return entryErrorsResponse(err)
+	}
+	resp := make([]BulkStockResultResponse, len(results))
+	for i, result := range results {
+		resp[i] = BulkStockResultResponse{
+			ProductID:    result.ProductID,
+			Code:         result.Code,
+			VariantID:    result.VariantID,
+			VariantCode:  result.VariantCode,
+			Previous:     result.Previous,
+			Stock:        result.Stock,
+			ProductStock: result.ProductStock,
+		}
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// UpdateReorderSettings godoc
+// @Summary Update the reorder settings of a product
+// @Description Set the stock level at which a low-stock alert is raised and the quantity to reorder
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param reorder body updateReorderRequest true "Reorder settings"
+// @Success 200 {object} ProductResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /products/{id}/reorder [patch]
+func (h *ProductHandler) UpdateReorderSettings(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	var body updateReorderRequest
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	product, err := h.service.UpdateReorderSettings(c.Request().Context(), uint(id), userID, body.ReorderPoint, body.ReorderQuantity)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toProductResponse(product))
+}
+
 // DeleteProduct godoc
 // @Summary Delete a product
 // @Description Delete a product of the authenticated user
@@ -0,0 +1,304 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/internal/storage"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ImageURLSigner signs the URLs of product images in responses.
+type ImageURLSigner interface {
+	ImageURLs(image *domain.ProductImage) (string, string, error)
+}
+
+var imageURLSigner ImageURLSigner
+
+// UseImageURLSigner sets how image URLs in product responses are signed.
+// Without one, responses list images without URLs.
+func UseImageURLSigner(signer ImageURLSigner) {
+	imageURLSigner = signer
+}
+
+type ProductImageResponse struct {
+	ID           uint   `json:"id" example:"1"`
+	URL          string `json:"url" example:"https://cdn.example.com/products/1/1/ab12.jpg?signature=..."`
+	ThumbnailURL string `json:"thumbnail_url" example:"https://cdn.example.com/products/1/1/ab12-thumb.jpg?signature=..."`
+	Filename     string `json:"filename" example:"front.jpg"`
+	ContentType  string `json:"content_type" example:"image/jpeg"`
+	Size         int64  `json:"size" example:"204800"`
+	Width        int    `json:"width" example:"1200"`
+	Height       int    `json:"height" example:"800"`
+	Position     int    `json:"position" example:"0"`
+	Primary      bool   `json:"primary" example:"true"`
+}
+
+type reorderImagesRequest struct {
+	ImageIDs []uint `json:"image_ids" example:"3,1,2"`
+}
+
+func toProductImageResponse(image *domain.ProductImage) ProductImageResponse {
+	resp := ProductImageResponse{
+		ID:          image.ID,
+		Filename:    image.Filename,
+		ContentType: image.ContentType,
+		Size:        image.Size,
+		Width:       image.Width,
+		Height:      image.Height,
+		Position:    image.Position,
+		Primary:     image.Primary,
+	}
+	if imageURLSigner != nil {
+		// A URL that cannot be signed is left out rather than failing the response.
+		resp.URL, resp.ThumbnailURL, _ = imageURLSigner.ImageURLs(image)
+	}
+	return resp
+}
+
+func toProductImageResponses(images []domain.ProductImage) []ProductImageResponse {
+	resp := make([]ProductImageResponse, len(images))
+	for i := range images {
+		resp[i] = toProductImageResponse(&images[i])
+	}
+	return resp
+}
+
+type ProductImageHandler struct {
+	service *service.ProductImageService
+}
+
+func NewProductImageHandler(service *service.ProductImageService) *ProductImageHandler {
+	return &ProductImageHandler{service: service}
+}
+
+func (h *ProductImageHandler) listResponse(images []*domain.ProductImage) []ProductImageResponse {
+	resp := make([]ProductImageResponse, len(images))
+	for i, image := range images {
+		resp[i] = toProductImageResponse(image)
+	}
+	return resp
+}
+
+// UploadProductImage godoc
+// @Summary Upload a product image
+// @Description Upload a JPEG, PNG or GIF image of a product as the multipart field "image". The type is detected from the content and a thumbnail is generated. The first image becomes the primary image.
+// @Tags product-images
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param image formData file true "Image file"
+// @Success 201 {object} ProductImageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Router /products/{id}/images [post]
+func (h *ProductImageHandler) UploadProductImage(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+
+	// Leave room for the multipart framing around the file.
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, h.service.MaxSize()+64<<10)
+	file, err := c.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "image is too large")
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, "image file is required")
+	}
+	body, err := file.Open()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "image file could not be read")
+	}
+	defer body.Close()
+
+	image, err := h.service.Upload(req.Context(), uint(id), userID, file.Filename, body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, toProductImageResponse(image))
+}
+
+// ListProductImages godoc
+// @Summary List product images
+// @Description Get the images of a product in display order, with signed URLs
+// @Tags product-images
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Success 200 {array} ProductImageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /products/{id}/images [get]
+func (h *ProductImageHandler) ListProductImages(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	images, err := h.service.GetImages(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.JSON(http.StatusOK, h.listResponse(images))
+}
+
+// ReorderProductImages godoc
+// @Summary Reorder product images
+// @Description Set the display order of a product's images; image_ids must list every image once
+// @Tags product-images
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param order body reorderImagesRequest true "Image IDs in display order"
+// @Success 200 {array} ProductImageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /products/{id}/images/order [put]
+func (h *ProductImageHandler) ReorderProductImages(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	var req reorderImagesRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	images, err := h.service.Reorder(c.Request().Context(), uint(id), userID, req.ImageIDs)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, h.listResponse(images))
+}
+
+// SetPrimaryProductImage godoc
+// @Summary Set the primary product image
+// @Description Make an image the primary image of its product
+// @Tags product-images
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param imageId path int true "Image ID"
+// @Success 200 {array} ProductImageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /products/{id}/images/{imageId}/primary [post]
+func (h *ProductImageHandler) SetPrimaryProductImage(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	imageID, err := strconv.ParseUint(c.Param("imageId"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid image id")
+	}
+	images, err := h.service.SetPrimary(c.Request().Context(), uint(id), uint(imageID), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, h.listResponse(images))
+}
+
+// DeleteProductImage godoc
+// @Summary Delete a product image
+// @Description Delete an image and its thumbnail. If it was the primary image, the next image becomes primary.
+// @Tags product-images
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param imageId path int true "Image ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /products/{id}/images/{imageId} [delete]
+func (h *ProductImageHandler) DeleteProductImage(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	imageID, err := strconv.ParseUint(c.Param("imageId"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid image id")
+	}
+	if err := h.service.DeleteImage(c.Request().Context(), uint(id), uint(imageID), userID); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+type BlobHandler struct {
+	store *storage.LocalStore
+}
+
+func NewBlobHandler(store *storage.LocalStore) *BlobHandler {
+	return &BlobHandler{store: store}
+}
+
+// GetBlob godoc
+// @Summary Download a stored file
+// @Description Serve a file of the local blob store through a signed URL as returned in product responses
+// @Tags product-images
+// @Produce octet-stream
+// @Param key path string true "Blob key"
+// @Param expires query int true "Expiry as a Unix timestamp"
+// @Param signature query string true "URL signature"
+// @Success 200 {file} file
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /blobs/{key} [get]
+func (h *BlobHandler) GetBlob(c echo.Context) error {
+	key := c.Param("*")
+	if !h.store.Verify(key, c.QueryParam("expires"), c.QueryParam("signature")) {
+		return echo.NewHTTPError(http.StatusForbidden, "invalid or expired signature")
+	}
+	body, err := h.store.Get(c.Request().Context(), key)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "file not found")
+	}
+	defer body.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = echo.MIMEOctetStream
+	}
+	c.Response().Header().Set("Cache-Control", "private, max-age=3600")
+	c.Response().Header().Set("X-Content-Type-Options", "nosniff")
+	return c.Stream(http.StatusOK, contentType, body)
+}
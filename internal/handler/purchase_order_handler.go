@@ -0,0 +1,341 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type PurchaseOrderLineResponse struct {
+	ID               uint    `json:"id" example:"1"`
+	ProductID        uint    `json:"product_id" example:"1"`
+	VariantID        *uint   `json:"variant_id,omitempty" example:"4"`
+	ProductCode      string  `json:"product_code" example:"PROD001"`
+	ProductName      string  `json:"product_name" example:"Laptop"`
+	QuantityOrdered  int     `json:"quantity_ordered" example:"20"`
+	QuantityReceived int     `json:"quantity_received" example:"5"`
+	UnitCost         float64 `json:"unit_cost" example:"950.00"`
+	Subtotal         float64 `json:"subtotal" example:"19000.00"`
+}
+
+type PurchaseOrderReceiptLineResponse struct {
+	LineID   uint `json:"line_id" example:"1"`
+	Quantity int  `json:"quantity" example:"5"`
+}
+
+type PurchaseOrderReceiptResponse struct {
+	ID         uint                               `json:"id" example:"1"`
+	Notes      string                             `json:"notes" example:"2 boxes damaged"`
+	ReceivedAt time.Time                          `json:"received_at" example:"2024-01-22T09:00:00Z"`
+	Lines      []PurchaseOrderReceiptLineResponse `json:"lines"`
+}
+
+type PurchaseOrderResponse struct {
+	ID         uint                           `json:"id" example:"1"`
+	SupplierID uint                           `json:"supplier_id" example:"1"`
+	Status     string                         `json:"status" example:"partially_received"`
+	Notes      string                         `json:"notes" example:"Q1 restock"`
+	TotalCost  float64                        `json:"total_cost" example:"19000.00"`
+	ExpectedAt *time.Time                     `json:"expected_at" example:"2024-01-22T00:00:00Z"`
+	SentAt     *time.Time                     `json:"sent_at" example:"2024-01-15T10:30:00Z"`
+	ReceivedAt *time.Time                     `json:"received_at"`
+	ClosedAt   *time.Time                     `json:"closed_at"`
+	Lines      []PurchaseOrderLineResponse    `json:"lines"`
+	Receipts   []PurchaseOrderReceiptResponse `json:"receipts"`
+	CreatedAt  time.Time                      `json:"created_at" example:"2024-01-15T10:00:00Z"`
+	UpdatedAt  time.Time                      `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+}
+
+func toPurchaseOrderResponse(order *domain.PurchaseOrder) PurchaseOrderResponse {
+	lines := make([]PurchaseOrderLineResponse, len(order.Lines))
+	for i, line := range order.Lines {
+		lines[i] = PurchaseOrderLineResponse{
+			ID:               line.ID,
+			ProductID:        line.ProductID,
+			VariantID:        line.VariantID,
+			ProductCode:      line.Product.Code,
+			ProductName:      line.Product.Name,
+			QuantityOrdered:  line.QuantityOrdered,
+			QuantityReceived: line.QuantityReceived,
+			UnitCost:         line.UnitCost,
+			Subtotal:         line.Subtotal,
+		}
+	}
+	receipts := make([]PurchaseOrderReceiptResponse, len(order.Receipts))
+	for i, receipt := range order.Receipts {
+		receiptLines := make([]PurchaseOrderReceiptLineResponse, len(receipt.Lines))
+		for j, line := range receipt.Lines {
+			receiptLines[j] = PurchaseOrderReceiptLineResponse{LineID: line.LineID, Quantity: line.Quantity}
+		}
+		receipts[i] = PurchaseOrderReceiptResponse{
+			ID:         receipt.ID,
+			Notes:      receipt.Notes,
+			ReceivedAt: receipt.ReceivedAt,
+			Lines:      receiptLines,
+		}
+	}
+	return PurchaseOrderResponse{
+		ID:         order.ID,
+		SupplierID: order.SupplierID,
+		Status:     string(order.Status),
+		Notes:      order.Notes,
+		TotalCost:  order.TotalCost,
+		ExpectedAt: order.ExpectedAt,
+		SentAt:     order.SentAt,
+		ReceivedAt: order.ReceivedAt,
+		ClosedAt:   order.ClosedAt,
+		Lines:      lines,
+		Receipts:   receipts,
+		CreatedAt:  order.CreatedAt,
+		UpdatedAt:  order.UpdatedAt,
+	}
+}
+
+type PurchaseOrderHandler struct {
+	service *service.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(service *service.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{service: service}
+}
+
+// CreatePurchaseOrder godoc
+// @Summary Create a purchase order
+// @Description Create a draft purchase order for a supplier. Lines without a unit cost use the cost of the supplier's product link.
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param order body service.PurchaseOrderRequest true "Purchase order data"
+// @Success 201 {object} PurchaseOrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /purchase-orders [post]
+func (h *PurchaseOrderHandler) CreatePurchaseOrder(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	var req service.PurchaseOrderRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	order, err := h.service.CreatePurchaseOrder(c.Request().Context(), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, toPurchaseOrderResponse(order))
+}
+
+// ListPurchaseOrders godoc
+// @Summary List purchase orders
+// @Description Get the purchase orders of the authenticated user, newest first
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Filter by status" Enums(draft, sent, partially_received, received, closed)
+// @Success 200 {array} PurchaseOrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /purchase-orders [get]
+func (h *PurchaseOrderHandler) ListPurchaseOrders(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	status := domain.PurchaseOrderStatus(c.QueryParam("status"))
+	orders, err := h.service.GetPurchaseOrdersByUser(c.Request().Context(), userID, status)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	resp := make([]PurchaseOrderResponse, len(orders))
+	for i, order := range orders {
+		resp[i] = toPurchaseOrderResponse(order)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetPurchaseOrder godoc
+// @Summary Get a purchase order
+// @Description Get a purchase order with its lines and receipts
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} PurchaseOrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /purchase-orders/{id} [get]
+func (h *PurchaseOrderHandler) GetPurchaseOrder(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid purchase order id")
+	}
+	order, err := h.service.GetPurchaseOrder(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.JSON(http.StatusOK, toPurchaseOrderResponse(order))
+}
+
+// UpdatePurchaseOrder godoc
+// @Summary Update a draft purchase order
+// @Description Edit the notes, expected date or lines of a draft. Lines, when given, replace the existing ones.
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Purchase order ID"
+// @Param order body service.UpdatePurchaseOrderRequest true "Data to update"
+// @Success 200 {object} PurchaseOrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /purchase-orders/{id} [patch]
+func (h *PurchaseOrderHandler) UpdatePurchaseOrder(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid purchase order id")
+	}
+	var req service.UpdatePurchaseOrderRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	order, err := h.service.UpdatePurchaseOrder(c.Request().Context(), uint(id), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toPurchaseOrderResponse(order))
+}
+
+// SendPurchaseOrder godoc
+// @Summary Send a purchase order
+// @Description Mark a draft as sent to the supplier. Without an expected date, one is derived from the longest supplier lead time.
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} PurchaseOrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /purchase-orders/{id}/send [post]
+func (h *PurchaseOrderHandler) SendPurchaseOrder(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid purchase order id")
+	}
+	order, err := h.service.SendPurchaseOrder(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toPurchaseOrderResponse(order))
+}
+
+// ReceivePurchaseOrder godoc
+// @Summary Receive stock against a purchase order
+// @Description Book a delivery and add the received quantities to product stock. Omitting lines receives everything outstanding.
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Purchase order ID"
+// @Param receipt body service.ReceivePurchaseOrderRequest true "Received quantities"
+// @Success 200 {object} PurchaseOrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /purchase-orders/{id}/receive [post]
+func (h *PurchaseOrderHandler) ReceivePurchaseOrder(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid purchase order id")
+	}
+	var req service.ReceivePurchaseOrderRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	order, err := h.service.ReceivePurchaseOrder(c.Request().Context(), uint(id), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toPurchaseOrderResponse(order))
+}
+
+// ClosePurchaseOrder godoc
+// @Summary Close a purchase order
+// @Description Close a sent or received purchase order. Outstanding quantities are no longer expected.
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} PurchaseOrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /purchase-orders/{id}/close [post]
+func (h *PurchaseOrderHandler) ClosePurchaseOrder(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid purchase order id")
+	}
+	order, err := h.service.ClosePurchaseOrder(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toPurchaseOrderResponse(order))
+}
+
+// DeletePurchaseOrder godoc
+// @Summary Delete a draft purchase order
+// @Description Delete a purchase order that has not been sent
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Purchase order ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /purchase-orders/{id} [delete]
+func (h *PurchaseOrderHandler) DeletePurchaseOrder(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid purchase order id")
+	}
+	if err := h.service.DeletePurchaseOrder(c.Request().Context(), uint(id), userID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
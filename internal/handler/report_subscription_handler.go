@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ReportSubscriptionResponse struct {
+	ID         uint       `json:"id" example:"1"`
+	Report     string     `json:"report" example:"daily_sales"`
+	Cron       string     `json:"cron" example:"0 8 * * 1-5"`
+	Timezone   string     `json:"timezone" example:"Europe/Madrid"`
+	Recipients []string   `json:"recipients" example:"owner@example.com"`
+	Enabled    bool       `json:"enabled" example:"true"`
+	NextRunAt  *time.Time `json:"next_run_at" example:"2024-01-16T07:00:00Z"`
+	LastRunAt  *time.Time `json:"last_run_at" example:"2024-01-15T07:00:00Z"`
+	LastError  string     `json:"last_error" example:""`
+	CreatedAt  time.Time  `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt  time.Time  `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+}
+
+func toReportSubscriptionResponse(subscription *domain.ReportSubscription) ReportSubscriptionResponse {
+	return ReportSubscriptionResponse{
+		ID:         subscription.ID,
+		Report:     string(subscription.Report),
+		Cron:       subscription.Cron,
+		Timezone:   subscription.Timezone,
+		Recipients: subscription.RecipientList(),
+		Enabled:    subscription.Enabled,
+		NextRunAt:  subscription.NextRunAt,
+		LastRunAt:  subscription.LastRunAt,
+		LastError:  subscription.LastError,
+		CreatedAt:  subscription.CreatedAt,
+		UpdatedAt:  subscription.UpdatedAt,
+	}
+}
+
+type ReportSubscriptionHandler struct {
+	service *service.ReportSubscriptionService
+}
+
+func NewReportSubscriptionHandler(service *service.ReportSubscriptionService) *ReportSubscriptionHandler {
+	return &ReportSubscriptionHandler{service: service}
+}
+
+// CreateReportSubscription godoc
+// @Summary Subscribe to a report
+// @Description Email a report whenever a five field cron expression (minute hour day-of-month month day-of-week, or @daily, @weekly...) fires in the given time zone. daily_sales summarises the previous day's sales and low_stock lists the products at or below their reorder point. Reports go to the recipients, or to the account address when there are none
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param subscription body service.CreateReportSubscriptionRequest true "Subscription data"
+// @Success 201 {object} ReportSubscriptionResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /report-subscriptions [post]
+func (h *ReportSubscriptionHandler) CreateReportSubscription(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	var req service.CreateReportSubscriptionRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	subscription, err := h.service.CreateSubscription(c.Request().Context(), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, toReportSubscriptionResponse(subscription))
+}
+
+// ListReportSubscriptions godoc
+// @Summary List report subscriptions
+// @Description Get all report subscriptions of the authenticated user
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} ReportSubscriptionResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /report-subscriptions [get]
+func (h *ReportSubscriptionHandler) ListReportSubscriptions(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	subscriptions, err := h.service.GetSubscriptionsByUser(c.Request().Context(), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	resp := make([]ReportSubscriptionResponse, len(subscriptions))
+	for i, subscription := range subscriptions {
+		resp[i] = toReportSubscriptionResponse(subscription)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetReportSubscription godoc
+// @Summary Get a report subscription
+// @Description Get a report subscription of the authenticated user, with when it last ran and runs next
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Report subscription ID"
+// @Success 200 {object} ReportSubscriptionResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /report-subscriptions/{id} [get]
+func (h *ReportSubscriptionHandler) GetReportSubscription(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid report subscription id")
+	}
+	subscription, err := h.service.GetSubscription(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.JSON(http.StatusOK, toReportSubscriptionResponse(subscription))
+}
+
+// UpdateReportSubscription godoc
+// @Summary Update a report subscription
+// @Description Change the cron expression, time zone, recipients or enabled flag of a subscription. Its next run is scheduled anew
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Report subscription ID"
+// @Param subscription body service.UpdateReportSubscriptionRequest true "Data to update"
+// @Success 200 {object} ReportSubscriptionResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /report-subscriptions/{id} [patch]
+func (h *ReportSubscriptionHandler) UpdateReportSubscription(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid report subscription id")
+	}
+	var req service.UpdateReportSubscriptionRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	subscription, err := h.service.UpdateSubscription(c.Request().Context(), uint(id), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toReportSubscriptionResponse(subscription))
+}
+
+// DeleteReportSubscription godoc
+// @Summary Delete a report subscription
+// @Description Stop emailing a report
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Report subscription ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /report-subscriptions/{id} [delete]
+func (h *ReportSubscriptionHandler) DeleteReportSubscription(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid report subscription id")
+	}
+	if err := h.service.DeleteSubscription(c.Request().Context(), uint(id), userID); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// SendReportSubscription godoc
+// @Summary Send a report now
+// @Description Email the report of a subscription straight away, without changing its schedule
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Report subscription ID"
+// @Success 200 {object} ReportSubscriptionResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Router /report-subscriptions/{id}/send [post]
+func (h *ReportSubscriptionHandler) SendReportSubscription(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid report subscription id")
+	}
+	ctx := c.Request().Context()
+	if _, err := h.service.GetSubscription(ctx, uint(id), userID); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	subscription, err := h.service.SendNow(ctx, uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadGateway, err.Error())
+	}
+	return c.JSON(http.StatusOK, toReportSubscriptionResponse(subscription))
+}
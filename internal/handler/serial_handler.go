@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SerialResponse struct {
+	ID          uint       `json:"id" example:"12"`
+	Number      string     `json:"number" example:"SN-0001"`
+	ProductID   uint       `json:"product_id" example:"1"`
+	ProductCode string     `json:"product_code,omitempty" example:"LAPTOP-G15"`
+	ProductName string     `json:"product_name,omitempty" example:"Laptop Gaming"`
+	VariantID   *uint      `json:"variant_id,omitempty" example:"4"`
+	VariantCode string     `json:"variant_code,omitempty" example:"LAPTOP-G15-32GB"`
+	Status      string     `json:"status" example:"assigned"`
+	OrderID     *uint      `json:"order_id,omitempty" example:"7"`
+	OrderItemID *uint      `json:"order_item_id,omitempty" example:"15"`
+	OrderStatus string     `json:"order_status,omitempty" example:"shipped"`
+	ReceivedAt  time.Time  `json:"received_at" example:"2024-03-01T08:00:00Z"`
+	ReturnedAt  *time.Time `json:"returned_at" example:"2024-03-20T10:00:00Z"`
+}
+
+type serialsRequest struct {
+	Serials []string `json:"serials" example:"SN-0001,SN-0002"`
+}
+
+type serializedRequest struct {
+	Serialized bool `json:"serialized" example:"true"`
+}
+
+func toSerialResponse(serial *domain.Serial) SerialResponse {
+	resp := SerialResponse{
+		ID:          serial.ID,
+		Number:      serial.Number,
+		ProductID:   serial.ProductID,
+		ProductCode: serial.Product.Code,
+		ProductName: serial.Product.Name,
+		VariantID:   serial.VariantID,
+		Status:      string(serial.Status),
+		OrderItemID: serial.OrderItemID,
+		ReceivedAt:  serial.ReceivedAt,
+		ReturnedAt:  serial.ReturnedAt,
+	}
+	if serial.Variant != nil {
+		resp.VariantCode = serial.Variant.Code
+	}
+	if serial.OrderItem != nil {
+		resp.OrderID = &serial.OrderItem.OrderID
+		resp.OrderStatus = string(serial.OrderItem.Order.Status)
+	}
+	return resp
+}
+
+func toSerialResponses(serials []*domain.Serial) []SerialResponse {
+	resp := make([]SerialResponse, len(serials))
+	for i, serial := range serials {
+		resp[i] = toSerialResponse(serial)
+	}
+	return resp
+}
+
+type SerialHandler struct {
+	service *service.SerialService
+}
+
+func NewSerialHandler(service *service.SerialService) *SerialHandler {
+	return &SerialHandler{service: service}
+}
+
+// SetSerialized godoc
+// @Summary Turn serial tracking of a product on or off
+// @Description Every unit of a serialized product has a serial, assigned to order items before they ship. Serial tracking can only be turned on while the product has no stock.
+// @Tags serials
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param settings body serializedRequest true "Serial tracking"
+// @Success 200 {object} ProductResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /products/{id}/serialized [patch]
+func (h *SerialHandler) SetSerialized(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	var body serializedRequest
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	product, err := h.service.SetSerialized(c.Request().Context(), uint(id), userID, body.Serialized)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toProductResponse(product))
+}
+
+// GetProductSerials godoc
+// @Summary List the serials of a product
+// @Description Get the serials of a product, ordered by number
+// @Tags serials
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param status query string false "Filter by status" Enums(in_stock, assigned)
+// @Success 200 {array} SerialResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /products/{id}/serials [get]
+func (h *SerialHandler) GetProductSerials(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	status := domain.SerialStatus(c.QueryParam("status"))
+	serials, err := h.service.GetProductSerials(c.Request().Context(), uint(id), userID, status)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toSerialResponses(serials))
+}
+
+// ReceiveSerials godoc
+// @Summary Receive serialized stock
+// @Description Register the serials of received units of a serialized product and add one unit of stock per serial
+// @Tags serials
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param serials body service.ReceiveSerialsRequest true "Received serials"
+// @Success 201 {array} SerialResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /serials [post]
+func (h *SerialHandler) ReceiveSerials(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	var req service.ReceiveSerialsRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	serials, err := h.service.ReceiveSerials(c.Request().Context(), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, toSerialResponses(serials))
+}
+
+// LookupSerial godoc
+// @Summary Look up a serial
+// @Description Get a serial with its product and the order it was last sold in
+// @Tags serials
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param number path string true "Serial number"
+// @Success 200 {object} SerialResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /serials/{number} [get]
+func (h *SerialHandler) LookupSerial(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	serial, err := h.service.LookupSerial(c.Request().Context(), c.Param("number"), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.JSON(http.StatusOK, toSerialResponse(serial))
+}
+
+// ReturnSerials godoc
+// @Summary Return serialized units
+// @Description Bring shipped serials back into stock. Each serial keeps the order it was returned from until it is sold again.
+// @Tags serials
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param serials body serialsRequest true "Returned serials"
+// @Success 200 {array} SerialResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /serials/returns [post]
+func (h *SerialHandler) ReturnSerials(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	var body serialsRequest
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	serials, err := h.service.ReturnSerials(c.Request().Context(), userID, body.Serials)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toSerialResponses(serials))
+}
+
+// AssignSerials godoc
+// @Summary Assign serials to an order item
+// @Description Set the serials of an order item of a serialized product. The given serials replace those assigned before. Items of serialized products cannot ship until every unit has a serial.
+// @Tags serials
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Order ID"
+// @Param itemId path int true "Order item ID"
+// @Param serials body serialsRequest true "Serials of the item"
+// @Success 200 {object} OrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /orders/{id}/items/{itemId}/serials [put]
+func (h *SerialHandler) AssignSerials(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid order id")
+	}
+	itemID, err := strconv.ParseUint(c.Param("itemId"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid order item id")
+	}
+	var body serialsRequest
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	order, err := h.service.AssignSerials(c.Request().Context(), uint(orderID), uint(itemID), userID, body.Serials)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toOrderResponse(order))
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ShipmentItemResponse struct {
+	ID          uint `json:"id" example:"1"`
+	OrderItemID uint `json:"order_item_id" example:"1"`
+	Quantity    int  `json:"quantity" example:"2"`
+}
+
+type ShipmentResponse struct {
+	ID             uint                   `json:"id" example:"1"`
+	OrderID        uint                   `json:"order_id" example:"1"`
+	Carrier        string                 `json:"carrier" example:"DHL"`
+	Service        string                 `json:"service" example:"Express"`
+	TrackingNumber string                 `json:"tracking_number" example:"JD014600006281234567"`
+	Weight         float64                `json:"weight" example:"2.5"`
+	Status         string                 `json:"status" example:"shipped"`
+	ShippedAt      *time.Time             `json:"shipped_at" example:"2024-01-16T09:00:00Z"`
+	DeliveredAt    *time.Time             `json:"delivered_at" example:"2024-01-18T14:30:00Z"`
+	Items          []ShipmentItemResponse `json:"items"`
+	CreatedAt      time.Time              `json:"created_at" example:"2024-01-16T08:45:00Z"`
+	UpdatedAt      time.Time              `json:"updated_at" example:"2024-01-16T08:45:00Z"`
+}
+
+func toShipmentResponse(shipment *domain.Shipment) ShipmentResponse {
+	items := make([]ShipmentItemResponse, len(shipment.Items))
+	for i, item := range shipment.Items {
+		items[i] = ShipmentItemResponse{
+			ID:          item.ID,
+			OrderItemID: item.OrderItemID,
+			Quantity:    item.Quantity,
+		}
+	}
+	return ShipmentResponse{
+		ID:             shipment.ID,
+		OrderID:        shipment.OrderID,
+		Carrier:        shipment.Carrier,
+		Service:        shipment.Service,
+		TrackingNumber: shipment.TrackingNumber,
+		Weight:         shipment.Weight,
+		Status:         string(shipment.Status),
+		ShippedAt:      shipment.ShippedAt,
+		DeliveredAt:    shipment.DeliveredAt,
+		Items:          items,
+		CreatedAt:      shipment.CreatedAt,
+		UpdatedAt:      shipment.UpdatedAt,
+	}
+}
+
+type ShipmentHandler struct {
+	service *service.ShipmentService
+}
+
+func NewShipmentHandler(service *service.ShipmentService) *ShipmentHandler {
+	return &ShipmentHandler{service: service}
+}
+
+// CreateShipment godoc
+// @Summary Create a shipment for an order
+// @Description Ship some or all items of a confirmed order. Omitting items ships everything still unshipped. The order moves to shipped once all items are shipped.
+// @Tags shipments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Order ID"
+// @Param shipment body service.CreateShipmentRequest true "Shipment data"
+// @Success 201 {object} ShipmentResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /orders/{id}/shipments [post]
+func (h *ShipmentHandler) CreateShipment(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid order id")
+	}
+	var req service.CreateShipmentRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	shipment, err := h.service.CreateShipment(c.Request().Context(), userID, uint(orderID), req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, toShipmentResponse(shipment))
+}
+
+// ListShipments godoc
+// @Summary List the shipments of an order
+// @Description Get all shipments registered for an order of the authenticated user
+// @Tags shipments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Order ID"
+// @Success 200 {array} ShipmentResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /orders/{id}/shipments [get]
+func (h *ShipmentHandler) ListShipments(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid order id")
+	}
+	shipments, err := h.service.GetShipments(c.Request().Context(), userID, uint(orderID))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	resp := make([]ShipmentResponse, len(shipments))
+	for i, shipment := range shipments {
+		resp[i] = toShipmentResponse(shipment)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// UpdateShipment godoc
+// @Summary Update a shipment
+// @Description Update carrier details or record shipped/delivered timestamps. The order moves to delivered once all items are delivered.
+// @Tags shipments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Order ID"
+// @Param shipmentId path int true "Shipment ID"
+// @Param shipment body service.UpdateShipmentRequest true "Data to update"
+// @Success 200 {object} ShipmentResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /orders/{id}/shipments/{shipmentId} [patch]
+func (h *ShipmentHandler) UpdateShipment(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid order id")
+	}
+	shipmentID, err := strconv.ParseUint(c.Param("shipmentId"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid shipment id")
+	}
+	var req service.UpdateShipmentRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	shipment, err := h.service.UpdateShipment(c.Request().Context(), userID, uint(orderID), uint(shipmentID), req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toShipmentResponse(shipment))
+}
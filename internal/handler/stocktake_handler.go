@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type StocktakeLineResponse struct {
+	ID          uint       `json:"id" example:"1"`
+	ProductID   uint       `json:"product_id" example:"1"`
+	VariantID   *uint      `json:"variant_id,omitempty" example:"4"`
+	Code        string     `json:"code" example:"TSHIRT-RED"`
+	ProductName string     `json:"product_name" example:"T-Shirt"`
+	SystemStock int        `json:"system_stock" example:"12"`
+	Expected    *int       `json:"expected" example:"12"`
+	Counted     *int       `json:"counted" example:"10"`
+	Variance    *int       `json:"variance" example:"-2"`
+	Adjustment  int        `json:"adjustment" example:"0"`
+	CountedAt   *time.Time `json:"counted_at" example:"2024-01-31T17:05:00Z"`
+}
+
+type StocktakeResponse struct {
+	ID           uint                    `json:"id" example:"1"`
+	Name         string                  `json:"name" example:"Year-end count"`
+	Notes        string                  `json:"notes" example:"Back room first"`
+	Status       string                  `json:"status" example:"open"`
+	LinesCounted int                     `json:"lines_counted" example:"1"`
+	Lines        []StocktakeLineResponse `json:"lines"`
+	ApprovedAt   *time.Time              `json:"approved_at"`
+	CancelledAt  *time.Time              `json:"cancelled_at"`
+	CreatedAt    time.Time               `json:"created_at" example:"2024-01-31T17:00:00Z"`
+	UpdatedAt    time.Time               `json:"updated_at" example:"2024-01-31T17:05:00Z"`
+}
+
+type recordCountsRequest struct {
+	Counts []service.StocktakeCount `json:"counts"`
+}
+
+func toStocktakeResponse(stocktake *domain.Stocktake) StocktakeResponse {
+	resp := StocktakeResponse{
+		ID:          stocktake.ID,
+		Name:        stocktake.Name,
+		Notes:       stocktake.Notes,
+		Status:      string(stocktake.Status),
+		Lines:       make([]StocktakeLineResponse, len(stocktake.Lines)),
+		ApprovedAt:  stocktake.ApprovedAt,
+		CancelledAt: stocktake.CancelledAt,
+		CreatedAt:   stocktake.CreatedAt,
+		UpdatedAt:   stocktake.UpdatedAt,
+	}
+	for i := range stocktake.Lines {
+		line := &stocktake.Lines[i]
+		if line.Counted != nil {
+			resp.LinesCounted++
+		}
+		resp.Lines[i] = StocktakeLineResponse{
+			ID:          line.ID,
+			ProductID:   line.ProductID,
+			VariantID:   line.VariantID,
+			Code:        line.Code(),
+			ProductName: line.Product.Name,
+			SystemStock: line.SystemStock(),
+			Expected:    line.Expected,
+			Counted:     line.Counted,
+			Variance:    line.Variance(),
+			Adjustment:  line.Adjustment,
+			CountedAt:   line.CountedAt,
+		}
+	}
+	return resp
+}
+
+type StocktakeHandler struct {
+	service *service.StocktakeService
+}
+
+func NewStocktakeHandler(service *service.StocktakeService) *StocktakeHandler {
+	return &StocktakeHandler{service: service}
+}
+
+// OpenStocktake godoc
+// @Summary Open a stocktake
+// @Description Start a physical count of the given products, of a category, or of all products. Products with variants are counted per variant. A product can be in only one open stocktake.
+// @Tags stocktakes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param stocktake body service.OpenStocktakeRequest true "Products to count"
+// @Success 201 {object} StocktakeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /stocktakes [post]
+func (h *StocktakeHandler) OpenStocktake(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	var req service.OpenStocktakeRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	stocktake, err := h.service.OpenStocktake(c.Request().Context(), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, toStocktakeResponse(stocktake))
+}
+
+// ListStocktakes godoc
+// @Summary List stocktakes
+// @Description Get the stocktakes of the authenticated user, newest first
+// @Tags stocktakes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Filter by status" Enums(open, approved, cancelled)
+// @Success 200 {array} StocktakeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /stocktakes [get]
+func (h *StocktakeHandler) ListStocktakes(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	status := domain.StocktakeStatus(c.QueryParam("status"))
+	stocktakes, err := h.service.GetStocktakes(c.Request().Context(), userID, status)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	resp := make([]StocktakeResponse, len(stocktakes))
+	for i, stocktake := range stocktakes {
+		resp[i] = toStocktakeResponse(stocktake)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetStocktake godoc
+// @Summary Get a stocktake
+// @Description Get a stocktake with the counted quantity, the expected stock and the variance of each line. Expected is the system stock when the line was first counted.
+// @Tags stocktakes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Stocktake ID"
+// @Success 200 {object} StocktakeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /stocktakes/{id} [get]
+func (h *StocktakeHandler) GetStocktake(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid stocktake id")
+	}
+	stocktake, err := h.service.GetStocktake(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.JSON(http.StatusOK, toStocktakeResponse(stocktake))
+}
+
+// RecordStocktakeCounts godoc
+// @Summary Record counted quantities
+// @Description Record counts for lines of an open stocktake, by scanned code or by product and variant ID. Counts add to earlier passes unless mode is set. The counts are recorded all or none; if any is invalid, the errors of all invalid counts are returned.
+// @Tags stocktakes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Stocktake ID"
+// @Param counts body recordCountsRequest true "Counted quantities"
+// @Success 200 {object} StocktakeResponse
+// @Failure 400 {object} EntryErrorsResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /stocktakes/{id}/counts [post]
+func (h *StocktakeHandler) RecordStocktakeCounts(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid stocktake id")
+	}
+	var req recordCountsRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	stocktake, err := h.service.RecordCounts(c.Request().Context(), uint(id), userID, req.Counts)
+	if err != nil {
+		return entryErrorsResponse(err)
+	}
+	return c.JSON(http.StatusOK, toStocktakeResponse(stocktake))
+}
+
+// ApproveStocktake godoc
+// @Summary Approve a stocktake
+// @Description Post the variance of each counted line as a stock adjustment and close the stocktake. Variances are added to the current stock, so orders placed after a line was counted are kept. Uncounted lines are not adjusted.
+// @Tags stocktakes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Stocktake ID"
+// @Success 200 {object} StocktakeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /stocktakes/{id}/approve [post]
+func (h *StocktakeHandler) ApproveStocktake(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid stocktake id")
+	}
+	stocktake, err := h.service.ApproveStocktake(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toStocktakeResponse(stocktake))
+}
+
+// CancelStocktake godoc
+// @Summary Cancel a stocktake
+// @Description Close an open stocktake without changing stock
+// @Tags stocktakes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Stocktake ID"
+// @Success 200 {object} StocktakeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /stocktakes/{id}/cancel [post]
+func (h *StocktakeHandler) CancelStocktake(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid stocktake id")
+	}
+	stocktake, err := h.service.CancelStocktake(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toStocktakeResponse(stocktake))
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+// StreamResetEvent tells the client that events were missed while it was
+// disconnected and that it should reload its data.
+const StreamResetEvent = "stream.reset"
+
+type StreamHandler struct {
+	hub       *service.StreamHub
+	heartbeat time.Duration
+}
+
+func NewStreamHandler(hub *service.StreamHub) *StreamHandler {
+	return &StreamHandler{hub: hub, heartbeat: 15 * time.Second}
+}
+
+// StreamEvents godoc
+// @Summary Stream live order and stock events
+// @Description Server-Sent Events stream of order.created, order.status_changed, product.stock_adjusted, product.stock_low and product.deleted events of the authenticated user. Each event carries the outbox message ID; reconnect with the Last-Event-ID header (or last_event_id query parameter) to replay missed events. When they are no longer buffered a stream.reset event is sent first. Browsers may pass the token in the access_token query parameter.
+// @Tags events
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param Last-Event-ID header int false "ID of the last received event"
+// @Param last_event_id query int false "ID of the last received event"
+// @Param access_token query string false "JWT for clients that cannot send headers"
+// @Success 200 {string} string "event stream"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /events/stream [get]
+func (h *StreamHandler) StreamEvents(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+
+	lastEventID := c.Request().Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.QueryParam("last_event_id")
+	}
+	var lastID uint64
+	if lastEventID != "" {
+		lastID, err = strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid Last-Event-ID")
+		}
+	}
+
+	sub := h.hub.Subscribe(userID, uint(lastID))
+	defer sub.Close()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprint(res, "retry: 3000\n\n"); err != nil {
+		return nil
+	}
+	if sub.Missed {
+		if _, err := fmt.Fprintf(res, "event: %s\ndata: {}\n\n", StreamResetEvent); err != nil {
+			return nil
+		}
+	}
+	for _, event := range sub.Replay {
+		if err := writeStreamEvent(res, event); err != nil {
+			return nil
+		}
+	}
+	res.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-sub.Events:
+			if !ok {
+				// Dropped for lagging behind; the client reconnects and resumes.
+				return nil
+			}
+			if err := writeStreamEvent(res, event); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+		}
+		res.Flush()
+	}
+}
+
+func writeStreamEvent(res *echo.Response, event service.StreamEvent) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "id: %d\nevent: %s\n", event.ID, event.Type)
+	for _, line := range strings.Split(event.Data, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	_, err := res.Write([]byte(b.String()))
+	return err
+}
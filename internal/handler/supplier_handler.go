@@ -0,0 +1,309 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SupplierResponse struct {
+	ID        uint      `json:"id" example:"1"`
+	Name      string    `json:"name" example:"Acme Components"`
+	Email     string    `json:"email" example:"sales@acme.example.com"`
+	Phone     string    `json:"phone" example:"+57 300 123 4567"`
+	Notes     string    `json:"notes" example:"Net 30"`
+	CreatedAt time.Time `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt time.Time `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+}
+
+type SupplierProductResponse struct {
+	ProductID    uint      `json:"product_id" example:"1"`
+	SupplierSKU  string    `json:"supplier_sku" example:"ACM-LAP-001"`
+	UnitCost     float64   `json:"unit_cost" example:"950.00"`
+	LeadTimeDays int       `json:"lead_time_days" example:"7"`
+	UpdatedAt    time.Time `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+}
+
+func toSupplierResponse(supplier *domain.Supplier) SupplierResponse {
+	return SupplierResponse{
+		ID:        supplier.ID,
+		Name:      supplier.Name,
+		Email:     supplier.Email,
+		Phone:     supplier.Phone,
+		Notes:     supplier.Notes,
+		CreatedAt: supplier.CreatedAt,
+		UpdatedAt: supplier.UpdatedAt,
+	}
+}
+
+func toSupplierProductResponse(link *domain.SupplierProduct) SupplierProductResponse {
+	return SupplierProductResponse{
+		ProductID:    link.ProductID,
+		SupplierSKU:  link.SupplierSKU,
+		UnitCost:     link.UnitCost,
+		LeadTimeDays: link.LeadTimeDays,
+		UpdatedAt:    link.UpdatedAt,
+	}
+}
+
+type SupplierHandler struct {
+	service *service.SupplierService
+}
+
+func NewSupplierHandler(service *service.SupplierService) *SupplierHandler {
+	return &SupplierHandler{service: service}
+}
+
+// CreateSupplier godoc
+// @Summary Create a supplier
+// @Description Create a supplier for the authenticated user
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param supplier body service.SupplierRequest true "Supplier data"
+// @Success 201 {object} SupplierResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /suppliers [post]
+func (h *SupplierHandler) CreateSupplier(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	var req service.SupplierRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	supplier, err := h.service.CreateSupplier(c.Request().Context(), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, toSupplierResponse(supplier))
+}
+
+// ListSuppliers godoc
+// @Summary List suppliers
+// @Description Get all suppliers of the authenticated user
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} SupplierResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /suppliers [get]
+func (h *SupplierHandler) ListSuppliers(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	suppliers, err := h.service.GetSuppliersByUser(c.Request().Context(), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	resp := make([]SupplierResponse, len(suppliers))
+	for i, supplier := range suppliers {
+		resp[i] = toSupplierResponse(supplier)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetSupplier godoc
+// @Summary Get a supplier
+// @Description Get a supplier of the authenticated user
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Supplier ID"
+// @Success 200 {object} SupplierResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /suppliers/{id} [get]
+func (h *SupplierHandler) GetSupplier(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid supplier id")
+	}
+	supplier, err := h.service.GetSupplier(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.JSON(http.StatusOK, toSupplierResponse(supplier))
+}
+
+// UpdateSupplier godoc
+// @Summary Update a supplier
+// @Description Update the contact details of a supplier
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Supplier ID"
+// @Param supplier body service.UpdateSupplierRequest true "Data to update"
+// @Success 200 {object} SupplierResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /suppliers/{id} [patch]
+func (h *SupplierHandler) UpdateSupplier(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid supplier id")
+	}
+	var req service.UpdateSupplierRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	supplier, err := h.service.UpdateSupplier(c.Request().Context(), uint(id), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toSupplierResponse(supplier))
+}
+
+// DeleteSupplier godoc
+// @Summary Delete a supplier
+// @Description Delete a supplier without purchase orders
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Supplier ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /suppliers/{id} [delete]
+func (h *SupplierHandler) DeleteSupplier(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid supplier id")
+	}
+	if err := h.service.DeleteSupplier(c.Request().Context(), uint(id), userID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListSupplierProducts godoc
+// @Summary List the products of a supplier
+// @Description Get the products a supplier sells, with SKU, unit cost and lead time
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Supplier ID"
+// @Success 200 {array} SupplierProductResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /suppliers/{id}/products [get]
+func (h *SupplierHandler) ListSupplierProducts(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid supplier id")
+	}
+	links, err := h.service.GetSupplierProducts(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	resp := make([]SupplierProductResponse, len(links))
+	for i, link := range links {
+		resp[i] = toSupplierProductResponse(link)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// SetSupplierProduct godoc
+// @Summary Link a product to a supplier
+// @Description Create or replace the supplier SKU, unit cost and lead time of a product
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Supplier ID"
+// @Param productId path int true "Product ID"
+// @Param link body service.SupplierProductRequest true "Supplier product data"
+// @Success 200 {object} SupplierProductResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /suppliers/{id}/products/{productId} [put]
+func (h *SupplierHandler) SetSupplierProduct(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid supplier id")
+	}
+	productID, err := strconv.ParseUint(c.Param("productId"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	var req service.SupplierProductRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	link, err := h.service.SetSupplierProduct(c.Request().Context(), uint(id), uint(productID), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toSupplierProductResponse(link))
+}
+
+// DeleteSupplierProduct godoc
+// @Summary Unlink a product from a supplier
+// @Description Remove a product from the products a supplier sells
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Supplier ID"
+// @Param productId path int true "Product ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /suppliers/{id}/products/{productId} [delete]
+func (h *SupplierHandler) DeleteSupplierProduct(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid supplier id")
+	}
+	productID, err := strconv.ParseUint(c.Param("productId"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	if err := h.service.DeleteSupplierProduct(c.Request().Context(), uint(id), uint(productID), userID); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type TagResponse struct {
+	ID       uint   `json:"id" example:"1"`
+	Name     string `json:"name" example:"summer"`
+	Products int64  `json:"products" example:"8"`
+}
+
+type setProductTagsRequest struct {
+	Tags []string `json:"tags" example:"summer,cotton"`
+}
+
+func tagNames(tags []domain.Tag) []string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return names
+}
+
+type TagHandler struct {
+	service *service.TagService
+}
+
+func NewTagHandler(service *service.TagService) *TagHandler {
+	return &TagHandler{service: service}
+}
+
+// ListTags godoc
+// @Summary List tags
+// @Description Get the tags of the authenticated user with the number of products carrying each
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} TagResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tags [get]
+func (h *TagHandler) ListTags(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	tags, err := h.service.GetTags(c.Request().Context(), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	resp := make([]TagResponse, len(tags))
+	for i, tag := range tags {
+		resp[i] = TagResponse{ID: tag.ID, Name: tag.Name, Products: tag.Products}
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// DeleteTag godoc
+// @Summary Delete a tag
+// @Description Delete a tag and remove it from all products
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Tag ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /tags/{id} [delete]
+func (h *TagHandler) DeleteTag(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid tag id")
+	}
+	if err := h.service.DeleteTag(c.Request().Context(), uint(id), userID); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// SetProductTags godoc
+// @Summary Set the tags of a product
+// @Description Replace the tags of a product. Tags are free-form, lower-cased and created on first use.
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param tags body setProductTagsRequest true "Tag names"
+// @Success 200 {object} ProductResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /products/{id}/tags [put]
+func (h *TagHandler) SetProductTags(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	var req setProductTagsRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	product, err := h.service.SetProductTags(c.Request().Context(), uint(id), userID, req.Tags)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toProductResponse(product))
+}
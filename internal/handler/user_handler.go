@@ -2,10 +2,13 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"time"
 	"vertice-backend/pkg"
 
 	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
 
 	"github.com/labstack/echo/v4"
 )
@@ -14,6 +17,10 @@ type UserServiceInterface interface {
 	Register(ctx context.Context, name, email, password string) (*domain.User, error)
 	Authenticate(ctx context.Context, email, password string) (*domain.User, error)
 	GetProfile(ctx context.Context, id uint) (*domain.User, error)
+	VerifyEmail(ctx context.Context, token string) (*domain.User, error)
+	ResendVerification(ctx context.Context, email string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
 }
 
 type UserHandler struct {
@@ -27,20 +34,43 @@ func NewUserHandler(service UserServiceInterface) *UserHandler {
 type registerRequest struct {
 	Name     string `json:"name" example:"John Doe"`
 	Email    string `json:"email" example:"john@example.com"`
-	Password string `json:"password" example:"password123"`
+	Password string `json:"password" example:"correct-horse-42"`
 }
 
 type loginRequest struct {
 	Email    string `json:"email" example:"john@example.com"`
-	Password string `json:"password" example:"password123"`
+	Password string `json:"password" example:"correct-horse-42"`
 }
 
-type userResponse struct {
-	ID    uint   `json:"id" example:"1"`
-	Name  string `json:"name" example:"John Doe"`
+type verifyEmailRequest struct {
+	Token string `json:"token" example:"q3Jx2v8mT0bKc1yZ4nW6pR9sU5eH7aLd-fGiOjVkXwE"`
+}
+
+type emailRequest struct {
 	Email string `json:"email" example:"john@example.com"`
 }
 
+type resetPasswordRequest struct {
+	Token    string `json:"token" example:"q3Jx2v8mT0bKc1yZ4nW6pR9sU5eH7aLd-fGiOjVkXwE"`
+	Password string `json:"password" example:"newpassword123"`
+}
+
+type userResponse struct {
+	ID              uint       `json:"id" example:"1"`
+	Name            string     `json:"name" example:"John Doe"`
+	Email           string     `json:"email" example:"john@example.com"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at" example:"2024-01-15T10:30:00Z"`
+}
+
+func toUserResponse(user *domain.User) userResponse {
+	return userResponse{
+		ID:              user.ID,
+		Name:            user.Name,
+		Email:           user.Email,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+	}
+}
+
 type loginResponse struct {
 	Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
 }
@@ -53,7 +83,9 @@ type loginResponse struct {
 // @Produce json
 // @Param user body registerRequest true "User data"
 // @Success 201 {object} userResponse
-// @Failure 400 {object} ErrorResponse
+// @Failure 400 {object} FieldErrorsResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /users/register [post]
 func (h *UserHandler) Register(c echo.Context) error {
 	var req registerRequest
@@ -61,14 +93,16 @@ func (h *UserHandler) Register(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
 	}
 	user, err := h.service.Register(c.Request().Context(), req.Name, req.Email, req.Password)
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	var fieldErrs *service.FieldErrors
+	switch {
+	case errors.As(err, &fieldErrs):
+		return c.JSON(http.StatusBadRequest, FieldErrorsResponse{Error: err.Error(), Fields: fieldErrs.Fields})
+	case errors.Is(err, service.ErrEmailTaken):
+		return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "could not register user"})
 	}
-	return c.JSON(http.StatusCreated, userResponse{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
-	})
+	return c.JSON(http.StatusCreated, toUserResponse(user))
 }
 
 // Login godoc
@@ -81,6 +115,7 @@ func (h *UserHandler) Register(c echo.Context) error {
 // @Success 200 {object} loginResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Router /users/login [post]
 func (h *UserHandler) Login(c echo.Context) error {
 	var req loginRequest
@@ -88,10 +123,13 @@ func (h *UserHandler) Login(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
 	}
 	user, err := h.service.Authenticate(c.Request().Context(), req.Email, req.Password)
+	if errors.Is(err, service.ErrEmailNotVerified) {
+		return c.JSON(http.StatusForbidden, echo.Map{"error": "verify your email address before logging in"})
+	}
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "invalid credentials"})
 	}
-	token, err := pkg.GenerateJWT(user.ID)
+	token, err := pkg.GenerateJWT(user.ID, user.SessionVersion)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "could not generate token"})
 	}
@@ -118,9 +156,95 @@ func (h *UserHandler) Profile(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusNotFound, echo.Map{"error": "user not found"})
 	}
-	return c.JSON(http.StatusOK, userResponse{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
-	})
+	return c.JSON(http.StatusOK, toUserResponse(user))
+}
+
+// VerifyEmail godoc
+// @Summary Verify an email address
+// @Description Confirm the address of an account with the token emailed on registration. Tokens work once and expire after 48 hours
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param token body verifyEmailRequest true "Verification token"
+// @Success 200 {object} userResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /auth/verify-email [post]
+func (h *UserHandler) VerifyEmail(c echo.Context) error {
+	var req verifyEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+	user, err := h.service.VerifyEmail(c.Request().Context(), req.Token)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, toUserResponse(user))
+}
+
+// ResendVerification godoc
+// @Summary Resend the verification email
+// @Description Email a new verification link to an unverified account. Earlier links stop working. The response is the same whether or not the address has an account
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param email body emailRequest true "Account email"
+// @Success 202 "Accepted"
+// @Failure 400 {object} ErrorResponse
+// @Router /auth/resend-verification [post]
+func (h *UserHandler) ResendVerification(c echo.Context) error {
+	var req emailRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+	if err := h.service.ResendVerification(c.Request().Context(), req.Email); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusAccepted)
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Email a password reset link, valid for one hour, to the account with this address. The response is the same whether or not the address has an account
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param email body emailRequest true "Account email"
+// @Success 202 "Accepted"
+// @Failure 400 {object} ErrorResponse
+// @Router /auth/forgot-password [post]
+func (h *UserHandler) ForgotPassword(c echo.Context) error {
+	var req emailRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+	if err := h.service.ForgotPassword(c.Request().Context(), req.Email); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusAccepted)
+}
+
+// ResetPassword godoc
+// @Summary Reset a password
+// @Description Set a new password with the token from a password reset email. Every existing session of the account is signed out
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param reset body resetPasswordRequest true "Reset token and new password"
+// @Success 204 "No Content"
+// @Failure 400 {object} FieldErrorsResponse
+// @Router /auth/reset-password [post]
+func (h *UserHandler) ResetPassword(c echo.Context) error {
+	var req resetPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request"})
+	}
+	err := h.service.ResetPassword(c.Request().Context(), req.Token, req.Password)
+	var fieldErrs *service.FieldErrors
+	if errors.As(err, &fieldErrs) {
+		return c.JSON(http.StatusBadRequest, FieldErrorsResponse{Error: err.Error(), Fields: fieldErrs.Fields})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
 }
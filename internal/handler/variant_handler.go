@@ -0,0 +1,383 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type OptionValueResponse struct {
+	ID       uint   `json:"id" example:"1"`
+	Value    string `json:"value" example:"Red"`
+	Position int    `json:"position" example:"0"`
+}
+
+type OptionTypeResponse struct {
+	ID     uint                  `json:"id" example:"1"`
+	Name   string                `json:"name" example:"Color"`
+	Values []OptionValueResponse `json:"values"`
+}
+
+type VariantOptionResponse struct {
+	OptionTypeID  uint   `json:"option_type_id" example:"1"`
+	OptionType    string `json:"option_type" example:"Color"`
+	OptionValueID uint   `json:"option_value_id" example:"1"`
+	Value         string `json:"value" example:"Red"`
+}
+
+// VariantResponse shows the price a variant sells at; PriceOverride is set
+// when it differs from the product price.
+type VariantResponse struct {
+	ID            uint                    `json:"id" example:"3"`
+	Code          string                  `json:"code" example:"PROD001-RED-M"`
+	Price         float64                 `json:"price" example:"1299.99"`
+	PriceOverride *float64                `json:"price_override" example:"1349.99"`
+	Stock         int                     `json:"stock" example:"4"`
+	Options       []VariantOptionResponse `json:"options"`
+}
+
+type addOptionValueRequest struct {
+	Value string `json:"value" example:"XL"`
+}
+
+type updateVariantStockRequest struct {
+	StockDelta int `json:"stockDelta" example:"5"`
+}
+
+func toOptionTypeResponse(optionType *domain.OptionType) OptionTypeResponse {
+	values := make([]OptionValueResponse, len(optionType.Values))
+	for i, value := range optionType.Values {
+		values[i] = OptionValueResponse{ID: value.ID, Value: value.Value, Position: value.Position}
+	}
+	return OptionTypeResponse{ID: optionType.ID, Name: optionType.Name, Values: values}
+}
+
+func toVariantResponses(p *domain.Product) []VariantResponse {
+	if !p.HasVariants() {
+		return nil
+	}
+	resp := make([]VariantResponse, len(p.Variants))
+	for i := range p.Variants {
+		variant := &p.Variants[i]
+		options := make([]VariantOptionResponse, len(variant.Options))
+		for j, option := range variant.Options {
+			options[j] = VariantOptionResponse{
+				OptionTypeID:  option.OptionTypeID,
+				OptionType:    option.OptionType.Name,
+				OptionValueID: option.OptionValueID,
+				Value:         option.OptionValue.Value,
+			}
+		}
+		resp[i] = VariantResponse{
+			ID:            variant.ID,
+			Code:          variant.Code,
+			Price:         variant.EffectivePrice(p),
+			PriceOverride: variant.Price,
+			Stock:         variant.Stock,
+			Options:       options,
+		}
+	}
+	return resp
+}
+
+type VariantHandler struct {
+	service *service.VariantService
+}
+
+func NewVariantHandler(service *service.VariantService) *VariantHandler {
+	return &VariantHandler{service: service}
+}
+
+// CreateOptionType godoc
+// @Summary Create an option type
+// @Description Create an option type such as size or color, with its values
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param optionType body service.OptionTypeRequest true "Option type data"
+// @Success 201 {object} OptionTypeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /option-types [post]
+func (h *VariantHandler) CreateOptionType(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	var req service.OptionTypeRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	optionType, err := h.service.CreateOptionType(c.Request().Context(), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, toOptionTypeResponse(optionType))
+}
+
+// ListOptionTypes godoc
+// @Summary List option types
+// @Description Get all option types of the authenticated user with their values
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} OptionTypeResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /option-types [get]
+func (h *VariantHandler) ListOptionTypes(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	optionTypes, err := h.service.GetOptionTypes(c.Request().Context(), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	resp := make([]OptionTypeResponse, len(optionTypes))
+	for i, optionType := range optionTypes {
+		resp[i] = toOptionTypeResponse(optionType)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// DeleteOptionType godoc
+// @Summary Delete an option type
+// @Description Delete an option type that no variant uses
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Option type ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /option-types/{id} [delete]
+func (h *VariantHandler) DeleteOptionType(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid option type id")
+	}
+	if err := h.service.DeleteOptionType(c.Request().Context(), uint(id), userID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// AddOptionValue godoc
+// @Summary Add an option value
+// @Description Add a value to an option type
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Option type ID"
+// @Param value body addOptionValueRequest true "Option value"
+// @Success 201 {object} OptionTypeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /option-types/{id}/values [post]
+func (h *VariantHandler) AddOptionValue(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid option type id")
+	}
+	var req addOptionValueRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	optionType, err := h.service.AddOptionValue(c.Request().Context(), uint(id), userID, req.Value)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, toOptionTypeResponse(optionType))
+}
+
+// DeleteOptionValue godoc
+// @Summary Delete an option value
+// @Description Delete an option value that no variant uses
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Option type ID"
+// @Param valueId path int true "Option value ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /option-types/{id}/values/{valueId} [delete]
+func (h *VariantHandler) DeleteOptionValue(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid option type id")
+	}
+	valueID, err := strconv.ParseUint(c.Param("valueId"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid option value id")
+	}
+	if err := h.service.DeleteOptionValue(c.Request().Context(), uint(id), uint(valueID), userID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// CreateVariant godoc
+// @Summary Add a variant to a product
+// @Description Add a variant with its own code, stock and optional price. The product stock becomes the sum of its variant stocks.
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param variant body service.CreateVariantRequest true "Variant data"
+// @Success 201 {object} ProductResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /products/{id}/variants [post]
+func (h *VariantHandler) CreateVariant(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	var req service.CreateVariantRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	product, err := h.service.CreateVariant(c.Request().Context(), uint(id), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, toProductResponse(product))
+}
+
+// UpdateVariant godoc
+// @Summary Update a variant
+// @Description Change the code or price of a variant; clear_price makes it sell at the product price again
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param variantId path int true "Variant ID"
+// @Param variant body service.UpdateVariantRequest true "Data to update"
+// @Success 200 {object} ProductResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /products/{id}/variants/{variantId} [patch]
+func (h *VariantHandler) UpdateVariant(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	variantID, err := strconv.ParseUint(c.Param("variantId"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid variant id")
+	}
+	var req service.UpdateVariantRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	product, err := h.service.UpdateVariant(c.Request().Context(), uint(id), uint(variantID), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toProductResponse(product))
+}
+
+// UpdateVariantStock godoc
+// @Summary Update variant stock
+// @Description Add a positive or negative delta to the stock of a variant
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param variantId path int true "Variant ID"
+// @Param stock body updateVariantStockRequest true "Stock delta"
+// @Success 200 {object} ProductResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /products/{id}/variants/{variantId}/stock [patch]
+func (h *VariantHandler) UpdateVariantStock(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	variantID, err := strconv.ParseUint(c.Param("variantId"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid variant id")
+	}
+	var req updateVariantStockRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	product, err := h.service.UpdateVariantStock(c.Request().Context(), uint(id), uint(variantID), userID, req.StockDelta)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toProductResponse(product))
+}
+
+// DeleteVariant godoc
+// @Summary Delete a variant
+// @Description Delete a variant and remove its stock from the product
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param variantId path int true "Variant ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /products/{id}/variants/{variantId} [delete]
+func (h *VariantHandler) DeleteVariant(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	variantID, err := strconv.ParseUint(c.Param("variantId"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid variant id")
+	}
+	if err := h.service.DeleteVariant(c.Request().Context(), uint(id), uint(variantID), userID); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,346 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg"
+
+	"github.com/labstack/echo/v4"
+)
+
+type WebhookResponse struct {
+	ID        uint      `json:"id" example:"1"`
+	URL       string    `json:"url" example:"https://erp.example.com/hooks/vertice"`
+	Events    []string  `json:"events" example:"order.created,order.status_changed"`
+	Active    bool      `json:"active" example:"true"`
+	CreatedAt time.Time `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt time.Time `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+}
+
+// WebhookCreatedResponse includes the signing secret, which is only shown once.
+type WebhookCreatedResponse struct {
+	WebhookResponse
+	Secret string `json:"secret" example:"9f86d081884c7d659a2feaa0c55ad015"`
+}
+
+type WebhookAttemptResponse struct {
+	Number       int       `json:"number" example:"1"`
+	StatusCode   int       `json:"status_code" example:"500"`
+	Error        string    `json:"error" example:"unexpected status 500 Internal Server Error"`
+	ResponseBody string    `json:"response_body" example:"internal error"`
+	DurationMs   int64     `json:"duration_ms" example:"120"`
+	CreatedAt    time.Time `json:"created_at" example:"2024-01-15T10:30:00Z"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             uint                     `json:"id" example:"1"`
+	EventType      string                   `json:"event_type" example:"order.created"`
+	Payload        string                   `json:"payload"`
+	Status         string                   `json:"status" example:"retrying"`
+	Attempts       int                      `json:"attempts" example:"1"`
+	NextAttemptAt  *time.Time               `json:"next_attempt_at" example:"2024-01-15T10:31:00Z"`
+	LastStatusCode int                      `json:"last_status_code" example:"500"`
+	LastError      string                   `json:"last_error" example:"unexpected status 500 Internal Server Error"`
+	DeliveredAt    *time.Time               `json:"delivered_at"`
+	AttemptLog     []WebhookAttemptResponse `json:"attempt_log"`
+	CreatedAt      time.Time                `json:"created_at" example:"2024-01-15T10:30:00Z"`
+}
+
+func toWebhookResponse(subscription *domain.WebhookSubscription) WebhookResponse {
+	return WebhookResponse{
+		ID:        subscription.ID,
+		URL:       subscription.URL,
+		Events:    subscription.EventTypes(),
+		Active:    subscription.Active,
+		CreatedAt: subscription.CreatedAt,
+		UpdatedAt: subscription.UpdatedAt,
+	}
+}
+
+func toWebhookDeliveryResponse(delivery *domain.WebhookDelivery) WebhookDeliveryResponse {
+	attempts := make([]WebhookAttemptResponse, len(delivery.AttemptLog))
+	for i, attempt := range delivery.AttemptLog {
+		attempts[i] = WebhookAttemptResponse{
+			Number:       attempt.Number,
+			StatusCode:   attempt.StatusCode,
+			Error:        attempt.Error,
+			ResponseBody: attempt.ResponseBody,
+			DurationMs:   attempt.DurationMs,
+			CreatedAt:    attempt.CreatedAt,
+		}
+	}
+	return WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		AttemptLog:     attempts,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
+
+type WebhookHandler struct {
+	service *service.WebhookService
+}
+
+func NewWebhookHandler(service *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// CreateWebhook godoc
+// @Summary Create a webhook subscription
+// @Description Register a URL that receives signed POST requests for the selected event types. Deliveries carry an X-Vertice-Signature header with the HMAC-SHA256 of "<X-Vertice-Timestamp>.<body>".
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param webhook body service.CreateWebhookRequest true "Webhook data"
+// @Success 201 {object} WebhookCreatedResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	var req service.CreateWebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	subscription, err := h.service.CreateSubscription(c.Request().Context(), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, WebhookCreatedResponse{
+		WebhookResponse: toWebhookResponse(subscription),
+		Secret:          subscription.Secret,
+	})
+}
+
+// ListWebhooks godoc
+// @Summary List webhook subscriptions
+// @Description Get all webhook subscriptions of the authenticated user
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} WebhookResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	subscriptions, err := h.service.GetSubscriptionsByUser(c.Request().Context(), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	resp := make([]WebhookResponse, len(subscriptions))
+	for i, subscription := range subscriptions {
+		resp[i] = toWebhookResponse(subscription)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetWebhook godoc
+// @Summary Get a webhook subscription
+// @Description Get a webhook subscription of the authenticated user
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} WebhookResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid webhook id")
+	}
+	subscription, err := h.service.GetSubscription(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.JSON(http.StatusOK, toWebhookResponse(subscription))
+}
+
+// UpdateWebhook godoc
+// @Summary Update a webhook subscription
+// @Description Change the URL, secret, event types or active flag of a subscription
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Param webhook body service.UpdateWebhookRequest true "Data to update"
+// @Success 200 {object} WebhookResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /webhooks/{id} [patch]
+func (h *WebhookHandler) UpdateWebhook(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid webhook id")
+	}
+	var req service.UpdateWebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	subscription, err := h.service.UpdateSubscription(c.Request().Context(), uint(id), userID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toWebhookResponse(subscription))
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook subscription
+// @Description Delete a webhook subscription and its delivery log
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid webhook id")
+	}
+	if err := h.service.DeleteSubscription(c.Request().Context(), uint(id), userID); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListDeliveries godoc
+// @Summary List webhook deliveries
+// @Description Get the latest deliveries of a subscription with every attempt made for them
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 200 {array} WebhookDeliveryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid webhook id")
+	}
+	deliveries, err := h.service.GetDeliveries(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	resp := make([]WebhookDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		resp[i] = toWebhookDeliveryResponse(delivery)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetDelivery godoc
+// @Summary Get a webhook delivery
+// @Description Get a delivery of a subscription with its attempt log
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Param deliveryId path int true "Delivery ID"
+// @Success 200 {object} WebhookDeliveryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /webhooks/{id}/deliveries/{deliveryId} [get]
+func (h *WebhookHandler) GetDelivery(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid webhook id")
+	}
+	deliveryID, err := strconv.ParseUint(c.Param("deliveryId"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid delivery id")
+	}
+	delivery, err := h.service.GetDelivery(c.Request().Context(), uint(id), uint(deliveryID), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.JSON(http.StatusOK, toWebhookDeliveryResponse(delivery))
+}
+
+// RedeliverDelivery godoc
+// @Summary Redeliver a webhook delivery
+// @Description Queue a delivery again, including dead-lettered ones. The retry counter starts over.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Param deliveryId path int true "Delivery ID"
+// @Success 202 {object} WebhookDeliveryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func (h *WebhookHandler) RedeliverDelivery(c echo.Context) error {
+	userID, err := pkg.GetUserIDFromJWTContext(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid webhook id")
+	}
+	deliveryID, err := strconv.ParseUint(c.Param("deliveryId"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid delivery id")
+	}
+	delivery, err := h.service.Redeliver(c.Request().Context(), uint(id), uint(deliveryID), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.JSON(http.StatusAccepted, toWebhookDeliveryResponse(delivery))
+}
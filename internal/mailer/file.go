@@ -0,0 +1,103 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileMailer writes every message to its own .eml file in a directory
+// instead of sending it, for development and testing.
+type FileMailer struct {
+	dir  string
+	from string
+	now  func() time.Time
+
+	mu  sync.Mutex
+	seq int
+}
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileMailer{dir: dir, from: from, now: time.Now}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("mailer: message has no recipients")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	now := m.now()
+	m.mu.Lock()
+	m.seq++
+	name := fmt.Sprintf("%s-%04d.eml", now.UTC().Format("20060102T150405.000000"), m.seq)
+	m.mu.Unlock()
+	return os.WriteFile(filepath.Join(m.dir, name), Build(m.from, msg, now), 0o644)
+}
+
+// LogMailer writes every message to a writer, such as stdout, instead of
+// sending it.
+type LogMailer struct {
+	w    io.Writer
+	from string
+	now  func() time.Time
+	mu   sync.Mutex
+}
+
+func NewLogMailer(w io.Writer, from string) *LogMailer {
+	return &LogMailer{w: w, from: from, now: time.Now}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("mailer: message has no recipients")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err := fmt.Fprintf(m.w, "%s\r\n", Build(m.from, msg, m.now()))
+	return err
+}
+
+// NewFromEnv builds the mailer MAIL_DRIVER selects: smtp, configured by the
+// SMTP_* variables; file, writing to MAIL_DIR (./mail by default); or log,
+// writing to stdout. Without MAIL_DRIVER, smtp is used when SMTP_HOST is set.
+// It returns nil when no mailer is configured.
+func NewFromEnv() (Mailer, error) {
+	from := os.Getenv("SMTP_FROM")
+	switch driver := os.Getenv("MAIL_DRIVER"); driver {
+	case "", "smtp":
+		if m, ok := NewSMTPMailerFromEnv(); ok {
+			return m, nil
+		}
+		if driver == "smtp" {
+			return nil, errors.New("mailer: MAIL_DRIVER is smtp but SMTP_HOST is not set")
+		}
+		return nil, nil
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "./mail"
+		}
+		m, err := NewFileMailer(dir, from)
+		if err != nil {
+			return nil, err
+		}
+		return m, nil
+	case "log":
+		return NewLogMailer(os.Stdout, from), nil
+	default:
+		return nil, fmt.Errorf("mailer: unknown MAIL_DRIVER %q, use smtp, file or log", driver)
+	}
+}
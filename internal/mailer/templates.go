@@ -0,0 +1,36 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+var templateFuncs = map[string]any{
+	"money": func(amount float64) string { return fmt.Sprintf("%.2f", amount) },
+}
+
+var (
+	textTemplates = texttemplate.Must(texttemplate.New("").Funcs(templateFuncs).ParseFS(templateFS, "templates/*.txt"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.New("").Funcs(templateFuncs).ParseFS(templateFS, "templates/*.html"))
+)
+
+// Render builds a message from the templates/<name>.txt and
+// templates/<name>.html templates, both executed with data.
+func Render(name string, to []string, subject string, data any) (Message, error) {
+	msg := Message{To: to, Subject: subject}
+	var text, html bytes.Buffer
+	if err := textTemplates.ExecuteTemplate(&text, name+".txt", data); err != nil {
+		return msg, err
+	}
+	if err := htmlTemplates.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return msg, err
+	}
+	msg.Text, msg.HTML = text.String(), html.String()
+	return msg, nil
+}
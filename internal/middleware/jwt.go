@@ -1,16 +1,56 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
-	"os"
+
+	"vertice-backend/pkg"
 
 	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
 )
 
+// SessionValidator returns an error when the tokens issued to a user for a
+// session version are no longer valid, such as after a password reset.
+type SessionValidator func(ctx context.Context, userID uint, sessionVersion int) error
+
+var sessionValidator SessionValidator
+
+// UseSessionValidator makes JWTMiddleware and JWTQueryMiddleware check every
+// token with v. Call it before the routes are registered.
+func UseSessionValidator(v SessionValidator) {
+	sessionValidator = v
+}
+
+func parseToken(c echo.Context, auth string) (interface{}, error) {
+	claims, err := pkg.ParseJWT(auth)
+	if err != nil {
+		return nil, err
+	}
+	if sessionValidator != nil {
+		if err := sessionValidator(c.Request().Context(), claims.UserID, claims.SessionVersion); err != nil {
+			return nil, err
+		}
+	}
+	return claims, nil
+}
+
 func JWTMiddleware() echo.MiddlewareFunc {
 	return echojwt.WithConfig(echojwt.Config{
-		SigningKey: []byte(os.Getenv("JWT_SECRET")),
+		ParseTokenFunc: parseToken,
+		ErrorHandler: func(c echo.Context, err error) error {
+			return c.JSON(http.StatusUnauthorized, echo.Map{"error": "invalid or missing token"})
+		},
+	})
+}
+
+// JWTQueryMiddleware also accepts the token in the access_token query
+// parameter. Use it only for endpoints consumed by clients that cannot send an
+// Authorization header, since URLs end up in access logs.
+func JWTQueryMiddleware() echo.MiddlewareFunc {
+	return echojwt.WithConfig(echojwt.Config{
+		ParseTokenFunc: parseToken,
+		TokenLookup:    "header:Authorization:Bearer ,query:" + pkg.AccessTokenQueryParam,
 		ErrorHandler: func(c echo.Context, err error) error {
 			return c.JSON(http.StatusUnauthorized, echo.Map{"error": "invalid or missing token"})
 		},
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type AnalyticsGormRepository struct {
+	db *gorm.DB
+}
+
+func NewAnalyticsGormRepository() domain.AnalyticsRepository {
+	return &AnalyticsGormRepository{db: config.DB}
+}
+
+// orders selects the orders of a user created in the filter's period.
+func (r *AnalyticsGormRepository) orders(ctx context.Context, userID uint, filter domain.SalesFilter) *gorm.DB {
+	query := conn(ctx, r.db).Table("orders").Where("orders.user_id = ?", userID)
+	if filter.From != nil {
+		query = query.Where("orders.created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("orders.created_at < ?", *filter.To)
+	}
+	return query
+}
+
+func (r *AnalyticsGormRepository) SalesOverTime(ctx context.Context, userID uint, bucket domain.SalesBucket, filter domain.SalesFilter) ([]domain.SalesPoint, error) {
+	zone := "UTC"
+	if filter.Location != nil {
+		zone = filter.Location.String()
+	}
+	var points []domain.SalesPoint
+	err := r.orders(ctx, userID, filter).
+		Select("date_trunc(?, orders.created_at AT TIME ZONE ?) AS period, COUNT(*) AS orders, COALESCE(SUM(orders.total_amount), 0) AS revenue", string(bucket), zone).
+		Where("orders.status <> ?", domain.OrderStatusCancelled).
+		Group("period").
+		Order("period ASC").
+		Scan(&points).Error
+	if err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+func (r *AnalyticsGormRepository) TopProducts(ctx context.Context, userID uint, filter domain.SalesFilter, byQuantity bool, limit int) ([]domain.ProductSales, error) {
+	order := "revenue DESC, quantity DESC"
+	if byQuantity {
+		order = "quantity DESC, revenue DESC"
+	}
+	var products []domain.ProductSales
+	err := r.orders(ctx, userID, filter).
+		Select("order_items.product_id, products.code, products.name, SUM(order_items.quantity) AS quantity, SUM(order_items.subtotal) AS revenue, COUNT(DISTINCT orders.id) AS orders").
+		Joins("JOIN order_items ON order_items.order_id = orders.id").
+		Joins("JOIN products ON products.id = order_items.product_id").
+		Where("orders.status <> ?", domain.OrderStatusCancelled).
+		Group("order_items.product_id, products.code, products.name").
+		Order(order + ", order_items.product_id ASC").
+		Limit(limit).
+		Scan(&products).Error
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (r *AnalyticsGormRepository) StatusCounts(ctx context.Context, userID uint, filter domain.SalesFilter) ([]domain.StatusCount, error) {
+	var counts []domain.StatusCount
+	err := r.orders(ctx, userID, filter).
+		Select("orders.status, COUNT(*) AS orders, COALESCE(SUM(orders.total_amount), 0) AS revenue").
+		Group("orders.status").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (r *AnalyticsGormRepository) DailyProductSales(ctx context.Context, userID uint, since time.Time) ([]domain.DailyProductSales, error) {
+	var sales []domain.DailyProductSales
+	err := conn(ctx, r.db).Raw(`
+		SELECT sold.product_id, sold.variant_id, sold.day, SUM(sold.quantity) AS quantity
+		FROM (
+			SELECT order_items.product_id, order_items.variant_id, order_items.quantity,
+				date_trunc('day', orders.created_at AT TIME ZONE 'UTC') AS day
+			FROM order_items JOIN orders ON orders.id = order_items.order_id
+			WHERE orders.user_id = ? AND orders.status <> ? AND orders.created_at >= ?
+			UNION ALL
+			SELECT order_item_components.product_id, order_item_components.variant_id, order_item_components.quantity,
+				date_trunc('day', orders.created_at AT TIME ZONE 'UTC') AS day
+			FROM order_item_components
+			JOIN order_items ON order_items.id = order_item_components.order_item_id
+			JOIN orders ON orders.id = order_items.order_id
+			WHERE orders.user_id = ? AND orders.status <> ? AND orders.created_at >= ?
+		) AS sold
+		GROUP BY sold.product_id, sold.variant_id, sold.day
+		ORDER BY sold.day ASC`,
+		userID, domain.OrderStatusCancelled, since,
+		userID, domain.OrderStatusCancelled, since,
+	).Scan(&sales).Error
+	if err != nil {
+		return nil, err
+	}
+	return sales, nil
+}
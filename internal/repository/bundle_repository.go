@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BundleGormRepository struct {
+	db *gorm.DB
+}
+
+func NewBundleGormRepository() domain.BundleRepository {
+	return &BundleGormRepository{db: config.DB}
+}
+
+func (r *BundleGormRepository) ReplaceComponents(ctx context.Context, bundleID uint, components []domain.BundleComponent) error {
+	db := conn(ctx, r.db)
+	if err := db.Where("bundle_id = ?", bundleID).Delete(&domain.BundleComponent{}).Error; err != nil {
+		return err
+	}
+	if len(components) == 0 {
+		return nil
+	}
+	for i := range components {
+		components[i].BundleID = bundleID
+	}
+	return db.Omit(clause.Associations).Create(&components).Error
+}
+
+func (r *BundleGormRepository) FindBundleIDsByComponent(ctx context.Context, productID uint) ([]uint, error) {
+	var ids []uint
+	err := conn(ctx, r.db).Model(&domain.BundleComponent{}).
+		Distinct("bundle_id").
+		Where("component_id = ?", productID).
+		Pluck("bundle_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type CategoryGormRepository struct {
+	db *gorm.DB
+}
+
+func NewCategoryGormRepository() domain.CategoryRepository {
+	return &CategoryGormRepository{db: config.DB}
+}
+
+func (r *CategoryGormRepository) Create(ctx context.Context, category *domain.Category) error {
+	return conn(ctx, r.db).Omit("Parent").Create(category).Error
+}
+
+func (r *CategoryGormRepository) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.Category, error) {
+	var category domain.Category
+	err := conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).First(&category).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *CategoryGormRepository) FindBySlugAndUserID(ctx context.Context, slug string, userID uint) (*domain.Category, error) {
+	var category domain.Category
+	err := conn(ctx, r.db).Where("slug = ? AND user_id = ?", slug, userID).First(&category).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *CategoryGormRepository) FindByUserID(ctx context.Context, userID uint) ([]*domain.Category, error) {
+	var categories []*domain.Category
+	err := conn(ctx, r.db).Where("user_id = ?", userID).Order("position ASC, name ASC").Find(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (r *CategoryGormRepository) FindByIDs(ctx context.Context, ids []uint, userID uint) ([]*domain.Category, error) {
+	var categories []*domain.Category
+	err := conn(ctx, r.db).Where("id IN ? AND user_id = ?", ids, userID).Find(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (r *CategoryGormRepository) Update(ctx context.Context, category *domain.Category) error {
+	// Select all columns so that moving a category to the root clears parent_id.
+	return conn(ctx, r.db).Model(&domain.Category{}).
+		Where("id = ? AND user_id = ?", category.ID, category.UserID).
+		Select("*").Omit("id", "user_id", "created_at", "Parent").
+		Updates(category).Error
+}
+
+func (r *CategoryGormRepository) Delete(ctx context.Context, id, userID uint) error {
+	return conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).Delete(&domain.Category{}).Error
+}
+
+func (r *CategoryGormRepository) ReplaceProductCategories(ctx context.Context, product *domain.Product, categories []*domain.Category) error {
+	return conn(ctx, r.db).Model(product).Omit("Categories.*").Association("Categories").Replace(categories)
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CustomerGormRepository struct {
+	db *gorm.DB
+}
+
+func NewCustomerGormRepository() domain.CustomerRepository {
+	return &CustomerGormRepository{db: config.DB}
+}
+
+func (r *CustomerGormRepository) Create(ctx context.Context, customer *domain.Customer) error {
+	return conn(ctx, r.db).Omit(clause.Associations).Create(customer).Error
+}
+
+func (r *CustomerGormRepository) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.Customer, error) {
+	var customer domain.Customer
+	err := conn(ctx, r.db).Preload("Group").Where("id = ? AND user_id = ?", id, userID).First(&customer).Error
+	if err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+func (r *CustomerGormRepository) FindByUserID(ctx context.Context, userID uint) ([]*domain.Customer, error) {
+	var customers []*domain.Customer
+	err := conn(ctx, r.db).Preload("Group").Where("user_id = ?", userID).Order("name ASC, id ASC").Find(&customers).Error
+	if err != nil {
+		return nil, err
+	}
+	return customers, nil
+}
+
+func (r *CustomerGormRepository) Update(ctx context.Context, customer *domain.Customer, userID uint) error {
+	return conn(ctx, r.db).
+		Where("id = ? AND user_id = ?", customer.ID, userID).
+		Omit(clause.Associations).
+		Save(customer).Error
+}
+
+func (r *CustomerGormRepository) Delete(ctx context.Context, id, userID uint) error {
+	return conn(ctx, r.db).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&domain.Customer{}).Error
+}
+
+func (r *CustomerGormRepository) CreateGroup(ctx context.Context, group *domain.CustomerGroup) error {
+	return conn(ctx, r.db).Create(group).Error
+}
+
+func (r *CustomerGormRepository) FindGroupByIDAndUserID(ctx context.Context, id, userID uint) (*domain.CustomerGroup, error) {
+	var group domain.CustomerGroup
+	err := conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).First(&group).Error
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *CustomerGormRepository) FindGroupByNameAndUserID(ctx context.Context, name string, userID uint) (*domain.CustomerGroup, error) {
+	var group domain.CustomerGroup
+	err := conn(ctx, r.db).Where("name = ? AND user_id = ?", name, userID).First(&group).Error
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *CustomerGormRepository) FindGroupsByUserID(ctx context.Context, userID uint) ([]*domain.CustomerGroup, error) {
+	var groups []*domain.CustomerGroup
+	err := conn(ctx, r.db).Where("user_id = ?", userID).Order("name ASC").Find(&groups).Error
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (r *CustomerGormRepository) DeleteGroup(ctx context.Context, id, userID uint) error {
+	return conn(ctx, r.db).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&domain.CustomerGroup{}).Error
+}
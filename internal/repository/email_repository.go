@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EmailLogGormRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailLogGormRepository() domain.EmailLogRepository {
+	return &EmailLogGormRepository{db: config.DB}
+}
+
+func (r *EmailLogGormRepository) Create(ctx context.Context, entry *domain.EmailLog) error {
+	return conn(ctx, r.db).Omit(clause.Associations).Create(entry).Error
+}
+
+func (r *EmailLogGormRepository) FindByEventID(ctx context.Context, eventID uint) (*domain.EmailLog, error) {
+	var entry domain.EmailLog
+	if err := conn(ctx, r.db).Where("event_id = ?", eventID).First(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *EmailLogGormRepository) FindByUserID(ctx context.Context, userID uint, limit int) ([]*domain.EmailLog, error) {
+	var entries []*domain.EmailLog
+	err := conn(ctx, r.db).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+type NotificationPreferencesGormRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationPreferencesGormRepository() domain.NotificationPreferencesRepository {
+	return &NotificationPreferencesGormRepository{db: config.DB}
+}
+
+func (r *NotificationPreferencesGormRepository) FindByUserID(ctx context.Context, userID uint) (*domain.NotificationPreferences, error) {
+	var preferences domain.NotificationPreferences
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).First(&preferences).Error; err != nil {
+		return nil, err
+	}
+	return &preferences, nil
+}
+
+func (r *NotificationPreferencesGormRepository) Save(ctx context.Context, preferences *domain.NotificationPreferences) error {
+	return conn(ctx, r.db).
+		Omit(clause.Associations).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}}, UpdateAll: true}).
+		Create(preferences).Error
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type ImportJobGormRepository struct {
+	db *gorm.DB
+}
+
+func NewImportJobGormRepository() domain.ImportJobRepository {
+	return &ImportJobGormRepository{db: config.DB}
+}
+
+func (r *ImportJobGormRepository) Create(ctx context.Context, job *domain.ImportJob) error {
+	return conn(ctx, r.db).Create(job).Error
+}
+
+func (r *ImportJobGormRepository) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.ImportJob, error) {
+	var job domain.ImportJob
+	err := conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *ImportJobGormRepository) Update(ctx context.Context, job *domain.ImportJob) error {
+	return conn(ctx, r.db).Save(job).Error
+}
+
+func (r *ImportJobGormRepository) CreateErrors(ctx context.Context, errors []domain.ImportJobError) error {
+	if len(errors) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).CreateInBatches(errors, 500).Error
+}
+
+func (r *ImportJobGormRepository) FindErrors(ctx context.Context, jobID uint) ([]domain.ImportJobError, error) {
+	var errors []domain.ImportJobError
+	err := conn(ctx, r.db).Where("job_id = ?", jobID).Order("row ASC, id ASC").Find(&errors).Error
+	if err != nil {
+		return nil, err
+	}
+	return errors, nil
+}
+
+func (r *ImportJobGormRepository) FailStale(ctx context.Context, before time.Time, message string) (int64, error) {
+	now := time.Now()
+	result := conn(ctx, r.db).Model(&domain.ImportJob{}).
+		Where("status IN ? AND updated_at < ?", []domain.ImportJobStatus{domain.ImportJobPending, domain.ImportJobRunning}, before).
+		Updates(map[string]interface{}{
+			"status":      domain.ImportJobFailed,
+			"error":       message,
+			"finished_at": now,
+			"updated_at":  now,
+		})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InventoryMovementGormRepository struct {
+	db *gorm.DB
+}
+
+func NewInventoryMovementGormRepository() domain.InventoryMovementRepository {
+	return &InventoryMovementGormRepository{db: config.DB}
+}
+
+func (r *InventoryMovementGormRepository) Create(ctx context.Context, movement *domain.InventoryMovement) error {
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "event_id"}}, DoNothing: true}).
+		Omit(clause.Associations).
+		Create(movement).Error
+}
+
+// inventoryBalancesQuery sums the ledger of each product up to a time. Under
+// FIFO the oldest stock leaves first, so what is on hand is the newest
+// receipts: each receipt counts for the part of the quantity on hand that
+// the receipts after it do not cover.
+const inventoryBalancesQuery = `
+WITH movements AS (
+	SELECT id, product_id, quantity, unit_cost, occurred_at
+	FROM inventory_movements
+	WHERE user_id = ? AND occurred_at <= ?
+), on_hand AS (
+	SELECT product_id, SUM(quantity) AS quantity
+	FROM movements
+	GROUP BY product_id
+), receipts AS (
+	SELECT product_id, quantity, unit_cost,
+		SUM(quantity) OVER (PARTITION BY product_id ORDER BY occurred_at DESC, id DESC) - quantity AS newer
+	FROM movements
+	WHERE quantity > 0
+)
+SELECT o.product_id, p.code, p.name, o.quantity,
+	COALESCE(SUM(GREATEST(LEAST(r.quantity, o.quantity - r.newer), 0) * r.unit_cost), 0) AS fifo_value,
+	COALESCE(SUM(r.quantity * r.unit_cost) / NULLIF(SUM(r.quantity), 0), 0) AS average_cost
+FROM on_hand o
+JOIN products p ON p.id = o.product_id
+LEFT JOIN receipts r ON r.product_id = o.product_id
+WHERE o.quantity <> 0
+GROUP BY o.product_id, p.code, p.name, o.quantity
+ORDER BY o.product_id`
+
+func (r *InventoryMovementGormRepository) BalancesAt(ctx context.Context, userID uint, t time.Time) ([]domain.InventoryBalance, error) {
+	var balances []domain.InventoryBalance
+	err := conn(ctx, r.db).Raw(inventoryBalancesQuery, userID, t).Scan(&balances).Error
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LotGormRepository struct {
+	db *gorm.DB
+}
+
+func NewLotGormRepository() domain.LotRepository {
+	return &LotGormRepository{db: config.DB}
+}
+
+// fefo orders lots the first to expire first, with lots without an expiry
+// date last.
+const fefo = "expires_at ASC NULLS LAST, id ASC"
+
+func (r *LotGormRepository) Create(ctx context.Context, lot *domain.Lot) error {
+	return conn(ctx, r.db).Omit(clause.Associations).Create(lot).Error
+}
+
+func (r *LotGormRepository) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.Lot, error) {
+	var lot domain.Lot
+	err := conn(ctx, r.db).
+		Preload("Product").
+		Preload("Variant").
+		Where("id = ? AND user_id = ?", id, userID).
+		First(&lot).Error
+	if err != nil {
+		return nil, err
+	}
+	return &lot, nil
+}
+
+func (r *LotGormRepository) FindByNumber(ctx context.Context, productID uint, variantID *uint, number string) (*domain.Lot, error) {
+	var lot domain.Lot
+	err := forVariant(conn(ctx, r.db).Where("product_id = ? AND number = ?", productID, number), variantID).
+		First(&lot).Error
+	if err != nil {
+		return nil, err
+	}
+	return &lot, nil
+}
+
+func (r *LotGormRepository) FindByProductID(ctx context.Context, productID, userID uint) ([]*domain.Lot, error) {
+	var lots []*domain.Lot
+	err := conn(ctx, r.db).
+		Preload("Product").
+		Preload("Variant").
+		Where("product_id = ? AND user_id = ?", productID, userID).
+		Order(fefo).
+		Find(&lots).Error
+	if err != nil {
+		return nil, err
+	}
+	return lots, nil
+}
+
+func (r *LotGormRepository) LockAllocatable(ctx context.Context, productID uint, variantID *uint, now time.Time) ([]*domain.Lot, error) {
+	var lots []*domain.Lot
+	err := forVariant(conn(ctx, r.db).Where("product_id = ?", productID), variantID).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("quantity > 0 AND (expires_at IS NULL OR expires_at > ?)", now).
+		Order(fefo).
+		Find(&lots).Error
+	if err != nil {
+		return nil, err
+	}
+	return lots, nil
+}
+
+func (r *LotGormRepository) FindExpiring(ctx context.Context, userID uint, before time.Time) ([]*domain.Lot, error) {
+	var lots []*domain.Lot
+	err := conn(ctx, r.db).
+		Preload("Product").
+		Preload("Variant").
+		Where("user_id = ? AND quantity > 0 AND expires_at < ?", userID, before).
+		Order(fefo).
+		Find(&lots).Error
+	if err != nil {
+		return nil, err
+	}
+	return lots, nil
+}
+
+func (r *LotGormRepository) Update(ctx context.Context, lot *domain.Lot) error {
+	return conn(ctx, r.db).Omit(clause.Associations).Save(lot).Error
+}
+
+func (r *LotGormRepository) AddQuantity(ctx context.Context, id uint, quantity int) error {
+	return conn(ctx, r.db).Model(&domain.Lot{}).
+		Where("id = ?", id).
+		Update("quantity", gorm.Expr("quantity + ?", quantity)).Error
+}
+
+func forVariant(db *gorm.DB, variantID *uint) *gorm.DB {
+	if variantID == nil {
+		return db.Where("variant_id IS NULL")
+	}
+	return db.Where("variant_id = ?", *variantID)
+}
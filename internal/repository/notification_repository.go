@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type NotificationGormRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationGormRepository() domain.NotificationRepository {
+	return &NotificationGormRepository{db: config.DB}
+}
+
+func (r *NotificationGormRepository) Create(ctx context.Context, notification *domain.Notification) error {
+	return conn(ctx, r.db).Create(notification).Error
+}
+
+func (r *NotificationGormRepository) FindByEventID(ctx context.Context, eventID uint) (*domain.Notification, error) {
+	var notification domain.Notification
+	err := conn(ctx, r.db).Where("event_id = ?", eventID).First(&notification).Error
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+func (r *NotificationGormRepository) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.Notification, error) {
+	var notification domain.Notification
+	err := conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).First(&notification).Error
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+func (r *NotificationGormRepository) FindByUserID(ctx context.Context, userID uint, unreadOnly bool, limit int) ([]*domain.Notification, error) {
+	var notifications []*domain.Notification
+	query := conn(ctx, r.db).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	err := query.Order("id DESC").Limit(limit).Find(&notifications).Error
+	if err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (r *NotificationGormRepository) Update(ctx context.Context, notification *domain.Notification) error {
+	return conn(ctx, r.db).Save(notification).Error
+}
+
+func (r *NotificationGormRepository) MarkAllRead(ctx context.Context, userID uint, readAt time.Time) (int64, error) {
+	result := conn(ctx, r.db).Model(&domain.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", readAt)
+	return result.RowsAffected, result.Error
+}
@@ -17,13 +17,20 @@ func NewOrderGormRepository() domain.OrderRepository {
 }
 
 func (r *OrderGormRepository) Create(ctx context.Context, order *domain.Order) error {
-	return r.db.WithContext(ctx).Create(order).Error
+	return conn(ctx, r.db).Create(order).Error
 }
 
 func (r *OrderGormRepository) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.Order, error) {
 	var order domain.Order
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Preload("Items.Product").
+		Preload("Items.Variant").
+		Preload("Items.Lots.Lot").
+		Preload("Items.Serials", "status = ?", domain.SerialStatusAssigned).
+		Preload("Items.Components.Product").
+		Preload("Items.Components.Variant").
+		Preload("Items.PriceList").
+		Preload("Customer").
 		Preload("User").
 		Where("id = ? AND user_id = ?", id, userID).
 		First(&order).Error
@@ -35,8 +42,15 @@ func (r *OrderGormRepository) FindByIDAndUserID(ctx context.Context, id, userID
 
 func (r *OrderGormRepository) FindByUserID(ctx context.Context, userID uint) ([]*domain.Order, error) {
 	var orders []*domain.Order
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Preload("Items.Product").
+		Preload("Items.Variant").
+		Preload("Items.Lots.Lot").
+		Preload("Items.Serials", "status = ?", domain.SerialStatusAssigned).
+		Preload("Items.Components.Product").
+		Preload("Items.Components.Variant").
+		Preload("Items.PriceList").
+		Preload("Customer").
 		Preload("User").
 		Where("user_id = ?", userID).
 		Order("created_at DESC").
@@ -47,14 +61,54 @@ func (r *OrderGormRepository) FindByUserID(ctx context.Context, userID uint) ([]
 	return orders, nil
 }
 
+func (r *OrderGormRepository) FindByFilter(ctx context.Context, userID uint, filter domain.OrderFilter) ([]*domain.Order, error) {
+	var orders []*domain.Order
+	err := r.filter(ctx, userID, filter).Preload("User").Order("created_at DESC").Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (r *OrderGormRepository) FindInBatches(ctx context.Context, userID uint, filter domain.OrderFilter, batchSize int, fn func([]*domain.Order) error) error {
+	var orders []*domain.Order
+	return r.filter(ctx, userID, filter).FindInBatches(&orders, batchSize, func(*gorm.DB, int) error {
+		return fn(orders)
+	}).Error
+}
+
+func (r *OrderGormRepository) filter(ctx context.Context, userID uint, filter domain.OrderFilter) *gorm.DB {
+	query := conn(ctx, r.db).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Items.Product").
+		Preload("Items.Variant").
+		Preload("Items.Lots.Lot").
+		Preload("Items.Serials", "status = ?", domain.SerialStatusAssigned).
+		Preload("Items.Components.Product").
+		Preload("Items.Components.Variant").
+		Preload("Items.PriceList").
+		Preload("Customer").
+		Where("user_id = ?", userID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	return query
+}
+
 func (r *OrderGormRepository) Update(ctx context.Context, order *domain.Order, userID uint) error {
-	return r.db.WithContext(ctx).
+	return conn(ctx, r.db).
 		Where("id = ? AND user_id = ?", order.ID, userID).
 		Save(order).Error
 }
 
 func (r *OrderGormRepository) Delete(ctx context.Context, id, userID uint) error {
-	return r.db.WithContext(ctx).
+	return conn(ctx, r.db).
 		Where("id = ? AND user_id = ?", id, userID).
 		Delete(&domain.Order{}).Error
 }
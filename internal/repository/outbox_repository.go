@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type OutboxGormRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxGormRepository() domain.OutboxRepository {
+	return &OutboxGormRepository{db: config.DB}
+}
+
+func (r *OutboxGormRepository) Create(ctx context.Context, message *domain.OutboxMessage) error {
+	return conn(ctx, r.db).Create(message).Error
+}
+
+// FindPending returns unrelayed messages in the order they were recorded.
+func (r *OutboxGormRepository) FindPending(ctx context.Context, limit int) ([]*domain.OutboxMessage, error) {
+	var messages []*domain.OutboxMessage
+	err := conn(ctx, r.db).
+		Where("status = ?", domain.OutboxPending).
+		Order("id ASC").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *OutboxGormRepository) Update(ctx context.Context, message *domain.OutboxMessage) error {
+	return conn(ctx, r.db).Save(message).Error
+}
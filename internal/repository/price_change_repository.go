@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PriceChangeGormRepository struct {
+	db *gorm.DB
+}
+
+func NewPriceChangeGormRepository() domain.PriceChangeRepository {
+	return &PriceChangeGormRepository{db: config.DB}
+}
+
+func (r *PriceChangeGormRepository) Create(ctx context.Context, change *domain.PriceChange) error {
+	return conn(ctx, r.db).Omit(clause.Associations).Create(change).Error
+}
+
+func (r *PriceChangeGormRepository) FindByProductID(ctx context.Context, productID, userID uint) ([]*domain.PriceChange, error) {
+	var changes []*domain.PriceChange
+	err := conn(ctx, r.db).
+		Where("product_id = ? AND user_id = ?", productID, userID).
+		Order("changed_at DESC, id DESC").
+		Find(&changes).Error
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (r *PriceChangeGormRepository) CreateScheduled(ctx context.Context, change *domain.ScheduledPriceChange) error {
+	return conn(ctx, r.db).Omit(clause.Associations).Create(change).Error
+}
+
+func (r *PriceChangeGormRepository) FindScheduledByIDAndUserID(ctx context.Context, id, userID uint) (*domain.ScheduledPriceChange, error) {
+	var change domain.ScheduledPriceChange
+	err := conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).First(&change).Error
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+func (r *PriceChangeGormRepository) FindScheduledByProductID(ctx context.Context, productID, userID uint, status domain.ScheduledPriceStatus) ([]*domain.ScheduledPriceChange, error) {
+	query := conn(ctx, r.db).Where("product_id = ? AND user_id = ?", productID, userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var changes []*domain.ScheduledPriceChange
+	if err := query.Order("effective_at ASC, id ASC").Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (r *PriceChangeGormRepository) LockDueScheduled(ctx context.Context, t time.Time, limit int) ([]*domain.ScheduledPriceChange, error) {
+	var changes []*domain.ScheduledPriceChange
+	err := conn(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND effective_at <= ?", domain.ScheduledPricePending, t).
+		Order("effective_at ASC, id ASC").
+		Limit(limit).
+		Find(&changes).Error
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (r *PriceChangeGormRepository) UpdateScheduled(ctx context.Context, change *domain.ScheduledPriceChange) error {
+	return conn(ctx, r.db).Omit(clause.Associations).Save(change).Error
+}
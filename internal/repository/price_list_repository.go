@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PriceListGormRepository struct {
+	db *gorm.DB
+}
+
+func NewPriceListGormRepository() domain.PriceListRepository {
+	return &PriceListGormRepository{db: config.DB}
+}
+
+func withPriceListDetails(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Prices", func(db *gorm.DB) *gorm.DB {
+			return db.Order("product_id ASC, variant_id ASC NULLS FIRST, min_quantity ASC")
+		}).
+		Preload("Customers", func(db *gorm.DB) *gorm.DB { return db.Order("name ASC") }).
+		Preload("Groups", func(db *gorm.DB) *gorm.DB { return db.Order("name ASC") })
+}
+
+func (r *PriceListGormRepository) Create(ctx context.Context, list *domain.PriceList) error {
+	return conn(ctx, r.db).Omit("Customers.*", "Groups.*").Create(list).Error
+}
+
+func (r *PriceListGormRepository) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.PriceList, error) {
+	var list domain.PriceList
+	err := withPriceListDetails(conn(ctx, r.db)).Where("id = ? AND user_id = ?", id, userID).First(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (r *PriceListGormRepository) FindByNameAndUserID(ctx context.Context, name string, userID uint) (*domain.PriceList, error) {
+	var list domain.PriceList
+	err := conn(ctx, r.db).Where("name = ? AND user_id = ?", name, userID).First(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (r *PriceListGormRepository) FindByUserID(ctx context.Context, userID uint) ([]*domain.PriceList, error) {
+	var lists []*domain.PriceList
+	err := withPriceListDetails(conn(ctx, r.db)).Where("user_id = ?", userID).Order("name ASC").Find(&lists).Error
+	if err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+func (r *PriceListGormRepository) Update(ctx context.Context, list *domain.PriceList, userID uint) error {
+	db := conn(ctx, r.db)
+	err := db.Model(&domain.PriceList{}).
+		Where("id = ? AND user_id = ?", list.ID, userID).
+		Select("name", "valid_from", "valid_until").
+		Updates(list).Error
+	if err != nil {
+		return err
+	}
+	if err := db.Where("price_list_id = ?", list.ID).Delete(&domain.PriceListPrice{}).Error; err != nil {
+		return err
+	}
+	if len(list.Prices) > 0 {
+		for i := range list.Prices {
+			list.Prices[i].ID, list.Prices[i].PriceListID = 0, list.ID
+		}
+		if err := db.Omit(clause.Associations).Create(&list.Prices).Error; err != nil {
+			return err
+		}
+	}
+	if err := db.Model(list).Omit("Customers.*").Association("Customers").Replace(list.Customers); err != nil {
+		return err
+	}
+	return db.Model(list).Omit("Groups.*").Association("Groups").Replace(list.Groups)
+}
+
+func (r *PriceListGormRepository) Delete(ctx context.Context, id, userID uint) error {
+	return conn(ctx, r.db).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&domain.PriceList{}).Error
+}
+
+func (r *PriceListGormRepository) FindApplicable(ctx context.Context, userID uint, customerID, groupID *uint, productID uint, t time.Time) ([]*domain.PriceList, error) {
+	// Lists assigned to no one apply to every order.
+	audience := "NOT EXISTS (SELECT 1 FROM price_list_customers WHERE price_list_id = price_lists.id)" +
+		" AND NOT EXISTS (SELECT 1 FROM price_list_customer_groups WHERE price_list_id = price_lists.id)"
+	var args []interface{}
+	if customerID != nil {
+		audience += " OR EXISTS (SELECT 1 FROM price_list_customers WHERE price_list_id = price_lists.id AND customer_id = ?)"
+		args = append(args, *customerID)
+	}
+	if groupID != nil {
+		audience += " OR EXISTS (SELECT 1 FROM price_list_customer_groups WHERE price_list_id = price_lists.id AND customer_group_id = ?)"
+		args = append(args, *groupID)
+	}
+
+	var lists []*domain.PriceList
+	err := conn(ctx, r.db).
+		Preload("Prices", "product_id = ?", productID).
+		Where("user_id = ?", userID).
+		Where("valid_from IS NULL OR valid_from <= ?", t).
+		Where("valid_until IS NULL OR valid_until > ?", t).
+		Where("("+audience+")", args...).
+		Where("EXISTS (SELECT 1 FROM price_list_prices WHERE price_list_id = price_lists.id AND product_id = ?)", productID).
+		Order("id ASC").
+		Find(&lists).Error
+	if err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
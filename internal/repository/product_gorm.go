@@ -4,6 +4,9 @@ import (
 	"context"
 	"vertice-backend/config"
 	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductGormRepository struct{}
@@ -13,12 +16,28 @@ func NewProductGormRepository() *ProductGormRepository {
 }
 
 func (r *ProductGormRepository) Create(ctx context.Context, product *domain.Product) error {
-	return config.DB.WithContext(ctx).Create(product).Error
+	return conn(ctx, config.DB).Omit(clause.Associations).Create(product).Error
+}
+
+// withVariants loads the variants of the products with their option values,
+// their bundle components, and their categories, tags and images.
+func withVariants(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Images", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC, id ASC") }).
+		Preload("Categories", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC, name ASC") }).
+		Preload("Tags", func(db *gorm.DB) *gorm.DB { return db.Order("name ASC") }).
+		Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Variants.Options", func(db *gorm.DB) *gorm.DB { return db.Order("option_type_id ASC") }).
+		Preload("Variants.Options.OptionType").
+		Preload("Variants.Options.OptionValue").
+		Preload("Components", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Components.Component").
+		Preload("Components.Variant")
 }
 
 func (r *ProductGormRepository) FindByIDAndUserID(ctx context.Context, id uint, userID uint) (*domain.Product, error) {
 	var product domain.Product
-	err := config.DB.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&product).Error
+	err := withVariants(conn(ctx, config.DB)).Where("id = ? AND user_id = ?", id, userID).First(&product).Error
 	if err != nil {
 		return nil, err
 	}
@@ -27,28 +46,83 @@ func (r *ProductGormRepository) FindByIDAndUserID(ctx context.Context, id uint,
 
 func (r *ProductGormRepository) FindByUserID(ctx context.Context, userID uint) ([]*domain.Product, error) {
 	var products []*domain.Product
-	err := config.DB.WithContext(ctx).Where("user_id = ?", userID).Find(&products).Error
+	err := withVariants(conn(ctx, config.DB)).Where("user_id = ?", userID).Find(&products).Error
 	if err != nil {
 		return nil, err
 	}
 	return products, nil
 }
 
+func (r *ProductGormRepository) FindByFilter(ctx context.Context, userID uint, filter domain.ProductFilter) ([]*domain.Product, error) {
+	var products []*domain.Product
+	if err := filterProducts(ctx, userID, filter).Order("id ASC").Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (r *ProductGormRepository) FindInBatches(ctx context.Context, userID uint, filter domain.ProductFilter, batchSize int, fn func([]*domain.Product) error) error {
+	var products []*domain.Product
+	return filterProducts(ctx, userID, filter).FindInBatches(&products, batchSize, func(*gorm.DB, int) error {
+		return fn(products)
+	}).Error
+}
+
+func filterProducts(ctx context.Context, userID uint, filter domain.ProductFilter) *gorm.DB {
+	query := withVariants(conn(ctx, config.DB)).Where("user_id = ?", userID)
+	if len(filter.CategoryIDs) > 0 {
+		query = query.Where("id IN (?)", conn(ctx, config.DB).Table("product_categories").
+			Select("product_id").Where("category_id IN ?", filter.CategoryIDs))
+	}
+	if filter.Tag != "" {
+		query = query.Where("id IN (?)", conn(ctx, config.DB).Table("product_tags").
+			Select("product_tags.product_id").
+			Joins("JOIN tags ON tags.id = product_tags.tag_id").
+			Where("tags.name = ? AND tags.user_id = ?", filter.Tag, userID))
+	}
+	return query
+}
+
 func (r *ProductGormRepository) FindByCodeAndUserID(ctx context.Context, code string, userID uint) (*domain.Product, error) {
 	var product domain.Product
-	err := config.DB.WithContext(ctx).Where("code = ? AND user_id = ?", code, userID).First(&product).Error
+	err := withVariants(conn(ctx, config.DB)).Where("code = ? AND user_id = ?", code, userID).First(&product).Error
 	if err != nil {
 		return nil, err
 	}
 	return &product, nil
 }
 
+func (r *ProductGormRepository) FindLowStockByUserID(ctx context.Context, userID uint) ([]*domain.Product, error) {
+	var products []*domain.Product
+	err := withVariants(conn(ctx, config.DB)).
+		Where("user_id = ? AND stock <= reorder_point", userID).
+		// Bundles have no stock of their own.
+		Where("NOT EXISTS (SELECT 1 FROM bundle_components WHERE bundle_components.bundle_id = products.id)").
+		Order("stock - reorder_point ASC, id ASC").
+		Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
 func (r *ProductGormRepository) Update(ctx context.Context, product *domain.Product, userID uint) error {
-	return config.DB.WithContext(ctx).Model(&domain.Product{}).
+	// Select all columns so zero values such as an emptied stock are persisted.
+	return conn(ctx, config.DB).Model(&domain.Product{}).
 		Where("id = ? AND user_id = ?", product.ID, userID).
+		Select("*").Omit("id", "user_id", "created_at", clause.Associations).
 		Updates(product).Error
 }
 
 func (r *ProductGormRepository) Delete(ctx context.Context, id uint, userID uint) error {
-	return config.DB.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&domain.Product{}).Error
+	return conn(ctx, config.DB).Where("id = ? AND user_id = ?", id, userID).Delete(&domain.Product{}).Error
+}
+
+func (r *ProductGormRepository) LockByIDs(ctx context.Context, ids []uint, userID uint) error {
+	var locked []uint
+	return conn(ctx, config.DB).Model(&domain.Product{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ? AND user_id = ?", ids, userID).
+		Order("id ASC").
+		Pluck("id", &locked).Error
 }
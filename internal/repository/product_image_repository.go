@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type ProductImageGormRepository struct {
+	db *gorm.DB
+}
+
+func NewProductImageGormRepository() domain.ProductImageRepository {
+	return &ProductImageGormRepository{db: config.DB}
+}
+
+func (r *ProductImageGormRepository) Create(ctx context.Context, image *domain.ProductImage) error {
+	return conn(ctx, r.db).Omit("Product").Create(image).Error
+}
+
+func (r *ProductImageGormRepository) FindByProductID(ctx context.Context, productID, userID uint) ([]*domain.ProductImage, error) {
+	var images []*domain.ProductImage
+	err := conn(ctx, r.db).
+		Where("product_id = ? AND user_id = ?", productID, userID).
+		Order("position ASC, id ASC").
+		Find(&images).Error
+	if err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+func (r *ProductImageGormRepository) UpdateOrder(ctx context.Context, images []*domain.ProductImage) error {
+	db := conn(ctx, r.db)
+	for _, image := range images {
+		err := db.Model(&domain.ProductImage{}).
+			Where("id = ? AND user_id = ?", image.ID, image.UserID).
+			Select("position", "primary").
+			Updates(map[string]interface{}{"position": image.Position, "primary": image.Primary}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ProductImageGormRepository) Delete(ctx context.Context, id, userID uint) error {
+	return conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).Delete(&domain.ProductImage{}).Error
+}
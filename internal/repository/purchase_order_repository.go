@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PurchaseOrderGormRepository struct {
+	db *gorm.DB
+}
+
+func NewPurchaseOrderGormRepository() domain.PurchaseOrderRepository {
+	return &PurchaseOrderGormRepository{db: config.DB}
+}
+
+func (r *PurchaseOrderGormRepository) Create(ctx context.Context, order *domain.PurchaseOrder) error {
+	return conn(ctx, r.db).Omit("Lines.Product").Create(order).Error
+}
+
+func (r *PurchaseOrderGormRepository) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.PurchaseOrder, error) {
+	var order domain.PurchaseOrder
+	err := conn(ctx, r.db).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Lines.Product").
+		Preload("Receipts.Lines").
+		Where("id = ? AND user_id = ?", id, userID).
+		First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *PurchaseOrderGormRepository) FindByUserID(ctx context.Context, userID uint, status domain.PurchaseOrderStatus) ([]*domain.PurchaseOrder, error) {
+	var orders []*domain.PurchaseOrder
+	query := conn(ctx, r.db).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Lines.Product").
+		Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at DESC").Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (r *PurchaseOrderGormRepository) CountBySupplierID(ctx context.Context, supplierID, userID uint) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).Model(&domain.PurchaseOrder{}).
+		Where("supplier_id = ? AND user_id = ?", supplierID, userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *PurchaseOrderGormRepository) Update(ctx context.Context, order *domain.PurchaseOrder, userID uint) error {
+	db := conn(ctx, r.db)
+	if err := db.Where("id = ? AND user_id = ?", order.ID, userID).Omit(clause.Associations).Save(order).Error; err != nil {
+		return err
+	}
+
+	keep := make([]uint, 0, len(order.Lines))
+	for i := range order.Lines {
+		line := &order.Lines[i]
+		line.PurchaseOrderID = order.ID
+		if err := db.Omit(clause.Associations).Save(line).Error; err != nil {
+			return err
+		}
+		keep = append(keep, line.ID)
+	}
+
+	stale := db.Where("purchase_order_id = ?", order.ID)
+	if len(keep) > 0 {
+		stale = stale.Where("id NOT IN ?", keep)
+	}
+	return stale.Delete(&domain.PurchaseOrderLine{}).Error
+}
+
+func (r *PurchaseOrderGormRepository) Delete(ctx context.Context, id, userID uint) error {
+	db := conn(ctx, r.db)
+	if err := db.Where("purchase_order_id = ?", id).Delete(&domain.PurchaseOrderLine{}).Error; err != nil {
+		return err
+	}
+	return db.Where("id = ? AND user_id = ?", id, userID).Delete(&domain.PurchaseOrder{}).Error
+}
+
+func (r *PurchaseOrderGormRepository) CreateReceipt(ctx context.Context, receipt *domain.PurchaseOrderReceipt) error {
+	return conn(ctx, r.db).Create(receipt).Error
+}
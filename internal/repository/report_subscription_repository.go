@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReportSubscriptionGormRepository struct {
+	db *gorm.DB
+}
+
+func NewReportSubscriptionGormRepository() domain.ReportSubscriptionRepository {
+	return &ReportSubscriptionGormRepository{db: config.DB}
+}
+
+func (r *ReportSubscriptionGormRepository) Create(ctx context.Context, subscription *domain.ReportSubscription) error {
+	return conn(ctx, r.db).Omit(clause.Associations).Create(subscription).Error
+}
+
+func (r *ReportSubscriptionGormRepository) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.ReportSubscription, error) {
+	var subscription domain.ReportSubscription
+	err := conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).First(&subscription).Error
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *ReportSubscriptionGormRepository) FindByUserID(ctx context.Context, userID uint) ([]*domain.ReportSubscription, error) {
+	var subscriptions []*domain.ReportSubscription
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).Order("id ASC").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *ReportSubscriptionGormRepository) Update(ctx context.Context, subscription *domain.ReportSubscription) error {
+	return conn(ctx, r.db).Omit(clause.Associations).Save(subscription).Error
+}
+
+func (r *ReportSubscriptionGormRepository) UpdateLastError(ctx context.Context, id uint, lastError string) error {
+	return conn(ctx, r.db).Model(&domain.ReportSubscription{}).Where("id = ?", id).Update("last_error", lastError).Error
+}
+
+func (r *ReportSubscriptionGormRepository) Delete(ctx context.Context, id, userID uint) error {
+	return conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).Delete(&domain.ReportSubscription{}).Error
+}
+
+func (r *ReportSubscriptionGormRepository) LockDue(ctx context.Context, t time.Time, limit int) ([]*domain.ReportSubscription, error) {
+	var subscriptions []*domain.ReportSubscription
+	err := conn(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("enabled = ? AND next_run_at <= ?", true, t).
+		Order("next_run_at ASC, id ASC").
+		Limit(limit).
+		Find(&subscriptions).Error
+	if err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SerialGormRepository struct {
+	db *gorm.DB
+}
+
+func NewSerialGormRepository() domain.SerialRepository {
+	return &SerialGormRepository{db: config.DB}
+}
+
+func (r *SerialGormRepository) Create(ctx context.Context, serials []*domain.Serial) error {
+	return conn(ctx, r.db).Omit(clause.Associations).CreateInBatches(serials, 500).Error
+}
+
+func (r *SerialGormRepository) FindByNumber(ctx context.Context, number string, userID uint) (*domain.Serial, error) {
+	var serial domain.Serial
+	err := conn(ctx, r.db).
+		Preload("Product").
+		Preload("Variant").
+		Preload("OrderItem.Order").
+		Where("number = ? AND user_id = ?", number, userID).
+		First(&serial).Error
+	if err != nil {
+		return nil, err
+	}
+	return &serial, nil
+}
+
+func (r *SerialGormRepository) LockByNumbers(ctx context.Context, numbers []string, userID uint) ([]*domain.Serial, error) {
+	var serials []*domain.Serial
+	err := conn(ctx, r.db).
+		Preload("OrderItem.Order").
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("number IN ? AND user_id = ?", numbers, userID).
+		Find(&serials).Error
+	if err != nil {
+		return nil, err
+	}
+	return serials, nil
+}
+
+func (r *SerialGormRepository) FindByProductID(ctx context.Context, productID, userID uint, status domain.SerialStatus) ([]*domain.Serial, error) {
+	var serials []*domain.Serial
+	query := conn(ctx, r.db).
+		Preload("Variant").
+		Where("product_id = ? AND user_id = ?", productID, userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("number ASC").Find(&serials).Error; err != nil {
+		return nil, err
+	}
+	return serials, nil
+}
+
+func (r *SerialGormRepository) Update(ctx context.Context, serial *domain.Serial) error {
+	return conn(ctx, r.db).Omit(clause.Associations).Save(serial).Error
+}
+
+func (r *SerialGormRepository) Unassign(ctx context.Context, orderItemIDs []uint) error {
+	if len(orderItemIDs) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Model(&domain.Serial{}).
+		Where("order_item_id IN ? AND status = ?", orderItemIDs, domain.SerialStatusAssigned).
+		Updates(map[string]interface{}{"status": domain.SerialStatusInStock, "order_item_id": nil}).Error
+}
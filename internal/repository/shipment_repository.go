@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type ShipmentGormRepository struct {
+	db *gorm.DB
+}
+
+func NewShipmentGormRepository() domain.ShipmentRepository {
+	return &ShipmentGormRepository{db: config.DB}
+}
+
+func (r *ShipmentGormRepository) Create(ctx context.Context, shipment *domain.Shipment) error {
+	return conn(ctx, r.db).Create(shipment).Error
+}
+
+func (r *ShipmentGormRepository) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.Shipment, error) {
+	var shipment domain.Shipment
+	err := conn(ctx, r.db).
+		Preload("Items").
+		Where("id = ? AND user_id = ?", id, userID).
+		First(&shipment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &shipment, nil
+}
+
+func (r *ShipmentGormRepository) FindByOrderID(ctx context.Context, orderID, userID uint) ([]*domain.Shipment, error) {
+	var shipments []*domain.Shipment
+	err := conn(ctx, r.db).
+		Preload("Items").
+		Where("order_id = ? AND user_id = ?", orderID, userID).
+		Order("created_at ASC").
+		Find(&shipments).Error
+	if err != nil {
+		return nil, err
+	}
+	return shipments, nil
+}
+
+func (r *ShipmentGormRepository) Update(ctx context.Context, shipment *domain.Shipment, userID uint) error {
+	return conn(ctx, r.db).
+		Where("id = ? AND user_id = ?", shipment.ID, userID).
+		Omit("Items").
+		Save(shipment).Error
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StocktakeGormRepository struct {
+	db *gorm.DB
+}
+
+func NewStocktakeGormRepository() domain.StocktakeRepository {
+	return &StocktakeGormRepository{db: config.DB}
+}
+
+func (r *StocktakeGormRepository) Create(ctx context.Context, stocktake *domain.Stocktake) error {
+	return conn(ctx, r.db).Omit("Lines.Product", "Lines.Variant").Create(stocktake).Error
+}
+
+func withLines(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Lines.Product").
+		Preload("Lines.Variant")
+}
+
+func (r *StocktakeGormRepository) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.Stocktake, error) {
+	var stocktake domain.Stocktake
+	err := withLines(conn(ctx, r.db)).Where("id = ? AND user_id = ?", id, userID).First(&stocktake).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stocktake, nil
+}
+
+func (r *StocktakeGormRepository) LockByIDAndUserID(ctx context.Context, id, userID uint) (*domain.Stocktake, error) {
+	var stocktake domain.Stocktake
+	err := withLines(conn(ctx, r.db)).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND user_id = ?", id, userID).
+		First(&stocktake).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stocktake, nil
+}
+
+func (r *StocktakeGormRepository) FindByUserID(ctx context.Context, userID uint, status domain.StocktakeStatus) ([]*domain.Stocktake, error) {
+	var stocktakes []*domain.Stocktake
+	query := withLines(conn(ctx, r.db)).Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("created_at DESC").Find(&stocktakes).Error; err != nil {
+		return nil, err
+	}
+	return stocktakes, nil
+}
+
+func (r *StocktakeGormRepository) FindOpenProductIDs(ctx context.Context, userID uint) ([]uint, error) {
+	var ids []uint
+	err := conn(ctx, r.db).Model(&domain.StocktakeLine{}).
+		Distinct("stocktake_lines.product_id").
+		Joins("JOIN stocktakes ON stocktakes.id = stocktake_lines.stocktake_id").
+		Where("stocktakes.user_id = ? AND stocktakes.status = ?", userID, domain.StocktakeStatusOpen).
+		Pluck("stocktake_lines.product_id", &ids).Error
+	return ids, err
+}
+
+func (r *StocktakeGormRepository) Update(ctx context.Context, stocktake *domain.Stocktake, lines []*domain.StocktakeLine) error {
+	db := conn(ctx, r.db)
+	if err := db.Omit(clause.Associations).Save(stocktake).Error; err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if err := db.Omit(clause.Associations).Save(line).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type SupplierGormRepository struct {
+	db *gorm.DB
+}
+
+func NewSupplierGormRepository() domain.SupplierRepository {
+	return &SupplierGormRepository{db: config.DB}
+}
+
+func (r *SupplierGormRepository) Create(ctx context.Context, supplier *domain.Supplier) error {
+	return conn(ctx, r.db).Create(supplier).Error
+}
+
+func (r *SupplierGormRepository) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.Supplier, error) {
+	var supplier domain.Supplier
+	err := conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).First(&supplier).Error
+	if err != nil {
+		return nil, err
+	}
+	return &supplier, nil
+}
+
+func (r *SupplierGormRepository) FindByUserID(ctx context.Context, userID uint) ([]*domain.Supplier, error) {
+	var suppliers []*domain.Supplier
+	err := conn(ctx, r.db).Where("user_id = ?", userID).Order("name ASC").Find(&suppliers).Error
+	if err != nil {
+		return nil, err
+	}
+	return suppliers, nil
+}
+
+func (r *SupplierGormRepository) FindByNameAndUserID(ctx context.Context, name string, userID uint) (*domain.Supplier, error) {
+	var supplier domain.Supplier
+	err := conn(ctx, r.db).Where("name = ? AND user_id = ?", name, userID).First(&supplier).Error
+	if err != nil {
+		return nil, err
+	}
+	return &supplier, nil
+}
+
+func (r *SupplierGormRepository) Update(ctx context.Context, supplier *domain.Supplier, userID uint) error {
+	return conn(ctx, r.db).
+		Where("id = ? AND user_id = ?", supplier.ID, userID).
+		Save(supplier).Error
+}
+
+func (r *SupplierGormRepository) Delete(ctx context.Context, id, userID uint) error {
+	return conn(ctx, r.db).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&domain.Supplier{}).Error
+}
+
+func (r *SupplierGormRepository) FindProducts(ctx context.Context, supplierID, userID uint) ([]*domain.SupplierProduct, error) {
+	var links []*domain.SupplierProduct
+	err := conn(ctx, r.db).
+		Where("supplier_id = ? AND user_id = ?", supplierID, userID).
+		Order("product_id ASC").
+		Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (r *SupplierGormRepository) FindProduct(ctx context.Context, supplierID, productID, userID uint) (*domain.SupplierProduct, error) {
+	var link domain.SupplierProduct
+	err := conn(ctx, r.db).
+		Where("supplier_id = ? AND product_id = ? AND user_id = ?", supplierID, productID, userID).
+		First(&link).Error
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *SupplierGormRepository) SaveProduct(ctx context.Context, link *domain.SupplierProduct) error {
+	return conn(ctx, r.db).Omit("Supplier", "Product").Save(link).Error
+}
+
+func (r *SupplierGormRepository) DeleteProduct(ctx context.Context, supplierID, productID, userID uint) error {
+	return conn(ctx, r.db).
+		Where("supplier_id = ? AND product_id = ? AND user_id = ?", supplierID, productID, userID).
+		Delete(&domain.SupplierProduct{}).Error
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TagGormRepository struct {
+	db *gorm.DB
+}
+
+func NewTagGormRepository() domain.TagRepository {
+	return &TagGormRepository{db: config.DB}
+}
+
+func (r *TagGormRepository) FindByUserID(ctx context.Context, userID uint) ([]*domain.TagUsage, error) {
+	var tags []*domain.TagUsage
+	err := conn(ctx, r.db).Model(&domain.Tag{}).
+		Select("tags.*, COUNT(product_tags.product_id) AS products").
+		Joins("LEFT JOIN product_tags ON product_tags.tag_id = tags.id").
+		Where("tags.user_id = ?", userID).
+		Group("tags.id").
+		Order("tags.name ASC").
+		Scan(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (r *TagGormRepository) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.Tag, error) {
+	var tag domain.Tag
+	err := conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).First(&tag).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *TagGormRepository) FindOrCreate(ctx context.Context, userID uint, names []string) ([]*domain.Tag, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	db := conn(ctx, r.db)
+	tags := make([]*domain.Tag, len(names))
+	for i, name := range names {
+		tags[i] = &domain.Tag{UserID: userID, Name: name}
+	}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+		return nil, err
+	}
+	var existing []*domain.Tag
+	err := db.Where("user_id = ? AND name IN ?", userID, names).Order("name ASC").Find(&existing).Error
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+func (r *TagGormRepository) Delete(ctx context.Context, id, userID uint) error {
+	return conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).Delete(&domain.Tag{}).Error
+}
+
+func (r *TagGormRepository) ReplaceProductTags(ctx context.Context, product *domain.Product, tags []*domain.Tag) error {
+	return conn(ctx, r.db).Model(product).Omit("Tags.*").Association("Tags").Replace(tags)
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"vertice-backend/config"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+// conn returns the transaction bound to ctx by GormTransactor, falling back to
+// db when the call is not part of a transaction.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+type GormTransactor struct {
+	db *gorm.DB
+}
+
+func NewGormTransactor() *GormTransactor {
+	return &GormTransactor{db: config.DB}
+}
+
+// WithinTransaction runs fn in a database transaction that every repository
+// picks up from the context. Nested calls join the outer transaction.
+func (t *GormTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
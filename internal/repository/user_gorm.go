@@ -13,12 +13,14 @@ func NewUserGormRepository() *UserGormRepository {
 }
 
 func (r *UserGormRepository) Create(ctx context.Context, user *domain.User) error {
-	return config.DB.WithContext(ctx).Create(user).Error
+	return conn(ctx, config.DB).Create(user).Error
 }
 
+// FindByEmail ignores case, as accounts registered before addresses were
+// lower cased may have kept capitals.
 func (r *UserGormRepository) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
 	var user domain.User
-	err := config.DB.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	err := conn(ctx, config.DB).Where("LOWER(email) = LOWER(?)", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -27,9 +29,13 @@ func (r *UserGormRepository) FindByEmail(ctx context.Context, email string) (*do
 
 func (r *UserGormRepository) FindByID(ctx context.Context, id uint) (*domain.User, error) {
 	var user domain.User
-	err := config.DB.WithContext(ctx).First(&user, id).Error
+	err := conn(ctx, config.DB).First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
+
+func (r *UserGormRepository) Update(ctx context.Context, user *domain.User) error {
+	return conn(ctx, config.DB).Save(user).Error
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserTokenGormRepository struct {
+	db *gorm.DB
+}
+
+func NewUserTokenGormRepository() domain.UserTokenRepository {
+	return &UserTokenGormRepository{db: config.DB}
+}
+
+func (r *UserTokenGormRepository) Create(ctx context.Context, token *domain.UserToken) error {
+	return conn(ctx, r.db).Omit(clause.Associations).Create(token).Error
+}
+
+func (r *UserTokenGormRepository) LockByHash(ctx context.Context, hash string) (*domain.UserToken, error) {
+	var token domain.UserToken
+	err := conn(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", hash).
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *UserTokenGormRepository) Update(ctx context.Context, token *domain.UserToken) error {
+	return conn(ctx, r.db).Omit(clause.Associations).Save(token).Error
+}
+
+func (r *UserTokenGormRepository) InvalidateByUserID(ctx context.Context, userID uint, purpose domain.UserTokenPurpose, t time.Time) error {
+	return conn(ctx, r.db).
+		Model(&domain.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", t).Error
+}
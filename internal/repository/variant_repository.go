@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type VariantGormRepository struct {
+	db *gorm.DB
+}
+
+func NewVariantGormRepository() domain.VariantRepository {
+	return &VariantGormRepository{db: config.DB}
+}
+
+func orderedValues(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC, id ASC")
+}
+
+func (r *VariantGormRepository) CreateOptionType(ctx context.Context, optionType *domain.OptionType) error {
+	return conn(ctx, r.db).Create(optionType).Error
+}
+
+func (r *VariantGormRepository) FindOptionTypeByIDAndUserID(ctx context.Context, id, userID uint) (*domain.OptionType, error) {
+	var optionType domain.OptionType
+	err := conn(ctx, r.db).
+		Preload("Values", orderedValues).
+		Where("id = ? AND user_id = ?", id, userID).
+		First(&optionType).Error
+	if err != nil {
+		return nil, err
+	}
+	return &optionType, nil
+}
+
+func (r *VariantGormRepository) FindOptionTypeByNameAndUserID(ctx context.Context, name string, userID uint) (*domain.OptionType, error) {
+	var optionType domain.OptionType
+	err := conn(ctx, r.db).Where("name = ? AND user_id = ?", name, userID).First(&optionType).Error
+	if err != nil {
+		return nil, err
+	}
+	return &optionType, nil
+}
+
+func (r *VariantGormRepository) FindOptionTypesByUserID(ctx context.Context, userID uint) ([]*domain.OptionType, error) {
+	var optionTypes []*domain.OptionType
+	err := conn(ctx, r.db).
+		Preload("Values", orderedValues).
+		Where("user_id = ?", userID).
+		Order("name ASC").
+		Find(&optionTypes).Error
+	if err != nil {
+		return nil, err
+	}
+	return optionTypes, nil
+}
+
+func (r *VariantGormRepository) DeleteOptionType(ctx context.Context, id, userID uint) error {
+	return conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).Delete(&domain.OptionType{}).Error
+}
+
+func (r *VariantGormRepository) CreateOptionValue(ctx context.Context, value *domain.OptionValue) error {
+	return conn(ctx, r.db).Omit("OptionType").Create(value).Error
+}
+
+func (r *VariantGormRepository) DeleteOptionValue(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).Delete(&domain.OptionValue{}, id).Error
+}
+
+func (r *VariantGormRepository) FindOptionValues(ctx context.Context, ids []uint, userID uint) ([]*domain.OptionValue, error) {
+	var values []*domain.OptionValue
+	err := conn(ctx, r.db).
+		Preload("OptionType").
+		Joins("JOIN option_types ON option_types.id = option_values.option_type_id").
+		Where("option_values.id IN ? AND option_types.user_id = ?", ids, userID).
+		Find(&values).Error
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (r *VariantGormRepository) CountVariantsByOptionType(ctx context.Context, optionTypeID uint) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).Model(&domain.VariantOption{}).Where("option_type_id = ?", optionTypeID).Count(&count).Error
+	return count, err
+}
+
+func (r *VariantGormRepository) CountVariantsByOptionValue(ctx context.Context, optionValueID uint) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).Model(&domain.VariantOption{}).Where("option_value_id = ?", optionValueID).Count(&count).Error
+	return count, err
+}
+
+func (r *VariantGormRepository) CreateVariant(ctx context.Context, variant *domain.ProductVariant) error {
+	return conn(ctx, r.db).
+		Omit("Product", "Options.OptionType", "Options.OptionValue").
+		Create(variant).Error
+}
+
+func (r *VariantGormRepository) FindVariantByCodeAndUserID(ctx context.Context, code string, userID uint) (*domain.ProductVariant, error) {
+	var variant domain.ProductVariant
+	err := conn(ctx, r.db).Where("code = ? AND user_id = ?", code, userID).First(&variant).Error
+	if err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+func (r *VariantGormRepository) UpdateVariant(ctx context.Context, variant *domain.ProductVariant) error {
+	return conn(ctx, r.db).
+		Where("id = ? AND user_id = ?", variant.ID, variant.UserID).
+		Omit(clause.Associations).
+		Save(variant).Error
+}
+
+func (r *VariantGormRepository) DeleteVariant(ctx context.Context, id, userID uint) error {
+	return conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).Delete(&domain.ProductVariant{}).Error
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"vertice-backend/config"
+	"vertice-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookGormRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookGormRepository() domain.WebhookRepository {
+	return &WebhookGormRepository{db: config.DB}
+}
+
+func (r *WebhookGormRepository) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	return conn(ctx, r.db).Create(subscription).Error
+}
+
+func (r *WebhookGormRepository) FindSubscriptionByIDAndUserID(ctx context.Context, id, userID uint) (*domain.WebhookSubscription, error) {
+	var subscription domain.WebhookSubscription
+	err := conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).First(&subscription).Error
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *WebhookGormRepository) FindSubscriptionsByUserID(ctx context.Context, userID uint) ([]*domain.WebhookSubscription, error) {
+	var subscriptions []*domain.WebhookSubscription
+	err := conn(ctx, r.db).Where("user_id = ?", userID).Order("id ASC").Find(&subscriptions).Error
+	if err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *WebhookGormRepository) FindActiveSubscriptions(ctx context.Context, userID uint) ([]*domain.WebhookSubscription, error) {
+	var subscriptions []*domain.WebhookSubscription
+	err := conn(ctx, r.db).Where("user_id = ? AND active = ?", userID, true).Find(&subscriptions).Error
+	if err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *WebhookGormRepository) UpdateSubscription(ctx context.Context, subscription *domain.WebhookSubscription, userID uint) error {
+	return conn(ctx, r.db).
+		Where("id = ? AND user_id = ?", subscription.ID, userID).
+		Save(subscription).Error
+}
+
+func (r *WebhookGormRepository) DeleteSubscription(ctx context.Context, id, userID uint) error {
+	return conn(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).Delete(&domain.WebhookSubscription{}).Error
+}
+
+// CreateDelivery skips a delivery whose event was already queued for the
+// subscription.
+func (r *WebhookGormRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "event_id"}, {Name: "subscription_id"}}, DoNothing: true}).
+		Create(delivery).Error
+}
+
+func (r *WebhookGormRepository) FindDeliveryByIDAndUserID(ctx context.Context, id, userID uint) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	err := conn(ctx, r.db).
+		Preload("AttemptLog", func(db *gorm.DB) *gorm.DB { return db.Order("number ASC") }).
+		Preload("Subscription").
+		Where("id = ? AND user_id = ?", id, userID).
+		First(&delivery).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *WebhookGormRepository) FindDeliveriesBySubscriptionID(ctx context.Context, subscriptionID, userID uint) ([]*domain.WebhookDelivery, error) {
+	var deliveries []*domain.WebhookDelivery
+	err := conn(ctx, r.db).
+		Preload("AttemptLog", func(db *gorm.DB) *gorm.DB { return db.Order("number ASC") }).
+		Where("subscription_id = ? AND user_id = ?", subscriptionID, userID).
+		Order("created_at DESC").
+		Limit(100).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookGormRepository) LockDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	var deliveries []*domain.WebhookDelivery
+	err := conn(ctx, r.db).
+		Preload("Subscription").
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status IN ? AND next_attempt_at <= ?",
+			[]domain.WebhookDeliveryStatus{domain.WebhookDeliveryPending, domain.WebhookDeliveryRetrying}, now).
+		Order("next_attempt_at ASC, id ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookGormRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	return conn(ctx, r.db).Omit("Subscription", "AttemptLog").Save(delivery).Error
+}
+
+func (r *WebhookGormRepository) CreateAttempt(ctx context.Context, attempt *domain.WebhookAttempt) error {
+	return conn(ctx, r.db).Create(attempt).Error
+}
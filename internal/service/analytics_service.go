@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+	"vertice-backend/internal/domain"
+)
+
+// maxSalesPeriods bounds how many periods a sales report spans.
+const maxSalesPeriods = 1000
+
+// AnalyticsService reports sales figures aggregated over the orders.
+type AnalyticsService struct {
+	repo domain.AnalyticsRepository
+}
+
+func NewAnalyticsService(repo domain.AnalyticsRepository) *AnalyticsService {
+	return &AnalyticsService{repo: repo}
+}
+
+// SalesOverTime returns the orders and revenue of each day, week or month of
+// the filter's period in its time zone, cancelled orders excluded. Weeks
+// start on Monday. Periods without orders are included with zeros and each
+// Period is the start of the period in the filter's time zone.
+func (s *AnalyticsService) SalesOverTime(ctx context.Context, userID uint, bucket domain.SalesBucket, filter domain.SalesFilter) ([]domain.SalesPoint, error) {
+	switch bucket {
+	case "":
+		bucket = domain.SalesBucketDay
+	case domain.SalesBucketDay, domain.SalesBucketWeek, domain.SalesBucketMonth:
+	default:
+		return nil, errors.New("bucket must be day, week or month")
+	}
+	loc := filter.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	points, err := s.repo.SalesOverTime(ctx, userID, bucket, filter)
+	if err != nil {
+		return nil, err
+	}
+	byPeriod := make(map[time.Time]domain.SalesPoint, len(points))
+	for _, point := range points {
+		byPeriod[point.Period.UTC()] = point
+	}
+
+	var first, last time.Time
+	if filter.From != nil {
+		first = periodStart(filter.From.In(loc), bucket)
+	} else if len(points) > 0 {
+		first = points[0].Period.UTC()
+	}
+	if filter.To != nil {
+		last = periodStart(filter.To.Add(-time.Nanosecond).In(loc), bucket)
+	} else if len(points) > 0 {
+		last = points[len(points)-1].Period.UTC()
+	}
+	if first.IsZero() || last.IsZero() {
+		return []domain.SalesPoint{}, nil
+	}
+
+	series := []domain.SalesPoint{}
+	for period := first; !period.After(last); period = nextPeriod(period, bucket) {
+		if len(series) == maxSalesPeriods {
+			return nil, errors.New("the period spans too many buckets, use a longer bucket or a shorter period")
+		}
+		point := byPeriod[period]
+		point.Period = time.Date(period.Year(), period.Month(), period.Day(), 0, 0, 0, 0, loc)
+		point.Revenue = roundMoney(point.Revenue)
+		series = append(series, point)
+	}
+	return series, nil
+}
+
+// periodStart returns the wall clock start of the period t is in, read as
+// UTC like the periods of the repository.
+func periodStart(t time.Time, bucket domain.SalesBucket) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch bucket {
+	case domain.SalesBucketWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case domain.SalesBucketMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+func nextPeriod(period time.Time, bucket domain.SalesBucket) time.Time {
+	switch bucket {
+	case domain.SalesBucketWeek:
+		return period.AddDate(0, 0, 7)
+	case domain.SalesBucketMonth:
+		return period.AddDate(0, 1, 0)
+	}
+	return period.AddDate(0, 0, 1)
+}
+
+const (
+	defaultTopProducts = 10
+	maxTopProducts     = 100
+)
+
+// TopProducts returns the best selling products of the filter's period, by
+// "revenue" or "quantity", cancelled orders excluded.
+func (s *AnalyticsService) TopProducts(ctx context.Context, userID uint, filter domain.SalesFilter, by string, limit int) ([]domain.ProductSales, error) {
+	if by != "" && by != "revenue" && by != "quantity" {
+		return nil, errors.New("by must be revenue or quantity")
+	}
+	if limit < 0 {
+		return nil, errors.New("limit cannot be negative")
+	}
+	if limit == 0 {
+		limit = defaultTopProducts
+	}
+	limit = min(limit, maxTopProducts)
+
+	products, err := s.repo.TopProducts(ctx, userID, filter, by == "quantity", limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range products {
+		products[i].Revenue = roundMoney(products[i].Revenue)
+	}
+	return products, nil
+}
+
+// FunnelStage is how many of the orders placed reached a status, and which
+// percentage of them that is.
+type FunnelStage struct {
+	Status  domain.OrderStatus
+	Orders  int
+	Percent float64
+}
+
+// funnelStages are the statuses an order moves through, in order.
+var funnelStages = []domain.OrderStatus{
+	domain.OrderStatusPending,
+	domain.OrderStatusConfirmed,
+	domain.OrderStatusShipped,
+	domain.OrderStatusDelivered,
+}
+
+type SalesSummary struct {
+	// Orders counts every order placed, cancelled ones included.
+	Orders int
+	// Revenue and AverageOrderValue leave cancelled orders out.
+	Revenue           float64
+	AverageOrderValue float64
+	Cancelled         int
+	CancellationRate  float64
+	Statuses          []domain.StatusCount
+	// Funnel counts, for each status, the orders that are in it or past it.
+	// Cancelled orders only count as placed, since how far they got is not
+	// known.
+	Funnel []FunnelStage
+}
+
+// SalesSummary sums up the orders of the filter's period: revenue, average
+// order value, cancellation rate and the status funnel.
+func (s *AnalyticsService) SalesSummary(ctx context.Context, userID uint, filter domain.SalesFilter) (*SalesSummary, error) {
+	counts, err := s.repo.StatusCounts(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &SalesSummary{Statuses: counts}
+	byStatus := map[domain.OrderStatus]int{}
+	for _, count := range counts {
+		byStatus[count.Status] = count.Orders
+		summary.Orders += count.Orders
+		if count.Status == domain.OrderStatusCancelled {
+			summary.Cancelled = count.Orders
+		} else {
+			summary.Revenue += count.Revenue
+		}
+	}
+	summary.Revenue = roundMoney(summary.Revenue)
+	if kept := summary.Orders - summary.Cancelled; kept > 0 {
+		summary.AverageOrderValue = roundMoney(summary.Revenue / float64(kept))
+	}
+	summary.CancellationRate = percentOf(summary.Cancelled, summary.Orders)
+
+	for i, status := range funnelStages {
+		stage := FunnelStage{Status: status}
+		if i == 0 {
+			stage.Orders = summary.Orders
+		} else {
+			for _, later := range funnelStages[i:] {
+				stage.Orders += byStatus[later]
+			}
+		}
+		stage.Percent = percentOf(stage.Orders, summary.Orders)
+		summary.Funnel = append(summary.Funnel, stage)
+	}
+	return summary, nil
+}
+
+// percentOf returns part as a percentage of whole with two decimals, zero
+// when whole is.
+func percentOf(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(whole)*10000) / 100
+}
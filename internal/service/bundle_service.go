@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"vertice-backend/internal/domain"
+)
+
+// maxBundleComponents bounds the number of components of a bundle.
+const maxBundleComponents = 50
+
+var errBundleStock = errors.New("stock of a bundle is derived from its components")
+
+type BundleService struct {
+	bundleRepo  domain.BundleRepository
+	productRepo domain.ProductRepository
+	tx          domain.Transactor
+}
+
+type BundleServiceOption func(*BundleService)
+
+// WithBundleTransactor makes replacing the components of a bundle atomic.
+func WithBundleTransactor(tx domain.Transactor) BundleServiceOption {
+	return func(s *BundleService) {
+		s.tx = tx
+	}
+}
+
+func NewBundleService(bundleRepo domain.BundleRepository, productRepo domain.ProductRepository, opts ...BundleServiceOption) *BundleService {
+	s := &BundleService{
+		bundleRepo:  bundleRepo,
+		productRepo: productRepo,
+		tx:          noTransaction{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// BundleComponentRequest puts a quantity of a product, naming the variant for
+// products with variants, in each unit of a bundle.
+type BundleComponentRequest struct {
+	ProductID uint  `json:"product_id" example:"2"`
+	VariantID *uint `json:"variant_id,omitempty"`
+	Quantity  int   `json:"quantity" example:"1"`
+}
+
+// SetComponents makes a product a bundle of the given components, replacing
+// any it had. No components make it a regular product again. A bundle has no
+// stock of its own, variants, lots or serials, and its components cannot be
+// bundles, batch-managed or serialized themselves.
+func (s *BundleService) SetComponents(ctx context.Context, productID, userID uint, components []BundleComponentRequest) (*domain.Product, error) {
+	if len(components) > maxBundleComponents {
+		return nil, errors.New("a bundle can have at most 50 components")
+	}
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		bundle, err := s.productRepo.FindByIDAndUserID(ctx, productID, userID)
+		if err != nil {
+			return errors.New("product not found")
+		}
+		if len(components) > 0 {
+			if err := s.checkBundle(ctx, bundle); err != nil {
+				return err
+			}
+		}
+
+		seen := make(map[[2]uint]bool, len(components))
+		rows := make([]domain.BundleComponent, 0, len(components))
+		for _, req := range components {
+			if req.Quantity <= 0 {
+				return errors.New("component quantity must be greater than 0")
+			}
+			if req.ProductID == bundle.ID {
+				return errors.New("a bundle cannot contain itself")
+			}
+			component, err := s.productRepo.FindByIDAndUserID(ctx, req.ProductID, userID)
+			if err != nil {
+				return errors.New("component product not found")
+			}
+			if err := checkComponent(component); err != nil {
+				return err
+			}
+			var variantID uint
+			if req.VariantID != nil {
+				if _, ok := component.Variant(*req.VariantID); !ok {
+					return errors.New("variant not found")
+				}
+				variantID = *req.VariantID
+			} else if component.HasVariants() {
+				return errors.New("variant_id is required for component: " + component.Name)
+			}
+			key := [2]uint{component.ID, variantID}
+			if seen[key] {
+				return errors.New("component is listed twice: " + component.Name)
+			}
+			seen[key] = true
+			rows = append(rows, domain.BundleComponent{ComponentID: component.ID, VariantID: req.VariantID, Quantity: req.Quantity})
+		}
+		return s.bundleRepo.ReplaceComponents(ctx, bundle.ID, rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.productRepo.FindByIDAndUserID(ctx, productID, userID)
+}
+
+// checkBundle refuses to make a product a bundle when it holds stock of its
+// own or is a component of another bundle.
+func (s *BundleService) checkBundle(ctx context.Context, bundle *domain.Product) error {
+	switch {
+	case bundle.HasVariants():
+		return errors.New("products with variants cannot be bundles")
+	case bundle.BatchManaged || bundle.Serialized:
+		return errors.New("batch-managed and serialized products cannot be bundles")
+	case bundle.Stock != 0:
+		return errors.New("stock must be zero to make a product a bundle")
+	}
+	parents, err := s.bundleRepo.FindBundleIDsByComponent(ctx, bundle.ID)
+	if err != nil {
+		return err
+	}
+	if len(parents) > 0 {
+		return errors.New("a component of another bundle cannot be a bundle")
+	}
+	return nil
+}
+
+func checkComponent(component *domain.Product) error {
+	switch {
+	case component.IsBundle():
+		return errors.New("bundles cannot be components: " + component.Name)
+	case component.BatchManaged || component.Serialized:
+		return errors.New("batch-managed and serialized products cannot be components: " + component.Name)
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+	"vertice-backend/internal/domain"
+)
+
+type CategoryService struct {
+	categoryRepo domain.CategoryRepository
+	productRepo  domain.ProductRepository
+}
+
+func NewCategoryService(categoryRepo domain.CategoryRepository, productRepo domain.ProductRepository) *CategoryService {
+	return &CategoryService{categoryRepo: categoryRepo, productRepo: productRepo}
+}
+
+// CategoryRequest creates a category. Slug defaults to one derived from the
+// name; ParentID nests the category under another one.
+type CategoryRequest struct {
+	Name     string `json:"name"`
+	Slug     string `json:"slug"`
+	ParentID *uint  `json:"parent_id"`
+	Position int    `json:"position"`
+}
+
+// UpdateCategoryRequest changes a category. A ParentID of 0 moves it to the
+// top level.
+type UpdateCategoryRequest struct {
+	Name     *string `json:"name"`
+	Slug     *string `json:"slug"`
+	ParentID *uint   `json:"parent_id"`
+	Position *int    `json:"position"`
+}
+
+// CategoryNode is a category with its children, for returning the tree.
+type CategoryNode struct {
+	*domain.Category
+	Path     string
+	Children []*CategoryNode
+}
+
+// CategoryReport summarises the products of a category and its descendants.
+// A product in several categories of the subtree is counted once.
+type CategoryReport struct {
+	Category   *domain.Category
+	Path       string
+	Products   int
+	Units      int
+	StockValue float64
+	LowStock   int
+}
+
+func (s *CategoryService) CreateCategory(ctx context.Context, userID uint, req CategoryRequest) (*domain.Category, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	slug := slugify(req.Slug)
+	if slug == "" {
+		slug = slugify(name)
+	}
+	if slug == "" {
+		return nil, errors.New("slug is required")
+	}
+	if existing, err := s.categoryRepo.FindBySlugAndUserID(ctx, slug, userID); err == nil && existing != nil {
+		return nil, errors.New("category slug already exists")
+	}
+
+	category := &domain.Category{UserID: userID, Name: name, Slug: slug, Position: req.Position}
+	if req.ParentID != nil && *req.ParentID != 0 {
+		if _, err := s.categoryRepo.FindByIDAndUserID(ctx, *req.ParentID, userID); err != nil {
+			return nil, errors.New("parent category not found")
+		}
+		category.ParentID = req.ParentID
+	}
+
+	if err := s.categoryRepo.Create(ctx, category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// GetCategoryTree returns the top-level categories with their descendants.
+func (s *CategoryService) GetCategoryTree(ctx context.Context, userID uint) ([]*CategoryNode, error) {
+	categories, err := s.categoryRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	tree := domain.NewCategoryTree(categories)
+	var build func(categories []*domain.Category) []*CategoryNode
+	build = func(categories []*domain.Category) []*CategoryNode {
+		nodes := make([]*CategoryNode, len(categories))
+		for i, category := range categories {
+			nodes[i] = &CategoryNode{
+				Category: category,
+				Path:     tree.Path(category.ID),
+				Children: build(tree.Children(category.ID)),
+			}
+		}
+		return nodes
+	}
+	return build(tree.Roots()), nil
+}
+
+func (s *CategoryService) GetCategory(ctx context.Context, id, userID uint) (*domain.Category, error) {
+	category, err := s.categoryRepo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("category not found")
+	}
+	return category, nil
+}
+
+func (s *CategoryService) UpdateCategory(ctx context.Context, id, userID uint, req UpdateCategoryRequest) (*domain.Category, error) {
+	category, err := s.categoryRepo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("category not found")
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, errors.New("name cannot be empty")
+		}
+		category.Name = name
+	}
+	if req.Slug != nil {
+		slug := slugify(*req.Slug)
+		if slug == "" {
+			return nil, errors.New("slug cannot be empty")
+		}
+		if slug != category.Slug {
+			if existing, err := s.categoryRepo.FindBySlugAndUserID(ctx, slug, userID); err == nil && existing != nil {
+				return nil, errors.New("category slug already exists")
+			}
+		}
+		category.Slug = slug
+	}
+	if req.Position != nil {
+		category.Position = *req.Position
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == 0 {
+			category.ParentID = nil
+		} else {
+			categories, err := s.categoryRepo.FindByUserID(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			tree := domain.NewCategoryTree(categories)
+			if _, ok := tree.Get(*req.ParentID); !ok {
+				return nil, errors.New("parent category not found")
+			}
+			for _, descendant := range tree.Subtree(category.ID) {
+				if descendant == *req.ParentID {
+					return nil, errors.New("a category cannot be moved under itself or its descendants")
+				}
+			}
+			category.ParentID = req.ParentID
+		}
+	}
+
+	if err := s.categoryRepo.Update(ctx, category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// DeleteCategory deletes a category without subcategories. Its products stay
+// and are no longer in the category.
+func (s *CategoryService) DeleteCategory(ctx context.Context, id, userID uint) error {
+	categories, err := s.categoryRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	tree := domain.NewCategoryTree(categories)
+	if _, ok := tree.Get(id); !ok {
+		return errors.New("category not found")
+	}
+	if len(tree.Children(id)) > 0 {
+		return errors.New("category has subcategories")
+	}
+	return s.categoryRepo.Delete(ctx, id, userID)
+}
+
+// SetProductCategories replaces the categories of a product.
+func (s *CategoryService) SetProductCategories(ctx context.Context, productID, userID uint, categoryIDs []uint) (*domain.Product, error) {
+	product, err := s.productRepo.FindByIDAndUserID(ctx, productID, userID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+
+	ids := uniqueIDs(categoryIDs)
+	categories := []*domain.Category{}
+	if len(ids) > 0 {
+		categories, err = s.categoryRepo.FindByIDs(ctx, ids, userID)
+		if err != nil {
+			return nil, err
+		}
+		if len(categories) != len(ids) {
+			return nil, errors.New("category not found")
+		}
+	}
+
+	if err := s.categoryRepo.ReplaceProductCategories(ctx, product, categories); err != nil {
+		return nil, err
+	}
+	product.Categories = make([]domain.Category, len(categories))
+	for i, category := range categories {
+		product.Categories[i] = *category
+	}
+	return product, nil
+}
+
+// Report summarises stock per category, including the products of
+// subcategories, in tree order.
+func (s *CategoryService) Report(ctx context.Context, userID uint) ([]CategoryReport, error) {
+	categories, err := s.categoryRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	products, err := s.productRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byCategory := make(map[uint][]*domain.Product)
+	for _, product := range products {
+		for _, category := range product.Categories {
+			byCategory[category.ID] = append(byCategory[category.ID], product)
+		}
+	}
+
+	tree := domain.NewCategoryTree(categories)
+	var reports []CategoryReport
+	var walk func(categories []*domain.Category)
+	walk = func(categories []*domain.Category) {
+		for _, category := range categories {
+			report := CategoryReport{Category: category, Path: tree.Path(category.ID)}
+			seen := make(map[uint]bool)
+			for _, id := range tree.Subtree(category.ID) {
+				for _, product := range byCategory[id] {
+					if seen[product.ID] {
+						continue
+					}
+					seen[product.ID] = true
+					report.Products++
+					report.Units += product.Stock
+					report.StockValue += float64(product.Stock) * product.Price
+					if product.LowStock() {
+						report.LowStock++
+					}
+				}
+			}
+			reports = append(reports, report)
+			walk(tree.Children(category.ID))
+		}
+	}
+	walk(tree.Roots())
+	return reports, nil
+}
+
+// slugify lower-cases s and joins its letters and digits with hyphens.
+func slugify(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(r)
+			continue
+		}
+		hyphen = true
+	}
+	return b.String()
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
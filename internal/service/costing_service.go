@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+	"vertice-backend/internal/domain"
+)
+
+// CostingService keeps the inventory ledger and reports margins and
+// inventory valuation from it.
+type CostingService struct {
+	movementRepo domain.InventoryMovementRepository
+	productRepo  domain.ProductRepository
+	orderRepo    domain.OrderRepository
+}
+
+func NewCostingService(movementRepo domain.InventoryMovementRepository, productRepo domain.ProductRepository, orderRepo domain.OrderRepository) *CostingService {
+	return &CostingService{
+		movementRepo: movementRepo,
+		productRepo:  productRepo,
+		orderRepo:    orderRepo,
+	}
+}
+
+// Handle books the stock changes the event bus delivers into the inventory
+// ledger.
+func (s *CostingService) Handle(ctx context.Context, message *domain.OutboxMessage) error {
+	if message.EventType != domain.EventStockAdjusted {
+		return nil
+	}
+	var event domain.StockAdjusted
+	if err := message.Decode(&event); err != nil {
+		return err
+	}
+	eventID := message.ID
+	movement := &domain.InventoryMovement{
+		UserID:     message.UserID,
+		EventID:    &eventID,
+		ProductID:  event.ProductID,
+		Quantity:   event.Delta,
+		UnitCost:   event.UnitCost,
+		Reason:     event.Reason,
+		OccurredAt: message.OccurredAt,
+	}
+	if event.VariantID != 0 {
+		variantID := event.VariantID
+		movement.VariantID = &variantID
+	}
+	return s.movementRepo.Create(ctx, movement)
+}
+
+type SetCostRequest struct {
+	Cost       float64           `json:"cost"`
+	CostMethod domain.CostMethod `json:"cost_method"`
+}
+
+// SetCost sets the unit cost of a product and, when given, how it is kept.
+func (s *CostingService) SetCost(ctx context.Context, productID, userID uint, req SetCostRequest) (*domain.Product, error) {
+	if err := validateCost(req.Cost, req.CostMethod); err != nil {
+		return nil, err
+	}
+	product, err := s.productRepo.FindByIDAndUserID(ctx, productID, userID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+	if product.IsBundle() {
+		return nil, errors.New("cost of a bundle is derived from its components")
+	}
+	product.Cost = req.Cost
+	if req.CostMethod != "" {
+		product.CostMethod = req.CostMethod
+	}
+	if err := s.productRepo.Update(ctx, product, userID); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+type MarginGroup string
+
+const (
+	MarginByOrder   MarginGroup = "order"
+	MarginByProduct MarginGroup = "product"
+	MarginByDay     MarginGroup = "day"
+	MarginByMonth   MarginGroup = "month"
+)
+
+// MarginLine is the revenue and cost of sales of an order, a product or a
+// period, depending on how the report is grouped.
+type MarginLine struct {
+	OrderID     uint
+	ProductID   uint
+	ProductCode string
+	ProductName string
+	Period      string
+	Quantity    int
+	Revenue     float64
+	Cost        float64
+}
+
+func (l MarginLine) Margin() float64 {
+	return roundMoney(l.Revenue - l.Cost)
+}
+
+// MarginPercent is the margin as a percentage of revenue, zero without
+// revenue.
+func (l MarginLine) MarginPercent() float64 {
+	if l.Revenue == 0 {
+		return 0
+	}
+	return math.Round((l.Revenue-l.Cost)/l.Revenue*10000) / 100
+}
+
+type MarginReport struct {
+	GroupBy MarginGroup
+	Lines   []MarginLine
+	Total   MarginLine
+}
+
+// MarginReport sums the revenue and the cost of sales of the orders created
+// in the filter's period, cancelled orders excluded, grouped by order,
+// product, day or month. Items are costed at their unit cost when sold.
+func (s *CostingService) MarginReport(ctx context.Context, userID uint, groupBy MarginGroup, filter domain.OrderFilter) (*MarginReport, error) {
+	switch groupBy {
+	case "":
+		groupBy = MarginByOrder
+	case MarginByOrder, MarginByProduct, MarginByDay, MarginByMonth:
+	default:
+		return nil, errors.New("group_by must be order, product, day or month")
+	}
+
+	report := &MarginReport{GroupBy: groupBy}
+	lines := map[string]*MarginLine{}
+	err := s.orderRepo.FindInBatches(ctx, userID, filter, 500, func(orders []*domain.Order) error {
+		for _, order := range orders {
+			if order.Status == domain.OrderStatusCancelled {
+				continue
+			}
+			for _, item := range order.Items {
+				var key string
+				line := MarginLine{}
+				switch groupBy {
+				case MarginByOrder:
+					key = strconv.FormatUint(uint64(order.ID), 10)
+					line.OrderID = order.ID
+				case MarginByProduct:
+					key = strconv.FormatUint(uint64(item.ProductID), 10)
+					line.ProductID = item.ProductID
+					line.ProductCode = item.Product.Code
+					line.ProductName = item.Product.Name
+				case MarginByDay:
+					key = order.CreatedAt.UTC().Format(time.DateOnly)
+					line.Period = key
+				case MarginByMonth:
+					key = order.CreatedAt.UTC().Format("2006-01")
+					line.Period = key
+				}
+				if lines[key] == nil {
+					lines[key] = &line
+				}
+				lines[key].add(item)
+				report.Total.add(item)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		line.Revenue, line.Cost = roundMoney(line.Revenue), roundMoney(line.Cost)
+		report.Lines = append(report.Lines, *line)
+	}
+	report.Total.Revenue, report.Total.Cost = roundMoney(report.Total.Revenue), roundMoney(report.Total.Cost)
+	sort.Slice(report.Lines, func(i, j int) bool {
+		a, b := report.Lines[i], report.Lines[j]
+		switch groupBy {
+		case MarginByOrder:
+			return a.OrderID < b.OrderID
+		case MarginByProduct:
+			return a.ProductCode < b.ProductCode
+		default:
+			return a.Period < b.Period
+		}
+	})
+	return report, nil
+}
+
+// validateCost checks a unit cost and a cost method, which may be left empty.
+func validateCost(cost float64, method domain.CostMethod) error {
+	if cost < 0 {
+		return errors.New("cost cannot be negative")
+	}
+	switch method {
+	case "", domain.CostMethodStandard, domain.CostMethodMovingAverage:
+		return nil
+	default:
+		return errors.New("cost_method must be standard or moving_average")
+	}
+}
+
+// roundMoney rounds an amount to the cent.
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func (l *MarginLine) add(item domain.OrderItem) {
+	l.Quantity += item.Quantity
+	l.Revenue += item.Subtotal
+	l.Cost += item.UnitCost * float64(item.Quantity)
+}
+
+type ValuationMethod string
+
+const (
+	ValuationFIFO            ValuationMethod = "fifo"
+	ValuationWeightedAverage ValuationMethod = "weighted_average"
+)
+
+// ValuationLine is the stock of a product on hand at a date and what it is
+// worth.
+type ValuationLine struct {
+	ProductID uint
+	Code      string
+	Name      string
+	Quantity  int
+	UnitCost  float64
+	Value     float64
+}
+
+type InventoryValuation struct {
+	AsOf   time.Time
+	Method ValuationMethod
+	Lines  []ValuationLine
+	Total  float64
+}
+
+// InventoryValuation values the stock on hand at asOf from the inventory
+// ledger up to then. FIFO values it at the cost of the most recent receipts,
+// since the oldest were sold first; weighted average at the average cost of
+// everything received. Stock taken beyond what was received has no value.
+func (s *CostingService) InventoryValuation(ctx context.Context, userID uint, asOf time.Time, method ValuationMethod) (*InventoryValuation, error) {
+	switch method {
+	case "":
+		method = ValuationWeightedAverage
+	case ValuationFIFO, ValuationWeightedAverage:
+	default:
+		return nil, errors.New("method must be fifo or weighted_average")
+	}
+
+	balances, err := s.movementRepo.BalancesAt(ctx, userID, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	valuation := &InventoryValuation{AsOf: asOf, Method: method}
+	for _, balance := range balances {
+		line := ValuationLine{
+			ProductID: balance.ProductID,
+			Code:      balance.Code,
+			Name:      balance.Name,
+			Quantity:  balance.Quantity,
+		}
+		if balance.Quantity > 0 {
+			value := balance.FIFOValue
+			if method == ValuationWeightedAverage {
+				value = float64(balance.Quantity) * balance.AverageCost
+			}
+			line.Value = roundMoney(value)
+			line.UnitCost = math.Round(value/float64(balance.Quantity)*10000) / 10000
+		}
+		valuation.Lines = append(valuation.Lines, line)
+		valuation.Total += line.Value
+	}
+	valuation.Total = roundMoney(valuation.Total)
+	return valuation, nil
+}
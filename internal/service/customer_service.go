@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"vertice-backend/internal/domain"
+)
+
+type CustomerService struct {
+	customerRepo domain.CustomerRepository
+}
+
+func NewCustomerService(customerRepo domain.CustomerRepository) *CustomerService {
+	return &CustomerService{customerRepo: customerRepo}
+}
+
+type CustomerRequest struct {
+	Name    string `json:"name" example:"Acme Retail"`
+	Email   string `json:"email" example:"buying@acme.example.com"`
+	GroupID *uint  `json:"group_id,omitempty" example:"1"`
+}
+
+// UpdateCustomerRequest changes the fields that are set. A GroupID of 0
+// takes the customer out of its group.
+type UpdateCustomerRequest struct {
+	Name    *string `json:"name"`
+	Email   *string `json:"email"`
+	GroupID *uint   `json:"group_id"`
+}
+
+func (s *CustomerService) CreateCustomer(ctx context.Context, userID uint, req CustomerRequest) (*domain.Customer, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	customer := &domain.Customer{UserID: userID, Name: name, Email: strings.TrimSpace(req.Email)}
+	if req.GroupID != nil {
+		group, err := s.customerRepo.FindGroupByIDAndUserID(ctx, *req.GroupID, userID)
+		if err != nil {
+			return nil, errors.New("customer group not found")
+		}
+		customer.GroupID, customer.Group = &group.ID, group
+	}
+	if err := s.customerRepo.Create(ctx, customer); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+func (s *CustomerService) GetCustomer(ctx context.Context, id, userID uint) (*domain.Customer, error) {
+	customer, err := s.customerRepo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("customer not found")
+	}
+	return customer, nil
+}
+
+func (s *CustomerService) GetCustomersByUser(ctx context.Context, userID uint) ([]*domain.Customer, error) {
+	return s.customerRepo.FindByUserID(ctx, userID)
+}
+
+func (s *CustomerService) UpdateCustomer(ctx context.Context, id, userID uint, req UpdateCustomerRequest) (*domain.Customer, error) {
+	customer, err := s.customerRepo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("customer not found")
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, errors.New("name cannot be empty")
+		}
+		customer.Name = name
+	}
+	if req.Email != nil {
+		customer.Email = strings.TrimSpace(*req.Email)
+	}
+	if req.GroupID != nil {
+		if *req.GroupID == 0 {
+			customer.GroupID, customer.Group = nil, nil
+		} else {
+			group, err := s.customerRepo.FindGroupByIDAndUserID(ctx, *req.GroupID, userID)
+			if err != nil {
+				return nil, errors.New("customer group not found")
+			}
+			customer.GroupID, customer.Group = &group.ID, group
+		}
+	}
+	if err := s.customerRepo.Update(ctx, customer, userID); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+// DeleteCustomer removes a customer. Its orders are kept without it.
+func (s *CustomerService) DeleteCustomer(ctx context.Context, id, userID uint) error {
+	if _, err := s.customerRepo.FindByIDAndUserID(ctx, id, userID); err != nil {
+		return errors.New("customer not found")
+	}
+	return s.customerRepo.Delete(ctx, id, userID)
+}
+
+func (s *CustomerService) CreateGroup(ctx context.Context, userID uint, name string) (*domain.CustomerGroup, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if existing, err := s.customerRepo.FindGroupByNameAndUserID(ctx, name, userID); err == nil && existing != nil {
+		return nil, errors.New("customer group name already exists for this user")
+	}
+	group := &domain.CustomerGroup{UserID: userID, Name: name}
+	if err := s.customerRepo.CreateGroup(ctx, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (s *CustomerService) GetGroups(ctx context.Context, userID uint) ([]*domain.CustomerGroup, error) {
+	return s.customerRepo.FindGroupsByUserID(ctx, userID)
+}
+
+// DeleteGroup removes a customer group. Its customers are kept without a
+// group.
+func (s *CustomerService) DeleteGroup(ctx context.Context, id, userID uint) error {
+	if _, err := s.customerRepo.FindGroupByIDAndUserID(ctx, id, userID); err != nil {
+		return errors.New("customer group not found")
+	}
+	return s.customerRepo.DeleteGroup(ctx, id, userID)
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/mailer"
+)
+
+// EmailService sends the transactional emails of account and order events,
+// as the user's notification preferences allow, and logs every one of them.
+type EmailService struct {
+	logs        domain.EmailLogRepository
+	preferences domain.NotificationPreferencesRepository
+	users       domain.UserRepository
+	orders      domain.OrderRepository
+	mailer      mailer.Mailer
+	appURL      string
+}
+
+type EmailServiceOption func(*EmailService)
+
+// WithEmailMailer sets the mailer emails are sent with. Without one, no
+// emails are sent.
+func WithEmailMailer(m mailer.Mailer) EmailServiceOption {
+	return func(s *EmailService) {
+		s.mailer = m
+	}
+}
+
+// WithEmailAppURL sets the address of the web app, which verification and
+// password reset emails link to. Without it, they carry the bare token.
+func WithEmailAppURL(appURL string) EmailServiceOption {
+	return func(s *EmailService) {
+		s.appURL = strings.TrimRight(appURL, "/")
+	}
+}
+
+func NewEmailService(logs domain.EmailLogRepository, preferences domain.NotificationPreferencesRepository, users domain.UserRepository, orders domain.OrderRepository, opts ...EmailServiceOption) *EmailService {
+	s := &EmailService{logs: logs, preferences: preferences, users: users, orders: orders}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	OrderCreated       *bool `json:"order_created" example:"false"`
+	OrderStatusChanged *bool `json:"order_status_changed" example:"true"`
+	OrderCancelled     *bool `json:"order_cancelled" example:"true"`
+}
+
+// AccountEmail is the data of the account email templates.
+type AccountEmail struct {
+	Name  string
+	Email string
+}
+
+// OrderEmail is the data of the order email templates. From and To are the
+// statuses of a status change.
+type OrderEmail struct {
+	Name  string
+	Order *domain.Order
+	From  string
+	To    string
+}
+
+// TokenEmail is the data of the verify_email and password_reset templates.
+// Link is empty when no app URL is configured.
+type TokenEmail struct {
+	Name      string
+	Token     string
+	Link      string
+	ExpiresIn string
+}
+
+// tokenEmailPaths are the app pages the token emails link to.
+var tokenEmailPaths = map[domain.EmailKind]string{
+	domain.EmailVerifyEmail:   "/verify-email",
+	domain.EmailPasswordReset: "/reset-password",
+}
+
+// Handle is an EventBus handler that emails users about their registration
+// and their orders. Redelivered events are recognised by their outbox ID.
+// Sending is best effort: failures are logged instead of returned so the
+// relay does not redeliver the event to every other sink. Mailers bound how
+// long a send may block the relay.
+func (s *EmailService) Handle(ctx context.Context, message *domain.OutboxMessage) error {
+	if s.mailer == nil {
+		return nil
+	}
+	switch message.EventType {
+	case domain.EventUserRegistered, domain.EventOrderCreated, domain.EventOrderStatusChanged:
+	default:
+		return nil
+	}
+	if _, err := s.logs.FindByEventID(ctx, message.ID); err == nil {
+		return nil
+	}
+	user, err := s.users.FindByID(ctx, message.UserID)
+	if err != nil {
+		return err
+	}
+
+	var (
+		kind    domain.EmailKind
+		subject string
+		data    any
+	)
+	switch message.EventType {
+	case domain.EventUserRegistered:
+		kind, subject = domain.EmailWelcome, "Welcome to Vertice"
+		data = AccountEmail{Name: user.Name, Email: user.Email}
+	case domain.EventOrderCreated:
+		var event domain.OrderCreated
+		if err := message.Decode(&event); err != nil {
+			return err
+		}
+		order, err := s.orders.FindByIDAndUserID(ctx, event.OrderID, message.UserID)
+		if err != nil {
+			// The order was deleted before the email went out.
+			return nil
+		}
+		kind, subject = domain.EmailOrderCreated, fmt.Sprintf("Order #%d placed", order.ID)
+		data = OrderEmail{Name: user.Name, Order: order}
+	case domain.EventOrderStatusChanged:
+		var event domain.OrderStatusChanged
+		if err := message.Decode(&event); err != nil {
+			return err
+		}
+		order, err := s.orders.FindByIDAndUserID(ctx, event.OrderID, message.UserID)
+		if err != nil {
+			return nil
+		}
+		kind, subject = domain.EmailOrderStatusChanged, fmt.Sprintf("Order #%d is %s", order.ID, event.To)
+		if event.To == string(domain.OrderStatusCancelled) {
+			kind, subject = domain.EmailOrderCancelled, fmt.Sprintf("Order #%d cancelled", order.ID)
+		}
+		data = OrderEmail{Name: user.Name, Order: order, From: event.From, To: event.To}
+	}
+
+	eventID := message.ID
+	return s.deliver(ctx, &domain.EmailLog{UserID: user.ID, EventID: &eventID, Kind: kind, Subject: subject}, user.Email, data)
+}
+
+// SendTokenEmail emails a user a single use token, which expires after
+// expiresIn, to verify their address or reset their password. The token is
+// left out of the email log.
+func (s *EmailService) SendTokenEmail(ctx context.Context, user *domain.User, kind domain.EmailKind, token string, expiresIn time.Duration) error {
+	if s.mailer == nil {
+		return errors.New("no mailer is configured")
+	}
+	path, ok := tokenEmailPaths[kind]
+	if !ok {
+		return fmt.Errorf("%s is not a token email", kind)
+	}
+	data := TokenEmail{Name: user.Name, Token: token, ExpiresIn: formatHours(expiresIn)}
+	if s.appURL != "" {
+		data.Link = s.appURL + path + "?token=" + url.QueryEscape(token)
+	}
+	subject := "Verify your email address"
+	if kind == domain.EmailPasswordReset {
+		subject = "Reset your password"
+	}
+	entry := &domain.EmailLog{UserID: user.ID, Kind: kind, Subject: subject}
+	if err := s.deliver(ctx, entry, user.Email, data); err != nil {
+		return err
+	}
+	if entry.Status == domain.EmailFailed {
+		return errors.New(entry.Error)
+	}
+	return nil
+}
+
+// formatHours writes a duration of whole hours as "1 hour" or "48 hours".
+func formatHours(d time.Duration) string {
+	hours := int(d.Hours())
+	if hours == 1 {
+		return "1 hour"
+	}
+	return fmt.Sprintf("%d hours", hours)
+}
+
+// deliver renders the template of the entry's kind, sends it to recipient
+// unless the user opted out of it, and logs the outcome in entry.
+func (s *EmailService) deliver(ctx context.Context, entry *domain.EmailLog, recipient string, data any) error {
+	preferences, err := s.GetPreferences(ctx, entry.UserID)
+	if err != nil {
+		return err
+	}
+	entry.Recipients = recipient
+	entry.Status = domain.EmailSkipped
+	if preferences.Allows(entry.Kind) {
+		entry.Status = domain.EmailSent
+		msg, err := mailer.Render(string(entry.Kind), []string{recipient}, entry.Subject, data)
+		if err == nil {
+			err = s.mailer.Send(ctx, msg)
+		}
+		if err != nil {
+			log.Printf("emails: sending %s email to user %d failed: %v", entry.Kind, entry.UserID, err)
+			entry.Status, entry.Error = domain.EmailFailed, err.Error()
+		}
+	}
+	return s.logs.Create(ctx, entry)
+}
+
+// GetPreferences returns the notification preferences of a user, the
+// defaults when they never set any.
+func (s *EmailService) GetPreferences(ctx context.Context, userID uint) (*domain.NotificationPreferences, error) {
+	preferences, err := s.preferences.FindByUserID(ctx, userID)
+	if err != nil {
+		return domain.DefaultNotificationPreferences(userID), nil
+	}
+	return preferences, nil
+}
+
+func (s *EmailService) UpdatePreferences(ctx context.Context, userID uint, req UpdateNotificationPreferencesRequest) (*domain.NotificationPreferences, error) {
+	preferences, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if req.OrderCreated != nil {
+		preferences.OrderCreated = *req.OrderCreated
+	}
+	if req.OrderStatusChanged != nil {
+		preferences.OrderStatusChanged = *req.OrderStatusChanged
+	}
+	if req.OrderCancelled != nil {
+		preferences.OrderCancelled = *req.OrderCancelled
+	}
+	if err := s.preferences.Save(ctx, preferences); err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
+
+// GetEmailLog returns the latest emails of a user, newest first.
+func (s *EmailService) GetEmailLog(ctx context.Context, userID uint, limit int) ([]*domain.EmailLog, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return s.logs.FindByUserID(ctx, userID, limit)
+}
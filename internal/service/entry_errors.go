@@ -0,0 +1,27 @@
+package service
+
+import "fmt"
+
+// EntryError is the reason an entry of a batch request, by its index, was
+// rejected.
+type EntryError struct {
+	Index   int    `json:"index"`
+	Message string `json:"message"`
+}
+
+// EntryErrors lists every rejected entry of a batch request that is applied
+// all or nothing.
+type EntryErrors struct {
+	Entries []EntryError
+}
+
+func (e *EntryErrors) Add(index int, message string) {
+	e.Entries = append(e.Entries, EntryError{Index: index, Message: message})
+}
+
+func (e *EntryErrors) Error() string {
+	if len(e.Entries) == 1 {
+		return "1 entry is invalid"
+	}
+	return fmt.Sprintf("%d entries are invalid", len(e.Entries))
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+	"vertice-backend/internal/domain"
+)
+
+// AllEvents subscribes an EventBus handler to every event type.
+const AllEvents = "*"
+
+type EventHandler func(ctx context.Context, message *domain.OutboxMessage) error
+
+// EventBus is an in-process sink that fans relayed messages out to
+// subscribed handlers.
+type EventBus struct {
+	mu       sync.RWMutex
+	handlers map[string][]EventHandler
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{handlers: map[string][]EventHandler{}}
+}
+
+func (b *EventBus) Subscribe(eventType string, handler EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+func (b *EventBus) Handle(ctx context.Context, message *domain.OutboxMessage) error {
+	b.mu.RLock()
+	handlers := append(append([]EventHandler{}, b.handlers[message.EventType]...), b.handlers[AllEvents]...)
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NDJSONSink writes every relayed message as one JSON line, e.g. to stdout
+// for log shipping.
+type NDJSONSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewNDJSONSink(w io.Writer) *NDJSONSink {
+	return &NDJSONSink{w: w}
+}
+
+type ndjsonEvent struct {
+	ID            uint            `json:"id"`
+	Type          string          `json:"type"`
+	UserID        uint            `json:"user_id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   uint            `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Data          json.RawMessage `json:"data"`
+}
+
+func (s *NDJSONSink) Handle(_ context.Context, message *domain.OutboxMessage) error {
+	line, err := json.Marshal(ndjsonEvent{
+		ID:            message.ID,
+		Type:          message.EventType,
+		UserID:        message.UserID,
+		AggregateType: message.AggregateType,
+		AggregateID:   message.AggregateID,
+		OccurredAt:    message.OccurredAt,
+		Data:          json.RawMessage(message.Payload),
+	})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"vertice-backend/internal/domain"
+)
+
+// EventRecorder stores domain events. The outbox implementation writes them
+// with the repositories' transaction from ctx, so an event exists if and only
+// if the state change that produced it was committed.
+type EventRecorder interface {
+	Record(ctx context.Context, userID uint, event domain.Event) error
+}
+
+type discardEvents struct{}
+
+func (discardEvents) Record(context.Context, uint, domain.Event) error { return nil }
+
+// noTransaction runs the function directly. Services use it when no
+// transactor is configured.
+type noTransaction struct{}
+
+func (noTransaction) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func recordStatusChanged(ctx context.Context, events EventRecorder, order *domain.Order, from domain.OrderStatus) error {
+	return events.Record(ctx, order.UserID, domain.OrderStatusChanged{
+		OrderID: order.ID,
+		From:    string(from),
+		To:      string(order.Status),
+	})
+}
+
+// stockChange is a persisted change of the stock of a product, or of one of
+// its variants, whose events are still to be recorded. Units are valued at
+// unitCost when it is set and at the product cost otherwise.
+type stockChange struct {
+	product  *domain.Product
+	variant  *domain.ProductVariant
+	previous int
+	reason   string
+	unitCost *float64
+}
+
+// applyStockChange adds delta to the stock of the product and, for variant
+// products, of the variant, whose stocks the product stock sums up.
+func applyStockChange(ctx context.Context, products domain.ProductRepository, variants domain.VariantRepository, product *domain.Product, variant *domain.ProductVariant, delta int, reason string) (stockChange, error) {
+	change := stockChange{product: product, variant: variant, previous: product.Stock, reason: reason}
+	if variant != nil {
+		if variants == nil {
+			return change, errors.New("product variants are not available")
+		}
+		variant.Stock += delta
+		if err := variants.UpdateVariant(ctx, variant); err != nil {
+			return change, err
+		}
+	}
+	product.Stock += delta
+	if err := products.Update(ctx, product, product.UserID); err != nil {
+		return change, err
+	}
+	return change, nil
+}
+
+// record records the change, plus a low stock event when it takes the product
+// stock from above the reorder point to at or below it. Products without a
+// reorder point alert when they run out.
+func (c stockChange) record(ctx context.Context, events EventRecorder) error {
+	product := c.product
+	if product.Stock == c.previous {
+		return nil
+	}
+	event := domain.StockAdjusted{
+		ProductID: product.ID,
+		Code:      product.Code,
+		Previous:  c.previous,
+		Stock:     product.Stock,
+		Delta:     product.Stock - c.previous,
+		Reason:    c.reason,
+		UnitCost:  product.UnitCost(),
+	}
+	if c.unitCost != nil {
+		event.UnitCost = *c.unitCost
+	}
+	if c.variant != nil {
+		stock := c.variant.Stock
+		event.VariantID = c.variant.ID
+		event.VariantCode = c.variant.Code
+		event.VariantStock = &stock
+	}
+	if err := events.Record(ctx, product.UserID, event); err != nil {
+		return err
+	}
+	if c.previous <= product.ReorderPoint || !product.LowStock() {
+		return nil
+	}
+	return recordStockLow(ctx, events, product)
+}
+
+// recordStockLow records a low stock event for the product as it is now.
+func recordStockLow(ctx context.Context, events EventRecorder, product *domain.Product) error {
+	return events.Record(ctx, product.UserID, domain.ProductStockLow{
+		ProductID:       product.ID,
+		Code:            product.Code,
+		Name:            product.Name,
+		Stock:           product.Stock,
+		ReorderPoint:    product.ReorderPoint,
+		ReorderQuantity: product.ReorderQuantity,
+	})
+}
+
+// recordStockAdjusted records a product stock that was set from previous to
+// its current value.
+func recordStockAdjusted(ctx context.Context, events EventRecorder, product *domain.Product, previous int, reason string) error {
+	return stockChange{product: product, previous: previous, reason: reason}.record(ctx, events)
+}
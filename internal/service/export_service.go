@@ -0,0 +1,374 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"vertice-backend/internal/domain"
+	"vertice-backend/pkg/xlsx"
+)
+
+// exportBatchSize is how many products or orders are loaded at a time.
+const exportBatchSize = 500
+
+var exportContentTypes = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"xlsx":   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"ndjson": "application/x-ndjson",
+}
+
+// ExportContentType returns the content type of an export format, and false
+// for unsupported formats.
+func ExportContentType(format string) (string, bool) {
+	contentType, ok := exportContentTypes[format]
+	return contentType, ok
+}
+
+// exportColumn is a named value of an exported record.
+type exportColumn[T any] struct {
+	name  string
+	value func(T) interface{}
+}
+
+func columnNames[T any](columns []exportColumn[T]) []string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.name
+	}
+	return names
+}
+
+// selectColumns picks the named columns in the given order, or all of them
+// when no names are given.
+func selectColumns[T any](all []exportColumn[T], names []string) ([]exportColumn[T], error) {
+	if len(names) == 0 {
+		return all, nil
+	}
+	selected := make([]exportColumn[T], 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if seen[name] {
+			return nil, fmt.Errorf("column %q is selected twice", name)
+		}
+		seen[name] = true
+		found := false
+		for _, column := range all {
+			if column.name == name {
+				selected = append(selected, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q, expected one of %s", name, strings.Join(columnNames(all), ", "))
+		}
+	}
+	return selected, nil
+}
+
+func rowValues[T any](columns []exportColumn[T], record T) []interface{} {
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		values[i] = column.value(record)
+	}
+	return values
+}
+
+// Product columns use the names the product import reads, so an export can
+// be edited and imported again.
+var productExportColumns = []exportColumn[*domain.Product]{
+	{"id", func(p *domain.Product) interface{} { return p.ID }},
+	{"code", func(p *domain.Product) interface{} { return p.Code }},
+	{"name", func(p *domain.Product) interface{} { return p.Name }},
+	{"description", func(p *domain.Product) interface{} { return p.Description }},
+	{"price", func(p *domain.Product) interface{} { return p.Price }},
+	{"stock", func(p *domain.Product) interface{} { return p.Stock }},
+	{"reorder_point", func(p *domain.Product) interface{} { return p.ReorderPoint }},
+	{"reorder_quantity", func(p *domain.Product) interface{} { return p.ReorderQuantity }},
+	{"variants", func(p *domain.Product) interface{} { return len(p.Variants) }},
+	{"categories", func(p *domain.Product) interface{} {
+		names := make([]string, len(p.Categories))
+		for i, category := range p.Categories {
+			names[i] = category.Name
+		}
+		return strings.Join(names, "; ")
+	}},
+	{"tags", func(p *domain.Product) interface{} { return strings.Join(tagNames(p.Tags), "; ") }},
+	{"created_at", func(p *domain.Product) interface{} { return p.CreatedAt }},
+	{"updated_at", func(p *domain.Product) interface{} { return p.UpdatedAt }},
+}
+
+var orderExportColumns = []exportColumn[*domain.Order]{
+	{"id", func(o *domain.Order) interface{} { return o.ID }},
+	{"status", func(o *domain.Order) interface{} { return string(o.Status) }},
+	{"total_amount", func(o *domain.Order) interface{} { return o.TotalAmount }},
+	{"lines", func(o *domain.Order) interface{} { return len(o.Items) }},
+	{"units", func(o *domain.Order) interface{} {
+		units := 0
+		for _, item := range o.Items {
+			units += item.Quantity
+		}
+		return units
+	}},
+	{"created_at", func(o *domain.Order) interface{} { return o.CreatedAt }},
+	{"updated_at", func(o *domain.Order) interface{} { return o.UpdatedAt }},
+}
+
+// orderLine is an order item with the order it belongs to.
+type orderLine struct {
+	order *domain.Order
+	item  *domain.OrderItem
+}
+
+var orderLineExportColumns = []exportColumn[orderLine]{
+	{"order_id", func(l orderLine) interface{} { return l.order.ID }},
+	{"order_status", func(l orderLine) interface{} { return string(l.order.Status) }},
+	{"order_created_at", func(l orderLine) interface{} { return l.order.CreatedAt }},
+	{"line_id", func(l orderLine) interface{} { return l.item.ID }},
+	{"product_id", func(l orderLine) interface{} { return l.item.ProductID }},
+	{"product_code", func(l orderLine) interface{} { return l.item.Product.Code }},
+	{"product_name", func(l orderLine) interface{} { return l.item.Product.Name }},
+	{"variant_id", func(l orderLine) interface{} {
+		if l.item.VariantID == nil {
+			return nil
+		}
+		return *l.item.VariantID
+	}},
+	{"variant_code", func(l orderLine) interface{} {
+		if l.item.Variant == nil {
+			return ""
+		}
+		return l.item.Variant.Code
+	}},
+	{"quantity", func(l orderLine) interface{} { return l.item.Quantity }},
+	{"unit_price", func(l orderLine) interface{} { return l.item.UnitPrice }},
+	{"subtotal", func(l orderLine) interface{} { return l.item.Subtotal }},
+}
+
+func tagNames(tags []domain.Tag) []string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return names
+}
+
+type ExportService struct {
+	productRepo  domain.ProductRepository
+	orderRepo    domain.OrderRepository
+	categoryRepo domain.CategoryRepository
+}
+
+type ExportServiceOption func(*ExportService)
+
+// WithExportCategories lets product exports filter by category.
+func WithExportCategories(categoryRepo domain.CategoryRepository) ExportServiceOption {
+	return func(s *ExportService) {
+		s.categoryRepo = categoryRepo
+	}
+}
+
+func NewExportService(productRepo domain.ProductRepository, orderRepo domain.OrderRepository, opts ...ExportServiceOption) *ExportService {
+	s := &ExportService{productRepo: productRepo, orderRepo: orderRepo}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ExportRequest selects the format and the columns of an export. Without
+// columns, all columns are exported.
+type ExportRequest struct {
+	Format  string
+	Columns []string
+}
+
+// ExportProducts writes the products matching the filter to w. Nothing is
+// written when the request or the filter is invalid.
+func (s *ExportService) ExportProducts(ctx context.Context, userID uint, w io.Writer, req ExportRequest, filter ProductListFilter) error {
+	columns, err := selectColumns(productExportColumns, req.Columns)
+	if err != nil {
+		return err
+	}
+	repoFilter, err := resolveProductFilter(ctx, s.categoryRepo, userID, filter)
+	if err != nil {
+		return err
+	}
+	return export(w, req.Format, "Products", columnNames(columns), func(write func([]interface{}) error) error {
+		return s.productRepo.FindInBatches(ctx, userID, repoFilter, exportBatchSize, func(products []*domain.Product) error {
+			for _, product := range products {
+				if err := write(rowValues(columns, product)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// ExportOrders writes one record per order matching the filter to w.
+func (s *ExportService) ExportOrders(ctx context.Context, userID uint, w io.Writer, req ExportRequest, filter domain.OrderFilter) error {
+	columns, err := selectColumns(orderExportColumns, req.Columns)
+	if err != nil {
+		return err
+	}
+	if err := validateOrderFilter(filter); err != nil {
+		return err
+	}
+	return export(w, req.Format, "Orders", columnNames(columns), func(write func([]interface{}) error) error {
+		return s.orderRepo.FindInBatches(ctx, userID, filter, exportBatchSize, func(orders []*domain.Order) error {
+			for _, order := range orders {
+				if err := write(rowValues(columns, order)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// ExportOrderLines writes one record per item of the orders matching the
+// filter to w.
+func (s *ExportService) ExportOrderLines(ctx context.Context, userID uint, w io.Writer, req ExportRequest, filter domain.OrderFilter) error {
+	columns, err := selectColumns(orderLineExportColumns, req.Columns)
+	if err != nil {
+		return err
+	}
+	if err := validateOrderFilter(filter); err != nil {
+		return err
+	}
+	return export(w, req.Format, "Order lines", columnNames(columns), func(write func([]interface{}) error) error {
+		return s.orderRepo.FindInBatches(ctx, userID, filter, exportBatchSize, func(orders []*domain.Order) error {
+			for _, order := range orders {
+				for i := range order.Items {
+					if err := write(rowValues(columns, orderLine{order: order, item: &order.Items[i]})); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// export writes the header and the records produced by rows in the format.
+func export(w io.Writer, format, title string, header []string, rows func(write func([]interface{}) error) error) error {
+	if _, ok := exportContentTypes[format]; !ok {
+		return errors.New("unsupported export format, expected csv, xlsx or ndjson")
+	}
+	encoder, err := newExportEncoder(w, format, title, header)
+	if err != nil {
+		return err
+	}
+	if err := rows(encoder.write); err != nil {
+		return err
+	}
+	return encoder.close()
+}
+
+type exportEncoder interface {
+	write(values []interface{}) error
+	close() error
+}
+
+func newExportEncoder(w io.Writer, format, title string, header []string) (exportEncoder, error) {
+	switch format {
+	case "xlsx":
+		writer, err := xlsx.NewWriter(w, title)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, len(header))
+		for i, name := range header {
+			values[i] = name
+		}
+		return xlsxEncoder{writer}, writer.WriteRow(values...)
+	case "ndjson":
+		return &ndjsonEncoder{w: bufio.NewWriter(w), header: header}, nil
+	default:
+		writer := csv.NewWriter(w)
+		return csvEncoder{writer}, writer.Write(header)
+	}
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e csvEncoder) write(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = formatExportValue(value)
+	}
+	return e.w.Write(record)
+}
+
+func (e csvEncoder) close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type xlsxEncoder struct {
+	w *xlsx.Writer
+}
+
+func (e xlsxEncoder) write(values []interface{}) error {
+	return e.w.WriteRow(values...)
+}
+
+func (e xlsxEncoder) close() error {
+	return e.w.Close()
+}
+
+// ndjsonEncoder writes each record as a JSON object with the keys in column
+// order.
+type ndjsonEncoder struct {
+	w      *bufio.Writer
+	header []string
+}
+
+func (e *ndjsonEncoder) write(values []interface{}) error {
+	e.w.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			e.w.WriteByte(',')
+		}
+		key, _ := json.Marshal(e.header[i])
+		e.w.Write(key)
+		e.w.WriteByte(':')
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		e.w.Write(encoded)
+	}
+	_, err := e.w.WriteString("}\n")
+	return err
+}
+
+func (e *ndjsonEncoder) close() error {
+	return e.w.Flush()
+}
+
+func formatExportValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,34 @@
+package service
+
+import "fmt"
+
+// FieldError is the reason a field of a request was rejected.
+type FieldError struct {
+	Field   string `json:"field" example:"password"`
+	Message string `json:"message" example:"password must be at least 8 characters"`
+}
+
+// FieldErrors lists every rejected field of a request, so that a form can
+// show them all at once.
+type FieldErrors struct {
+	Fields []FieldError
+}
+
+func (e *FieldErrors) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// Err returns e when a field was rejected and nil otherwise.
+func (e *FieldErrors) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *FieldErrors) Error() string {
+	if len(e.Fields) == 1 {
+		return e.Fields[0].Message
+	}
+	return fmt.Sprintf("%d fields are invalid", len(e.Fields))
+}
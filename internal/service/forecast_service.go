@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+	"vertice-backend/internal/domain"
+)
+
+const (
+	defaultForecastWindowDays = 28
+	maxForecastWindowDays     = 365
+	defaultLeadTimeDays       = 7
+	defaultCoverDays          = 30
+)
+
+// ForecastService projects when products run out from how fast they sell.
+type ForecastService struct {
+	analyticsRepo     domain.AnalyticsRepository
+	productRepo       domain.ProductRepository
+	supplierRepo      domain.SupplierRepository
+	purchaseOrderRepo domain.PurchaseOrderRepository
+	now               func() time.Time
+}
+
+func NewForecastService(analyticsRepo domain.AnalyticsRepository, productRepo domain.ProductRepository, supplierRepo domain.SupplierRepository, purchaseOrderRepo domain.PurchaseOrderRepository) *ForecastService {
+	return &ForecastService{
+		analyticsRepo:     analyticsRepo,
+		productRepo:       productRepo,
+		supplierRepo:      supplierRepo,
+		purchaseOrderRepo: purchaseOrderRepo,
+		now:               time.Now,
+	}
+}
+
+// ForecastRequest configures a forecast. Sales velocity is measured over the
+// WindowDays full days before today, as their average or, when Alpha is set,
+// exponentially smoothed with Alpha as the weight of each newer day. Reorder
+// suggestions cover LeadTimeDays until delivery plus CoverDays after it.
+// With a SupplierID only the supplier's products are forecast, with the
+// supplier's lead times where known.
+type ForecastRequest struct {
+	WindowDays   int
+	Alpha        *float64
+	LeadTimeDays int
+	CoverDays    int
+	SupplierID   *uint
+}
+
+// StockForecast is the forecast of a product, or of one of its variants.
+// DaysOfCover and StockOutDate are nil when it does not sell.
+type StockForecast struct {
+	ProductID         uint
+	Code              string
+	Name              string
+	VariantID         *uint
+	VariantCode       string
+	Stock             int
+	OnOrder           int
+	AverageDailySales float64
+	DailySales        float64
+	DaysOfCover       *float64
+	StockOutDate      *time.Time
+	LeadTimeDays      int
+	SuggestedQuantity int
+}
+
+// AtRisk reports whether the stock runs out before an order placed today
+// would arrive.
+func (f StockForecast) AtRisk() bool {
+	return f.DaysOfCover != nil && *f.DaysOfCover < float64(f.LeadTimeDays)
+}
+
+// Forecast lists the forecasts, those running out soonest first. For a
+// supplier, PurchaseOrder is a draft of the suggested quantities, ready for
+// CreatePurchaseOrder; it is nil when nothing needs ordering.
+type Forecast struct {
+	WindowDays    int
+	Lines         []StockForecast
+	PurchaseOrder *PurchaseOrderRequest
+}
+
+// forecastKey identifies a product, or one of its variants.
+type forecastKey struct {
+	productID uint
+	variantID uint
+}
+
+func (s *ForecastService) Forecast(ctx context.Context, userID uint, req ForecastRequest) (*Forecast, error) {
+	if req.WindowDays == 0 {
+		req.WindowDays = defaultForecastWindowDays
+	}
+	if req.WindowDays < 1 || req.WindowDays > maxForecastWindowDays {
+		return nil, errors.New("window_days must be between 1 and 365")
+	}
+	if req.Alpha != nil && (*req.Alpha <= 0 || *req.Alpha > 1) {
+		return nil, errors.New("alpha must be greater than 0 and at most 1")
+	}
+	if req.LeadTimeDays < 0 || req.CoverDays < 0 {
+		return nil, errors.New("lead_time_days and cover_days cannot be negative")
+	}
+	if req.LeadTimeDays == 0 {
+		req.LeadTimeDays = defaultLeadTimeDays
+	}
+	if req.CoverDays == 0 {
+		req.CoverDays = defaultCoverDays
+	}
+
+	var leadTimes map[uint]int
+	if req.SupplierID != nil {
+		if _, err := s.supplierRepo.FindByIDAndUserID(ctx, *req.SupplierID, userID); err != nil {
+			return nil, errors.New("supplier not found")
+		}
+		links, err := s.supplierRepo.FindProducts(ctx, *req.SupplierID, userID)
+		if err != nil {
+			return nil, err
+		}
+		leadTimes = make(map[uint]int, len(links))
+		for _, link := range links {
+			leadTimes[link.ProductID] = link.LeadTimeDays
+		}
+	}
+
+	products, err := s.productRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	since := today.AddDate(0, 0, -req.WindowDays)
+	sales, err := s.analyticsRepo.DailyProductSales(ctx, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	daily := map[forecastKey][]float64{}
+	for _, sale := range sales {
+		day := int(sale.Day.UTC().Sub(since).Hours() / 24)
+		if day < 0 || day >= req.WindowDays {
+			continue
+		}
+		key := forecastKey{productID: sale.ProductID}
+		if sale.VariantID != nil {
+			key.variantID = *sale.VariantID
+		}
+		if daily[key] == nil {
+			daily[key] = make([]float64, req.WindowDays)
+		}
+		daily[key][day] += float64(sale.Quantity)
+	}
+
+	onOrder, err := s.onOrder(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	forecast := &Forecast{WindowDays: req.WindowDays, Lines: []StockForecast{}}
+	for _, product := range products {
+		if product.IsBundle() {
+			continue
+		}
+		leadTime := req.LeadTimeDays
+		if leadTimes != nil {
+			days, ok := leadTimes[product.ID]
+			if !ok {
+				continue
+			}
+			if days > 0 {
+				leadTime = days
+			}
+		}
+
+		line := StockForecast{
+			ProductID:    product.ID,
+			Code:         product.Code,
+			Name:         product.Name,
+			LeadTimeDays: leadTime,
+		}
+		if !product.HasVariants() {
+			line.Stock = product.Stock
+			key := forecastKey{productID: product.ID}
+			forecast.Lines = append(forecast.Lines, s.project(line, daily[key], onOrder[key], req, today))
+			continue
+		}
+		for _, variant := range product.Variants {
+			variantID := variant.ID
+			line.VariantID = &variantID
+			line.VariantCode = variant.Code
+			line.Stock = variant.Stock
+			key := forecastKey{productID: product.ID, variantID: variant.ID}
+			forecast.Lines = append(forecast.Lines, s.project(line, daily[key], onOrder[key], req, today))
+		}
+	}
+
+	sort.SliceStable(forecast.Lines, func(i, j int) bool {
+		a, b := forecast.Lines[i].DaysOfCover, forecast.Lines[j].DaysOfCover
+		return a != nil && (b == nil || *a < *b)
+	})
+
+	if req.SupplierID != nil {
+		draft := &PurchaseOrderRequest{SupplierID: *req.SupplierID}
+		for _, line := range forecast.Lines {
+			if line.SuggestedQuantity > 0 {
+				draft.Lines = append(draft.Lines, PurchaseOrderLineRequest{
+					ProductID: line.ProductID,
+					VariantID: line.VariantID,
+					Quantity:  line.SuggestedQuantity,
+				})
+			}
+		}
+		if len(draft.Lines) > 0 {
+			forecast.PurchaseOrder = draft
+		}
+	}
+	return forecast, nil
+}
+
+// project fills in the sales velocity of a line from its daily sales and
+// projects its stock from it.
+func (s *ForecastService) project(line StockForecast, daily []float64, onOrder int, req ForecastRequest, today time.Time) StockForecast {
+	var total float64
+	for _, quantity := range daily {
+		total += quantity
+	}
+	line.AverageDailySales = roundRate(total / float64(req.WindowDays))
+	line.DailySales = line.AverageDailySales
+	if req.Alpha != nil && len(daily) > 0 {
+		smoothed := daily[0]
+		for _, quantity := range daily[1:] {
+			smoothed = *req.Alpha*quantity + (1-*req.Alpha)*smoothed
+		}
+		line.DailySales = roundRate(smoothed)
+	}
+	line.OnOrder = onOrder
+
+	if line.DailySales > 0 {
+		cover := math.Max(float64(line.Stock), 0) / line.DailySales
+		rounded := math.Floor(cover*10) / 10
+		stockOut := today.AddDate(0, 0, int(cover))
+		line.DaysOfCover = &rounded
+		line.StockOutDate = &stockOut
+	}
+	needed := int(math.Ceil(line.DailySales * float64(line.LeadTimeDays+req.CoverDays)))
+	line.SuggestedQuantity = max(needed-line.Stock-line.OnOrder, 0)
+	return line
+}
+
+// onOrder sums what is still outstanding on the sent purchase orders.
+func (s *ForecastService) onOrder(ctx context.Context, userID uint) (map[forecastKey]int, error) {
+	outstanding := map[forecastKey]int{}
+	for _, status := range []domain.PurchaseOrderStatus{domain.PurchaseOrderStatusSent, domain.PurchaseOrderStatusPartiallyReceived} {
+		orders, err := s.purchaseOrderRepo.FindByUserID(ctx, userID, status)
+		if err != nil {
+			return nil, err
+		}
+		for _, order := range orders {
+			for i := range order.Lines {
+				line := &order.Lines[i]
+				key := forecastKey{productID: line.ProductID}
+				if line.VariantID != nil {
+					key.variantID = *line.VariantID
+				}
+				outstanding[key] += line.Outstanding()
+			}
+		}
+	}
+	return outstanding, nil
+}
+
+// roundRate rounds a daily rate to three decimals.
+func roundRate(rate float64) float64 {
+	return math.Round(rate*1000) / 1000
+}
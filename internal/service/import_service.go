@@ -0,0 +1,550 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"vertice-backend/internal/domain"
+	"vertice-backend/pkg/xlsx"
+)
+
+const (
+	// DefaultMaxImportSize is the largest import file accepted.
+	DefaultMaxImportSize = 20 << 20
+	// DefaultImportAsyncThreshold is the number of rows above which an
+	// import runs in the background.
+	DefaultImportAsyncThreshold = 500
+	maxImportRows               = 50000
+	// importProgressRows is how often a running import saves its counts.
+	// The saves double as a heartbeat: a job not updated for
+	// importStaleAfter is taken to have died with its server.
+	importProgressRows = 100
+	importStaleAfter   = 10 * time.Minute
+)
+
+// Product fields an import column can map to.
+const (
+	importFieldCode            = "code"
+	importFieldName            = "name"
+	importFieldDescription     = "description"
+	importFieldPrice           = "price"
+	importFieldCost            = "cost"
+	importFieldStock           = "stock"
+	importFieldReorderPoint    = "reorder_point"
+	importFieldReorderQuantity = "reorder_quantity"
+)
+
+// importHeaderAliases maps normalized column headers to product fields.
+var importHeaderAliases = map[string]string{
+	"code":             importFieldCode,
+	"sku":              importFieldCode,
+	"product_code":     importFieldCode,
+	"name":             importFieldName,
+	"title":            importFieldName,
+	"product_name":     importFieldName,
+	"description":      importFieldDescription,
+	"price":            importFieldPrice,
+	"unit_price":       importFieldPrice,
+	"cost":             importFieldCost,
+	"stock":            importFieldStock,
+	"quantity":         importFieldStock,
+	"qty":              importFieldStock,
+	"reorder_point":    importFieldReorderPoint,
+	"reorder_quantity": importFieldReorderQuantity,
+}
+
+type ImportService struct {
+	productRepo    domain.ProductRepository
+	jobRepo        domain.ImportJobRepository
+	tx             domain.Transactor
+	events         EventRecorder
+	priceChanges   domain.PriceChangeRepository
+	asyncThreshold int
+	running        sync.WaitGroup
+}
+
+type ImportServiceOption func(*ImportService)
+
+// WithImportTransactor makes each imported row, its stock change and its
+// events commit atomically.
+func WithImportTransactor(tx domain.Transactor) ImportServiceOption {
+	return func(s *ImportService) {
+		s.tx = tx
+	}
+}
+
+// WithImportEvents sets where stock events of imported rows are recorded.
+func WithImportEvents(events EventRecorder) ImportServiceOption {
+	return func(s *ImportService) {
+		s.events = events
+	}
+}
+
+// WithImportPriceHistory records the price changes of imported rows.
+func WithImportPriceHistory(priceChangeRepo domain.PriceChangeRepository) ImportServiceOption {
+	return func(s *ImportService) {
+		s.priceChanges = priceChangeRepo
+	}
+}
+
+// WithImportAsyncThreshold sets the number of rows above which an import
+// runs in the background.
+func WithImportAsyncThreshold(rows int) ImportServiceOption {
+	return func(s *ImportService) {
+		s.asyncThreshold = rows
+	}
+}
+
+func NewImportService(productRepo domain.ProductRepository, jobRepo domain.ImportJobRepository, opts ...ImportServiceOption) *ImportService {
+	s := &ImportService{
+		productRepo:    productRepo,
+		jobRepo:        jobRepo,
+		tx:             noTransaction{},
+		events:         discardEvents{},
+		asyncThreshold: DefaultImportAsyncThreshold,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// MaxSize is the largest import file accepted, in bytes.
+func (s *ImportService) MaxSize() int64 {
+	return DefaultMaxImportSize
+}
+
+// ImportRequest is an uploaded product file. Mapping maps column headers of
+// the file to product fields, for headers that are not recognised on their own.
+type ImportRequest struct {
+	Filename string
+	Body     []byte
+	DryRun   bool
+	Mapping  map[string]string
+}
+
+// importRow is a data row of an import file with its line number.
+type importRow struct {
+	line   int
+	values []string
+}
+
+// Import creates and updates products from a CSV or XLSX file, matching rows
+// to existing products by code. Rows are imported one by one, so a rejected
+// row does not prevent the others. Files with more rows than the async
+// threshold are imported in the background and the returned job is pending.
+func (s *ImportService) Import(ctx context.Context, userID uint, req ImportRequest) (*domain.ImportJob, error) {
+	if len(req.Body) == 0 {
+		return nil, errors.New("import file is empty")
+	}
+	if len(req.Body) > DefaultMaxImportSize {
+		return nil, errors.New("import file is too large")
+	}
+
+	format, records, err := parseImportFile(req.Filename, req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("import file has no header row")
+	}
+	columns, err := mapImportColumns(records[0].values, req.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []importRow
+	for _, record := range records[1:] {
+		if !isBlankRecord(record.values) {
+			rows = append(rows, record)
+		}
+	}
+	if len(rows) > maxImportRows {
+		return nil, fmt.Errorf("import file has more than %d rows", maxImportRows)
+	}
+
+	job := &domain.ImportJob{
+		UserID:    userID,
+		Filename:  path.Base(req.Filename),
+		Format:    format,
+		DryRun:    req.DryRun,
+		Status:    domain.ImportJobPending,
+		TotalRows: len(rows),
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	if len(rows) <= s.asyncThreshold {
+		s.run(ctx, job, columns, rows)
+		return job, nil
+	}
+
+	background := *job
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		s.run(context.WithoutCancel(ctx), &background, columns, rows)
+	}()
+	return job, nil
+}
+
+// Wait blocks until the background imports have finished.
+func (s *ImportService) Wait() {
+	s.running.Wait()
+}
+
+// FailStaleJobs marks imports that stopped saving progress, because the
+// server running them stopped, as failed. Rows imported before the stop
+// are kept; the counts on the job show how far it got.
+func (s *ImportService) FailStaleJobs(ctx context.Context, now time.Time) (int64, error) {
+	return s.jobRepo.FailStale(ctx, now.Add(-importStaleAfter), "the import was interrupted before it finished; some rows may have been imported")
+}
+
+// Run fails stale imports at once and then at every interval until ctx is
+// cancelled.
+func (s *ImportService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := s.FailStaleJobs(ctx, time.Now()); err != nil {
+			log.Printf("imports: failing stale jobs failed: %v", err)
+		} else if n > 0 {
+			log.Printf("imports: marked %d interrupted jobs as failed", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ImportService) GetJob(ctx context.Context, id, userID uint) (*domain.ImportJob, error) {
+	job, err := s.jobRepo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("import job not found")
+	}
+	return job, nil
+}
+
+// GetJobErrors returns the rejected rows of an import in file order.
+func (s *ImportService) GetJobErrors(ctx context.Context, id, userID uint) ([]domain.ImportJobError, error) {
+	job, err := s.GetJob(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.jobRepo.FindErrors(ctx, job.ID)
+}
+
+// run imports the rows and stores the outcome on the job.
+func (s *ImportService) run(ctx context.Context, job *domain.ImportJob, columns map[string]int, rows []importRow) {
+	job.Status = domain.ImportJobRunning
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		log.Printf("imports: starting job %d failed: %v", job.ID, err)
+	}
+
+	var rowErrors []domain.ImportJobError
+	seen := make(map[string]int, len(rows))
+	for i, row := range rows {
+		if i > 0 && i%importProgressRows == 0 {
+			if err := s.jobRepo.Update(ctx, job); err != nil {
+				log.Printf("imports: saving progress of job %d failed: %v", job.ID, err)
+			}
+		}
+		code := importValue(row.values, columns, importFieldCode)
+		created, err := s.importRow(ctx, job, columns, row, seen)
+		if err != nil {
+			job.Failed++
+			rowErrors = append(rowErrors, domain.ImportJobError{JobID: job.ID, Row: row.line, Code: code, Message: err.Error()})
+			continue
+		}
+		if created {
+			job.Created++
+		} else {
+			job.Updated++
+		}
+	}
+
+	job.Status = domain.ImportJobCompleted
+	if err := s.jobRepo.CreateErrors(ctx, rowErrors); err != nil {
+		job.Status = domain.ImportJobFailed
+		job.Error = "storing the error report failed"
+		log.Printf("imports: storing errors of job %d failed: %v", job.ID, err)
+	}
+	now := time.Now()
+	job.FinishedAt = &now
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		log.Printf("imports: finishing job %d failed: %v", job.ID, err)
+	}
+}
+
+// importRow creates or updates the product of a row and reports whether it
+// was created. Blank cells leave the current value of a product unchanged.
+func (s *ImportService) importRow(ctx context.Context, job *domain.ImportJob, columns map[string]int, row importRow, seen map[string]int) (bool, error) {
+	code := importValue(row.values, columns, importFieldCode)
+	if code == "" {
+		return false, errors.New("code is required")
+	}
+	if line, ok := seen[code]; ok {
+		return false, fmt.Errorf("code is already used on row %d", line)
+	}
+	seen[code] = row.line
+
+	price, err := importFloat(row.values, columns, importFieldPrice)
+	if err != nil {
+		return false, err
+	}
+	cost, err := importFloat(row.values, columns, importFieldCost)
+	if err != nil {
+		return false, err
+	}
+	stock, err := importInt(row.values, columns, importFieldStock)
+	if err != nil {
+		return false, err
+	}
+	reorderPoint, err := importInt(row.values, columns, importFieldReorderPoint)
+	if err != nil {
+		return false, err
+	}
+	reorderQuantity, err := importInt(row.values, columns, importFieldReorderQuantity)
+	if err != nil {
+		return false, err
+	}
+	name := importValue(row.values, columns, importFieldName)
+	description := importValue(row.values, columns, importFieldDescription)
+
+	product, err := s.productRepo.FindByCodeAndUserID(ctx, code, job.UserID)
+	if err != nil || product == nil {
+		if name == "" {
+			return false, errors.New("name is required for new products")
+		}
+		product = &domain.Product{UserID: job.UserID, Code: code, Name: name, Description: description}
+		if price != nil {
+			product.Price = *price
+		}
+		if cost != nil {
+			product.Cost = *cost
+		}
+		if stock != nil {
+			product.Stock = *stock
+		}
+		if reorderPoint != nil {
+			product.ReorderPoint = *reorderPoint
+		}
+		if reorderQuantity != nil {
+			product.ReorderQuantity = *reorderQuantity
+		}
+		if job.DryRun {
+			return true, nil
+		}
+		return true, s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := s.productRepo.Create(ctx, product); err != nil {
+				return err
+			}
+			return recordStockAdjusted(ctx, s.events, product, 0, domain.StockReasonImport)
+		})
+	}
+
+	if name != "" {
+		product.Name = name
+	}
+	if description != "" {
+		product.Description = description
+	}
+	previousPrice := product.Price
+	if price != nil {
+		product.Price = *price
+	}
+	if cost != nil {
+		if product.IsBundle() {
+			return false, errors.New("cost of a bundle is derived from its components")
+		}
+		product.Cost = *cost
+	}
+	if reorderPoint != nil {
+		product.ReorderPoint = *reorderPoint
+	}
+	if reorderQuantity != nil {
+		product.ReorderQuantity = *reorderQuantity
+	}
+	delta := 0
+	if stock != nil {
+		if product.HasVariants() && *stock != product.Stock {
+			return false, errors.New("stock of a product with variants is set per variant")
+		}
+		if *stock != product.Stock {
+			if err := directStockError(product); err != nil {
+				return false, err
+			}
+		}
+		delta = *stock - product.Stock
+	}
+	if job.DryRun {
+		return false, nil
+	}
+
+	return false, s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if delta == 0 {
+			if err := s.productRepo.Update(ctx, product, job.UserID); err != nil {
+				return err
+			}
+		} else {
+			change, err := applyStockChange(ctx, s.productRepo, nil, product, nil, delta, domain.StockReasonImport)
+			if err != nil {
+				return err
+			}
+			if err := change.record(ctx, s.events); err != nil {
+				return err
+			}
+		}
+		return recordPriceChange(ctx, s.priceChanges, s.events, product, previousPrice, domain.PriceChangeSourceImport, &job.UserID)
+	})
+}
+
+// parseImportFile reads the records of a CSV or XLSX file from the first
+// non-blank one. XLSX files are recognised by their zip signature, so the
+// file extension does not matter.
+func parseImportFile(filename string, body []byte) (string, []importRow, error) {
+	var records []importRow
+	if bytes.HasPrefix(body, []byte("PK\x03\x04")) {
+		values, err := xlsx.Read(bytes.NewReader(body), int64(len(body)))
+		if err != nil {
+			return "", nil, errors.New("invalid xlsx file")
+		}
+		for i, record := range values {
+			if len(records) > 0 || !isBlankRecord(record) {
+				records = append(records, importRow{line: i + 1, values: record})
+			}
+		}
+		return "xlsx", records, nil
+	}
+	if strings.EqualFold(path.Ext(filename), ".xlsx") {
+		return "", nil, errors.New("invalid xlsx file")
+	}
+
+	body = bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.Comma = csvDelimiter(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid csv file: %v", err)
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, importRow{line: line, values: record})
+	}
+	return "csv", records, nil
+}
+
+// csvDelimiter picks a semicolon when the header row has more semicolons than
+// commas, as spreadsheet programs write in locales with a decimal comma.
+func csvDelimiter(body []byte) rune {
+	header, _, _ := bytes.Cut(body, []byte("\n"))
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
+
+// mapImportColumns maps product fields to column indexes. Headers listed in
+// mapping take the given field; other headers are matched by name.
+func mapImportColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	explicit := make(map[string]string, len(mapping))
+	for column, field := range mapping {
+		field = normalizeImportHeader(field)
+		if _, ok := importHeaderAliases[field]; !ok || importHeaderAliases[field] != field {
+			return nil, fmt.Errorf("unknown product field %q in mapping", field)
+		}
+		explicit[normalizeImportHeader(column)] = field
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = normalizeImportHeader(name)
+		field, ok := explicit[name]
+		if !ok {
+			if field, ok = importHeaderAliases[name]; !ok {
+				continue
+			}
+		}
+		if _, ok := columns[field]; ok {
+			return nil, fmt.Errorf("more than one column maps to %s", field)
+		}
+		columns[field] = i
+	}
+	if _, ok := columns[importFieldCode]; !ok {
+		return nil, errors.New("import file needs a code column")
+	}
+	return columns, nil
+}
+
+func normalizeImportHeader(header string) string {
+	header = strings.ToLower(strings.TrimSpace(header))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(header)
+}
+
+func importValue(values []string, columns map[string]int, field string) string {
+	i, ok := columns[field]
+	if !ok || i >= len(values) {
+		return ""
+	}
+	return strings.TrimSpace(values[i])
+}
+
+func importFloat(values []string, columns map[string]int, field string) (*float64, error) {
+	value := importValue(values, columns, field)
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number", field)
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("%s must be a finite number", field)
+	}
+	if f < 0 {
+		return nil, fmt.Errorf("%s cannot be negative", field)
+	}
+	return &f, nil
+}
+
+func importInt(values []string, columns map[string]int, field string) (*int, error) {
+	value := importValue(values, columns, field)
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a whole number", field)
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("%s cannot be negative", field)
+	}
+	return &n, nil
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"vertice-backend/internal/domain"
+)
+
+const (
+	// DefaultExpiringDays is how far ahead the expiring lots report looks
+	// when no horizon is given.
+	DefaultExpiringDays = 30
+	maxExpiringDays     = 365
+)
+
+var errLotStock = errors.New("stock of a batch-managed product changes through its lots; receive or write off lots instead")
+
+type LotService struct {
+	lotRepo     domain.LotRepository
+	productRepo domain.ProductRepository
+	variantRepo domain.VariantRepository
+	tx          domain.Transactor
+	events      EventRecorder
+	now         func() time.Time
+}
+
+type LotServiceOption func(*LotService)
+
+// WithLotTransactor makes a lot change, the stock change and its events
+// commit atomically.
+func WithLotTransactor(tx domain.Transactor) LotServiceOption {
+	return func(s *LotService) {
+		s.tx = tx
+	}
+}
+
+// WithLotEvents sets where the stock events of lot receipts and write-offs
+// are recorded.
+func WithLotEvents(events EventRecorder) LotServiceOption {
+	return func(s *LotService) {
+		s.events = events
+	}
+}
+
+// WithLotVariants lets lots hold stock of product variants.
+func WithLotVariants(variantRepo domain.VariantRepository) LotServiceOption {
+	return func(s *LotService) {
+		s.variantRepo = variantRepo
+	}
+}
+
+func NewLotService(lotRepo domain.LotRepository, productRepo domain.ProductRepository, opts ...LotServiceOption) *LotService {
+	s := &LotService{
+		lotRepo:     lotRepo,
+		productRepo: productRepo,
+		tx:          noTransaction{},
+		events:      discardEvents{},
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ReceiveLotRequest books stock of a batch-managed product, naming the
+// variant for products with variants, into the lot with the given number.
+type ReceiveLotRequest struct {
+	ProductID uint       `json:"product_id" example:"1"`
+	VariantID *uint      `json:"variant_id,omitempty"`
+	Number    string     `json:"number" example:"L2024-031"`
+	ExpiresAt *time.Time `json:"expires_at" example:"2024-06-30T00:00:00Z"`
+	Quantity  int        `json:"quantity" example:"24"`
+}
+
+// SetBatchManaged turns lot tracking of a product on or off. Orders for a
+// batch-managed product can only be allocated from its lots, so it cannot
+// be turned on while the product has stock: write the stock off and receive
+// it into lots instead.
+func (s *LotService) SetBatchManaged(ctx context.Context, productID, userID uint, enabled bool) (*domain.Product, error) {
+	product, err := s.productRepo.FindByIDAndUserID(ctx, productID, userID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+	if enabled && !product.BatchManaged {
+		if product.Serialized {
+			return nil, errors.New("serialized products cannot be batch-managed")
+		}
+		if product.IsBundle() {
+			return nil, errors.New("bundles cannot be batch-managed")
+		}
+		if product.Stock > 0 {
+			return nil, errors.New("stock must be zero to turn on batch management; receive it into lots instead")
+		}
+	}
+	product.BatchManaged = enabled
+	if err := s.productRepo.Update(ctx, product, userID); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// ReceiveLot adds stock to a batch-managed product and books it into a lot.
+// Receiving into an existing lot number adds to that lot.
+func (s *LotService) ReceiveLot(ctx context.Context, userID uint, req ReceiveLotRequest) (*domain.Lot, error) {
+	if req.Quantity <= 0 {
+		return nil, errors.New("quantity must be greater than 0")
+	}
+	var lot *domain.Lot
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		product, err := s.productRepo.FindByIDAndUserID(ctx, req.ProductID, userID)
+		if err != nil {
+			return errors.New("product not found")
+		}
+		var variant *domain.ProductVariant
+		if req.VariantID != nil {
+			v, ok := product.Variant(*req.VariantID)
+			if !ok {
+				return errors.New("variant not found")
+			}
+			variant = v
+		} else if product.HasVariants() {
+			return errors.New("variant_id is required for product: " + product.Name)
+		}
+
+		if lot, err = receiveIntoLot(ctx, s.lotRepo, product, variant, req.Number, req.ExpiresAt, req.Quantity); err != nil {
+			return err
+		}
+		change, err := applyStockChange(ctx, s.productRepo, s.variantRepo, product, variant, req.Quantity, domain.StockReasonLotReceived)
+		if err != nil {
+			return err
+		}
+		return change.record(ctx, s.events)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.lotRepo.FindByIDAndUserID(ctx, lot.ID, userID)
+}
+
+// receiveIntoLot adds quantity to the lot of the product or variant with the
+// given number, creating the lot if there is none. The caller changes the
+// stock.
+func receiveIntoLot(ctx context.Context, lotRepo domain.LotRepository, product *domain.Product, variant *domain.ProductVariant, number string, expiresAt *time.Time, quantity int) (*domain.Lot, error) {
+	if !product.BatchManaged {
+		return nil, errors.New("product is not batch-managed: " + product.Name)
+	}
+	if lotRepo == nil {
+		return nil, errors.New("lots are not available")
+	}
+	number = strings.TrimSpace(number)
+	if number == "" {
+		return nil, errors.New("lot number is required for batch-managed product: " + product.Name)
+	}
+	var variantID *uint
+	if variant != nil {
+		variantID = &variant.ID
+	}
+
+	lot, err := lotRepo.FindByNumber(ctx, product.ID, variantID, number)
+	if err != nil {
+		lot = &domain.Lot{
+			UserID:    product.UserID,
+			ProductID: product.ID,
+			VariantID: variantID,
+			Number:    number,
+			ExpiresAt: expiresAt,
+			Received:  quantity,
+			Quantity:  quantity,
+		}
+		return lot, lotRepo.Create(ctx, lot)
+	}
+	if expiresAt != nil && (lot.ExpiresAt == nil || !lot.ExpiresAt.Equal(*expiresAt)) {
+		return nil, fmt.Errorf("lot %s already exists with a different expiry date", number)
+	}
+	lot.Received += quantity
+	lot.Quantity += quantity
+	return lot, lotRepo.Update(ctx, lot)
+}
+
+func (s *LotService) GetLot(ctx context.Context, id, userID uint) (*domain.Lot, error) {
+	lot, err := s.lotRepo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("lot not found")
+	}
+	return lot, nil
+}
+
+// GetProductLots returns the lots of a product, the first to expire first.
+func (s *LotService) GetProductLots(ctx context.Context, productID, userID uint) ([]*domain.Lot, error) {
+	if _, err := s.productRepo.FindByIDAndUserID(ctx, productID, userID); err != nil {
+		return nil, errors.New("product not found")
+	}
+	return s.lotRepo.FindByProductID(ctx, productID, userID)
+}
+
+// ExpiringLots returns the lots with stock left that expire within the next
+// days days, or have already expired.
+func (s *LotService) ExpiringLots(ctx context.Context, userID uint, days int) ([]*domain.Lot, error) {
+	if days < 0 || days > maxExpiringDays {
+		return nil, fmt.Errorf("days must be between 0 and %d", maxExpiringDays)
+	}
+	return s.lotRepo.FindExpiring(ctx, userID, s.now().AddDate(0, 0, days))
+}
+
+// WriteOffLot removes quantity from a lot and from stock, or everything left
+// in the lot when quantity is nil. It is how expired or damaged lots leave
+// stock.
+func (s *LotService) WriteOffLot(ctx context.Context, id, userID uint, quantity *int) (*domain.Lot, error) {
+	var lot *domain.Lot
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if lot, err = s.lotRepo.FindByIDAndUserID(ctx, id, userID); err != nil {
+			return errors.New("lot not found")
+		}
+		amount := lot.Quantity
+		if quantity != nil {
+			amount = *quantity
+		}
+		if amount <= 0 {
+			return errors.New("quantity must be greater than 0")
+		}
+		if amount > lot.Quantity {
+			return fmt.Errorf("lot %s has only %d left", lot.Number, lot.Quantity)
+		}
+
+		product, err := s.productRepo.FindByIDAndUserID(ctx, lot.ProductID, userID)
+		if err != nil {
+			return errors.New("product not found")
+		}
+		current := product.Stock
+		var variant *domain.ProductVariant
+		if lot.VariantID != nil {
+			var ok bool
+			if variant, ok = product.Variant(*lot.VariantID); !ok {
+				return errors.New("variant not found")
+			}
+			current = variant.Stock
+		}
+
+		lot.Quantity -= amount
+		if err := s.lotRepo.Update(ctx, lot); err != nil {
+			return err
+		}
+		// Stock written directly before that was refused may be below
+		// what the lots hold.
+		delta := -min(amount, current)
+		if delta == 0 {
+			return nil
+		}
+		change, err := applyStockChange(ctx, s.productRepo, s.variantRepo, product, variant, delta, domain.StockReasonLotWrittenOff)
+		if err != nil {
+			return err
+		}
+		return change.record(ctx, s.events)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lot, nil
+}
+
+// allocateLots takes quantity of a batch-managed product or variant from its
+// unexpired lots, first expired first out, and returns the allocations.
+// Expired lots are never allocated.
+func allocateLots(ctx context.Context, lotRepo domain.LotRepository, product *domain.Product, variant *domain.ProductVariant, quantity int, now time.Time) ([]domain.OrderItemLot, error) {
+	if lotRepo == nil {
+		return nil, errors.New("lots are not available")
+	}
+	var variantID *uint
+	if variant != nil {
+		variantID = &variant.ID
+	}
+	lots, err := lotRepo.LockAllocatable(ctx, product.ID, variantID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var allocations []domain.OrderItemLot
+	remaining := quantity
+	for _, lot := range lots {
+		if remaining == 0 {
+			break
+		}
+		take := min(remaining, lot.Quantity)
+		lot.Quantity -= take
+		if err := lotRepo.Update(ctx, lot); err != nil {
+			return nil, err
+		}
+		allocations = append(allocations, domain.OrderItemLot{LotID: lot.ID, Quantity: take})
+		remaining -= take
+	}
+	if remaining > 0 {
+		return nil, errors.New("insufficient unexpired stock for product: " + product.Name)
+	}
+	return allocations, nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/mailer"
+)
+
+type NotificationService struct {
+	repo   domain.NotificationRepository
+	mailer mailer.Mailer
+	users  domain.UserRepository
+	now    func() time.Time
+}
+
+type NotificationServiceOption func(*NotificationService)
+
+// WithNotificationMailer also emails alerts to the user's address.
+func WithNotificationMailer(m mailer.Mailer, users domain.UserRepository) NotificationServiceOption {
+	return func(s *NotificationService) {
+		s.mailer = m
+		s.users = users
+	}
+}
+
+func NewNotificationService(repo domain.NotificationRepository, opts ...NotificationServiceOption) *NotificationService {
+	s := &NotificationService{repo: repo, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Handle is an EventBus handler that turns low stock events into
+// notifications. Redelivered events are recognised by their outbox ID.
+func (s *NotificationService) Handle(ctx context.Context, message *domain.OutboxMessage) error {
+	if message.EventType != domain.EventProductStockLow {
+		return nil
+	}
+	var event domain.ProductStockLow
+	if err := message.Decode(&event); err != nil {
+		return err
+	}
+
+	notification, err := s.repo.FindByEventID(ctx, message.ID)
+	if err != nil {
+		productID := event.ProductID
+		notification = &domain.Notification{
+			UserID:    message.UserID,
+			EventID:   message.ID,
+			Type:      domain.NotificationLowStock,
+			Title:     fmt.Sprintf("Low stock: %s (%s)", event.Name, event.Code),
+			Message:   lowStockMessage(event),
+			ProductID: &productID,
+		}
+		if err := s.repo.Create(ctx, notification); err != nil {
+			return err
+		}
+	}
+
+	if s.mailer == nil || notification.EmailedAt != nil {
+		return nil
+	}
+	// Email is best effort: a failure is logged instead of returned so the
+	// relay does not redeliver the event to every other sink.
+	if err := s.email(ctx, notification); err != nil {
+		log.Printf("notifications: emailing notification %d failed: %v", notification.ID, err)
+		return nil
+	}
+	now := s.now()
+	notification.EmailedAt = &now
+	return s.repo.Update(ctx, notification)
+}
+
+func (s *NotificationService) email(ctx context.Context, notification *domain.Notification) error {
+	user, err := s.users.FindByID(ctx, notification.UserID)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      []string{user.Email},
+		Subject: notification.Title,
+		Text:    notification.Message,
+	})
+}
+
+func lowStockMessage(event domain.ProductStockLow) string {
+	msg := fmt.Sprintf("%s (%s) has %d units left, at or below its reorder point of %d.",
+		event.Name, event.Code, event.Stock, event.ReorderPoint)
+	if event.ReorderQuantity > 0 {
+		msg += fmt.Sprintf(" Suggested reorder: %d units.", event.ReorderQuantity)
+	}
+	return msg
+}
+
+func (s *NotificationService) GetNotifications(ctx context.Context, userID uint, unreadOnly bool, limit int) ([]*domain.Notification, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return s.repo.FindByUserID(ctx, userID, unreadOnly, limit)
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, id, userID uint) (*domain.Notification, error) {
+	notification, err := s.repo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("notification not found")
+	}
+	if notification.ReadAt != nil {
+		return notification, nil
+	}
+	now := s.now()
+	notification.ReadAt = &now
+	if err := s.repo.Update(ctx, notification); err != nil {
+		return nil, err
+	}
+	return notification, nil
+}
+
+// MarkAllRead marks every unread notification of the user as read and returns
+// how many were changed.
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID uint) (int64, error) {
+	return s.repo.MarkAllRead(ctx, userID, s.now())
+}
@@ -4,28 +4,96 @@ import (
 	"context"
 	"errors"
 	"slices"
+	"time"
 	"vertice-backend/internal/domain"
 )
 
 type OrderService struct {
 	orderRepo   domain.OrderRepository
 	productRepo domain.ProductRepository
+	variantRepo domain.VariantRepository
+	lotRepo     domain.LotRepository
+	serialRepo  domain.SerialRepository
+	priceLists  domain.PriceListRepository
+	customers   domain.CustomerRepository
+	tx          domain.Transactor
+	events      EventRecorder
 }
 
-func NewOrderService(orderRepo domain.OrderRepository, productRepo domain.ProductRepository) *OrderService {
-	return &OrderService{
+type OrderServiceOption func(*OrderService)
+
+// WithOrderTransactor makes order changes, their stock movements and their
+// events commit atomically.
+func WithOrderTransactor(tx domain.Transactor) OrderServiceOption {
+	return func(s *OrderService) {
+		s.tx = tx
+	}
+}
+
+// WithOrderVariants lets orders reference product variants.
+func WithOrderVariants(variantRepo domain.VariantRepository) OrderServiceOption {
+	return func(s *OrderService) {
+		s.variantRepo = variantRepo
+	}
+}
+
+// WithOrderLots lets orders for batch-managed products be allocated from lots.
+func WithOrderLots(lotRepo domain.LotRepository) OrderServiceOption {
+	return func(s *OrderService) {
+		s.lotRepo = lotRepo
+	}
+}
+
+// WithOrderSerials lets orders for serialized products release their serials
+// when cancelled.
+func WithOrderSerials(serialRepo domain.SerialRepository) OrderServiceOption {
+	return func(s *OrderService) {
+		s.serialRepo = serialRepo
+	}
+}
+
+// WithOrderPriceLists prices order lines from the best applicable price list
+// and lets orders name a customer.
+func WithOrderPriceLists(priceListRepo domain.PriceListRepository, customerRepo domain.CustomerRepository) OrderServiceOption {
+	return func(s *OrderService) {
+		s.priceLists = priceListRepo
+		s.customers = customerRepo
+	}
+}
+
+// WithOrderEvents sets where order and stock events are recorded.
+func WithOrderEvents(events EventRecorder) OrderServiceOption {
+	return func(s *OrderService) {
+		s.events = events
+	}
+}
+
+func NewOrderService(orderRepo domain.OrderRepository, productRepo domain.ProductRepository, opts ...OrderServiceOption) *OrderService {
+	s := &OrderService{
 		orderRepo:   orderRepo,
 		productRepo: productRepo,
+		tx:          noTransaction{},
+		events:      discardEvents{},
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
+// CreateOrderRequest places an order, optionally for a customer whose price
+// lists then apply.
 type CreateOrderRequest struct {
-	Items []OrderItemRequest `json:"items"`
+	CustomerID *uint              `json:"customer_id,omitempty"`
+	Items      []OrderItemRequest `json:"items"`
 }
 
+// OrderItemRequest orders a quantity of a product. Products with variants
+// must name the variant.
 type OrderItemRequest struct {
-	ProductID uint `json:"product_id"`
-	Quantity  int  `json:"quantity"`
+	ProductID uint  `json:"product_id"`
+	VariantID *uint `json:"variant_id,omitempty"`
+	Quantity  int   `json:"quantity"`
 }
 
 func (s *OrderService) CreateOrder(ctx context.Context, userID uint, req CreateOrderRequest) (*domain.Order, error) {
@@ -40,39 +108,119 @@ func (s *OrderService) CreateOrder(ctx context.Context, userID uint, req CreateO
 		Items:       []domain.OrderItem{},
 	}
 
-	for _, itemReq := range req.Items {
-		if itemReq.Quantity <= 0 {
-			return nil, errors.New("quantity must be greater than 0")
+	var customer *domain.Customer
+	if req.CustomerID != nil {
+		if s.customers == nil {
+			return nil, errors.New("customers are not available")
 		}
-
-		product, err := s.productRepo.FindByIDAndUserID(ctx, itemReq.ProductID, userID)
+		c, err := s.customers.FindByIDAndUserID(ctx, *req.CustomerID, userID)
 		if err != nil {
-			return nil, errors.New("product not found")
+			return nil, errors.New("customer not found")
 		}
+		customer, order.CustomerID = c, &c.ID
+	}
 
-		if product.Stock < itemReq.Quantity {
-			return nil, errors.New("insufficient stock for product: " + product.Name)
-		}
+	now := time.Now()
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var stockChanges []stockChange
+
+		for _, itemReq := range req.Items {
+			if itemReq.Quantity <= 0 {
+				return errors.New("quantity must be greater than 0")
+			}
+
+			product, err := s.productRepo.FindByIDAndUserID(ctx, itemReq.ProductID, userID)
+			if err != nil {
+				return errors.New("product not found")
+			}
 
-		subtotal := float64(itemReq.Quantity) * product.Price
+			var variant *domain.ProductVariant
+			if itemReq.VariantID != nil {
+				v, ok := product.Variant(*itemReq.VariantID)
+				if !ok {
+					return errors.New("variant not found")
+				}
+				variant = v
+			} else if product.HasVariants() {
+				return errors.New("variant_id is required for product: " + product.Name)
+			}
 
-		orderItem := domain.OrderItem{
-			ProductID: product.ID,
-			Quantity:  itemReq.Quantity,
-			UnitPrice: product.Price,
-			Subtotal:  subtotal,
-		}
+			available, unitPrice := product.Stock, product.Price
+			if variant != nil {
+				available, unitPrice = variant.Stock, variant.EffectivePrice(product)
+			}
+			if product.IsBundle() {
+				available = product.BundleStock()
+			}
+			if available < itemReq.Quantity {
+				return errors.New("insufficient stock for product: " + product.Name)
+			}
 
-		order.Items = append(order.Items, orderItem)
-		order.TotalAmount += subtotal
+			var lots []domain.OrderItemLot
+			if product.BatchManaged {
+				if lots, err = allocateLots(ctx, s.lotRepo, product, variant, itemReq.Quantity, now); err != nil {
+					return err
+				}
+			}
 
-		product.Stock -= itemReq.Quantity
-		if err := s.productRepo.Update(ctx, product, userID); err != nil {
-			return nil, err
+			var priceList *domain.PriceList
+			if s.priceLists != nil {
+				lists, err := s.priceLists.FindApplicable(ctx, userID, order.CustomerID, customerGroupID(customer), product.ID, now)
+				if err != nil {
+					return err
+				}
+				unitPrice, priceList = bestPrice(lists, product.ID, itemReq.VariantID, itemReq.Quantity, unitPrice)
+			}
+
+			subtotal := float64(itemReq.Quantity) * unitPrice
+
+			orderItem := domain.OrderItem{
+				ProductID: product.ID,
+				VariantID: itemReq.VariantID,
+				Quantity:  itemReq.Quantity,
+				UnitPrice: unitPrice,
+				Subtotal:  subtotal,
+				UnitCost:  product.UnitCost(),
+				Lots:      lots,
+			}
+			if priceList != nil {
+				orderItem.PriceListID = &priceList.ID
+			}
+
+			if product.IsBundle() {
+				components, changes, err := s.takeComponents(ctx, userID, product, itemReq.Quantity)
+				if err != nil {
+					return err
+				}
+				orderItem.Components = components
+				stockChanges = append(stockChanges, changes...)
+			} else {
+				change, err := applyStockChange(ctx, s.productRepo, s.variantRepo, product, variant, -itemReq.Quantity, domain.StockReasonOrderPlaced)
+				if err != nil {
+					return err
+				}
+				stockChanges = append(stockChanges, change)
+			}
+
+			order.Items = append(order.Items, orderItem)
+			order.TotalAmount += subtotal
+		}
+
+		if err := s.orderRepo.Create(ctx, order); err != nil {
+			return err
 		}
-	}
 
-	if err := s.orderRepo.Create(ctx, order); err != nil {
+		if err := s.events.Record(ctx, userID, domain.NewOrderCreated(order)); err != nil {
+			return err
+		}
+		for _, change := range stockChanges {
+			if err := change.record(ctx, s.events); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -87,6 +235,27 @@ func (s *OrderService) GetOrdersByUser(ctx context.Context, userID uint) ([]*dom
 	return s.orderRepo.FindByUserID(ctx, userID)
 }
 
+// ListOrders returns the orders matching the filter, newest first.
+func (s *OrderService) ListOrders(ctx context.Context, userID uint, filter domain.OrderFilter) ([]*domain.Order, error) {
+	if filter == (domain.OrderFilter{}) {
+		return s.orderRepo.FindByUserID(ctx, userID)
+	}
+	if err := validateOrderFilter(filter); err != nil {
+		return nil, err
+	}
+	return s.orderRepo.FindByFilter(ctx, userID, filter)
+}
+
+func validateOrderFilter(filter domain.OrderFilter) error {
+	if filter.Status != "" && !isValidOrderStatus(filter.Status) {
+		return errors.New("invalid order status")
+	}
+	if filter.From != nil && filter.To != nil && !filter.To.After(*filter.From) {
+		return errors.New("from must be before to")
+	}
+	return nil
+}
+
 func (s *OrderService) UpdateOrderStatus(ctx context.Context, id, userID uint, status domain.OrderStatus) (*domain.Order, error) {
 	order, err := s.orderRepo.FindByIDAndUserID(ctx, id, userID)
 	if err != nil {
@@ -96,9 +265,21 @@ func (s *OrderService) UpdateOrderStatus(ctx context.Context, id, userID uint, s
 	if !isValidStatusTransition(order.Status, status) {
 		return nil, errors.New("invalid status transition")
 	}
+	if status == domain.OrderStatusShipped {
+		if err := checkSerialsAssigned(order, nil); err != nil {
+			return nil, err
+		}
+	}
 
+	previous := order.Status
 	order.Status = status
-	if err := s.orderRepo.Update(ctx, order, userID); err != nil {
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.orderRepo.Update(ctx, order, userID); err != nil {
+			return err
+		}
+		return recordStatusChanged(ctx, s.events, order, previous)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -119,25 +300,130 @@ func (s *OrderService) CancelOrder(ctx context.Context, id, userID uint) (*domai
 		return nil, errors.New("cannot cancel delivered order")
 	}
 
-	if order.Status == domain.OrderStatusPending || order.Status == domain.OrderStatusConfirmed {
-		for _, item := range order.Items {
-			product, err := s.productRepo.FindByIDAndUserID(ctx, item.ProductID, userID)
-			if err != nil {
-				continue
+	previous := order.Status
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if order.Status == domain.OrderStatusPending || order.Status == domain.OrderStatusConfirmed {
+			for _, item := range order.Items {
+				// The components of a bundle go back to stock, not the bundle.
+				if len(item.Components) > 0 {
+					for _, component := range item.Components {
+						if err := s.restoreStock(ctx, userID, component.ProductID, component.VariantID, component.Quantity); err != nil {
+							return err
+						}
+					}
+					continue
+				}
+				if err := s.restoreStock(ctx, userID, item.ProductID, item.VariantID, item.Quantity); err != nil {
+					return err
+				}
+				// Allocated quantities go back to their lots, even expired ones,
+				// so that they can be written off.
+				if len(item.Lots) > 0 && s.lotRepo == nil {
+					return errors.New("lots are not available")
+				}
+				for _, allocation := range item.Lots {
+					if err := s.lotRepo.AddQuantity(ctx, allocation.LotID, allocation.Quantity); err != nil {
+						return err
+					}
+				}
+			}
+
+			// Assigned serials go back in stock with their units. Units of
+			// a shipped order come back only through ReturnSerials.
+			var serialized []uint
+			for _, item := range order.Items {
+				if len(item.Serials) > 0 {
+					serialized = append(serialized, item.ID)
+				}
+			}
+			if len(serialized) > 0 {
+				if s.serialRepo == nil {
+					return errors.New("serials are not available")
+				}
+				if err := s.serialRepo.Unassign(ctx, serialized); err != nil {
+					return err
+				}
 			}
-			product.Stock += item.Quantity
-			s.productRepo.Update(ctx, product, userID)
 		}
-	}
 
-	order.Status = domain.OrderStatusCancelled
-	if err := s.orderRepo.Update(ctx, order, userID); err != nil {
+		order.Status = domain.OrderStatusCancelled
+		if err := s.orderRepo.Update(ctx, order, userID); err != nil {
+			return err
+		}
+		return recordStatusChanged(ctx, s.events, order, previous)
+	})
+	if err != nil {
+		order.Status = previous
 		return nil, err
 	}
 
 	return order, nil
 }
 
+func customerGroupID(customer *domain.Customer) *uint {
+	if customer == nil {
+		return nil
+	}
+	return customer.GroupID
+}
+
+// restoreStock puts the quantity of a cancelled order item back in stock.
+// Deleted products are skipped, and a deleted variant's quantity is restored
+// to the product alone.
+func (s *OrderService) restoreStock(ctx context.Context, userID, productID uint, variantID *uint, quantity int) error {
+	product, err := s.productRepo.FindByIDAndUserID(ctx, productID, userID)
+	if err != nil {
+		return nil
+	}
+	var variant *domain.ProductVariant
+	if variantID != nil {
+		variant, _ = product.Variant(*variantID)
+	}
+	change, err := applyStockChange(ctx, s.productRepo, s.variantRepo, product, variant, quantity, domain.StockReasonOrderCancelled)
+	if err != nil {
+		return err
+	}
+	return change.record(ctx, s.events)
+}
+
+// takeComponents takes the components of quantity units of a bundle from
+// stock and returns what was taken.
+func (s *OrderService) takeComponents(ctx context.Context, userID uint, bundle *domain.Product, quantity int) ([]domain.OrderItemComponent, []stockChange, error) {
+	var taken []domain.OrderItemComponent
+	var changes []stockChange
+	for _, bundleComponent := range bundle.Components {
+		component, err := s.productRepo.FindByIDAndUserID(ctx, bundleComponent.ComponentID, userID)
+		if err != nil {
+			return nil, nil, errors.New("component product not found")
+		}
+		if err := checkComponent(component); err != nil {
+			return nil, nil, err
+		}
+		available := component.Stock
+		var variant *domain.ProductVariant
+		if bundleComponent.VariantID != nil {
+			v, ok := component.Variant(*bundleComponent.VariantID)
+			if !ok {
+				return nil, nil, errors.New("variant not found")
+			}
+			variant, available = v, v.Stock
+		} else if component.HasVariants() {
+			return nil, nil, errors.New("variant_id is required for component: " + component.Name)
+		}
+		need := bundleComponent.Quantity * quantity
+		if available < need {
+			return nil, nil, errors.New("insufficient stock for product: " + component.Name)
+		}
+		change, err := applyStockChange(ctx, s.productRepo, s.variantRepo, component, variant, -need, domain.StockReasonOrderPlaced)
+		if err != nil {
+			return nil, nil, err
+		}
+		changes = append(changes, change)
+		taken = append(taken, domain.OrderItemComponent{ProductID: component.ID, VariantID: bundleComponent.VariantID, Quantity: need})
+	}
+	return taken, changes, nil
+}
+
 func (s *OrderService) DeleteOrder(ctx context.Context, id, userID uint) error {
 	order, err := s.orderRepo.FindByIDAndUserID(ctx, id, userID)
 	if err != nil {
@@ -151,6 +437,15 @@ func (s *OrderService) DeleteOrder(ctx context.Context, id, userID uint) error {
 	return s.orderRepo.Delete(ctx, id, userID)
 }
 
+func isValidOrderStatus(status domain.OrderStatus) bool {
+	switch status {
+	case domain.OrderStatusPending, domain.OrderStatusConfirmed, domain.OrderStatusShipped,
+		domain.OrderStatusDelivered, domain.OrderStatusCancelled:
+		return true
+	}
+	return false
+}
+
 func isValidStatusTransition(current, new domain.OrderStatus) bool {
 	validTransitions := map[domain.OrderStatus][]domain.OrderStatus{
 		domain.OrderStatusPending: {
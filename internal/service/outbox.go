@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+	"vertice-backend/internal/domain"
+)
+
+// Outbox records domain events in the outbox table. Call Record with the
+// context of the transaction that performs the state change.
+type Outbox struct {
+	repo domain.OutboxRepository
+	now  func() time.Time
+}
+
+func NewOutbox(repo domain.OutboxRepository) *Outbox {
+	return &Outbox{repo: repo, now: time.Now}
+}
+
+func (o *Outbox) Record(ctx context.Context, userID uint, event domain.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return o.repo.Create(ctx, &domain.OutboxMessage{
+		UserID:        userID,
+		AggregateType: event.AggregateType(),
+		AggregateID:   event.AggregateID(),
+		EventType:     event.EventType(),
+		Payload:       string(payload),
+		Status:        domain.OutboxPending,
+		OccurredAt:    o.now(),
+	})
+}
+
+// EventSink receives relayed outbox messages. Delivery is at least once, so a
+// sink may see the same message again after a failure; the message ID can be
+// used to deduplicate.
+type EventSink interface {
+	Handle(ctx context.Context, message *domain.OutboxMessage) error
+}
+
+// OutboxRelay drains pending outbox messages to the sinks. A message is marked
+// processed once every sink accepted it. When a message fails, later messages
+// of the same aggregate are held back so each aggregate's events stay in
+// order; after MaxAttempts the message is marked failed and skipped.
+//
+// Only one relay should run against a database at a time.
+type OutboxRelay struct {
+	repo        domain.OutboxRepository
+	sinks       []EventSink
+	BatchSize   int
+	MaxAttempts int
+	now         func() time.Time
+}
+
+func NewOutboxRelay(repo domain.OutboxRepository, sinks ...EventSink) *OutboxRelay {
+	return &OutboxRelay{
+		repo:        repo,
+		sinks:       sinks,
+		BatchSize:   100,
+		MaxAttempts: 10,
+		now:         time.Now,
+	}
+}
+
+// RelayPending relays one batch of pending messages and returns how many were
+// processed successfully.
+func (r *OutboxRelay) RelayPending(ctx context.Context) (int, error) {
+	messages, err := r.repo.FindPending(ctx, r.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	held := map[string]bool{}
+	processed := 0
+	for _, message := range messages {
+		aggregate := message.AggregateType + ":" + strconv.FormatUint(uint64(message.AggregateID), 10)
+		if held[aggregate] {
+			continue
+		}
+
+		if err := r.dispatch(ctx, message); err != nil {
+			held[aggregate] = true
+			message.Attempts++
+			message.LastError = err.Error()
+			if message.Attempts >= r.MaxAttempts {
+				message.Status = domain.OutboxFailed
+				log.Printf("outbox: giving up on message %d (%s): %v", message.ID, message.EventType, err)
+			}
+			if err := r.repo.Update(ctx, message); err != nil {
+				return processed, err
+			}
+			continue
+		}
+
+		now := r.now()
+		message.Status = domain.OutboxProcessed
+		message.ProcessedAt = &now
+		message.LastError = ""
+		if err := r.repo.Update(ctx, message); err != nil {
+			return processed, err
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// Run relays pending messages every interval until the context is cancelled.
+func (r *OutboxRelay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.RelayPending(ctx); err != nil {
+				log.Printf("outbox: relaying messages failed: %v", err)
+			}
+		}
+	}
+}
+
+func (r *OutboxRelay) dispatch(ctx context.Context, message *domain.OutboxMessage) error {
+	var errs []error
+	for _, sink := range r.sinks {
+		if err := sink.Handle(ctx, message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"vertice-backend/internal/domain"
+)
+
+type PriceChangeService struct {
+	priceChangeRepo domain.PriceChangeRepository
+	productRepo     domain.ProductRepository
+	tx              domain.Transactor
+	events          EventRecorder
+}
+
+type PriceChangeServiceOption func(*PriceChangeService)
+
+// WithPriceChangeTransactor makes each applied scheduled change, its history
+// entry and its event commit atomically.
+func WithPriceChangeTransactor(tx domain.Transactor) PriceChangeServiceOption {
+	return func(s *PriceChangeService) {
+		s.tx = tx
+	}
+}
+
+// WithPriceChangeEvents sets where the events of applied changes are
+// recorded.
+func WithPriceChangeEvents(events EventRecorder) PriceChangeServiceOption {
+	return func(s *PriceChangeService) {
+		s.events = events
+	}
+}
+
+func NewPriceChangeService(priceChangeRepo domain.PriceChangeRepository, productRepo domain.ProductRepository, opts ...PriceChangeServiceOption) *PriceChangeService {
+	s := &PriceChangeService{
+		priceChangeRepo: priceChangeRepo,
+		productRepo:     productRepo,
+		tx:              noTransaction{},
+		events:          discardEvents{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// SchedulePriceRequest sets the price of a product at EffectiveAt.
+type SchedulePriceRequest struct {
+	Price       float64   `json:"price" example:"1199.99"`
+	EffectiveAt time.Time `json:"effective_at" example:"2026-11-01T00:00:00Z"`
+}
+
+// GetPriceHistory returns the price changes of a product, newest first.
+func (s *PriceChangeService) GetPriceHistory(ctx context.Context, productID, userID uint) ([]*domain.PriceChange, error) {
+	if _, err := s.productRepo.FindByIDAndUserID(ctx, productID, userID); err != nil {
+		return nil, errors.New("product not found")
+	}
+	return s.priceChangeRepo.FindByProductID(ctx, productID, userID)
+}
+
+// SchedulePriceChange schedules a future price for a product. Several
+// changes may be scheduled; they are applied in the order they take effect.
+func (s *PriceChangeService) SchedulePriceChange(ctx context.Context, productID, userID uint, req SchedulePriceRequest) (*domain.ScheduledPriceChange, error) {
+	if req.Price < 0 {
+		return nil, errors.New("price cannot be negative")
+	}
+	if req.EffectiveAt.IsZero() {
+		return nil, errors.New("effective_at is required")
+	}
+	if !req.EffectiveAt.After(time.Now()) {
+		return nil, errors.New("effective_at must be in the future")
+	}
+	if _, err := s.productRepo.FindByIDAndUserID(ctx, productID, userID); err != nil {
+		return nil, errors.New("product not found")
+	}
+	change := &domain.ScheduledPriceChange{
+		UserID:      userID,
+		ProductID:   productID,
+		Price:       req.Price,
+		EffectiveAt: req.EffectiveAt.UTC(),
+		Status:      domain.ScheduledPricePending,
+		CreatedBy:   userID,
+	}
+	if err := s.priceChangeRepo.CreateScheduled(ctx, change); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// GetScheduledChanges returns the scheduled changes of a product with the
+// given status, or all of them when status is empty.
+func (s *PriceChangeService) GetScheduledChanges(ctx context.Context, productID, userID uint, status domain.ScheduledPriceStatus) ([]*domain.ScheduledPriceChange, error) {
+	switch status {
+	case "", domain.ScheduledPricePending, domain.ScheduledPriceApplied, domain.ScheduledPriceCancelled:
+	default:
+		return nil, errors.New("invalid status")
+	}
+	if _, err := s.productRepo.FindByIDAndUserID(ctx, productID, userID); err != nil {
+		return nil, errors.New("product not found")
+	}
+	return s.priceChangeRepo.FindScheduledByProductID(ctx, productID, userID, status)
+}
+
+// CancelScheduledChange cancels a pending scheduled change.
+func (s *PriceChangeService) CancelScheduledChange(ctx context.Context, productID, id, userID uint) (*domain.ScheduledPriceChange, error) {
+	change, err := s.priceChangeRepo.FindScheduledByIDAndUserID(ctx, id, userID)
+	if err != nil || change.ProductID != productID {
+		return nil, errors.New("scheduled price change not found")
+	}
+	if change.Status != domain.ScheduledPricePending {
+		return nil, errors.New("only pending price changes can be cancelled")
+	}
+	change.Status = domain.ScheduledPriceCancelled
+	if err := s.priceChangeRepo.UpdateScheduled(ctx, change); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// ApplyDue applies up to limit scheduled changes that are due at now and
+// returns how many were applied.
+func (s *PriceChangeService) ApplyDue(ctx context.Context, now time.Time, limit int) (int, error) {
+	applied := 0
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		due, err := s.priceChangeRepo.LockDueScheduled(ctx, now, limit)
+		if err != nil {
+			return err
+		}
+		for _, change := range due {
+			product, err := s.productRepo.FindByIDAndUserID(ctx, change.ProductID, change.UserID)
+			if err != nil {
+				change.Status = domain.ScheduledPriceCancelled
+				if err := s.priceChangeRepo.UpdateScheduled(ctx, change); err != nil {
+					return err
+				}
+				continue
+			}
+			previous := product.Price
+			product.Price = change.Price
+			if err := s.productRepo.Update(ctx, product, product.UserID); err != nil {
+				return err
+			}
+			if err := recordPriceChange(ctx, s.priceChangeRepo, s.events, product, previous, domain.PriceChangeSourceScheduled, &change.CreatedBy); err != nil {
+				return err
+			}
+			change.Status, change.AppliedAt = domain.ScheduledPriceApplied, &now
+			if err := s.priceChangeRepo.UpdateScheduled(ctx, change); err != nil {
+				return err
+			}
+			applied++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return applied, nil
+}
+
+// Run applies due scheduled changes every interval until the context is
+// cancelled.
+func (s *PriceChangeService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ApplyDue(ctx, time.Now(), 100); err != nil {
+				log.Printf("prices: applying scheduled changes failed: %v", err)
+			}
+		}
+	}
+}
+
+// recordPriceChange records a change of the product price from previous in
+// the price history, when one is kept, and as an event. Unchanged prices are
+// not recorded.
+func recordPriceChange(ctx context.Context, history domain.PriceChangeRepository, events EventRecorder, product *domain.Product, previous float64, source string, changedBy *uint) error {
+	if product.Price == previous {
+		return nil
+	}
+	if history != nil {
+		err := history.Create(ctx, &domain.PriceChange{
+			UserID:    product.UserID,
+			ProductID: product.ID,
+			OldPrice:  previous,
+			NewPrice:  product.Price,
+			Source:    source,
+			ChangedBy: changedBy,
+			ChangedAt: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return events.Record(ctx, product.UserID, domain.ProductPriceChanged{
+		ProductID: product.ID,
+		Code:      product.Code,
+		OldPrice:  previous,
+		NewPrice:  product.Price,
+		Source:    source,
+	})
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"vertice-backend/internal/domain"
+)
+
+// maxPriceListPrices bounds the number of prices of a price list.
+const maxPriceListPrices = 1000
+
+type PriceListService struct {
+	priceListRepo domain.PriceListRepository
+	customerRepo  domain.CustomerRepository
+	productRepo   domain.ProductRepository
+	tx            domain.Transactor
+}
+
+type PriceListServiceOption func(*PriceListService)
+
+// WithPriceListTransactor makes saving a price list with its prices and
+// assignments atomic.
+func WithPriceListTransactor(tx domain.Transactor) PriceListServiceOption {
+	return func(s *PriceListService) {
+		s.tx = tx
+	}
+}
+
+func NewPriceListService(priceListRepo domain.PriceListRepository, customerRepo domain.CustomerRepository, productRepo domain.ProductRepository, opts ...PriceListServiceOption) *PriceListService {
+	s := &PriceListService{
+		priceListRepo: priceListRepo,
+		customerRepo:  customerRepo,
+		productRepo:   productRepo,
+		tx:            noTransaction{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// PriceListRequest is a whole price list. A list without customers and
+// groups applies to every order.
+type PriceListRequest struct {
+	Name        string                  `json:"name" example:"Wholesale 2024"`
+	ValidFrom   *time.Time              `json:"valid_from,omitempty" example:"2024-01-01T00:00:00Z"`
+	ValidUntil  *time.Time              `json:"valid_until,omitempty" example:"2025-01-01T00:00:00Z"`
+	Prices      []PriceListPriceRequest `json:"prices"`
+	CustomerIDs []uint                  `json:"customer_ids"`
+	GroupIDs    []uint                  `json:"group_ids"`
+}
+
+// PriceListPriceRequest prices a product, or one of its variants, from
+// MinQuantity units per order line, with either a unit price or a discount
+// percentage off the regular price.
+type PriceListPriceRequest struct {
+	ProductID       uint     `json:"product_id" example:"1"`
+	VariantID       *uint    `json:"variant_id,omitempty"`
+	MinQuantity     int      `json:"min_quantity,omitempty" example:"10"`
+	Price           *float64 `json:"price,omitempty"`
+	DiscountPercent *float64 `json:"discount_percent,omitempty" example:"5"`
+}
+
+func (s *PriceListService) CreatePriceList(ctx context.Context, userID uint, req PriceListRequest) (*domain.PriceList, error) {
+	list := &domain.PriceList{UserID: userID}
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.fill(ctx, userID, list, req); err != nil {
+			return err
+		}
+		return s.priceListRepo.Create(ctx, list)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.priceListRepo.FindByIDAndUserID(ctx, list.ID, userID)
+}
+
+func (s *PriceListService) GetPriceList(ctx context.Context, id, userID uint) (*domain.PriceList, error) {
+	list, err := s.priceListRepo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("price list not found")
+	}
+	return list, nil
+}
+
+func (s *PriceListService) GetPriceListsByUser(ctx context.Context, userID uint) ([]*domain.PriceList, error) {
+	return s.priceListRepo.FindByUserID(ctx, userID)
+}
+
+// UpdatePriceList replaces a price list, with its prices and assignments.
+// Orders already placed keep their prices.
+func (s *PriceListService) UpdatePriceList(ctx context.Context, id, userID uint, req PriceListRequest) (*domain.PriceList, error) {
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		list, err := s.priceListRepo.FindByIDAndUserID(ctx, id, userID)
+		if err != nil {
+			return errors.New("price list not found")
+		}
+		if err := s.fill(ctx, userID, list, req); err != nil {
+			return err
+		}
+		return s.priceListRepo.Update(ctx, list, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.priceListRepo.FindByIDAndUserID(ctx, id, userID)
+}
+
+// DeletePriceList removes a price list. Order items priced from it keep
+// their prices.
+func (s *PriceListService) DeletePriceList(ctx context.Context, id, userID uint) error {
+	if _, err := s.priceListRepo.FindByIDAndUserID(ctx, id, userID); err != nil {
+		return errors.New("price list not found")
+	}
+	return s.priceListRepo.Delete(ctx, id, userID)
+}
+
+// fill validates the request and sets it on the list.
+func (s *PriceListService) fill(ctx context.Context, userID uint, list *domain.PriceList, req PriceListRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if name != list.Name {
+		if existing, err := s.priceListRepo.FindByNameAndUserID(ctx, name, userID); err == nil && existing != nil {
+			return errors.New("price list name already exists for this user")
+		}
+	}
+	if req.ValidFrom != nil && req.ValidUntil != nil && !req.ValidUntil.After(*req.ValidFrom) {
+		return errors.New("valid_until must be after valid_from")
+	}
+	if len(req.Prices) > maxPriceListPrices {
+		return fmt.Errorf("a price list can have at most %d prices", maxPriceListPrices)
+	}
+
+	prices, err := s.prices(ctx, userID, req.Prices)
+	if err != nil {
+		return err
+	}
+	var customers []domain.Customer
+	for _, id := range uniqueIDs(req.CustomerIDs) {
+		customer, err := s.customerRepo.FindByIDAndUserID(ctx, id, userID)
+		if err != nil {
+			return fmt.Errorf("customer %d not found", id)
+		}
+		customers = append(customers, *customer)
+	}
+	var groups []domain.CustomerGroup
+	for _, id := range uniqueIDs(req.GroupIDs) {
+		group, err := s.customerRepo.FindGroupByIDAndUserID(ctx, id, userID)
+		if err != nil {
+			return fmt.Errorf("customer group %d not found", id)
+		}
+		groups = append(groups, *group)
+	}
+
+	list.Name, list.ValidFrom, list.ValidUntil = name, req.ValidFrom, req.ValidUntil
+	list.Prices, list.Customers, list.Groups = prices, customers, groups
+	return nil
+}
+
+func (s *PriceListService) prices(ctx context.Context, userID uint, reqs []PriceListPriceRequest) ([]domain.PriceListPrice, error) {
+	products := make(map[uint]*domain.Product)
+	seen := make(map[[3]uint]bool, len(reqs))
+	prices := make([]domain.PriceListPrice, 0, len(reqs))
+	for _, req := range reqs {
+		if (req.Price == nil) == (req.DiscountPercent == nil) {
+			return nil, errors.New("exactly one of price and discount_percent is required")
+		}
+		if req.Price != nil && *req.Price < 0 {
+			return nil, errors.New("price cannot be negative")
+		}
+		if req.DiscountPercent != nil && (*req.DiscountPercent <= 0 || *req.DiscountPercent > 100) {
+			return nil, errors.New("discount_percent must be greater than 0 and at most 100")
+		}
+		minQuantity := req.MinQuantity
+		if minQuantity == 0 {
+			minQuantity = 1
+		}
+		if minQuantity < 0 {
+			return nil, errors.New("min_quantity must be greater than 0")
+		}
+
+		product := products[req.ProductID]
+		if product == nil {
+			p, err := s.productRepo.FindByIDAndUserID(ctx, req.ProductID, userID)
+			if err != nil {
+				return nil, fmt.Errorf("product %d not found", req.ProductID)
+			}
+			product, products[req.ProductID] = p, p
+		}
+		var variantID uint
+		if req.VariantID != nil {
+			if _, ok := product.Variant(*req.VariantID); !ok {
+				return nil, errors.New("variant not found")
+			}
+			variantID = *req.VariantID
+		}
+		key := [3]uint{product.ID, variantID, uint(minQuantity)}
+		if seen[key] {
+			return nil, fmt.Errorf("product %s is priced twice from %d units", product.Code, minQuantity)
+		}
+		seen[key] = true
+
+		prices = append(prices, domain.PriceListPrice{
+			ProductID:       product.ID,
+			VariantID:       req.VariantID,
+			MinQuantity:     minQuantity,
+			Price:           req.Price,
+			DiscountPercent: req.DiscountPercent,
+		})
+	}
+	return prices, nil
+}
+
+// bestPrice returns the lowest unit price the lists give for quantity units
+// of a product or variant with the regular price, and the list giving it.
+// The list is nil when none beats the regular price. On a tie the first list
+// wins.
+func bestPrice(lists []*domain.PriceList, productID uint, variantID *uint, quantity int, regular float64) (float64, *domain.PriceList) {
+	best, from := regular, (*domain.PriceList)(nil)
+	for _, list := range lists {
+		for i := range list.Prices {
+			price := &list.Prices[i]
+			if !price.Applies(productID, variantID, quantity) {
+				continue
+			}
+			if unitPrice := price.UnitPrice(regular); unitPrice < best {
+				best, from = unitPrice, list
+			}
+		}
+	}
+	return best, from
+}
@@ -0,0 +1,365 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // registers the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/storage"
+)
+
+const (
+	// DefaultMaxImageSize is the largest image upload accepted by default.
+	DefaultMaxImageSize = 10 << 20
+	// maxImagePixels guards against images that are small on disk but huge
+	// once decoded.
+	maxImagePixels = 40_000_000
+	// thumbnailSize bounds the width and height of generated thumbnails.
+	thumbnailSize         = 320
+	defaultImageURLExpiry = time.Hour
+)
+
+// imageTypes maps the accepted sniffed content types to file extensions.
+var imageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+type ProductImageService struct {
+	imageRepo   domain.ProductImageRepository
+	productRepo domain.ProductRepository
+	store       storage.BlobStore
+	tx          domain.Transactor
+	maxSize     int64
+	urlExpiry   time.Duration
+}
+
+type ProductImageServiceOption func(*ProductImageService)
+
+// WithImageTransactor makes reordering and deleting images atomic.
+func WithImageTransactor(tx domain.Transactor) ProductImageServiceOption {
+	return func(s *ProductImageService) {
+		s.tx = tx
+	}
+}
+
+// WithImageMaxSize sets the largest accepted upload in bytes.
+func WithImageMaxSize(size int64) ProductImageServiceOption {
+	return func(s *ProductImageService) {
+		s.maxSize = size
+	}
+}
+
+// WithImageURLExpiry sets how long signed image URLs stay valid.
+func WithImageURLExpiry(expiry time.Duration) ProductImageServiceOption {
+	return func(s *ProductImageService) {
+		s.urlExpiry = expiry
+	}
+}
+
+func NewProductImageService(imageRepo domain.ProductImageRepository, productRepo domain.ProductRepository, store storage.BlobStore, opts ...ProductImageServiceOption) *ProductImageService {
+	s := &ProductImageService{
+		imageRepo:   imageRepo,
+		productRepo: productRepo,
+		store:       store,
+		tx:          noTransaction{},
+		maxSize:     DefaultMaxImageSize,
+		urlExpiry:   defaultImageURLExpiry,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// MaxSize is the largest accepted upload in bytes.
+func (s *ProductImageService) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Upload stores an image of a product and a thumbnail of it. The type is
+// sniffed from the content, not taken from the client. The first image of a
+// product becomes its primary image; later ones are appended.
+func (s *ProductImageService) Upload(ctx context.Context, productID, userID uint, filename string, body io.Reader) (*domain.ProductImage, error) {
+	if _, err := s.productRepo.FindByIDAndUserID(ctx, productID, userID); err != nil {
+		return nil, errors.New("product not found")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, s.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxSize {
+		return nil, fmt.Errorf("image is larger than %d bytes", s.maxSize)
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := imageTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported image type: %s", contentType)
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("image could not be decoded")
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, errors.New("image has too many pixels")
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("image could not be decoded")
+	}
+	thumbnail, thumbnailType, err := encodeThumbnail(img, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	base := fmt.Sprintf("products/%d/%d/%s", userID, productID, randomName())
+	productImage := &domain.ProductImage{
+		UserID:       userID,
+		ProductID:    productID,
+		Key:          base + ext,
+		ThumbnailKey: base + "-thumb" + imageTypes[thumbnailType],
+		Filename:     path.Base(strings.ReplaceAll(filename, "\\", "/")),
+		ContentType:  contentType,
+		Size:         int64(len(data)),
+		Width:        config.Width,
+		Height:       config.Height,
+	}
+	if err := s.store.Put(ctx, productImage.Key, data, contentType); err != nil {
+		return nil, err
+	}
+	if err := s.store.Put(ctx, productImage.ThumbnailKey, thumbnail, thumbnailType); err != nil {
+		s.deleteBlobs(ctx, productImage.Key)
+		return nil, err
+	}
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		images, err := s.imageRepo.FindByProductID(ctx, productID, userID)
+		if err != nil {
+			return err
+		}
+		productImage.Position = len(images)
+		productImage.Primary = len(images) == 0
+		return s.imageRepo.Create(ctx, productImage)
+	})
+	if err != nil {
+		s.deleteBlobs(ctx, productImage.Key, productImage.ThumbnailKey)
+		return nil, err
+	}
+	return productImage, nil
+}
+
+// ImageURLs returns signed URLs of an image and its thumbnail.
+func (s *ProductImageService) ImageURLs(image *domain.ProductImage) (string, string, error) {
+	url, err := s.store.SignedURL(image.Key, s.urlExpiry)
+	if err != nil {
+		return "", "", err
+	}
+	thumbnailURL, err := s.store.SignedURL(image.ThumbnailKey, s.urlExpiry)
+	if err != nil {
+		return "", "", err
+	}
+	return url, thumbnailURL, nil
+}
+
+// GetImages returns the images of a product in display order.
+func (s *ProductImageService) GetImages(ctx context.Context, productID, userID uint) ([]*domain.ProductImage, error) {
+	if _, err := s.productRepo.FindByIDAndUserID(ctx, productID, userID); err != nil {
+		return nil, errors.New("product not found")
+	}
+	return s.imageRepo.FindByProductID(ctx, productID, userID)
+}
+
+// Reorder puts the images of a product in the given order. imageIDs must list
+// every image of the product exactly once.
+func (s *ProductImageService) Reorder(ctx context.Context, productID, userID uint, imageIDs []uint) ([]*domain.ProductImage, error) {
+	var result []*domain.ProductImage
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		images, err := s.imageRepo.FindByProductID(ctx, productID, userID)
+		if err != nil {
+			return err
+		}
+		byID := make(map[uint]*domain.ProductImage, len(images))
+		for _, image := range images {
+			byID[image.ID] = image
+		}
+		if len(imageIDs) != len(images) {
+			return errors.New("image_ids must list every image of the product once")
+		}
+		result = make([]*domain.ProductImage, len(imageIDs))
+		for i, id := range imageIDs {
+			image, ok := byID[id]
+			if !ok {
+				return errors.New("image_ids must list every image of the product once")
+			}
+			delete(byID, id)
+			image.Position = i
+			result[i] = image
+		}
+		return s.imageRepo.UpdateOrder(ctx, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SetPrimary makes an image the primary image of its product.
+func (s *ProductImageService) SetPrimary(ctx context.Context, productID, imageID, userID uint) ([]*domain.ProductImage, error) {
+	var images []*domain.ProductImage
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		images, err = s.imageRepo.FindByProductID(ctx, productID, userID)
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, image := range images {
+			image.Primary = image.ID == imageID
+			found = found || image.Primary
+		}
+		if !found {
+			return errors.New("image not found")
+		}
+		return s.imageRepo.UpdateOrder(ctx, images)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// DeleteImage removes an image and its stored files. When it was the primary
+// image, the next image in order takes its place.
+func (s *ProductImageService) DeleteImage(ctx context.Context, productID, imageID, userID uint) error {
+	var deleted *domain.ProductImage
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		images, err := s.imageRepo.FindByProductID(ctx, productID, userID)
+		if err != nil {
+			return err
+		}
+		remaining := make([]*domain.ProductImage, 0, len(images))
+		for _, image := range images {
+			if image.ID == imageID {
+				deleted = image
+				continue
+			}
+			remaining = append(remaining, image)
+		}
+		if deleted == nil {
+			return errors.New("image not found")
+		}
+		if err := s.imageRepo.Delete(ctx, imageID, userID); err != nil {
+			return err
+		}
+		for i, image := range remaining {
+			image.Position = i
+			if deleted.Primary {
+				image.Primary = i == 0
+			}
+		}
+		return s.imageRepo.UpdateOrder(ctx, remaining)
+	})
+	if err != nil {
+		return err
+	}
+	s.deleteBlobs(ctx, deleted.Key, deleted.ThumbnailKey)
+	return nil
+}
+
+// deleteBlobs removes stored files best effort; an orphaned file is harmless.
+func (s *ProductImageService) deleteBlobs(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			log.Printf("images: deleting blob %s failed: %v", key, err)
+		}
+	}
+}
+
+func randomName() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// encodeThumbnail scales img down to fit thumbnailSize and encodes it as
+// JPEG, or as PNG when the original may be transparent.
+func encodeThumbnail(img image.Image, contentType string) ([]byte, string, error) {
+	thumb := scaleDown(img, thumbnailSize)
+	var buf bytes.Buffer
+	if contentType == "image/jpeg" {
+		if err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	}
+	if err := png.Encode(&buf, thumb); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/png", nil
+}
+
+// scaleDown resizes img to fit in a size×size box, keeping its aspect ratio,
+// by averaging the source pixels that fall into each target pixel. Images
+// that already fit are copied unchanged.
+func scaleDown(img image.Image, size int) *image.NRGBA {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	dstW, dstH := srcW, srcH
+	if srcW > size || srcH > size {
+		if srcW >= srcH {
+			dstW, dstH = size, max(1, srcH*size/srcW)
+		} else {
+			dstW, dstH = max(1, srcW*size/srcH), size
+		}
+	}
+
+	src := image.NewNRGBA(image.Rect(0, 0, srcW, srcH))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+	if dstW == srcW && dstH == srcH {
+		return src
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		y0, y1 := y*srcH/dstH, max((y+1)*srcH/dstH, y*srcH/dstH+1)
+		for x := 0; x < dstW; x++ {
+			x0, x1 := x*srcW/dstW, max((x+1)*srcW/dstW, x*srcW/dstW+1)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := src.NRGBAAt(sx, sy)
+					// Weight colours by alpha so transparent pixels do not darken edges.
+					r += uint64(c.R) * uint64(c.A)
+					g += uint64(c.G) * uint64(c.A)
+					b += uint64(c.B) * uint64(c.A)
+					a += uint64(c.A)
+					n++
+				}
+			}
+			if a == 0 {
+				continue
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{R: uint8(r / a), G: uint8(g / a), B: uint8(b / a), A: uint8(a / n)})
+		}
+	}
+	return dst
+}
@@ -3,24 +3,88 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
 	"vertice-backend/internal/domain"
+	"vertice-backend/internal/storage"
 )
 
 type ProductService struct {
-	repo domain.ProductRepository
+	repo         domain.ProductRepository
+	variantRepo  domain.VariantRepository
+	categoryRepo domain.CategoryRepository
+	blobs        storage.BlobStore
+	priceChanges domain.PriceChangeRepository
+	tx           domain.Transactor
+	events       EventRecorder
 }
 
-func NewProductService(repo domain.ProductRepository) *ProductService {
-	return &ProductService{repo: repo}
+type ProductServiceOption func(*ProductService)
+
+// WithProductTransactor makes product changes and their events commit atomically.
+func WithProductTransactor(tx domain.Transactor) ProductServiceOption {
+	return func(s *ProductService) {
+		s.tx = tx
+	}
+}
+
+// WithProductEvents sets where stock and product events are recorded.
+func WithProductEvents(events EventRecorder) ProductServiceOption {
+	return func(s *ProductService) {
+		s.events = events
+	}
+}
+
+// WithProductVariants lets bulk stock adjustments change variant stock.
+func WithProductVariants(variantRepo domain.VariantRepository) ProductServiceOption {
+	return func(s *ProductService) {
+		s.variantRepo = variantRepo
+	}
+}
+
+// WithProductCategories lets product listings filter by category.
+func WithProductCategories(categoryRepo domain.CategoryRepository) ProductServiceOption {
+	return func(s *ProductService) {
+		s.categoryRepo = categoryRepo
+	}
+}
+
+// WithProductBlobStore removes the stored image files of deleted products.
+func WithProductBlobStore(blobs storage.BlobStore) ProductServiceOption {
+	return func(s *ProductService) {
+		s.blobs = blobs
+	}
+}
+
+// WithProductPriceHistory records every price change of a product.
+func WithProductPriceHistory(priceChangeRepo domain.PriceChangeRepository) ProductServiceOption {
+	return func(s *ProductService) {
+		s.priceChanges = priceChangeRepo
+	}
 }
 
-func (s *ProductService) CreateProduct(ctx context.Context, userID uint, code, name, description string, price float64, stock int) (*domain.Product, error) {
+func NewProductService(repo domain.ProductRepository, opts ...ProductServiceOption) *ProductService {
+	s := &ProductService{repo: repo, tx: noTransaction{}, events: discardEvents{}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// CreateProduct creates a product. Its initial stock is recorded as a stock
+// change at the given cost, so that the inventory ledger books it.
+func (s *ProductService) CreateProduct(ctx context.Context, userID uint, code, name, description string, price, cost float64, costMethod domain.CostMethod, stock int) (*domain.Product, error) {
 	if code == "" || name == "" {
 		return nil, errors.New("code and name are required")
 	}
 	if price < 0 {
 		return nil, errors.New("price cannot be negative")
 	}
+	if err := validateCost(cost, costMethod); err != nil {
+		return nil, err
+	}
 	if stock < 0 {
 		return nil, errors.New("stock cannot be negative")
 	}
@@ -36,13 +100,20 @@ func (s *ProductService) CreateProduct(ctx context.Context, userID uint, code, n
 		Name:        name,
 		Description: description,
 		Price:       price,
+		Cost:        cost,
+		CostMethod:  costMethod,
 		Stock:       stock,
 	}
 
-	if err := s.repo.Create(ctx, product); err != nil {
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, product); err != nil {
+			return err
+		}
+		return recordStockAdjusted(ctx, s.events, product, 0, domain.StockReasonInitial)
+	})
+	if err != nil {
 		return nil, err
 	}
-
 	return product, nil
 }
 
@@ -54,6 +125,59 @@ func (s *ProductService) GetProductsByUser(ctx context.Context, userID uint) ([]
 	return s.repo.FindByUserID(ctx, userID)
 }
 
+// ProductListFilter narrows ListProducts. Category is a category ID or slug
+// and matches products in the category or any of its descendants.
+type ProductListFilter struct {
+	Category string
+	Tag      string
+}
+
+func (s *ProductService) ListProducts(ctx context.Context, userID uint, filter ProductListFilter) ([]*domain.Product, error) {
+	if filter.Category == "" && filter.Tag == "" {
+		return s.repo.FindByUserID(ctx, userID)
+	}
+
+	repoFilter, err := resolveProductFilter(ctx, s.categoryRepo, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindByFilter(ctx, userID, repoFilter)
+}
+
+// resolveProductFilter turns a list filter into a repository filter, with the
+// category expanded to its subtree.
+func resolveProductFilter(ctx context.Context, categoryRepo domain.CategoryRepository, userID uint, filter ProductListFilter) (domain.ProductFilter, error) {
+	var repoFilter domain.ProductFilter
+	if filter.Category != "" {
+		if categoryRepo == nil {
+			return repoFilter, errors.New("categories are not available")
+		}
+		categories, err := categoryRepo.FindByUserID(ctx, userID)
+		if err != nil {
+			return repoFilter, err
+		}
+		tree := domain.NewCategoryTree(categories)
+		category, ok := findCategory(categories, filter.Category)
+		if !ok {
+			return repoFilter, errors.New("category not found")
+		}
+		repoFilter.CategoryIDs = tree.Subtree(category.ID)
+	}
+	repoFilter.Tag = normalizeTag(filter.Tag)
+	return repoFilter, nil
+}
+
+// findCategory looks a category up by ID or slug.
+func findCategory(categories []*domain.Category, ref string) (*domain.Category, bool) {
+	id, err := strconv.ParseUint(ref, 10, 64)
+	for _, category := range categories {
+		if (err == nil && category.ID == uint(id)) || category.Slug == strings.ToLower(ref) {
+			return category, true
+		}
+	}
+	return nil, false
+}
+
 func (s *ProductService) GetProductByCode(ctx context.Context, code string, userID uint) (*domain.Product, error) {
 	return s.repo.FindByCodeAndUserID(ctx, code, userID)
 }
@@ -63,6 +187,7 @@ func (s *ProductService) UpdateProduct(ctx context.Context, id, userID uint, cod
 	if err != nil {
 		return nil, errors.New("product not found")
 	}
+	previousStock, previousPrice := existingProduct.Stock, existingProduct.Price
 
 	// Update only the fields that are provided (not nil)
 	if code != nil {
@@ -100,10 +225,27 @@ func (s *ProductService) UpdateProduct(ctx context.Context, id, userID uint, cod
 		if *stock < 0 {
 			return nil, errors.New("stock cannot be negative")
 		}
+		if existingProduct.HasVariants() && *stock != existingProduct.Stock {
+			return nil, errors.New("stock of a product with variants is set per variant")
+		}
+		if *stock != existingProduct.Stock {
+			if err := directStockError(existingProduct); err != nil {
+				return nil, err
+			}
+		}
 		existingProduct.Stock = *stock
 	}
 
-	if err := s.repo.Update(ctx, existingProduct, userID); err != nil {
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, existingProduct, userID); err != nil {
+			return err
+		}
+		if err := recordStockAdjusted(ctx, s.events, existingProduct, previousStock, domain.StockReasonManual); err != nil {
+			return err
+		}
+		return recordPriceChange(ctx, s.priceChanges, s.events, existingProduct, previousPrice, domain.PriceChangeSourceManual, &userID)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -115,21 +257,285 @@ func (s *ProductService) UpdateProductStock(ctx context.Context, id, userID uint
 	if err != nil {
 		return nil, errors.New("product not found")
 	}
+	if product.HasVariants() {
+		return nil, errors.New("stock of a product with variants is set per variant")
+	}
+	if err := directStockError(product); err != nil {
+		return nil, err
+	}
 	if product.Stock+stockDelta < 0 {
 		return nil, errors.New("stock cannot be negative")
 	}
-	product.Stock += stockDelta
-	if err := s.repo.Update(ctx, product, userID); err != nil {
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		change, err := applyStockChange(ctx, s.repo, nil, product, nil, stockDelta, domain.StockReasonManual)
+		if err != nil {
+			return err
+		}
+		return change.record(ctx, s.events)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return product, nil
 }
 
+// maxBulkStockEntries bounds the size of a bulk stock adjustment.
+const maxBulkStockEntries = 1000
+
+// BulkStockEntry changes the stock of a product, found by ID or code, or of
+// one of its variants, either by Delta or to SetTo. Reason is recorded on the
+// stock event and defaults to manual.
+type BulkStockEntry struct {
+	ProductID *uint  `json:"product_id,omitempty"`
+	Code      string `json:"code,omitempty"`
+	VariantID *uint  `json:"variant_id,omitempty"`
+	Delta     *int   `json:"delta,omitempty"`
+	SetTo     *int   `json:"set_to,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// BulkStockResult is the stock after an entry was applied. Stock is the
+// stock of the variant for variant entries and of the product otherwise.
+type BulkStockResult struct {
+	ProductID    uint
+	Code         string
+	VariantID    *uint
+	VariantCode  string
+	Previous     int
+	Stock        int
+	ProductStock int
+}
+
+// bulkStockTarget is a validated entry.
+type bulkStockTarget struct {
+	product  *domain.Product
+	variant  *domain.ProductVariant
+	delta    int
+	previous int
+	reason   string
+}
+
+// BulkUpdateStock applies all entries in one transaction, or none of them.
+// Every entry is validated first, and when any is invalid an *EntryErrors
+// lists them all. A product or variant may appear only once.
+func (s *ProductService) BulkUpdateStock(ctx context.Context, userID uint, entries []BulkStockEntry) ([]BulkStockResult, error) {
+	if len(entries) == 0 {
+		return nil, errors.New("at least one stock adjustment is required")
+	}
+	if len(entries) > maxBulkStockEntries {
+		return nil, fmt.Errorf("at most %d stock adjustments can be applied at once", maxBulkStockEntries)
+	}
+
+	var results []BulkStockResult
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		targets, err := s.bulkStockTargets(ctx, userID, entries)
+		if err != nil {
+			return err
+		}
+
+		results = make([]BulkStockResult, len(targets))
+		for i, target := range targets {
+			change, err := applyStockChange(ctx, s.repo, s.variantRepo, target.product, target.variant, target.delta, target.reason)
+			if err != nil {
+				return err
+			}
+			if err := change.record(ctx, s.events); err != nil {
+				return err
+			}
+			result := BulkStockResult{
+				ProductID:    target.product.ID,
+				Code:         target.product.Code,
+				Previous:     target.previous,
+				Stock:        target.product.Stock,
+				ProductStock: target.product.Stock,
+			}
+			if target.variant != nil {
+				result.VariantID = &target.variant.ID
+				result.VariantCode = target.variant.Code
+				result.Stock = target.variant.Stock
+			}
+			results[i] = result
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// directStockError returns why the stock of a product cannot be set
+// directly: a bundle's stock is derived from its components, and the stock
+// of a batch-managed or serialized product must match its lots or serials.
+func directStockError(product *domain.Product) error {
+	switch {
+	case product.IsBundle():
+		return errBundleStock
+	case product.BatchManaged:
+		return errLotStock
+	case product.Serialized:
+		return errSerialStock
+	}
+	return nil
+}
+
+// bulkStockTargets resolves and validates the entries. Entries of the same
+// product share its *domain.Product, so that its stock adds up across them.
+func (s *ProductService) bulkStockTargets(ctx context.Context, userID uint, entries []BulkStockEntry) ([]bulkStockTarget, error) {
+	products := make(map[uint]*domain.Product)
+	codes := make(map[string]*domain.Product)
+	seen := make(map[[2]uint]int)
+	targets := make([]bulkStockTarget, len(entries))
+	entryErrs := &EntryErrors{}
+
+	for i, entry := range entries {
+		if (entry.ProductID == nil) == (entry.Code == "") {
+			entryErrs.Add(i, "exactly one of product_id and code is required")
+			continue
+		}
+		if (entry.Delta == nil) == (entry.SetTo == nil) {
+			entryErrs.Add(i, "exactly one of delta and set_to is required")
+			continue
+		}
+		reason := strings.TrimSpace(entry.Reason)
+		if reason == "" {
+			reason = domain.StockReasonManual
+		}
+		if len(reason) > 100 {
+			entryErrs.Add(i, "reason cannot be longer than 100 characters")
+			continue
+		}
+
+		var product *domain.Product
+		var err error
+		if entry.ProductID != nil {
+			if product = products[*entry.ProductID]; product == nil {
+				product, err = s.repo.FindByIDAndUserID(ctx, *entry.ProductID, userID)
+			}
+		} else if product = codes[entry.Code]; product == nil {
+			product, err = s.repo.FindByCodeAndUserID(ctx, entry.Code, userID)
+			if err == nil && products[product.ID] != nil {
+				product = products[product.ID]
+			}
+		}
+		if err != nil || product == nil {
+			entryErrs.Add(i, "product not found")
+			continue
+		}
+		products[product.ID] = product
+		codes[product.Code] = product
+		if err := directStockError(product); err != nil {
+			entryErrs.Add(i, err.Error())
+			continue
+		}
+
+		var variant *domain.ProductVariant
+		var variantID uint
+		if entry.VariantID != nil {
+			v, ok := product.Variant(*entry.VariantID)
+			if !ok {
+				entryErrs.Add(i, "variant not found")
+				continue
+			}
+			variant, variantID = v, v.ID
+		} else if product.HasVariants() {
+			entryErrs.Add(i, "variant_id is required for products with variants")
+			continue
+		}
+		key := [2]uint{product.ID, variantID}
+		if first, ok := seen[key]; ok {
+			entryErrs.Add(i, fmt.Sprintf("adjusts the same stock as entry %d", first))
+			continue
+		}
+		seen[key] = i
+
+		current := product.Stock
+		if variant != nil {
+			current = variant.Stock
+		}
+		delta := 0
+		if entry.Delta != nil {
+			delta = *entry.Delta
+		} else {
+			delta = *entry.SetTo - current
+		}
+		if current+delta < 0 {
+			entryErrs.Add(i, "stock cannot be negative")
+			continue
+		}
+		targets[i] = bulkStockTarget{product: product, variant: variant, delta: delta, previous: current, reason: reason}
+	}
+
+	if len(entryErrs.Entries) > 0 {
+		return nil, entryErrs
+	}
+	return targets, nil
+}
+
+// UpdateReorderSettings changes the reorder point and quantity of a product.
+// Only the provided values are changed. Raising the reorder point to or above
+// the current stock records a low stock event, as a stock change would.
+func (s *ProductService) UpdateReorderSettings(ctx context.Context, id, userID uint, reorderPoint, reorderQuantity *int) (*domain.Product, error) {
+	product, err := s.repo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+	wasLow := product.LowStock()
+	if reorderPoint != nil {
+		if *reorderPoint < 0 {
+			return nil, errors.New("reorder point cannot be negative")
+		}
+		product.ReorderPoint = *reorderPoint
+	}
+	if reorderQuantity != nil {
+		if *reorderQuantity < 0 {
+			return nil, errors.New("reorder quantity cannot be negative")
+		}
+		product.ReorderQuantity = *reorderQuantity
+	}
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, product, userID); err != nil {
+			return err
+		}
+		if wasLow || !product.LowStock() {
+			return nil
+		}
+		return recordStockLow(ctx, s.events, product)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// GetLowStockProducts returns the products at or below their reorder point.
+func (s *ProductService) GetLowStockProducts(ctx context.Context, userID uint) ([]*domain.Product, error) {
+	return s.repo.FindLowStockByUserID(ctx, userID)
+}
+
 func (s *ProductService) DeleteProduct(ctx context.Context, id, userID uint) error {
-	_, err := s.repo.FindByIDAndUserID(ctx, id, userID)
+	product, err := s.repo.FindByIDAndUserID(ctx, id, userID)
 	if err != nil {
 		return errors.New("product not found")
 	}
 
-	return s.repo.Delete(ctx, id, userID)
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id, userID); err != nil {
+			return err
+		}
+		return s.events.Record(ctx, userID, domain.ProductDeleted{ProductID: product.ID, Code: product.Code})
+	})
+	if err != nil {
+		return err
+	}
+	if s.blobs != nil {
+		for _, image := range product.Images {
+			for _, key := range []string{image.Key, image.ThumbnailKey} {
+				if err := s.blobs.Delete(ctx, key); err != nil {
+					log.Printf("products: deleting blob %s failed: %v", key, err)
+				}
+			}
+		}
+	}
+	return nil
 }
@@ -0,0 +1,466 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"vertice-backend/internal/domain"
+)
+
+type PurchaseOrderService struct {
+	purchaseOrderRepo domain.PurchaseOrderRepository
+	supplierRepo      domain.SupplierRepository
+	productRepo       domain.ProductRepository
+	variantRepo       domain.VariantRepository
+	lotRepo           domain.LotRepository
+	serialRepo        domain.SerialRepository
+	tx                domain.Transactor
+	events            EventRecorder
+	now               func() time.Time
+}
+
+type PurchaseOrderServiceOption func(*PurchaseOrderService)
+
+// WithPurchaseOrderTransactor makes a receipt, the stock it adds and its
+// events commit atomically.
+func WithPurchaseOrderTransactor(tx domain.Transactor) PurchaseOrderServiceOption {
+	return func(s *PurchaseOrderService) {
+		s.tx = tx
+	}
+}
+
+// WithPurchaseOrderVariants lets purchase order lines reference product variants.
+func WithPurchaseOrderVariants(variantRepo domain.VariantRepository) PurchaseOrderServiceOption {
+	return func(s *PurchaseOrderService) {
+		s.variantRepo = variantRepo
+	}
+}
+
+// WithPurchaseOrderLots lets receipts of batch-managed products be booked
+// into lots.
+func WithPurchaseOrderLots(lotRepo domain.LotRepository) PurchaseOrderServiceOption {
+	return func(s *PurchaseOrderService) {
+		s.lotRepo = lotRepo
+	}
+}
+
+// WithPurchaseOrderSerials lets receipts of serialized products register
+// their serials.
+func WithPurchaseOrderSerials(serialRepo domain.SerialRepository) PurchaseOrderServiceOption {
+	return func(s *PurchaseOrderService) {
+		s.serialRepo = serialRepo
+	}
+}
+
+// WithPurchaseOrderEvents sets where stock received from purchase orders is recorded.
+func WithPurchaseOrderEvents(events EventRecorder) PurchaseOrderServiceOption {
+	return func(s *PurchaseOrderService) {
+		s.events = events
+	}
+}
+
+func NewPurchaseOrderService(purchaseOrderRepo domain.PurchaseOrderRepository, supplierRepo domain.SupplierRepository, productRepo domain.ProductRepository, opts ...PurchaseOrderServiceOption) *PurchaseOrderService {
+	s := &PurchaseOrderService{
+		purchaseOrderRepo: purchaseOrderRepo,
+		supplierRepo:      supplierRepo,
+		productRepo:       productRepo,
+		tx:                noTransaction{},
+		events:            discardEvents{},
+		now:               time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+type PurchaseOrderRequest struct {
+	SupplierID uint                       `json:"supplier_id"`
+	Notes      string                     `json:"notes"`
+	ExpectedAt *time.Time                 `json:"expected_at"`
+	Lines      []PurchaseOrderLineRequest `json:"lines"`
+}
+
+type UpdatePurchaseOrderRequest struct {
+	Notes      *string                    `json:"notes"`
+	ExpectedAt *time.Time                 `json:"expected_at"`
+	Lines      []PurchaseOrderLineRequest `json:"lines"`
+}
+
+// PurchaseOrderLineRequest orders a quantity of a product, naming the variant
+// for products with variants. When UnitCost is omitted the cost from the
+// supplier's product link is used.
+type PurchaseOrderLineRequest struct {
+	ProductID uint     `json:"product_id"`
+	VariantID *uint    `json:"variant_id,omitempty"`
+	Quantity  int      `json:"quantity"`
+	UnitCost  *float64 `json:"unit_cost"`
+}
+
+type ReceivePurchaseOrderRequest struct {
+	Notes string               `json:"notes"`
+	Lines []ReceiveLineRequest `json:"lines"`
+}
+
+// ReceiveLineRequest receives a quantity of a line. Quantities of
+// batch-managed products are booked into the lot LotNumber, which is
+// created with ExpiresAt if it does not exist yet. Serialized products list
+// the serial of each received unit.
+type ReceiveLineRequest struct {
+	LineID    uint       `json:"line_id"`
+	Quantity  int        `json:"quantity"`
+	LotNumber string     `json:"lot_number,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Serials   []string   `json:"serials,omitempty"`
+}
+
+// checkReceiveTracking refuses lot and serial details on a receive line of a
+// product that does not track them, rather than dropping them.
+func checkReceiveTracking(product *domain.Product, lineReq ReceiveLineRequest) error {
+	if !product.BatchManaged {
+		if lineReq.LotNumber != "" {
+			return errors.New("lot_number is only accepted for batch-managed products: " + product.Name)
+		}
+		if lineReq.ExpiresAt != nil {
+			return errors.New("expires_at is only accepted for batch-managed products: " + product.Name)
+		}
+	}
+	if !product.Serialized && len(lineReq.Serials) > 0 {
+		return errors.New("serials are only accepted for serialized products: " + product.Name)
+	}
+	return nil
+}
+
+func (s *PurchaseOrderService) CreatePurchaseOrder(ctx context.Context, userID uint, req PurchaseOrderRequest) (*domain.PurchaseOrder, error) {
+	if _, err := s.supplierRepo.FindByIDAndUserID(ctx, req.SupplierID, userID); err != nil {
+		return nil, errors.New("supplier not found")
+	}
+
+	order := &domain.PurchaseOrder{
+		UserID:     userID,
+		SupplierID: req.SupplierID,
+		Status:     domain.PurchaseOrderStatusDraft,
+		Notes:      req.Notes,
+		ExpectedAt: req.ExpectedAt,
+	}
+	if err := s.setLines(ctx, order, req.Lines, userID); err != nil {
+		return nil, err
+	}
+
+	if err := s.purchaseOrderRepo.Create(ctx, order); err != nil {
+		return nil, err
+	}
+	return s.purchaseOrderRepo.FindByIDAndUserID(ctx, order.ID, userID)
+}
+
+func (s *PurchaseOrderService) GetPurchaseOrder(ctx context.Context, id, userID uint) (*domain.PurchaseOrder, error) {
+	order, err := s.purchaseOrderRepo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("purchase order not found")
+	}
+	return order, nil
+}
+
+// GetPurchaseOrdersByUser lists the user's purchase orders, optionally only
+// those with the given status.
+func (s *PurchaseOrderService) GetPurchaseOrdersByUser(ctx context.Context, userID uint, status domain.PurchaseOrderStatus) ([]*domain.PurchaseOrder, error) {
+	if status != "" && !isPurchaseOrderStatus(status) {
+		return nil, errors.New("invalid status")
+	}
+	return s.purchaseOrderRepo.FindByUserID(ctx, userID, status)
+}
+
+// UpdatePurchaseOrder edits a draft. Lines, when given, replace the existing ones.
+func (s *PurchaseOrderService) UpdatePurchaseOrder(ctx context.Context, id, userID uint, req UpdatePurchaseOrderRequest) (*domain.PurchaseOrder, error) {
+	order, err := s.purchaseOrderRepo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("purchase order not found")
+	}
+	if order.Status != domain.PurchaseOrderStatusDraft {
+		return nil, errors.New("only draft purchase orders can be edited")
+	}
+
+	if req.Notes != nil {
+		order.Notes = *req.Notes
+	}
+	if req.ExpectedAt != nil {
+		order.ExpectedAt = req.ExpectedAt
+	}
+	if req.Lines != nil {
+		if err := s.setLines(ctx, order, req.Lines, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.purchaseOrderRepo.Update(ctx, order, userID); err != nil {
+		return nil, err
+	}
+	return s.purchaseOrderRepo.FindByIDAndUserID(ctx, order.ID, userID)
+}
+
+// SendPurchaseOrder marks a draft as sent to the supplier. Without an expected
+// date, one is derived from the longest lead time of the ordered products.
+func (s *PurchaseOrderService) SendPurchaseOrder(ctx context.Context, id, userID uint) (*domain.PurchaseOrder, error) {
+	order, err := s.purchaseOrderRepo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("purchase order not found")
+	}
+	if order.Status != domain.PurchaseOrderStatusDraft {
+		return nil, errors.New("only draft purchase orders can be sent")
+	}
+
+	now := s.now()
+	order.Status = domain.PurchaseOrderStatusSent
+	order.SentAt = &now
+	if order.ExpectedAt == nil {
+		leadTime := 0
+		for _, line := range order.Lines {
+			link, err := s.supplierRepo.FindProduct(ctx, order.SupplierID, line.ProductID, userID)
+			if err == nil && link.LeadTimeDays > leadTime {
+				leadTime = link.LeadTimeDays
+			}
+		}
+		if leadTime > 0 {
+			expected := now.AddDate(0, 0, leadTime)
+			order.ExpectedAt = &expected
+		}
+	}
+
+	if err := s.purchaseOrderRepo.Update(ctx, order, userID); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// ReceivePurchaseOrder books a delivery against a sent purchase order and adds
+// the received quantities to stock. Without lines, everything outstanding is
+// received; lines are needed to name the lots of batch-managed products and
+// the serials of serialized ones.
+func (s *PurchaseOrderService) ReceivePurchaseOrder(ctx context.Context, id, userID uint, req ReceivePurchaseOrderRequest) (*domain.PurchaseOrder, error) {
+	order, err := s.purchaseOrderRepo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("purchase order not found")
+	}
+	if order.Status != domain.PurchaseOrderStatusSent && order.Status != domain.PurchaseOrderStatusPartiallyReceived {
+		return nil, errors.New("only sent purchase orders can be received")
+	}
+
+	received := map[uint]int{}
+	lineReqs := map[uint][]ReceiveLineRequest{}
+	if len(req.Lines) == 0 {
+		for _, line := range order.Lines {
+			if line.Outstanding() > 0 {
+				received[line.ID] = line.Outstanding()
+			}
+		}
+	} else {
+		outstanding := map[uint]int{}
+		for _, line := range order.Lines {
+			outstanding[line.ID] = line.Outstanding()
+		}
+		for _, lineReq := range req.Lines {
+			if lineReq.Quantity <= 0 {
+				return nil, errors.New("quantity must be greater than 0")
+			}
+			left, ok := outstanding[lineReq.LineID]
+			if !ok {
+				return nil, errors.New("purchase order line not found")
+			}
+			if received[lineReq.LineID]+lineReq.Quantity > left {
+				return nil, errors.New("quantity exceeds the outstanding quantity of the line")
+			}
+			received[lineReq.LineID] += lineReq.Quantity
+			lineReqs[lineReq.LineID] = append(lineReqs[lineReq.LineID], lineReq)
+		}
+	}
+	if len(received) == 0 {
+		return nil, errors.New("nothing left to receive")
+	}
+
+	now := s.now()
+	receipt := &domain.PurchaseOrderReceipt{
+		PurchaseOrderID: order.ID,
+		UserID:          userID,
+		Notes:           req.Notes,
+		ReceivedAt:      now,
+	}
+	previousStatus := order.Status
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		complete := true
+		for i := range order.Lines {
+			line := &order.Lines[i]
+			quantity := received[line.ID]
+			if quantity > 0 {
+				line.QuantityReceived += quantity
+				receipt.Lines = append(receipt.Lines, domain.PurchaseOrderReceiptLine{LineID: line.ID, Quantity: quantity})
+
+				product, err := s.productRepo.FindByIDAndUserID(ctx, line.ProductID, userID)
+				if err != nil {
+					return errors.New("product not found")
+				}
+				var variant *domain.ProductVariant
+				if line.VariantID != nil {
+					if variant, _ = product.Variant(*line.VariantID); variant == nil {
+						return errors.New("variant not found")
+					}
+				}
+				for _, lineReq := range lineReqs[line.ID] {
+					if err := checkReceiveTracking(product, lineReq); err != nil {
+						return err
+					}
+				}
+				if product.Serialized {
+					var serials []string
+					for _, lineReq := range lineReqs[line.ID] {
+						if len(lineReq.Serials) != lineReq.Quantity {
+							return errors.New("a serial is required for each received unit of serialized product: " + product.Name)
+						}
+						serials = append(serials, lineReq.Serials...)
+					}
+					if len(serials) == 0 {
+						return errors.New("serials are required to receive serialized product: " + product.Name)
+					}
+					if _, err := registerSerials(ctx, s.serialRepo, product, variant, serials, now); err != nil {
+						return err
+					}
+				}
+				if product.BatchManaged {
+					if len(lineReqs[line.ID]) == 0 {
+						return errors.New("lot_number is required to receive batch-managed product: " + product.Name)
+					}
+					for _, lineReq := range lineReqs[line.ID] {
+						if _, err := receiveIntoLot(ctx, s.lotRepo, product, variant, lineReq.LotNumber, lineReq.ExpiresAt, lineReq.Quantity); err != nil {
+							return err
+						}
+					}
+				}
+				unitCost := product.ReceiveCost(quantity, line.UnitCost)
+				change, err := applyStockChange(ctx, s.productRepo, s.variantRepo, product, variant, quantity, domain.StockReasonPurchaseReceived)
+				if err != nil {
+					return err
+				}
+				change.unitCost = &unitCost
+				if err := change.record(ctx, s.events); err != nil {
+					return err
+				}
+			}
+			if line.Outstanding() > 0 {
+				complete = false
+			}
+		}
+
+		if err := s.purchaseOrderRepo.CreateReceipt(ctx, receipt); err != nil {
+			return err
+		}
+		if complete {
+			order.Status = domain.PurchaseOrderStatusReceived
+			order.ReceivedAt = &now
+		} else {
+			order.Status = domain.PurchaseOrderStatusPartiallyReceived
+		}
+		return s.purchaseOrderRepo.Update(ctx, order, userID)
+	})
+	if err != nil {
+		order.Status = previousStatus
+		return nil, err
+	}
+
+	return s.purchaseOrderRepo.FindByIDAndUserID(ctx, order.ID, userID)
+}
+
+// ClosePurchaseOrder ends a purchase order. Closing one that is not fully
+// received cancels whatever is still outstanding.
+func (s *PurchaseOrderService) ClosePurchaseOrder(ctx context.Context, id, userID uint) (*domain.PurchaseOrder, error) {
+	order, err := s.purchaseOrderRepo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("purchase order not found")
+	}
+	switch order.Status {
+	case domain.PurchaseOrderStatusSent, domain.PurchaseOrderStatusPartiallyReceived, domain.PurchaseOrderStatusReceived:
+	case domain.PurchaseOrderStatusClosed:
+		return nil, errors.New("purchase order is already closed")
+	default:
+		return nil, errors.New("draft purchase orders cannot be closed, delete them instead")
+	}
+
+	now := s.now()
+	order.Status = domain.PurchaseOrderStatusClosed
+	order.ClosedAt = &now
+	if err := s.purchaseOrderRepo.Update(ctx, order, userID); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (s *PurchaseOrderService) DeletePurchaseOrder(ctx context.Context, id, userID uint) error {
+	order, err := s.purchaseOrderRepo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return errors.New("purchase order not found")
+	}
+	if order.Status != domain.PurchaseOrderStatusDraft {
+		return errors.New("only draft purchase orders can be deleted")
+	}
+	return s.purchaseOrderRepo.Delete(ctx, id, userID)
+}
+
+func (s *PurchaseOrderService) setLines(ctx context.Context, order *domain.PurchaseOrder, lines []PurchaseOrderLineRequest, userID uint) error {
+	if len(lines) == 0 {
+		return errors.New("purchase order must have at least one line")
+	}
+
+	order.Lines = nil
+	order.TotalCost = 0
+	for _, lineReq := range lines {
+		if lineReq.Quantity <= 0 {
+			return errors.New("quantity must be greater than 0")
+		}
+		product, err := s.productRepo.FindByIDAndUserID(ctx, lineReq.ProductID, userID)
+		if err != nil {
+			return errors.New("product not found")
+		}
+		if product.IsBundle() {
+			return errors.New("bundles cannot be purchased, order their components instead: " + product.Name)
+		}
+		if lineReq.VariantID != nil {
+			if _, ok := product.Variant(*lineReq.VariantID); !ok {
+				return errors.New("variant not found")
+			}
+		} else if product.HasVariants() {
+			return errors.New("variant_id is required for product: " + product.Name)
+		}
+
+		var unitCost float64
+		if lineReq.UnitCost != nil {
+			unitCost = *lineReq.UnitCost
+		} else if link, err := s.supplierRepo.FindProduct(ctx, order.SupplierID, lineReq.ProductID, userID); err == nil {
+			unitCost = link.UnitCost
+		}
+		if unitCost < 0 {
+			return errors.New("unit cost cannot be negative")
+		}
+
+		subtotal := float64(lineReq.Quantity) * unitCost
+		order.Lines = append(order.Lines, domain.PurchaseOrderLine{
+			ProductID:       lineReq.ProductID,
+			VariantID:       lineReq.VariantID,
+			QuantityOrdered: lineReq.Quantity,
+			UnitCost:        unitCost,
+			Subtotal:        subtotal,
+		})
+		order.TotalCost += subtotal
+	}
+	return nil
+}
+
+func isPurchaseOrderStatus(status domain.PurchaseOrderStatus) bool {
+	switch status {
+	case domain.PurchaseOrderStatusDraft,
+		domain.PurchaseOrderStatusSent,
+		domain.PurchaseOrderStatusPartiallyReceived,
+		domain.PurchaseOrderStatusReceived,
+		domain.PurchaseOrderStatusClosed:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"time"
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/mailer"
+	"vertice-backend/pkg/cron"
+)
+
+const (
+	maxReportRecipients = 10
+	reportTopProducts   = 5
+)
+
+// ReportSubscriptionService emails reports on the schedules users subscribe
+// to.
+type ReportSubscriptionService struct {
+	repo        domain.ReportSubscriptionRepository
+	analytics   *AnalyticsService
+	productRepo domain.ProductRepository
+	users       domain.UserRepository
+	mailer      mailer.Mailer
+	tx          domain.Transactor
+	now         func() time.Time
+}
+
+type ReportSubscriptionServiceOption func(*ReportSubscriptionService)
+
+// WithReportTransactor locks due subscriptions for as long as they are being
+// sent, so that several schedulers never send the same report twice.
+func WithReportTransactor(tx domain.Transactor) ReportSubscriptionServiceOption {
+	return func(s *ReportSubscriptionService) {
+		s.tx = tx
+	}
+}
+
+// WithReportMailer sets the mailer reports are sent with. Without one,
+// reports cannot be sent.
+func WithReportMailer(m mailer.Mailer) ReportSubscriptionServiceOption {
+	return func(s *ReportSubscriptionService) {
+		s.mailer = m
+	}
+}
+
+func NewReportSubscriptionService(repo domain.ReportSubscriptionRepository, analytics *AnalyticsService, productRepo domain.ProductRepository, users domain.UserRepository, opts ...ReportSubscriptionServiceOption) *ReportSubscriptionService {
+	s := &ReportSubscriptionService{
+		repo:        repo,
+		analytics:   analytics,
+		productRepo: productRepo,
+		users:       users,
+		tx:          noTransaction{},
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+type CreateReportSubscriptionRequest struct {
+	Report     domain.ReportType `json:"report" example:"daily_sales"`
+	Cron       string            `json:"cron" example:"0 8 * * 1-5"`
+	Timezone   string            `json:"timezone" example:"Europe/Madrid"`
+	Recipients []string          `json:"recipients" example:"owner@example.com"`
+}
+
+type UpdateReportSubscriptionRequest struct {
+	Cron       *string  `json:"cron"`
+	Timezone   *string  `json:"timezone"`
+	Recipients []string `json:"recipients"`
+	Enabled    *bool    `json:"enabled"`
+}
+
+// DailySalesReport is the data of the daily_sales templates.
+type DailySalesReport struct {
+	Date        string
+	Summary     *SalesSummary
+	TopProducts []domain.ProductSales
+}
+
+// LowStockReport is the data of the low_stock templates.
+type LowStockReport struct {
+	Products []*domain.Product
+}
+
+func (s *ReportSubscriptionService) CreateSubscription(ctx context.Context, userID uint, req CreateReportSubscriptionRequest) (*domain.ReportSubscription, error) {
+	if req.Report != domain.ReportDailySales && req.Report != domain.ReportLowStock {
+		return nil, errors.New("report must be daily_sales or low_stock")
+	}
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	if err := validateReportRecipients(req.Recipients); err != nil {
+		return nil, err
+	}
+	subscription := &domain.ReportSubscription{
+		UserID:   userID,
+		Report:   req.Report,
+		Cron:     req.Cron,
+		Timezone: req.Timezone,
+		Enabled:  true,
+	}
+	subscription.SetRecipients(req.Recipients)
+	if err := s.schedule(subscription, s.now()); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (s *ReportSubscriptionService) GetSubscription(ctx context.Context, id, userID uint) (*domain.ReportSubscription, error) {
+	subscription, err := s.repo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("report subscription not found")
+	}
+	return subscription, nil
+}
+
+func (s *ReportSubscriptionService) GetSubscriptionsByUser(ctx context.Context, userID uint) ([]*domain.ReportSubscription, error) {
+	return s.repo.FindByUserID(ctx, userID)
+}
+
+// UpdateSubscription changes a subscription and schedules its next run anew.
+func (s *ReportSubscriptionService) UpdateSubscription(ctx context.Context, id, userID uint, req UpdateReportSubscriptionRequest) (*domain.ReportSubscription, error) {
+	subscription, err := s.repo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("report subscription not found")
+	}
+	if req.Cron != nil {
+		subscription.Cron = *req.Cron
+	}
+	if req.Timezone != nil {
+		subscription.Timezone = *req.Timezone
+	}
+	if req.Recipients != nil {
+		if err := validateReportRecipients(req.Recipients); err != nil {
+			return nil, err
+		}
+		subscription.SetRecipients(req.Recipients)
+	}
+	if req.Enabled != nil {
+		subscription.Enabled = *req.Enabled
+	}
+	if err := s.schedule(subscription, s.now()); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (s *ReportSubscriptionService) DeleteSubscription(ctx context.Context, id, userID uint) error {
+	if _, err := s.repo.FindByIDAndUserID(ctx, id, userID); err != nil {
+		return errors.New("report subscription not found")
+	}
+	return s.repo.Delete(ctx, id, userID)
+}
+
+// SendNow sends the report of a subscription straight away, whether or not
+// it is enabled. Its schedule is left as it is.
+func (s *ReportSubscriptionService) SendNow(ctx context.Context, id, userID uint) (*domain.ReportSubscription, error) {
+	subscription, err := s.repo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("report subscription not found")
+	}
+	now := s.now()
+	sendErr := s.send(ctx, subscription, now)
+	subscription.LastRunAt = &now
+	subscription.LastError = errorText(sendErr)
+	if err := s.repo.Update(ctx, subscription); err != nil {
+		return nil, err
+	}
+	if sendErr != nil {
+		return nil, sendErr
+	}
+	return subscription, nil
+}
+
+// SendDue sends up to limit reports that are due at now and returns how many
+// were sent. Each subscription is moved on to its next run and committed
+// before its report is sent, so no row stays locked while mail goes out and
+// a failure afterwards cannot send the report twice. A report that fails is
+// not retried: its error is kept in LastError and it runs again at its next
+// scheduled time. Runs missed while no scheduler was running are sent once.
+func (s *ReportSubscriptionService) SendDue(ctx context.Context, now time.Time, limit int) (int, error) {
+	sent := 0
+	for range limit {
+		subscription, err := s.claimDue(ctx, now)
+		if err != nil {
+			return sent, err
+		}
+		if subscription == nil {
+			break
+		}
+		sendErr := s.send(ctx, subscription, now)
+		if sendErr == nil {
+			sent++
+			continue
+		}
+		log.Printf("reports: sending report subscription %d failed: %v", subscription.ID, sendErr)
+		lastError := sendErr.Error()
+		if subscription.LastError != "" {
+			lastError += "; " + subscription.LastError
+		}
+		if err := s.repo.UpdateLastError(ctx, subscription.ID, lastError); err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// claimDue locks the next subscription due at now, schedules its next run
+// and commits, so that no other scheduler picks it up. It returns nil when
+// nothing is due.
+func (s *ReportSubscriptionService) claimDue(ctx context.Context, now time.Time) (*domain.ReportSubscription, error) {
+	var claimed *domain.ReportSubscription
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		due, err := s.repo.LockDue(ctx, now, 1)
+		if err != nil || len(due) == 0 {
+			return err
+		}
+		subscription := due[0]
+		subscription.LastRunAt = &now
+		subscription.LastError = ""
+		if err := s.schedule(subscription, now); err != nil {
+			// The expression no longer fires, so the subscription is
+			// turned off rather than picked up again.
+			subscription.Enabled, subscription.NextRunAt = false, nil
+			subscription.LastError = err.Error()
+		}
+		if err := s.repo.Update(ctx, subscription); err != nil {
+			return err
+		}
+		claimed = subscription
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// Run sends due reports every interval until the context is cancelled.
+func (s *ReportSubscriptionService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.SendDue(ctx, time.Now(), 50); err != nil {
+				log.Printf("reports: sending due reports failed: %v", err)
+			}
+		}
+	}
+}
+
+// schedule validates the cron expression and time zone of a subscription and
+// sets when it next runs after t.
+func (s *ReportSubscriptionService) schedule(subscription *domain.ReportSubscription, t time.Time) error {
+	schedule, err := cron.Parse(subscription.Cron)
+	if err != nil {
+		return err
+	}
+	loc, err := time.LoadLocation(subscription.Timezone)
+	// Local is the server's zone, which reports cannot be queried in.
+	if err != nil || subscription.Timezone == "Local" {
+		return errors.New("timezone must be an IANA time zone such as Europe/Madrid")
+	}
+	next := schedule.Next(t.In(loc))
+	if next.IsZero() {
+		return errors.New("cron expression never fires")
+	}
+	subscription.NextRunAt = nil
+	if subscription.Enabled {
+		next = next.UTC()
+		subscription.NextRunAt = &next
+	}
+	return nil
+}
+
+func (s *ReportSubscriptionService) send(ctx context.Context, subscription *domain.ReportSubscription, now time.Time) error {
+	if s.mailer == nil {
+		return errors.New("no mailer is configured")
+	}
+	recipients := subscription.RecipientList()
+	if len(recipients) == 0 {
+		user, err := s.users.FindByID(ctx, subscription.UserID)
+		if err != nil {
+			return err
+		}
+		recipients = []string{user.Email}
+	}
+	msg, err := s.render(ctx, subscription, recipients, now)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, msg)
+}
+
+// render builds the report of a subscription as of now.
+func (s *ReportSubscriptionService) render(ctx context.Context, subscription *domain.ReportSubscription, recipients []string, now time.Time) (mailer.Message, error) {
+	switch subscription.Report {
+	case domain.ReportDailySales:
+		loc, err := time.LoadLocation(subscription.Timezone)
+		if err != nil {
+			return mailer.Message{}, err
+		}
+		local := now.In(loc)
+		to := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+		from := to.AddDate(0, 0, -1)
+		filter := domain.SalesFilter{From: &from, To: &to, Location: loc}
+		summary, err := s.analytics.SalesSummary(ctx, subscription.UserID, filter)
+		if err != nil {
+			return mailer.Message{}, err
+		}
+		top, err := s.analytics.TopProducts(ctx, subscription.UserID, filter, "revenue", reportTopProducts)
+		if err != nil {
+			return mailer.Message{}, err
+		}
+		date := from.Format(time.DateOnly)
+		return mailer.Render("daily_sales", recipients, "Daily sales for "+date, DailySalesReport{
+			Date:        date,
+			Summary:     summary,
+			TopProducts: top,
+		})
+	case domain.ReportLowStock:
+		products, err := s.productRepo.FindLowStockByUserID(ctx, subscription.UserID)
+		if err != nil {
+			return mailer.Message{}, err
+		}
+		subject := fmt.Sprintf("Low stock: %d products", len(products))
+		return mailer.Render("low_stock", recipients, subject, LowStockReport{Products: products})
+	default:
+		return mailer.Message{}, fmt.Errorf("unknown report %q", subscription.Report)
+	}
+}
+
+func validateReportRecipients(recipients []string) error {
+	if len(recipients) > maxReportRecipients {
+		return fmt.Errorf("at most %d recipients are allowed", maxReportRecipients)
+	}
+	for _, recipient := range recipients {
+		address, err := mail.ParseAddress(recipient)
+		if err != nil || address.Address != recipient {
+			return fmt.Errorf("invalid recipient %q", recipient)
+		}
+	}
+	return nil
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
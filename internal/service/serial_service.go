@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"vertice-backend/internal/domain"
+)
+
+// maxSerialsPerRequest bounds how many serials one request registers,
+// assigns or returns.
+const maxSerialsPerRequest = 1000
+
+var errSerialStock = errors.New("stock of a serialized product changes through its serials; receive or return serials instead")
+
+type SerialService struct {
+	serialRepo  domain.SerialRepository
+	productRepo domain.ProductRepository
+	orderRepo   domain.OrderRepository
+	variantRepo domain.VariantRepository
+	tx          domain.Transactor
+	events      EventRecorder
+	now         func() time.Time
+}
+
+type SerialServiceOption func(*SerialService)
+
+// WithSerialTransactor makes serial changes, the stock changes and their
+// events commit atomically.
+func WithSerialTransactor(tx domain.Transactor) SerialServiceOption {
+	return func(s *SerialService) {
+		s.tx = tx
+	}
+}
+
+// WithSerialEvents sets where the stock events of receipts and returns are
+// recorded.
+func WithSerialEvents(events EventRecorder) SerialServiceOption {
+	return func(s *SerialService) {
+		s.events = events
+	}
+}
+
+// WithSerialVariants lets serials be units of product variants.
+func WithSerialVariants(variantRepo domain.VariantRepository) SerialServiceOption {
+	return func(s *SerialService) {
+		s.variantRepo = variantRepo
+	}
+}
+
+func NewSerialService(serialRepo domain.SerialRepository, productRepo domain.ProductRepository, orderRepo domain.OrderRepository, opts ...SerialServiceOption) *SerialService {
+	s := &SerialService{
+		serialRepo:  serialRepo,
+		productRepo: productRepo,
+		orderRepo:   orderRepo,
+		tx:          noTransaction{},
+		events:      discardEvents{},
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ReceiveSerialsRequest adds one unit of stock per serial to a serialized
+// product, naming the variant for products with variants.
+type ReceiveSerialsRequest struct {
+	ProductID uint     `json:"product_id" example:"1"`
+	VariantID *uint    `json:"variant_id,omitempty"`
+	Serials   []string `json:"serials" example:"SN-0001,SN-0002"`
+}
+
+// SetSerialized turns serial tracking of a product on or off. As every unit
+// of a serialized product needs a serial, it cannot be turned on while the
+// product has stock.
+func (s *SerialService) SetSerialized(ctx context.Context, productID, userID uint, enabled bool) (*domain.Product, error) {
+	product, err := s.productRepo.FindByIDAndUserID(ctx, productID, userID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+	if enabled && !product.Serialized {
+		if product.BatchManaged {
+			return nil, errors.New("batch-managed products cannot be serialized")
+		}
+		if product.IsBundle() {
+			return nil, errors.New("bundles cannot be serialized")
+		}
+		if product.Stock > 0 {
+			return nil, errors.New("stock must be zero to turn on serial tracking; receive it with serials instead")
+		}
+	}
+	product.Serialized = enabled
+	if err := s.productRepo.Update(ctx, product, userID); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// ReceiveSerials registers serials of a serialized product and adds them to
+// stock.
+func (s *SerialService) ReceiveSerials(ctx context.Context, userID uint, req ReceiveSerialsRequest) ([]*domain.Serial, error) {
+	var serials []*domain.Serial
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		product, err := s.productRepo.FindByIDAndUserID(ctx, req.ProductID, userID)
+		if err != nil {
+			return errors.New("product not found")
+		}
+		var variant *domain.ProductVariant
+		if req.VariantID != nil {
+			v, ok := product.Variant(*req.VariantID)
+			if !ok {
+				return errors.New("variant not found")
+			}
+			variant = v
+		} else if product.HasVariants() {
+			return errors.New("variant_id is required for product: " + product.Name)
+		}
+
+		if serials, err = registerSerials(ctx, s.serialRepo, product, variant, req.Serials, s.now()); err != nil {
+			return err
+		}
+		change, err := applyStockChange(ctx, s.productRepo, s.variantRepo, product, variant, len(serials), domain.StockReasonSerialsReceived)
+		if err != nil {
+			return err
+		}
+		return change.record(ctx, s.events)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return serials, nil
+}
+
+// registerSerials saves new in-stock serials of a serialized product or
+// variant. The caller changes the stock.
+func registerSerials(ctx context.Context, serialRepo domain.SerialRepository, product *domain.Product, variant *domain.ProductVariant, numbers []string, now time.Time) ([]*domain.Serial, error) {
+	if !product.Serialized {
+		return nil, errors.New("product is not serialized: " + product.Name)
+	}
+	if serialRepo == nil {
+		return nil, errors.New("serials are not available")
+	}
+	numbers, err := normalizeSerials(numbers)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := serialRepo.LockByNumbers(ctx, numbers, product.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("serial %s is already registered", existing[0].Number)
+	}
+
+	var variantID *uint
+	if variant != nil {
+		variantID = &variant.ID
+	}
+	serials := make([]*domain.Serial, len(numbers))
+	for i, number := range numbers {
+		serials[i] = &domain.Serial{
+			UserID:     product.UserID,
+			Number:     number,
+			ProductID:  product.ID,
+			VariantID:  variantID,
+			Status:     domain.SerialStatusInStock,
+			ReceivedAt: now,
+		}
+	}
+	return serials, serialRepo.Create(ctx, serials)
+}
+
+// normalizeSerials trims the serials and rejects blank and repeated ones.
+func normalizeSerials(numbers []string) ([]string, error) {
+	if len(numbers) == 0 {
+		return nil, errors.New("at least one serial is required")
+	}
+	if len(numbers) > maxSerialsPerRequest {
+		return nil, fmt.Errorf("at most %d serials can be given at once", maxSerialsPerRequest)
+	}
+	normalized := make([]string, len(numbers))
+	seen := make(map[string]bool, len(numbers))
+	for i, number := range numbers {
+		number = strings.TrimSpace(number)
+		if number == "" {
+			return nil, errors.New("serials cannot be blank")
+		}
+		if seen[number] {
+			return nil, fmt.Errorf("serial %s is given twice", number)
+		}
+		seen[number] = true
+		normalized[i] = number
+	}
+	return normalized, nil
+}
+
+// AssignSerials sets the serials of an order item of a serialized product.
+// The given serials replace those assigned before, which go back in stock,
+// so a wrong scan can be corrected until the item ships.
+func (s *SerialService) AssignSerials(ctx context.Context, orderID, itemID, userID uint, numbers []string) (*domain.Order, error) {
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		order, err := s.orderRepo.FindByIDAndUserID(ctx, orderID, userID)
+		if err != nil {
+			return errors.New("order not found")
+		}
+		if order.Status != domain.OrderStatusPending && order.Status != domain.OrderStatusConfirmed {
+			return errors.New("serials can only be assigned before the order ships")
+		}
+		var item *domain.OrderItem
+		for i := range order.Items {
+			if order.Items[i].ID == itemID {
+				item = &order.Items[i]
+			}
+		}
+		if item == nil {
+			return errors.New("order item not found")
+		}
+		if !item.Product.Serialized {
+			return errors.New("product is not serialized: " + item.Product.Name)
+		}
+		if numbers, err = normalizeSerials(numbers); err != nil {
+			return err
+		}
+		if len(numbers) > item.Quantity {
+			return fmt.Errorf("the item has %d units, but %d serials were given", item.Quantity, len(numbers))
+		}
+
+		serials, err := s.serialRepo.LockByNumbers(ctx, numbers, userID)
+		if err != nil {
+			return err
+		}
+		found := make(map[string]*domain.Serial, len(serials))
+		for _, serial := range serials {
+			found[serial.Number] = serial
+		}
+		keep := make(map[uint]bool, len(numbers))
+		for _, number := range numbers {
+			serial, ok := found[number]
+			if !ok {
+				return fmt.Errorf("serial %s not found", number)
+			}
+			if serial.ProductID != item.ProductID || !sameVariant(serial.VariantID, item.VariantID) {
+				return fmt.Errorf("serial %s is not a unit of the ordered product", number)
+			}
+			assignedHere := serial.Status == domain.SerialStatusAssigned && serial.OrderItemID != nil && *serial.OrderItemID == item.ID
+			if serial.Status != domain.SerialStatusInStock && !assignedHere {
+				return fmt.Errorf("serial %s is not in stock", number)
+			}
+			keep[serial.ID] = true
+		}
+
+		for i := range item.Serials {
+			previous := &item.Serials[i]
+			if keep[previous.ID] {
+				continue
+			}
+			previous.Status, previous.OrderItemID = domain.SerialStatusInStock, nil
+			if err := s.serialRepo.Update(ctx, previous); err != nil {
+				return err
+			}
+		}
+		for _, serial := range serials {
+			serial.Status, serial.OrderItemID = domain.SerialStatusAssigned, &item.ID
+			if err := s.serialRepo.Update(ctx, serial); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.orderRepo.FindByIDAndUserID(ctx, orderID, userID)
+}
+
+func sameVariant(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// ReturnSerials brings shipped serials back into stock, including those of
+// orders cancelled after they shipped.
+func (s *SerialService) ReturnSerials(ctx context.Context, userID uint, numbers []string) ([]*domain.Serial, error) {
+	var returned []*domain.Serial
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if numbers, err = normalizeSerials(numbers); err != nil {
+			return err
+		}
+		serials, err := s.serialRepo.LockByNumbers(ctx, numbers, userID)
+		if err != nil {
+			return err
+		}
+		found := make(map[string]*domain.Serial, len(serials))
+		for _, serial := range serials {
+			found[serial.Number] = serial
+		}
+
+		now := s.now()
+		products := make(map[uint]*domain.Product)
+		for _, number := range numbers {
+			serial, ok := found[number]
+			if !ok {
+				return fmt.Errorf("serial %s not found", number)
+			}
+			if serial.Status != domain.SerialStatusAssigned || serial.OrderItem == nil {
+				return fmt.Errorf("serial %s was not sold", number)
+			}
+			// Cancelling an unshipped order unassigns its serials, so an
+			// assigned serial of a cancelled order left with the shipment.
+			if status := serial.OrderItem.Order.Status; status != domain.OrderStatusShipped && status != domain.OrderStatusDelivered && status != domain.OrderStatusCancelled {
+				return fmt.Errorf("serial %s has not shipped; cancel the order or assign another serial instead", number)
+			}
+
+			product, ok := products[serial.ProductID]
+			if !ok {
+				if product, err = s.productRepo.FindByIDAndUserID(ctx, serial.ProductID, userID); err != nil {
+					return errors.New("product not found")
+				}
+				products[serial.ProductID] = product
+			}
+			var variant *domain.ProductVariant
+			if serial.VariantID != nil {
+				if variant, ok = product.Variant(*serial.VariantID); !ok {
+					return errors.New("variant not found")
+				}
+			}
+
+			serial.Status, serial.ReturnedAt = domain.SerialStatusInStock, &now
+			if err := s.serialRepo.Update(ctx, serial); err != nil {
+				return err
+			}
+			change, err := applyStockChange(ctx, s.productRepo, s.variantRepo, product, variant, 1, domain.StockReasonReturned)
+			if err != nil {
+				return err
+			}
+			if err := change.record(ctx, s.events); err != nil {
+				return err
+			}
+			returned = append(returned, serial)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return returned, nil
+}
+
+// LookupSerial finds a serial with the order item and order it was last
+// assigned to.
+func (s *SerialService) LookupSerial(ctx context.Context, number string, userID uint) (*domain.Serial, error) {
+	serial, err := s.serialRepo.FindByNumber(ctx, strings.TrimSpace(number), userID)
+	if err != nil {
+		return nil, errors.New("serial not found")
+	}
+	return serial, nil
+}
+
+// GetProductSerials returns the serials of a product, optionally only those
+// with the given status.
+func (s *SerialService) GetProductSerials(ctx context.Context, productID, userID uint, status domain.SerialStatus) ([]*domain.Serial, error) {
+	if status != "" && status != domain.SerialStatusInStock && status != domain.SerialStatusAssigned {
+		return nil, errors.New("invalid status")
+	}
+	if _, err := s.productRepo.FindByIDAndUserID(ctx, productID, userID); err != nil {
+		return nil, errors.New("product not found")
+	}
+	return s.serialRepo.FindByProductID(ctx, productID, userID, status)
+}
+
+// checkSerialsAssigned refuses to ship an item of a serialized product
+// before every unit of it has a serial. shipped holds the shipped quantity
+// of each order item; nil ships the whole order.
+func checkSerialsAssigned(order *domain.Order, shipped map[uint]int) error {
+	for _, item := range order.Items {
+		if !item.Product.Serialized || (shipped != nil && shipped[item.ID] == 0) {
+			continue
+		}
+		if len(item.Serials) < item.Quantity {
+			return fmt.Errorf("serials must be assigned to all %d units of %s before shipping", item.Quantity, item.Product.Name)
+		}
+	}
+	return nil
+}
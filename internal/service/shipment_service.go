@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"vertice-backend/internal/domain"
+)
+
+type ShipmentService struct {
+	shipmentRepo domain.ShipmentRepository
+	orderRepo    domain.OrderRepository
+	tx           domain.Transactor
+	events       EventRecorder
+}
+
+type ShipmentServiceOption func(*ShipmentService)
+
+// WithShipmentTransactor makes a shipment change and the order status it
+// implies commit atomically.
+func WithShipmentTransactor(tx domain.Transactor) ShipmentServiceOption {
+	return func(s *ShipmentService) {
+		s.tx = tx
+	}
+}
+
+// WithShipmentEvents sets where order status changes caused by shipments are recorded.
+func WithShipmentEvents(events EventRecorder) ShipmentServiceOption {
+	return func(s *ShipmentService) {
+		s.events = events
+	}
+}
+
+func NewShipmentService(shipmentRepo domain.ShipmentRepository, orderRepo domain.OrderRepository, opts ...ShipmentServiceOption) *ShipmentService {
+	s := &ShipmentService{
+		shipmentRepo: shipmentRepo,
+		orderRepo:    orderRepo,
+		tx:           noTransaction{},
+		events:       discardEvents{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+type CreateShipmentRequest struct {
+	Carrier        string                `json:"carrier"`
+	Service        string                `json:"service"`
+	TrackingNumber string                `json:"tracking_number"`
+	Weight         float64               `json:"weight"`
+	ShippedAt      *time.Time            `json:"shipped_at"`
+	Items          []ShipmentItemRequest `json:"items"`
+}
+
+type ShipmentItemRequest struct {
+	OrderItemID uint `json:"order_item_id"`
+	Quantity    int  `json:"quantity"`
+}
+
+type UpdateShipmentRequest struct {
+	Carrier        *string    `json:"carrier"`
+	Service        *string    `json:"service"`
+	TrackingNumber *string    `json:"tracking_number"`
+	Weight         *float64   `json:"weight"`
+	ShippedAt      *time.Time `json:"shipped_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
+
+// CreateShipment registers a shipment for part or all of an order. When no
+// items are given, every quantity not yet assigned to a shipment is included.
+func (s *ShipmentService) CreateShipment(ctx context.Context, userID, orderID uint, req CreateShipmentRequest) (*domain.Shipment, error) {
+	order, err := s.orderRepo.FindByIDAndUserID(ctx, orderID, userID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+	if order.Status != domain.OrderStatusConfirmed {
+		return nil, errors.New("only confirmed orders can be shipped")
+	}
+	if req.Weight < 0 {
+		return nil, errors.New("weight cannot be negative")
+	}
+
+	existing, err := s.shipmentRepo.FindByOrderID(ctx, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	remaining := remainingQuantities(order, existing)
+
+	shipment := &domain.Shipment{
+		OrderID:        order.ID,
+		UserID:         userID,
+		Carrier:        req.Carrier,
+		Service:        req.Service,
+		TrackingNumber: req.TrackingNumber,
+		Weight:         req.Weight,
+		Status:         domain.ShipmentStatusPending,
+		ShippedAt:      req.ShippedAt,
+	}
+
+	if len(req.Items) == 0 {
+		for _, item := range order.Items {
+			if remaining[item.ID] > 0 {
+				shipment.Items = append(shipment.Items, domain.ShipmentItem{
+					OrderItemID: item.ID,
+					Quantity:    remaining[item.ID],
+				})
+			}
+		}
+		if len(shipment.Items) == 0 {
+			return nil, errors.New("all order items are already assigned to shipments")
+		}
+	} else {
+		for _, itemReq := range req.Items {
+			if itemReq.Quantity <= 0 {
+				return nil, errors.New("quantity must be greater than 0")
+			}
+			left, ok := remaining[itemReq.OrderItemID]
+			if !ok {
+				return nil, errors.New("order item not found")
+			}
+			if itemReq.Quantity > left {
+				return nil, errors.New("quantity exceeds the unshipped quantity of the order item")
+			}
+			remaining[itemReq.OrderItemID] -= itemReq.Quantity
+			shipment.Items = append(shipment.Items, domain.ShipmentItem{
+				OrderItemID: itemReq.OrderItemID,
+				Quantity:    itemReq.Quantity,
+			})
+		}
+	}
+
+	if shipment.ShippedAt != nil {
+		shipment.Status = domain.ShipmentStatusShipped
+	}
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.shipmentRepo.Create(ctx, shipment); err != nil {
+			return err
+		}
+		return s.syncOrderStatus(ctx, order, append(existing, shipment), userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shipment, nil
+}
+
+func (s *ShipmentService) GetShipments(ctx context.Context, userID, orderID uint) ([]*domain.Shipment, error) {
+	if _, err := s.orderRepo.FindByIDAndUserID(ctx, orderID, userID); err != nil {
+		return nil, errors.New("order not found")
+	}
+	return s.shipmentRepo.FindByOrderID(ctx, orderID, userID)
+}
+
+// UpdateShipment edits carrier details and records the hand-over and delivery
+// timestamps. Once every item of the order is shipped (or delivered) the order
+// status follows automatically.
+func (s *ShipmentService) UpdateShipment(ctx context.Context, userID, orderID, shipmentID uint, req UpdateShipmentRequest) (*domain.Shipment, error) {
+	shipment, err := s.shipmentRepo.FindByIDAndUserID(ctx, shipmentID, userID)
+	if err != nil || shipment.OrderID != orderID {
+		return nil, errors.New("shipment not found")
+	}
+
+	if req.Carrier != nil {
+		shipment.Carrier = *req.Carrier
+	}
+	if req.Service != nil {
+		shipment.Service = *req.Service
+	}
+	if req.TrackingNumber != nil {
+		shipment.TrackingNumber = *req.TrackingNumber
+	}
+	if req.Weight != nil {
+		if *req.Weight < 0 {
+			return nil, errors.New("weight cannot be negative")
+		}
+		shipment.Weight = *req.Weight
+	}
+	if req.ShippedAt != nil {
+		shipment.ShippedAt = req.ShippedAt
+	}
+	if req.DeliveredAt != nil {
+		if shipment.ShippedAt == nil {
+			shipment.ShippedAt = req.DeliveredAt
+		}
+		shipment.DeliveredAt = req.DeliveredAt
+	}
+	// Either timestamp may have changed alone, so they are checked together.
+	if shipment.DeliveredAt != nil && shipment.DeliveredAt.Before(*shipment.ShippedAt) {
+		return nil, errors.New("delivered_at cannot be before shipped_at")
+	}
+
+	switch {
+	case shipment.DeliveredAt != nil:
+		shipment.Status = domain.ShipmentStatusDelivered
+	case shipment.ShippedAt != nil:
+		shipment.Status = domain.ShipmentStatusShipped
+	default:
+		shipment.Status = domain.ShipmentStatusPending
+	}
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.shipmentRepo.Update(ctx, shipment, userID); err != nil {
+			return err
+		}
+
+		order, err := s.orderRepo.FindByIDAndUserID(ctx, orderID, userID)
+		if err != nil {
+			return errors.New("order not found")
+		}
+		shipments, err := s.shipmentRepo.FindByOrderID(ctx, orderID, userID)
+		if err != nil {
+			return err
+		}
+		return s.syncOrderStatus(ctx, order, shipments, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shipment, nil
+}
+
+// syncOrderStatus moves the order to shipped once all of its quantities have
+// left in shipments, and to delivered once all of them have arrived.
+func (s *ShipmentService) syncOrderStatus(ctx context.Context, order *domain.Order, shipments []*domain.Shipment, userID uint) error {
+	shipped := map[uint]int{}
+	delivered := map[uint]int{}
+	for _, shipment := range shipments {
+		for _, item := range shipment.Items {
+			if shipment.ShippedAt != nil {
+				shipped[item.OrderItemID] += item.Quantity
+			}
+			if shipment.DeliveredAt != nil {
+				delivered[item.OrderItemID] += item.Quantity
+			}
+		}
+	}
+
+	// Serials are checked while the order is still on its way to shipped.
+	// Once it has shipped, returned serials are no longer assigned to it and
+	// later updates, such as the delivery date, must not trip over them.
+	if order.Status == domain.OrderStatusConfirmed {
+		if err := checkSerialsAssigned(order, shipped); err != nil {
+			return err
+		}
+	}
+
+	allShipped, allDelivered := true, true
+	for _, item := range order.Items {
+		if shipped[item.ID] < item.Quantity {
+			allShipped = false
+		}
+		if delivered[item.ID] < item.Quantity {
+			allDelivered = false
+		}
+	}
+
+	next := order.Status
+	if allShipped && next == domain.OrderStatusConfirmed {
+		next = domain.OrderStatusShipped
+	}
+	if allDelivered && next == domain.OrderStatusShipped {
+		next = domain.OrderStatusDelivered
+	}
+	if next == order.Status {
+		return nil
+	}
+
+	previous := order.Status
+	order.Status = next
+	if err := s.orderRepo.Update(ctx, order, userID); err != nil {
+		return err
+	}
+	return recordStatusChanged(ctx, s.events, order, previous)
+}
+
+func remainingQuantities(order *domain.Order, shipments []*domain.Shipment) map[uint]int {
+	remaining := make(map[uint]int, len(order.Items))
+	for _, item := range order.Items {
+		remaining[item.ID] = item.Quantity
+	}
+	for _, shipment := range shipments {
+		for _, item := range shipment.Items {
+			remaining[item.OrderItemID] -= item.Quantity
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"vertice-backend/internal/domain"
+)
+
+type StocktakeService struct {
+	stocktakeRepo domain.StocktakeRepository
+	productRepo   domain.ProductRepository
+	variantRepo   domain.VariantRepository
+	categoryRepo  domain.CategoryRepository
+	tx            domain.Transactor
+	events        EventRecorder
+}
+
+type StocktakeServiceOption func(*StocktakeService)
+
+// WithStocktakeTransactor makes counts and approvals, with their stock
+// changes and events, commit atomically.
+func WithStocktakeTransactor(tx domain.Transactor) StocktakeServiceOption {
+	return func(s *StocktakeService) {
+		s.tx = tx
+	}
+}
+
+// WithStocktakeEvents sets where the stock events of approvals are recorded.
+func WithStocktakeEvents(events EventRecorder) StocktakeServiceOption {
+	return func(s *StocktakeService) {
+		s.events = events
+	}
+}
+
+// WithStocktakeVariants lets approvals change variant stock.
+func WithStocktakeVariants(variantRepo domain.VariantRepository) StocktakeServiceOption {
+	return func(s *StocktakeService) {
+		s.variantRepo = variantRepo
+	}
+}
+
+// WithStocktakeCategories lets stocktakes be opened for a category.
+func WithStocktakeCategories(categoryRepo domain.CategoryRepository) StocktakeServiceOption {
+	return func(s *StocktakeService) {
+		s.categoryRepo = categoryRepo
+	}
+}
+
+func NewStocktakeService(stocktakeRepo domain.StocktakeRepository, productRepo domain.ProductRepository, opts ...StocktakeServiceOption) *StocktakeService {
+	s := &StocktakeService{
+		stocktakeRepo: stocktakeRepo,
+		productRepo:   productRepo,
+		tx:            noTransaction{},
+		events:        discardEvents{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// OpenStocktakeRequest names the products to count: those in ProductIDs, or
+// else those in Category, or else all products.
+type OpenStocktakeRequest struct {
+	Name       string `json:"name" example:"Year-end count"`
+	Notes      string `json:"notes" example:"Back room first"`
+	ProductIDs []uint `json:"product_ids" example:"1,2,3"`
+	Category   string `json:"category" example:"kitchen"`
+}
+
+// StocktakeCount records a counted quantity for a line, found by the code a
+// scanner reads (a variant code or the code of a product without variants)
+// or by product and variant ID. Mode "add", the default, adds Quantity to
+// what earlier passes counted; "set" replaces it.
+type StocktakeCount struct {
+	Code      string `json:"code,omitempty" example:"TSHIRT-RED"`
+	ProductID *uint  `json:"product_id,omitempty"`
+	VariantID *uint  `json:"variant_id,omitempty"`
+	Quantity  int    `json:"quantity" example:"1"`
+	Mode      string `json:"mode,omitempty" example:"add"`
+}
+
+// OpenStocktake starts a count with a line per product, or per variant for
+// products with variants. Products already in an open stocktake are refused.
+func (s *StocktakeService) OpenStocktake(ctx context.Context, userID uint, req OpenStocktakeRequest) (*domain.Stocktake, error) {
+	if req.Name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	var products []*domain.Product
+	if len(req.ProductIDs) > 0 {
+		for _, id := range uniqueIDs(req.ProductIDs) {
+			product, err := s.productRepo.FindByIDAndUserID(ctx, id, userID)
+			if err != nil {
+				return nil, fmt.Errorf("product %d not found", id)
+			}
+			products = append(products, product)
+		}
+	} else {
+		filter, err := resolveProductFilter(ctx, s.categoryRepo, userID, ProductListFilter{Category: req.Category})
+		if err != nil {
+			return nil, err
+		}
+		if products, err = s.productRepo.FindByFilter(ctx, userID, filter); err != nil {
+			return nil, err
+		}
+	}
+
+	// Bundles hold no stock of their own; their components are counted.
+	// Batch-managed and serialized stock is corrected through lots and
+	// serials, never by a plain adjustment.
+	var ids []uint
+	for _, product := range products {
+		if directStockError(product) == nil {
+			ids = append(ids, product.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("no products to count")
+	}
+
+	stocktake := &domain.Stocktake{UserID: userID, Name: req.Name, Notes: req.Notes, Status: domain.StocktakeStatusOpen}
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Stocktakes opened at the same time over the same products wait
+		// for each other here, so the later one sees the earlier's lines.
+		if err := s.productRepo.LockByIDs(ctx, ids, userID); err != nil {
+			return err
+		}
+		open, err := s.stocktakeRepo.FindOpenProductIDs(ctx, userID)
+		if err != nil {
+			return err
+		}
+		counting := make(map[uint]bool, len(open))
+		for _, id := range open {
+			counting[id] = true
+		}
+		for _, product := range products {
+			if directStockError(product) != nil {
+				continue
+			}
+			if counting[product.ID] {
+				return fmt.Errorf("product %s is already being counted in another stocktake", product.Code)
+			}
+			if !product.HasVariants() {
+				stocktake.Lines = append(stocktake.Lines, domain.StocktakeLine{ProductID: product.ID})
+				continue
+			}
+			for i := range product.Variants {
+				variantID := product.Variants[i].ID
+				stocktake.Lines = append(stocktake.Lines, domain.StocktakeLine{ProductID: product.ID, VariantID: &variantID})
+			}
+		}
+		return s.stocktakeRepo.Create(ctx, stocktake)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.stocktakeRepo.FindByIDAndUserID(ctx, stocktake.ID, userID)
+}
+
+func (s *StocktakeService) GetStocktake(ctx context.Context, id, userID uint) (*domain.Stocktake, error) {
+	stocktake, err := s.stocktakeRepo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("stocktake not found")
+	}
+	return stocktake, nil
+}
+
+func (s *StocktakeService) GetStocktakes(ctx context.Context, userID uint, status domain.StocktakeStatus) ([]*domain.Stocktake, error) {
+	return s.stocktakeRepo.FindByUserID(ctx, userID, status)
+}
+
+// RecordCounts adds counted quantities to an open stocktake. The counts are
+// applied in order and all or none: if any is invalid, an *EntryErrors lists
+// them. A line's expected stock is taken from the system stock when the line
+// is first counted.
+func (s *StocktakeService) RecordCounts(ctx context.Context, id, userID uint, counts []StocktakeCount) (*domain.Stocktake, error) {
+	if len(counts) == 0 {
+		return nil, errors.New("at least one count is required")
+	}
+
+	var stocktake *domain.Stocktake
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		stocktake, err = s.lockOpen(ctx, id, userID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		changed := make(map[*domain.StocktakeLine]bool)
+		var order []*domain.StocktakeLine
+		entryErrs := &EntryErrors{}
+		for i, count := range counts {
+			line, err := findStocktakeLine(stocktake, count)
+			if err != nil {
+				entryErrs.Add(i, err.Error())
+				continue
+			}
+			counted := count.Quantity
+			switch count.Mode {
+			case "", "add":
+				if line.Counted != nil {
+					counted += *line.Counted
+				}
+			case "set":
+			default:
+				entryErrs.Add(i, "mode must be add or set")
+				continue
+			}
+			if counted < 0 {
+				entryErrs.Add(i, "counted quantity cannot be negative")
+				continue
+			}
+			if line.Expected == nil {
+				expected := line.SystemStock()
+				line.Expected = &expected
+			}
+			line.Counted = &counted
+			line.CountedAt = &now
+			if !changed[line] {
+				changed[line] = true
+				order = append(order, line)
+			}
+		}
+		if len(entryErrs.Entries) > 0 {
+			return entryErrs
+		}
+		return s.stocktakeRepo.Update(ctx, stocktake, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stocktake, nil
+}
+
+// findStocktakeLine returns the line a count is for.
+func findStocktakeLine(stocktake *domain.Stocktake, count StocktakeCount) (*domain.StocktakeLine, error) {
+	if (count.Code == "") == (count.ProductID == nil) {
+		return nil, errors.New("exactly one of code and product_id is required")
+	}
+	for i := range stocktake.Lines {
+		line := &stocktake.Lines[i]
+		if count.Code != "" {
+			if line.Code() == count.Code {
+				return line, nil
+			}
+			continue
+		}
+		if line.ProductID != *count.ProductID {
+			continue
+		}
+		if count.VariantID == nil && line.VariantID == nil {
+			return line, nil
+		}
+		if count.VariantID != nil && line.VariantID != nil && *count.VariantID == *line.VariantID {
+			return line, nil
+		}
+	}
+	return nil, errors.New("not part of this stocktake")
+}
+
+// ApproveStocktake posts the variance of each counted line as a stock
+// adjustment. Variances are added to the current stock rather than replacing
+// it, so orders placed after a line was counted are kept. A stock that the
+// variance would take below zero is set to zero. Uncounted lines, and lines
+// of products whose stock can no longer be set directly, are left unchanged.
+func (s *StocktakeService) ApproveStocktake(ctx context.Context, id, userID uint) (*domain.Stocktake, error) {
+	var stocktake *domain.Stocktake
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		stocktake, err = s.lockOpen(ctx, id, userID)
+		if err != nil {
+			return err
+		}
+
+		products := make(map[uint]*domain.Product)
+		var adjusted []*domain.StocktakeLine
+		for i := range stocktake.Lines {
+			line := &stocktake.Lines[i]
+			variance := line.Variance()
+			if variance == nil || *variance == 0 {
+				continue
+			}
+			product, ok := products[line.ProductID]
+			if !ok {
+				product, err = s.productRepo.FindByIDAndUserID(ctx, line.ProductID, userID)
+				if err != nil {
+					return fmt.Errorf("product %d not found", line.ProductID)
+				}
+				products[line.ProductID] = product
+			}
+			// The product became a bundle, batch-managed or serialized
+			// after it was counted.
+			if directStockError(product) != nil {
+				continue
+			}
+			current := product.Stock
+			var variant *domain.ProductVariant
+			if line.VariantID != nil {
+				if variant, ok = product.Variant(*line.VariantID); !ok {
+					continue
+				}
+				current = variant.Stock
+			} else if product.HasVariants() {
+				// The product gained variants after it was counted.
+				continue
+			}
+			delta := max(*variance, -current)
+			if delta == 0 {
+				continue
+			}
+			change, err := applyStockChange(ctx, s.productRepo, s.variantRepo, product, variant, delta, domain.StockReasonStocktake)
+			if err != nil {
+				return err
+			}
+			if err := change.record(ctx, s.events); err != nil {
+				return err
+			}
+			line.Adjustment = delta
+			adjusted = append(adjusted, line)
+		}
+
+		now := time.Now()
+		stocktake.Status = domain.StocktakeStatusApproved
+		stocktake.ApprovedAt = &now
+		return s.stocktakeRepo.Update(ctx, stocktake, adjusted)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.stocktakeRepo.FindByIDAndUserID(ctx, stocktake.ID, userID)
+}
+
+// CancelStocktake closes an open stocktake without changing stock.
+func (s *StocktakeService) CancelStocktake(ctx context.Context, id, userID uint) (*domain.Stocktake, error) {
+	var stocktake *domain.Stocktake
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		stocktake, err = s.lockOpen(ctx, id, userID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		stocktake.Status = domain.StocktakeStatusCancelled
+		stocktake.CancelledAt = &now
+		return s.stocktakeRepo.Update(ctx, stocktake, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stocktake, nil
+}
+
+func (s *StocktakeService) lockOpen(ctx context.Context, id, userID uint) (*domain.Stocktake, error) {
+	stocktake, err := s.stocktakeRepo.LockByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("stocktake not found")
+	}
+	if stocktake.Status != domain.StocktakeStatusOpen {
+		return nil, errors.New("stocktake is not open")
+	}
+	return stocktake, nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"vertice-backend/internal/domain"
+)
+
+// StreamEventTypes are the events pushed to live dashboards.
+var StreamEventTypes = []string{
+	domain.EventOrderCreated,
+	domain.EventOrderStatusChanged,
+	domain.EventStockAdjusted,
+	domain.EventProductStockLow,
+	domain.EventProductDeleted,
+	domain.EventProductPriceChanged,
+}
+
+// StreamEvent is one server-sent event. ID is the outbox message ID.
+type StreamEvent struct {
+	ID   uint
+	Type string
+	Data string
+}
+
+// StreamSubscription is a live subscription to a user's events. Replay holds
+// the buffered events after the requested Last-Event-ID. Missed is set when
+// that ID is no longer in the buffer, so the client should reload its data.
+// Events is closed when the subscriber falls too far behind or is cancelled.
+type StreamSubscription struct {
+	Replay []StreamEvent
+	Missed bool
+	Events <-chan StreamEvent
+
+	hub    *StreamHub
+	userID uint
+	ch     chan StreamEvent
+}
+
+func (s *StreamSubscription) Close() {
+	s.hub.unsubscribe(s.userID, s.ch)
+}
+
+// StreamHub fans relayed events out to the connected clients of each user and
+// keeps a bounded per-user replay buffer for reconnects.
+type StreamHub struct {
+	mu          sync.Mutex
+	replaySize  int
+	channelSize int
+	buffers     map[uint][]StreamEvent
+	subscribers map[uint]map[chan StreamEvent]struct{}
+}
+
+type StreamHubOption func(*StreamHub)
+
+// WithStreamReplaySize sets how many events are kept per user for resuming.
+func WithStreamReplaySize(size int) StreamHubOption {
+	return func(h *StreamHub) {
+		h.replaySize = size
+	}
+}
+
+// WithStreamChannelSize sets how many events a subscriber may lag behind
+// before it is disconnected.
+func WithStreamChannelSize(size int) StreamHubOption {
+	return func(h *StreamHub) {
+		h.channelSize = size
+	}
+}
+
+func NewStreamHub(opts ...StreamHubOption) *StreamHub {
+	h := &StreamHub{
+		replaySize:  256,
+		channelSize: 64,
+		buffers:     map[uint][]StreamEvent{},
+		subscribers: map[uint]map[chan StreamEvent]struct{}{},
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Handle is an EventBus handler. Events that are not streamed are ignored.
+func (h *StreamHub) Handle(_ context.Context, message *domain.OutboxMessage) error {
+	if !slices.Contains(StreamEventTypes, message.EventType) {
+		return nil
+	}
+	event := StreamEvent{ID: message.ID, Type: message.EventType, Data: message.Payload}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	buffer := h.buffers[message.UserID]
+	if slices.ContainsFunc(buffer, func(e StreamEvent) bool { return e.ID == event.ID }) {
+		// The relay delivers at least once; do not push a redelivery twice.
+		return nil
+	}
+	buffer = append(buffer, event)
+	if len(buffer) > h.replaySize {
+		buffer = slices.Delete(buffer, 0, len(buffer)-h.replaySize)
+	}
+	h.buffers[message.UserID] = buffer
+
+	for ch := range h.subscribers[message.UserID] {
+		select {
+		case ch <- event:
+		default:
+			// A slow client is dropped rather than blocking the relay; it
+			// resumes from its Last-Event-ID after reconnecting.
+			delete(h.subscribers[message.UserID], ch)
+			close(ch)
+		}
+	}
+	return nil
+}
+
+// Subscribe registers a client of the user. lastEventID is the ID of the last
+// event the client saw, or 0 for a fresh connection without replay.
+func (h *StreamHub) Subscribe(userID, lastEventID uint) *StreamSubscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub := &StreamSubscription{hub: h, userID: userID, ch: make(chan StreamEvent, h.channelSize)}
+	sub.Events = sub.ch
+
+	if lastEventID != 0 {
+		// Outbox IDs are not strictly increasing in delivery order because
+		// failed aggregates are held back, so resume by buffer position.
+		buffer := h.buffers[userID]
+		i := slices.IndexFunc(buffer, func(e StreamEvent) bool { return e.ID == lastEventID })
+		if i >= 0 {
+			sub.Replay = slices.Clone(buffer[i+1:])
+		} else {
+			sub.Replay = slices.Clone(buffer)
+			sub.Missed = true
+		}
+	}
+
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = map[chan StreamEvent]struct{}{}
+	}
+	h.subscribers[userID][sub.ch] = struct{}{}
+	return sub
+}
+
+func (h *StreamHub) unsubscribe(userID uint, ch chan StreamEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[userID][ch]; !ok {
+		return
+	}
+	delete(h.subscribers[userID], ch)
+	if len(h.subscribers[userID]) == 0 {
+		delete(h.subscribers, userID)
+	}
+	close(ch)
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"vertice-backend/internal/domain"
+)
+
+type SupplierService struct {
+	supplierRepo      domain.SupplierRepository
+	productRepo       domain.ProductRepository
+	purchaseOrderRepo domain.PurchaseOrderRepository
+}
+
+func NewSupplierService(supplierRepo domain.SupplierRepository, productRepo domain.ProductRepository, purchaseOrderRepo domain.PurchaseOrderRepository) *SupplierService {
+	return &SupplierService{
+		supplierRepo:      supplierRepo,
+		productRepo:       productRepo,
+		purchaseOrderRepo: purchaseOrderRepo,
+	}
+}
+
+type SupplierRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+	Notes string `json:"notes"`
+}
+
+type UpdateSupplierRequest struct {
+	Name  *string `json:"name"`
+	Email *string `json:"email"`
+	Phone *string `json:"phone"`
+	Notes *string `json:"notes"`
+}
+
+type SupplierProductRequest struct {
+	SupplierSKU  string  `json:"supplier_sku"`
+	UnitCost     float64 `json:"unit_cost"`
+	LeadTimeDays int     `json:"lead_time_days"`
+}
+
+func (s *SupplierService) CreateSupplier(ctx context.Context, userID uint, req SupplierRequest) (*domain.Supplier, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if existing, err := s.supplierRepo.FindByNameAndUserID(ctx, name, userID); err == nil && existing != nil {
+		return nil, errors.New("supplier name already exists for this user")
+	}
+
+	supplier := &domain.Supplier{
+		UserID: userID,
+		Name:   name,
+		Email:  req.Email,
+		Phone:  req.Phone,
+		Notes:  req.Notes,
+	}
+	if err := s.supplierRepo.Create(ctx, supplier); err != nil {
+		return nil, err
+	}
+	return supplier, nil
+}
+
+func (s *SupplierService) GetSupplier(ctx context.Context, id, userID uint) (*domain.Supplier, error) {
+	supplier, err := s.supplierRepo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("supplier not found")
+	}
+	return supplier, nil
+}
+
+func (s *SupplierService) GetSuppliersByUser(ctx context.Context, userID uint) ([]*domain.Supplier, error) {
+	return s.supplierRepo.FindByUserID(ctx, userID)
+}
+
+func (s *SupplierService) UpdateSupplier(ctx context.Context, id, userID uint, req UpdateSupplierRequest) (*domain.Supplier, error) {
+	supplier, err := s.supplierRepo.FindByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("supplier not found")
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, errors.New("name cannot be empty")
+		}
+		if name != supplier.Name {
+			if existing, err := s.supplierRepo.FindByNameAndUserID(ctx, name, userID); err == nil && existing != nil {
+				return nil, errors.New("supplier name already exists for this user")
+			}
+		}
+		supplier.Name = name
+	}
+	if req.Email != nil {
+		supplier.Email = *req.Email
+	}
+	if req.Phone != nil {
+		supplier.Phone = *req.Phone
+	}
+	if req.Notes != nil {
+		supplier.Notes = *req.Notes
+	}
+
+	if err := s.supplierRepo.Update(ctx, supplier, userID); err != nil {
+		return nil, err
+	}
+	return supplier, nil
+}
+
+// DeleteSupplier removes a supplier and its product links. Suppliers with
+// purchase orders are kept for the purchasing history.
+func (s *SupplierService) DeleteSupplier(ctx context.Context, id, userID uint) error {
+	if _, err := s.supplierRepo.FindByIDAndUserID(ctx, id, userID); err != nil {
+		return errors.New("supplier not found")
+	}
+	count, err := s.purchaseOrderRepo.CountBySupplierID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("cannot delete a supplier with purchase orders")
+	}
+	return s.supplierRepo.Delete(ctx, id, userID)
+}
+
+func (s *SupplierService) GetSupplierProducts(ctx context.Context, supplierID, userID uint) ([]*domain.SupplierProduct, error) {
+	if _, err := s.supplierRepo.FindByIDAndUserID(ctx, supplierID, userID); err != nil {
+		return nil, errors.New("supplier not found")
+	}
+	return s.supplierRepo.FindProducts(ctx, supplierID, userID)
+}
+
+// SetSupplierProduct creates or replaces the link between a supplier and one
+// of the user's products.
+func (s *SupplierService) SetSupplierProduct(ctx context.Context, supplierID, productID, userID uint, req SupplierProductRequest) (*domain.SupplierProduct, error) {
+	if _, err := s.supplierRepo.FindByIDAndUserID(ctx, supplierID, userID); err != nil {
+		return nil, errors.New("supplier not found")
+	}
+	if _, err := s.productRepo.FindByIDAndUserID(ctx, productID, userID); err != nil {
+		return nil, errors.New("product not found")
+	}
+	if req.UnitCost < 0 {
+		return nil, errors.New("unit cost cannot be negative")
+	}
+	if req.LeadTimeDays < 0 {
+		return nil, errors.New("lead time cannot be negative")
+	}
+
+	link, err := s.supplierRepo.FindProduct(ctx, supplierID, productID, userID)
+	if err != nil {
+		link = &domain.SupplierProduct{UserID: userID, SupplierID: supplierID, ProductID: productID}
+	}
+	link.SupplierSKU = req.SupplierSKU
+	link.UnitCost = req.UnitCost
+	link.LeadTimeDays = req.LeadTimeDays
+
+	if err := s.supplierRepo.SaveProduct(ctx, link); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+func (s *SupplierService) DeleteSupplierProduct(ctx context.Context, supplierID, productID, userID uint) error {
+	if _, err := s.supplierRepo.FindProduct(ctx, supplierID, productID, userID); err != nil {
+		return errors.New("supplier product not found")
+	}
+	return s.supplierRepo.DeleteProduct(ctx, supplierID, productID, userID)
+}
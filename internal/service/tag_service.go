@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"vertice-backend/internal/domain"
+)
+
+// maxTagLength bounds free-form tag names.
+const maxTagLength = 50
+
+type TagService struct {
+	tagRepo     domain.TagRepository
+	productRepo domain.ProductRepository
+}
+
+func NewTagService(tagRepo domain.TagRepository, productRepo domain.ProductRepository) *TagService {
+	return &TagService{tagRepo: tagRepo, productRepo: productRepo}
+}
+
+// GetTags returns the tags of the user with the number of products using them.
+func (s *TagService) GetTags(ctx context.Context, userID uint) ([]*domain.TagUsage, error) {
+	return s.tagRepo.FindByUserID(ctx, userID)
+}
+
+// SetProductTags replaces the tags of a product, creating tags that do not
+// exist yet. Names are trimmed and lower-cased.
+func (s *TagService) SetProductTags(ctx context.Context, productID, userID uint, names []string) (*domain.Product, error) {
+	product, err := s.productRepo.FindByIDAndUserID(ctx, productID, userID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+
+	seen := make(map[string]bool, len(names))
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		name = normalizeTag(name)
+		if name == "" {
+			return nil, errors.New("tags cannot be empty")
+		}
+		if len(name) > maxTagLength {
+			return nil, errors.New("tags cannot be longer than 50 characters")
+		}
+		if !seen[name] {
+			seen[name] = true
+			normalized = append(normalized, name)
+		}
+	}
+
+	tags := []*domain.Tag{}
+	if len(normalized) > 0 {
+		tags, err = s.tagRepo.FindOrCreate(ctx, userID, normalized)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.tagRepo.ReplaceProductTags(ctx, product, tags); err != nil {
+		return nil, err
+	}
+	product.Tags = make([]domain.Tag, len(tags))
+	for i, tag := range tags {
+		product.Tags[i] = *tag
+	}
+	return product, nil
+}
+
+// DeleteTag removes a tag from all products.
+func (s *TagService) DeleteTag(ctx context.Context, id, userID uint) error {
+	if _, err := s.tagRepo.FindByIDAndUserID(ctx, id, userID); err != nil {
+		return errors.New("tag not found")
+	}
+	return s.tagRepo.Delete(ctx, id, userID)
+}
+
+func normalizeTag(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
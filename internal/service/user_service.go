@@ -2,21 +2,132 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 	"vertice-backend/internal/domain"
+	"vertice-backend/pkg/password"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	verifyEmailTokenTTL   = 48 * time.Hour
+	resetPasswordTokenTTL = time.Hour
+	maxUserNameLength     = 100
+	maxEmailLength        = 254
+)
+
+// ErrEmailNotVerified is returned by Authenticate for users who have not
+// verified their address when verified addresses are required.
+var ErrEmailNotVerified = errors.New("email not verified")
+
+// ErrEmailTaken is returned by Register when the address already has an
+// account.
+var ErrEmailTaken = errors.New("email is already registered")
+
+var errInvalidUserToken = errors.New("invalid or expired token")
+
 type UserService struct {
-	repo domain.UserRepository
+	repo                 domain.UserRepository
+	tx                   domain.Transactor
+	events               EventRecorder
+	tokens               domain.UserTokenRepository
+	emails               *EmailService
+	requireVerifiedEmail bool
+	passwords            password.Policy
+	now                  func() time.Time
+	background           sync.WaitGroup
+}
+
+type UserServiceOption func(*UserService)
+
+func WithUserTransactor(tx domain.Transactor) UserServiceOption {
+	return func(s *UserService) {
+		s.tx = tx
+	}
+}
+
+// WithUserEvents records a user.registered event for every new account.
+func WithUserEvents(events EventRecorder) UserServiceOption {
+	return func(s *UserService) {
+		s.events = events
+	}
+}
+
+// WithUserTokens enables email verification and password resets, with
+// single use tokens kept in tokens and emailed through emails.
+func WithUserTokens(tokens domain.UserTokenRepository, emails *EmailService) UserServiceOption {
+	return func(s *UserService) {
+		s.tokens = tokens
+		s.emails = emails
+	}
+}
+
+// WithRequireVerifiedEmail refuses to log in users who have not verified
+// their email address.
+func WithRequireVerifiedEmail() UserServiceOption {
+	return func(s *UserService) {
+		s.requireVerifiedEmail = true
+	}
+}
+
+// WithPasswordPolicy sets the policy new passwords must satisfy, on
+// registration and on reset. It defaults to password.DefaultPolicy.
+func WithPasswordPolicy(policy password.Policy) UserServiceOption {
+	return func(s *UserService) {
+		s.passwords = policy
+	}
 }
 
-func NewUserService(repo domain.UserRepository) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo domain.UserRepository, opts ...UserServiceOption) *UserService {
+	s := &UserService{
+		repo:      repo,
+		tx:        noTransaction{},
+		events:    discardEvents{},
+		passwords: password.DefaultPolicy(),
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
+// Register creates an account and, when tokens are enabled, emails a link to
+// verify its address. The email is stored lower cased. Invalid fields are
+// reported together in a *FieldErrors.
 func (s *UserService) Register(ctx context.Context, name, email, password string) (*domain.User, error) {
+	name, email = strings.TrimSpace(name), normalizeEmail(email)
+	var fieldErrs FieldErrors
+	switch {
+	case name == "":
+		fieldErrs.Add("name", "name is required")
+	case utf8.RuneCountInString(name) > maxUserNameLength:
+		fieldErrs.Add("name", fmt.Sprintf("name must be at most %d characters", maxUserNameLength))
+	}
+	if err := validateEmail(email); err != nil {
+		fieldErrs.Add("email", err.Error())
+	}
+	if err := s.passwords.Check(password); err != nil {
+		fieldErrs.Add("password", err.Error())
+	}
+	if err := fieldErrs.Err(); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.FindByEmail(ctx, email); err == nil {
+		return nil, ErrEmailTaken
+	}
+
 	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, err
@@ -26,23 +137,235 @@ func (s *UserService) Register(ctx context.Context, name, email, password string
 		Email:    email,
 		Password: string(hashed),
 	}
-	if err := s.repo.Create(ctx, user); err != nil {
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, user); err != nil {
+			return err
+		}
+		return s.events.Record(ctx, user.ID, domain.UserRegistered{UserID: user.ID, Name: user.Name, Email: user.Email})
+	})
+	if err != nil {
+		// Another registration may have taken the address in the meantime.
+		if _, findErr := s.repo.FindByEmail(ctx, email); findErr == nil {
+			return nil, ErrEmailTaken
+		}
 		return nil, err
 	}
+	if s.tokens != nil {
+		// The account exists either way; a lost email can be sent again.
+		if err := s.sendToken(ctx, user, domain.TokenVerifyEmail); err != nil {
+			log.Printf("users: emailing verification to user %d failed: %v", user.ID, err)
+		}
+	}
 	return user, nil
 }
 
 func (s *UserService) Authenticate(ctx context.Context, email, password string) (*domain.User, error) {
-	user, err := s.repo.FindByEmail(ctx, email)
+	user, err := s.repo.FindByEmail(ctx, normalizeEmail(email))
 	if err != nil {
 		return nil, errors.New("invalid credentials")
 	}
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
 		return nil, errors.New("invalid credentials")
 	}
+	if s.requireVerifiedEmail && user.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
+	}
 	return user, nil
 }
 
 func (s *UserService) GetProfile(ctx context.Context, id uint) (*domain.User, error) {
 	return s.repo.FindByID(ctx, id)
 }
+
+// ValidateSession returns an error when the tokens issued to a user for
+// sessionVersion were revoked, by a password reset, or the user is gone.
+func (s *UserService) ValidateSession(ctx context.Context, userID uint, sessionVersion int) error {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if user.SessionVersion != sessionVersion {
+		return errors.New("session has been revoked")
+	}
+	return nil
+}
+
+// ResendVerification emails a new verification link to the address, unless
+// it belongs to no account or is verified already. Either way it succeeds
+// at once, so that neither its result nor its timing reveals which
+// addresses have accounts.
+func (s *UserService) ResendVerification(ctx context.Context, email string) error {
+	if s.tokens == nil {
+		return errors.New("email verification is not available")
+	}
+	s.inBackground(ctx, func(ctx context.Context) {
+		user, err := s.repo.FindByEmail(ctx, normalizeEmail(email))
+		if err != nil || user.EmailVerifiedAt != nil {
+			return
+		}
+		if err := s.sendToken(ctx, user, domain.TokenVerifyEmail); err != nil {
+			log.Printf("users: emailing verification to user %d failed: %v", user.ID, err)
+		}
+	})
+	return nil
+}
+
+// VerifyEmail marks the address of the token's user as verified.
+func (s *UserService) VerifyEmail(ctx context.Context, token string) (*domain.User, error) {
+	return s.consumeToken(ctx, token, domain.TokenVerifyEmail, func(user *domain.User, now time.Time) error {
+		if user.EmailVerifiedAt == nil {
+			user.EmailVerifiedAt = &now
+		}
+		return nil
+	})
+}
+
+// ForgotPassword emails a password reset link to the address when it belongs
+// to an account. Either way it succeeds at once, so that neither its result
+// nor its timing reveals which addresses have accounts.
+func (s *UserService) ForgotPassword(ctx context.Context, email string) error {
+	if s.tokens == nil {
+		return errors.New("password reset is not available")
+	}
+	s.inBackground(ctx, func(ctx context.Context) {
+		user, err := s.repo.FindByEmail(ctx, normalizeEmail(email))
+		if err != nil {
+			return
+		}
+		if err := s.sendToken(ctx, user, domain.TokenResetPassword); err != nil {
+			log.Printf("users: emailing password reset to user %d failed: %v", user.ID, err)
+		}
+	})
+	return nil
+}
+
+// inBackground runs fn after the request has been answered.
+func (s *UserService) inBackground(ctx context.Context, fn func(ctx context.Context)) {
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		fn(context.WithoutCancel(ctx))
+	}()
+}
+
+// Wait blocks until the emails being sent in the background have been sent.
+func (s *UserService) Wait() {
+	s.background.Wait()
+}
+
+// ResetPassword sets a new password for the token's user and signs them out
+// of every session. Having received the token, their address counts as
+// verified. A password the policy refuses is reported in a *FieldErrors and
+// leaves the token unused.
+func (s *UserService) ResetPassword(ctx context.Context, token, password string) error {
+	if err := s.passwords.Check(password); err != nil {
+		fieldErrs := &FieldErrors{}
+		fieldErrs.Add("password", err.Error())
+		return fieldErrs
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	_, err = s.consumeToken(ctx, token, domain.TokenResetPassword, func(user *domain.User, now time.Time) error {
+		user.Password = string(hashed)
+		user.SessionVersion++
+		if user.EmailVerifiedAt == nil {
+			user.EmailVerifiedAt = &now
+		}
+		return nil
+	})
+	return err
+}
+
+// sendToken emails the user a new token for purpose. Earlier tokens for the
+// same purpose stop working.
+func (s *UserService) sendToken(ctx context.Context, user *domain.User, purpose domain.UserTokenPurpose) error {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	raw := base64.RawURLEncoding.EncodeToString(buf)
+
+	kind, ttl := domain.EmailVerifyEmail, verifyEmailTokenTTL
+	if purpose == domain.TokenResetPassword {
+		kind, ttl = domain.EmailPasswordReset, resetPasswordTokenTTL
+	}
+	now := s.now()
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.tokens.InvalidateByUserID(ctx, user.ID, purpose, now); err != nil {
+			return err
+		}
+		return s.tokens.Create(ctx, &domain.UserToken{
+			UserID:    user.ID,
+			Purpose:   purpose,
+			TokenHash: hashUserToken(raw),
+			ExpiresAt: now.Add(ttl),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return s.emails.SendTokenEmail(ctx, user, kind, raw, ttl)
+}
+
+// consumeToken uses up a valid token for purpose and applies change to its
+// user.
+func (s *UserService) consumeToken(ctx context.Context, raw string, purpose domain.UserTokenPurpose, change func(user *domain.User, now time.Time) error) (*domain.User, error) {
+	if s.tokens == nil {
+		return nil, errInvalidUserToken
+	}
+	now := s.now()
+	var user *domain.User
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		token, err := s.tokens.LockByHash(ctx, hashUserToken(raw))
+		if err != nil || token.Purpose != purpose || token.UsedAt != nil || !now.Before(token.ExpiresAt) {
+			return errInvalidUserToken
+		}
+		if user, err = s.repo.FindByID(ctx, token.UserID); err != nil {
+			return errInvalidUserToken
+		}
+		if err := change(user, now); err != nil {
+			return err
+		}
+		if err := s.repo.Update(ctx, user); err != nil {
+			return err
+		}
+		token.UsedAt = &now
+		if err := s.tokens.Update(ctx, token); err != nil {
+			return err
+		}
+		return s.tokens.InvalidateByUserID(ctx, user.ID, purpose, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func hashUserToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeEmail trims and lower cases an address, so that each mailbox has
+// one account whatever the case it is typed in.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// validateEmail accepts a bare address, without a display name, whose domain
+// has a dot in it.
+func validateEmail(email string) error {
+	if email == "" {
+		return errors.New("email is required")
+	}
+	if len(email) > maxEmailLength {
+		return fmt.Errorf("email must be at most %d characters", maxEmailLength)
+	}
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || !strings.Contains(email[strings.LastIndex(email, "@")+1:], ".") {
+		return errors.New("email is not a valid address")
+	}
+	return nil
+}
@@ -0,0 +1,373 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"vertice-backend/internal/domain"
+)
+
+type VariantService struct {
+	productRepo domain.ProductRepository
+	variantRepo domain.VariantRepository
+	tx          domain.Transactor
+	events      EventRecorder
+}
+
+type VariantServiceOption func(*VariantService)
+
+// WithVariantTransactor makes variant stock changes and the product total
+// commit atomically.
+func WithVariantTransactor(tx domain.Transactor) VariantServiceOption {
+	return func(s *VariantService) {
+		s.tx = tx
+	}
+}
+
+// WithVariantEvents sets where variant stock changes are recorded.
+func WithVariantEvents(events EventRecorder) VariantServiceOption {
+	return func(s *VariantService) {
+		s.events = events
+	}
+}
+
+func NewVariantService(productRepo domain.ProductRepository, variantRepo domain.VariantRepository, opts ...VariantServiceOption) *VariantService {
+	s := &VariantService{
+		productRepo: productRepo,
+		variantRepo: variantRepo,
+		tx:          noTransaction{},
+		events:      discardEvents{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+type OptionTypeRequest struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+type CreateVariantRequest struct {
+	Code           string   `json:"code"`
+	Price          *float64 `json:"price"`
+	Stock          int      `json:"stock"`
+	OptionValueIDs []uint   `json:"option_value_ids"`
+}
+
+// UpdateVariantRequest changes the code or price of a variant. ClearPrice
+// removes the price override so the product price applies again.
+type UpdateVariantRequest struct {
+	Code       *string  `json:"code"`
+	Price      *float64 `json:"price"`
+	ClearPrice bool     `json:"clear_price"`
+}
+
+func (s *VariantService) CreateOptionType(ctx context.Context, userID uint, req OptionTypeRequest) (*domain.OptionType, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if existing, err := s.variantRepo.FindOptionTypeByNameAndUserID(ctx, name, userID); err == nil && existing != nil {
+		return nil, errors.New("option type already exists")
+	}
+
+	optionType := &domain.OptionType{UserID: userID, Name: name}
+	for i, raw := range req.Values {
+		value := strings.TrimSpace(raw)
+		if value == "" {
+			return nil, errors.New("option values cannot be empty")
+		}
+		if slices.ContainsFunc(optionType.Values, func(v domain.OptionValue) bool { return v.Value == value }) {
+			return nil, errors.New("duplicate option value: " + value)
+		}
+		optionType.Values = append(optionType.Values, domain.OptionValue{Value: value, Position: i})
+	}
+
+	if err := s.variantRepo.CreateOptionType(ctx, optionType); err != nil {
+		return nil, err
+	}
+	return optionType, nil
+}
+
+func (s *VariantService) GetOptionTypes(ctx context.Context, userID uint) ([]*domain.OptionType, error) {
+	return s.variantRepo.FindOptionTypesByUserID(ctx, userID)
+}
+
+func (s *VariantService) AddOptionValue(ctx context.Context, optionTypeID, userID uint, value string) (*domain.OptionType, error) {
+	optionType, err := s.variantRepo.FindOptionTypeByIDAndUserID(ctx, optionTypeID, userID)
+	if err != nil {
+		return nil, errors.New("option type not found")
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, errors.New("option values cannot be empty")
+	}
+	if slices.ContainsFunc(optionType.Values, func(v domain.OptionValue) bool { return v.Value == value }) {
+		return nil, errors.New("duplicate option value: " + value)
+	}
+
+	optionValue := &domain.OptionValue{OptionTypeID: optionType.ID, Value: value, Position: len(optionType.Values)}
+	if err := s.variantRepo.CreateOptionValue(ctx, optionValue); err != nil {
+		return nil, err
+	}
+	optionType.Values = append(optionType.Values, *optionValue)
+	return optionType, nil
+}
+
+// DeleteOptionType removes an option type that no variant uses.
+func (s *VariantService) DeleteOptionType(ctx context.Context, optionTypeID, userID uint) error {
+	if _, err := s.variantRepo.FindOptionTypeByIDAndUserID(ctx, optionTypeID, userID); err != nil {
+		return errors.New("option type not found")
+	}
+	count, err := s.variantRepo.CountVariantsByOptionType(ctx, optionTypeID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("option type is used by variants")
+	}
+	return s.variantRepo.DeleteOptionType(ctx, optionTypeID, userID)
+}
+
+// DeleteOptionValue removes an option value that no variant uses.
+func (s *VariantService) DeleteOptionValue(ctx context.Context, optionTypeID, valueID, userID uint) error {
+	optionType, err := s.variantRepo.FindOptionTypeByIDAndUserID(ctx, optionTypeID, userID)
+	if err != nil {
+		return errors.New("option type not found")
+	}
+	if !slices.ContainsFunc(optionType.Values, func(v domain.OptionValue) bool { return v.ID == valueID }) {
+		return errors.New("option value not found")
+	}
+	count, err := s.variantRepo.CountVariantsByOptionValue(ctx, valueID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("option value is used by variants")
+	}
+	return s.variantRepo.DeleteOptionValue(ctx, valueID)
+}
+
+// CreateVariant adds a variant to a product and returns the product with its
+// variants. All variants of a product vary in the same option types and
+// differ in at least one value. The product stock becomes the sum of its
+// variant stocks, so stock held by the product before its first variant is
+// replaced by that variant's stock.
+func (s *VariantService) CreateVariant(ctx context.Context, productID, userID uint, req CreateVariantRequest) (*domain.Product, error) {
+	product, err := s.productRepo.FindByIDAndUserID(ctx, productID, userID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+	if product.IsBundle() {
+		return nil, errors.New("bundles cannot have variants")
+	}
+	code := strings.TrimSpace(req.Code)
+	if code == "" {
+		return nil, errors.New("code is required")
+	}
+	if err := s.checkCodeAvailable(ctx, code, userID); err != nil {
+		return nil, err
+	}
+	if req.Price != nil && *req.Price < 0 {
+		return nil, errors.New("price cannot be negative")
+	}
+	if req.Stock < 0 {
+		return nil, errors.New("stock cannot be negative")
+	}
+	if req.Stock != 0 {
+		if err := directStockError(product); err != nil {
+			return nil, err
+		}
+	}
+
+	options, err := s.resolveOptions(ctx, req.OptionValueIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVariantOptions(product, options); err != nil {
+		return nil, err
+	}
+
+	variant := domain.ProductVariant{
+		UserID:    userID,
+		ProductID: product.ID,
+		Code:      code,
+		Price:     req.Price,
+		Stock:     req.Stock,
+		Options:   options,
+	}
+
+	previous := product.Stock
+	if !product.HasVariants() {
+		product.Stock = 0
+	}
+	product.Stock += variant.Stock
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.variantRepo.CreateVariant(ctx, &variant); err != nil {
+			return err
+		}
+		if err := s.productRepo.Update(ctx, product, userID); err != nil {
+			return err
+		}
+		return stockChange{product: product, variant: &variant, previous: previous, reason: domain.StockReasonManual}.record(ctx, s.events)
+	})
+	if err != nil {
+		return nil, err
+	}
+	product.Variants = append(product.Variants, variant)
+	return product, nil
+}
+
+func (s *VariantService) UpdateVariant(ctx context.Context, productID, variantID, userID uint, req UpdateVariantRequest) (*domain.Product, error) {
+	product, err := s.productRepo.FindByIDAndUserID(ctx, productID, userID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+	variant, ok := product.Variant(variantID)
+	if !ok {
+		return nil, errors.New("variant not found")
+	}
+	if req.Code != nil {
+		code := strings.TrimSpace(*req.Code)
+		if code == "" {
+			return nil, errors.New("code cannot be empty")
+		}
+		if code != variant.Code {
+			if err := s.checkCodeAvailable(ctx, code, userID); err != nil {
+				return nil, err
+			}
+		}
+		variant.Code = code
+	}
+	if req.ClearPrice {
+		variant.Price = nil
+	} else if req.Price != nil {
+		if *req.Price < 0 {
+			return nil, errors.New("price cannot be negative")
+		}
+		variant.Price = req.Price
+	}
+
+	if err := s.variantRepo.UpdateVariant(ctx, variant); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// UpdateVariantStock adds delta to the stock of a variant and its product.
+func (s *VariantService) UpdateVariantStock(ctx context.Context, productID, variantID, userID uint, delta int) (*domain.Product, error) {
+	product, err := s.productRepo.FindByIDAndUserID(ctx, productID, userID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+	variant, ok := product.Variant(variantID)
+	if !ok {
+		return nil, errors.New("variant not found")
+	}
+	if err := directStockError(product); err != nil {
+		return nil, err
+	}
+	if variant.Stock+delta < 0 {
+		return nil, errors.New("stock cannot be negative")
+	}
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		change, err := applyStockChange(ctx, s.productRepo, s.variantRepo, product, variant, delta, domain.StockReasonManual)
+		if err != nil {
+			return err
+		}
+		return change.record(ctx, s.events)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// DeleteVariant removes a variant and its stock from the product total.
+func (s *VariantService) DeleteVariant(ctx context.Context, productID, variantID, userID uint) error {
+	product, err := s.productRepo.FindByIDAndUserID(ctx, productID, userID)
+	if err != nil {
+		return errors.New("product not found")
+	}
+	variant, ok := product.Variant(variantID)
+	if !ok {
+		return errors.New("variant not found")
+	}
+
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.variantRepo.DeleteVariant(ctx, variant.ID, userID); err != nil {
+			return err
+		}
+		previous := product.Stock
+		product.Stock -= variant.Stock
+		if err := s.productRepo.Update(ctx, product, userID); err != nil {
+			return err
+		}
+		return recordStockAdjusted(ctx, s.events, product, previous, domain.StockReasonManual)
+	})
+}
+
+// checkCodeAvailable rejects codes already used by a product or a variant of
+// the user, so a code always identifies one sellable item.
+func (s *VariantService) checkCodeAvailable(ctx context.Context, code string, userID uint) error {
+	if existing, err := s.productRepo.FindByCodeAndUserID(ctx, code, userID); err == nil && existing != nil {
+		return errors.New("code is already used by a product")
+	}
+	if existing, err := s.variantRepo.FindVariantByCodeAndUserID(ctx, code, userID); err == nil && existing != nil {
+		return errors.New("code is already used by a variant")
+	}
+	return nil
+}
+
+func (s *VariantService) resolveOptions(ctx context.Context, valueIDs []uint, userID uint) ([]domain.VariantOption, error) {
+	if len(valueIDs) == 0 {
+		return nil, errors.New("a variant needs at least one option value")
+	}
+	values, err := s.variantRepo.FindOptionValues(ctx, valueIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != len(valueIDs) {
+		return nil, errors.New("option value not found")
+	}
+
+	options := make([]domain.VariantOption, 0, len(values))
+	for _, value := range values {
+		if slices.ContainsFunc(options, func(o domain.VariantOption) bool { return o.OptionTypeID == value.OptionTypeID }) {
+			return nil, errors.New("a variant can have only one value per option type")
+		}
+		option := domain.VariantOption{OptionTypeID: value.OptionTypeID, OptionValueID: value.ID, OptionValue: *value}
+		if value.OptionType != nil {
+			option.OptionType = *value.OptionType
+		}
+		options = append(options, option)
+	}
+	slices.SortFunc(options, func(a, b domain.VariantOption) int { return int(a.OptionTypeID) - int(b.OptionTypeID) })
+	return options, nil
+}
+
+func checkVariantOptions(product *domain.Product, options []domain.VariantOption) error {
+	for _, existing := range product.Variants {
+		if len(existing.Options) != len(options) {
+			return errors.New("variants of a product must use the same option types")
+		}
+		same := true
+		for i, option := range options {
+			if existing.Options[i].OptionTypeID != option.OptionTypeID {
+				return errors.New("variants of a product must use the same option types")
+			}
+			if existing.Options[i].OptionValueID != option.OptionValueID {
+				same = false
+			}
+		}
+		if same {
+			return errors.New("a variant with these options already exists")
+		}
+	}
+	return nil
+}
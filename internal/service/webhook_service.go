@@ -0,0 +1,543 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+	"vertice-backend/internal/domain"
+)
+
+const (
+	WebhookEventHeader     = "X-Vertice-Event"
+	WebhookDeliveryHeader  = "X-Vertice-Delivery"
+	WebhookTimestampHeader = "X-Vertice-Timestamp"
+	WebhookSignatureHeader = "X-Vertice-Signature"
+)
+
+// WebhookRetryPolicy controls how failed deliveries are retried. The delay
+// doubles after each failed attempt up to MaxDelay, and a delivery is moved to
+// the dead letter state once MaxAttempts have failed.
+type WebhookRetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// webhookClaimLease is how long a claimed delivery is held back from other
+// instances while it is sent. It is well above the client timeout, so a
+// delivery is only sent twice when its instance dies mid-send.
+const webhookClaimLease = 2 * time.Minute
+
+var DefaultWebhookRetryPolicy = WebhookRetryPolicy{
+	MaxAttempts: 8,
+	BaseDelay:   30 * time.Second,
+	MaxDelay:    6 * time.Hour,
+}
+
+// WebhookResolver looks up the addresses of a webhook host. *net.Resolver
+// implements it.
+type WebhookResolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+type WebhookService struct {
+	repo     domain.WebhookRepository
+	tx       domain.Transactor
+	client   *http.Client
+	resolver WebhookResolver
+	policy   WebhookRetryPolicy
+	now      func() time.Time
+}
+
+type WebhookServiceOption func(*WebhookService)
+
+func WithWebhookTransactor(tx domain.Transactor) WebhookServiceOption {
+	return func(s *WebhookService) {
+		s.tx = tx
+	}
+}
+
+// WithWebhookHTTPClient replaces the client deliveries are sent with. The
+// default client refuses to connect to internal addresses; a replacement
+// should do the same.
+func WithWebhookHTTPClient(client *http.Client) WebhookServiceOption {
+	return func(s *WebhookService) {
+		s.client = client
+	}
+}
+
+// WithWebhookResolver sets how webhook hosts are resolved when a URL is
+// validated.
+func WithWebhookResolver(resolver WebhookResolver) WebhookServiceOption {
+	return func(s *WebhookService) {
+		s.resolver = resolver
+	}
+}
+
+func WithWebhookRetryPolicy(policy WebhookRetryPolicy) WebhookServiceOption {
+	return func(s *WebhookService) {
+		s.policy = policy
+	}
+}
+
+func NewWebhookService(repo domain.WebhookRepository, opts ...WebhookServiceOption) *WebhookService {
+	s := &WebhookService{
+		repo:     repo,
+		tx:       noTransaction{},
+		client:   newWebhookHTTPClient(),
+		resolver: net.DefaultResolver,
+		policy:   DefaultWebhookRetryPolicy,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" example:"https://erp.example.com/hooks/vertice"`
+	Secret string   `json:"secret" example:"s3cr3t"`
+	Events []string `json:"events" example:"order.created,order.status_changed"`
+}
+
+type UpdateWebhookRequest struct {
+	URL    *string  `json:"url"`
+	Secret *string  `json:"secret"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+// WebhookPayload is the JSON body sent to subscribers. ID identifies the
+// event and can be used by receivers to ignore duplicates.
+type WebhookPayload struct {
+	ID        uint            `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// CreateSubscription registers a webhook endpoint. When no secret is given a
+// random one is generated; it is only returned by this call.
+func (s *WebhookService) CreateSubscription(ctx context.Context, userID uint, req CreateWebhookRequest) (*domain.WebhookSubscription, error) {
+	if err := s.validateURL(ctx, req.URL); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookEvents(req.Events); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		generated, err := generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+
+	subscription := &domain.WebhookSubscription{
+		UserID: userID,
+		URL:    req.URL,
+		Secret: secret,
+		Active: true,
+	}
+	subscription.SetEventTypes(req.Events)
+
+	if err := s.repo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (s *WebhookService) GetSubscription(ctx context.Context, id, userID uint) (*domain.WebhookSubscription, error) {
+	subscription, err := s.repo.FindSubscriptionByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("webhook not found")
+	}
+	return subscription, nil
+}
+
+func (s *WebhookService) GetSubscriptionsByUser(ctx context.Context, userID uint) ([]*domain.WebhookSubscription, error) {
+	return s.repo.FindSubscriptionsByUserID(ctx, userID)
+}
+
+func (s *WebhookService) UpdateSubscription(ctx context.Context, id, userID uint, req UpdateWebhookRequest) (*domain.WebhookSubscription, error) {
+	subscription, err := s.repo.FindSubscriptionByIDAndUserID(ctx, id, userID)
+	if err != nil {
+		return nil, errors.New("webhook not found")
+	}
+
+	if req.URL != nil {
+		if err := s.validateURL(ctx, *req.URL); err != nil {
+			return nil, err
+		}
+		subscription.URL = *req.URL
+	}
+	if req.Secret != nil {
+		if *req.Secret == "" {
+			return nil, errors.New("secret cannot be empty")
+		}
+		subscription.Secret = *req.Secret
+	}
+	if req.Events != nil {
+		if err := validateWebhookEvents(req.Events); err != nil {
+			return nil, err
+		}
+		subscription.SetEventTypes(req.Events)
+	}
+	if req.Active != nil {
+		subscription.Active = *req.Active
+	}
+
+	if err := s.repo.UpdateSubscription(ctx, subscription, userID); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id, userID uint) error {
+	if _, err := s.repo.FindSubscriptionByIDAndUserID(ctx, id, userID); err != nil {
+		return errors.New("webhook not found")
+	}
+	return s.repo.DeleteSubscription(ctx, id, userID)
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, subscriptionID, userID uint) ([]*domain.WebhookDelivery, error) {
+	if _, err := s.repo.FindSubscriptionByIDAndUserID(ctx, subscriptionID, userID); err != nil {
+		return nil, errors.New("webhook not found")
+	}
+	return s.repo.FindDeliveriesBySubscriptionID(ctx, subscriptionID, userID)
+}
+
+func (s *WebhookService) GetDelivery(ctx context.Context, subscriptionID, deliveryID, userID uint) (*domain.WebhookDelivery, error) {
+	delivery, err := s.repo.FindDeliveryByIDAndUserID(ctx, deliveryID, userID)
+	if err != nil || delivery.SubscriptionID != subscriptionID {
+		return nil, errors.New("delivery not found")
+	}
+	return delivery, nil
+}
+
+// Redeliver queues a delivery again regardless of its current state. The
+// retry policy starts over, while attempts keep their numbers.
+func (s *WebhookService) Redeliver(ctx context.Context, subscriptionID, deliveryID, userID uint) (*domain.WebhookDelivery, error) {
+	delivery, err := s.GetDelivery(ctx, subscriptionID, deliveryID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	delivery.Status = domain.WebhookDeliveryPending
+	delivery.RetryFrom = delivery.Attempts
+	delivery.NextAttemptAt = &now
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Dispatch queues one delivery for each active subscription of the user that
+// listens to the event type. Subscriptions the event was already queued for
+// are skipped.
+func (s *WebhookService) Dispatch(ctx context.Context, userID, eventID uint, eventType string, data any) error {
+	subscriptions, err := s.repo.FindActiveSubscriptions(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	now := s.now()
+	for _, subscription := range subscriptions {
+		if !subscription.Subscribes(eventType) {
+			continue
+		}
+		if body == nil {
+			encoded, err := json.Marshal(data)
+			if err != nil {
+				return err
+			}
+			body, err = json.Marshal(WebhookPayload{ID: eventID, Type: eventType, CreatedAt: now, Data: encoded})
+			if err != nil {
+				return err
+			}
+		}
+		delivery := &domain.WebhookDelivery{
+			EventID:        eventID,
+			SubscriptionID: subscription.ID,
+			UserID:         userID,
+			EventType:      eventType,
+			Payload:        string(body),
+			Status:         domain.WebhookDeliveryPending,
+			NextAttemptAt:  &now,
+		}
+		if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handle implements EventSink by queueing deliveries for relayed outbox
+// messages. The relay redelivers a message to every sink when any of them
+// fails; deliveries are unique per event and subscription, so a redelivered
+// message queues nothing twice.
+func (s *WebhookService) Handle(ctx context.Context, message *domain.OutboxMessage) error {
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return s.Dispatch(ctx, message.UserID, message.ID, message.EventType, json.RawMessage(message.Payload))
+	})
+}
+
+// ProcessDue sends every delivery whose next attempt is due and returns how
+// many were attempted. A delivery whose attempt cannot be stored is logged
+// and skipped; its claim runs out and it is attempted again later.
+func (s *WebhookService) ProcessDue(ctx context.Context, limit int) (int, error) {
+	deliveries, err := s.claimDue(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+	attempted := 0
+	for _, delivery := range deliveries {
+		if err := s.attempt(ctx, delivery); err != nil {
+			log.Printf("webhooks: attempting delivery %d failed: %v", delivery.ID, err)
+			continue
+		}
+		attempted++
+	}
+	return attempted, nil
+}
+
+// claimDue locks the due deliveries, moves their next attempt past the claim
+// lease and commits, so that other instances skip them while they are sent.
+func (s *WebhookService) claimDue(ctx context.Context, limit int) ([]*domain.WebhookDelivery, error) {
+	var claimed []*domain.WebhookDelivery
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		due, err := s.repo.LockDueDeliveries(ctx, s.now(), limit)
+		if err != nil {
+			return err
+		}
+		lease := s.now().Add(webhookClaimLease)
+		for _, delivery := range due {
+			delivery.NextAttemptAt = &lease
+			if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+				return err
+			}
+		}
+		claimed = due
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// Run processes due deliveries every interval until the context is cancelled.
+func (s *WebhookService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ProcessDue(ctx, 100); err != nil {
+				log.Printf("webhooks: processing deliveries failed: %v", err)
+			}
+		}
+	}
+}
+
+func (s *WebhookService) attempt(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	subscription := delivery.Subscription
+	if subscription == nil || !subscription.Active {
+		delivery.Status = domain.WebhookDeliveryDead
+		delivery.LastError = "subscription is inactive"
+		delivery.NextAttemptAt = nil
+		return s.repo.UpdateDelivery(ctx, delivery)
+	}
+
+	delivery.Attempts++
+	started := s.now()
+	statusCode, responseBody, sendErr := s.send(ctx, subscription, delivery)
+
+	attempt := &domain.WebhookAttempt{
+		DeliveryID:   delivery.ID,
+		Number:       delivery.Attempts,
+		StatusCode:   statusCode,
+		ResponseBody: responseBody,
+		DurationMs:   s.now().Sub(started).Milliseconds(),
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+	if err := s.repo.CreateAttempt(ctx, attempt); err != nil {
+		return err
+	}
+
+	delivery.LastStatusCode = statusCode
+	if sendErr == nil {
+		now := s.now()
+		delivery.Status = domain.WebhookDeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+		return s.repo.UpdateDelivery(ctx, delivery)
+	}
+
+	delivery.LastError = sendErr.Error()
+	retries := delivery.Attempts - delivery.RetryFrom
+	if retries >= s.policy.MaxAttempts {
+		delivery.Status = domain.WebhookDeliveryDead
+		delivery.NextAttemptAt = nil
+	} else {
+		next := s.now().Add(s.backoff(retries))
+		delivery.Status = domain.WebhookDeliveryRetrying
+		delivery.NextAttemptAt = &next
+	}
+	return s.repo.UpdateDelivery(ctx, delivery)
+}
+
+func (s *WebhookService) send(ctx context.Context, subscription *domain.WebhookSubscription, delivery *domain.WebhookDelivery) (int, string, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Vertice-Webhooks/1.0")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(subscription.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(snippet), errors.New("unexpected status " + resp.Status)
+	}
+	return resp.StatusCode, string(snippet), nil
+}
+
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	delay := s.policy.BaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= s.policy.MaxDelay {
+			return s.policy.MaxDelay
+		}
+	}
+	return delay
+}
+
+// SignWebhookPayload returns the signature header value for a payload:
+// "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>".
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// validateURL accepts absolute http and https URLs whose host resolves only
+// to public addresses, so that webhooks cannot be used to reach the internal
+// network. The dialer of the default client checks the address again when
+// connecting, in case the host later resolves elsewhere.
+func (s *WebhookService) validateURL(ctx context.Context, raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	host := parsed.Hostname()
+	var addrs []netip.Addr
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs = append(addrs, addr)
+	} else {
+		addrs, err = s.resolver.LookupNetIP(ctx, "ip", host)
+		if err != nil || len(addrs) == 0 {
+			return fmt.Errorf("url host %s could not be resolved", host)
+		}
+	}
+	for _, addr := range addrs {
+		if err := checkWebhookAddr(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkWebhookAddr refuses loopback, private, link-local and unspecified
+// addresses.
+func checkWebhookAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsUnspecified() {
+		return fmt.Errorf("url must not point to the internal address %s", addr)
+	}
+	return nil
+}
+
+// newWebhookHTTPClient returns a client that only connects to addresses
+// checkWebhookAddr accepts. The check runs on the resolved address of every
+// connection, including redirects, so a host that passed validation cannot
+// be pointed at the internal network later. Proxies are not used, since the
+// check would then see the proxy rather than the receiver.
+func newWebhookHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			return checkWebhookAddr(addrPort.Addr())
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: 10 * time.Second, Transport: transport}
+}
+
+func validateWebhookEvents(events []string) error {
+	if len(events) == 0 {
+		return errors.New("at least one event type is required")
+	}
+	for _, event := range events {
+		if !slices.Contains(domain.WebhookEventTypes, event) {
+			return errors.New("unknown event type: " + event)
+		}
+	}
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
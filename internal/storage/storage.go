@@ -0,0 +1,70 @@
+// Package storage keeps uploaded files in a blob store.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when a blob does not exist.
+var ErrNotFound = errors.New("storage: blob not found")
+
+// BlobStore stores blobs under slash-separated keys and hands out
+// time-limited URLs to read them.
+type BlobStore interface {
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that reads the blob until expiry has passed.
+	SignedURL(key string, expiry time.Duration) (string, error)
+}
+
+// NewBlobStoreFromEnv builds the store selected by BLOB_STORE: "s3" uses the
+// S3_* variables, anything else the local filesystem under BLOB_LOCAL_DIR.
+func NewBlobStoreFromEnv() (BlobStore, error) {
+	if os.Getenv("BLOB_STORE") == "s3" {
+		return NewS3Store(S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PathStyle:       os.Getenv("S3_PATH_STYLE") == "true",
+		})
+	}
+
+	dir := os.Getenv("BLOB_LOCAL_DIR")
+	if dir == "" {
+		dir = "uploads"
+	}
+	baseURL := os.Getenv("BLOB_PUBLIC_URL")
+	if baseURL == "" {
+		port := os.Getenv("PORT")
+		if port == "" {
+			port = "8080"
+		}
+		baseURL = "http://localhost:" + port
+	}
+	secret := os.Getenv("BLOB_SIGNING_KEY")
+	if secret == "" {
+		secret = os.Getenv("JWT_SECRET")
+	}
+	return NewLocalStore(dir, strings.TrimSuffix(baseURL, "/")+LocalBlobPath, []byte(secret))
+}
+
+// validKey rejects keys that could escape the store's namespace.
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
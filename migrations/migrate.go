@@ -7,10 +7,98 @@ import (
 )
 
 func AutoMigrateAll(db *gorm.DB) error {
-	return db.AutoMigrate(
+	if err := dedupeWebhookDeliveries(db); err != nil {
+		return err
+	}
+	ledgerIsNew := !db.Migrator().HasTable(&domain.InventoryMovement{})
+	err := db.AutoMigrate(
 		&domain.User{},
+		&domain.UserToken{},
+		&domain.CustomerGroup{},
+		&domain.Customer{},
+		&domain.Category{},
+		&domain.Tag{},
 		&domain.Product{},
+		&domain.BundleComponent{},
+		&domain.PriceList{},
+		&domain.PriceListPrice{},
+		&domain.PriceChange{},
+		&domain.ScheduledPriceChange{},
+		&domain.OptionType{},
+		&domain.OptionValue{},
+		&domain.ProductVariant{},
+		&domain.VariantOption{},
+		&domain.ProductImage{},
+		&domain.ImportJob{},
+		&domain.ImportJobError{},
+		&domain.Stocktake{},
+		&domain.StocktakeLine{},
 		&domain.Order{},
 		&domain.OrderItem{},
+		&domain.OrderItemComponent{},
+		&domain.Lot{},
+		&domain.OrderItemLot{},
+		&domain.Serial{},
+		&domain.Shipment{},
+		&domain.ShipmentItem{},
+		&domain.WebhookSubscription{},
+		&domain.WebhookDelivery{},
+		&domain.WebhookAttempt{},
+		&domain.OutboxMessage{},
+		&domain.Notification{},
+		&domain.NotificationPreferences{},
+		&domain.EmailLog{},
+		&domain.Supplier{},
+		&domain.SupplierProduct{},
+		&domain.PurchaseOrder{},
+		&domain.PurchaseOrderLine{},
+		&domain.PurchaseOrderReceipt{},
+		&domain.PurchaseOrderReceiptLine{},
+		&domain.InventoryMovement{},
+		&domain.ReportSubscription{},
 	)
+	if err != nil {
+		return err
+	}
+	if ledgerIsNew {
+		return bookOpeningStock(db)
+	}
+	return nil
+}
+
+// dedupeWebhookDeliveries removes the duplicate deliveries that redelivered
+// outbox messages used to queue, keeping the first of each, so that the
+// unique index on event and subscription can be created.
+func dedupeWebhookDeliveries(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&domain.WebhookDelivery{}) {
+		return nil
+	}
+	const duplicates = `SELECT a.id FROM webhook_deliveries a JOIN webhook_deliveries b
+		ON a.event_id = b.event_id AND a.subscription_id = b.subscription_id AND a.id > b.id`
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM webhook_attempts WHERE delivery_id IN (" + duplicates + ")").Error; err != nil {
+			return err
+		}
+		return tx.Exec("DELETE FROM webhook_deliveries WHERE id IN (" + duplicates + ")").Error
+	})
+}
+
+// bookOpeningStock starts the inventory ledger with the stock each product
+// already has, at its cost and dated when the product was created. Stock
+// events still waiting in the outbox are left out, since the ledger books
+// them when they are relayed.
+func bookOpeningStock(db *gorm.DB) error {
+	return db.Exec(`
+INSERT INTO inventory_movements (user_id, product_id, quantity, unit_cost, reason, occurred_at)
+SELECT p.user_id, p.id, p.stock - COALESCE(pending.delta, 0), p.cost, ?, p.created_at
+FROM products p
+LEFT JOIN (
+	SELECT aggregate_id AS product_id, SUM((payload::jsonb->>'delta')::int) AS delta
+	FROM outbox
+	WHERE event_type = ? AND status = ?
+	GROUP BY aggregate_id
+) pending ON pending.product_id = p.id
+WHERE p.stock - COALESCE(pending.delta, 0) <> 0
+	AND NOT EXISTS (SELECT 1 FROM bundle_components c WHERE c.bundle_id = p.id)`,
+		domain.StockReasonOpening, domain.EventStockAdjusted, domain.OutboxPending).Error
 }
@@ -7,14 +7,18 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 )
 
+// CustomClaims are the claims of the tokens issued at login. SessionVersion is
+// the user's session version when the token was issued.
 type CustomClaims struct {
-	UserID uint `json:"user_id"`
+	UserID         uint `json:"user_id"`
+	SessionVersion int  `json:"sv,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateJWT(userID uint) (string, error) {
+func GenerateJWT(userID uint, sessionVersion int) (string, error) {
 	claims := CustomClaims{
-		UserID: userID,
+		UserID:         userID,
+		SessionVersion: sessionVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
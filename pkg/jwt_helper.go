@@ -6,10 +6,18 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
+// AccessTokenQueryParam carries the JWT for clients that cannot set headers,
+// such as the browser EventSource API.
+const AccessTokenQueryParam = "access_token"
+
 func GetUserIDFromJWTContext(c echo.Context) (uint, error) {
 	header := c.Request().Header.Get("Authorization")
 	if header == "" {
-		return 0, echo.ErrUnauthorized
+		if token := c.QueryParam(AccessTokenQueryParam); token != "" {
+			header = "Bearer " + token
+		} else {
+			return 0, echo.ErrUnauthorized
+		}
 	}
 	parts := strings.Split(header, " ")
 	if len(parts) != 2 || parts[0] != "Bearer" {
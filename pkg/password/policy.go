@@ -0,0 +1,155 @@
+// Package password checks new passwords against a policy of length,
+// character classes and a list of breached passwords.
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxLength is the longest password, in bytes, that bcrypt can hash.
+const MaxLength = 72
+
+//go:embed common.txt
+var commonList string
+
+// common is the built in list of breached passwords, lower cased.
+var common = mustParseList(commonList)
+
+// Policy is what a new password must satisfy. Passwords on its breached list
+// are refused whatever their strength.
+type Policy struct {
+	MinLength        int
+	RequireMixedCase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	breached         map[string]struct{}
+}
+
+// DefaultPolicy requires 8 characters and refuses the built in list of
+// breached passwords.
+func DefaultPolicy() Policy {
+	return Policy{MinLength: 8, breached: common}
+}
+
+// PolicyFromEnv builds the policy set by PASSWORD_MIN_LENGTH (8 by default),
+// PASSWORD_REQUIRE_MIXED_CASE, PASSWORD_REQUIRE_DIGIT and
+// PASSWORD_REQUIRE_SYMBOL. PASSWORD_BREACHED_LIST names a file of further
+// passwords to refuse, one per line.
+func PolicyFromEnv() (Policy, error) {
+	p := DefaultPolicy()
+	if v := os.Getenv("PASSWORD_MIN_LENGTH"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxLength {
+			return Policy{}, fmt.Errorf("PASSWORD_MIN_LENGTH must be between 1 and %d", MaxLength)
+		}
+		p.MinLength = n
+	}
+	for name, dst := range map[string]*bool{
+		"PASSWORD_REQUIRE_MIXED_CASE": &p.RequireMixedCase,
+		"PASSWORD_REQUIRE_DIGIT":      &p.RequireDigit,
+		"PASSWORD_REQUIRE_SYMBOL":     &p.RequireSymbol,
+	} {
+		if v := os.Getenv(name); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return Policy{}, fmt.Errorf("%s must be true or false", name)
+			}
+			*dst = b
+		}
+	}
+	if path := os.Getenv("PASSWORD_BREACHED_LIST"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return Policy{}, err
+		}
+		defer f.Close()
+		if err := p.Refuse(f); err != nil {
+			return Policy{}, fmt.Errorf("reading %s: %w", path, err)
+		}
+	}
+	return p, nil
+}
+
+// Refuse adds the passwords in r, one per line, to the breached list. Blank
+// lines and lines starting with # are skipped.
+func (p *Policy) Refuse(r io.Reader) error {
+	list, err := parseList(r)
+	if err != nil {
+		return err
+	}
+	breached := make(map[string]struct{}, len(p.breached)+len(list))
+	for password := range p.breached {
+		breached[password] = struct{}{}
+	}
+	for password := range list {
+		breached[password] = struct{}{}
+	}
+	p.breached = breached
+	return nil
+}
+
+// Check returns an error describing the first rule the password breaks.
+func (p Policy) Check(password string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters", p.MinLength)
+	}
+	if len(password) > MaxLength {
+		return fmt.Errorf("password must be at most %d bytes", MaxLength)
+	}
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireMixedCase && !(upper && lower) {
+		return errors.New("password must contain upper and lower case letters")
+	}
+	if p.RequireDigit && !digit {
+		return errors.New("password must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		return errors.New("password must contain a symbol")
+	}
+	if _, ok := p.breached[strings.ToLower(password)]; ok {
+		return errors.New("password is too common; it has appeared in data breaches")
+	}
+	return nil
+}
+
+func parseList(r io.Reader) (map[string]struct{}, error) {
+	list := make(map[string]struct{})
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		list[strings.ToLower(line)] = struct{}{}
+	}
+	return list, scanner.Err()
+}
+
+func mustParseList(s string) map[string]struct{} {
+	list, err := parseList(strings.NewReader(s))
+	if err != nil {
+		panic(err)
+	}
+	return list
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterAnalyticsRoutes(e *echo.Echo, analyticsService *service.AnalyticsService) {
+	analyticsHandler := handler.NewAnalyticsHandler(analyticsService)
+
+	api := e.Group("/api/v1")
+	reports := api.Group("/reports", middleware.JWTMiddleware())
+
+	reports.GET("/sales", analyticsHandler.GetSalesOverTime)
+	reports.GET("/top-products", analyticsHandler.GetTopProducts)
+	reports.GET("/summary", analyticsHandler.GetSalesSummary)
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterBundleRoutes(e *echo.Echo, bundleService *service.BundleService) {
+	bundleHandler := handler.NewBundleHandler(bundleService)
+
+	api := e.Group("/api/v1")
+	api.PUT("/products/:id/components", bundleHandler.SetComponents, middleware.JWTMiddleware())
+}
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterCategoryRoutes(e *echo.Echo, categoryService *service.CategoryService, tagService *service.TagService) {
+	categoryHandler := handler.NewCategoryHandler(categoryService)
+	tagHandler := handler.NewTagHandler(tagService)
+
+	api := e.Group("/api/v1")
+
+	categories := api.Group("/categories", middleware.JWTMiddleware())
+	categories.POST("", categoryHandler.CreateCategory)
+	categories.GET("", categoryHandler.ListCategories)
+	categories.GET("/report", categoryHandler.CategoryReport)
+	categories.GET("/:id", categoryHandler.GetCategory)
+	categories.PATCH("/:id", categoryHandler.UpdateCategory)
+	categories.DELETE("/:id", categoryHandler.DeleteCategory)
+
+	tags := api.Group("/tags", middleware.JWTMiddleware())
+	tags.GET("", tagHandler.ListTags)
+	tags.DELETE("/:id", tagHandler.DeleteTag)
+
+	products := api.Group("/products/:id", middleware.JWTMiddleware())
+	products.PUT("/categories", categoryHandler.SetProductCategories)
+	products.PUT("/tags", tagHandler.SetProductTags)
+}
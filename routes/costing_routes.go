@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterCostingRoutes(e *echo.Echo, costingService *service.CostingService) {
+	costingHandler := handler.NewCostingHandler(costingService)
+
+	api := e.Group("/api/v1")
+	api.PUT("/products/:id/cost", costingHandler.SetProductCost, middleware.JWTMiddleware())
+
+	reports := api.Group("/reports", middleware.JWTMiddleware())
+	reports.GET("/margins", costingHandler.GetMarginReport)
+	reports.GET("/inventory-valuation", costingHandler.GetInventoryValuation)
+}
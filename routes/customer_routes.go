@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterCustomerRoutes(e *echo.Echo, customerService *service.CustomerService) {
+	customerHandler := handler.NewCustomerHandler(customerService)
+
+	api := e.Group("/api/v1")
+	customers := api.Group("/customers", middleware.JWTMiddleware())
+
+	customers.POST("", customerHandler.CreateCustomer)
+	customers.GET("", customerHandler.ListCustomers)
+	customers.GET("/:id", customerHandler.GetCustomer)
+	customers.PATCH("/:id", customerHandler.UpdateCustomer)
+	customers.DELETE("/:id", customerHandler.DeleteCustomer)
+
+	groups := api.Group("/customer-groups", middleware.JWTMiddleware())
+	groups.POST("", customerHandler.CreateGroup)
+	groups.GET("", customerHandler.ListGroups)
+	groups.DELETE("/:id", customerHandler.DeleteGroup)
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterEmailRoutes(e *echo.Echo, emailService *service.EmailService) {
+	emailHandler := handler.NewEmailHandler(emailService)
+
+	api := e.Group("/api/v1")
+	users := api.Group("/users", middleware.JWTMiddleware())
+
+	users.GET("/notification-preferences", emailHandler.GetNotificationPreferences)
+	users.PATCH("/notification-preferences", emailHandler.UpdateNotificationPreferences)
+	users.GET("/email-log", emailHandler.ListEmailLog)
+}
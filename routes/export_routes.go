@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterExportRoutes(e *echo.Echo, exportService *service.ExportService) {
+	exportHandler := handler.NewExportHandler(exportService)
+
+	api := e.Group("/api/v1")
+
+	exports := api.Group("/exports", middleware.JWTMiddleware())
+	exports.GET("/products", exportHandler.ExportProducts)
+	exports.GET("/orders", exportHandler.ExportOrders)
+	exports.GET("/order-lines", exportHandler.ExportOrderLines)
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterForecastRoutes(e *echo.Echo, forecastService *service.ForecastService) {
+	forecastHandler := handler.NewForecastHandler(forecastService)
+
+	api := e.Group("/api/v1")
+	api.GET("/products/forecast", forecastHandler.GetForecast, middleware.JWTMiddleware())
+}
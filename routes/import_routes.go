@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterImportRoutes(e *echo.Echo, importService *service.ImportService) {
+	importHandler := handler.NewImportHandler(importService)
+
+	api := e.Group("/api/v1")
+
+	imports := api.Group("/products/import", middleware.JWTMiddleware())
+	imports.POST("", importHandler.ImportProducts)
+	imports.GET("/:id", importHandler.GetImportJob)
+	imports.GET("/:id/errors", importHandler.GetImportJobErrors)
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterLotRoutes(e *echo.Echo, lotService *service.LotService) {
+	lotHandler := handler.NewLotHandler(lotService)
+
+	api := e.Group("/api/v1")
+
+	productLots := api.Group("/products/:id", middleware.JWTMiddleware())
+	productLots.PATCH("/batch-managed", lotHandler.SetBatchManaged)
+	productLots.GET("/lots", lotHandler.GetProductLots)
+
+	lots := api.Group("/lots", middleware.JWTMiddleware())
+	lots.POST("", lotHandler.ReceiveLot)
+	lots.GET("/expiring", lotHandler.GetExpiringLots)
+	lots.GET("/:id", lotHandler.GetLot)
+	lots.POST("/:id/write-off", lotHandler.WriteOffLot)
+}
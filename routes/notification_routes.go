@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterNotificationRoutes(e *echo.Echo, notificationService *service.NotificationService) {
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+
+	api := e.Group("/api/v1")
+	notifications := api.Group("/notifications", middleware.JWTMiddleware())
+
+	notifications.GET("", notificationHandler.ListNotifications)
+	notifications.POST("/read-all", notificationHandler.MarkAllNotificationsRead)
+	notifications.POST("/:id/read", notificationHandler.MarkNotificationRead)
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterPriceChangeRoutes(e *echo.Echo, priceChangeService *service.PriceChangeService) {
+	priceChangeHandler := handler.NewPriceChangeHandler(priceChangeService)
+
+	api := e.Group("/api/v1")
+	products := api.Group("/products/:id", middleware.JWTMiddleware())
+
+	products.GET("/price-history", priceChangeHandler.GetPriceHistory)
+	products.POST("/scheduled-prices", priceChangeHandler.SchedulePriceChange)
+	products.GET("/scheduled-prices", priceChangeHandler.ListScheduledPriceChanges)
+	products.POST("/scheduled-prices/:changeId/cancel", priceChangeHandler.CancelScheduledPriceChange)
+}
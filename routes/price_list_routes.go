@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterPriceListRoutes(e *echo.Echo, priceListService *service.PriceListService) {
+	priceListHandler := handler.NewPriceListHandler(priceListService)
+
+	api := e.Group("/api/v1")
+	priceLists := api.Group("/price-lists", middleware.JWTMiddleware())
+
+	priceLists.POST("", priceListHandler.CreatePriceList)
+	priceLists.GET("", priceListHandler.ListPriceLists)
+	priceLists.GET("/:id", priceListHandler.GetPriceList)
+	priceLists.PUT("/:id", priceListHandler.UpdatePriceList)
+	priceLists.DELETE("/:id", priceListHandler.DeletePriceList)
+}
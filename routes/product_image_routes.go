@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+	"vertice-backend/internal/storage"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterProductImageRoutes(e *echo.Echo, imageService *service.ProductImageService, blobStore storage.BlobStore) {
+	imageHandler := handler.NewProductImageHandler(imageService)
+	handler.UseImageURLSigner(imageService)
+
+	api := e.Group("/api/v1")
+
+	images := api.Group("/products/:id/images", middleware.JWTMiddleware())
+	images.POST("", imageHandler.UploadProductImage)
+	images.GET("", imageHandler.ListProductImages)
+	images.PUT("/order", imageHandler.ReorderProductImages)
+	images.POST("/:imageId/primary", imageHandler.SetPrimaryProductImage)
+	images.DELETE("/:imageId", imageHandler.DeleteProductImage)
+
+	// Local blobs are served by the API itself; signed URLs of other stores
+	// point straight at the store.
+	if local, ok := blobStore.(*storage.LocalStore); ok {
+		api.GET("/blobs/*", handler.NewBlobHandler(local).GetBlob)
+	}
+}
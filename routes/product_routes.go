@@ -17,8 +17,11 @@ func RegisterProductRoutes(e *echo.Echo, productService *service.ProductService)
 
 	products.POST("", productHandler.CreateProduct)
 	products.GET("", productHandler.ListProducts)
+	products.GET("/low-stock", productHandler.ListLowStockProducts)
+	products.POST("/stock/bulk", productHandler.BulkUpdateStock)
 	products.GET("/:id", productHandler.GetProduct)
 	products.PATCH("/:id", productHandler.UpdateProduct)
 	products.DELETE("/:id", productHandler.DeleteProduct)
 	products.PATCH("/:id/stock", productHandler.UpdateProductStock)
+	products.PATCH("/:id/reorder", productHandler.UpdateReorderSettings)
 }
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterPurchaseOrderRoutes(e *echo.Echo, purchaseOrderService *service.PurchaseOrderService) {
+	purchaseOrderHandler := handler.NewPurchaseOrderHandler(purchaseOrderService)
+
+	api := e.Group("/api/v1")
+	purchaseOrders := api.Group("/purchase-orders", middleware.JWTMiddleware())
+
+	purchaseOrders.POST("", purchaseOrderHandler.CreatePurchaseOrder)
+	purchaseOrders.GET("", purchaseOrderHandler.ListPurchaseOrders)
+	purchaseOrders.GET("/:id", purchaseOrderHandler.GetPurchaseOrder)
+	purchaseOrders.PATCH("/:id", purchaseOrderHandler.UpdatePurchaseOrder)
+	purchaseOrders.DELETE("/:id", purchaseOrderHandler.DeletePurchaseOrder)
+	purchaseOrders.POST("/:id/send", purchaseOrderHandler.SendPurchaseOrder)
+	purchaseOrders.POST("/:id/receive", purchaseOrderHandler.ReceivePurchaseOrder)
+	purchaseOrders.POST("/:id/close", purchaseOrderHandler.ClosePurchaseOrder)
+}
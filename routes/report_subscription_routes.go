@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterReportSubscriptionRoutes(e *echo.Echo, reportSubscriptionService *service.ReportSubscriptionService) {
+	reportSubscriptionHandler := handler.NewReportSubscriptionHandler(reportSubscriptionService)
+
+	api := e.Group("/api/v1")
+	subscriptions := api.Group("/report-subscriptions", middleware.JWTMiddleware())
+
+	subscriptions.POST("", reportSubscriptionHandler.CreateReportSubscription)
+	subscriptions.GET("", reportSubscriptionHandler.ListReportSubscriptions)
+	subscriptions.GET("/:id", reportSubscriptionHandler.GetReportSubscription)
+	subscriptions.PATCH("/:id", reportSubscriptionHandler.UpdateReportSubscription)
+	subscriptions.DELETE("/:id", reportSubscriptionHandler.DeleteReportSubscription)
+	subscriptions.POST("/:id/send", reportSubscriptionHandler.SendReportSubscription)
+}
@@ -2,18 +2,67 @@ package routes
 
 import (
 	"vertice-backend/internal/service"
+	"vertice-backend/internal/storage"
 
 	"github.com/labstack/echo/v4"
 )
 
 type AppDependencies struct {
-	UserService    *service.UserService
-	ProductService *service.ProductService
-	OrderService   *service.OrderService
+	UserService               *service.UserService
+	ProductService            *service.ProductService
+	OrderService              *service.OrderService
+	ShipmentService           *service.ShipmentService
+	WebhookService            *service.WebhookService
+	StreamHub                 *service.StreamHub
+	NotificationService       *service.NotificationService
+	SupplierService           *service.SupplierService
+	PurchaseOrderService      *service.PurchaseOrderService
+	VariantService            *service.VariantService
+	CategoryService           *service.CategoryService
+	TagService                *service.TagService
+	ProductImageService       *service.ProductImageService
+	ImportService             *service.ImportService
+	ExportService             *service.ExportService
+	StocktakeService          *service.StocktakeService
+	LotService                *service.LotService
+	SerialService             *service.SerialService
+	BundleService             *service.BundleService
+	CustomerService           *service.CustomerService
+	PriceListService          *service.PriceListService
+	PriceChangeService        *service.PriceChangeService
+	CostingService            *service.CostingService
+	AnalyticsService          *service.AnalyticsService
+	ForecastService           *service.ForecastService
+	ReportSubscriptionService *service.ReportSubscriptionService
+	EmailService              *service.EmailService
+	BlobStore                 storage.BlobStore
 }
 
 func RegisterAllRoutes(e *echo.Echo, deps AppDependencies) {
 	RegisterUserRoutes(e, deps.UserService)
 	RegisterProductRoutes(e, deps.ProductService)
 	RegisterOrderRoutes(e, deps.OrderService)
+	RegisterShipmentRoutes(e, deps.ShipmentService)
+	RegisterWebhookRoutes(e, deps.WebhookService)
+	RegisterStreamRoutes(e, deps.StreamHub)
+	RegisterNotificationRoutes(e, deps.NotificationService)
+	RegisterSupplierRoutes(e, deps.SupplierService)
+	RegisterPurchaseOrderRoutes(e, deps.PurchaseOrderService)
+	RegisterVariantRoutes(e, deps.VariantService)
+	RegisterCategoryRoutes(e, deps.CategoryService, deps.TagService)
+	RegisterProductImageRoutes(e, deps.ProductImageService, deps.BlobStore)
+	RegisterImportRoutes(e, deps.ImportService)
+	RegisterExportRoutes(e, deps.ExportService)
+	RegisterStocktakeRoutes(e, deps.StocktakeService)
+	RegisterLotRoutes(e, deps.LotService)
+	RegisterSerialRoutes(e, deps.SerialService)
+	RegisterBundleRoutes(e, deps.BundleService)
+	RegisterCustomerRoutes(e, deps.CustomerService)
+	RegisterPriceListRoutes(e, deps.PriceListService)
+	RegisterPriceChangeRoutes(e, deps.PriceChangeService)
+	RegisterCostingRoutes(e, deps.CostingService)
+	RegisterAnalyticsRoutes(e, deps.AnalyticsService)
+	RegisterForecastRoutes(e, deps.ForecastService)
+	RegisterReportSubscriptionRoutes(e, deps.ReportSubscriptionService)
+	RegisterEmailRoutes(e, deps.EmailService)
 }
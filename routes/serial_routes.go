@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterSerialRoutes(e *echo.Echo, serialService *service.SerialService) {
+	serialHandler := handler.NewSerialHandler(serialService)
+
+	api := e.Group("/api/v1")
+
+	productSerials := api.Group("/products/:id", middleware.JWTMiddleware())
+	productSerials.PATCH("/serialized", serialHandler.SetSerialized)
+	productSerials.GET("/serials", serialHandler.GetProductSerials)
+
+	api.PUT("/orders/:id/items/:itemId/serials", serialHandler.AssignSerials, middleware.JWTMiddleware())
+
+	serials := api.Group("/serials", middleware.JWTMiddleware())
+	serials.POST("", serialHandler.ReceiveSerials)
+	serials.POST("/returns", serialHandler.ReturnSerials)
+	serials.GET("/:number", serialHandler.LookupSerial)
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterShipmentRoutes(e *echo.Echo, shipmentService *service.ShipmentService) {
+	shipmentHandler := handler.NewShipmentHandler(shipmentService)
+
+	api := e.Group("/api/v1")
+	shipments := api.Group("/orders/:id/shipments", middleware.JWTMiddleware())
+
+	shipments.POST("", shipmentHandler.CreateShipment)
+	shipments.GET("", shipmentHandler.ListShipments)
+	shipments.PATCH("/:shipmentId", shipmentHandler.UpdateShipment)
+}
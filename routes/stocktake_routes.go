@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterStocktakeRoutes(e *echo.Echo, stocktakeService *service.StocktakeService) {
+	stocktakeHandler := handler.NewStocktakeHandler(stocktakeService)
+
+	api := e.Group("/api/v1")
+	stocktakes := api.Group("/stocktakes", middleware.JWTMiddleware())
+
+	stocktakes.POST("", stocktakeHandler.OpenStocktake)
+	stocktakes.GET("", stocktakeHandler.ListStocktakes)
+	stocktakes.GET("/:id", stocktakeHandler.GetStocktake)
+	stocktakes.POST("/:id/counts", stocktakeHandler.RecordStocktakeCounts)
+	stocktakes.POST("/:id/approve", stocktakeHandler.ApproveStocktake)
+	stocktakes.POST("/:id/cancel", stocktakeHandler.CancelStocktake)
+}
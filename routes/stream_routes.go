@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterStreamRoutes(e *echo.Echo, hub *service.StreamHub) {
+	streamHandler := handler.NewStreamHandler(hub)
+
+	api := e.Group("/api/v1")
+	events := api.Group("/events", middleware.JWTQueryMiddleware())
+
+	events.GET("/stream", streamHandler.StreamEvents)
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterSupplierRoutes(e *echo.Echo, supplierService *service.SupplierService) {
+	supplierHandler := handler.NewSupplierHandler(supplierService)
+
+	api := e.Group("/api/v1")
+	suppliers := api.Group("/suppliers", middleware.JWTMiddleware())
+
+	suppliers.POST("", supplierHandler.CreateSupplier)
+	suppliers.GET("", supplierHandler.ListSuppliers)
+	suppliers.GET("/:id", supplierHandler.GetSupplier)
+	suppliers.PATCH("/:id", supplierHandler.UpdateSupplier)
+	suppliers.DELETE("/:id", supplierHandler.DeleteSupplier)
+	suppliers.GET("/:id/products", supplierHandler.ListSupplierProducts)
+	suppliers.PUT("/:id/products/:productId", supplierHandler.SetSupplierProduct)
+	suppliers.DELETE("/:id/products/:productId", supplierHandler.DeleteSupplierProduct)
+}
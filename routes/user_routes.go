@@ -10,11 +10,17 @@ import (
 
 func RegisterUserRoutes(e *echo.Echo, userService *service.UserService) {
 	userHandler := handler.NewUserHandler(userService)
+	// Tokens issued before a password reset stop working.
+	middleware.UseSessionValidator(userService.ValidateSession)
 
 	api := e.Group("/api/v1")
 
 	api.POST("/auth/register", userHandler.Register)
 	api.POST("/auth/login", userHandler.Login)
+	api.POST("/auth/verify-email", userHandler.VerifyEmail)
+	api.POST("/auth/resend-verification", userHandler.ResendVerification)
+	api.POST("/auth/forgot-password", userHandler.ForgotPassword)
+	api.POST("/auth/reset-password", userHandler.ResetPassword)
 
 	users := api.Group("/users", middleware.JWTMiddleware())
 
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterVariantRoutes(e *echo.Echo, variantService *service.VariantService) {
+	variantHandler := handler.NewVariantHandler(variantService)
+
+	api := e.Group("/api/v1")
+
+	optionTypes := api.Group("/option-types", middleware.JWTMiddleware())
+	optionTypes.POST("", variantHandler.CreateOptionType)
+	optionTypes.GET("", variantHandler.ListOptionTypes)
+	optionTypes.DELETE("/:id", variantHandler.DeleteOptionType)
+	optionTypes.POST("/:id/values", variantHandler.AddOptionValue)
+	optionTypes.DELETE("/:id/values/:valueId", variantHandler.DeleteOptionValue)
+
+	variants := api.Group("/products/:id/variants", middleware.JWTMiddleware())
+	variants.POST("", variantHandler.CreateVariant)
+	variants.PATCH("/:variantId", variantHandler.UpdateVariant)
+	variants.DELETE("/:variantId", variantHandler.DeleteVariant)
+	variants.PATCH("/:variantId/stock", variantHandler.UpdateVariantStock)
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"vertice-backend/internal/handler"
+	"vertice-backend/internal/middleware"
+	"vertice-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterWebhookRoutes(e *echo.Echo, webhookService *service.WebhookService) {
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+
+	api := e.Group("/api/v1")
+	webhooks := api.Group("/webhooks", middleware.JWTMiddleware())
+
+	webhooks.POST("", webhookHandler.CreateWebhook)
+	webhooks.GET("", webhookHandler.ListWebhooks)
+	webhooks.GET("/:id", webhookHandler.GetWebhook)
+	webhooks.PATCH("/:id", webhookHandler.UpdateWebhook)
+	webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+	webhooks.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+	webhooks.GET("/:id/deliveries/:deliveryId", webhookHandler.GetDelivery)
+	webhooks.POST("/:id/deliveries/:deliveryId/redeliver", webhookHandler.RedeliverDelivery)
+}
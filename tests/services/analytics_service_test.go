@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAnalyticsRepo struct {
+	mock.Mock
+}
+
+func (m *MockAnalyticsRepo) SalesOverTime(ctx context.Context, userID uint, bucket domain.SalesBucket, filter domain.SalesFilter) ([]domain.SalesPoint, error) {
+	args := m.Called(ctx, userID, bucket, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.SalesPoint), args.Error(1)
+}
+
+func (m *MockAnalyticsRepo) TopProducts(ctx context.Context, userID uint, filter domain.SalesFilter, byQuantity bool, limit int) ([]domain.ProductSales, error) {
+	args := m.Called(ctx, userID, filter, byQuantity, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ProductSales), args.Error(1)
+}
+
+func (m *MockAnalyticsRepo) StatusCounts(ctx context.Context, userID uint, filter domain.SalesFilter) ([]domain.StatusCount, error) {
+	args := m.Called(ctx, userID, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.StatusCount), args.Error(1)
+}
+
+func (m *MockAnalyticsRepo) DailyProductSales(ctx context.Context, userID uint, since time.Time) ([]domain.DailyProductSales, error) {
+	args := m.Called(ctx, userID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.DailyProductSales), args.Error(1)
+}
+
+func TestSalesOverTime_FillsEmptyDaysInTimeZone(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepo)
+	analyticsService := service.NewAnalyticsService(mockRepo)
+
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	assert.NoError(t, err)
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, madrid)
+	to := time.Date(2024, 3, 4, 0, 0, 0, 0, madrid)
+	filter := domain.SalesFilter{From: &from, To: &to, Location: madrid}
+	mockRepo.On("SalesOverTime", mock.Anything, uint(1), domain.SalesBucketDay, filter).Return([]domain.SalesPoint{
+		{Period: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Orders: 2, Revenue: 30.5},
+		{Period: time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC), Orders: 1, Revenue: 12},
+	}, nil)
+
+	points, err := analyticsService.SalesOverTime(context.Background(), 1, "", filter)
+
+	assert.NoError(t, err)
+	assert.Len(t, points, 3)
+	assert.True(t, points[0].Period.Equal(from))
+	assert.Equal(t, 2, points[0].Orders)
+	assert.True(t, points[1].Period.Equal(time.Date(2024, 3, 2, 0, 0, 0, 0, madrid)))
+	assert.Equal(t, 0, points[1].Orders)
+	assert.Equal(t, 12.0, points[2].Revenue)
+}
+
+func TestSalesOverTime_WeeksStartOnMonday(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepo)
+	analyticsService := service.NewAnalyticsService(mockRepo)
+
+	// 2024-01-03 is a Wednesday and 2024-01-17 one two weeks later.
+	from := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 18, 0, 0, 0, 0, time.UTC)
+	filter := domain.SalesFilter{From: &from, To: &to}
+	mockRepo.On("SalesOverTime", mock.Anything, uint(1), domain.SalesBucketWeek, filter).Return([]domain.SalesPoint{
+		{Period: time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC), Orders: 4, Revenue: 100},
+	}, nil)
+
+	points, err := analyticsService.SalesOverTime(context.Background(), 1, domain.SalesBucketWeek, filter)
+
+	assert.NoError(t, err)
+	assert.Len(t, points, 3)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), points[0].Period)
+	assert.Equal(t, 4, points[1].Orders)
+	assert.Equal(t, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), points[2].Period)
+}
+
+func TestSalesOverTime_Error_InvalidBucket(t *testing.T) {
+	analyticsService := service.NewAnalyticsService(new(MockAnalyticsRepo))
+
+	_, err := analyticsService.SalesOverTime(context.Background(), 1, "year", domain.SalesFilter{})
+
+	assert.EqualError(t, err, "bucket must be day, week or month")
+}
+
+func TestSalesOverTime_Error_TooManyPeriods(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepo)
+	analyticsService := service.NewAnalyticsService(mockRepo)
+
+	from := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	filter := domain.SalesFilter{From: &from, To: &to}
+	mockRepo.On("SalesOverTime", mock.Anything, uint(1), domain.SalesBucketDay, filter).Return([]domain.SalesPoint{}, nil)
+
+	_, err := analyticsService.SalesOverTime(context.Background(), 1, domain.SalesBucketDay, filter)
+
+	assert.Error(t, err)
+}
+
+func TestTopProducts_CapsLimit(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepo)
+	analyticsService := service.NewAnalyticsService(mockRepo)
+
+	mockRepo.On("TopProducts", mock.Anything, uint(1), domain.SalesFilter{}, true, 100).Return([]domain.ProductSales{
+		{ProductID: 1, Code: "MOUSE", Quantity: 40, Revenue: 799.996},
+	}, nil)
+
+	products, err := analyticsService.TopProducts(context.Background(), 1, domain.SalesFilter{}, "quantity", 500)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 800.0, products[0].Revenue)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTopProducts_Error_InvalidRanking(t *testing.T) {
+	analyticsService := service.NewAnalyticsService(new(MockAnalyticsRepo))
+
+	_, err := analyticsService.TopProducts(context.Background(), 1, domain.SalesFilter{}, "margin", 0)
+
+	assert.EqualError(t, err, "by must be revenue or quantity")
+}
+
+func TestSalesSummary_ComputesRatesAndFunnel(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepo)
+	analyticsService := service.NewAnalyticsService(mockRepo)
+
+	mockRepo.On("StatusCounts", mock.Anything, uint(1), domain.SalesFilter{}).Return([]domain.StatusCount{
+		{Status: domain.OrderStatusPending, Orders: 2, Revenue: 50},
+		{Status: domain.OrderStatusConfirmed, Orders: 1, Revenue: 30},
+		{Status: domain.OrderStatusShipped, Orders: 3, Revenue: 90},
+		{Status: domain.OrderStatusDelivered, Orders: 2, Revenue: 70},
+		{Status: domain.OrderStatusCancelled, Orders: 2, Revenue: 500},
+	}, nil)
+
+	summary, err := analyticsService.SalesSummary(context.Background(), 1, domain.SalesFilter{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10, summary.Orders)
+	assert.Equal(t, 240.0, summary.Revenue)
+	assert.Equal(t, 30.0, summary.AverageOrderValue)
+	assert.Equal(t, 2, summary.Cancelled)
+	assert.Equal(t, 20.0, summary.CancellationRate)
+	assert.Equal(t, []service.FunnelStage{
+		{Status: domain.OrderStatusPending, Orders: 10, Percent: 100},
+		{Status: domain.OrderStatusConfirmed, Orders: 6, Percent: 60},
+		{Status: domain.OrderStatusShipped, Orders: 5, Percent: 50},
+		{Status: domain.OrderStatusDelivered, Orders: 2, Percent: 20},
+	}, summary.Funnel)
+}
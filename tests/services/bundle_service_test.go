@@ -0,0 +1,204 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockBundleRepo struct {
+	mock.Mock
+}
+
+func (m *MockBundleRepo) ReplaceComponents(ctx context.Context, bundleID uint, components []domain.BundleComponent) error {
+	args := m.Called(ctx, bundleID, components)
+	return args.Error(0)
+}
+
+func (m *MockBundleRepo) FindBundleIDsByComponent(ctx context.Context, productID uint) ([]uint, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+// starterKit is a bundle of two mugs and a red t-shirt.
+func starterKit() (*domain.Product, *domain.Product, *domain.Product) {
+	mug := &domain.Product{ID: 2, UserID: 1, Code: "MUG", Name: "Mug", Price: 5, Stock: 7}
+	shirt := productWithVariants()
+	red := &shirt.Variants[0]
+	kit := &domain.Product{ID: 3, UserID: 1, Code: "KIT", Name: "Starter Kit", Price: 18, Components: []domain.BundleComponent{
+		{BundleID: 3, ComponentID: 2, Component: *mug, Quantity: 2},
+		{BundleID: 3, ComponentID: 1, Component: *shirt, VariantID: uintPtr(red.ID), Variant: red, Quantity: 1},
+	}}
+	return kit, mug, shirt
+}
+
+func TestBundleStock_IsLimitedByScarcestComponent(t *testing.T) {
+	kit, _, _ := starterKit()
+
+	// 7 mugs make 3 kits, but there are 3 red t-shirts.
+	assert.Equal(t, 3, kit.BundleStock())
+	kit.Components[0].Component.Stock = 3
+	assert.Equal(t, 1, kit.BundleStock())
+	assert.True(t, kit.IsBundle())
+	assert.False(t, kit.LowStock())
+}
+
+func TestSetComponents_ReplacesComponents(t *testing.T) {
+	bundleRepo := new(MockBundleRepo)
+	productRepo := new(MockProductRepo)
+	bundleService := service.NewBundleService(bundleRepo, productRepo)
+
+	kit := &domain.Product{ID: 3, UserID: 1, Name: "Starter Kit"}
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(3), uint(1)).Return(kit, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(&domain.Product{ID: 2, UserID: 1, Name: "Mug"}, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(productWithVariants(), nil)
+	bundleRepo.On("FindBundleIDsByComponent", mock.Anything, uint(3)).Return([]uint{}, nil)
+	bundleRepo.On("ReplaceComponents", mock.Anything, uint(3), []domain.BundleComponent{
+		{ComponentID: 2, Quantity: 2},
+		{ComponentID: 1, VariantID: uintPtr(5), Quantity: 1},
+	}).Return(nil)
+
+	_, err := bundleService.SetComponents(context.Background(), 3, 1, []service.BundleComponentRequest{
+		{ProductID: 2, Quantity: 2},
+		{ProductID: 1, VariantID: uintPtr(5), Quantity: 1},
+	})
+
+	assert.NoError(t, err)
+	bundleRepo.AssertExpectations(t)
+}
+
+func TestSetComponents_Validation(t *testing.T) {
+	bundleRepo := new(MockBundleRepo)
+	productRepo := new(MockProductRepo)
+	bundleService := service.NewBundleService(bundleRepo, productRepo)
+
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(3), uint(1)).Return(&domain.Product{ID: 3, UserID: 1, Name: "Starter Kit"}, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(4), uint(1)).Return(&domain.Product{ID: 4, UserID: 1, Name: "Boxed Mug", Stock: 2}, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(&domain.Product{ID: 2, UserID: 1, Name: "Mug"}, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(productWithVariants(), nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(6), uint(1)).Return(&domain.Product{ID: 6, UserID: 1, Name: "Gift Set", Components: []domain.BundleComponent{{ComponentID: 2, Quantity: 1}}}, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(7), uint(1)).Return(&domain.Product{ID: 7, UserID: 1, Name: "Laptop", Serialized: true}, nil)
+	bundleRepo.On("FindBundleIDsByComponent", mock.Anything, uint(3)).Return([]uint{}, nil)
+	bundleRepo.On("FindBundleIDsByComponent", mock.Anything, uint(2)).Return([]uint{3}, nil)
+
+	tests := []struct {
+		name       string
+		bundleID   uint
+		components []service.BundleComponentRequest
+		err        string
+	}{
+		{"bundle with stock", 4, []service.BundleComponentRequest{{ProductID: 2, Quantity: 1}}, "stock must be zero to make a product a bundle"},
+		{"component of another bundle", 2, []service.BundleComponentRequest{{ProductID: 1, VariantID: uintPtr(5), Quantity: 1}}, "a component of another bundle cannot be a bundle"},
+		{"product with variants", 1, []service.BundleComponentRequest{{ProductID: 2, Quantity: 1}}, "products with variants cannot be bundles"},
+		{"itself", 3, []service.BundleComponentRequest{{ProductID: 3, Quantity: 1}}, "a bundle cannot contain itself"},
+		{"zero quantity", 3, []service.BundleComponentRequest{{ProductID: 2}}, "component quantity must be greater than 0"},
+		{"missing variant", 3, []service.BundleComponentRequest{{ProductID: 1, Quantity: 1}}, "variant_id is required for component: T-Shirt"},
+		{"nested bundle", 3, []service.BundleComponentRequest{{ProductID: 6, Quantity: 1}}, "bundles cannot be components: Gift Set"},
+		{"serialized", 3, []service.BundleComponentRequest{{ProductID: 7, Quantity: 1}}, "batch-managed and serialized products cannot be components: Laptop"},
+		{"listed twice", 3, []service.BundleComponentRequest{{ProductID: 2, Quantity: 1}, {ProductID: 2, Quantity: 2}}, "component is listed twice: Mug"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := bundleService.SetComponents(context.Background(), tt.bundleID, 1, tt.components)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+	bundleRepo.AssertNotCalled(t, "ReplaceComponents", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateOrder_BundleTakesComponentsFromStock(t *testing.T) {
+	orderRepo := new(MockOrderRepo)
+	productRepo := new(MockProductRepo)
+	variantRepo := new(MockVariantRepo)
+	orderService := service.NewOrderService(orderRepo, productRepo, service.WithOrderVariants(variantRepo))
+
+	kit, mug, shirt := starterKit()
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(3), uint(1)).Return(kit, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(mug, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(shirt, nil)
+	productRepo.On("Update", mock.Anything, mock.Anything, uint(1)).Return(nil)
+	variantRepo.On("UpdateVariant", mock.Anything, mock.Anything).Return(nil)
+	var created *domain.Order
+	orderRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Order")).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*domain.Order)
+	}).Return(nil)
+	orderRepo.On("FindByIDAndUserID", mock.Anything, mock.Anything, uint(1)).Return(&domain.Order{ID: 1}, nil)
+
+	_, err := orderService.CreateOrder(context.Background(), 1, service.CreateOrderRequest{
+		Items: []service.OrderItemRequest{{ProductID: 3, Quantity: 2}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, mug.Stock)
+	assert.Equal(t, 1, shirt.Variants[0].Stock)
+	assert.Equal(t, 36.0, created.TotalAmount)
+	assert.Equal(t, []domain.OrderItemComponent{
+		{ProductID: 2, Quantity: 4},
+		{ProductID: 1, VariantID: uintPtr(5), Quantity: 2},
+	}, created.Items[0].Components)
+}
+
+func TestCreateOrder_BundleBeyondComponentStock(t *testing.T) {
+	orderRepo := new(MockOrderRepo)
+	productRepo := new(MockProductRepo)
+	orderService := service.NewOrderService(orderRepo, productRepo)
+
+	kit, _, _ := starterKit()
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(3), uint(1)).Return(kit, nil)
+
+	_, err := orderService.CreateOrder(context.Background(), 1, service.CreateOrderRequest{
+		Items: []service.OrderItemRequest{{ProductID: 3, Quantity: 4}},
+	})
+
+	assert.EqualError(t, err, "insufficient stock for product: Starter Kit")
+	orderRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCancelOrder_RestoresBundleComponents(t *testing.T) {
+	orderRepo := new(MockOrderRepo)
+	productRepo := new(MockProductRepo)
+	variantRepo := new(MockVariantRepo)
+	orderService := service.NewOrderService(orderRepo, productRepo, service.WithOrderVariants(variantRepo))
+
+	_, mug, shirt := starterKit()
+	order := &domain.Order{ID: 1, UserID: 1, Status: domain.OrderStatusConfirmed, Items: []domain.OrderItem{
+		{ProductID: 3, Quantity: 2, Components: []domain.OrderItemComponent{
+			{ProductID: 2, Quantity: 4},
+			{ProductID: 1, VariantID: uintPtr(5), Quantity: 2},
+		}},
+	}}
+	orderRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(order, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(mug, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(shirt, nil)
+	productRepo.On("Update", mock.Anything, mock.Anything, uint(1)).Return(nil)
+	variantRepo.On("UpdateVariant", mock.Anything, mock.Anything).Return(nil)
+	orderRepo.On("Update", mock.Anything, order, uint(1)).Return(nil)
+
+	_, err := orderService.CancelOrder(context.Background(), 1, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 11, mug.Stock)
+	assert.Equal(t, 5, shirt.Variants[0].Stock)
+	productRepo.AssertNotCalled(t, "FindByIDAndUserID", mock.Anything, uint(3), uint(1))
+}
+
+func TestUpdateProductStock_RefusesBundles(t *testing.T) {
+	productRepo := new(MockProductRepo)
+	productService := service.NewProductService(productRepo)
+
+	kit, _, _ := starterKit()
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(3), uint(1)).Return(kit, nil)
+
+	_, err := productService.UpdateProductStock(context.Background(), 3, 1, 5)
+
+	assert.EqualError(t, err, "stock of a bundle is derived from its components")
+	productRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,229 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCategoryRepo struct {
+	mock.Mock
+}
+
+func (m *MockCategoryRepo) Create(ctx context.Context, category *domain.Category) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepo) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.Category, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepo) FindBySlugAndUserID(ctx context.Context, slug string, userID uint) (*domain.Category, error) {
+	args := m.Called(ctx, slug, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepo) FindByUserID(ctx context.Context, userID uint) ([]*domain.Category, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepo) FindByIDs(ctx context.Context, ids []uint, userID uint) ([]*domain.Category, error) {
+	args := m.Called(ctx, ids, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepo) Update(ctx context.Context, category *domain.Category) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepo) Delete(ctx context.Context, id, userID uint) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepo) ReplaceProductCategories(ctx context.Context, product *domain.Product, categories []*domain.Category) error {
+	args := m.Called(ctx, product, categories)
+	return args.Error(0)
+}
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+// categoryFixture returns Clothing > T-Shirts > Kids and a separate Shoes root.
+func categoryFixture() []*domain.Category {
+	return []*domain.Category{
+		{ID: 1, UserID: 1, Name: "Clothing", Slug: "clothing"},
+		{ID: 2, UserID: 1, Name: "T-Shirts", Slug: "t-shirts", ParentID: uintPtr(1)},
+		{ID: 3, UserID: 1, Name: "Kids", Slug: "kids", ParentID: uintPtr(2)},
+		{ID: 4, UserID: 1, Name: "Shoes", Slug: "shoes", Position: -1},
+	}
+}
+
+func TestCategoryTree_SubtreeAndPath(t *testing.T) {
+	tree := domain.NewCategoryTree(categoryFixture())
+
+	assert.Equal(t, []uint{1, 2, 3}, tree.Subtree(1))
+	assert.Equal(t, "Clothing / T-Shirts / Kids", tree.Path(3))
+	assert.Equal(t, "Shoes", tree.Roots()[0].Name)
+	assert.Nil(t, tree.Subtree(99))
+}
+
+func TestCreateCategory_DerivesSlug(t *testing.T) {
+	mockCategoryRepo := new(MockCategoryRepo)
+	categoryService := service.NewCategoryService(mockCategoryRepo, new(MockProductRepo))
+
+	mockCategoryRepo.On("FindBySlugAndUserID", mock.Anything, "summer-t-shirts", uint(1)).Return(nil, errors.New("not found"))
+	mockCategoryRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(categoryFixture()[0], nil)
+	mockCategoryRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Category")).Return(nil)
+
+	category, err := categoryService.CreateCategory(context.Background(), 1, service.CategoryRequest{Name: "Summer T-Shirts!", ParentID: uintPtr(1)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "summer-t-shirts", category.Slug)
+	assert.Equal(t, uint(1), *category.ParentID)
+}
+
+func TestCreateCategory_Error_SlugTaken(t *testing.T) {
+	mockCategoryRepo := new(MockCategoryRepo)
+	categoryService := service.NewCategoryService(mockCategoryRepo, new(MockProductRepo))
+
+	mockCategoryRepo.On("FindBySlugAndUserID", mock.Anything, "clothing", uint(1)).Return(categoryFixture()[0], nil)
+
+	_, err := categoryService.CreateCategory(context.Background(), 1, service.CategoryRequest{Name: "Clothing"})
+
+	assert.EqualError(t, err, "category slug already exists")
+	mockCategoryRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUpdateCategory_Error_MoveUnderDescendant(t *testing.T) {
+	mockCategoryRepo := new(MockCategoryRepo)
+	categoryService := service.NewCategoryService(mockCategoryRepo, new(MockProductRepo))
+
+	categories := categoryFixture()
+	mockCategoryRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(categories[0], nil)
+	mockCategoryRepo.On("FindByUserID", mock.Anything, uint(1)).Return(categories, nil)
+
+	_, err := categoryService.UpdateCategory(context.Background(), 1, 1, service.UpdateCategoryRequest{ParentID: uintPtr(3)})
+
+	assert.EqualError(t, err, "a category cannot be moved under itself or its descendants")
+	mockCategoryRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUpdateCategory_MoveToRoot(t *testing.T) {
+	mockCategoryRepo := new(MockCategoryRepo)
+	categoryService := service.NewCategoryService(mockCategoryRepo, new(MockProductRepo))
+
+	mockCategoryRepo.On("FindByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(categoryFixture()[1], nil)
+	mockCategoryRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Category")).Return(nil)
+
+	category, err := categoryService.UpdateCategory(context.Background(), 2, 1, service.UpdateCategoryRequest{ParentID: uintPtr(0)})
+
+	assert.NoError(t, err)
+	assert.Nil(t, category.ParentID)
+}
+
+func TestDeleteCategory_Error_HasSubcategories(t *testing.T) {
+	mockCategoryRepo := new(MockCategoryRepo)
+	categoryService := service.NewCategoryService(mockCategoryRepo, new(MockProductRepo))
+
+	mockCategoryRepo.On("FindByUserID", mock.Anything, uint(1)).Return(categoryFixture(), nil)
+
+	err := categoryService.DeleteCategory(context.Background(), 2, 1)
+
+	assert.EqualError(t, err, "category has subcategories")
+	mockCategoryRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSetProductCategories_Error_UnknownCategory(t *testing.T) {
+	mockCategoryRepo := new(MockCategoryRepo)
+	mockProductRepo := new(MockProductRepo)
+	categoryService := service.NewCategoryService(mockCategoryRepo, mockProductRepo)
+
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Product{ID: 1, UserID: 1}, nil)
+	mockCategoryRepo.On("FindByIDs", mock.Anything, []uint{2, 9}, uint(1)).Return([]*domain.Category{categoryFixture()[1]}, nil)
+
+	_, err := categoryService.SetProductCategories(context.Background(), 1, 1, []uint{2, 9, 2})
+
+	assert.EqualError(t, err, "category not found")
+	mockCategoryRepo.AssertNotCalled(t, "ReplaceProductCategories", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCategoryReport_RollsUpSubcategoriesOnce(t *testing.T) {
+	mockCategoryRepo := new(MockCategoryRepo)
+	mockProductRepo := new(MockProductRepo)
+	categoryService := service.NewCategoryService(mockCategoryRepo, mockProductRepo)
+
+	categories := categoryFixture()
+	products := []*domain.Product{
+		// In both T-Shirts and Kids: counted once for Clothing and T-Shirts.
+		{ID: 1, Price: 10, Stock: 4, ReorderPoint: 5, Categories: []domain.Category{*categories[1], *categories[2]}},
+		{ID: 2, Price: 20, Stock: 1, Categories: []domain.Category{*categories[0]}},
+		{ID: 3, Price: 5, Stock: 100},
+	}
+	mockCategoryRepo.On("FindByUserID", mock.Anything, uint(1)).Return(categories, nil)
+	mockProductRepo.On("FindByUserID", mock.Anything, uint(1)).Return(products, nil)
+
+	reports, err := categoryService.Report(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, reports, 4)
+	assert.Equal(t, "Shoes", reports[0].Category.Name)
+	assert.Equal(t, 0, reports[0].Products)
+	clothing := reports[1]
+	assert.Equal(t, 2, clothing.Products)
+	assert.Equal(t, 5, clothing.Units)
+	assert.Equal(t, 60.0, clothing.StockValue)
+	assert.Equal(t, 1, clothing.LowStock)
+	assert.Equal(t, "Clothing / T-Shirts", reports[2].Path)
+	assert.Equal(t, 1, reports[2].Products)
+}
+
+func TestListProducts_FiltersByCategorySubtree(t *testing.T) {
+	mockProductRepo := new(MockProductRepo)
+	mockCategoryRepo := new(MockCategoryRepo)
+	productService := service.NewProductService(mockProductRepo, service.WithProductCategories(mockCategoryRepo))
+
+	mockCategoryRepo.On("FindByUserID", mock.Anything, uint(1)).Return(categoryFixture(), nil)
+	filter := domain.ProductFilter{CategoryIDs: []uint{2, 3}, Tag: "summer"}
+	mockProductRepo.On("FindByFilter", mock.Anything, uint(1), filter).Return([]*domain.Product{{ID: 1}}, nil)
+
+	products, err := productService.ListProducts(context.Background(), 1, service.ProductListFilter{Category: "T-Shirts", Tag: " Summer "})
+
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+}
+
+func TestListProducts_Error_UnknownCategory(t *testing.T) {
+	mockProductRepo := new(MockProductRepo)
+	mockCategoryRepo := new(MockCategoryRepo)
+	productService := service.NewProductService(mockProductRepo, service.WithProductCategories(mockCategoryRepo))
+
+	mockCategoryRepo.On("FindByUserID", mock.Anything, uint(1)).Return(categoryFixture(), nil)
+
+	_, err := productService.ListProducts(context.Background(), 1, service.ProductListFilter{Category: "99"})
+
+	assert.EqualError(t, err, "category not found")
+}
@@ -0,0 +1,289 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockInventoryMovementRepo struct {
+	mock.Mock
+}
+
+func (m *MockInventoryMovementRepo) Create(ctx context.Context, movement *domain.InventoryMovement) error {
+	args := m.Called(ctx, movement)
+	return args.Error(0)
+}
+
+func (m *MockInventoryMovementRepo) BalancesAt(ctx context.Context, userID uint, t time.Time) ([]domain.InventoryBalance, error) {
+	args := m.Called(ctx, userID, t)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.InventoryBalance), args.Error(1)
+}
+
+func TestCostingHandle_BooksStockAdjustedIntoLedger(t *testing.T) {
+	mockMovementRepo := new(MockInventoryMovementRepo)
+	costingService := service.NewCostingService(mockMovementRepo, new(MockProductRepo), new(MockOrderRepo))
+
+	occurredAt := time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC)
+	mockMovementRepo.On("Create", mock.Anything, mock.MatchedBy(func(m *domain.InventoryMovement) bool {
+		return m.UserID == 1 && m.EventID != nil && *m.EventID == 7 && m.ProductID == 3 &&
+			m.VariantID != nil && *m.VariantID == 5 &&
+			m.Quantity == 6 && m.UnitCost == 4.5 &&
+			m.Reason == domain.StockReasonPurchaseReceived && m.OccurredAt.Equal(occurredAt)
+	})).Return(nil)
+
+	err := costingService.Handle(context.Background(), &domain.OutboxMessage{
+		ID:         7,
+		UserID:     1,
+		EventType:  domain.EventStockAdjusted,
+		Payload:    `{"product_id":3,"code":"PROD001","previous":2,"stock":8,"delta":6,"reason":"purchase_received","variant_id":5,"unit_cost":4.5}`,
+		OccurredAt: occurredAt,
+	})
+
+	assert.NoError(t, err)
+	mockMovementRepo.AssertExpectations(t)
+}
+
+func TestCostingHandle_IgnoresOtherEvents(t *testing.T) {
+	mockMovementRepo := new(MockInventoryMovementRepo)
+	costingService := service.NewCostingService(mockMovementRepo, new(MockProductRepo), new(MockOrderRepo))
+
+	err := costingService.Handle(context.Background(), &domain.OutboxMessage{ID: 1, EventType: domain.EventOrderCreated})
+
+	assert.NoError(t, err)
+	mockMovementRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestReceivePurchaseOrder_UpdatesMovingAverageCost(t *testing.T) {
+	mockPORepo := new(MockPurchaseOrderRepo)
+	mockProductRepo := new(MockProductRepo)
+	events := &recordingEvents{}
+	poService := service.NewPurchaseOrderService(mockPORepo, new(MockSupplierRepo), mockProductRepo,
+		service.WithPurchaseOrderEvents(events),
+	)
+
+	order := sentPurchaseOrder()
+	product := &domain.Product{ID: 1, UserID: 1, Stock: 2, Cost: 4, CostMethod: domain.CostMethodMovingAverage}
+	mockPORepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(order, nil)
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	mockProductRepo.On("Update", mock.Anything, product, uint(1)).Return(nil)
+	mockPORepo.On("CreateReceipt", mock.Anything, mock.AnythingOfType("*domain.PurchaseOrderReceipt")).Return(nil)
+	mockPORepo.On("Update", mock.Anything, order, uint(1)).Return(nil)
+
+	_, err := poService.ReceivePurchaseOrder(context.Background(), 1, 1, service.ReceivePurchaseOrderRequest{
+		Lines: []service.ReceiveLineRequest{{LineID: 10, Quantity: 6}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4.75, product.Cost)
+	assert.Equal(t, 5.0, events.events[0].(domain.StockAdjusted).UnitCost)
+}
+
+func TestReceivePurchaseOrder_KeepsStandardCost(t *testing.T) {
+	mockPORepo := new(MockPurchaseOrderRepo)
+	mockProductRepo := new(MockProductRepo)
+	events := &recordingEvents{}
+	poService := service.NewPurchaseOrderService(mockPORepo, new(MockSupplierRepo), mockProductRepo,
+		service.WithPurchaseOrderEvents(events),
+	)
+
+	order := sentPurchaseOrder()
+	product := &domain.Product{ID: 1, UserID: 1, Stock: 2, Cost: 4, CostMethod: domain.CostMethodStandard}
+	mockPORepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(order, nil)
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	mockProductRepo.On("Update", mock.Anything, product, uint(1)).Return(nil)
+	mockPORepo.On("CreateReceipt", mock.Anything, mock.AnythingOfType("*domain.PurchaseOrderReceipt")).Return(nil)
+	mockPORepo.On("Update", mock.Anything, order, uint(1)).Return(nil)
+
+	_, err := poService.ReceivePurchaseOrder(context.Background(), 1, 1, service.ReceivePurchaseOrderRequest{
+		Lines: []service.ReceiveLineRequest{{LineID: 10, Quantity: 6}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4.0, product.Cost)
+	assert.Equal(t, 4.0, events.events[0].(domain.StockAdjusted).UnitCost)
+}
+
+func TestCreateOrder_StoresUnitCost(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepo)
+	mockProductRepo := new(MockProductRepo)
+	orderService := service.NewOrderService(mockOrderRepo, mockProductRepo)
+
+	product := &domain.Product{ID: 1, UserID: 1, Name: "Laptop", Price: 10, Cost: 6.5, Stock: 5}
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	mockProductRepo.On("Update", mock.Anything, product, uint(1)).Return(nil)
+	mockOrderRepo.On("Create", mock.Anything, mock.MatchedBy(func(o *domain.Order) bool {
+		return len(o.Items) == 1 && o.Items[0].UnitCost == 6.5
+	})).Return(nil)
+	mockOrderRepo.On("FindByIDAndUserID", mock.Anything, mock.Anything, uint(1)).Return(&domain.Order{ID: 1}, nil)
+
+	_, err := orderService.CreateOrder(context.Background(), 1, service.CreateOrderRequest{
+		Items: []service.OrderItemRequest{{ProductID: 1, Quantity: 2}},
+	})
+
+	assert.NoError(t, err)
+	mockOrderRepo.AssertExpectations(t)
+}
+
+func TestProductUnitCost_BundleSumsComponents(t *testing.T) {
+	bundle := &domain.Product{
+		ID:   1,
+		Cost: 100,
+		Components: []domain.BundleComponent{
+			{ComponentID: 2, Component: domain.Product{ID: 2, Cost: 3.25}, Quantity: 2},
+			{ComponentID: 3, Component: domain.Product{ID: 3, Cost: 7}, Quantity: 1},
+		},
+	}
+
+	assert.Equal(t, 13.5, bundle.UnitCost())
+}
+
+func TestSetCost_Error_Bundle(t *testing.T) {
+	mockProductRepo := new(MockProductRepo)
+	costingService := service.NewCostingService(new(MockInventoryMovementRepo), mockProductRepo, new(MockOrderRepo))
+
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Product{
+		ID:         1,
+		Components: []domain.BundleComponent{{ComponentID: 2, Quantity: 1}},
+	}, nil)
+
+	_, err := costingService.SetCost(context.Background(), 1, 1, service.SetCostRequest{Cost: 5})
+
+	assert.EqualError(t, err, "cost of a bundle is derived from its components")
+	mockProductRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSetCost_SwitchesToStandard(t *testing.T) {
+	mockProductRepo := new(MockProductRepo)
+	costingService := service.NewCostingService(new(MockInventoryMovementRepo), mockProductRepo, new(MockOrderRepo))
+
+	product := &domain.Product{ID: 1, UserID: 1, Cost: 4.75, CostMethod: domain.CostMethodMovingAverage}
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	mockProductRepo.On("Update", mock.Anything, product, uint(1)).Return(nil)
+
+	_, err := costingService.SetCost(context.Background(), 1, 1, service.SetCostRequest{Cost: 5, CostMethod: domain.CostMethodStandard})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, product.Cost)
+	assert.Equal(t, domain.CostMethodStandard, product.CostMethod)
+}
+
+func TestMarginReport_GroupsByProductAndSkipsCancelled(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepo)
+	costingService := service.NewCostingService(new(MockInventoryMovementRepo), new(MockProductRepo), mockOrderRepo)
+
+	laptop := domain.Product{ID: 1, Code: "LAPTOP", Name: "Laptop"}
+	mouse := domain.Product{ID: 2, Code: "MOUSE", Name: "Mouse"}
+	orders := []*domain.Order{
+		{ID: 1, Status: domain.OrderStatusDelivered, Items: []domain.OrderItem{
+			{ProductID: 1, Product: laptop, Quantity: 1, Subtotal: 1000, UnitCost: 700},
+			{ProductID: 2, Product: mouse, Quantity: 4, Subtotal: 80, UnitCost: 12.5},
+		}},
+		{ID: 2, Status: domain.OrderStatusCancelled, Items: []domain.OrderItem{
+			{ProductID: 1, Product: laptop, Quantity: 3, Subtotal: 3000, UnitCost: 700},
+		}},
+		{ID: 3, Status: domain.OrderStatusPending, Items: []domain.OrderItem{
+			{ProductID: 1, Product: laptop, Quantity: 1, Subtotal: 900, UnitCost: 720},
+		}},
+	}
+	mockOrderRepo.On("FindInBatches", mock.Anything, uint(1), domain.OrderFilter{}, mock.Anything).Return([][]*domain.Order{orders}, nil)
+
+	report, err := costingService.MarginReport(context.Background(), 1, service.MarginByProduct, domain.OrderFilter{})
+
+	assert.NoError(t, err)
+	assert.Len(t, report.Lines, 2)
+	assert.Equal(t, "LAPTOP", report.Lines[0].ProductCode)
+	assert.Equal(t, 2, report.Lines[0].Quantity)
+	assert.Equal(t, 1900.0, report.Lines[0].Revenue)
+	assert.Equal(t, 1420.0, report.Lines[0].Cost)
+	assert.Equal(t, 480.0, report.Lines[0].Margin())
+	assert.Equal(t, 25.26, report.Lines[0].MarginPercent())
+	assert.Equal(t, "MOUSE", report.Lines[1].ProductCode)
+	assert.Equal(t, 30.0, report.Lines[1].Margin())
+	assert.Equal(t, 1980.0, report.Total.Revenue)
+	assert.Equal(t, 1470.0, report.Total.Cost)
+}
+
+func TestMarginReport_Error_InvalidGroup(t *testing.T) {
+	costingService := service.NewCostingService(new(MockInventoryMovementRepo), new(MockProductRepo), new(MockOrderRepo))
+
+	_, err := costingService.MarginReport(context.Background(), 1, "week", domain.OrderFilter{})
+
+	assert.EqualError(t, err, "group_by must be order, product, day or month")
+}
+
+// widgetBalance is a widget ledger of 2 units at 3, 10 at 4 and 10 at 6,
+// less 15 sold, and 5 more at 8: 12 on hand, the 5 at 8 and 7 of the 10 at 6
+// under FIFO, and 146/27 a unit on average.
+func widgetBalance() domain.InventoryBalance {
+	return domain.InventoryBalance{ProductID: 1, Code: "WIDGET", Name: "Widget", Quantity: 12, FIFOValue: 82, AverageCost: 146.0 / 27}
+}
+
+func TestInventoryValuation_FIFO(t *testing.T) {
+	mockMovementRepo := new(MockInventoryMovementRepo)
+	costingService := service.NewCostingService(mockMovementRepo, new(MockProductRepo), new(MockOrderRepo))
+
+	asOf := time.Date(2024, 1, 31, 23, 0, 0, 0, time.UTC)
+	mockMovementRepo.On("BalancesAt", mock.Anything, uint(1), asOf).Return([]domain.InventoryBalance{widgetBalance()}, nil)
+
+	valuation, err := costingService.InventoryValuation(context.Background(), 1, asOf, service.ValuationFIFO)
+
+	assert.NoError(t, err)
+	assert.Len(t, valuation.Lines, 1)
+	assert.Equal(t, 12, valuation.Lines[0].Quantity)
+	assert.Equal(t, 82.0, valuation.Lines[0].Value)
+	assert.Equal(t, 6.8333, valuation.Lines[0].UnitCost)
+	assert.Equal(t, 82.0, valuation.Total)
+}
+
+func TestInventoryValuation_WeightedAverage(t *testing.T) {
+	mockMovementRepo := new(MockInventoryMovementRepo)
+	costingService := service.NewCostingService(mockMovementRepo, new(MockProductRepo), new(MockOrderRepo))
+
+	asOf := time.Date(2024, 1, 31, 23, 0, 0, 0, time.UTC)
+	mockMovementRepo.On("BalancesAt", mock.Anything, uint(1), asOf).Return([]domain.InventoryBalance{widgetBalance()}, nil)
+
+	valuation, err := costingService.InventoryValuation(context.Background(), 1, asOf, service.ValuationWeightedAverage)
+
+	assert.NoError(t, err)
+	assert.Len(t, valuation.Lines, 1)
+	assert.Equal(t, 12, valuation.Lines[0].Quantity)
+	assert.Equal(t, 64.89, valuation.Lines[0].Value)
+	assert.Equal(t, 5.4074, valuation.Lines[0].UnitCost)
+}
+
+func TestInventoryValuation_NegativeStockHasNoValue(t *testing.T) {
+	mockMovementRepo := new(MockInventoryMovementRepo)
+	costingService := service.NewCostingService(mockMovementRepo, new(MockProductRepo), new(MockOrderRepo))
+
+	asOf := time.Date(2024, 1, 31, 23, 0, 0, 0, time.UTC)
+	mockMovementRepo.On("BalancesAt", mock.Anything, uint(1), asOf).Return([]domain.InventoryBalance{
+		{ProductID: 2, Code: "OVERSOLD", Quantity: -3, AverageCost: 5},
+		widgetBalance(),
+	}, nil)
+
+	valuation, err := costingService.InventoryValuation(context.Background(), 1, asOf, service.ValuationWeightedAverage)
+
+	assert.NoError(t, err)
+	assert.Len(t, valuation.Lines, 2)
+	assert.Equal(t, -3, valuation.Lines[0].Quantity)
+	assert.Equal(t, 0.0, valuation.Lines[0].Value)
+	assert.Equal(t, 64.89, valuation.Total)
+}
+
+func TestInventoryValuation_Error_InvalidMethod(t *testing.T) {
+	costingService := service.NewCostingService(new(MockInventoryMovementRepo), new(MockProductRepo), new(MockOrderRepo))
+
+	_, err := costingService.InventoryValuation(context.Background(), 1, time.Now(), "lifo")
+
+	assert.EqualError(t, err, "method must be fifo or weighted_average")
+}
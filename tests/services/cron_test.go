@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"vertice-backend/pkg/cron"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronNext(t *testing.T) {
+	// 2024-01-15 10:30 is a Monday.
+	from := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 8 * * *", time.Date(2024, 1, 16, 8, 0, 0, 0, time.UTC)},
+		{"0 8 * * mon-fri", time.Date(2024, 1, 16, 8, 0, 0, 0, time.UTC)},
+		{"0 9 * * sat,sun", time.Date(2024, 1, 20, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)},
+		{"30 6 1 * *", time.Date(2024, 2, 1, 6, 30, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Both days restricted: either matches.
+		{"0 0 20 * 3", time.Date(2024, 1, 17, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		schedule, err := cron.Parse(tt.expr)
+		if assert.NoError(t, err, tt.expr) {
+			assert.Equal(t, tt.want, schedule.Next(from), tt.expr)
+		}
+	}
+}
+
+func TestCronNext_KeepsLocation(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	assert.NoError(t, err)
+	schedule, err := cron.Parse("0 8 * * *")
+	assert.NoError(t, err)
+
+	// The clocks go forward on 2024-03-31, so 08:00 is 06:00 UTC from then on.
+	next := schedule.Next(time.Date(2024, 3, 30, 9, 0, 0, 0, madrid))
+
+	assert.Equal(t, time.Date(2024, 3, 31, 8, 0, 0, 0, madrid), next)
+	assert.Equal(t, 6, next.UTC().Hour())
+}
+
+func TestCronNext_NeverFires(t *testing.T) {
+	schedule, err := cron.Parse("0 0 30 2 *")
+	assert.NoError(t, err)
+
+	assert.True(t, schedule.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero())
+}
+
+func TestCronParse_Errors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		_, err := cron.Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCustomerRepo struct {
+	mock.Mock
+}
+
+func (m *MockCustomerRepo) Create(ctx context.Context, customer *domain.Customer) error {
+	args := m.Called(ctx, customer)
+	return args.Error(0)
+}
+
+func (m *MockCustomerRepo) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.Customer, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Customer), args.Error(1)
+}
+
+func (m *MockCustomerRepo) FindByUserID(ctx context.Context, userID uint) ([]*domain.Customer, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Customer), args.Error(1)
+}
+
+func (m *MockCustomerRepo) Update(ctx context.Context, customer *domain.Customer, userID uint) error {
+	args := m.Called(ctx, customer, userID)
+	return args.Error(0)
+}
+
+func (m *MockCustomerRepo) Delete(ctx context.Context, id, userID uint) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+func (m *MockCustomerRepo) CreateGroup(ctx context.Context, group *domain.CustomerGroup) error {
+	args := m.Called(ctx, group)
+	return args.Error(0)
+}
+
+func (m *MockCustomerRepo) FindGroupByIDAndUserID(ctx context.Context, id, userID uint) (*domain.CustomerGroup, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CustomerGroup), args.Error(1)
+}
+
+func (m *MockCustomerRepo) FindGroupByNameAndUserID(ctx context.Context, name string, userID uint) (*domain.CustomerGroup, error) {
+	args := m.Called(ctx, name, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CustomerGroup), args.Error(1)
+}
+
+func (m *MockCustomerRepo) FindGroupsByUserID(ctx context.Context, userID uint) ([]*domain.CustomerGroup, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.CustomerGroup), args.Error(1)
+}
+
+func (m *MockCustomerRepo) DeleteGroup(ctx context.Context, id, userID uint) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+func wholesaleCustomer() *domain.Customer {
+	return &domain.Customer{ID: 4, UserID: 1, Name: "Acme Retail", GroupID: uintPtr(2), Group: &domain.CustomerGroup{ID: 2, UserID: 1, Name: "Wholesale"}}
+}
+
+func TestCreateCustomer_InGroup(t *testing.T) {
+	customerRepo := new(MockCustomerRepo)
+	customerService := service.NewCustomerService(customerRepo)
+
+	customerRepo.On("FindGroupByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(&domain.CustomerGroup{ID: 2, UserID: 1, Name: "Wholesale"}, nil)
+	customerRepo.On("FindGroupByIDAndUserID", mock.Anything, uint(9), uint(1)).Return(nil, errors.New("record not found"))
+	customerRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Customer")).Return(nil)
+
+	customer, err := customerService.CreateCustomer(context.Background(), 1, service.CustomerRequest{Name: " Acme Retail ", GroupID: uintPtr(2)})
+	assert.NoError(t, err)
+	assert.Equal(t, "Acme Retail", customer.Name)
+	assert.Equal(t, uint(2), *customer.GroupID)
+
+	_, err = customerService.CreateCustomer(context.Background(), 1, service.CustomerRequest{Name: "Other", GroupID: uintPtr(9)})
+	assert.EqualError(t, err, "customer group not found")
+
+	_, err = customerService.CreateCustomer(context.Background(), 1, service.CustomerRequest{Name: " "})
+	assert.EqualError(t, err, "name is required")
+}
+
+func TestUpdateCustomer_GroupZeroLeavesGroup(t *testing.T) {
+	customerRepo := new(MockCustomerRepo)
+	customerService := service.NewCustomerService(customerRepo)
+
+	customer := wholesaleCustomer()
+	customerRepo.On("FindByIDAndUserID", mock.Anything, uint(4), uint(1)).Return(customer, nil)
+	customerRepo.On("Update", mock.Anything, customer, uint(1)).Return(nil)
+
+	updated, err := customerService.UpdateCustomer(context.Background(), 4, 1, service.UpdateCustomerRequest{GroupID: uintPtr(0)})
+
+	assert.NoError(t, err)
+	assert.Nil(t, updated.GroupID)
+	assert.Nil(t, updated.Group)
+}
+
+func TestCreateGroup_RejectsDuplicateName(t *testing.T) {
+	customerRepo := new(MockCustomerRepo)
+	customerService := service.NewCustomerService(customerRepo)
+
+	customerRepo.On("FindGroupByNameAndUserID", mock.Anything, "Wholesale", uint(1)).Return(&domain.CustomerGroup{ID: 2}, nil)
+
+	_, err := customerService.CreateGroup(context.Background(), 1, "Wholesale")
+
+	assert.EqualError(t, err, "customer group name already exists for this user")
+	customerRepo.AssertNotCalled(t, "CreateGroup", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,213 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockEmailLogRepo struct {
+	mock.Mock
+}
+
+func (m *MockEmailLogRepo) Create(ctx context.Context, entry *domain.EmailLog) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockEmailLogRepo) FindByEventID(ctx context.Context, eventID uint) (*domain.EmailLog, error) {
+	args := m.Called(ctx, eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.EmailLog), args.Error(1)
+}
+
+func (m *MockEmailLogRepo) FindByUserID(ctx context.Context, userID uint, limit int) ([]*domain.EmailLog, error) {
+	args := m.Called(ctx, userID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.EmailLog), args.Error(1)
+}
+
+type MockNotificationPreferencesRepo struct {
+	mock.Mock
+}
+
+func (m *MockNotificationPreferencesRepo) FindByUserID(ctx context.Context, userID uint) (*domain.NotificationPreferences, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.NotificationPreferences), args.Error(1)
+}
+
+func (m *MockNotificationPreferencesRepo) Save(ctx context.Context, preferences *domain.NotificationPreferences) error {
+	args := m.Called(ctx, preferences)
+	return args.Error(0)
+}
+
+// emailServiceMocks builds an EmailService whose user 1 is John with no
+// saved preferences and no emails logged yet.
+func emailServiceMocks(m *recordingMailer) (*service.EmailService, *MockEmailLogRepo, *MockNotificationPreferencesRepo, *MockOrderRepo) {
+	logs := new(MockEmailLogRepo)
+	preferences := new(MockNotificationPreferencesRepo)
+	users := new(MockUserRepo)
+	orders := new(MockOrderRepo)
+	users.On("FindByID", mock.Anything, uint(1)).Return(&domain.User{ID: 1, Name: "John", Email: "john@example.com"}, nil)
+	logs.On("FindByEventID", mock.Anything, mock.Anything).Return(nil, errors.New("not found"))
+	return service.NewEmailService(logs, preferences, users, orders, service.WithEmailMailer(m)), logs, preferences, orders
+}
+
+func emailOrder() *domain.Order {
+	return &domain.Order{
+		ID:          42,
+		UserID:      1,
+		Status:      domain.OrderStatusPending,
+		TotalAmount: 59.97,
+		Customer:    &domain.Customer{Name: "Acme"},
+		Items: []domain.OrderItem{
+			{Quantity: 3, Subtotal: 59.97, Product: domain.Product{Code: "MOUSE", Name: "Mouse"}},
+		},
+	}
+}
+
+func TestEmailHandle_Welcome(t *testing.T) {
+	m := &recordingMailer{}
+	emailService, logs, preferences, _ := emailServiceMocks(m)
+
+	preferences.On("FindByUserID", mock.Anything, uint(1)).Return(nil, errors.New("not found"))
+	logs.On("Create", mock.Anything, mock.MatchedBy(func(e *domain.EmailLog) bool {
+		return e.Kind == domain.EmailWelcome && e.Status == domain.EmailSent && *e.EventID == 5 && e.Recipients == "john@example.com"
+	})).Return(nil)
+
+	err := emailService.Handle(context.Background(), &domain.OutboxMessage{
+		ID: 5, UserID: 1, EventType: domain.EventUserRegistered,
+		Payload: `{"user_id":1,"name":"John","email":"john@example.com"}`,
+	})
+
+	assert.NoError(t, err)
+	if assert.Len(t, m.sent, 1) {
+		assert.Equal(t, "Welcome to Vertice", m.sent[0].Subject)
+		assert.Contains(t, m.sent[0].Text, "Hi John,")
+		assert.Contains(t, m.sent[0].HTML, "<strong>john@example.com</strong>")
+	}
+	logs.AssertExpectations(t)
+}
+
+func TestEmailHandle_OrderCreated(t *testing.T) {
+	m := &recordingMailer{}
+	emailService, logs, preferences, orders := emailServiceMocks(m)
+
+	preferences.On("FindByUserID", mock.Anything, uint(1)).Return(nil, errors.New("not found"))
+	orders.On("FindByIDAndUserID", mock.Anything, uint(42), uint(1)).Return(emailOrder(), nil)
+	logs.On("Create", mock.Anything, mock.AnythingOfType("*domain.EmailLog")).Return(nil)
+
+	err := emailService.Handle(context.Background(), &domain.OutboxMessage{
+		ID: 6, UserID: 1, EventType: domain.EventOrderCreated, Payload: `{"order_id":42}`,
+	})
+
+	assert.NoError(t, err)
+	if assert.Len(t, m.sent, 1) {
+		assert.Equal(t, "Order #42 placed", m.sent[0].Subject)
+		assert.Contains(t, m.sent[0].Text, "Order #42 for Acme was placed, for a total of 59.97.")
+		assert.Contains(t, m.sent[0].Text, "- 3 x Mouse (MOUSE): 59.97")
+	}
+}
+
+func TestEmailHandle_Cancelled(t *testing.T) {
+	m := &recordingMailer{}
+	emailService, logs, preferences, orders := emailServiceMocks(m)
+
+	preferences.On("FindByUserID", mock.Anything, uint(1)).Return(nil, errors.New("not found"))
+	orders.On("FindByIDAndUserID", mock.Anything, uint(42), uint(1)).Return(emailOrder(), nil)
+	logs.On("Create", mock.Anything, mock.MatchedBy(func(e *domain.EmailLog) bool {
+		return e.Kind == domain.EmailOrderCancelled && e.Status == domain.EmailSent
+	})).Return(nil)
+
+	err := emailService.Handle(context.Background(), &domain.OutboxMessage{
+		ID: 7, UserID: 1, EventType: domain.EventOrderStatusChanged, Payload: `{"order_id":42,"from":"confirmed","to":"cancelled"}`,
+	})
+
+	assert.NoError(t, err)
+	if assert.Len(t, m.sent, 1) {
+		assert.Equal(t, "Order #42 cancelled", m.sent[0].Subject)
+		assert.Contains(t, m.sent[0].Text, "was cancelled while confirmed")
+	}
+	logs.AssertExpectations(t)
+}
+
+func TestEmailHandle_SkipsOptedOutEmails(t *testing.T) {
+	m := &recordingMailer{}
+	emailService, logs, preferences, orders := emailServiceMocks(m)
+
+	prefs := domain.DefaultNotificationPreferences(1)
+	prefs.OrderStatusChanged = false
+	preferences.On("FindByUserID", mock.Anything, uint(1)).Return(prefs, nil)
+	orders.On("FindByIDAndUserID", mock.Anything, uint(42), uint(1)).Return(emailOrder(), nil)
+	logs.On("Create", mock.Anything, mock.MatchedBy(func(e *domain.EmailLog) bool {
+		return e.Kind == domain.EmailOrderStatusChanged && e.Status == domain.EmailSkipped
+	})).Return(nil)
+
+	err := emailService.Handle(context.Background(), &domain.OutboxMessage{
+		ID: 8, UserID: 1, EventType: domain.EventOrderStatusChanged, Payload: `{"order_id":42,"from":"pending","to":"shipped"}`,
+	})
+
+	assert.NoError(t, err)
+	assert.Empty(t, m.sent)
+	logs.AssertExpectations(t)
+}
+
+func TestEmailHandle_LogsFailure(t *testing.T) {
+	m := &recordingMailer{err: errors.New("connection refused")}
+	emailService, logs, preferences, _ := emailServiceMocks(m)
+
+	preferences.On("FindByUserID", mock.Anything, uint(1)).Return(nil, errors.New("not found"))
+	logs.On("Create", mock.Anything, mock.MatchedBy(func(e *domain.EmailLog) bool {
+		return e.Status == domain.EmailFailed && e.Error == "connection refused"
+	})).Return(nil)
+
+	err := emailService.Handle(context.Background(), &domain.OutboxMessage{ID: 9, UserID: 1, EventType: domain.EventUserRegistered, Payload: `{}`})
+
+	assert.NoError(t, err)
+	logs.AssertExpectations(t)
+}
+
+func TestEmailHandle_IgnoresRedeliveredEvent(t *testing.T) {
+	logs := new(MockEmailLogRepo)
+	m := &recordingMailer{}
+	emailService := service.NewEmailService(logs, new(MockNotificationPreferencesRepo), new(MockUserRepo), new(MockOrderRepo), service.WithEmailMailer(m))
+
+	eventID := uint(5)
+	logs.On("FindByEventID", mock.Anything, uint(5)).Return(&domain.EmailLog{EventID: &eventID}, nil)
+
+	err := emailService.Handle(context.Background(), &domain.OutboxMessage{ID: 5, UserID: 1, EventType: domain.EventUserRegistered, Payload: `{}`})
+
+	assert.NoError(t, err)
+	assert.Empty(t, m.sent)
+	logs.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUpdateNotificationPreferences_StartsFromDefaults(t *testing.T) {
+	preferences := new(MockNotificationPreferencesRepo)
+	emailService := service.NewEmailService(new(MockEmailLogRepo), preferences, new(MockUserRepo), new(MockOrderRepo))
+
+	preferences.On("FindByUserID", mock.Anything, uint(1)).Return(nil, errors.New("not found"))
+	preferences.On("Save", mock.Anything, mock.AnythingOfType("*domain.NotificationPreferences")).Return(nil)
+
+	off := false
+	prefs, err := emailService.UpdatePreferences(context.Background(), 1, service.UpdateNotificationPreferencesRequest{OrderCreated: &off})
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), prefs.UserID)
+	assert.False(t, prefs.OrderCreated)
+	assert.True(t, prefs.OrderStatusChanged)
+	assert.True(t, prefs.OrderCancelled)
+}
@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/pkg/xlsx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func exportOrders() []*domain.Order {
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	variantID := uint(5)
+	return []*domain.Order{{
+		ID: 10, UserID: 1, Status: domain.OrderStatusConfirmed, TotalAmount: 34, CreatedAt: created,
+		Items: []domain.OrderItem{
+			{ID: 20, ProductID: 1, Product: domain.Product{Code: "MUG", Name: "Mug"}, Quantity: 2, UnitPrice: 5, Subtotal: 10},
+			{ID: 21, ProductID: 2, Product: domain.Product{Code: "TSHIRT", Name: "T-Shirt"}, VariantID: &variantID,
+				Variant: &domain.ProductVariant{ID: 5, Code: "TSHIRT-RED"}, Quantity: 2, UnitPrice: 12, Subtotal: 24},
+		},
+	}}
+}
+
+func TestExportProducts_CSVWithSelectedColumns(t *testing.T) {
+	productRepo := new(MockProductRepo)
+	svc := service.NewExportService(productRepo, new(MockOrderRepo))
+
+	batches := [][]*domain.Product{
+		{{ID: 1, Code: "MUG", Name: "Mug, large", Price: 5.5, Stock: 3}},
+		{{ID: 2, Code: "CUP", Name: "Cup", Price: 2, Stock: 0, Tags: []domain.Tag{{Name: "kitchen"}, {Name: "sale"}}}},
+	}
+	productRepo.On("FindInBatches", mock.Anything, uint(1), domain.ProductFilter{Tag: "kitchen"}, mock.Anything).Return(batches, nil)
+
+	var buf bytes.Buffer
+	err := svc.ExportProducts(context.Background(), 1, &buf, service.ExportRequest{
+		Format:  "csv",
+		Columns: []string{"code", "name", "price", "stock", "tags"},
+	}, service.ProductListFilter{Tag: "Kitchen"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "code,name,price,stock,tags\nMUG,\"Mug, large\",5.5,3,\nCUP,Cup,2,0,kitchen; sale\n", buf.String())
+}
+
+func TestExportProducts_XLSXReadsBack(t *testing.T) {
+	productRepo := new(MockProductRepo)
+	svc := service.NewExportService(productRepo, new(MockOrderRepo))
+
+	batches := [][]*domain.Product{{{ID: 1, Code: "007", Name: "Fish & <Chips>", Price: 1.25, Stock: 4}}}
+	productRepo.On("FindInBatches", mock.Anything, uint(1), domain.ProductFilter{}, mock.Anything).Return(batches, nil)
+
+	var buf bytes.Buffer
+	err := svc.ExportProducts(context.Background(), 1, &buf, service.ExportRequest{
+		Format:  "xlsx",
+		Columns: []string{"code", "name", "price", "stock"},
+	}, service.ProductListFilter{})
+	assert.NoError(t, err)
+
+	rows, err := xlsx.Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"code", "name", "price", "stock"}, {"007", "Fish & <Chips>", "1.25", "4"}}, rows)
+}
+
+func TestExportOrderLines_NDJSON(t *testing.T) {
+	orderRepo := new(MockOrderRepo)
+	svc := service.NewExportService(new(MockProductRepo), orderRepo)
+
+	filter := domain.OrderFilter{Status: domain.OrderStatusConfirmed}
+	orderRepo.On("FindInBatches", mock.Anything, uint(1), filter, mock.Anything).Return([][]*domain.Order{exportOrders()}, nil)
+
+	var buf bytes.Buffer
+	err := svc.ExportOrderLines(context.Background(), 1, &buf, service.ExportRequest{
+		Format:  "ndjson",
+		Columns: []string{"order_id", "order_created_at", "product_code", "variant_id", "subtotal"},
+	}, filter)
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`{"order_id":10,"order_created_at":"2024-03-01T09:30:00Z","product_code":"MUG","variant_id":null,"subtotal":10}`+"\n"+
+			`{"order_id":10,"order_created_at":"2024-03-01T09:30:00Z","product_code":"TSHIRT","variant_id":5,"subtotal":24}`+"\n",
+		buf.String())
+}
+
+func TestExportOrders_AllColumns(t *testing.T) {
+	orderRepo := new(MockOrderRepo)
+	svc := service.NewExportService(new(MockProductRepo), orderRepo)
+	orderRepo.On("FindInBatches", mock.Anything, uint(1), domain.OrderFilter{}, mock.Anything).Return([][]*domain.Order{exportOrders()}, nil)
+
+	var buf bytes.Buffer
+	err := svc.ExportOrders(context.Background(), 1, &buf, service.ExportRequest{Format: "csv"}, domain.OrderFilter{})
+
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, []string{
+		"id,status,total_amount,lines,units,created_at,updated_at",
+		"10,confirmed,34,2,4,2024-03-01T09:30:00Z,0001-01-01T00:00:00Z",
+	}, lines)
+}
+
+func TestExport_InvalidRequestsWriteNothing(t *testing.T) {
+	productRepo := new(MockProductRepo)
+	orderRepo := new(MockOrderRepo)
+	svc := service.NewExportService(productRepo, orderRepo)
+	var buf bytes.Buffer
+
+	err := svc.ExportProducts(context.Background(), 1, &buf, service.ExportRequest{Format: "csv", Columns: []string{"code", "weight"}}, service.ProductListFilter{})
+	assert.ErrorContains(t, err, `unknown column "weight"`)
+
+	err = svc.ExportProducts(context.Background(), 1, &buf, service.ExportRequest{Format: "csv", Columns: []string{"code", "code"}}, service.ProductListFilter{})
+	assert.EqualError(t, err, `column "code" is selected twice`)
+
+	err = svc.ExportOrders(context.Background(), 1, &buf, service.ExportRequest{Format: "csv"}, domain.OrderFilter{Status: "lost"})
+	assert.EqualError(t, err, "invalid order status")
+
+	err = svc.ExportProducts(context.Background(), 1, &buf, service.ExportRequest{Format: "csv"}, service.ProductListFilter{Category: "shoes"})
+	assert.EqualError(t, err, "categories are not available")
+
+	productRepo.On("FindInBatches", mock.Anything, uint(1), domain.ProductFilter{}, mock.Anything).Return(nil, nil)
+	err = svc.ExportProducts(context.Background(), 1, &buf, service.ExportRequest{Format: "pdf"}, service.ProductListFilter{})
+	assert.ErrorContains(t, err, "unsupported export format")
+
+	assert.Zero(t, buf.Len())
+	productRepo.AssertNotCalled(t, "FindInBatches", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	orderRepo.AssertNotCalled(t, "FindInBatches", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestExport_BatchErrorIsReturned(t *testing.T) {
+	orderRepo := new(MockOrderRepo)
+	svc := service.NewExportService(new(MockProductRepo), orderRepo)
+	orderRepo.On("FindInBatches", mock.Anything, uint(1), domain.OrderFilter{}, mock.Anything).Return([][]*domain.Order{exportOrders()}, errors.New("connection reset"))
+
+	var buf bytes.Buffer
+	err := svc.ExportOrders(context.Background(), 1, &buf, service.ExportRequest{Format: "csv"}, domain.OrderFilter{})
+
+	assert.EqualError(t, err, "connection reset")
+}
+
+func TestListOrders_Filter(t *testing.T) {
+	orderRepo := new(MockOrderRepo)
+	svc := service.NewOrderService(orderRepo, new(MockProductRepo))
+
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	filter := domain.OrderFilter{Status: domain.OrderStatusShipped, From: &from, To: &to}
+	orderRepo.On("FindByFilter", mock.Anything, uint(1), filter).Return(exportOrders(), nil)
+
+	orders, err := svc.ListOrders(context.Background(), 1, filter)
+	assert.NoError(t, err)
+	assert.Len(t, orders, 1)
+
+	_, err = svc.ListOrders(context.Background(), 1, domain.OrderFilter{From: &to, To: &from})
+	assert.EqualError(t, err, "from must be before to")
+}
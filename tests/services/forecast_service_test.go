@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// daysAgo returns the start of the UTC day n days before today.
+func daysAgo(n int) time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day()-n, 0, 0, 0, 0, time.UTC)
+}
+
+func TestForecast_ProjectsStockOutFromAverageSales(t *testing.T) {
+	mockAnalyticsRepo := new(MockAnalyticsRepo)
+	mockProductRepo := new(MockProductRepo)
+	mockPORepo := new(MockPurchaseOrderRepo)
+	forecastService := service.NewForecastService(mockAnalyticsRepo, mockProductRepo, new(MockSupplierRepo), mockPORepo)
+
+	mockProductRepo.On("FindByUserID", mock.Anything, uint(1)).Return([]*domain.Product{
+		{ID: 1, Code: "LAPTOP", Name: "Laptop", Stock: 10},
+		{ID: 2, Code: "CABLE", Name: "Cable", Stock: 40},
+	}, nil)
+	mockAnalyticsRepo.On("DailyProductSales", mock.Anything, uint(1), daysAgo(28)).Return([]domain.DailyProductSales{
+		{ProductID: 1, Day: daysAgo(10), Quantity: 28},
+		{ProductID: 1, Day: daysAgo(1), Quantity: 28},
+		// Today is not over yet and does not count.
+		{ProductID: 1, Day: daysAgo(0), Quantity: 100},
+	}, nil)
+	mockPORepo.On("FindByUserID", mock.Anything, uint(1), domain.PurchaseOrderStatusSent).Return([]*domain.PurchaseOrder{
+		{Lines: []domain.PurchaseOrderLine{{ProductID: 1, QuantityOrdered: 4}}},
+	}, nil)
+	mockPORepo.On("FindByUserID", mock.Anything, uint(1), domain.PurchaseOrderStatusPartiallyReceived).Return([]*domain.PurchaseOrder{}, nil)
+
+	forecast, err := forecastService.Forecast(context.Background(), 1, service.ForecastRequest{})
+
+	assert.NoError(t, err)
+	assert.Len(t, forecast.Lines, 2)
+	laptop := forecast.Lines[0]
+	assert.Equal(t, "LAPTOP", laptop.Code)
+	assert.Equal(t, 2.0, laptop.DailySales)
+	assert.Equal(t, 5.0, *laptop.DaysOfCover)
+	assert.Equal(t, daysAgo(-5), *laptop.StockOutDate)
+	assert.Equal(t, 4, laptop.OnOrder)
+	assert.Equal(t, 60, laptop.SuggestedQuantity)
+	assert.True(t, laptop.AtRisk())
+	cable := forecast.Lines[1]
+	assert.Nil(t, cable.DaysOfCover)
+	assert.Nil(t, cable.StockOutDate)
+	assert.Equal(t, 0, cable.SuggestedQuantity)
+	assert.Nil(t, forecast.PurchaseOrder)
+}
+
+func TestForecast_ExponentialSmoothingWeighsRecentSales(t *testing.T) {
+	mockAnalyticsRepo := new(MockAnalyticsRepo)
+	mockProductRepo := new(MockProductRepo)
+	mockPORepo := new(MockPurchaseOrderRepo)
+	forecastService := service.NewForecastService(mockAnalyticsRepo, mockProductRepo, new(MockSupplierRepo), mockPORepo)
+
+	mockProductRepo.On("FindByUserID", mock.Anything, uint(1)).Return([]*domain.Product{{ID: 1, Code: "LAPTOP", Stock: 20}}, nil)
+	mockAnalyticsRepo.On("DailyProductSales", mock.Anything, uint(1), daysAgo(4)).Return([]domain.DailyProductSales{
+		{ProductID: 1, Day: daysAgo(1), Quantity: 8},
+	}, nil)
+	mockPORepo.On("FindByUserID", mock.Anything, uint(1), mock.Anything).Return([]*domain.PurchaseOrder{}, nil)
+
+	alpha := 0.5
+	forecast, err := forecastService.Forecast(context.Background(), 1, service.ForecastRequest{WindowDays: 4, Alpha: &alpha})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, forecast.Lines[0].AverageDailySales)
+	assert.Equal(t, 4.0, forecast.Lines[0].DailySales)
+	assert.Equal(t, 5.0, *forecast.Lines[0].DaysOfCover)
+}
+
+func TestForecast_PrefillsPurchaseOrderForSupplier(t *testing.T) {
+	mockAnalyticsRepo := new(MockAnalyticsRepo)
+	mockProductRepo := new(MockProductRepo)
+	mockSupplierRepo := new(MockSupplierRepo)
+	mockPORepo := new(MockPurchaseOrderRepo)
+	forecastService := service.NewForecastService(mockAnalyticsRepo, mockProductRepo, mockSupplierRepo, mockPORepo)
+
+	supplierID := uint(3)
+	mockSupplierRepo.On("FindByIDAndUserID", mock.Anything, supplierID, uint(1)).Return(&domain.Supplier{ID: supplierID}, nil)
+	mockSupplierRepo.On("FindProducts", mock.Anything, supplierID, uint(1)).Return([]*domain.SupplierProduct{
+		{ProductID: 1, LeadTimeDays: 10},
+	}, nil)
+	mockProductRepo.On("FindByUserID", mock.Anything, uint(1)).Return([]*domain.Product{
+		productWithVariants(),
+		{ID: 2, Code: "MUG", Stock: 0},
+	}, nil)
+	variantID := uint(5)
+	mockAnalyticsRepo.On("DailyProductSales", mock.Anything, uint(1), daysAgo(28)).Return([]domain.DailyProductSales{
+		{ProductID: 1, VariantID: &variantID, Day: daysAgo(3), Quantity: 28},
+		{ProductID: 2, Day: daysAgo(3), Quantity: 50},
+	}, nil)
+	mockPORepo.On("FindByUserID", mock.Anything, uint(1), mock.Anything).Return([]*domain.PurchaseOrder{}, nil)
+
+	forecast, err := forecastService.Forecast(context.Background(), 1, service.ForecastRequest{SupplierID: &supplierID})
+
+	assert.NoError(t, err)
+	assert.Len(t, forecast.Lines, 2)
+	red := forecast.Lines[0]
+	assert.Equal(t, "TSHIRT-RED", red.VariantCode)
+	assert.Equal(t, 10, red.LeadTimeDays)
+	assert.Equal(t, 37, red.SuggestedQuantity)
+	assert.Equal(t, 0, forecast.Lines[1].SuggestedQuantity)
+	assert.Equal(t, &service.PurchaseOrderRequest{
+		SupplierID: supplierID,
+		Lines:      []service.PurchaseOrderLineRequest{{ProductID: 1, VariantID: &variantID, Quantity: 37}},
+	}, forecast.PurchaseOrder)
+}
+
+func TestForecast_Error_SupplierNotFound(t *testing.T) {
+	mockSupplierRepo := new(MockSupplierRepo)
+	forecastService := service.NewForecastService(new(MockAnalyticsRepo), new(MockProductRepo), mockSupplierRepo, new(MockPurchaseOrderRepo))
+
+	supplierID := uint(9)
+	mockSupplierRepo.On("FindByIDAndUserID", mock.Anything, supplierID, uint(1)).Return(nil, errors.New("not found"))
+
+	_, err := forecastService.Forecast(context.Background(), 1, service.ForecastRequest{SupplierID: &supplierID})
+
+	assert.EqualError(t, err, "supplier not found")
+}
+
+func TestForecast_Error_InvalidParameters(t *testing.T) {
+	forecastService := service.NewForecastService(new(MockAnalyticsRepo), new(MockProductRepo), new(MockSupplierRepo), new(MockPurchaseOrderRepo))
+
+	alpha := 1.5
+	_, err := forecastService.Forecast(context.Background(), 1, service.ForecastRequest{Alpha: &alpha})
+	assert.EqualError(t, err, "alpha must be greater than 0 and at most 1")
+
+	_, err = forecastService.Forecast(context.Background(), 1, service.ForecastRequest{WindowDays: 400})
+	assert.EqualError(t, err, "window_days must be between 1 and 365")
+}
@@ -0,0 +1,317 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockImportJobRepo struct {
+	mock.Mock
+}
+
+func (m *MockImportJobRepo) Create(ctx context.Context, job *domain.ImportJob) error {
+	args := m.Called(ctx, job)
+	job.ID = 1
+	return args.Error(0)
+}
+
+func (m *MockImportJobRepo) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.ImportJob, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ImportJob), args.Error(1)
+}
+
+func (m *MockImportJobRepo) Update(ctx context.Context, job *domain.ImportJob) error {
+	args := m.Called(ctx, job)
+	return args.Error(0)
+}
+
+func (m *MockImportJobRepo) CreateErrors(ctx context.Context, errors []domain.ImportJobError) error {
+	args := m.Called(ctx, errors)
+	return args.Error(0)
+}
+
+func (m *MockImportJobRepo) FindErrors(ctx context.Context, jobID uint) ([]domain.ImportJobError, error) {
+	args := m.Called(ctx, jobID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ImportJobError), args.Error(1)
+}
+
+func (m *MockImportJobRepo) FailStale(ctx context.Context, before time.Time, message string) (int64, error) {
+	args := m.Called(ctx, before, message)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func newImportJobRepo() *MockImportJobRepo {
+	jobRepo := new(MockImportJobRepo)
+	jobRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	jobRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+	return jobRepo
+}
+
+// xlsxFile builds a workbook whose first sheet holds the rows as shared strings.
+func xlsxFile(t *testing.T, rows [][]string) []byte {
+	var sheet, shared bytes.Buffer
+	count := 0
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for _, row := range rows {
+		sheet.WriteString("<row>")
+		for _, value := range row {
+			sheet.WriteString(`<c t="s"><v>` + string(rune('0'+count/10)) + string(rune('0'+count%10)) + `</v></c>`)
+			shared.WriteString("<si><t>" + value + "</t></si>")
+			count++
+		}
+		sheet.WriteString("</row>")
+	}
+	sheet.WriteString("</sheetData></worksheet>")
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	parts := map[string]string{
+		"xl/workbook.xml":            `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Products" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml":   sheet.String(),
+		"xl/sharedStrings.xml":       `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` + shared.String() + `</sst>`,
+	}
+	for name, content := range parts {
+		w, err := archive.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, archive.Close())
+	return buf.Bytes()
+}
+
+func TestImport_CSVCreatesAndUpdatesProducts(t *testing.T) {
+	productRepo := new(MockProductRepo)
+	jobRepo := newImportJobRepo()
+	events := &recordingEvents{}
+	svc := service.NewImportService(productRepo, jobRepo, service.WithImportEvents(events))
+
+	existing := &domain.Product{ID: 7, UserID: 1, Code: "P-2", Name: "Old name", Price: 5, Stock: 10}
+	productRepo.On("FindByCodeAndUserID", mock.Anything, "P-1", uint(1)).Return(nil, errors.New("not found"))
+	productRepo.On("FindByCodeAndUserID", mock.Anything, "P-2", uint(1)).Return(existing, nil)
+	productRepo.On("Create", mock.Anything, mock.MatchedBy(func(p *domain.Product) bool {
+		return p.Code == "P-1" && p.Name == "Mug" && p.Price == 9.5 && p.Cost == 2.5 && p.Stock == 3
+	})).Return(nil)
+	productRepo.On("Update", mock.Anything, existing, uint(1)).Return(nil)
+	jobRepo.On("CreateErrors", mock.Anything, []domain.ImportJobError(nil)).Return(nil)
+
+	body := "\xef\xbb\xbfSKU;Name;Price;Cost;Stock\nP-1;Mug;9.5;2.5;3\nP-2;;;;4\n"
+	job, err := svc.Import(context.Background(), 1, service.ImportRequest{Filename: "catalog.csv", Body: []byte(body)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ImportJobCompleted, job.Status)
+	assert.Equal(t, "csv", job.Format)
+	assert.Equal(t, 2, job.TotalRows)
+	assert.Equal(t, 1, job.Created)
+	assert.Equal(t, 1, job.Updated)
+	assert.NotNil(t, job.FinishedAt)
+	// Blank cells keep the current values.
+	assert.Equal(t, "Old name", existing.Name)
+	assert.Equal(t, 5.0, existing.Price)
+	assert.Equal(t, 4, existing.Stock)
+	if assert.Len(t, events.events, 2) {
+		created := events.events[0].(domain.StockAdjusted)
+		assert.Equal(t, "P-1", created.Code)
+		assert.Equal(t, 3, created.Delta)
+		assert.Equal(t, 2.5, created.UnitCost)
+		assert.Equal(t, domain.StockReasonImport, created.Reason)
+		adjusted := events.events[1].(domain.StockAdjusted)
+		assert.Equal(t, -6, adjusted.Delta)
+		assert.Equal(t, domain.StockReasonImport, adjusted.Reason)
+	}
+	productRepo.AssertExpectations(t)
+}
+
+func TestImport_RowErrorsAreReported(t *testing.T) {
+	productRepo := new(MockProductRepo)
+	jobRepo := newImportJobRepo()
+	svc := service.NewImportService(productRepo, jobRepo)
+
+	productRepo.On("FindByCodeAndUserID", mock.Anything, mock.Anything, uint(1)).Return(nil, errors.New("not found"))
+	productRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	jobRepo.On("CreateErrors", mock.Anything, []domain.ImportJobError{
+		{JobID: 1, Row: 3, Code: "P-2", Message: "price must be a number"},
+		{JobID: 1, Row: 5, Code: "P-3", Message: "name is required for new products"},
+		{JobID: 1, Row: 6, Code: "P-1", Message: "code is already used on row 2"},
+		{JobID: 1, Row: 7, Code: "", Message: "code is required"},
+		{JobID: 1, Row: 8, Code: "P-4", Message: "price must be a finite number"},
+		{JobID: 1, Row: 9, Code: "P-5", Message: "price must be a finite number"},
+	}).Return(nil)
+
+	body := "code,name,price\nP-1,Mug,1\nP-2,Cup,abc\n\nP-3,,2\nP-1,Mug again,1\n,Plate,3\nP-4,Bowl,NaN\nP-5,Jug,-Inf\n"
+	job, err := svc.Import(context.Background(), 1, service.ImportRequest{Filename: "catalog.csv", Body: []byte(body)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7, job.TotalRows)
+	assert.Equal(t, 1, job.Created)
+	assert.Equal(t, 6, job.Failed)
+	jobRepo.AssertExpectations(t)
+	productRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestImport_DryRunDoesNotWrite(t *testing.T) {
+	productRepo := new(MockProductRepo)
+	jobRepo := newImportJobRepo()
+	svc := service.NewImportService(productRepo, jobRepo)
+
+	existing := &domain.Product{ID: 7, UserID: 1, Code: "P-2", Name: "Cup", Stock: 10}
+	productRepo.On("FindByCodeAndUserID", mock.Anything, "P-1", uint(1)).Return(nil, errors.New("not found"))
+	productRepo.On("FindByCodeAndUserID", mock.Anything, "P-2", uint(1)).Return(existing, nil)
+	jobRepo.On("CreateErrors", mock.Anything, []domain.ImportJobError(nil)).Return(nil)
+
+	body := "code,name,stock\nP-1,Mug,3\nP-2,Cup,4\n"
+	job, err := svc.Import(context.Background(), 1, service.ImportRequest{Filename: "catalog.csv", Body: []byte(body), DryRun: true})
+
+	assert.NoError(t, err)
+	assert.True(t, job.DryRun)
+	assert.Equal(t, 1, job.Created)
+	assert.Equal(t, 1, job.Updated)
+	productRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	productRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestImport_StockOfVariantProductIsRejected(t *testing.T) {
+	productRepo := new(MockProductRepo)
+	jobRepo := newImportJobRepo()
+	svc := service.NewImportService(productRepo, jobRepo)
+
+	product := productWithVariants()
+	productRepo.On("FindByCodeAndUserID", mock.Anything, product.Code, product.UserID).Return(product, nil)
+	jobRepo.On("CreateErrors", mock.Anything, mock.MatchedBy(func(errs []domain.ImportJobError) bool {
+		return len(errs) == 1 && errs[0].Message == "stock of a product with variants is set per variant"
+	})).Return(nil)
+
+	body := "code,stock\n" + product.Code + ",99\n"
+	job, err := svc.Import(context.Background(), product.UserID, service.ImportRequest{Filename: "catalog.csv", Body: []byte(body)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, job.Failed)
+	productRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestImport_XLSXWithColumnMapping(t *testing.T) {
+	productRepo := new(MockProductRepo)
+	jobRepo := newImportJobRepo()
+	svc := service.NewImportService(productRepo, jobRepo)
+
+	productRepo.On("FindByCodeAndUserID", mock.Anything, "A-1", uint(1)).Return(nil, errors.New("not found"))
+	productRepo.On("Create", mock.Anything, mock.MatchedBy(func(p *domain.Product) bool {
+		return p.Code == "A-1" && p.Name == "Lamp" && p.Price == 20
+	})).Return(nil)
+	jobRepo.On("CreateErrors", mock.Anything, []domain.ImportJobError(nil)).Return(nil)
+
+	body := xlsxFile(t, [][]string{{"Artikelnummer", "Bezeichnung", "Price"}, {"A-1", "Lamp", "20"}})
+	job, err := svc.Import(context.Background(), 1, service.ImportRequest{
+		Filename: "upload",
+		Body:     body,
+		Mapping:  map[string]string{"Artikelnummer": "code", "Bezeichnung": "name"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "xlsx", job.Format)
+	assert.Equal(t, 1, job.Created)
+	productRepo.AssertExpectations(t)
+}
+
+func TestImport_LargeFileRunsInBackground(t *testing.T) {
+	productRepo := new(MockProductRepo)
+	jobRepo := newImportJobRepo()
+	svc := service.NewImportService(productRepo, jobRepo, service.WithImportAsyncThreshold(1))
+
+	productRepo.On("FindByCodeAndUserID", mock.Anything, mock.Anything, uint(1)).Return(nil, errors.New("not found"))
+	productRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	jobRepo.On("CreateErrors", mock.Anything, []domain.ImportJobError(nil)).Return(nil)
+
+	body := "code,name\nP-1,Mug\nP-2,Cup\n"
+	job, err := svc.Import(context.Background(), 1, service.ImportRequest{Filename: "catalog.csv", Body: []byte(body)})
+	svc.Wait()
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ImportJobPending, job.Status)
+	productRepo.AssertNumberOfCalls(t, "Create", 2)
+	jobRepo.AssertCalled(t, "Update", mock.Anything, mock.MatchedBy(func(j *domain.ImportJob) bool {
+		return j.Status == domain.ImportJobCompleted && j.Created == 2
+	}))
+}
+
+func TestImport_InvalidFiles(t *testing.T) {
+	svc := service.NewImportService(new(MockProductRepo), new(MockImportJobRepo))
+
+	_, err := svc.Import(context.Background(), 1, service.ImportRequest{Filename: "catalog.csv", Body: []byte("name,price\nMug,1\n")})
+	assert.EqualError(t, err, "import file needs a code column")
+
+	_, err = svc.Import(context.Background(), 1, service.ImportRequest{Filename: "catalog.xlsx", Body: []byte("code\nP-1\n")})
+	assert.EqualError(t, err, "invalid xlsx file")
+
+	_, err = svc.Import(context.Background(), 1, service.ImportRequest{
+		Filename: "catalog.csv",
+		Body:     []byte("code\nP-1\n"),
+		Mapping:  map[string]string{"code": "weight"},
+	})
+	assert.EqualError(t, err, `unknown product field "weight" in mapping`)
+}
+
+func TestGetImportJobErrors_NotFound(t *testing.T) {
+	jobRepo := new(MockImportJobRepo)
+	svc := service.NewImportService(new(MockProductRepo), jobRepo)
+	jobRepo.On("FindByIDAndUserID", mock.Anything, uint(3), uint(1)).Return(nil, errors.New("record not found"))
+
+	_, err := svc.GetJobErrors(context.Background(), 3, 1)
+
+	assert.EqualError(t, err, "import job not found")
+}
+
+func TestImport_SavesProgressWhileRunning(t *testing.T) {
+	productRepo := new(MockProductRepo)
+	jobRepo := newImportJobRepo()
+	svc := service.NewImportService(productRepo, jobRepo)
+
+	productRepo.On("FindByCodeAndUserID", mock.Anything, mock.Anything, uint(1)).Return(nil, errors.New("not found"))
+	productRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	jobRepo.On("CreateErrors", mock.Anything, []domain.ImportJobError(nil)).Return(nil)
+
+	var body strings.Builder
+	body.WriteString("code,name\n")
+	for i := 0; i < 250; i++ {
+		fmt.Fprintf(&body, "P-%d,Mug %d\n", i, i)
+	}
+	job, err := svc.Import(context.Background(), 1, service.ImportRequest{Filename: "catalog.csv", Body: []byte(body.String())})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 250, job.Created)
+	// Started, after 100 and 200 rows, and finished.
+	jobRepo.AssertNumberOfCalls(t, "Update", 4)
+}
+
+func TestFailStaleJobs_FailsJobsWithoutRecentProgress(t *testing.T) {
+	jobRepo := new(MockImportJobRepo)
+	svc := service.NewImportService(new(MockProductRepo), jobRepo)
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	jobRepo.On("FailStale", mock.Anything, now.Add(-10*time.Minute), mock.AnythingOfType("string")).Return(int64(2), nil)
+
+	n, err := svc.FailStaleJobs(context.Background(), now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	jobRepo.AssertExpectations(t)
+}
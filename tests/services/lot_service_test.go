@@ -0,0 +1,307 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockLotRepo struct {
+	mock.Mock
+}
+
+func (m *MockLotRepo) Create(ctx context.Context, lot *domain.Lot) error {
+	args := m.Called(ctx, lot)
+	return args.Error(0)
+}
+
+func (m *MockLotRepo) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.Lot, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Lot), args.Error(1)
+}
+
+func (m *MockLotRepo) FindByNumber(ctx context.Context, productID uint, variantID *uint, number string) (*domain.Lot, error) {
+	args := m.Called(ctx, productID, variantID, number)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Lot), args.Error(1)
+}
+
+func (m *MockLotRepo) FindByProductID(ctx context.Context, productID, userID uint) ([]*domain.Lot, error) {
+	args := m.Called(ctx, productID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Lot), args.Error(1)
+}
+
+func (m *MockLotRepo) LockAllocatable(ctx context.Context, productID uint, variantID *uint, now time.Time) ([]*domain.Lot, error) {
+	args := m.Called(ctx, productID, variantID, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Lot), args.Error(1)
+}
+
+func (m *MockLotRepo) FindExpiring(ctx context.Context, userID uint, before time.Time) ([]*domain.Lot, error) {
+	args := m.Called(ctx, userID, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Lot), args.Error(1)
+}
+
+func (m *MockLotRepo) Update(ctx context.Context, lot *domain.Lot) error {
+	args := m.Called(ctx, lot)
+	return args.Error(0)
+}
+
+func (m *MockLotRepo) AddQuantity(ctx context.Context, id uint, quantity int) error {
+	args := m.Called(ctx, id, quantity)
+	return args.Error(0)
+}
+
+func datePtr(year int, month time.Month, day int) *time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestCreateOrder_AllocatesLotsFirstExpiredFirstOut(t *testing.T) {
+	orderRepo := new(MockOrderRepo)
+	productRepo := new(MockProductRepo)
+	lotRepo := new(MockLotRepo)
+	orderService := service.NewOrderService(orderRepo, productRepo, service.WithOrderLots(lotRepo))
+
+	milk := &domain.Product{ID: 1, UserID: 1, Name: "Milk", Price: 2, Stock: 10, BatchManaged: true}
+	soon := &domain.Lot{ID: 3, ProductID: 1, Number: "L1", ExpiresAt: datePtr(2030, 5, 1), Quantity: 2}
+	later := &domain.Lot{ID: 4, ProductID: 1, Number: "L2", ExpiresAt: datePtr(2030, 6, 1), Quantity: 8}
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(milk, nil)
+	productRepo.On("Update", mock.Anything, milk, uint(1)).Return(nil)
+	lotRepo.On("LockAllocatable", mock.Anything, uint(1), (*uint)(nil), mock.Anything).Return([]*domain.Lot{soon, later}, nil)
+	lotRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+	var created *domain.Order
+	orderRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Order")).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*domain.Order)
+	}).Return(nil)
+	orderRepo.On("FindByIDAndUserID", mock.Anything, mock.Anything, uint(1)).Return(&domain.Order{ID: 1}, nil)
+
+	_, err := orderService.CreateOrder(context.Background(), 1, service.CreateOrderRequest{
+		Items: []service.OrderItemRequest{{ProductID: 1, Quantity: 5}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, soon.Quantity)
+	assert.Equal(t, 5, later.Quantity)
+	assert.Equal(t, 5, milk.Stock)
+	assert.Equal(t, []domain.OrderItemLot{{LotID: 3, Quantity: 2}, {LotID: 4, Quantity: 3}}, created.Items[0].Lots)
+}
+
+func TestCreateOrder_StockInExpiredLotsCannotBeSold(t *testing.T) {
+	orderRepo := new(MockOrderRepo)
+	productRepo := new(MockProductRepo)
+	lotRepo := new(MockLotRepo)
+	orderService := service.NewOrderService(orderRepo, productRepo, service.WithOrderLots(lotRepo))
+
+	// Stock is 10, but only 3 units are in lots that have not expired.
+	milk := &domain.Product{ID: 1, UserID: 1, Name: "Milk", Price: 2, Stock: 10, BatchManaged: true}
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(milk, nil)
+	lotRepo.On("LockAllocatable", mock.Anything, uint(1), (*uint)(nil), mock.Anything).
+		Return([]*domain.Lot{{ID: 4, ProductID: 1, Number: "L2", Quantity: 3}}, nil)
+	lotRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+	_, err := orderService.CreateOrder(context.Background(), 1, service.CreateOrderRequest{
+		Items: []service.OrderItemRequest{{ProductID: 1, Quantity: 5}},
+	})
+
+	assert.EqualError(t, err, "insufficient unexpired stock for product: Milk")
+	orderRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCancelOrder_ReturnsQuantitiesToLots(t *testing.T) {
+	orderRepo := new(MockOrderRepo)
+	productRepo := new(MockProductRepo)
+	lotRepo := new(MockLotRepo)
+	orderService := service.NewOrderService(orderRepo, productRepo, service.WithOrderLots(lotRepo))
+
+	order := &domain.Order{ID: 1, UserID: 1, Status: domain.OrderStatusConfirmed, Items: []domain.OrderItem{
+		{ProductID: 1, Quantity: 5, Lots: []domain.OrderItemLot{{LotID: 3, Quantity: 2}, {LotID: 4, Quantity: 3}}},
+	}}
+	orderRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(order, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Product{ID: 1, UserID: 1, Stock: 5, BatchManaged: true}, nil)
+	productRepo.On("Update", mock.Anything, mock.Anything, uint(1)).Return(nil)
+	lotRepo.On("AddQuantity", mock.Anything, uint(3), 2).Return(nil)
+	lotRepo.On("AddQuantity", mock.Anything, uint(4), 3).Return(nil)
+	orderRepo.On("Update", mock.Anything, order, uint(1)).Return(nil)
+
+	_, err := orderService.CancelOrder(context.Background(), 1, 1)
+
+	assert.NoError(t, err)
+	lotRepo.AssertExpectations(t)
+}
+
+func TestReceiveLot_NewAndExistingLots(t *testing.T) {
+	lotRepo := new(MockLotRepo)
+	productRepo := new(MockProductRepo)
+	events := &recordingEvents{}
+	lotService := service.NewLotService(lotRepo, productRepo, service.WithLotEvents(events))
+
+	milk := &domain.Product{ID: 1, UserID: 1, Name: "Milk", BatchManaged: true}
+	existing := &domain.Lot{ID: 3, ProductID: 1, Number: "L1", ExpiresAt: datePtr(2030, 5, 1), Received: 4, Quantity: 1}
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(milk, nil)
+	productRepo.On("Update", mock.Anything, milk, uint(1)).Return(nil)
+	lotRepo.On("FindByNumber", mock.Anything, uint(1), (*uint)(nil), "L1").Return(existing, nil)
+	lotRepo.On("FindByNumber", mock.Anything, uint(1), (*uint)(nil), "L2").Return(nil, errors.New("record not found"))
+	lotRepo.On("Update", mock.Anything, existing).Return(nil)
+	var created *domain.Lot
+	lotRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Lot")).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*domain.Lot)
+		created.ID = 5
+	}).Return(nil)
+	lotRepo.On("FindByIDAndUserID", mock.Anything, mock.Anything, uint(1)).Return(&domain.Lot{}, nil)
+
+	_, err := lotService.ReceiveLot(context.Background(), 1, service.ReceiveLotRequest{ProductID: 1, Number: " L1 ", Quantity: 6})
+	assert.NoError(t, err)
+	assert.Equal(t, 10, existing.Received)
+	assert.Equal(t, 7, existing.Quantity)
+
+	_, err = lotService.ReceiveLot(context.Background(), 1, service.ReceiveLotRequest{ProductID: 1, Number: "L2", ExpiresAt: datePtr(2030, 7, 1), Quantity: 12})
+	assert.NoError(t, err)
+	assert.Equal(t, 12, created.Quantity)
+	assert.Equal(t, datePtr(2030, 7, 1), created.ExpiresAt)
+
+	assert.Equal(t, 18, milk.Stock)
+	assert.Equal(t, domain.StockReasonLotReceived, events.events[0].(domain.StockAdjusted).Reason)
+
+	_, err = lotService.ReceiveLot(context.Background(), 1, service.ReceiveLotRequest{ProductID: 1, Number: "L1", ExpiresAt: datePtr(2031, 1, 1), Quantity: 1})
+	assert.EqualError(t, err, "lot L1 already exists with a different expiry date")
+}
+
+func TestReceiveLot_RequiresBatchManagedProduct(t *testing.T) {
+	productRepo := new(MockProductRepo)
+	lotService := service.NewLotService(new(MockLotRepo), productRepo)
+
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(&domain.Product{ID: 2, UserID: 1, Name: "Mug"}, nil)
+
+	_, err := lotService.ReceiveLot(context.Background(), 1, service.ReceiveLotRequest{ProductID: 2, Number: "L1", Quantity: 1})
+	assert.EqualError(t, err, "product is not batch-managed: Mug")
+}
+
+func TestSetBatchManaged_RequiresZeroStock(t *testing.T) {
+	productRepo := new(MockProductRepo)
+	lotService := service.NewLotService(new(MockLotRepo), productRepo)
+
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Product{ID: 1, UserID: 1, Stock: 4}, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(&domain.Product{ID: 2, UserID: 1}, nil)
+	productRepo.On("Update", mock.Anything, mock.Anything, uint(1)).Return(nil)
+
+	_, err := lotService.SetBatchManaged(context.Background(), 1, 1, true)
+	assert.EqualError(t, err, "stock must be zero to turn on batch management; receive it into lots instead")
+
+	product, err := lotService.SetBatchManaged(context.Background(), 2, 1, true)
+	assert.NoError(t, err)
+	assert.True(t, product.BatchManaged)
+}
+
+func TestExpiringLots_Horizon(t *testing.T) {
+	lotRepo := new(MockLotRepo)
+	lotService := service.NewLotService(lotRepo, new(MockProductRepo))
+
+	inWeek := time.Now().AddDate(0, 0, 7)
+	lotRepo.On("FindExpiring", mock.Anything, uint(1), mock.MatchedBy(func(before time.Time) bool {
+		return before.Sub(inWeek).Abs() < time.Minute
+	})).Return([]*domain.Lot{{ID: 3}}, nil)
+
+	lots, err := lotService.ExpiringLots(context.Background(), 1, 7)
+	assert.NoError(t, err)
+	assert.Len(t, lots, 1)
+
+	_, err = lotService.ExpiringLots(context.Background(), 1, 400)
+	assert.EqualError(t, err, "days must be between 0 and 365")
+}
+
+func TestWriteOffLot_RemovesStock(t *testing.T) {
+	lotRepo := new(MockLotRepo)
+	productRepo := new(MockProductRepo)
+	events := &recordingEvents{}
+	lotService := service.NewLotService(lotRepo, productRepo, service.WithLotEvents(events))
+
+	lot := &domain.Lot{ID: 3, ProductID: 1, Number: "L1", Quantity: 4}
+	milk := &domain.Product{ID: 1, UserID: 1, Stock: 9, BatchManaged: true}
+	lotRepo.On("FindByIDAndUserID", mock.Anything, uint(3), uint(1)).Return(lot, nil)
+	lotRepo.On("Update", mock.Anything, lot).Return(nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(milk, nil)
+	productRepo.On("Update", mock.Anything, milk, uint(1)).Return(nil)
+
+	_, err := lotService.WriteOffLot(context.Background(), 3, 1, intPtr(5))
+	assert.EqualError(t, err, "lot L1 has only 4 left")
+
+	_, err = lotService.WriteOffLot(context.Background(), 3, 1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, lot.Quantity)
+	assert.Equal(t, 5, milk.Stock)
+	assert.Equal(t, domain.StockReasonLotWrittenOff, events.events[0].(domain.StockAdjusted).Reason)
+}
+
+func TestReceivePurchaseOrder_BooksBatchManagedStockIntoLots(t *testing.T) {
+	poRepo := new(MockPurchaseOrderRepo)
+	productRepo := new(MockProductRepo)
+	lotRepo := new(MockLotRepo)
+	poService := service.NewPurchaseOrderService(poRepo, new(MockSupplierRepo), productRepo, service.WithPurchaseOrderLots(lotRepo))
+
+	order := sentPurchaseOrder()
+	poRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(sentPurchaseOrder(), nil).Once()
+	poRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(order, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Product{ID: 1, UserID: 1, Name: "Milk", BatchManaged: true}, nil)
+	productRepo.On("Update", mock.Anything, mock.Anything, uint(1)).Return(nil)
+	lotRepo.On("FindByNumber", mock.Anything, uint(1), (*uint)(nil), mock.Anything).Return(nil, errors.New("record not found"))
+	lotRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Lot")).Return(nil)
+	poRepo.On("CreateReceipt", mock.Anything, mock.Anything).Return(nil)
+	poRepo.On("Update", mock.Anything, order, uint(1)).Return(nil)
+
+	_, err := poService.ReceivePurchaseOrder(context.Background(), 1, 1, service.ReceivePurchaseOrderRequest{})
+	assert.EqualError(t, err, "lot_number is required to receive batch-managed product: Milk")
+
+	_, err = poService.ReceivePurchaseOrder(context.Background(), 1, 1, service.ReceivePurchaseOrderRequest{
+		Lines: []service.ReceiveLineRequest{
+			{LineID: 10, Quantity: 2, LotNumber: "L1", ExpiresAt: datePtr(2030, 5, 1)},
+			{LineID: 10, Quantity: 3, LotNumber: "L2", ExpiresAt: datePtr(2030, 6, 1)},
+		},
+	})
+	assert.NoError(t, err)
+	lotRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestDirectStockWrites_RefuseBatchManagedProducts(t *testing.T) {
+	productRepo := new(MockProductRepo)
+	productService := service.NewProductService(productRepo)
+
+	milk := &domain.Product{ID: 1, UserID: 1, Code: "MILK", Stock: 4, BatchManaged: true}
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(milk, nil)
+	lotStock := "stock of a batch-managed product changes through its lots; receive or write off lots instead"
+
+	_, err := productService.UpdateProductStock(context.Background(), 1, 1, 2)
+	assert.EqualError(t, err, lotStock)
+
+	stock := 6
+	_, err = productService.UpdateProduct(context.Background(), 1, 1, nil, nil, nil, nil, &stock)
+	assert.EqualError(t, err, lotStock)
+
+	productID, delta := uint(1), -1
+	_, err = productService.BulkUpdateStock(context.Background(), 1, []service.BulkStockEntry{{ProductID: &productID, Delta: &delta}})
+	var entryErrs *service.EntryErrors
+	if assert.ErrorAs(t, err, &entryErrs) {
+		assert.Equal(t, []service.EntryError{{Index: 0, Message: lotStock}}, entryErrs.Entries)
+	}
+	productRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,268 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"vertice-backend/internal/mailer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpTransaction is what a fakeSMTPServer received in one session.
+type smtpTransaction struct {
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTPServer accepts a single SMTP session on a local port and reports
+// what it received.
+func fakeSMTPServer(t *testing.T) (host, port string, received <-chan smtpTransaction) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan smtpTransaction, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+		var tx smtpTransaction
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			command := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(command, "MAIL FROM:"):
+				tx.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+				reply("250 OK")
+			case strings.HasPrefix(command, "RCPT TO:"):
+				tx.to = append(tx.to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+				reply("250 OK")
+			case command == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				tx.data = data.String()
+				reply("250 OK")
+			case command == "QUIT":
+				reply("221 Bye")
+				ch <- tx
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	host, port, err = net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, err)
+	return host, port, ch
+}
+
+// readAlternatives parses a multipart/alternative message into its parts by
+// content type.
+func readAlternatives(t *testing.T, raw []byte) (*mail.Message, map[string]string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	require.NoError(t, err)
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	parts := map[string]string{}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[contentType] = string(body)
+	}
+	return msg, parts
+}
+
+func TestSMTPMailer_SendsMultipartToServer(t *testing.T) {
+	host, port, received := fakeSMTPServer(t)
+	m := mailer.NewSMTPMailer(mailer.SMTPConfig{Host: host, Port: port, From: "vertice@example.com"})
+
+	err := m.Send(context.Background(), mailer.Message{
+		To:      []string{"owner@example.com", "ops@example.com"},
+		Subject: "Daily sales",
+		Text:    "Orders: 3",
+		HTML:    "<p>Orders: 3</p>",
+	})
+	require.NoError(t, err)
+
+	var tx smtpTransaction
+	select {
+	case tx = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the SMTP server received nothing")
+	}
+	assert.Equal(t, "vertice@example.com", tx.from)
+	assert.Equal(t, []string{"owner@example.com", "ops@example.com"}, tx.to)
+
+	msg, parts := readAlternatives(t, []byte(tx.data))
+	assert.Equal(t, "Daily sales", msg.Header.Get("Subject"))
+	assert.Equal(t, "Orders: 3", parts["text/plain"])
+	assert.Equal(t, "<p>Orders: 3</p>", parts["text/html"])
+}
+
+// silentSMTPServer accepts connections and never answers them.
+func silentSMTPServer(t *testing.T) (host, port string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+	host, port, err = net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, err)
+	return host, port
+}
+
+func TestSMTPMailer_TimesOutOnUnresponsiveServer(t *testing.T) {
+	host, port := silentSMTPServer(t)
+	m := mailer.NewSMTPMailer(mailer.SMTPConfig{Host: host, Port: port, Timeout: 100 * time.Millisecond})
+
+	start := time.Now()
+	err := m.Send(context.Background(), mailer.Message{To: []string{"owner@example.com"}, Text: "hi"})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestSMTPMailer_StopsWhenContextIsCancelled(t *testing.T) {
+	host, port := silentSMTPServer(t)
+	m := mailer.NewSMTPMailer(mailer.SMTPConfig{Host: host, Port: port})
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := m.Send(ctx, mailer.Message{To: []string{"owner@example.com"}, Text: "hi"})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestBuild_TextOnly(t *testing.T) {
+	raw := mailer.Build("vertice@example.com", mailer.Message{
+		To:      []string{"owner@example.com"},
+		Subject: "Low stock",
+		Text:    "line one\nline two",
+	}, time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC))
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", msg.Header.Get("Content-Type"))
+	body, _ := io.ReadAll(msg.Body)
+	assert.Equal(t, "line one\r\nline two", string(body))
+}
+
+func TestFileMailer_WritesMessages(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	m, err := mailer.NewFileMailer(dir, "vertice@example.com")
+	require.NoError(t, err)
+
+	for _, subject := range []string{"first", "second"} {
+		err := m.Send(context.Background(), mailer.Message{To: []string{"owner@example.com"}, Subject: subject, Text: "hi"})
+		require.NoError(t, err)
+	}
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	raw, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "Subject: first")
+}
+
+func TestLogMailer_WritesMessages(t *testing.T) {
+	var out bytes.Buffer
+	m := mailer.NewLogMailer(&out, "vertice@example.com")
+
+	err := m.Send(context.Background(), mailer.Message{To: []string{"owner@example.com"}, Subject: "Hello", Text: "hi"})
+
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "To: owner@example.com")
+	assert.Contains(t, out.String(), "Subject: Hello")
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("SMTP_HOST", "")
+	t.Setenv("MAIL_DRIVER", "")
+	m, err := mailer.NewFromEnv()
+	assert.NoError(t, err)
+	assert.Nil(t, m)
+
+	t.Setenv("MAIL_DRIVER", "log")
+	m, err = mailer.NewFromEnv()
+	assert.NoError(t, err)
+	assert.IsType(t, &mailer.LogMailer{}, m)
+
+	t.Setenv("MAIL_DRIVER", "smtp")
+	_, err = mailer.NewFromEnv()
+	assert.Error(t, err)
+
+	t.Setenv("MAIL_DRIVER", "pigeon")
+	_, err = mailer.NewFromEnv()
+	assert.Error(t, err)
+}
+
+func TestRender_EscapesHTML(t *testing.T) {
+	msg, err := mailer.Render("low_stock", []string{"owner@example.com"}, "Low stock", map[string]any{
+		"Products": []map[string]any{
+			{"Name": "<b>Mouse</b>", "Code": "MOUSE", "Stock": 1, "ReorderPoint": 5, "ReorderQuantity": 10},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "- <b>Mouse</b> (MOUSE): 1 left, reorder point 5, reorder 10")
+	assert.Contains(t, msg.HTML, "&lt;b&gt;Mouse&lt;/b&gt;")
+}
@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/mailer"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockNotificationRepo struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepo) Create(ctx context.Context, notification *domain.Notification) error {
+	args := m.Called(ctx, notification)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepo) FindByEventID(ctx context.Context, eventID uint) (*domain.Notification, error) {
+	args := m.Called(ctx, eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Notification), args.Error(1)
+}
+
+func (m *MockNotificationRepo) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.Notification, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Notification), args.Error(1)
+}
+
+func (m *MockNotificationRepo) FindByUserID(ctx context.Context, userID uint, unreadOnly bool, limit int) ([]*domain.Notification, error) {
+	args := m.Called(ctx, userID, unreadOnly, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Notification), args.Error(1)
+}
+
+func (m *MockNotificationRepo) Update(ctx context.Context, notification *domain.Notification) error {
+	args := m.Called(ctx, notification)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepo) MarkAllRead(ctx context.Context, userID uint, readAt time.Time) (int64, error) {
+	args := m.Called(ctx, userID, readAt)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// recordingMailer keeps sent messages, or fails with err when set.
+type recordingMailer struct {
+	sent []mailer.Message
+	err  error
+}
+
+func (m *recordingMailer) Send(_ context.Context, msg mailer.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func stockLowMessage() *domain.OutboxMessage {
+	return &domain.OutboxMessage{
+		ID:        9,
+		UserID:    1,
+		EventType: domain.EventProductStockLow,
+		Payload:   `{"product_id":3,"code":"PROD001","name":"Laptop","stock":2,"reorder_point":5,"reorder_quantity":20}`,
+	}
+}
+
+func TestNotificationHandle_CreatesLowStockNotification(t *testing.T) {
+	mockRepo := new(MockNotificationRepo)
+	notificationService := service.NewNotificationService(mockRepo)
+
+	mockRepo.On("FindByEventID", mock.Anything, uint(9)).Return(nil, errors.New("not found"))
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(n *domain.Notification) bool {
+		return n.UserID == 1 &&
+			n.EventID == 9 &&
+			n.Type == domain.NotificationLowStock &&
+			n.Title == "Low stock: Laptop (PROD001)" &&
+			n.Message == "Laptop (PROD001) has 2 units left, at or below its reorder point of 5. Suggested reorder: 20 units." &&
+			*n.ProductID == 3
+	})).Return(nil)
+
+	err := notificationService.Handle(context.Background(), stockLowMessage())
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestNotificationHandle_IgnoresOtherEvents(t *testing.T) {
+	mockRepo := new(MockNotificationRepo)
+	notificationService := service.NewNotificationService(mockRepo)
+
+	err := notificationService.Handle(context.Background(), &domain.OutboxMessage{ID: 1, EventType: domain.EventOrderCreated})
+
+	assert.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestNotificationHandle_SkipsRedeliveredEvent(t *testing.T) {
+	mockRepo := new(MockNotificationRepo)
+	notificationService := service.NewNotificationService(mockRepo)
+
+	mockRepo.On("FindByEventID", mock.Anything, uint(9)).Return(&domain.Notification{ID: 4, EventID: 9}, nil)
+
+	err := notificationService.Handle(context.Background(), stockLowMessage())
+
+	assert.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestNotificationHandle_EmailsUser(t *testing.T) {
+	mockRepo := new(MockNotificationRepo)
+	mockUserRepo := new(MockUserRepo)
+	m := &recordingMailer{}
+	notificationService := service.NewNotificationService(mockRepo, service.WithNotificationMailer(m, mockUserRepo))
+
+	mockRepo.On("FindByEventID", mock.Anything, uint(9)).Return(nil, errors.New("not found"))
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Notification")).Return(nil)
+	mockUserRepo.On("FindByID", mock.Anything, uint(1)).Return(&domain.User{ID: 1, Email: "ops@example.com"}, nil)
+	mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(n *domain.Notification) bool {
+		return n.EmailedAt != nil
+	})).Return(nil)
+
+	err := notificationService.Handle(context.Background(), stockLowMessage())
+
+	assert.NoError(t, err)
+	assert.Len(t, m.sent, 1)
+	assert.Equal(t, []string{"ops@example.com"}, m.sent[0].To)
+	assert.Equal(t, "Low stock: Laptop (PROD001)", m.sent[0].Subject)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestNotificationHandle_EmailFailureKeepsNotification(t *testing.T) {
+	mockRepo := new(MockNotificationRepo)
+	mockUserRepo := new(MockUserRepo)
+	m := &recordingMailer{err: errors.New("smtp unavailable")}
+	notificationService := service.NewNotificationService(mockRepo, service.WithNotificationMailer(m, mockUserRepo))
+
+	mockRepo.On("FindByEventID", mock.Anything, uint(9)).Return(nil, errors.New("not found"))
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Notification")).Return(nil)
+	mockUserRepo.On("FindByID", mock.Anything, uint(1)).Return(&domain.User{ID: 1, Email: "ops@example.com"}, nil)
+
+	err := notificationService.Handle(context.Background(), stockLowMessage())
+
+	assert.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestMarkRead_Success(t *testing.T) {
+	mockRepo := new(MockNotificationRepo)
+	notificationService := service.NewNotificationService(mockRepo)
+
+	notification := &domain.Notification{ID: 4, UserID: 1}
+	mockRepo.On("FindByIDAndUserID", mock.Anything, uint(4), uint(1)).Return(notification, nil)
+	mockRepo.On("Update", mock.Anything, notification).Return(nil)
+
+	result, err := notificationService.MarkRead(context.Background(), 4, 1)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, result.ReadAt)
+}
+
+func TestMarkRead_Error_NotFound(t *testing.T) {
+	mockRepo := new(MockNotificationRepo)
+	notificationService := service.NewNotificationService(mockRepo)
+
+	mockRepo.On("FindByIDAndUserID", mock.Anything, uint(4), uint(1)).Return(nil, errors.New("record not found"))
+
+	_, err := notificationService.MarkRead(context.Background(), 4, 1)
+
+	assert.EqualError(t, err, "notification not found")
+}
+
+func TestGetNotifications_DefaultLimit(t *testing.T) {
+	mockRepo := new(MockNotificationRepo)
+	notificationService := service.NewNotificationService(mockRepo)
+
+	mockRepo.On("FindByUserID", mock.Anything, uint(1), true, 50).Return([]*domain.Notification{}, nil)
+
+	_, err := notificationService.GetNotifications(context.Background(), 1, true, 0)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
@@ -37,6 +37,26 @@ func (m *MockOrderRepo) FindByUserID(ctx context.Context, userID uint) ([]*domai
 	return args.Get(0).([]*domain.Order), args.Error(1)
 }
 
+func (m *MockOrderRepo) FindByFilter(ctx context.Context, userID uint, filter domain.OrderFilter) ([]*domain.Order, error) {
+	args := m.Called(ctx, userID, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Order), args.Error(1)
+}
+
+// FindInBatches passes the batches given to Return to fn.
+func (m *MockOrderRepo) FindInBatches(ctx context.Context, userID uint, filter domain.OrderFilter, batchSize int, fn func([]*domain.Order) error) error {
+	args := m.Called(ctx, userID, filter, batchSize)
+	batches, _ := args.Get(0).([][]*domain.Order)
+	for _, batch := range batches {
+		if err := fn(batch); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
 func (m *MockOrderRepo) Update(ctx context.Context, order *domain.Order, userID uint) error {
 	args := m.Called(ctx, order, userID)
 	return args.Error(0)
@@ -440,3 +460,64 @@ func TestCreateOrder_MultipleItems_Success(t *testing.T) {
 	mockOrderRepo.AssertExpectations(t)
 	mockProductRepo.AssertExpectations(t)
 }
+
+func TestCreateOrder_RecordsEvents(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepo)
+	mockProductRepo := new(MockProductRepo)
+	events := &recordingEvents{}
+	orderService := service.NewOrderService(mockOrderRepo, mockProductRepo, service.WithOrderEvents(events))
+
+	product := &domain.Product{ID: 1, UserID: 1, Code: "P1", Name: "Last one", Price: 10.0, Stock: 2}
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	mockProductRepo.On("Update", mock.Anything, product, uint(1)).Return(nil)
+	mockOrderRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Order")).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.Order).ID = 9
+	}).Return(nil)
+	mockOrderRepo.On("FindByIDAndUserID", mock.Anything, uint(9), uint(1)).Return(&domain.Order{ID: 9, UserID: 1}, nil)
+
+	_, err := orderService.CreateOrder(context.Background(), 1, service.CreateOrderRequest{
+		Items: []service.OrderItemRequest{{ProductID: 1, Quantity: 2}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{domain.EventOrderCreated, domain.EventStockAdjusted, domain.EventProductStockLow}, events.types())
+	created := events.events[0].(domain.OrderCreated)
+	assert.Equal(t, uint(9), created.OrderID)
+	assert.Equal(t, 20.0, created.TotalAmount)
+	assert.Equal(t, domain.StockAdjusted{ProductID: 1, Code: "P1", Previous: 2, Stock: 0, Delta: -2, Reason: domain.StockReasonOrderPlaced}, events.events[1])
+}
+
+func TestCreateOrder_Error_RecordingFails(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepo)
+	mockProductRepo := new(MockProductRepo)
+	events := &recordingEvents{err: errors.New("outbox unavailable")}
+	orderService := service.NewOrderService(mockOrderRepo, mockProductRepo, service.WithOrderEvents(events))
+
+	product := &domain.Product{ID: 1, UserID: 1, Price: 10.0, Stock: 5}
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	mockProductRepo.On("Update", mock.Anything, product, uint(1)).Return(nil)
+	mockOrderRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Order")).Return(nil)
+
+	_, err := orderService.CreateOrder(context.Background(), 1, service.CreateOrderRequest{
+		Items: []service.OrderItemRequest{{ProductID: 1, Quantity: 2}},
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "outbox unavailable", err.Error())
+	mockOrderRepo.AssertNotCalled(t, "FindByIDAndUserID", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateOrderStatus_RecordsStatusChanged(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepo)
+	mockProductRepo := new(MockProductRepo)
+	events := &recordingEvents{}
+	orderService := service.NewOrderService(mockOrderRepo, mockProductRepo, service.WithOrderEvents(events))
+
+	mockOrderRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Order{ID: 1, UserID: 1, Status: domain.OrderStatusPending}, nil)
+	mockOrderRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Order"), uint(1)).Return(nil)
+
+	_, err := orderService.UpdateOrderStatus(context.Background(), 1, 1, domain.OrderStatusConfirmed)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Event{domain.OrderStatusChanged{OrderID: 1, From: "pending", To: "confirmed"}}, events.events)
+}
@@ -0,0 +1,293 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOutboxRepo struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepo) Create(ctx context.Context, message *domain.OutboxMessage) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepo) FindPending(ctx context.Context, limit int) ([]*domain.OutboxMessage, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.OutboxMessage), args.Error(1)
+}
+
+func (m *MockOutboxRepo) Update(ctx context.Context, message *domain.OutboxMessage) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
+
+// recordingEvents collects recorded domain events for assertions.
+type recordingEvents struct {
+	events []domain.Event
+	err    error
+}
+
+func (r *recordingEvents) Record(_ context.Context, _ uint, event domain.Event) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recordingEvents) types() []string {
+	types := make([]string, len(r.events))
+	for i, event := range r.events {
+		types[i] = event.EventType()
+	}
+	return types
+}
+
+// countingTransactor runs functions directly and counts the transactions.
+type countingTransactor struct {
+	calls int
+}
+
+func (t *countingTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.calls++
+	return fn(ctx)
+}
+
+// scriptedSink fails for the message IDs in failFor and records the rest.
+type scriptedSink struct {
+	failFor  map[uint]bool
+	received []uint
+}
+
+func (s *scriptedSink) Handle(_ context.Context, message *domain.OutboxMessage) error {
+	if s.failFor[message.ID] {
+		return errors.New("sink unavailable")
+	}
+	s.received = append(s.received, message.ID)
+	return nil
+}
+
+func outboxMessage(id uint, aggregateType string, aggregateID uint) *domain.OutboxMessage {
+	return &domain.OutboxMessage{
+		ID:            id,
+		UserID:        1,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     domain.EventStockAdjusted,
+		Payload:       `{"product_id":1}`,
+		Status:        domain.OutboxPending,
+		OccurredAt:    time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+	}
+}
+
+func TestOutboxRecord_StoresEvent(t *testing.T) {
+	mockRepo := new(MockOutboxRepo)
+	outbox := service.NewOutbox(mockRepo)
+
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(m *domain.OutboxMessage) bool {
+		return m.UserID == 1 &&
+			m.AggregateType == domain.AggregateOrder &&
+			m.AggregateID == 7 &&
+			m.EventType == domain.EventOrderStatusChanged &&
+			m.Payload == `{"order_id":7,"from":"pending","to":"confirmed"}` &&
+			m.Status == domain.OutboxPending &&
+			!m.OccurredAt.IsZero()
+	})).Return(nil)
+
+	err := outbox.Record(context.Background(), 1, domain.OrderStatusChanged{OrderID: 7, From: "pending", To: "confirmed"})
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestOutboxRelay_ProcessesInOrder(t *testing.T) {
+	mockRepo := new(MockOutboxRepo)
+	sink := &scriptedSink{}
+	relay := service.NewOutboxRelay(mockRepo, sink)
+
+	messages := []*domain.OutboxMessage{
+		outboxMessage(1, domain.AggregateProduct, 1),
+		outboxMessage(2, domain.AggregateOrder, 4),
+		outboxMessage(3, domain.AggregateProduct, 1),
+	}
+	mockRepo.On("FindPending", mock.Anything, 100).Return(messages, nil)
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.OutboxMessage")).Return(nil)
+
+	processed, err := relay.RelayPending(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, processed)
+	assert.Equal(t, []uint{1, 2, 3}, sink.received)
+	for _, message := range messages {
+		assert.Equal(t, domain.OutboxProcessed, message.Status)
+		assert.NotNil(t, message.ProcessedAt)
+	}
+}
+
+func TestOutboxRelay_FailureHoldsBackSameAggregate(t *testing.T) {
+	mockRepo := new(MockOutboxRepo)
+	sink := &scriptedSink{failFor: map[uint]bool{1: true}}
+	relay := service.NewOutboxRelay(mockRepo, sink)
+
+	first := outboxMessage(1, domain.AggregateProduct, 1)
+	other := outboxMessage(2, domain.AggregateOrder, 4)
+	later := outboxMessage(3, domain.AggregateProduct, 1)
+	mockRepo.On("FindPending", mock.Anything, 100).Return([]*domain.OutboxMessage{first, other, later}, nil)
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.OutboxMessage")).Return(nil)
+
+	processed, err := relay.RelayPending(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	assert.Equal(t, []uint{2}, sink.received)
+	assert.Equal(t, domain.OutboxPending, first.Status)
+	assert.Equal(t, 1, first.Attempts)
+	assert.Equal(t, "sink unavailable", first.LastError)
+	assert.Equal(t, domain.OutboxPending, later.Status)
+	assert.Equal(t, 0, later.Attempts)
+}
+
+func TestOutboxRelay_MarksFailedAfterMaxAttempts(t *testing.T) {
+	mockRepo := new(MockOutboxRepo)
+	sink := &scriptedSink{failFor: map[uint]bool{1: true}}
+	relay := service.NewOutboxRelay(mockRepo, sink)
+	relay.MaxAttempts = 3
+
+	message := outboxMessage(1, domain.AggregateProduct, 1)
+	message.Attempts = 2
+	mockRepo.On("FindPending", mock.Anything, 100).Return([]*domain.OutboxMessage{message}, nil)
+	mockRepo.On("Update", mock.Anything, message).Return(nil)
+
+	_, err := relay.RelayPending(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OutboxFailed, message.Status)
+	assert.Nil(t, message.ProcessedAt)
+}
+
+func TestEventBus_DeliversToTypeAndWildcardSubscribers(t *testing.T) {
+	bus := service.NewEventBus()
+	var got []string
+	bus.Subscribe(domain.EventStockAdjusted, func(_ context.Context, m *domain.OutboxMessage) error {
+		got = append(got, "typed")
+		return nil
+	})
+	bus.Subscribe(domain.EventOrderCreated, func(_ context.Context, m *domain.OutboxMessage) error {
+		got = append(got, "other")
+		return nil
+	})
+	bus.Subscribe(service.AllEvents, func(_ context.Context, m *domain.OutboxMessage) error {
+		got = append(got, "all")
+		return nil
+	})
+
+	err := bus.Handle(context.Background(), outboxMessage(1, domain.AggregateProduct, 1))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"typed", "all"}, got)
+}
+
+func TestNDJSONSink_WritesOneLinePerEvent(t *testing.T) {
+	var buf bytes.Buffer
+	sink := service.NewNDJSONSink(&buf)
+
+	assert.NoError(t, sink.Handle(context.Background(), outboxMessage(1, domain.AggregateProduct, 1)))
+	assert.NoError(t, sink.Handle(context.Background(), outboxMessage(2, domain.AggregateProduct, 1)))
+
+	lines := bytes.Split(bytes.TrimRight(buf.Bytes(), "\n"), []byte("\n"))
+	assert.Len(t, lines, 2)
+	assert.JSONEq(t, `{
+		"id": 1,
+		"type": "product.stock_adjusted",
+		"user_id": 1,
+		"aggregate_type": "product",
+		"aggregate_id": 1,
+		"occurred_at": "2024-01-15T10:30:00Z",
+		"data": {"product_id": 1}
+	}`, string(lines[0]))
+}
+
+func TestWebhookHandle_DispatchesInTransaction(t *testing.T) {
+	mockRepo := new(MockWebhookRepo)
+	tx := &countingTransactor{}
+	webhookService := service.NewWebhookService(mockRepo, service.WithWebhookTransactor(tx))
+
+	subscription := &domain.WebhookSubscription{ID: 1, UserID: 1, Active: true, Events: domain.EventStockAdjusted}
+	mockRepo.On("FindActiveSubscriptions", mock.Anything, uint(1)).Return([]*domain.WebhookSubscription{subscription}, nil)
+	mockRepo.On("CreateDelivery", mock.Anything, mock.MatchedBy(func(d *domain.WebhookDelivery) bool {
+		return d.EventID == 5 && bytes.Contains([]byte(d.Payload), []byte(`"data":{"product_id":1}`))
+	})).Return(nil)
+
+	err := webhookService.Handle(context.Background(), outboxMessage(5, domain.AggregateProduct, 1))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, tx.calls)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCancelOrder_RestoresStockInOneTransaction(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepo)
+	mockProductRepo := new(MockProductRepo)
+	tx := &countingTransactor{}
+	events := &recordingEvents{}
+	orderService := service.NewOrderService(mockOrderRepo, mockProductRepo,
+		service.WithOrderTransactor(tx),
+		service.WithOrderEvents(events),
+	)
+
+	order := &domain.Order{
+		ID:     1,
+		UserID: 1,
+		Status: domain.OrderStatusConfirmed,
+		Items:  []domain.OrderItem{{ProductID: 1, Quantity: 2}},
+	}
+	product := &domain.Product{ID: 1, UserID: 1, Stock: 0}
+	mockOrderRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(order, nil)
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	mockProductRepo.On("Update", mock.Anything, product, uint(1)).Return(nil)
+	mockOrderRepo.On("Update", mock.Anything, order, uint(1)).Return(nil)
+
+	_, err := orderService.CancelOrder(context.Background(), 1, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, tx.calls)
+	assert.Equal(t, 2, product.Stock)
+	assert.Equal(t, []string{domain.EventStockAdjusted, domain.EventOrderStatusChanged}, events.types())
+}
+
+func TestCancelOrder_Error_StockUpdateFails(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepo)
+	mockProductRepo := new(MockProductRepo)
+	orderService := service.NewOrderService(mockOrderRepo, mockProductRepo)
+
+	order := &domain.Order{
+		ID:     1,
+		UserID: 1,
+		Status: domain.OrderStatusPending,
+		Items:  []domain.OrderItem{{ProductID: 1, Quantity: 2}},
+	}
+	mockOrderRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(order, nil)
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Product{ID: 1, UserID: 1}, nil)
+	mockProductRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Product"), uint(1)).Return(errors.New("db down"))
+
+	_, err := orderService.CancelOrder(context.Background(), 1, 1)
+
+	assert.Error(t, err)
+	assert.Equal(t, domain.OrderStatusPending, order.Status)
+	mockOrderRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"vertice-backend/pkg/password"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	strict := password.Policy{MinLength: 10, RequireMixedCase: true, RequireDigit: true, RequireSymbol: true}
+	for _, tc := range []struct {
+		policy   password.Policy
+		password string
+		err      string
+	}{
+		{password.DefaultPolicy(), "correct-horse-42", ""},
+		{password.DefaultPolicy(), "seven77", "password must be at least 8 characters"},
+		{password.DefaultPolicy(), "contraseña", ""},
+		{password.DefaultPolicy(), strings.Repeat("a1", 37), "password must be at most 72 bytes"},
+		{password.DefaultPolicy(), "Password123", "password is too common; it has appeared in data breaches"},
+		{password.DefaultPolicy(), "QWERTYUIOP", "password is too common; it has appeared in data breaches"},
+		{strict, "correct-horse-42", "password must contain upper and lower case letters"},
+		{strict, "Correct-Horse", "password must contain a digit"},
+		{strict, "CorrectHorse42", "password must contain a symbol"},
+		{strict, "Correct Horse 42", ""},
+	} {
+		err := tc.policy.Check(tc.password)
+		if tc.err == "" {
+			assert.NoError(t, err, tc.password)
+		} else {
+			assert.EqualError(t, err, tc.err, tc.password)
+		}
+	}
+}
+
+func TestPasswordPolicy_Refuse(t *testing.T) {
+	policy := password.DefaultPolicy()
+
+	require.NoError(t, policy.Refuse(strings.NewReader("# company words\n\nVertice2024\n")))
+
+	assert.Error(t, policy.Check("vertice2024"))
+	assert.Error(t, policy.Check("password1"))
+	assert.NoError(t, password.DefaultPolicy().Check("vertice2024"), "the default list is left as it is")
+}
+
+func TestPasswordPolicyFromEnv(t *testing.T) {
+	list := t.TempDir() + "/breached.txt"
+	require.NoError(t, os.WriteFile(list, []byte("tangerine-sky\n"), 0o644))
+	t.Setenv("PASSWORD_MIN_LENGTH", "12")
+	t.Setenv("PASSWORD_REQUIRE_DIGIT", "true")
+	t.Setenv("PASSWORD_BREACHED_LIST", list)
+
+	policy, err := password.PolicyFromEnv()
+
+	require.NoError(t, err)
+	assert.Equal(t, 12, policy.MinLength)
+	assert.True(t, policy.RequireDigit)
+	assert.False(t, policy.RequireSymbol)
+	assert.EqualError(t, policy.Check("tangerine-sky"), "password must contain a digit")
+	assert.NoError(t, policy.Check("tangerine-sky-9"))
+
+	t.Setenv("PASSWORD_MIN_LENGTH", "0")
+	_, err = password.PolicyFromEnv()
+	assert.EqualError(t, err, "PASSWORD_MIN_LENGTH must be between 1 and 72")
+}
@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPriceChangeRepo struct {
+	mock.Mock
+}
+
+func (m *MockPriceChangeRepo) Create(ctx context.Context, change *domain.PriceChange) error {
+	args := m.Called(ctx, change)
+	return args.Error(0)
+}
+
+func (m *MockPriceChangeRepo) FindByProductID(ctx context.Context, productID, userID uint) ([]*domain.PriceChange, error) {
+	args := m.Called(ctx, productID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PriceChange), args.Error(1)
+}
+
+func (m *MockPriceChangeRepo) CreateScheduled(ctx context.Context, change *domain.ScheduledPriceChange) error {
+	args := m.Called(ctx, change)
+	return args.Error(0)
+}
+
+func (m *MockPriceChangeRepo) FindScheduledByIDAndUserID(ctx context.Context, id, userID uint) (*domain.ScheduledPriceChange, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ScheduledPriceChange), args.Error(1)
+}
+
+func (m *MockPriceChangeRepo) FindScheduledByProductID(ctx context.Context, productID, userID uint, status domain.ScheduledPriceStatus) ([]*domain.ScheduledPriceChange, error) {
+	args := m.Called(ctx, productID, userID, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ScheduledPriceChange), args.Error(1)
+}
+
+func (m *MockPriceChangeRepo) LockDueScheduled(ctx context.Context, t time.Time, limit int) ([]*domain.ScheduledPriceChange, error) {
+	args := m.Called(ctx, t, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ScheduledPriceChange), args.Error(1)
+}
+
+func (m *MockPriceChangeRepo) UpdateScheduled(ctx context.Context, change *domain.ScheduledPriceChange) error {
+	args := m.Called(ctx, change)
+	return args.Error(0)
+}
+
+func TestUpdateProduct_RecordsPriceChange(t *testing.T) {
+	productRepo := new(MockProductRepo)
+	priceChangeRepo := new(MockPriceChangeRepo)
+	events := &recordingEvents{}
+	productService := service.NewProductService(productRepo,
+		service.WithProductEvents(events),
+		service.WithProductPriceHistory(priceChangeRepo),
+	)
+
+	mug := &domain.Product{ID: 2, UserID: 1, Code: "MUG", Name: "Mug", Price: 5, Stock: 10}
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(mug, nil)
+	productRepo.On("Update", mock.Anything, mug, uint(1)).Return(nil)
+	var recorded *domain.PriceChange
+	priceChangeRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.PriceChange")).Run(func(args mock.Arguments) {
+		recorded = args.Get(1).(*domain.PriceChange)
+	}).Return(nil).Once()
+
+	price := 6.5
+	_, err := productService.UpdateProduct(context.Background(), 2, 1, nil, nil, nil, &price, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, recorded.OldPrice)
+	assert.Equal(t, 6.5, recorded.NewPrice)
+	assert.Equal(t, domain.PriceChangeSourceManual, recorded.Source)
+	assert.Equal(t, uint(1), *recorded.ChangedBy)
+	assert.Equal(t, domain.ProductPriceChanged{ProductID: 2, Code: "MUG", OldPrice: 5, NewPrice: 6.5, Source: "manual"}, events.events[0])
+
+	// Setting the same price again is not a change.
+	_, err = productService.UpdateProduct(context.Background(), 2, 1, nil, nil, nil, &price, nil)
+	assert.NoError(t, err)
+	priceChangeRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestSchedulePriceChange_Validation(t *testing.T) {
+	priceChangeRepo := new(MockPriceChangeRepo)
+	productRepo := new(MockProductRepo)
+	priceChangeService := service.NewPriceChangeService(priceChangeRepo, productRepo)
+
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(9), uint(1)).Return(nil, errors.New("record not found"))
+	future := time.Now().Add(24 * time.Hour)
+
+	_, err := priceChangeService.SchedulePriceChange(context.Background(), 2, 1, service.SchedulePriceRequest{Price: -1, EffectiveAt: future})
+	assert.EqualError(t, err, "price cannot be negative")
+	_, err = priceChangeService.SchedulePriceChange(context.Background(), 2, 1, service.SchedulePriceRequest{Price: 6})
+	assert.EqualError(t, err, "effective_at is required")
+	_, err = priceChangeService.SchedulePriceChange(context.Background(), 2, 1, service.SchedulePriceRequest{Price: 6, EffectiveAt: time.Now().Add(-time.Minute)})
+	assert.EqualError(t, err, "effective_at must be in the future")
+	_, err = priceChangeService.SchedulePriceChange(context.Background(), 9, 1, service.SchedulePriceRequest{Price: 6, EffectiveAt: future})
+	assert.EqualError(t, err, "product not found")
+	priceChangeRepo.AssertNotCalled(t, "CreateScheduled", mock.Anything, mock.Anything)
+}
+
+func TestApplyDue_AppliesScheduledPrices(t *testing.T) {
+	priceChangeRepo := new(MockPriceChangeRepo)
+	productRepo := new(MockProductRepo)
+	events := &recordingEvents{}
+	priceChangeService := service.NewPriceChangeService(priceChangeRepo, productRepo, service.WithPriceChangeEvents(events))
+
+	now := time.Date(2026, 11, 1, 0, 0, 30, 0, time.UTC)
+	mug := &domain.Product{ID: 2, UserID: 1, Code: "MUG", Name: "Mug", Price: 5}
+	due := &domain.ScheduledPriceChange{ID: 3, UserID: 1, ProductID: 2, Price: 5.5, EffectiveAt: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), Status: domain.ScheduledPricePending, CreatedBy: 1}
+	orphan := &domain.ScheduledPriceChange{ID: 4, UserID: 1, ProductID: 9, Price: 1, Status: domain.ScheduledPricePending, CreatedBy: 1}
+	priceChangeRepo.On("LockDueScheduled", mock.Anything, now, 100).Return([]*domain.ScheduledPriceChange{due, orphan}, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(mug, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(9), uint(1)).Return(nil, errors.New("record not found"))
+	productRepo.On("Update", mock.Anything, mug, uint(1)).Return(nil)
+	var recorded *domain.PriceChange
+	priceChangeRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.PriceChange")).Run(func(args mock.Arguments) {
+		recorded = args.Get(1).(*domain.PriceChange)
+	}).Return(nil)
+	priceChangeRepo.On("UpdateScheduled", mock.Anything, mock.Anything).Return(nil)
+
+	applied, err := priceChangeService.ApplyDue(context.Background(), now, 100)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, applied)
+	assert.Equal(t, 5.5, mug.Price)
+	assert.Equal(t, domain.ScheduledPriceApplied, due.Status)
+	assert.Equal(t, now, *due.AppliedAt)
+	assert.Equal(t, domain.ScheduledPriceCancelled, orphan.Status)
+	assert.Equal(t, domain.PriceChangeSourceScheduled, recorded.Source)
+	assert.Equal(t, 5.0, recorded.OldPrice)
+	assert.Len(t, events.events, 1)
+}
+
+func TestCancelScheduledChange_OnlyPending(t *testing.T) {
+	priceChangeRepo := new(MockPriceChangeRepo)
+	priceChangeService := service.NewPriceChangeService(priceChangeRepo, new(MockProductRepo))
+
+	pending := &domain.ScheduledPriceChange{ID: 3, UserID: 1, ProductID: 2, Status: domain.ScheduledPricePending}
+	applied := &domain.ScheduledPriceChange{ID: 4, UserID: 1, ProductID: 2, Status: domain.ScheduledPriceApplied}
+	priceChangeRepo.On("FindScheduledByIDAndUserID", mock.Anything, uint(3), uint(1)).Return(pending, nil)
+	priceChangeRepo.On("FindScheduledByIDAndUserID", mock.Anything, uint(4), uint(1)).Return(applied, nil)
+	priceChangeRepo.On("UpdateScheduled", mock.Anything, pending).Return(nil)
+
+	change, err := priceChangeService.CancelScheduledChange(context.Background(), 2, 3, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ScheduledPriceCancelled, change.Status)
+
+	_, err = priceChangeService.CancelScheduledChange(context.Background(), 2, 4, 1)
+	assert.EqualError(t, err, "only pending price changes can be cancelled")
+
+	_, err = priceChangeService.CancelScheduledChange(context.Background(), 5, 3, 1)
+	assert.EqualError(t, err, "scheduled price change not found")
+}
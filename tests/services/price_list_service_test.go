@@ -0,0 +1,241 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPriceListRepo struct {
+	mock.Mock
+}
+
+func (m *MockPriceListRepo) Create(ctx context.Context, list *domain.PriceList) error {
+	args := m.Called(ctx, list)
+	return args.Error(0)
+}
+
+func (m *MockPriceListRepo) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.PriceList, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PriceList), args.Error(1)
+}
+
+func (m *MockPriceListRepo) FindByNameAndUserID(ctx context.Context, name string, userID uint) (*domain.PriceList, error) {
+	args := m.Called(ctx, name, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PriceList), args.Error(1)
+}
+
+func (m *MockPriceListRepo) FindByUserID(ctx context.Context, userID uint) ([]*domain.PriceList, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PriceList), args.Error(1)
+}
+
+func (m *MockPriceListRepo) Update(ctx context.Context, list *domain.PriceList, userID uint) error {
+	args := m.Called(ctx, list, userID)
+	return args.Error(0)
+}
+
+func (m *MockPriceListRepo) Delete(ctx context.Context, id, userID uint) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+func (m *MockPriceListRepo) FindApplicable(ctx context.Context, userID uint, customerID, groupID *uint, productID uint, t time.Time) ([]*domain.PriceList, error) {
+	args := m.Called(ctx, userID, customerID, groupID, productID, t)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PriceList), args.Error(1)
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestPriceList_ValidAt(t *testing.T) {
+	list := &domain.PriceList{ValidFrom: datePtr(2024, 1, 1), ValidUntil: datePtr(2024, 2, 1)}
+
+	assert.False(t, list.ValidAt(time.Date(2023, 12, 31, 23, 0, 0, 0, time.UTC)))
+	assert.True(t, list.ValidAt(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, list.ValidAt(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, (&domain.PriceList{}).ValidAt(time.Now()))
+}
+
+func TestCreatePriceList_Validation(t *testing.T) {
+	priceListRepo := new(MockPriceListRepo)
+	customerRepo := new(MockCustomerRepo)
+	productRepo := new(MockProductRepo)
+	priceListService := service.NewPriceListService(priceListRepo, customerRepo, productRepo)
+
+	priceListRepo.On("FindByNameAndUserID", mock.Anything, "Wholesale", uint(1)).Return(nil, errors.New("record not found"))
+	priceListRepo.On("FindByNameAndUserID", mock.Anything, "Taken", uint(1)).Return(&domain.PriceList{ID: 7}, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(productWithVariants(), nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(9), uint(1)).Return(nil, errors.New("record not found"))
+	customerRepo.On("FindByIDAndUserID", mock.Anything, uint(8), uint(1)).Return(nil, errors.New("record not found"))
+
+	tests := []struct {
+		name string
+		req  service.PriceListRequest
+		err  string
+	}{
+		{"no name", service.PriceListRequest{}, "name is required"},
+		{"name taken", service.PriceListRequest{Name: "Taken"}, "price list name already exists for this user"},
+		{"validity", service.PriceListRequest{Name: "Wholesale", ValidFrom: datePtr(2024, 2, 1), ValidUntil: datePtr(2024, 1, 1)}, "valid_until must be after valid_from"},
+		{"price and discount", service.PriceListRequest{Name: "Wholesale", Prices: []service.PriceListPriceRequest{{ProductID: 1, Price: floatPtr(9), DiscountPercent: floatPtr(5)}}}, "exactly one of price and discount_percent is required"},
+		{"discount over 100", service.PriceListRequest{Name: "Wholesale", Prices: []service.PriceListPriceRequest{{ProductID: 1, DiscountPercent: floatPtr(120)}}}, "discount_percent must be greater than 0 and at most 100"},
+		{"unknown product", service.PriceListRequest{Name: "Wholesale", Prices: []service.PriceListPriceRequest{{ProductID: 9, Price: floatPtr(9)}}}, "product 9 not found"},
+		{"unknown variant", service.PriceListRequest{Name: "Wholesale", Prices: []service.PriceListPriceRequest{{ProductID: 1, VariantID: uintPtr(99), Price: floatPtr(9)}}}, "variant not found"},
+		{"duplicate break", service.PriceListRequest{Name: "Wholesale", Prices: []service.PriceListPriceRequest{
+			{ProductID: 1, MinQuantity: 10, Price: floatPtr(9)},
+			{ProductID: 1, MinQuantity: 10, DiscountPercent: floatPtr(5)},
+		}}, "product TSHIRT is priced twice from 10 units"},
+		{"unknown customer", service.PriceListRequest{Name: "Wholesale", CustomerIDs: []uint{8}}, "customer 8 not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := priceListService.CreatePriceList(context.Background(), 1, tt.req)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+	priceListRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreatePriceList_DefaultsMinQuantity(t *testing.T) {
+	priceListRepo := new(MockPriceListRepo)
+	customerRepo := new(MockCustomerRepo)
+	productRepo := new(MockProductRepo)
+	priceListService := service.NewPriceListService(priceListRepo, customerRepo, productRepo)
+
+	priceListRepo.On("FindByNameAndUserID", mock.Anything, "Wholesale", uint(1)).Return(nil, errors.New("record not found"))
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(productWithVariants(), nil)
+	customerRepo.On("FindGroupByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(&domain.CustomerGroup{ID: 2, Name: "Wholesale"}, nil)
+	var created *domain.PriceList
+	priceListRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.PriceList")).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*domain.PriceList)
+		created.ID = 3
+	}).Return(nil)
+	priceListRepo.On("FindByIDAndUserID", mock.Anything, uint(3), uint(1)).Return(&domain.PriceList{ID: 3}, nil)
+
+	_, err := priceListService.CreatePriceList(context.Background(), 1, service.PriceListRequest{
+		Name:     " Wholesale ",
+		Prices:   []service.PriceListPriceRequest{{ProductID: 1, Price: floatPtr(9)}, {ProductID: 1, MinQuantity: 10, DiscountPercent: floatPtr(20)}},
+		GroupIDs: []uint{2, 2},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Wholesale", created.Name)
+	assert.Equal(t, 1, created.Prices[0].MinQuantity)
+	assert.Equal(t, 10, created.Prices[1].MinQuantity)
+	assert.Len(t, created.Groups, 1)
+}
+
+func TestCreateOrder_UsesBestApplicablePrice(t *testing.T) {
+	orderRepo := new(MockOrderRepo)
+	productRepo := new(MockProductRepo)
+	variantRepo := new(MockVariantRepo)
+	priceListRepo := new(MockPriceListRepo)
+	customerRepo := new(MockCustomerRepo)
+	orderService := service.NewOrderService(orderRepo, productRepo,
+		service.WithOrderVariants(variantRepo),
+		service.WithOrderPriceLists(priceListRepo, customerRepo),
+	)
+
+	shirt := productWithVariants()
+	shirt.Variants[0].Stock = 40
+	customer := wholesaleCustomer()
+	// Red t-shirts cost 10. The wholesale list takes 5% off from 10 units
+	// and sets 8.75 for red from 20; the promotion sets 9.80 for any variant.
+	wholesale := &domain.PriceList{ID: 2, Name: "Wholesale", Prices: []domain.PriceListPrice{
+		{ProductID: 1, MinQuantity: 10, DiscountPercent: floatPtr(5)},
+		{ProductID: 1, VariantID: uintPtr(5), MinQuantity: 20, Price: floatPtr(8.75)},
+	}}
+	promotion := &domain.PriceList{ID: 3, Name: "Promotion", Prices: []domain.PriceListPrice{
+		{ProductID: 1, MinQuantity: 1, Price: floatPtr(9.8)},
+	}}
+	customerRepo.On("FindByIDAndUserID", mock.Anything, uint(4), uint(1)).Return(customer, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(shirt, nil)
+	productRepo.On("Update", mock.Anything, shirt, uint(1)).Return(nil)
+	variantRepo.On("UpdateVariant", mock.Anything, mock.Anything).Return(nil)
+	priceListRepo.On("FindApplicable", mock.Anything, uint(1), uintPtr(4), uintPtr(2), uint(1), mock.Anything).
+		Return([]*domain.PriceList{wholesale, promotion}, nil)
+	var created *domain.Order
+	orderRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Order")).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*domain.Order)
+	}).Return(nil)
+	orderRepo.On("FindByIDAndUserID", mock.Anything, mock.Anything, uint(1)).Return(&domain.Order{ID: 1}, nil)
+
+	_, err := orderService.CreateOrder(context.Background(), 1, service.CreateOrderRequest{
+		CustomerID: uintPtr(4),
+		Items: []service.OrderItemRequest{
+			{ProductID: 1, VariantID: uintPtr(5), Quantity: 2},
+			{ProductID: 1, VariantID: uintPtr(5), Quantity: 10},
+			{ProductID: 1, VariantID: uintPtr(5), Quantity: 20},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(4), *created.CustomerID)
+	assert.Equal(t, 9.8, created.Items[0].UnitPrice)
+	assert.Equal(t, uint(3), *created.Items[0].PriceListID)
+	assert.Equal(t, 9.5, created.Items[1].UnitPrice)
+	assert.Equal(t, uint(2), *created.Items[1].PriceListID)
+	assert.Equal(t, 8.75, created.Items[2].UnitPrice)
+	assert.Equal(t, uint(2), *created.Items[2].PriceListID)
+}
+
+func TestCreateOrder_KeepsRegularPriceWithoutBetterList(t *testing.T) {
+	orderRepo := new(MockOrderRepo)
+	productRepo := new(MockProductRepo)
+	priceListRepo := new(MockPriceListRepo)
+	orderService := service.NewOrderService(orderRepo, productRepo, service.WithOrderPriceLists(priceListRepo, new(MockCustomerRepo)))
+
+	mug := &domain.Product{ID: 2, UserID: 1, Name: "Mug", Price: 5, Stock: 10}
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(mug, nil)
+	productRepo.On("Update", mock.Anything, mug, uint(1)).Return(nil)
+	priceListRepo.On("FindApplicable", mock.Anything, uint(1), (*uint)(nil), (*uint)(nil), uint(2), mock.Anything).
+		Return([]*domain.PriceList{{ID: 3, Prices: []domain.PriceListPrice{{ProductID: 2, MinQuantity: 1, Price: floatPtr(6)}}}}, nil)
+	var created *domain.Order
+	orderRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Order")).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*domain.Order)
+	}).Return(nil)
+	orderRepo.On("FindByIDAndUserID", mock.Anything, mock.Anything, uint(1)).Return(&domain.Order{ID: 1}, nil)
+
+	_, err := orderService.CreateOrder(context.Background(), 1, service.CreateOrderRequest{
+		Items: []service.OrderItemRequest{{ProductID: 2, Quantity: 1}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, created.Items[0].UnitPrice)
+	assert.Nil(t, created.Items[0].PriceListID)
+}
+
+func TestCreateOrder_UnknownCustomer(t *testing.T) {
+	orderRepo := new(MockOrderRepo)
+	customerRepo := new(MockCustomerRepo)
+	orderService := service.NewOrderService(orderRepo, new(MockProductRepo), service.WithOrderPriceLists(new(MockPriceListRepo), customerRepo))
+
+	customerRepo.On("FindByIDAndUserID", mock.Anything, uint(4), uint(1)).Return(nil, errors.New("record not found"))
+
+	_, err := orderService.CreateOrder(context.Background(), 1, service.CreateOrderRequest{
+		CustomerID: uintPtr(4),
+		Items:      []service.OrderItemRequest{{ProductID: 2, Quantity: 1}},
+	})
+
+	assert.EqualError(t, err, "customer not found")
+}
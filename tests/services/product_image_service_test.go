@@ -0,0 +1,255 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+	"vertice-backend/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProductImageRepo struct {
+	mock.Mock
+}
+
+func (m *MockProductImageRepo) Create(ctx context.Context, image *domain.ProductImage) error {
+	args := m.Called(ctx, image)
+	return args.Error(0)
+}
+
+func (m *MockProductImageRepo) FindByProductID(ctx context.Context, productID, userID uint) ([]*domain.ProductImage, error) {
+	args := m.Called(ctx, productID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ProductImage), args.Error(1)
+}
+
+func (m *MockProductImageRepo) UpdateOrder(ctx context.Context, images []*domain.ProductImage) error {
+	args := m.Called(ctx, images)
+	return args.Error(0)
+}
+
+func (m *MockProductImageRepo) Delete(ctx context.Context, id, userID uint) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+// memoryBlobs is an in-memory storage.BlobStore.
+type memoryBlobs struct {
+	blobs map[string][]byte
+	types map[string]string
+}
+
+func newMemoryBlobs() *memoryBlobs {
+	return &memoryBlobs{blobs: map[string][]byte{}, types: map[string]string{}}
+}
+
+func (m *memoryBlobs) Put(_ context.Context, key string, body []byte, contentType string) error {
+	m.blobs[key] = body
+	m.types[key] = contentType
+	return nil
+}
+
+func (m *memoryBlobs) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	body, ok := m.blobs[key]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(body)), nil
+}
+
+func (m *memoryBlobs) Delete(_ context.Context, key string) error {
+	delete(m.blobs, key)
+	return nil
+}
+
+func (m *memoryBlobs) SignedURL(key string, _ time.Duration) (string, error) {
+	return "https://blobs.test/" + key + "?signature=x", nil
+}
+
+func pngImage(width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+func TestUploadImage_StoresOriginalAndThumbnail(t *testing.T) {
+	mockImageRepo := new(MockProductImageRepo)
+	mockProductRepo := new(MockProductRepo)
+	blobs := newMemoryBlobs()
+	imageService := service.NewProductImageService(mockImageRepo, mockProductRepo, blobs)
+
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Product{ID: 1, UserID: 1}, nil)
+	mockImageRepo.On("FindByProductID", mock.Anything, uint(1), uint(1)).Return([]*domain.ProductImage{}, nil)
+	mockImageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.ProductImage")).Return(nil)
+
+	img, err := imageService.Upload(context.Background(), 1, 1, `C:\photos\front.png`, bytes.NewReader(pngImage(800, 400)))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", img.ContentType)
+	assert.Equal(t, "front.png", img.Filename)
+	assert.Equal(t, 800, img.Width)
+	assert.True(t, img.Primary)
+	assert.True(t, strings.HasPrefix(img.Key, "products/1/1/"))
+	assert.Contains(t, blobs.blobs, img.Key)
+
+	thumb, err := png.DecodeConfig(bytes.NewReader(blobs.blobs[img.ThumbnailKey]))
+	assert.NoError(t, err)
+	assert.Equal(t, 320, thumb.Width)
+	assert.Equal(t, 160, thumb.Height)
+}
+
+func TestUploadImage_AppendsAfterExistingImages(t *testing.T) {
+	mockImageRepo := new(MockProductImageRepo)
+	mockProductRepo := new(MockProductRepo)
+	imageService := service.NewProductImageService(mockImageRepo, mockProductRepo, newMemoryBlobs())
+
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Product{ID: 1, UserID: 1}, nil)
+	mockImageRepo.On("FindByProductID", mock.Anything, uint(1), uint(1)).Return([]*domain.ProductImage{{ID: 1, Primary: true}}, nil)
+	mockImageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.ProductImage")).Return(nil)
+
+	img, err := imageService.Upload(context.Background(), 1, 1, "side.png", bytes.NewReader(pngImage(10, 10)))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, img.Position)
+	assert.False(t, img.Primary)
+}
+
+func TestUploadImage_Error_NotAnImage(t *testing.T) {
+	mockProductRepo := new(MockProductRepo)
+	blobs := newMemoryBlobs()
+	imageService := service.NewProductImageService(new(MockProductImageRepo), mockProductRepo, blobs)
+
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Product{ID: 1}, nil)
+
+	_, err := imageService.Upload(context.Background(), 1, 1, "fake.png", strings.NewReader("<html>not an image</html>"))
+
+	assert.EqualError(t, err, "unsupported image type: text/html; charset=utf-8")
+	assert.Empty(t, blobs.blobs)
+}
+
+func TestUploadImage_Error_TooLarge(t *testing.T) {
+	mockProductRepo := new(MockProductRepo)
+	imageService := service.NewProductImageService(new(MockProductImageRepo), mockProductRepo, newMemoryBlobs(), service.WithImageMaxSize(100))
+
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Product{ID: 1}, nil)
+
+	_, err := imageService.Upload(context.Background(), 1, 1, "big.png", bytes.NewReader(pngImage(64, 64)))
+
+	assert.EqualError(t, err, "image is larger than 100 bytes")
+}
+
+func TestUploadImage_Error_SaveFailsRemovesBlobs(t *testing.T) {
+	mockImageRepo := new(MockProductImageRepo)
+	mockProductRepo := new(MockProductRepo)
+	blobs := newMemoryBlobs()
+	imageService := service.NewProductImageService(mockImageRepo, mockProductRepo, blobs)
+
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Product{ID: 1}, nil)
+	mockImageRepo.On("FindByProductID", mock.Anything, uint(1), uint(1)).Return([]*domain.ProductImage{}, nil)
+	mockImageRepo.On("Create", mock.Anything, mock.Anything).Return(errors.New("db down"))
+
+	_, err := imageService.Upload(context.Background(), 1, 1, "a.png", bytes.NewReader(pngImage(4, 4)))
+
+	assert.EqualError(t, err, "db down")
+	assert.Empty(t, blobs.blobs)
+}
+
+func TestReorderImages_Error_IncompleteList(t *testing.T) {
+	mockImageRepo := new(MockProductImageRepo)
+	imageService := service.NewProductImageService(mockImageRepo, new(MockProductRepo), newMemoryBlobs())
+
+	images := []*domain.ProductImage{{ID: 1}, {ID: 2}}
+	mockImageRepo.On("FindByProductID", mock.Anything, uint(1), uint(1)).Return(images, nil)
+
+	_, err := imageService.Reorder(context.Background(), 1, 1, []uint{2, 2})
+
+	assert.EqualError(t, err, "image_ids must list every image of the product once")
+	mockImageRepo.AssertNotCalled(t, "UpdateOrder", mock.Anything, mock.Anything)
+}
+
+func TestReorderImages_Success(t *testing.T) {
+	mockImageRepo := new(MockProductImageRepo)
+	imageService := service.NewProductImageService(mockImageRepo, new(MockProductRepo), newMemoryBlobs())
+
+	images := []*domain.ProductImage{{ID: 1, Position: 0}, {ID: 2, Position: 1}, {ID: 3, Position: 2}}
+	mockImageRepo.On("FindByProductID", mock.Anything, uint(1), uint(1)).Return(images, nil)
+	mockImageRepo.On("UpdateOrder", mock.Anything, mock.Anything).Return(nil)
+
+	result, err := imageService.Reorder(context.Background(), 1, 1, []uint{3, 1, 2})
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), result[0].ID)
+	assert.Equal(t, 0, result[0].Position)
+	assert.Equal(t, 2, result[2].Position)
+}
+
+func TestDeleteImage_PromotesNextPrimary(t *testing.T) {
+	mockImageRepo := new(MockProductImageRepo)
+	blobs := newMemoryBlobs()
+	imageService := service.NewProductImageService(mockImageRepo, new(MockProductRepo), blobs)
+
+	blobs.blobs["a.png"], blobs.blobs["a-thumb.png"] = []byte("a"), []byte("a")
+	images := []*domain.ProductImage{
+		{ID: 1, Key: "a.png", ThumbnailKey: "a-thumb.png", Position: 0, Primary: true},
+		{ID: 2, Position: 1},
+		{ID: 3, Position: 2},
+	}
+	mockImageRepo.On("FindByProductID", mock.Anything, uint(1), uint(1)).Return(images, nil)
+	mockImageRepo.On("Delete", mock.Anything, uint(1), uint(1)).Return(nil)
+	mockImageRepo.On("UpdateOrder", mock.Anything, mock.Anything).Return(nil)
+
+	err := imageService.DeleteImage(context.Background(), 1, 1, 1)
+
+	assert.NoError(t, err)
+	assert.True(t, images[1].Primary)
+	assert.Equal(t, 0, images[1].Position)
+	assert.Equal(t, 1, images[2].Position)
+	assert.Empty(t, blobs.blobs)
+}
+
+func TestSetPrimaryImage_Error_NotFound(t *testing.T) {
+	mockImageRepo := new(MockProductImageRepo)
+	imageService := service.NewProductImageService(mockImageRepo, new(MockProductRepo), newMemoryBlobs())
+
+	mockImageRepo.On("FindByProductID", mock.Anything, uint(1), uint(1)).Return([]*domain.ProductImage{{ID: 1, Primary: true}}, nil)
+
+	_, err := imageService.SetPrimary(context.Background(), 1, 9, 1)
+
+	assert.EqualError(t, err, "image not found")
+}
+
+func TestDeleteProduct_RemovesImageBlobs(t *testing.T) {
+	mockProductRepo := new(MockProductRepo)
+	blobs := newMemoryBlobs()
+	productService := service.NewProductService(mockProductRepo, service.WithProductBlobStore(blobs))
+
+	blobs.blobs["a.png"], blobs.blobs["a-thumb.png"] = []byte("a"), []byte("a")
+	product := &domain.Product{ID: 1, UserID: 1, Images: []domain.ProductImage{{Key: "a.png", ThumbnailKey: "a-thumb.png"}}}
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	mockProductRepo.On("Delete", mock.Anything, uint(1), uint(1)).Return(nil)
+
+	err := productService.DeleteProduct(context.Background(), 1, 1)
+
+	assert.NoError(t, err)
+	assert.Empty(t, blobs.blobs)
+}
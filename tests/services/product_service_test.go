@@ -38,6 +38,26 @@ func (m *MockProductRepo) FindByUserID(ctx context.Context, userID uint) ([]*dom
 	return args.Get(0).([]*domain.Product), args.Error(1)
 }
 
+func (m *MockProductRepo) FindByFilter(ctx context.Context, userID uint, filter domain.ProductFilter) ([]*domain.Product, error) {
+	args := m.Called(ctx, userID, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Product), args.Error(1)
+}
+
+// FindInBatches passes the batches given to Return to fn.
+func (m *MockProductRepo) FindInBatches(ctx context.Context, userID uint, filter domain.ProductFilter, batchSize int, fn func([]*domain.Product) error) error {
+	args := m.Called(ctx, userID, filter, batchSize)
+	batches, _ := args.Get(0).([][]*domain.Product)
+	for _, batch := range batches {
+		if err := fn(batch); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
 func (m *MockProductRepo) FindByCodeAndUserID(ctx context.Context, code string, userID uint) (*domain.Product, error) {
 	args := m.Called(ctx, code, userID)
 	if args.Get(0) == nil {
@@ -46,6 +66,14 @@ func (m *MockProductRepo) FindByCodeAndUserID(ctx context.Context, code string,
 	return args.Get(0).(*domain.Product), args.Error(1)
 }
 
+func (m *MockProductRepo) FindLowStockByUserID(ctx context.Context, userID uint) ([]*domain.Product, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Product), args.Error(1)
+}
+
 func (m *MockProductRepo) Update(ctx context.Context, product *domain.Product, userID uint) error {
 	args := m.Called(ctx, product, userID)
 	return args.Error(0)
@@ -56,6 +84,11 @@ func (m *MockProductRepo) Delete(ctx context.Context, id uint, userID uint) erro
 	return args.Error(0)
 }
 
+func (m *MockProductRepo) LockByIDs(ctx context.Context, ids []uint, userID uint) error {
+	args := m.Called(ctx, ids, userID)
+	return args.Error(0)
+}
+
 func TestCreateProduct_Success(t *testing.T) {
 	mockRepo := new(MockProductRepo)
 	service := service.NewProductService(mockRepo)
@@ -65,7 +98,7 @@ func TestCreateProduct_Success(t *testing.T) {
 	// Mock Create to succeed
 	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Product")).Return(nil)
 
-	product, err := service.CreateProduct(context.Background(), 1, "PROD001", "Test Product", "Test Description", 99.99, 10)
+	product, err := service.CreateProduct(context.Background(), 1, "PROD001", "Test Product", "Test Description", 99.99, 0, "", 10)
 
 	assert.NoError(t, err)
 	assert.Equal(t, uint(1), product.UserID)
@@ -77,11 +110,44 @@ func TestCreateProduct_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestCreateProduct_RecordsInitialStock(t *testing.T) {
+	mockRepo := new(MockProductRepo)
+	events := &recordingEvents{}
+	tx := &countingTransactor{}
+	productService := service.NewProductService(mockRepo, service.WithProductEvents(events), service.WithProductTransactor(tx))
+
+	mockRepo.On("FindByCodeAndUserID", mock.Anything, "PROD001", uint(1)).Return(nil, errors.New("not found"))
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Product")).Return(nil)
+
+	product, err := productService.CreateProduct(context.Background(), 1, "PROD001", "Test Product", "", 99.99, 60, domain.CostMethodStandard, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 60.0, product.Cost)
+	assert.Equal(t, domain.CostMethodStandard, product.CostMethod)
+	assert.Equal(t, 1, tx.calls)
+	if assert.Len(t, events.events, 1) {
+		adjusted := events.events[0].(domain.StockAdjusted)
+		assert.Equal(t, 0, adjusted.Previous)
+		assert.Equal(t, 10, adjusted.Delta)
+		assert.Equal(t, 60.0, adjusted.UnitCost)
+		assert.Equal(t, domain.StockReasonInitial, adjusted.Reason)
+	}
+}
+
+func TestCreateProduct_ValidationError_InvalidCostMethod(t *testing.T) {
+	mockRepo := new(MockProductRepo)
+	productService := service.NewProductService(mockRepo)
+
+	_, err := productService.CreateProduct(context.Background(), 1, "PROD001", "Test Product", "", 99.99, 60, "fifo", 10)
+
+	assert.EqualError(t, err, "cost_method must be standard or moving_average")
+}
+
 func TestCreateProduct_ValidationError_EmptyCode(t *testing.T) {
 	mockRepo := new(MockProductRepo)
 	service := service.NewProductService(mockRepo)
 
-	_, err := service.CreateProduct(context.Background(), 1, "", "Test Product", "Test Description", 99.99, 10)
+	_, err := service.CreateProduct(context.Background(), 1, "", "Test Product", "Test Description", 99.99, 0, "", 10)
 
 	assert.Error(t, err)
 	assert.Equal(t, "code and name are required", err.Error())
@@ -91,7 +157,7 @@ func TestCreateProduct_ValidationError_EmptyName(t *testing.T) {
 	mockRepo := new(MockProductRepo)
 	service := service.NewProductService(mockRepo)
 
-	_, err := service.CreateProduct(context.Background(), 1, "PROD001", "", "Test Description", 99.99, 10)
+	_, err := service.CreateProduct(context.Background(), 1, "PROD001", "", "Test Description", 99.99, 0, "", 10)
 
 	assert.Error(t, err)
 	assert.Equal(t, "code and name are required", err.Error())
@@ -101,7 +167,7 @@ func TestCreateProduct_ValidationError_NegativePrice(t *testing.T) {
 	mockRepo := new(MockProductRepo)
 	service := service.NewProductService(mockRepo)
 
-	_, err := service.CreateProduct(context.Background(), 1, "PROD001", "Test Product", "Test Description", -10.0, 10)
+	_, err := service.CreateProduct(context.Background(), 1, "PROD001", "Test Product", "Test Description", -10.0, 0, "", 10)
 
 	assert.Error(t, err)
 	assert.Equal(t, "price cannot be negative", err.Error())
@@ -111,7 +177,7 @@ func TestCreateProduct_ValidationError_NegativeStock(t *testing.T) {
 	mockRepo := new(MockProductRepo)
 	service := service.NewProductService(mockRepo)
 
-	_, err := service.CreateProduct(context.Background(), 1, "PROD001", "Test Product", "Test Description", 99.99, -5)
+	_, err := service.CreateProduct(context.Background(), 1, "PROD001", "Test Product", "Test Description", 99.99, 0, "", -5)
 
 	assert.Error(t, err)
 	assert.Equal(t, "stock cannot be negative", err.Error())
@@ -124,7 +190,7 @@ func TestCreateProduct_Error_CodeAlreadyExists(t *testing.T) {
 	existingProduct := &domain.Product{ID: 1, UserID: 1, Code: "PROD001", Name: "Existing Product"}
 	mockRepo.On("FindByCodeAndUserID", mock.Anything, "PROD001", uint(1)).Return(existingProduct, nil).Maybe()
 
-	_, err := service.CreateProduct(context.Background(), 1, "PROD001", "Test Product", "Test Description", 99.99, 10)
+	_, err := service.CreateProduct(context.Background(), 1, "PROD001", "Test Product", "Test Description", 99.99, 0, "", 10)
 
 	assert.Error(t, err)
 	assert.Equal(t, "product code already exists for this user", err.Error())
@@ -485,3 +551,196 @@ func TestUpdateProductPartial_Error_CodeConflict(t *testing.T) {
 	assert.Equal(t, "product code already exists for this user", err.Error())
 	mockRepo.AssertExpectations(t)
 }
+
+func TestUpdateProductStock_RecordsStockLowWhenCrossingReorderPoint(t *testing.T) {
+	mockRepo := new(MockProductRepo)
+	events := &recordingEvents{}
+	service := service.NewProductService(mockRepo, service.WithProductEvents(events))
+
+	product := &domain.Product{ID: 1, UserID: 1, Code: "PROD001", Name: "Laptop", Stock: 8, ReorderPoint: 5, ReorderQuantity: 20}
+	mockRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	mockRepo.On("Update", mock.Anything, product, uint(1)).Return(nil)
+
+	_, err := service.UpdateProductStock(context.Background(), 1, 1, -3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{domain.EventStockAdjusted, domain.EventProductStockLow}, events.types())
+	assert.Equal(t, domain.ProductStockLow{
+		ProductID:       1,
+		Code:            "PROD001",
+		Name:            "Laptop",
+		Stock:           5,
+		ReorderPoint:    5,
+		ReorderQuantity: 20,
+	}, events.events[1])
+}
+
+func TestUpdateProductStock_NoStockLowWhenAlreadyBelowReorderPoint(t *testing.T) {
+	mockRepo := new(MockProductRepo)
+	events := &recordingEvents{}
+	service := service.NewProductService(mockRepo, service.WithProductEvents(events))
+
+	product := &domain.Product{ID: 1, UserID: 1, Stock: 4, ReorderPoint: 5}
+	mockRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	mockRepo.On("Update", mock.Anything, product, uint(1)).Return(nil)
+
+	_, err := service.UpdateProductStock(context.Background(), 1, 1, -1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{domain.EventStockAdjusted}, events.types())
+}
+
+func TestUpdateProductStock_NoStockLowWhenRestocking(t *testing.T) {
+	mockRepo := new(MockProductRepo)
+	events := &recordingEvents{}
+	service := service.NewProductService(mockRepo, service.WithProductEvents(events))
+
+	product := &domain.Product{ID: 1, UserID: 1, Stock: 2, ReorderPoint: 5}
+	mockRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	mockRepo.On("Update", mock.Anything, product, uint(1)).Return(nil)
+
+	_, err := service.UpdateProductStock(context.Background(), 1, 1, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{domain.EventStockAdjusted}, events.types())
+}
+
+func TestUpdateReorderSettings_Success(t *testing.T) {
+	mockRepo := new(MockProductRepo)
+	events := &recordingEvents{}
+	service := service.NewProductService(mockRepo, service.WithProductEvents(events))
+
+	product := &domain.Product{ID: 1, UserID: 1, Stock: 10, ReorderPoint: 2, ReorderQuantity: 5}
+	mockRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	mockRepo.On("Update", mock.Anything, product, uint(1)).Return(nil)
+
+	reorderPoint := 10
+	updated, err := service.UpdateReorderSettings(context.Background(), 1, 1, &reorderPoint, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10, updated.ReorderPoint)
+	assert.Equal(t, 5, updated.ReorderQuantity)
+	assert.True(t, updated.LowStock())
+	assert.Equal(t, []string{domain.EventProductStockLow}, events.types())
+}
+
+func TestUpdateReorderSettings_AlreadyLow_NoEvent(t *testing.T) {
+	mockRepo := new(MockProductRepo)
+	events := &recordingEvents{}
+	service := service.NewProductService(mockRepo, service.WithProductEvents(events))
+
+	product := &domain.Product{ID: 1, UserID: 1, Stock: 3, ReorderPoint: 5}
+	mockRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	mockRepo.On("Update", mock.Anything, product, uint(1)).Return(nil)
+
+	reorderPoint := 8
+	_, err := service.UpdateReorderSettings(context.Background(), 1, 1, &reorderPoint, nil)
+
+	assert.NoError(t, err)
+	assert.Empty(t, events.types())
+}
+
+func TestUpdateReorderSettings_Error_Negative(t *testing.T) {
+	mockRepo := new(MockProductRepo)
+	service := service.NewProductService(mockRepo)
+
+	mockRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Product{ID: 1, UserID: 1}, nil)
+
+	reorderQuantity := -1
+	_, err := service.UpdateReorderSettings(context.Background(), 1, 1, nil, &reorderQuantity)
+
+	assert.EqualError(t, err, "reorder quantity cannot be negative")
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetLowStockProducts_Success(t *testing.T) {
+	mockRepo := new(MockProductRepo)
+	service := service.NewProductService(mockRepo)
+
+	products := []*domain.Product{{ID: 2, UserID: 1, Stock: 0, ReorderPoint: 3}}
+	mockRepo.On("FindLowStockByUserID", mock.Anything, uint(1)).Return(products, nil)
+
+	result, err := service.GetLowStockProducts(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, products, result)
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestBulkUpdateStock_Success(t *testing.T) {
+	mockRepo := new(MockProductRepo)
+	mockVariantRepo := new(MockVariantRepo)
+	events := &recordingEvents{}
+	productService := service.NewProductService(mockRepo, service.WithProductVariants(mockVariantRepo), service.WithProductEvents(events))
+
+	mug := &domain.Product{ID: 2, UserID: 1, Code: "MUG", Stock: 10}
+	shirt := productWithVariants()
+	mockRepo.On("FindByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(mug, nil)
+	mockRepo.On("FindByCodeAndUserID", mock.Anything, "TSHIRT", uint(1)).Return(shirt, nil)
+	mockRepo.On("Update", mock.Anything, mock.Anything, uint(1)).Return(nil)
+	mockVariantRepo.On("UpdateVariant", mock.Anything, mock.Anything).Return(nil)
+
+	results, err := productService.BulkUpdateStock(context.Background(), 1, []service.BulkStockEntry{
+		{ProductID: uintPtr(2), Delta: intPtr(-4), Reason: "damaged"},
+		{Code: "TSHIRT", VariantID: uintPtr(5), SetTo: intPtr(7)},
+		{Code: "TSHIRT", VariantID: uintPtr(6), SetTo: intPtr(0)},
+	})
+
+	assert.NoError(t, err)
+	if assert.Len(t, results, 3) {
+		assert.Equal(t, 10, results[0].Previous)
+		assert.Equal(t, 6, results[0].Stock)
+		assert.Equal(t, 7, results[1].Stock)
+		assert.Equal(t, "TSHIRT-RED", results[1].VariantCode)
+		assert.Equal(t, 0, results[2].Stock)
+		assert.Equal(t, 7, results[2].ProductStock)
+	}
+	assert.Equal(t, 6, mug.Stock)
+	assert.Equal(t, 7, shirt.Stock)
+	assert.Equal(t, "damaged", events.events[0].(domain.StockAdjusted).Reason)
+	assert.Equal(t, domain.StockReasonManual, events.events[1].(domain.StockAdjusted).Reason)
+}
+
+func TestBulkUpdateStock_InvalidEntriesApplyNothing(t *testing.T) {
+	mockRepo := new(MockProductRepo)
+	productService := service.NewProductService(mockRepo)
+
+	mockRepo.On("FindByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(&domain.Product{ID: 2, UserID: 1, Code: "MUG", Stock: 3}, nil)
+	mockRepo.On("FindByCodeAndUserID", mock.Anything, "MUG", uint(1)).Return(&domain.Product{ID: 2, UserID: 1, Code: "MUG", Stock: 3}, nil)
+	mockRepo.On("FindByCodeAndUserID", mock.Anything, "GONE", uint(1)).Return(nil, errors.New("not found"))
+	mockRepo.On("FindByCodeAndUserID", mock.Anything, "TSHIRT", uint(1)).Return(productWithVariants(), nil)
+
+	_, err := productService.BulkUpdateStock(context.Background(), 1, []service.BulkStockEntry{
+		{ProductID: uintPtr(2), Delta: intPtr(1)},
+		{Code: "GONE", Delta: intPtr(1)},
+		{Code: "MUG", SetTo: intPtr(5)},
+		{ProductID: uintPtr(2), Code: "MUG", Delta: intPtr(1)},
+		{Code: "TSHIRT", Delta: intPtr(1)},
+		{Code: "TSHIRT", VariantID: uintPtr(5), Delta: intPtr(-4)},
+		{Code: "TSHIRT", VariantID: uintPtr(6), Delta: intPtr(1), SetTo: intPtr(1)},
+	})
+
+	var entryErrs *service.EntryErrors
+	if assert.ErrorAs(t, err, &entryErrs) {
+		assert.Equal(t, []service.EntryError{
+			{Index: 1, Message: "product not found"},
+			{Index: 2, Message: "adjusts the same stock as entry 0"},
+			{Index: 3, Message: "exactly one of product_id and code is required"},
+			{Index: 4, Message: "variant_id is required for products with variants"},
+			{Index: 5, Message: "stock cannot be negative"},
+			{Index: 6, Message: "exactly one of delta and set_to is required"},
+		}, entryErrs.Entries)
+	}
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBulkUpdateStock_Error_Empty(t *testing.T) {
+	productService := service.NewProductService(new(MockProductRepo))
+
+	_, err := productService.BulkUpdateStock(context.Background(), 1, nil)
+
+	assert.EqualError(t, err, "at least one stock adjustment is required")
+}
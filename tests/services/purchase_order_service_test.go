@@ -0,0 +1,294 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPurchaseOrderRepo struct {
+	mock.Mock
+}
+
+func (m *MockPurchaseOrderRepo) Create(ctx context.Context, order *domain.PurchaseOrder) error {
+	args := m.Called(ctx, order)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseOrderRepo) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.PurchaseOrder, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PurchaseOrder), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepo) FindByUserID(ctx context.Context, userID uint, status domain.PurchaseOrderStatus) ([]*domain.PurchaseOrder, error) {
+	args := m.Called(ctx, userID, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PurchaseOrder), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepo) CountBySupplierID(ctx context.Context, supplierID, userID uint) (int64, error) {
+	args := m.Called(ctx, supplierID, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepo) Update(ctx context.Context, order *domain.PurchaseOrder, userID uint) error {
+	args := m.Called(ctx, order, userID)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseOrderRepo) Delete(ctx context.Context, id, userID uint) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseOrderRepo) CreateReceipt(ctx context.Context, receipt *domain.PurchaseOrderReceipt) error {
+	args := m.Called(ctx, receipt)
+	return args.Error(0)
+}
+
+func sentPurchaseOrder() *domain.PurchaseOrder {
+	return &domain.PurchaseOrder{
+		ID:         1,
+		UserID:     1,
+		SupplierID: 1,
+		Status:     domain.PurchaseOrderStatusSent,
+		Lines: []domain.PurchaseOrderLine{
+			{ID: 10, ProductID: 1, QuantityOrdered: 10, UnitCost: 5},
+			{ID: 11, ProductID: 2, QuantityOrdered: 4, UnitCost: 8},
+		},
+	}
+}
+
+func TestCreatePurchaseOrder_UsesSupplierUnitCost(t *testing.T) {
+	mockPORepo := new(MockPurchaseOrderRepo)
+	mockSupplierRepo := new(MockSupplierRepo)
+	mockProductRepo := new(MockProductRepo)
+	poService := service.NewPurchaseOrderService(mockPORepo, mockSupplierRepo, mockProductRepo)
+
+	explicitCost := 7.5
+	mockSupplierRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Supplier{ID: 1}, nil)
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Product{ID: 1}, nil)
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(&domain.Product{ID: 2}, nil)
+	mockSupplierRepo.On("FindProduct", mock.Anything, uint(1), uint(1), uint(1)).Return(&domain.SupplierProduct{UnitCost: 4}, nil)
+	mockPORepo.On("Create", mock.Anything, mock.MatchedBy(func(o *domain.PurchaseOrder) bool {
+		return o.Status == domain.PurchaseOrderStatusDraft &&
+			len(o.Lines) == 2 &&
+			o.Lines[0].UnitCost == 4 && o.Lines[0].Subtotal == 40 &&
+			o.Lines[1].UnitCost == 7.5 && o.Lines[1].Subtotal == 15 &&
+			o.TotalCost == 55
+	})).Return(nil)
+	mockPORepo.On("FindByIDAndUserID", mock.Anything, uint(0), uint(1)).Return(&domain.PurchaseOrder{}, nil)
+
+	_, err := poService.CreatePurchaseOrder(context.Background(), 1, service.PurchaseOrderRequest{
+		SupplierID: 1,
+		Lines: []service.PurchaseOrderLineRequest{
+			{ProductID: 1, Quantity: 10},
+			{ProductID: 2, Quantity: 2, UnitCost: &explicitCost},
+		},
+	})
+
+	assert.NoError(t, err)
+	mockPORepo.AssertExpectations(t)
+}
+
+func TestCreatePurchaseOrder_Error_SupplierNotFound(t *testing.T) {
+	mockSupplierRepo := new(MockSupplierRepo)
+	poService := service.NewPurchaseOrderService(new(MockPurchaseOrderRepo), mockSupplierRepo, new(MockProductRepo))
+
+	mockSupplierRepo.On("FindByIDAndUserID", mock.Anything, uint(9), uint(1)).Return(nil, errors.New("record not found"))
+
+	_, err := poService.CreatePurchaseOrder(context.Background(), 1, service.PurchaseOrderRequest{SupplierID: 9})
+
+	assert.EqualError(t, err, "supplier not found")
+}
+
+func TestSendPurchaseOrder_DerivesExpectedDateFromLeadTime(t *testing.T) {
+	mockPORepo := new(MockPurchaseOrderRepo)
+	mockSupplierRepo := new(MockSupplierRepo)
+	poService := service.NewPurchaseOrderService(mockPORepo, mockSupplierRepo, new(MockProductRepo))
+
+	order := sentPurchaseOrder()
+	order.Status = domain.PurchaseOrderStatusDraft
+	mockPORepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(order, nil)
+	mockSupplierRepo.On("FindProduct", mock.Anything, uint(1), uint(1), uint(1)).Return(&domain.SupplierProduct{LeadTimeDays: 3}, nil)
+	mockSupplierRepo.On("FindProduct", mock.Anything, uint(1), uint(2), uint(1)).Return(&domain.SupplierProduct{LeadTimeDays: 10}, nil)
+	mockPORepo.On("Update", mock.Anything, order, uint(1)).Return(nil)
+
+	result, err := poService.SendPurchaseOrder(context.Background(), 1, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PurchaseOrderStatusSent, result.Status)
+	assert.NotNil(t, result.SentAt)
+	assert.Equal(t, result.SentAt.AddDate(0, 0, 10), *result.ExpectedAt)
+}
+
+func TestReceivePurchaseOrder_PartialReceiptAddsStock(t *testing.T) {
+	mockPORepo := new(MockPurchaseOrderRepo)
+	mockProductRepo := new(MockProductRepo)
+	tx := &countingTransactor{}
+	events := &recordingEvents{}
+	poService := service.NewPurchaseOrderService(mockPORepo, new(MockSupplierRepo), mockProductRepo,
+		service.WithPurchaseOrderTransactor(tx),
+		service.WithPurchaseOrderEvents(events),
+	)
+
+	order := sentPurchaseOrder()
+	product := &domain.Product{ID: 1, UserID: 1, Stock: 2}
+	mockPORepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(order, nil)
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	mockProductRepo.On("Update", mock.Anything, product, uint(1)).Return(nil)
+	mockPORepo.On("CreateReceipt", mock.Anything, mock.MatchedBy(func(r *domain.PurchaseOrderReceipt) bool {
+		return len(r.Lines) == 1 && r.Lines[0].LineID == 10 && r.Lines[0].Quantity == 6
+	})).Return(nil)
+	mockPORepo.On("Update", mock.Anything, order, uint(1)).Return(nil)
+
+	_, err := poService.ReceivePurchaseOrder(context.Background(), 1, 1, service.ReceivePurchaseOrderRequest{
+		Lines: []service.ReceiveLineRequest{{LineID: 10, Quantity: 6}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, tx.calls)
+	assert.Equal(t, 8, product.Stock)
+	assert.Equal(t, 6, order.Lines[0].QuantityReceived)
+	assert.Equal(t, domain.PurchaseOrderStatusPartiallyReceived, order.Status)
+	assert.Nil(t, order.ReceivedAt)
+	assert.Equal(t, []string{domain.EventStockAdjusted}, events.types())
+	assert.Equal(t, domain.StockReasonPurchaseReceived, events.events[0].(domain.StockAdjusted).Reason)
+	mockPORepo.AssertExpectations(t)
+}
+
+func TestReceivePurchaseOrder_ReceivesEverythingOutstanding(t *testing.T) {
+	mockPORepo := new(MockPurchaseOrderRepo)
+	mockProductRepo := new(MockProductRepo)
+	poService := service.NewPurchaseOrderService(mockPORepo, new(MockSupplierRepo), mockProductRepo)
+
+	order := sentPurchaseOrder()
+	order.Status = domain.PurchaseOrderStatusPartiallyReceived
+	order.Lines[0].QuantityReceived = 6
+	first := &domain.Product{ID: 1, UserID: 1, Stock: 8}
+	second := &domain.Product{ID: 2, UserID: 1, Stock: 0}
+	mockPORepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(order, nil)
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(first, nil)
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(second, nil)
+	mockProductRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Product"), uint(1)).Return(nil)
+	mockPORepo.On("CreateReceipt", mock.Anything, mock.AnythingOfType("*domain.PurchaseOrderReceipt")).Return(nil)
+	mockPORepo.On("Update", mock.Anything, order, uint(1)).Return(nil)
+
+	_, err := poService.ReceivePurchaseOrder(context.Background(), 1, 1, service.ReceivePurchaseOrderRequest{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 12, first.Stock)
+	assert.Equal(t, 4, second.Stock)
+	assert.Equal(t, domain.PurchaseOrderStatusReceived, order.Status)
+	assert.NotNil(t, order.ReceivedAt)
+}
+
+func TestReceivePurchaseOrder_Error_ExceedsOutstanding(t *testing.T) {
+	mockPORepo := new(MockPurchaseOrderRepo)
+	mockProductRepo := new(MockProductRepo)
+	poService := service.NewPurchaseOrderService(mockPORepo, new(MockSupplierRepo), mockProductRepo)
+
+	mockPORepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(sentPurchaseOrder(), nil)
+
+	_, err := poService.ReceivePurchaseOrder(context.Background(), 1, 1, service.ReceivePurchaseOrderRequest{
+		Lines: []service.ReceiveLineRequest{{LineID: 11, Quantity: 3}, {LineID: 11, Quantity: 2}},
+	})
+
+	assert.EqualError(t, err, "quantity exceeds the outstanding quantity of the line")
+	mockProductRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReceivePurchaseOrder_Error_TrackingOfUntrackedProduct(t *testing.T) {
+	expires := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for want, line := range map[string]service.ReceiveLineRequest{
+		"lot_number is only accepted for batch-managed products: Mug": {LineID: 10, Quantity: 1, LotNumber: "L1"},
+		"expires_at is only accepted for batch-managed products: Mug": {LineID: 10, Quantity: 1, ExpiresAt: &expires},
+		"serials are only accepted for serialized products: Mug":      {LineID: 10, Quantity: 1, Serials: []string{"SN1"}},
+	} {
+		mockPORepo := new(MockPurchaseOrderRepo)
+		mockProductRepo := new(MockProductRepo)
+		poService := service.NewPurchaseOrderService(mockPORepo, new(MockSupplierRepo), mockProductRepo)
+
+		mockPORepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(sentPurchaseOrder(), nil)
+		mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Product{ID: 1, UserID: 1, Name: "Mug"}, nil)
+
+		_, err := poService.ReceivePurchaseOrder(context.Background(), 1, 1, service.ReceivePurchaseOrderRequest{
+			Lines: []service.ReceiveLineRequest{line},
+		})
+
+		assert.EqualError(t, err, want)
+		mockProductRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	}
+}
+
+func TestReceivePurchaseOrder_Error_Draft(t *testing.T) {
+	mockPORepo := new(MockPurchaseOrderRepo)
+	poService := service.NewPurchaseOrderService(mockPORepo, new(MockSupplierRepo), new(MockProductRepo))
+
+	order := sentPurchaseOrder()
+	order.Status = domain.PurchaseOrderStatusDraft
+	mockPORepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(order, nil)
+
+	_, err := poService.ReceivePurchaseOrder(context.Background(), 1, 1, service.ReceivePurchaseOrderRequest{})
+
+	assert.EqualError(t, err, "only sent purchase orders can be received")
+}
+
+func TestClosePurchaseOrder_PartiallyReceived(t *testing.T) {
+	mockPORepo := new(MockPurchaseOrderRepo)
+	poService := service.NewPurchaseOrderService(mockPORepo, new(MockSupplierRepo), new(MockProductRepo))
+
+	order := sentPurchaseOrder()
+	order.Status = domain.PurchaseOrderStatusPartiallyReceived
+	mockPORepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(order, nil)
+	mockPORepo.On("Update", mock.Anything, order, uint(1)).Return(nil)
+
+	result, err := poService.ClosePurchaseOrder(context.Background(), 1, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PurchaseOrderStatusClosed, result.Status)
+	assert.NotNil(t, result.ClosedAt)
+}
+
+func TestUpdatePurchaseOrder_Error_NotDraft(t *testing.T) {
+	mockPORepo := new(MockPurchaseOrderRepo)
+	poService := service.NewPurchaseOrderService(mockPORepo, new(MockSupplierRepo), new(MockProductRepo))
+
+	mockPORepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(sentPurchaseOrder(), nil)
+
+	notes := "rush"
+	_, err := poService.UpdatePurchaseOrder(context.Background(), 1, 1, service.UpdatePurchaseOrderRequest{Notes: &notes})
+
+	assert.EqualError(t, err, "only draft purchase orders can be edited")
+}
+
+func TestDeletePurchaseOrder_Error_NotDraft(t *testing.T) {
+	mockPORepo := new(MockPurchaseOrderRepo)
+	poService := service.NewPurchaseOrderService(mockPORepo, new(MockSupplierRepo), new(MockProductRepo))
+
+	mockPORepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(sentPurchaseOrder(), nil)
+
+	err := poService.DeletePurchaseOrder(context.Background(), 1, 1)
+
+	assert.EqualError(t, err, "only draft purchase orders can be deleted")
+}
+
+func TestGetPurchaseOrdersByUser_Error_InvalidStatus(t *testing.T) {
+	poService := service.NewPurchaseOrderService(new(MockPurchaseOrderRepo), new(MockSupplierRepo), new(MockProductRepo))
+
+	_, err := poService.GetPurchaseOrdersByUser(context.Background(), 1, "shipped")
+
+	assert.EqualError(t, err, "invalid status")
+}
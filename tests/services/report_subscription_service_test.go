@@ -0,0 +1,250 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockReportSubscriptionRepo struct {
+	mock.Mock
+}
+
+func (m *MockReportSubscriptionRepo) Create(ctx context.Context, subscription *domain.ReportSubscription) error {
+	args := m.Called(ctx, subscription)
+	return args.Error(0)
+}
+
+func (m *MockReportSubscriptionRepo) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.ReportSubscription, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ReportSubscription), args.Error(1)
+}
+
+func (m *MockReportSubscriptionRepo) FindByUserID(ctx context.Context, userID uint) ([]*domain.ReportSubscription, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ReportSubscription), args.Error(1)
+}
+
+func (m *MockReportSubscriptionRepo) Update(ctx context.Context, subscription *domain.ReportSubscription) error {
+	args := m.Called(ctx, subscription)
+	return args.Error(0)
+}
+
+func (m *MockReportSubscriptionRepo) UpdateLastError(ctx context.Context, id uint, lastError string) error {
+	args := m.Called(ctx, id, lastError)
+	return args.Error(0)
+}
+
+func (m *MockReportSubscriptionRepo) Delete(ctx context.Context, id, userID uint) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+func (m *MockReportSubscriptionRepo) LockDue(ctx context.Context, t time.Time, limit int) ([]*domain.ReportSubscription, error) {
+	args := m.Called(ctx, t, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ReportSubscription), args.Error(1)
+}
+
+func TestCreateReportSubscription_SchedulesInTimeZone(t *testing.T) {
+	mockRepo := new(MockReportSubscriptionRepo)
+	reportService := service.NewReportSubscriptionService(mockRepo, nil, nil, nil)
+
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.ReportSubscription")).Return(nil)
+
+	subscription, err := reportService.CreateSubscription(context.Background(), 1, service.CreateReportSubscriptionRequest{
+		Report:     domain.ReportDailySales,
+		Cron:       "30 8 * * *",
+		Timezone:   "Europe/Madrid",
+		Recipients: []string{"owner@example.com", "ops@example.com"},
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, subscription.Enabled)
+	assert.Equal(t, "owner@example.com,ops@example.com", subscription.Recipients)
+	madrid, _ := time.LoadLocation("Europe/Madrid")
+	next := subscription.NextRunAt.In(madrid)
+	assert.Equal(t, 8, next.Hour())
+	assert.Equal(t, 30, next.Minute())
+	assert.True(t, next.After(time.Now()))
+}
+
+func TestCreateReportSubscription_Errors(t *testing.T) {
+	reportService := service.NewReportSubscriptionService(new(MockReportSubscriptionRepo), nil, nil, nil)
+
+	tests := []struct {
+		req  service.CreateReportSubscriptionRequest
+		want string
+	}{
+		{service.CreateReportSubscriptionRequest{Report: "weekly_pnl", Cron: "@daily"}, "report must be daily_sales or low_stock"},
+		{service.CreateReportSubscriptionRequest{Report: domain.ReportLowStock, Cron: "0 25 * * *"}, `cron: invalid hour: "25"`},
+		{service.CreateReportSubscriptionRequest{Report: domain.ReportLowStock, Cron: "@daily", Timezone: "Mars/Olympus"}, "timezone must be an IANA time zone such as Europe/Madrid"},
+		{service.CreateReportSubscriptionRequest{Report: domain.ReportLowStock, Cron: "@daily", Timezone: "Local"}, "timezone must be an IANA time zone such as Europe/Madrid"},
+		{service.CreateReportSubscriptionRequest{Report: domain.ReportLowStock, Cron: "0 0 31 2 *"}, "cron expression never fires"},
+		{service.CreateReportSubscriptionRequest{Report: domain.ReportLowStock, Cron: "@daily", Recipients: []string{"Owner <owner@example.com>"}}, `invalid recipient "Owner <owner@example.com>"`},
+	}
+	for _, tt := range tests {
+		_, err := reportService.CreateSubscription(context.Background(), 1, tt.req)
+		assert.EqualError(t, err, tt.want)
+	}
+}
+
+func TestUpdateReportSubscription_DisablingClearsNextRun(t *testing.T) {
+	mockRepo := new(MockReportSubscriptionRepo)
+	reportService := service.NewReportSubscriptionService(mockRepo, nil, nil, nil)
+
+	next := time.Now().Add(time.Hour)
+	mockRepo.On("FindByIDAndUserID", mock.Anything, uint(3), uint(1)).Return(&domain.ReportSubscription{
+		ID: 3, UserID: 1, Report: domain.ReportLowStock, Cron: "@hourly", Timezone: "UTC", Enabled: true, NextRunAt: &next,
+	}, nil)
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.ReportSubscription")).Return(nil)
+
+	disabled := false
+	subscription, err := reportService.UpdateSubscription(context.Background(), 3, 1, service.UpdateReportSubscriptionRequest{Enabled: &disabled})
+
+	assert.NoError(t, err)
+	assert.False(t, subscription.Enabled)
+	assert.Nil(t, subscription.NextRunAt)
+}
+
+func TestSendDue_DailySales(t *testing.T) {
+	mockRepo := new(MockReportSubscriptionRepo)
+	mockAnalyticsRepo := new(MockAnalyticsRepo)
+	mockUserRepo := new(MockUserRepo)
+	m := &recordingMailer{}
+	tx := &countingTransactor{}
+	reportService := service.NewReportSubscriptionService(mockRepo, service.NewAnalyticsService(mockAnalyticsRepo), nil, mockUserRepo,
+		service.WithReportMailer(m),
+		service.WithReportTransactor(tx),
+	)
+
+	// 08:00 in Madrid, so the report covers 2024-03-01 there.
+	now := time.Date(2024, 3, 2, 7, 0, 0, 0, time.UTC)
+	madrid, _ := time.LoadLocation("Europe/Madrid")
+	yesterday := func(f domain.SalesFilter) bool {
+		return f.From.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, madrid)) && f.To.Equal(time.Date(2024, 3, 2, 0, 0, 0, 0, madrid))
+	}
+	mockRepo.On("LockDue", mock.Anything, now, 1).Return([]*domain.ReportSubscription{
+		{ID: 3, UserID: 1, Report: domain.ReportDailySales, Cron: "0 8 * * *", Timezone: "Europe/Madrid", Enabled: true, NextRunAt: &now},
+	}, nil).Once()
+	mockRepo.On("LockDue", mock.Anything, now, 1).Return([]*domain.ReportSubscription{}, nil)
+	mockAnalyticsRepo.On("StatusCounts", mock.Anything, uint(1), mock.MatchedBy(yesterday)).Return([]domain.StatusCount{
+		{Status: domain.OrderStatusDelivered, Orders: 3, Revenue: 120},
+		{Status: domain.OrderStatusCancelled, Orders: 1, Revenue: 40},
+	}, nil)
+	mockAnalyticsRepo.On("TopProducts", mock.Anything, uint(1), mock.MatchedBy(yesterday), false, 5).Return([]domain.ProductSales{
+		{ProductID: 7, Code: "MOUSE", Name: "Mouse", Quantity: 4, Revenue: 80},
+	}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, uint(1)).Return(&domain.User{ID: 1, Email: "owner@example.com"}, nil)
+	mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(s *domain.ReportSubscription) bool {
+		return s.LastRunAt.Equal(now) && s.LastError == "" &&
+			s.NextRunAt.Equal(time.Date(2024, 3, 3, 7, 0, 0, 0, time.UTC))
+	})).Return(nil)
+
+	sent, err := reportService.SendDue(context.Background(), now, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, 2, tx.calls, "one claim per subscription and one that finds nothing due")
+	if assert.Len(t, m.sent, 1) {
+		msg := m.sent[0]
+		assert.Equal(t, []string{"owner@example.com"}, msg.To)
+		assert.Equal(t, "Daily sales for 2024-03-01", msg.Subject)
+		assert.Contains(t, msg.Text, "Revenue:             120.00")
+		assert.Contains(t, msg.Text, "- Mouse (MOUSE): 4 sold, 80.00")
+		assert.Contains(t, msg.HTML, "<td>Mouse</td>")
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSendDue_RecordsFailure(t *testing.T) {
+	mockRepo := new(MockReportSubscriptionRepo)
+	mockProductRepo := new(MockProductRepo)
+	m := &recordingMailer{err: errors.New("connection refused")}
+	reportService := service.NewReportSubscriptionService(mockRepo, nil, mockProductRepo, nil, service.WithReportMailer(m))
+
+	now := time.Date(2024, 3, 2, 7, 0, 0, 0, time.UTC)
+	mockRepo.On("LockDue", mock.Anything, now, 1).Return([]*domain.ReportSubscription{
+		{ID: 4, UserID: 1, Report: domain.ReportLowStock, Cron: "@daily", Timezone: "UTC", Recipients: "ops@example.com", Enabled: true, NextRunAt: &now},
+	}, nil).Once()
+	mockRepo.On("LockDue", mock.Anything, now, 1).Return([]*domain.ReportSubscription{}, nil)
+	mockProductRepo.On("FindLowStockByUserID", mock.Anything, uint(1)).Return([]*domain.Product{
+		{ID: 2, Code: "KEYB", Name: "Keyboard", Stock: 1, ReorderPoint: 5},
+	}, nil)
+	mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(s *domain.ReportSubscription) bool {
+		return s.LastError == "" && s.NextRunAt.Equal(time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC))
+	})).Return(nil)
+	mockRepo.On("UpdateLastError", mock.Anything, uint(4), "connection refused").Return(nil)
+
+	sent, err := reportService.SendDue(context.Background(), now, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSendDue_ScheduleCommittedBeforeSending(t *testing.T) {
+	mockRepo := new(MockReportSubscriptionRepo)
+	mockProductRepo := new(MockProductRepo)
+	var order []string
+	m := &recordingMailer{}
+	tx := &countingTransactor{}
+	reportService := service.NewReportSubscriptionService(mockRepo, nil, mockProductRepo, nil,
+		service.WithReportMailer(m),
+		service.WithReportTransactor(tx),
+	)
+
+	now := time.Date(2024, 3, 2, 7, 0, 0, 0, time.UTC)
+	mockRepo.On("LockDue", mock.Anything, now, 1).Return([]*domain.ReportSubscription{
+		{ID: 4, UserID: 1, Report: domain.ReportLowStock, Cron: "@daily", Timezone: "UTC", Recipients: "ops@example.com", Enabled: true, NextRunAt: &now},
+	}, nil).Once()
+	mockRepo.On("LockDue", mock.Anything, now, 1).Return([]*domain.ReportSubscription{
+		{ID: 5, UserID: 1, Report: domain.ReportLowStock, Cron: "@daily", Timezone: "UTC", Recipients: "ops@example.com", Enabled: true, NextRunAt: &now},
+	}, nil).Once()
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.ReportSubscription")).Run(func(args mock.Arguments) {
+		order = append(order, fmt.Sprintf("schedule %d", args.Get(1).(*domain.ReportSubscription).ID))
+	}).Return(nil)
+	mockProductRepo.On("FindLowStockByUserID", mock.Anything, uint(1)).Run(func(mock.Arguments) {
+		order = append(order, fmt.Sprintf("send after %d transactions", tx.calls))
+	}).Return([]*domain.Product{}, nil)
+
+	sent, err := reportService.SendDue(context.Background(), now, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, 2, tx.calls, "the limit stops claiming")
+	assert.Equal(t, []string{"schedule 4", "send after 1 transactions", "schedule 5", "send after 2 transactions"}, order)
+	assert.Len(t, m.sent, 2)
+}
+
+func TestSendNow_Error_NoMailer(t *testing.T) {
+	mockRepo := new(MockReportSubscriptionRepo)
+	reportService := service.NewReportSubscriptionService(mockRepo, nil, nil, nil)
+
+	mockRepo.On("FindByIDAndUserID", mock.Anything, uint(3), uint(1)).Return(&domain.ReportSubscription{
+		ID: 3, UserID: 1, Report: domain.ReportLowStock, Cron: "@daily", Timezone: "UTC",
+	}, nil)
+	mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(s *domain.ReportSubscription) bool {
+		return s.LastError == "no mailer is configured"
+	})).Return(nil)
+
+	_, err := reportService.SendNow(context.Background(), 3, 1)
+
+	assert.EqualError(t, err, "no mailer is configured")
+}
@@ -0,0 +1,333 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSerialRepo struct {
+	mock.Mock
+}
+
+func (m *MockSerialRepo) Create(ctx context.Context, serials []*domain.Serial) error {
+	args := m.Called(ctx, serials)
+	return args.Error(0)
+}
+
+func (m *MockSerialRepo) FindByNumber(ctx context.Context, number string, userID uint) (*domain.Serial, error) {
+	args := m.Called(ctx, number, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Serial), args.Error(1)
+}
+
+func (m *MockSerialRepo) LockByNumbers(ctx context.Context, numbers []string, userID uint) ([]*domain.Serial, error) {
+	args := m.Called(ctx, numbers, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Serial), args.Error(1)
+}
+
+func (m *MockSerialRepo) FindByProductID(ctx context.Context, productID, userID uint, status domain.SerialStatus) ([]*domain.Serial, error) {
+	args := m.Called(ctx, productID, userID, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Serial), args.Error(1)
+}
+
+func (m *MockSerialRepo) Update(ctx context.Context, serial *domain.Serial) error {
+	args := m.Called(ctx, serial)
+	return args.Error(0)
+}
+
+func (m *MockSerialRepo) Unassign(ctx context.Context, orderItemIDs []uint) error {
+	args := m.Called(ctx, orderItemIDs)
+	return args.Error(0)
+}
+
+func laptop() *domain.Product {
+	return &domain.Product{ID: 1, UserID: 1, Code: "LAPTOP", Name: "Laptop Gaming", Price: 1200, Serialized: true}
+}
+
+// laptopOrder returns an order for two laptops with the given serials
+// assigned.
+func laptopOrder(status domain.OrderStatus, serials ...domain.Serial) *domain.Order {
+	return &domain.Order{ID: 7, UserID: 1, Status: status, Items: []domain.OrderItem{
+		{ID: 15, OrderID: 7, ProductID: 1, Product: *laptop(), Quantity: 2, Serials: serials},
+	}}
+}
+
+func TestReceiveSerials_AddsStockPerSerial(t *testing.T) {
+	serialRepo := new(MockSerialRepo)
+	productRepo := new(MockProductRepo)
+	events := &recordingEvents{}
+	serialService := service.NewSerialService(serialRepo, productRepo, new(MockOrderRepo), service.WithSerialEvents(events))
+
+	product := laptop()
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	productRepo.On("Update", mock.Anything, product, uint(1)).Return(nil)
+	serialRepo.On("LockByNumbers", mock.Anything, []string{"SN-1", "SN-2"}, uint(1)).Return([]*domain.Serial{}, nil)
+	serialRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	serials, err := serialService.ReceiveSerials(context.Background(), 1, service.ReceiveSerialsRequest{ProductID: 1, Serials: []string{" SN-1", "SN-2 "}})
+
+	assert.NoError(t, err)
+	if assert.Len(t, serials, 2) {
+		assert.Equal(t, "SN-1", serials[0].Number)
+		assert.Equal(t, domain.SerialStatusInStock, serials[1].Status)
+	}
+	assert.Equal(t, 2, product.Stock)
+	assert.Equal(t, domain.StockReasonSerialsReceived, events.events[0].(domain.StockAdjusted).Reason)
+}
+
+func TestReceiveSerials_RejectsRepeatedAndKnownSerials(t *testing.T) {
+	serialRepo := new(MockSerialRepo)
+	productRepo := new(MockProductRepo)
+	serialService := service.NewSerialService(serialRepo, productRepo, new(MockOrderRepo))
+
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(laptop(), nil)
+	serialRepo.On("LockByNumbers", mock.Anything, []string{"SN-1"}, uint(1)).Return([]*domain.Serial{{Number: "SN-1"}}, nil)
+
+	_, err := serialService.ReceiveSerials(context.Background(), 1, service.ReceiveSerialsRequest{ProductID: 1, Serials: []string{"SN-2", "SN-2"}})
+	assert.EqualError(t, err, "serial SN-2 is given twice")
+
+	_, err = serialService.ReceiveSerials(context.Background(), 1, service.ReceiveSerialsRequest{ProductID: 1, Serials: []string{"SN-1"}})
+	assert.EqualError(t, err, "serial SN-1 is already registered")
+	serialRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAssignSerials_ReplacesEarlierAssignment(t *testing.T) {
+	serialRepo := new(MockSerialRepo)
+	orderRepo := new(MockOrderRepo)
+	serialService := service.NewSerialService(serialRepo, new(MockProductRepo), orderRepo)
+
+	itemID := uint(15)
+	wrong := domain.Serial{ID: 1, Number: "SN-1", ProductID: 1, Status: domain.SerialStatusAssigned, OrderItemID: &itemID}
+	kept := domain.Serial{ID: 2, Number: "SN-2", ProductID: 1, Status: domain.SerialStatusAssigned, OrderItemID: &itemID}
+	order := laptopOrder(domain.OrderStatusConfirmed, wrong, kept)
+	orderRepo.On("FindByIDAndUserID", mock.Anything, uint(7), uint(1)).Return(order, nil)
+
+	right := &domain.Serial{ID: 3, Number: "SN-3", ProductID: 1, Status: domain.SerialStatusInStock}
+	keptLocked := kept
+	serialRepo.On("LockByNumbers", mock.Anything, []string{"SN-2", "SN-3"}, uint(1)).Return([]*domain.Serial{&keptLocked, right}, nil)
+	serialRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+	_, err := serialService.AssignSerials(context.Background(), 7, 15, 1, []string{"SN-2", "SN-3"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.SerialStatusInStock, order.Items[0].Serials[0].Status)
+	assert.Nil(t, order.Items[0].Serials[0].OrderItemID)
+	assert.Equal(t, domain.SerialStatusAssigned, right.Status)
+	assert.Equal(t, uint(15), *right.OrderItemID)
+	serialRepo.AssertNumberOfCalls(t, "Update", 3)
+}
+
+func TestAssignSerials_Errors(t *testing.T) {
+	serialRepo := new(MockSerialRepo)
+	orderRepo := new(MockOrderRepo)
+	serialService := service.NewSerialService(serialRepo, new(MockProductRepo), orderRepo)
+
+	otherItem := uint(99)
+	orderRepo.On("FindByIDAndUserID", mock.Anything, uint(7), uint(1)).Return(laptopOrder(domain.OrderStatusConfirmed), nil)
+	orderRepo.On("FindByIDAndUserID", mock.Anything, uint(8), uint(1)).Return(laptopOrder(domain.OrderStatusShipped), nil)
+	serialRepo.On("LockByNumbers", mock.Anything, []string{"MUG-1"}, uint(1)).Return([]*domain.Serial{{Number: "MUG-1", ProductID: 2, Status: domain.SerialStatusInStock}}, nil)
+	serialRepo.On("LockByNumbers", mock.Anything, []string{"SN-9"}, uint(1)).Return([]*domain.Serial{{Number: "SN-9", ProductID: 1, Status: domain.SerialStatusAssigned, OrderItemID: &otherItem}}, nil)
+	serialRepo.On("LockByNumbers", mock.Anything, []string{"SN-404"}, uint(1)).Return([]*domain.Serial{}, nil)
+
+	_, err := serialService.AssignSerials(context.Background(), 7, 15, 1, []string{"A", "B", "C"})
+	assert.EqualError(t, err, "the item has 2 units, but 3 serials were given")
+	_, err = serialService.AssignSerials(context.Background(), 7, 15, 1, []string{"MUG-1"})
+	assert.EqualError(t, err, "serial MUG-1 is not a unit of the ordered product")
+	_, err = serialService.AssignSerials(context.Background(), 7, 15, 1, []string{"SN-9"})
+	assert.EqualError(t, err, "serial SN-9 is not in stock")
+	_, err = serialService.AssignSerials(context.Background(), 7, 15, 1, []string{"SN-404"})
+	assert.EqualError(t, err, "serial SN-404 not found")
+	_, err = serialService.AssignSerials(context.Background(), 7, 16, 1, []string{"SN-1"})
+	assert.EqualError(t, err, "order item not found")
+	_, err = serialService.AssignSerials(context.Background(), 8, 15, 1, []string{"SN-1"})
+	assert.EqualError(t, err, "serials can only be assigned before the order ships")
+	serialRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestShipping_RequiresAssignedSerials(t *testing.T) {
+	orderRepo := new(MockOrderRepo)
+	orderService := service.NewOrderService(orderRepo, new(MockProductRepo))
+	orderRepo.On("FindByIDAndUserID", mock.Anything, uint(7), uint(1)).Return(laptopOrder(domain.OrderStatusConfirmed, domain.Serial{Number: "SN-1"}), nil).Once()
+
+	_, err := orderService.UpdateOrderStatus(context.Background(), 7, 1, domain.OrderStatusShipped)
+	assert.EqualError(t, err, "serials must be assigned to all 2 units of Laptop Gaming before shipping")
+
+	shipmentRepo := new(MockShipmentRepo)
+	shipmentService := service.NewShipmentService(shipmentRepo, orderRepo)
+	orderRepo.On("FindByIDAndUserID", mock.Anything, uint(7), uint(1)).Return(laptopOrder(domain.OrderStatusConfirmed), nil)
+	shipmentRepo.On("FindByOrderID", mock.Anything, uint(7), uint(1)).Return([]*domain.Shipment{}, nil)
+	shipmentRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	shippedAt := time.Now()
+	_, err = shipmentService.CreateShipment(context.Background(), 1, 7, service.CreateShipmentRequest{
+		ShippedAt: &shippedAt,
+		Items:     []service.ShipmentItemRequest{{OrderItemID: 15, Quantity: 1}},
+	})
+	assert.EqualError(t, err, "serials must be assigned to all 2 units of Laptop Gaming before shipping")
+
+	// A shipment that has not left yet needs no serials.
+	_, err = shipmentService.CreateShipment(context.Background(), 1, 7, service.CreateShipmentRequest{})
+	assert.NoError(t, err)
+}
+
+func TestCancelOrder_ReleasesSerials(t *testing.T) {
+	orderRepo := new(MockOrderRepo)
+	productRepo := new(MockProductRepo)
+	serialRepo := new(MockSerialRepo)
+	orderService := service.NewOrderService(orderRepo, productRepo, service.WithOrderSerials(serialRepo))
+
+	order := laptopOrder(domain.OrderStatusConfirmed, domain.Serial{ID: 1, Number: "SN-1"})
+	orderRepo.On("FindByIDAndUserID", mock.Anything, uint(7), uint(1)).Return(order, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(laptop(), nil)
+	productRepo.On("Update", mock.Anything, mock.Anything, uint(1)).Return(nil)
+	serialRepo.On("Unassign", mock.Anything, []uint{15}).Return(nil)
+	orderRepo.On("Update", mock.Anything, order, uint(1)).Return(nil)
+
+	_, err := orderService.CancelOrder(context.Background(), 7, 1)
+
+	assert.NoError(t, err)
+	serialRepo.AssertExpectations(t)
+}
+
+func TestCancelOrder_ShippedOrderKeepsSerials(t *testing.T) {
+	orderRepo := new(MockOrderRepo)
+	serialRepo := new(MockSerialRepo)
+	orderService := service.NewOrderService(orderRepo, new(MockProductRepo), service.WithOrderSerials(serialRepo))
+
+	order := laptopOrder(domain.OrderStatusShipped, domain.Serial{ID: 1, Number: "SN-1"})
+	orderRepo.On("FindByIDAndUserID", mock.Anything, uint(7), uint(1)).Return(order, nil)
+	orderRepo.On("Update", mock.Anything, order, uint(1)).Return(nil)
+
+	_, err := orderService.CancelOrder(context.Background(), 7, 1)
+
+	assert.NoError(t, err)
+	serialRepo.AssertNotCalled(t, "Unassign", mock.Anything, mock.Anything)
+}
+
+func TestReturnSerials_OnlyShippedSerials(t *testing.T) {
+	serialRepo := new(MockSerialRepo)
+	productRepo := new(MockProductRepo)
+	events := &recordingEvents{}
+	serialService := service.NewSerialService(serialRepo, productRepo, new(MockOrderRepo), service.WithSerialEvents(events))
+
+	itemID := uint(15)
+	shipped := &domain.Serial{ID: 1, Number: "SN-1", ProductID: 1, Status: domain.SerialStatusAssigned, OrderItemID: &itemID,
+		OrderItem: &domain.OrderItem{ID: 15, OrderID: 7, Order: domain.Order{ID: 7, Status: domain.OrderStatusDelivered}}}
+	packed := &domain.Serial{ID: 2, Number: "SN-2", ProductID: 1, Status: domain.SerialStatusAssigned, OrderItemID: &itemID,
+		OrderItem: &domain.OrderItem{ID: 15, OrderID: 8, Order: domain.Order{ID: 8, Status: domain.OrderStatusConfirmed}}}
+	product := laptop()
+	serialRepo.On("LockByNumbers", mock.Anything, []string{"SN-2"}, uint(1)).Return([]*domain.Serial{packed}, nil)
+	serialRepo.On("LockByNumbers", mock.Anything, []string{"SN-1"}, uint(1)).Return([]*domain.Serial{shipped}, nil)
+	serialRepo.On("Update", mock.Anything, shipped).Return(nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	productRepo.On("Update", mock.Anything, product, uint(1)).Return(nil)
+
+	_, err := serialService.ReturnSerials(context.Background(), 1, []string{"SN-2"})
+	assert.EqualError(t, err, "serial SN-2 has not shipped; cancel the order or assign another serial instead")
+
+	returned, err := serialService.ReturnSerials(context.Background(), 1, []string{"SN-1"})
+	assert.NoError(t, err)
+	assert.Len(t, returned, 1)
+	assert.Equal(t, domain.SerialStatusInStock, shipped.Status)
+	assert.NotNil(t, shipped.ReturnedAt)
+	assert.Equal(t, uint(15), *shipped.OrderItemID, "a returned serial keeps the order it came back from")
+	assert.Equal(t, 1, product.Stock)
+	assert.Equal(t, domain.StockReasonReturned, events.events[0].(domain.StockAdjusted).Reason)
+}
+
+func TestSetSerialized(t *testing.T) {
+	productRepo := new(MockProductRepo)
+	serialService := service.NewSerialService(new(MockSerialRepo), productRepo, new(MockOrderRepo))
+
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Product{ID: 1, UserID: 1, Stock: 3}, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(&domain.Product{ID: 2, UserID: 1, BatchManaged: true}, nil)
+
+	_, err := serialService.SetSerialized(context.Background(), 1, 1, true)
+	assert.EqualError(t, err, "stock must be zero to turn on serial tracking; receive it with serials instead")
+	_, err = serialService.SetSerialized(context.Background(), 2, 1, true)
+	assert.EqualError(t, err, "batch-managed products cannot be serialized")
+}
+
+func TestUpdateProductStock_RefusesSerializedProducts(t *testing.T) {
+	productRepo := new(MockProductRepo)
+	productService := service.NewProductService(productRepo)
+
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(laptop(), nil)
+
+	_, err := productService.UpdateProductStock(context.Background(), 1, 1, 1)
+
+	assert.EqualError(t, err, "stock of a serialized product changes through its serials; receive or return serials instead")
+	productRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateShipment_DeliveryAfterSerialsReturned(t *testing.T) {
+	orderRepo := new(MockOrderRepo)
+	shipmentRepo := new(MockShipmentRepo)
+	shipmentService := service.NewShipmentService(shipmentRepo, orderRepo)
+
+	// One of the two shipped laptops was returned, so only one serial is
+	// still assigned to the order.
+	order := laptopOrder(domain.OrderStatusShipped, domain.Serial{Number: "SN-2"})
+	shippedAt := time.Now().Add(-48 * time.Hour)
+	shipment := &domain.Shipment{ID: 3, OrderID: 7, UserID: 1, ShippedAt: &shippedAt, Status: domain.ShipmentStatusShipped,
+		Items: []domain.ShipmentItem{{OrderItemID: 15, Quantity: 2}}}
+	shipmentRepo.On("FindByIDAndUserID", mock.Anything, uint(3), uint(1)).Return(shipment, nil)
+	shipmentRepo.On("Update", mock.Anything, shipment, uint(1)).Return(nil)
+	orderRepo.On("FindByIDAndUserID", mock.Anything, uint(7), uint(1)).Return(order, nil)
+	shipmentRepo.On("FindByOrderID", mock.Anything, uint(7), uint(1)).Return([]*domain.Shipment{shipment}, nil)
+	orderRepo.On("Update", mock.Anything, order, uint(1)).Return(nil)
+
+	deliveredAt := time.Now()
+	_, err := shipmentService.UpdateShipment(context.Background(), 1, 7, 3, service.UpdateShipmentRequest{DeliveredAt: &deliveredAt})
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OrderStatusDelivered, order.Status)
+}
+
+func TestReturnSerials_AfterShippedOrderIsCancelled(t *testing.T) {
+	orderRepo := new(MockOrderRepo)
+	productRepo := new(MockProductRepo)
+	serialRepo := new(MockSerialRepo)
+	orderService := service.NewOrderService(orderRepo, productRepo, service.WithOrderSerials(serialRepo))
+	serialService := service.NewSerialService(serialRepo, productRepo, orderRepo)
+
+	itemID := uint(15)
+	order := laptopOrder(domain.OrderStatusConfirmed, domain.Serial{ID: 1, Number: "SN-1"}, domain.Serial{ID: 2, Number: "SN-2"})
+	orderRepo.On("FindByIDAndUserID", mock.Anything, uint(7), uint(1)).Return(order, nil)
+	orderRepo.On("Update", mock.Anything, order, uint(1)).Return(nil)
+
+	_, err := orderService.UpdateOrderStatus(context.Background(), 7, 1, domain.OrderStatusShipped)
+	assert.NoError(t, err)
+	_, err = orderService.CancelOrder(context.Background(), 7, 1)
+	assert.NoError(t, err)
+	serialRepo.AssertNotCalled(t, "Unassign", mock.Anything, mock.Anything)
+
+	serial := &domain.Serial{ID: 1, Number: "SN-1", ProductID: 1, Status: domain.SerialStatusAssigned, OrderItemID: &itemID,
+		OrderItem: &domain.OrderItem{ID: 15, OrderID: 7, Order: *order}}
+	product := laptop()
+	serialRepo.On("LockByNumbers", mock.Anything, []string{"SN-1"}, uint(1)).Return([]*domain.Serial{serial}, nil)
+	serialRepo.On("Update", mock.Anything, serial).Return(nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	productRepo.On("Update", mock.Anything, product, uint(1)).Return(nil)
+
+	_, err = serialService.ReturnSerials(context.Background(), 1, []string{"SN-1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.SerialStatusInStock, serial.Status)
+	assert.Equal(t, 1, product.Stock)
+}
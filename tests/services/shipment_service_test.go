@@ -0,0 +1,241 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockShipmentRepo struct {
+	mock.Mock
+}
+
+func (m *MockShipmentRepo) Create(ctx context.Context, shipment *domain.Shipment) error {
+	args := m.Called(ctx, shipment)
+	return args.Error(0)
+}
+
+func (m *MockShipmentRepo) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.Shipment, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Shipment), args.Error(1)
+}
+
+func (m *MockShipmentRepo) FindByOrderID(ctx context.Context, orderID, userID uint) ([]*domain.Shipment, error) {
+	args := m.Called(ctx, orderID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Shipment), args.Error(1)
+}
+
+func (m *MockShipmentRepo) Update(ctx context.Context, shipment *domain.Shipment, userID uint) error {
+	args := m.Called(ctx, shipment, userID)
+	return args.Error(0)
+}
+
+func confirmedOrder() *domain.Order {
+	return &domain.Order{
+		ID:     1,
+		UserID: 1,
+		Status: domain.OrderStatusConfirmed,
+		Items: []domain.OrderItem{
+			{ID: 10, ProductID: 1, Quantity: 2},
+			{ID: 11, ProductID: 2, Quantity: 1},
+		},
+	}
+}
+
+func TestCreateShipment_AllRemainingItems_MarksOrderShipped(t *testing.T) {
+	mockShipmentRepo := new(MockShipmentRepo)
+	mockOrderRepo := new(MockOrderRepo)
+	shipmentService := service.NewShipmentService(mockShipmentRepo, mockOrderRepo)
+
+	order := confirmedOrder()
+	shippedAt := time.Now()
+	mockOrderRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(order, nil)
+	mockShipmentRepo.On("FindByOrderID", mock.Anything, uint(1), uint(1)).Return([]*domain.Shipment{}, nil)
+	mockShipmentRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Shipment")).Return(nil)
+	mockOrderRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Order"), uint(1)).Return(nil)
+
+	shipment, err := shipmentService.CreateShipment(context.Background(), 1, 1, service.CreateShipmentRequest{
+		Carrier:        "DHL",
+		TrackingNumber: "TRACK1",
+		ShippedAt:      &shippedAt,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ShipmentStatusShipped, shipment.Status)
+	assert.Len(t, shipment.Items, 2)
+	assert.Equal(t, 2, shipment.Items[0].Quantity)
+	assert.Equal(t, domain.OrderStatusShipped, order.Status)
+	mockShipmentRepo.AssertExpectations(t)
+	mockOrderRepo.AssertExpectations(t)
+}
+
+func TestCreateShipment_Split_KeepsOrderConfirmed(t *testing.T) {
+	mockShipmentRepo := new(MockShipmentRepo)
+	mockOrderRepo := new(MockOrderRepo)
+	shipmentService := service.NewShipmentService(mockShipmentRepo, mockOrderRepo)
+
+	order := confirmedOrder()
+	shippedAt := time.Now()
+	mockOrderRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(order, nil)
+	mockShipmentRepo.On("FindByOrderID", mock.Anything, uint(1), uint(1)).Return([]*domain.Shipment{}, nil)
+	mockShipmentRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Shipment")).Return(nil)
+
+	shipment, err := shipmentService.CreateShipment(context.Background(), 1, 1, service.CreateShipmentRequest{
+		ShippedAt: &shippedAt,
+		Items:     []service.ShipmentItemRequest{{OrderItemID: 10, Quantity: 1}},
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, shipment.Items, 1)
+	assert.Equal(t, domain.OrderStatusConfirmed, order.Status)
+	mockOrderRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateShipment_Error_QuantityExceedsRemaining(t *testing.T) {
+	mockShipmentRepo := new(MockShipmentRepo)
+	mockOrderRepo := new(MockOrderRepo)
+	shipmentService := service.NewShipmentService(mockShipmentRepo, mockOrderRepo)
+
+	previous := &domain.Shipment{
+		ID:    5,
+		Items: []domain.ShipmentItem{{OrderItemID: 10, Quantity: 2}},
+	}
+	mockOrderRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(confirmedOrder(), nil)
+	mockShipmentRepo.On("FindByOrderID", mock.Anything, uint(1), uint(1)).Return([]*domain.Shipment{previous}, nil)
+
+	_, err := shipmentService.CreateShipment(context.Background(), 1, 1, service.CreateShipmentRequest{
+		Items: []service.ShipmentItemRequest{{OrderItemID: 10, Quantity: 1}},
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "quantity exceeds the unshipped quantity of the order item", err.Error())
+	mockShipmentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateShipment_Error_OrderNotConfirmed(t *testing.T) {
+	mockShipmentRepo := new(MockShipmentRepo)
+	mockOrderRepo := new(MockOrderRepo)
+	shipmentService := service.NewShipmentService(mockShipmentRepo, mockOrderRepo)
+
+	order := confirmedOrder()
+	order.Status = domain.OrderStatusPending
+	mockOrderRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(order, nil)
+
+	_, err := shipmentService.CreateShipment(context.Background(), 1, 1, service.CreateShipmentRequest{})
+
+	assert.Error(t, err)
+	assert.Equal(t, "only confirmed orders can be shipped", err.Error())
+}
+
+func TestCreateShipment_Error_OrderNotFound(t *testing.T) {
+	mockShipmentRepo := new(MockShipmentRepo)
+	mockOrderRepo := new(MockOrderRepo)
+	shipmentService := service.NewShipmentService(mockShipmentRepo, mockOrderRepo)
+
+	mockOrderRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(nil, errors.New("not found"))
+
+	_, err := shipmentService.CreateShipment(context.Background(), 1, 1, service.CreateShipmentRequest{})
+
+	assert.Error(t, err)
+	assert.Equal(t, "order not found", err.Error())
+}
+
+func TestUpdateShipment_Delivered_MarksOrderDelivered(t *testing.T) {
+	mockShipmentRepo := new(MockShipmentRepo)
+	mockOrderRepo := new(MockOrderRepo)
+	shipmentService := service.NewShipmentService(mockShipmentRepo, mockOrderRepo)
+
+	shippedAt := time.Now().Add(-48 * time.Hour)
+	deliveredAt := time.Now()
+	order := confirmedOrder()
+	order.Status = domain.OrderStatusShipped
+	shipment := &domain.Shipment{
+		ID:        5,
+		OrderID:   1,
+		UserID:    1,
+		Status:    domain.ShipmentStatusShipped,
+		ShippedAt: &shippedAt,
+		Items: []domain.ShipmentItem{
+			{OrderItemID: 10, Quantity: 2},
+			{OrderItemID: 11, Quantity: 1},
+		},
+	}
+	mockShipmentRepo.On("FindByIDAndUserID", mock.Anything, uint(5), uint(1)).Return(shipment, nil)
+	mockShipmentRepo.On("Update", mock.Anything, shipment, uint(1)).Return(nil)
+	mockOrderRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(order, nil)
+	mockShipmentRepo.On("FindByOrderID", mock.Anything, uint(1), uint(1)).Return([]*domain.Shipment{shipment}, nil)
+	mockOrderRepo.On("Update", mock.Anything, order, uint(1)).Return(nil)
+
+	updated, err := shipmentService.UpdateShipment(context.Background(), 1, 1, 5, service.UpdateShipmentRequest{
+		DeliveredAt: &deliveredAt,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ShipmentStatusDelivered, updated.Status)
+	assert.Equal(t, domain.OrderStatusDelivered, order.Status)
+	mockShipmentRepo.AssertExpectations(t)
+	mockOrderRepo.AssertExpectations(t)
+}
+
+func TestUpdateShipment_Error_DeliveredBeforeShipped(t *testing.T) {
+	mockShipmentRepo := new(MockShipmentRepo)
+	mockOrderRepo := new(MockOrderRepo)
+	shipmentService := service.NewShipmentService(mockShipmentRepo, mockOrderRepo)
+
+	shippedAt := time.Now()
+	deliveredAt := shippedAt.Add(-time.Hour)
+	shipment := &domain.Shipment{ID: 5, OrderID: 1, UserID: 1, ShippedAt: &shippedAt}
+	mockShipmentRepo.On("FindByIDAndUserID", mock.Anything, uint(5), uint(1)).Return(shipment, nil)
+
+	_, err := shipmentService.UpdateShipment(context.Background(), 1, 1, 5, service.UpdateShipmentRequest{
+		DeliveredAt: &deliveredAt,
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "delivered_at cannot be before shipped_at", err.Error())
+}
+
+func TestUpdateShipment_Error_ShippedAfterDelivered(t *testing.T) {
+	mockShipmentRepo := new(MockShipmentRepo)
+	mockOrderRepo := new(MockOrderRepo)
+	shipmentService := service.NewShipmentService(mockShipmentRepo, mockOrderRepo)
+
+	deliveredAt := time.Now()
+	shippedAt := deliveredAt.Add(-24 * time.Hour)
+	lateShippedAt := deliveredAt.Add(time.Hour)
+	shipment := &domain.Shipment{ID: 5, OrderID: 1, UserID: 1, ShippedAt: &shippedAt, DeliveredAt: &deliveredAt}
+	mockShipmentRepo.On("FindByIDAndUserID", mock.Anything, uint(5), uint(1)).Return(shipment, nil)
+
+	_, err := shipmentService.UpdateShipment(context.Background(), 1, 1, 5, service.UpdateShipmentRequest{
+		ShippedAt: &lateShippedAt,
+	})
+
+	assert.EqualError(t, err, "delivered_at cannot be before shipped_at")
+	mockShipmentRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateShipment_Error_WrongOrder(t *testing.T) {
+	mockShipmentRepo := new(MockShipmentRepo)
+	mockOrderRepo := new(MockOrderRepo)
+	shipmentService := service.NewShipmentService(mockShipmentRepo, mockOrderRepo)
+
+	mockShipmentRepo.On("FindByIDAndUserID", mock.Anything, uint(5), uint(1)).Return(&domain.Shipment{ID: 5, OrderID: 2}, nil)
+
+	_, err := shipmentService.UpdateShipment(context.Background(), 1, 1, 5, service.UpdateShipmentRequest{})
+
+	assert.Error(t, err)
+	assert.Equal(t, "shipment not found", err.Error())
+}
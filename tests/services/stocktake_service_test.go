@@ -0,0 +1,291 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockStocktakeRepo struct {
+	mock.Mock
+}
+
+func (m *MockStocktakeRepo) Create(ctx context.Context, stocktake *domain.Stocktake) error {
+	args := m.Called(ctx, stocktake)
+	return args.Error(0)
+}
+
+func (m *MockStocktakeRepo) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.Stocktake, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Stocktake), args.Error(1)
+}
+
+func (m *MockStocktakeRepo) LockByIDAndUserID(ctx context.Context, id, userID uint) (*domain.Stocktake, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Stocktake), args.Error(1)
+}
+
+func (m *MockStocktakeRepo) FindByUserID(ctx context.Context, userID uint, status domain.StocktakeStatus) ([]*domain.Stocktake, error) {
+	args := m.Called(ctx, userID, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Stocktake), args.Error(1)
+}
+
+func (m *MockStocktakeRepo) FindOpenProductIDs(ctx context.Context, userID uint) ([]uint, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockStocktakeRepo) Update(ctx context.Context, stocktake *domain.Stocktake, lines []*domain.StocktakeLine) error {
+	args := m.Called(ctx, stocktake, lines)
+	return args.Error(0)
+}
+
+// openStocktake returns an open stocktake counting MUG and both variants of
+// the T-shirt.
+func openStocktake(mug, shirt *domain.Product) *domain.Stocktake {
+	return &domain.Stocktake{
+		ID: 7, UserID: 1, Name: "Year-end", Status: domain.StocktakeStatusOpen,
+		Lines: []domain.StocktakeLine{
+			{ID: 1, StocktakeID: 7, ProductID: mug.ID, Product: *mug},
+			{ID: 2, StocktakeID: 7, ProductID: shirt.ID, Product: *shirt, VariantID: uintPtr(5), Variant: &shirt.Variants[0]},
+			{ID: 3, StocktakeID: 7, ProductID: shirt.ID, Product: *shirt, VariantID: uintPtr(6), Variant: &shirt.Variants[1]},
+		},
+	}
+}
+
+func TestOpenStocktake_LinePerVariant(t *testing.T) {
+	stocktakeRepo := new(MockStocktakeRepo)
+	productRepo := new(MockProductRepo)
+	stocktakeService := service.NewStocktakeService(stocktakeRepo, productRepo)
+
+	mug := &domain.Product{ID: 2, UserID: 1, Code: "MUG", Stock: 10}
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(mug, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(productWithVariants(), nil)
+	productRepo.On("LockByIDs", mock.Anything, []uint{2, 1}, uint(1)).Return(nil)
+	stocktakeRepo.On("FindOpenProductIDs", mock.Anything, uint(1)).Return([]uint{9}, nil)
+	var created *domain.Stocktake
+	stocktakeRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Stocktake")).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*domain.Stocktake)
+		created.ID = 7
+	}).Return(nil)
+	stocktakeRepo.On("FindByIDAndUserID", mock.Anything, uint(7), uint(1)).Return(&domain.Stocktake{ID: 7}, nil)
+
+	_, err := stocktakeService.OpenStocktake(context.Background(), 1, service.OpenStocktakeRequest{Name: "Year-end", ProductIDs: []uint{2, 1, 2}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StocktakeStatusOpen, created.Status)
+	if assert.Len(t, created.Lines, 3) {
+		assert.Nil(t, created.Lines[0].VariantID)
+		assert.Equal(t, uint(5), *created.Lines[1].VariantID)
+		assert.Equal(t, uint(6), *created.Lines[2].VariantID)
+	}
+}
+
+func TestOpenStocktake_RefusesProductsAlreadyBeingCounted(t *testing.T) {
+	stocktakeRepo := new(MockStocktakeRepo)
+	productRepo := new(MockProductRepo)
+	stocktakeService := service.NewStocktakeService(stocktakeRepo, productRepo)
+
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(&domain.Product{ID: 2, UserID: 1, Code: "MUG"}, nil)
+	productRepo.On("LockByIDs", mock.Anything, []uint{2}, uint(1)).Return(nil)
+	stocktakeRepo.On("FindOpenProductIDs", mock.Anything, uint(1)).Return([]uint{2}, nil)
+
+	_, err := stocktakeService.OpenStocktake(context.Background(), 1, service.OpenStocktakeRequest{Name: "Spot check", ProductIDs: []uint{2}})
+
+	assert.EqualError(t, err, "product MUG is already being counted in another stocktake")
+	stocktakeRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+	_, err = stocktakeService.OpenStocktake(context.Background(), 1, service.OpenStocktakeRequest{ProductIDs: []uint{2}})
+	assert.EqualError(t, err, "name is required")
+}
+
+func TestRecordCounts_ScannerPasses(t *testing.T) {
+	stocktakeRepo := new(MockStocktakeRepo)
+	stocktakeService := service.NewStocktakeService(stocktakeRepo, new(MockProductRepo))
+
+	stocktake := openStocktake(&domain.Product{ID: 2, UserID: 1, Code: "MUG", Stock: 10}, productWithVariants())
+	stocktakeRepo.On("LockByIDAndUserID", mock.Anything, uint(7), uint(1)).Return(stocktake, nil)
+	stocktakeRepo.On("Update", mock.Anything, stocktake, mock.Anything).Return(nil)
+
+	_, err := stocktakeService.RecordCounts(context.Background(), 7, 1, []service.StocktakeCount{
+		{Code: "TSHIRT-RED", Quantity: 1},
+		{Code: "TSHIRT-RED", Quantity: 1},
+		{Code: "MUG", Quantity: 4},
+	})
+	assert.NoError(t, err)
+
+	// A second pass adds to the first; a recount replaces it.
+	stocktake.Lines[1].Variant.Stock = 1
+	result, err := stocktakeService.RecordCounts(context.Background(), 7, 1, []service.StocktakeCount{
+		{Code: "TSHIRT-RED", Quantity: 1},
+		{ProductID: uintPtr(2), Quantity: 9, Mode: "set"},
+	})
+	assert.NoError(t, err)
+
+	red := result.Lines[1]
+	assert.Equal(t, 3, *red.Counted)
+	assert.Equal(t, 3, *red.Expected, "expected stock is taken at the first count")
+	assert.Equal(t, 0, *red.Variance())
+	assert.Equal(t, 9, *result.Lines[0].Counted)
+	assert.Equal(t, -1, *result.Lines[0].Variance())
+	assert.Nil(t, result.Lines[2].Counted)
+	assert.Nil(t, result.Lines[2].Variance())
+}
+
+func TestRecordCounts_InvalidCountsRecordNothing(t *testing.T) {
+	stocktakeRepo := new(MockStocktakeRepo)
+	stocktakeService := service.NewStocktakeService(stocktakeRepo, new(MockProductRepo))
+
+	stocktake := openStocktake(&domain.Product{ID: 2, UserID: 1, Code: "MUG", Stock: 10}, productWithVariants())
+	stocktakeRepo.On("LockByIDAndUserID", mock.Anything, uint(7), uint(1)).Return(stocktake, nil)
+
+	_, err := stocktakeService.RecordCounts(context.Background(), 7, 1, []service.StocktakeCount{
+		{Code: "MUG", Quantity: 2},
+		{Code: "BOWL", Quantity: 1},
+		{Code: "TSHIRT-RED", Quantity: -1},
+		{ProductID: uintPtr(1), Quantity: 1},
+		{Code: "MUG", Quantity: 1, Mode: "replace"},
+	})
+
+	var entryErrs *service.EntryErrors
+	if assert.ErrorAs(t, err, &entryErrs) {
+		assert.Equal(t, []service.EntryError{
+			{Index: 1, Message: "not part of this stocktake"},
+			{Index: 2, Message: "counted quantity cannot be negative"},
+			{Index: 3, Message: "not part of this stocktake"},
+			{Index: 4, Message: "mode must be add or set"},
+		}, entryErrs.Entries)
+	}
+	stocktakeRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestApproveStocktake_PostsVariance(t *testing.T) {
+	stocktakeRepo := new(MockStocktakeRepo)
+	productRepo := new(MockProductRepo)
+	variantRepo := new(MockVariantRepo)
+	events := &recordingEvents{}
+	stocktakeService := service.NewStocktakeService(stocktakeRepo, productRepo,
+		service.WithStocktakeVariants(variantRepo), service.WithStocktakeEvents(events))
+
+	mug := &domain.Product{ID: 2, UserID: 1, Code: "MUG", Stock: 10}
+	shirt := productWithVariants()
+	stocktake := openStocktake(mug, shirt)
+	// MUG counted 7 of 10; 2 were sold since. The red T-shirt counted 0 of 3
+	// but 2 were sold since, so only the last one can be written off.
+	stocktake.Lines[0].Expected, stocktake.Lines[0].Counted = intPtr(10), intPtr(7)
+	stocktake.Lines[1].Expected, stocktake.Lines[1].Counted = intPtr(3), intPtr(0)
+	mug.Stock = 8
+	shirt.Variants[0].Stock, shirt.Stock = 1, 3
+
+	stocktakeRepo.On("LockByIDAndUserID", mock.Anything, uint(7), uint(1)).Return(stocktake, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(mug, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(shirt, nil)
+	productRepo.On("Update", mock.Anything, mock.Anything, uint(1)).Return(nil)
+	variantRepo.On("UpdateVariant", mock.Anything, mock.Anything).Return(nil)
+	stocktakeRepo.On("Update", mock.Anything, stocktake, mock.Anything).Return(nil)
+	stocktakeRepo.On("FindByIDAndUserID", mock.Anything, uint(7), uint(1)).Return(stocktake, nil)
+
+	result, err := stocktakeService.ApproveStocktake(context.Background(), 7, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StocktakeStatusApproved, result.Status)
+	assert.NotNil(t, result.ApprovedAt)
+	assert.Equal(t, 5, mug.Stock)
+	assert.Equal(t, 0, shirt.Variants[0].Stock)
+	assert.Equal(t, 2, shirt.Stock)
+	assert.Equal(t, -3, result.Lines[0].Adjustment)
+	assert.Equal(t, -1, result.Lines[1].Adjustment)
+	assert.Equal(t, 0, result.Lines[2].Adjustment)
+	if assert.Len(t, events.events, 2) {
+		assert.Equal(t, domain.StockReasonStocktake, events.events[0].(domain.StockAdjusted).Reason)
+	}
+}
+
+func TestOpenStocktake_SkipsLotAndSerialTrackedProducts(t *testing.T) {
+	stocktakeRepo := new(MockStocktakeRepo)
+	productRepo := new(MockProductRepo)
+	stocktakeService := service.NewStocktakeService(stocktakeRepo, productRepo)
+
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(2), uint(1)).Return(&domain.Product{ID: 2, UserID: 1, Code: "MUG"}, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(3), uint(1)).Return(&domain.Product{ID: 3, UserID: 1, Code: "MILK", BatchManaged: true}, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(4), uint(1)).Return(&domain.Product{ID: 4, UserID: 1, Code: "LAPTOP", Serialized: true}, nil)
+	productRepo.On("LockByIDs", mock.Anything, []uint{2}, uint(1)).Return(nil)
+	stocktakeRepo.On("FindOpenProductIDs", mock.Anything, uint(1)).Return([]uint{}, nil)
+	var created *domain.Stocktake
+	stocktakeRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Stocktake")).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*domain.Stocktake)
+	}).Return(nil)
+	stocktakeRepo.On("FindByIDAndUserID", mock.Anything, uint(0), uint(1)).Return(&domain.Stocktake{}, nil)
+
+	_, err := stocktakeService.OpenStocktake(context.Background(), 1, service.OpenStocktakeRequest{Name: "Spot check", ProductIDs: []uint{2, 3, 4}})
+
+	assert.NoError(t, err)
+	if assert.Len(t, created.Lines, 1) {
+		assert.Equal(t, uint(2), created.Lines[0].ProductID)
+	}
+
+	_, err = stocktakeService.OpenStocktake(context.Background(), 1, service.OpenStocktakeRequest{Name: "Spot check", ProductIDs: []uint{3, 4}})
+	assert.EqualError(t, err, "no products to count")
+}
+
+func TestApproveStocktake_LeavesProductsTrackedSinceCounting(t *testing.T) {
+	stocktakeRepo := new(MockStocktakeRepo)
+	productRepo := new(MockProductRepo)
+	stocktakeService := service.NewStocktakeService(stocktakeRepo, productRepo)
+
+	milk := &domain.Product{ID: 3, UserID: 1, Code: "MILK", Stock: 4, BatchManaged: true}
+	stocktake := &domain.Stocktake{ID: 7, UserID: 1, Status: domain.StocktakeStatusOpen, Lines: []domain.StocktakeLine{
+		{ID: 1, StocktakeID: 7, ProductID: 3, Expected: intPtr(4), Counted: intPtr(1)},
+	}}
+	stocktakeRepo.On("LockByIDAndUserID", mock.Anything, uint(7), uint(1)).Return(stocktake, nil)
+	productRepo.On("FindByIDAndUserID", mock.Anything, uint(3), uint(1)).Return(milk, nil)
+	stocktakeRepo.On("Update", mock.Anything, stocktake, mock.Anything).Return(nil)
+	stocktakeRepo.On("FindByIDAndUserID", mock.Anything, uint(7), uint(1)).Return(stocktake, nil)
+
+	result, err := stocktakeService.ApproveStocktake(context.Background(), 7, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, milk.Stock)
+	assert.Equal(t, 0, result.Lines[0].Adjustment)
+	productRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestStocktake_ClosedCannotChange(t *testing.T) {
+	stocktakeRepo := new(MockStocktakeRepo)
+	stocktakeService := service.NewStocktakeService(stocktakeRepo, new(MockProductRepo))
+
+	stocktake := openStocktake(&domain.Product{ID: 2, UserID: 1, Code: "MUG", Stock: 10}, productWithVariants())
+	stocktakeRepo.On("LockByIDAndUserID", mock.Anything, uint(7), uint(1)).Return(stocktake, nil)
+	stocktakeRepo.On("LockByIDAndUserID", mock.Anything, uint(8), uint(1)).Return(nil, errors.New("record not found"))
+	stocktakeRepo.On("Update", mock.Anything, stocktake, mock.Anything).Return(nil)
+
+	result, err := stocktakeService.CancelStocktake(context.Background(), 7, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StocktakeStatusCancelled, result.Status)
+	assert.NotNil(t, result.CancelledAt)
+
+	_, err = stocktakeService.RecordCounts(context.Background(), 7, 1, []service.StocktakeCount{{Code: "MUG", Quantity: 1}})
+	assert.EqualError(t, err, "stocktake is not open")
+	_, err = stocktakeService.ApproveStocktake(context.Background(), 7, 1)
+	assert.EqualError(t, err, "stocktake is not open")
+	_, err = stocktakeService.CancelStocktake(context.Background(), 8, 1)
+	assert.EqualError(t, err, "stocktake not found")
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func streamMessage(id, userID uint, eventType string) *domain.OutboxMessage {
+	return &domain.OutboxMessage{ID: id, UserID: userID, EventType: eventType, Payload: `{"order_id":1}`}
+}
+
+func streamIDs(events []service.StreamEvent) []uint {
+	ids := make([]uint, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+	return ids
+}
+
+func TestStreamHub_PushesOnlyOwnEvents(t *testing.T) {
+	hub := service.NewStreamHub()
+	sub := hub.Subscribe(1, 0)
+	defer sub.Close()
+
+	assert.NoError(t, hub.Handle(context.Background(), streamMessage(1, 2, domain.EventOrderCreated)))
+	assert.NoError(t, hub.Handle(context.Background(), streamMessage(2, 1, domain.EventOrderCreated)))
+
+	event := <-sub.Events
+	assert.Equal(t, uint(2), event.ID)
+	assert.Equal(t, domain.EventOrderCreated, event.Type)
+	assert.Equal(t, `{"order_id":1}`, event.Data)
+	assert.Empty(t, sub.Events)
+}
+
+func TestStreamHub_IgnoresOtherEventTypes(t *testing.T) {
+	hub := service.NewStreamHub()
+	sub := hub.Subscribe(1, 0)
+	defer sub.Close()
+
+	assert.NoError(t, hub.Handle(context.Background(), streamMessage(1, 1, "user.registered")))
+
+	assert.Empty(t, sub.Events)
+}
+
+func TestStreamHub_ReplaysAfterLastEventID(t *testing.T) {
+	hub := service.NewStreamHub()
+	for id := uint(1); id <= 4; id++ {
+		assert.NoError(t, hub.Handle(context.Background(), streamMessage(id, 1, domain.EventStockAdjusted)))
+	}
+
+	sub := hub.Subscribe(1, 2)
+	defer sub.Close()
+
+	assert.False(t, sub.Missed)
+	assert.Equal(t, []uint{3, 4}, streamIDs(sub.Replay))
+}
+
+func TestStreamHub_FreshConnectionDoesNotReplay(t *testing.T) {
+	hub := service.NewStreamHub()
+	assert.NoError(t, hub.Handle(context.Background(), streamMessage(1, 1, domain.EventOrderCreated)))
+
+	sub := hub.Subscribe(1, 0)
+	defer sub.Close()
+
+	assert.False(t, sub.Missed)
+	assert.Empty(t, sub.Replay)
+}
+
+func TestStreamHub_ReportsMissedEventsBeyondBuffer(t *testing.T) {
+	hub := service.NewStreamHub(service.WithStreamReplaySize(2))
+	for id := uint(1); id <= 4; id++ {
+		assert.NoError(t, hub.Handle(context.Background(), streamMessage(id, 1, domain.EventOrderCreated)))
+	}
+
+	sub := hub.Subscribe(1, 1)
+	defer sub.Close()
+
+	assert.True(t, sub.Missed)
+	assert.Equal(t, []uint{3, 4}, streamIDs(sub.Replay))
+}
+
+func TestStreamHub_SkipsRedeliveredEvents(t *testing.T) {
+	hub := service.NewStreamHub()
+	sub := hub.Subscribe(1, 0)
+	defer sub.Close()
+
+	assert.NoError(t, hub.Handle(context.Background(), streamMessage(1, 1, domain.EventOrderCreated)))
+	assert.NoError(t, hub.Handle(context.Background(), streamMessage(1, 1, domain.EventOrderCreated)))
+
+	assert.Len(t, sub.Events, 1)
+}
+
+func TestStreamHub_DropsLaggingSubscriber(t *testing.T) {
+	hub := service.NewStreamHub(service.WithStreamChannelSize(1))
+	sub := hub.Subscribe(1, 0)
+
+	assert.NoError(t, hub.Handle(context.Background(), streamMessage(1, 1, domain.EventOrderCreated)))
+	assert.NoError(t, hub.Handle(context.Background(), streamMessage(2, 1, domain.EventOrderCreated)))
+
+	first, ok := <-sub.Events
+	assert.True(t, ok)
+	assert.Equal(t, uint(1), first.ID)
+	_, ok = <-sub.Events
+	assert.False(t, ok)
+
+	// Closing an already dropped subscription is a no-op.
+	sub.Close()
+}
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSupplierRepo struct {
+	mock.Mock
+}
+
+func (m *MockSupplierRepo) Create(ctx context.Context, supplier *domain.Supplier) error {
+	args := m.Called(ctx, supplier)
+	return args.Error(0)
+}
+
+func (m *MockSupplierRepo) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.Supplier, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Supplier), args.Error(1)
+}
+
+func (m *MockSupplierRepo) FindByUserID(ctx context.Context, userID uint) ([]*domain.Supplier, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Supplier), args.Error(1)
+}
+
+func (m *MockSupplierRepo) FindByNameAndUserID(ctx context.Context, name string, userID uint) (*domain.Supplier, error) {
+	args := m.Called(ctx, name, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Supplier), args.Error(1)
+}
+
+func (m *MockSupplierRepo) Update(ctx context.Context, supplier *domain.Supplier, userID uint) error {
+	args := m.Called(ctx, supplier, userID)
+	return args.Error(0)
+}
+
+func (m *MockSupplierRepo) Delete(ctx context.Context, id, userID uint) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+func (m *MockSupplierRepo) FindProducts(ctx context.Context, supplierID, userID uint) ([]*domain.SupplierProduct, error) {
+	args := m.Called(ctx, supplierID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.SupplierProduct), args.Error(1)
+}
+
+func (m *MockSupplierRepo) FindProduct(ctx context.Context, supplierID, productID, userID uint) (*domain.SupplierProduct, error) {
+	args := m.Called(ctx, supplierID, productID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SupplierProduct), args.Error(1)
+}
+
+func (m *MockSupplierRepo) SaveProduct(ctx context.Context, link *domain.SupplierProduct) error {
+	args := m.Called(ctx, link)
+	return args.Error(0)
+}
+
+func (m *MockSupplierRepo) DeleteProduct(ctx context.Context, supplierID, productID, userID uint) error {
+	args := m.Called(ctx, supplierID, productID, userID)
+	return args.Error(0)
+}
+
+func TestCreateSupplier_Success(t *testing.T) {
+	mockSupplierRepo := new(MockSupplierRepo)
+	supplierService := service.NewSupplierService(mockSupplierRepo, new(MockProductRepo), new(MockPurchaseOrderRepo))
+
+	mockSupplierRepo.On("FindByNameAndUserID", mock.Anything, "Acme", uint(1)).Return(nil, errors.New("not found"))
+	mockSupplierRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Supplier")).Return(nil)
+
+	supplier, err := supplierService.CreateSupplier(context.Background(), 1, service.SupplierRequest{Name: " Acme ", Email: "sales@acme.test"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Acme", supplier.Name)
+	assert.Equal(t, uint(1), supplier.UserID)
+}
+
+func TestCreateSupplier_Error_DuplicateName(t *testing.T) {
+	mockSupplierRepo := new(MockSupplierRepo)
+	supplierService := service.NewSupplierService(mockSupplierRepo, new(MockProductRepo), new(MockPurchaseOrderRepo))
+
+	mockSupplierRepo.On("FindByNameAndUserID", mock.Anything, "Acme", uint(1)).Return(&domain.Supplier{ID: 2}, nil)
+
+	_, err := supplierService.CreateSupplier(context.Background(), 1, service.SupplierRequest{Name: "Acme"})
+
+	assert.EqualError(t, err, "supplier name already exists for this user")
+}
+
+func TestDeleteSupplier_Error_HasPurchaseOrders(t *testing.T) {
+	mockSupplierRepo := new(MockSupplierRepo)
+	mockPurchaseOrderRepo := new(MockPurchaseOrderRepo)
+	supplierService := service.NewSupplierService(mockSupplierRepo, new(MockProductRepo), mockPurchaseOrderRepo)
+
+	mockSupplierRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Supplier{ID: 1, UserID: 1}, nil)
+	mockPurchaseOrderRepo.On("CountBySupplierID", mock.Anything, uint(1), uint(1)).Return(int64(2), nil)
+
+	err := supplierService.DeleteSupplier(context.Background(), 1, 1)
+
+	assert.EqualError(t, err, "cannot delete a supplier with purchase orders")
+	mockSupplierRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSetSupplierProduct_UpdatesExistingLink(t *testing.T) {
+	mockSupplierRepo := new(MockSupplierRepo)
+	mockProductRepo := new(MockProductRepo)
+	supplierService := service.NewSupplierService(mockSupplierRepo, mockProductRepo, new(MockPurchaseOrderRepo))
+
+	link := &domain.SupplierProduct{ID: 5, UserID: 1, SupplierID: 1, ProductID: 3, SupplierSKU: "OLD"}
+	mockSupplierRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Supplier{ID: 1}, nil)
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(3), uint(1)).Return(&domain.Product{ID: 3}, nil)
+	mockSupplierRepo.On("FindProduct", mock.Anything, uint(1), uint(3), uint(1)).Return(link, nil)
+	mockSupplierRepo.On("SaveProduct", mock.Anything, link).Return(nil)
+
+	result, err := supplierService.SetSupplierProduct(context.Background(), 1, 3, 1, service.SupplierProductRequest{
+		SupplierSKU:  "ACM-3",
+		UnitCost:     12.5,
+		LeadTimeDays: 7,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(5), result.ID)
+	assert.Equal(t, "ACM-3", result.SupplierSKU)
+	assert.Equal(t, 12.5, result.UnitCost)
+	assert.Equal(t, 7, result.LeadTimeDays)
+}
+
+func TestSetSupplierProduct_Error_NegativeLeadTime(t *testing.T) {
+	mockSupplierRepo := new(MockSupplierRepo)
+	mockProductRepo := new(MockProductRepo)
+	supplierService := service.NewSupplierService(mockSupplierRepo, mockProductRepo, new(MockPurchaseOrderRepo))
+
+	mockSupplierRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Supplier{ID: 1}, nil)
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(3), uint(1)).Return(&domain.Product{ID: 3}, nil)
+
+	_, err := supplierService.SetSupplierProduct(context.Background(), 1, 3, 1, service.SupplierProductRequest{LeadTimeDays: -1})
+
+	assert.EqualError(t, err, "lead time cannot be negative")
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTagRepo struct {
+	mock.Mock
+}
+
+func (m *MockTagRepo) FindByUserID(ctx context.Context, userID uint) ([]*domain.TagUsage, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.TagUsage), args.Error(1)
+}
+
+func (m *MockTagRepo) FindByIDAndUserID(ctx context.Context, id, userID uint) (*domain.Tag, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Tag), args.Error(1)
+}
+
+func (m *MockTagRepo) FindOrCreate(ctx context.Context, userID uint, names []string) ([]*domain.Tag, error) {
+	args := m.Called(ctx, userID, names)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Tag), args.Error(1)
+}
+
+func (m *MockTagRepo) Delete(ctx context.Context, id, userID uint) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+func (m *MockTagRepo) ReplaceProductTags(ctx context.Context, product *domain.Product, tags []*domain.Tag) error {
+	args := m.Called(ctx, product, tags)
+	return args.Error(0)
+}
+
+func TestSetProductTags_NormalizesNames(t *testing.T) {
+	mockTagRepo := new(MockTagRepo)
+	mockProductRepo := new(MockProductRepo)
+	tagService := service.NewTagService(mockTagRepo, mockProductRepo)
+
+	product := &domain.Product{ID: 1, UserID: 1}
+	tags := []*domain.Tag{{ID: 1, Name: "cotton"}, {ID: 2, Name: "summer"}}
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	mockTagRepo.On("FindOrCreate", mock.Anything, uint(1), []string{"summer", "cotton"}).Return(tags, nil)
+	mockTagRepo.On("ReplaceProductTags", mock.Anything, product, tags).Return(nil)
+
+	result, err := tagService.SetProductTags(context.Background(), 1, 1, []string{" Summer", "cotton", "SUMMER "})
+
+	assert.NoError(t, err)
+	assert.Len(t, result.Tags, 2)
+	assert.Equal(t, "cotton", result.Tags[0].Name)
+}
+
+func TestSetProductTags_EmptyListClearsTags(t *testing.T) {
+	mockTagRepo := new(MockTagRepo)
+	mockProductRepo := new(MockProductRepo)
+	tagService := service.NewTagService(mockTagRepo, mockProductRepo)
+
+	product := &domain.Product{ID: 1, UserID: 1, Tags: []domain.Tag{{ID: 1, Name: "summer"}}}
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	mockTagRepo.On("ReplaceProductTags", mock.Anything, product, []*domain.Tag{}).Return(nil)
+
+	result, err := tagService.SetProductTags(context.Background(), 1, 1, nil)
+
+	assert.NoError(t, err)
+	assert.Empty(t, result.Tags)
+	mockTagRepo.AssertNotCalled(t, "FindOrCreate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSetProductTags_Error_EmptyTag(t *testing.T) {
+	mockTagRepo := new(MockTagRepo)
+	mockProductRepo := new(MockProductRepo)
+	tagService := service.NewTagService(mockTagRepo, mockProductRepo)
+
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Product{ID: 1}, nil)
+
+	_, err := tagService.SetProductTags(context.Background(), 1, 1, []string{"summer", "  "})
+
+	assert.EqualError(t, err, "tags cannot be empty")
+}
+
+func TestDeleteTag_Error_NotFound(t *testing.T) {
+	mockTagRepo := new(MockTagRepo)
+	tagService := service.NewTagService(mockTagRepo, new(MockProductRepo))
+
+	mockTagRepo.On("FindByIDAndUserID", mock.Anything, uint(9), uint(1)).Return(nil, errors.New("record not found"))
+
+	err := tagService.DeleteTag(context.Background(), 9, 1)
+
+	assert.EqualError(t, err, "tag not found")
+}
@@ -2,14 +2,20 @@ package tests
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"regexp"
 	"testing"
+	"time"
 
 	"vertice-backend/internal/domain"
 	"vertice-backend/internal/service"
+	"vertice-backend/pkg/password"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Mock of UserRepository
@@ -35,20 +41,126 @@ func (m *MockUserRepo) FindByID(ctx context.Context, id uint) (*domain.User, err
 	}
 	return args.Get(0).(*domain.User), args.Error(1)
 }
+func (m *MockUserRepo) Update(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+type MockUserTokenRepo struct {
+	mock.Mock
+}
+
+func (m *MockUserTokenRepo) Create(ctx context.Context, token *domain.UserToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockUserTokenRepo) LockByHash(ctx context.Context, hash string) (*domain.UserToken, error) {
+	args := m.Called(ctx, hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UserToken), args.Error(1)
+}
+
+func (m *MockUserTokenRepo) Update(ctx context.Context, token *domain.UserToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockUserTokenRepo) InvalidateByUserID(ctx context.Context, userID uint, purpose domain.UserTokenPurpose, t time.Time) error {
+	args := m.Called(ctx, userID, purpose, t)
+	return args.Error(0)
+}
 
 func TestRegister_Success(t *testing.T) {
 	mockRepo := new(MockUserRepo)
 	service := service.NewUserService(mockRepo)
 
+	mockRepo.On("FindByEmail", mock.Anything, "test@mail.com").Return(nil, errors.New("not found"))
 	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil)
 
-	user, err := service.Register(context.Background(), "Test", "test@mail.com", "password123")
+	user, err := service.Register(context.Background(), "Test", "test@mail.com", "correct-horse-42")
 	assert.NoError(t, err)
 	assert.Equal(t, "Test", user.Name)
 	assert.Equal(t, "test@mail.com", user.Email)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestRegister_NormalizesEmail(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	service := service.NewUserService(mockRepo)
+
+	mockRepo.On("FindByEmail", mock.Anything, "test@mail.com").Return(nil, errors.New("not found"))
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil)
+
+	user, err := service.Register(context.Background(), "  Test ", " Test@Mail.COM ", "correct-horse-42")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Test", user.Name)
+	assert.Equal(t, "test@mail.com", user.Email)
+}
+
+func TestRegister_Error_InvalidFields(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	userService := service.NewUserService(mockRepo)
+
+	_, err := userService.Register(context.Background(), " ", "not-an-email", "short")
+
+	var fieldErrs *service.FieldErrors
+	if assert.ErrorAs(t, err, &fieldErrs) {
+		assert.EqualError(t, err, "3 fields are invalid")
+		assert.Equal(t, []service.FieldError{
+			{Field: "name", Message: "name is required"},
+			{Field: "email", Message: "email is not a valid address"},
+			{Field: "password", Message: "password must be at least 8 characters"},
+		}, fieldErrs.Fields)
+	}
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestRegister_Error_InvalidEmails(t *testing.T) {
+	for _, email := range []string{"", "John <john@mail.com>", "john@localhost", "john@@mail.com", "john mail.com"} {
+		_, err := service.NewUserService(new(MockUserRepo)).Register(context.Background(), "John", email, "correct-horse-42")
+
+		var fieldErrs *service.FieldErrors
+		if assert.ErrorAs(t, err, &fieldErrs, email) {
+			assert.Equal(t, "email", fieldErrs.Fields[0].Field, email)
+		}
+	}
+}
+
+func TestRegister_Error_BreachedPassword(t *testing.T) {
+	_, err := service.NewUserService(new(MockUserRepo)).Register(context.Background(), "Test", "test@mail.com", "Password123")
+
+	assert.EqualError(t, err, "password is too common; it has appeared in data breaches")
+}
+
+func TestRegister_Error_EmailTaken(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	userService := service.NewUserService(mockRepo)
+
+	mockRepo.On("FindByEmail", mock.Anything, "test@mail.com").Return(&domain.User{ID: 7}, nil)
+
+	_, err := userService.Register(context.Background(), "Test", "Test@mail.com", "correct-horse-42")
+
+	assert.ErrorIs(t, err, service.ErrEmailTaken)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestRegister_Error_EmailTakenConcurrently(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	userService := service.NewUserService(mockRepo)
+
+	mockRepo.On("FindByEmail", mock.Anything, "test@mail.com").Return(nil, errors.New("not found")).Once()
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.User")).Return(errors.New(`ERROR: duplicate key value violates unique constraint "idx_users_email" (SQLSTATE 23505)`))
+	mockRepo.On("FindByEmail", mock.Anything, "test@mail.com").Return(&domain.User{ID: 8}, nil).Once()
+
+	_, err := userService.Register(context.Background(), "Test", "test@mail.com", "correct-horse-42")
+
+	assert.ErrorIs(t, err, service.ErrEmailTaken)
+}
+
 func TestAuthenticate_Fail_NotFound(t *testing.T) {
 	mockRepo := new(MockUserRepo)
 	service := service.NewUserService(mockRepo)
@@ -58,3 +170,226 @@ func TestAuthenticate_Fail_NotFound(t *testing.T) {
 	_, err := service.Authenticate(context.Background(), "notfound@mail.com", "password123")
 	assert.Error(t, err)
 }
+
+func TestRegister_RecordsEvent(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	events := &recordingEvents{}
+	tx := &countingTransactor{}
+	service := service.NewUserService(mockRepo, service.WithUserTransactor(tx), service.WithUserEvents(events))
+
+	mockRepo.On("FindByEmail", mock.Anything, "test@mail.com").Return(nil, errors.New("not found"))
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.User")).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.User).ID = 7
+	}).Return(nil)
+
+	_, err := service.Register(context.Background(), "Test", "test@mail.com", "correct-horse-42")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, tx.calls)
+	assert.Equal(t, []domain.Event{domain.UserRegistered{UserID: 7, Name: "Test", Email: "test@mail.com"}}, events.events)
+}
+
+// tokenUserService builds a UserService with tokens enabled whose emails,
+// without an app URL, carry the bare token.
+func tokenUserService(m *recordingMailer, opts ...service.UserServiceOption) (*service.UserService, *MockUserRepo, *MockUserTokenRepo) {
+	users := new(MockUserRepo)
+	tokens := new(MockUserTokenRepo)
+	logs := new(MockEmailLogRepo)
+	preferences := new(MockNotificationPreferencesRepo)
+	logs.On("Create", mock.Anything, mock.AnythingOfType("*domain.EmailLog")).Return(nil)
+	preferences.On("FindByUserID", mock.Anything, mock.Anything).Return(nil, errors.New("not found"))
+	emails := service.NewEmailService(logs, preferences, users, new(MockOrderRepo), service.WithEmailMailer(m))
+	opts = append([]service.UserServiceOption{service.WithUserTokens(tokens, emails)}, opts...)
+	return service.NewUserService(users, opts...), users, tokens
+}
+
+func tokenHash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestRegister_EmailsVerificationToken(t *testing.T) {
+	m := &recordingMailer{}
+	userService, users, tokens := tokenUserService(m)
+
+	users.On("FindByEmail", mock.Anything, "test@mail.com").Return(nil, errors.New("not found"))
+	users.On("Create", mock.Anything, mock.AnythingOfType("*domain.User")).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.User).ID = 7
+	}).Return(nil)
+	tokens.On("InvalidateByUserID", mock.Anything, uint(7), domain.TokenVerifyEmail, mock.Anything).Return(nil)
+	var stored *domain.UserToken
+	tokens.On("Create", mock.Anything, mock.AnythingOfType("*domain.UserToken")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*domain.UserToken)
+	}).Return(nil)
+
+	_, err := userService.Register(context.Background(), "Test", "test@mail.com", "correct-horse-42")
+
+	assert.NoError(t, err)
+	if assert.Len(t, m.sent, 1) && assert.NotNil(t, stored) {
+		assert.Equal(t, "Verify your email address", m.sent[0].Subject)
+		raw := regexp.MustCompile(`code: (\S+)`).FindStringSubmatch(m.sent[0].Text)
+		if assert.Len(t, raw, 2) {
+			assert.Equal(t, tokenHash(raw[1]), stored.TokenHash)
+		}
+		assert.Equal(t, domain.TokenVerifyEmail, stored.Purpose)
+		assert.WithinDuration(t, time.Now().Add(48*time.Hour), stored.ExpiresAt, time.Minute)
+	}
+}
+
+func TestVerifyEmail_Success(t *testing.T) {
+	userService, users, tokens := tokenUserService(&recordingMailer{})
+
+	token := &domain.UserToken{ID: 3, UserID: 7, Purpose: domain.TokenVerifyEmail, ExpiresAt: time.Now().Add(time.Hour)}
+	tokens.On("LockByHash", mock.Anything, tokenHash("abc")).Return(token, nil)
+	users.On("FindByID", mock.Anything, uint(7)).Return(&domain.User{ID: 7}, nil)
+	users.On("Update", mock.Anything, mock.MatchedBy(func(u *domain.User) bool { return u.EmailVerifiedAt != nil })).Return(nil)
+	tokens.On("Update", mock.Anything, mock.MatchedBy(func(t *domain.UserToken) bool { return t.UsedAt != nil })).Return(nil)
+	tokens.On("InvalidateByUserID", mock.Anything, uint(7), domain.TokenVerifyEmail, mock.Anything).Return(nil)
+
+	user, err := userService.VerifyEmail(context.Background(), "abc")
+
+	assert.NoError(t, err)
+	assert.NotNil(t, user.EmailVerifiedAt)
+	users.AssertExpectations(t)
+	tokens.AssertExpectations(t)
+}
+
+func TestVerifyEmail_Error_InvalidTokens(t *testing.T) {
+	used := time.Now().Add(-time.Minute)
+	for name, token := range map[string]*domain.UserToken{
+		"expired":       {UserID: 7, Purpose: domain.TokenVerifyEmail, ExpiresAt: time.Now().Add(-time.Second)},
+		"used":          {UserID: 7, Purpose: domain.TokenVerifyEmail, ExpiresAt: time.Now().Add(time.Hour), UsedAt: &used},
+		"wrong purpose": {UserID: 7, Purpose: domain.TokenResetPassword, ExpiresAt: time.Now().Add(time.Hour)},
+	} {
+		userService, _, tokens := tokenUserService(&recordingMailer{})
+		tokens.On("LockByHash", mock.Anything, tokenHash("abc")).Return(token, nil)
+
+		_, err := userService.VerifyEmail(context.Background(), "abc")
+
+		assert.EqualError(t, err, "invalid or expired token", name)
+	}
+}
+
+func TestResetPassword_RevokesSessions(t *testing.T) {
+	userService, users, tokens := tokenUserService(&recordingMailer{})
+
+	token := &domain.UserToken{ID: 4, UserID: 7, Purpose: domain.TokenResetPassword, ExpiresAt: time.Now().Add(time.Hour)}
+	tokens.On("LockByHash", mock.Anything, tokenHash("abc")).Return(token, nil)
+	users.On("FindByID", mock.Anything, uint(7)).Return(&domain.User{ID: 7, Password: "old", SessionVersion: 2}, nil)
+	var updated *domain.User
+	users.On("Update", mock.Anything, mock.AnythingOfType("*domain.User")).Run(func(args mock.Arguments) {
+		updated = args.Get(1).(*domain.User)
+	}).Return(nil)
+	tokens.On("Update", mock.Anything, mock.AnythingOfType("*domain.UserToken")).Return(nil)
+	tokens.On("InvalidateByUserID", mock.Anything, uint(7), domain.TokenResetPassword, mock.Anything).Return(nil)
+
+	err := userService.ResetPassword(context.Background(), "abc", "newpassword123")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, updated.SessionVersion)
+	assert.NotNil(t, updated.EmailVerifiedAt)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(updated.Password), []byte("newpassword123")))
+	tokens.AssertExpectations(t)
+}
+
+func TestForgotPassword_UnknownEmailSucceedsSilently(t *testing.T) {
+	m := &recordingMailer{}
+	userService, users, tokens := tokenUserService(m)
+
+	users.On("FindByEmail", mock.Anything, "nobody@mail.com").Return(nil, errors.New("not found"))
+
+	err := userService.ForgotPassword(context.Background(), "nobody@mail.com")
+	userService.Wait()
+
+	assert.NoError(t, err)
+	assert.Empty(t, m.sent)
+	tokens.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestForgotPassword_EmailsResetToken(t *testing.T) {
+	m := &recordingMailer{}
+	userService, users, tokens := tokenUserService(m)
+
+	users.On("FindByEmail", mock.Anything, "test@mail.com").Return(&domain.User{ID: 7, Name: "Test", Email: "test@mail.com"}, nil)
+	tokens.On("InvalidateByUserID", mock.Anything, uint(7), domain.TokenResetPassword, mock.Anything).Return(nil)
+	tokens.On("Create", mock.Anything, mock.MatchedBy(func(t *domain.UserToken) bool {
+		return t.Purpose == domain.TokenResetPassword && time.Until(t.ExpiresAt) <= time.Hour
+	})).Return(nil)
+
+	err := userService.ForgotPassword(context.Background(), "test@mail.com")
+	userService.Wait()
+
+	assert.NoError(t, err)
+	if assert.Len(t, m.sent, 1) {
+		assert.Equal(t, []string{"test@mail.com"}, m.sent[0].To)
+		assert.Equal(t, "Reset your password", m.sent[0].Subject)
+		assert.Contains(t, m.sent[0].Text, "expires in 1 hour")
+	}
+	tokens.AssertExpectations(t)
+}
+
+func TestForgotPassword_ReturnsBeforeLookingUpTheAccount(t *testing.T) {
+	m := &recordingMailer{}
+	userService, users, tokens := tokenUserService(m)
+
+	release := make(chan time.Time)
+	users.On("FindByEmail", mock.Anything, "test@mail.com").WaitUntil(release).Return(&domain.User{ID: 7, Email: "test@mail.com"}, nil)
+	tokens.On("InvalidateByUserID", mock.Anything, uint(7), domain.TokenResetPassword, mock.Anything).Return(nil)
+	tokens.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	err := userService.ForgotPassword(context.Background(), "test@mail.com")
+
+	assert.NoError(t, err)
+	close(release)
+	userService.Wait()
+	assert.Len(t, m.sent, 1)
+}
+
+func TestResendVerification_SkipsVerifiedAccounts(t *testing.T) {
+	m := &recordingMailer{}
+	userService, users, tokens := tokenUserService(m)
+
+	verifiedAt := time.Now()
+	users.On("FindByEmail", mock.Anything, "test@mail.com").Return(&domain.User{ID: 7, Email: "test@mail.com", EmailVerifiedAt: &verifiedAt}, nil)
+
+	err := userService.ResendVerification(context.Background(), "Test@Mail.com")
+	userService.Wait()
+
+	assert.NoError(t, err)
+	assert.Empty(t, m.sent)
+	tokens.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAuthenticate_Error_EmailNotVerified(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	userService := service.NewUserService(mockRepo, service.WithRequireVerifiedEmail())
+
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	mockRepo.On("FindByEmail", mock.Anything, "test@mail.com").Return(&domain.User{ID: 7, Password: string(hashed)}, nil)
+
+	_, err := userService.Authenticate(context.Background(), "test@mail.com", "password123")
+
+	assert.ErrorIs(t, err, service.ErrEmailNotVerified)
+}
+
+func TestValidateSession(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	userService := service.NewUserService(mockRepo)
+
+	mockRepo.On("FindByID", mock.Anything, uint(7)).Return(&domain.User{ID: 7, SessionVersion: 3}, nil)
+
+	assert.NoError(t, userService.ValidateSession(context.Background(), 7, 3))
+	assert.EqualError(t, userService.ValidateSession(context.Background(), 7, 2), "session has been revoked")
+}
+
+func TestResetPassword_Error_PolicyKeepsToken(t *testing.T) {
+	userService, _, tokens := tokenUserService(&recordingMailer{}, service.WithPasswordPolicy(password.Policy{MinLength: 8, RequireDigit: true}))
+
+	err := userService.ResetPassword(context.Background(), "abc", "no-digits-here")
+
+	var fieldErrs *service.FieldErrors
+	if assert.ErrorAs(t, err, &fieldErrs) {
+		assert.Equal(t, []service.FieldError{{Field: "password", Message: "password must contain a digit"}}, fieldErrs.Fields)
+	}
+	tokens.AssertNotCalled(t, "LockByHash", mock.Anything, mock.Anything)
+}
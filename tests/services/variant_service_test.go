@@ -0,0 +1,381 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockVariantRepo struct {
+	mock.Mock
+}
+
+func (m *MockVariantRepo) CreateOptionType(ctx context.Context, optionType *domain.OptionType) error {
+	args := m.Called(ctx, optionType)
+	return args.Error(0)
+}
+
+func (m *MockVariantRepo) FindOptionTypeByIDAndUserID(ctx context.Context, id, userID uint) (*domain.OptionType, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OptionType), args.Error(1)
+}
+
+func (m *MockVariantRepo) FindOptionTypeByNameAndUserID(ctx context.Context, name string, userID uint) (*domain.OptionType, error) {
+	args := m.Called(ctx, name, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OptionType), args.Error(1)
+}
+
+func (m *MockVariantRepo) FindOptionTypesByUserID(ctx context.Context, userID uint) ([]*domain.OptionType, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.OptionType), args.Error(1)
+}
+
+func (m *MockVariantRepo) DeleteOptionType(ctx context.Context, id, userID uint) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+func (m *MockVariantRepo) CreateOptionValue(ctx context.Context, value *domain.OptionValue) error {
+	args := m.Called(ctx, value)
+	return args.Error(0)
+}
+
+func (m *MockVariantRepo) DeleteOptionValue(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockVariantRepo) FindOptionValues(ctx context.Context, ids []uint, userID uint) ([]*domain.OptionValue, error) {
+	args := m.Called(ctx, ids, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.OptionValue), args.Error(1)
+}
+
+func (m *MockVariantRepo) CountVariantsByOptionType(ctx context.Context, optionTypeID uint) (int64, error) {
+	args := m.Called(ctx, optionTypeID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockVariantRepo) CountVariantsByOptionValue(ctx context.Context, optionValueID uint) (int64, error) {
+	args := m.Called(ctx, optionValueID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockVariantRepo) CreateVariant(ctx context.Context, variant *domain.ProductVariant) error {
+	args := m.Called(ctx, variant)
+	return args.Error(0)
+}
+
+func (m *MockVariantRepo) FindVariantByCodeAndUserID(ctx context.Context, code string, userID uint) (*domain.ProductVariant, error) {
+	args := m.Called(ctx, code, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ProductVariant), args.Error(1)
+}
+
+func (m *MockVariantRepo) UpdateVariant(ctx context.Context, variant *domain.ProductVariant) error {
+	args := m.Called(ctx, variant)
+	return args.Error(0)
+}
+
+func (m *MockVariantRepo) DeleteVariant(ctx context.Context, id, userID uint) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+var (
+	colorType = &domain.OptionType{ID: 1, Name: "Color"}
+	sizeType  = &domain.OptionType{ID: 2, Name: "Size"}
+	red       = &domain.OptionValue{ID: 10, OptionTypeID: 1, OptionType: colorType, Value: "Red"}
+	blue      = &domain.OptionValue{ID: 11, OptionTypeID: 1, OptionType: colorType, Value: "Blue"}
+	medium    = &domain.OptionValue{ID: 20, OptionTypeID: 2, OptionType: sizeType, Value: "M"}
+)
+
+func variantOption(value *domain.OptionValue) domain.VariantOption {
+	return domain.VariantOption{OptionTypeID: value.OptionTypeID, OptionValueID: value.ID, OptionValue: *value}
+}
+
+// productWithVariants returns a product with a red and a blue variant holding
+// 3 and 2 units.
+func productWithVariants() *domain.Product {
+	price := 12.0
+	return &domain.Product{
+		ID: 1, UserID: 1, Code: "TSHIRT", Name: "T-Shirt", Price: 10, Stock: 5, ReorderPoint: 1,
+		Variants: []domain.ProductVariant{
+			{ID: 5, UserID: 1, ProductID: 1, Code: "TSHIRT-RED", Stock: 3, Options: []domain.VariantOption{variantOption(red)}},
+			{ID: 6, UserID: 1, ProductID: 1, Code: "TSHIRT-BLUE", Price: &price, Stock: 2, Options: []domain.VariantOption{variantOption(blue)}},
+		},
+	}
+}
+
+func TestCreateOptionType_Success(t *testing.T) {
+	mockVariantRepo := new(MockVariantRepo)
+	variantService := service.NewVariantService(new(MockProductRepo), mockVariantRepo)
+
+	mockVariantRepo.On("FindOptionTypeByNameAndUserID", mock.Anything, "Size", uint(1)).Return(nil, errors.New("not found"))
+	mockVariantRepo.On("CreateOptionType", mock.Anything, mock.AnythingOfType("*domain.OptionType")).Return(nil)
+
+	optionType, err := variantService.CreateOptionType(context.Background(), 1, service.OptionTypeRequest{Name: " Size ", Values: []string{"S", "M", "L"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Size", optionType.Name)
+	assert.Len(t, optionType.Values, 3)
+	assert.Equal(t, 2, optionType.Values[2].Position)
+}
+
+func TestCreateOptionType_Error_DuplicateValue(t *testing.T) {
+	mockVariantRepo := new(MockVariantRepo)
+	variantService := service.NewVariantService(new(MockProductRepo), mockVariantRepo)
+
+	mockVariantRepo.On("FindOptionTypeByNameAndUserID", mock.Anything, "Size", uint(1)).Return(nil, errors.New("not found"))
+
+	_, err := variantService.CreateOptionType(context.Background(), 1, service.OptionTypeRequest{Name: "Size", Values: []string{"M", "M"}})
+
+	assert.EqualError(t, err, "duplicate option value: M")
+	mockVariantRepo.AssertNotCalled(t, "CreateOptionType", mock.Anything, mock.Anything)
+}
+
+func TestDeleteOptionValue_Error_InUse(t *testing.T) {
+	mockVariantRepo := new(MockVariantRepo)
+	variantService := service.NewVariantService(new(MockProductRepo), mockVariantRepo)
+
+	optionType := &domain.OptionType{ID: 1, UserID: 1, Name: "Color", Values: []domain.OptionValue{*red}}
+	mockVariantRepo.On("FindOptionTypeByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(optionType, nil)
+	mockVariantRepo.On("CountVariantsByOptionValue", mock.Anything, uint(10)).Return(int64(2), nil)
+
+	err := variantService.DeleteOptionValue(context.Background(), 1, 10, 1)
+
+	assert.EqualError(t, err, "option value is used by variants")
+	mockVariantRepo.AssertNotCalled(t, "DeleteOptionValue", mock.Anything, mock.Anything)
+}
+
+func TestCreateVariant_FirstVariantReplacesProductStock(t *testing.T) {
+	mockProductRepo := new(MockProductRepo)
+	mockVariantRepo := new(MockVariantRepo)
+	events := &recordingEvents{}
+	variantService := service.NewVariantService(mockProductRepo, mockVariantRepo, service.WithVariantEvents(events))
+
+	product := &domain.Product{ID: 1, UserID: 1, Code: "TSHIRT", Price: 10, Stock: 9}
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	mockProductRepo.On("FindByCodeAndUserID", mock.Anything, "TSHIRT-RED-M", uint(1)).Return(nil, errors.New("not found"))
+	mockVariantRepo.On("FindVariantByCodeAndUserID", mock.Anything, "TSHIRT-RED-M", uint(1)).Return(nil, errors.New("not found"))
+	mockVariantRepo.On("FindOptionValues", mock.Anything, []uint{20, 10}, uint(1)).Return([]*domain.OptionValue{medium, red}, nil)
+	mockVariantRepo.On("CreateVariant", mock.Anything, mock.AnythingOfType("*domain.ProductVariant")).Return(nil)
+	mockProductRepo.On("Update", mock.Anything, product, uint(1)).Return(nil)
+
+	result, err := variantService.CreateVariant(context.Background(), 1, 1, service.CreateVariantRequest{
+		Code:           "TSHIRT-RED-M",
+		Stock:          4,
+		OptionValueIDs: []uint{20, 10},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, result.Stock)
+	assert.Len(t, result.Variants, 1)
+	options := result.Variants[0].Options
+	assert.Equal(t, []uint{1, 2}, []uint{options[0].OptionTypeID, options[1].OptionTypeID})
+	assert.Equal(t, "Color", options[0].OptionType.Name)
+	assert.Len(t, events.events, 1)
+	adjusted := events.events[0].(domain.StockAdjusted)
+	assert.Equal(t, 9, adjusted.Previous)
+	assert.Equal(t, 4, *adjusted.VariantStock)
+}
+
+func TestCreateVariant_Error_DuplicateCombination(t *testing.T) {
+	mockProductRepo := new(MockProductRepo)
+	mockVariantRepo := new(MockVariantRepo)
+	variantService := service.NewVariantService(mockProductRepo, mockVariantRepo)
+
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(productWithVariants(), nil)
+	mockProductRepo.On("FindByCodeAndUserID", mock.Anything, "TSHIRT-RED-2", uint(1)).Return(nil, errors.New("not found"))
+	mockVariantRepo.On("FindVariantByCodeAndUserID", mock.Anything, "TSHIRT-RED-2", uint(1)).Return(nil, errors.New("not found"))
+	mockVariantRepo.On("FindOptionValues", mock.Anything, []uint{10}, uint(1)).Return([]*domain.OptionValue{red}, nil)
+
+	_, err := variantService.CreateVariant(context.Background(), 1, 1, service.CreateVariantRequest{Code: "TSHIRT-RED-2", OptionValueIDs: []uint{10}})
+
+	assert.EqualError(t, err, "a variant with these options already exists")
+	mockVariantRepo.AssertNotCalled(t, "CreateVariant", mock.Anything, mock.Anything)
+}
+
+func TestCreateVariant_Error_DifferentOptionTypes(t *testing.T) {
+	mockProductRepo := new(MockProductRepo)
+	mockVariantRepo := new(MockVariantRepo)
+	variantService := service.NewVariantService(mockProductRepo, mockVariantRepo)
+
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(productWithVariants(), nil)
+	mockProductRepo.On("FindByCodeAndUserID", mock.Anything, "TSHIRT-M", uint(1)).Return(nil, errors.New("not found"))
+	mockVariantRepo.On("FindVariantByCodeAndUserID", mock.Anything, "TSHIRT-M", uint(1)).Return(nil, errors.New("not found"))
+	mockVariantRepo.On("FindOptionValues", mock.Anything, []uint{20}, uint(1)).Return([]*domain.OptionValue{medium}, nil)
+
+	_, err := variantService.CreateVariant(context.Background(), 1, 1, service.CreateVariantRequest{Code: "TSHIRT-M", OptionValueIDs: []uint{20}})
+
+	assert.EqualError(t, err, "variants of a product must use the same option types")
+}
+
+func TestCreateVariant_Error_TwoValuesOfOneType(t *testing.T) {
+	mockProductRepo := new(MockProductRepo)
+	mockVariantRepo := new(MockVariantRepo)
+	variantService := service.NewVariantService(mockProductRepo, mockVariantRepo)
+
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(&domain.Product{ID: 1, UserID: 1}, nil)
+	mockProductRepo.On("FindByCodeAndUserID", mock.Anything, "TSHIRT-X", uint(1)).Return(nil, errors.New("not found"))
+	mockVariantRepo.On("FindVariantByCodeAndUserID", mock.Anything, "TSHIRT-X", uint(1)).Return(nil, errors.New("not found"))
+	mockVariantRepo.On("FindOptionValues", mock.Anything, []uint{10, 11}, uint(1)).Return([]*domain.OptionValue{red, blue}, nil)
+
+	_, err := variantService.CreateVariant(context.Background(), 1, 1, service.CreateVariantRequest{Code: "TSHIRT-X", OptionValueIDs: []uint{10, 11}})
+
+	assert.EqualError(t, err, "a variant can have only one value per option type")
+}
+
+func TestCreateVariant_Error_CodeUsedByProduct(t *testing.T) {
+	mockProductRepo := new(MockProductRepo)
+	mockVariantRepo := new(MockVariantRepo)
+	variantService := service.NewVariantService(mockProductRepo, mockVariantRepo)
+
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(productWithVariants(), nil)
+	mockProductRepo.On("FindByCodeAndUserID", mock.Anything, "TSHIRT", uint(1)).Return(&domain.Product{ID: 1}, nil)
+
+	_, err := variantService.CreateVariant(context.Background(), 1, 1, service.CreateVariantRequest{Code: "TSHIRT", OptionValueIDs: []uint{10}})
+
+	assert.EqualError(t, err, "code is already used by a product")
+}
+
+func TestUpdateVariant_ClearsPriceOverride(t *testing.T) {
+	mockProductRepo := new(MockProductRepo)
+	mockVariantRepo := new(MockVariantRepo)
+	variantService := service.NewVariantService(mockProductRepo, mockVariantRepo)
+
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(productWithVariants(), nil)
+	mockVariantRepo.On("UpdateVariant", mock.Anything, mock.AnythingOfType("*domain.ProductVariant")).Return(nil)
+
+	product, err := variantService.UpdateVariant(context.Background(), 1, 6, 1, service.UpdateVariantRequest{ClearPrice: true})
+
+	assert.NoError(t, err)
+	variant, _ := product.Variant(6)
+	assert.Nil(t, variant.Price)
+	assert.Equal(t, 10.0, variant.EffectivePrice(product))
+}
+
+func TestUpdateVariantStock_UpdatesVariantAndProduct(t *testing.T) {
+	mockProductRepo := new(MockProductRepo)
+	mockVariantRepo := new(MockVariantRepo)
+	events := &recordingEvents{}
+	tx := &countingTransactor{}
+	variantService := service.NewVariantService(mockProductRepo, mockVariantRepo,
+		service.WithVariantTransactor(tx),
+		service.WithVariantEvents(events),
+	)
+
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(productWithVariants(), nil)
+	mockVariantRepo.On("UpdateVariant", mock.Anything, mock.AnythingOfType("*domain.ProductVariant")).Return(nil)
+	mockProductRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Product"), uint(1)).Return(nil)
+
+	product, err := variantService.UpdateVariantStock(context.Background(), 1, 5, 1, -3)
+
+	assert.NoError(t, err)
+	variant, _ := product.Variant(5)
+	assert.Equal(t, 0, variant.Stock)
+	assert.Equal(t, 2, product.Stock)
+	assert.Equal(t, 1, tx.calls)
+	assert.Equal(t, []string{domain.EventStockAdjusted}, events.types())
+	assert.Equal(t, uint(5), events.events[0].(domain.StockAdjusted).VariantID)
+}
+
+func TestUpdateVariantStock_Error_Negative(t *testing.T) {
+	mockProductRepo := new(MockProductRepo)
+	mockVariantRepo := new(MockVariantRepo)
+	variantService := service.NewVariantService(mockProductRepo, mockVariantRepo)
+
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(productWithVariants(), nil)
+
+	_, err := variantService.UpdateVariantStock(context.Background(), 1, 6, 1, -3)
+
+	assert.EqualError(t, err, "stock cannot be negative")
+	mockVariantRepo.AssertNotCalled(t, "UpdateVariant", mock.Anything, mock.Anything)
+}
+
+func TestDeleteVariant_RemovesStockFromProduct(t *testing.T) {
+	mockProductRepo := new(MockProductRepo)
+	mockVariantRepo := new(MockVariantRepo)
+	variantService := service.NewVariantService(mockProductRepo, mockVariantRepo)
+
+	product := productWithVariants()
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	mockVariantRepo.On("DeleteVariant", mock.Anything, uint(5), uint(1)).Return(nil)
+	mockProductRepo.On("Update", mock.Anything, product, uint(1)).Return(nil)
+
+	err := variantService.DeleteVariant(context.Background(), 1, 5, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, product.Stock)
+}
+
+func TestCreateOrder_VariantUsesVariantPriceAndStock(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepo)
+	mockProductRepo := new(MockProductRepo)
+	mockVariantRepo := new(MockVariantRepo)
+	orderService := service.NewOrderService(mockOrderRepo, mockProductRepo, service.WithOrderVariants(mockVariantRepo))
+
+	product := productWithVariants()
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(product, nil)
+	mockVariantRepo.On("UpdateVariant", mock.Anything, mock.AnythingOfType("*domain.ProductVariant")).Return(nil)
+	mockProductRepo.On("Update", mock.Anything, product, uint(1)).Return(nil)
+	mockOrderRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Order")).Return(nil)
+	mockOrderRepo.On("FindByIDAndUserID", mock.Anything, mock.Anything, uint(1)).Return(&domain.Order{ID: 1}, nil)
+
+	variantID := uint(6)
+	_, err := orderService.CreateOrder(context.Background(), 1, service.CreateOrderRequest{Items: []service.OrderItemRequest{{ProductID: 1, VariantID: &variantID, Quantity: 2}}})
+
+	assert.NoError(t, err)
+	created := mockOrderRepo.Calls[0].Arguments.Get(1).(*domain.Order)
+	assert.Equal(t, 12.0, created.Items[0].UnitPrice)
+	assert.Equal(t, 24.0, created.TotalAmount)
+	variant, _ := product.Variant(6)
+	assert.Equal(t, 0, variant.Stock)
+	assert.Equal(t, 3, product.Stock)
+}
+
+func TestCreateOrder_Error_VariantRequired(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepo)
+	mockProductRepo := new(MockProductRepo)
+	orderService := service.NewOrderService(mockOrderRepo, mockProductRepo, service.WithOrderVariants(new(MockVariantRepo)))
+
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(productWithVariants(), nil)
+
+	_, err := orderService.CreateOrder(context.Background(), 1, service.CreateOrderRequest{Items: []service.OrderItemRequest{{ProductID: 1, Quantity: 1}}})
+
+	assert.EqualError(t, err, "variant_id is required for product: T-Shirt")
+	mockOrderRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateOrder_Error_VariantInsufficientStock(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepo)
+	mockProductRepo := new(MockProductRepo)
+	orderService := service.NewOrderService(mockOrderRepo, mockProductRepo, service.WithOrderVariants(new(MockVariantRepo)))
+
+	mockProductRepo.On("FindByIDAndUserID", mock.Anything, uint(1), uint(1)).Return(productWithVariants(), nil)
+
+	variantID := uint(6)
+	_, err := orderService.CreateOrder(context.Background(), 1, service.CreateOrderRequest{Items: []service.OrderItemRequest{{ProductID: 1, VariantID: &variantID, Quantity: 3}}})
+
+	assert.Error(t, err)
+	mockOrderRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,439 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"vertice-backend/internal/domain"
+	"vertice-backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWebhookRepo struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepo) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	args := m.Called(ctx, subscription)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepo) FindSubscriptionByIDAndUserID(ctx context.Context, id, userID uint) (*domain.WebhookSubscription, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookRepo) FindSubscriptionsByUserID(ctx context.Context, userID uint) ([]*domain.WebhookSubscription, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookRepo) FindActiveSubscriptions(ctx context.Context, userID uint) ([]*domain.WebhookSubscription, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookRepo) UpdateSubscription(ctx context.Context, subscription *domain.WebhookSubscription, userID uint) error {
+	args := m.Called(ctx, subscription, userID)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepo) DeleteSubscription(ctx context.Context, id, userID uint) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepo) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepo) FindDeliveryByIDAndUserID(ctx context.Context, id, userID uint) (*domain.WebhookDelivery, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepo) FindDeliveriesBySubscriptionID(ctx context.Context, subscriptionID, userID uint) ([]*domain.WebhookDelivery, error) {
+	args := m.Called(ctx, subscriptionID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepo) LockDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	args := m.Called(ctx, now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepo) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepo) CreateAttempt(ctx context.Context, attempt *domain.WebhookAttempt) error {
+	args := m.Called(ctx, attempt)
+	return args.Error(0)
+}
+
+// staticResolver resolves hosts from a fixed table.
+type staticResolver map[string][]netip.Addr
+
+func (r staticResolver) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	addrs, ok := r[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return addrs, nil
+}
+
+var publicResolver = staticResolver{
+	"erp.example.com":      {netip.MustParseAddr("93.184.216.34")},
+	"metadata.example.com": {netip.MustParseAddr("93.184.216.34"), netip.MustParseAddr("169.254.169.254")},
+	"intranet.example.com": {netip.MustParseAddr("10.0.0.5")},
+}
+
+func TestCreateWebhook_GeneratesSecret(t *testing.T) {
+	mockRepo := new(MockWebhookRepo)
+	webhookService := service.NewWebhookService(mockRepo, service.WithWebhookResolver(publicResolver))
+
+	mockRepo.On("CreateSubscription", mock.Anything, mock.AnythingOfType("*domain.WebhookSubscription")).Return(nil)
+
+	subscription, err := webhookService.CreateSubscription(context.Background(), 1, service.CreateWebhookRequest{
+		URL:    "https://erp.example.com/hooks",
+		Events: []string{domain.EventOrderCreated, domain.EventOrderStatusChanged},
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, subscription.Secret, 64)
+	assert.True(t, subscription.Active)
+	assert.Equal(t, []string{domain.EventOrderCreated, domain.EventOrderStatusChanged}, subscription.EventTypes())
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateWebhook_Error_UnknownEvent(t *testing.T) {
+	mockRepo := new(MockWebhookRepo)
+	webhookService := service.NewWebhookService(mockRepo, service.WithWebhookResolver(publicResolver))
+
+	_, err := webhookService.CreateSubscription(context.Background(), 1, service.CreateWebhookRequest{
+		URL:    "https://erp.example.com/hooks",
+		Events: []string{"order.exploded"},
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "unknown event type: order.exploded", err.Error())
+}
+
+func TestCreateWebhook_Error_InvalidURL(t *testing.T) {
+	mockRepo := new(MockWebhookRepo)
+	webhookService := service.NewWebhookService(mockRepo, service.WithWebhookResolver(publicResolver))
+
+	_, err := webhookService.CreateSubscription(context.Background(), 1, service.CreateWebhookRequest{
+		URL:    "ftp://erp.example.com",
+		Events: []string{domain.EventOrderCreated},
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "url must be an absolute http or https URL", err.Error())
+}
+
+func TestDispatch_OnlyMatchingSubscriptions(t *testing.T) {
+	mockRepo := new(MockWebhookRepo)
+	webhookService := service.NewWebhookService(mockRepo)
+
+	orders := &domain.WebhookSubscription{ID: 1, UserID: 1, Active: true, Events: domain.EventOrderCreated}
+	stock := &domain.WebhookSubscription{ID: 2, UserID: 1, Active: true, Events: domain.EventProductStockLow}
+	mockRepo.On("FindActiveSubscriptions", mock.Anything, uint(1)).Return([]*domain.WebhookSubscription{orders, stock}, nil)
+	mockRepo.On("CreateDelivery", mock.Anything, mock.MatchedBy(func(d *domain.WebhookDelivery) bool {
+		return d.SubscriptionID == 1 && d.EventID == 42 && d.EventType == domain.EventOrderCreated && d.Status == domain.WebhookDeliveryPending
+	})).Return(nil).Once()
+
+	err := webhookService.Dispatch(context.Background(), 1, 42, domain.EventOrderCreated, map[string]uint{"order_id": 7})
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProcessDue_Success_SignsRequest(t *testing.T) {
+	var received *http.Request
+	var receivedBody []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	mockRepo := new(MockWebhookRepo)
+	webhookService := service.NewWebhookService(mockRepo, service.WithWebhookHTTPClient(receiver.Client()))
+
+	payload := `{"type":"order.created","data":{"order_id":7}}`
+	delivery := &domain.WebhookDelivery{
+		ID:        3,
+		EventType: domain.EventOrderCreated,
+		Payload:   payload,
+		Status:    domain.WebhookDeliveryPending,
+		Subscription: &domain.WebhookSubscription{
+			ID: 1, URL: receiver.URL, Secret: "topsecret", Active: true,
+		},
+	}
+	mockRepo.On("LockDueDeliveries", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]*domain.WebhookDelivery{delivery}, nil)
+	mockRepo.On("CreateAttempt", mock.Anything, mock.MatchedBy(func(a *domain.WebhookAttempt) bool {
+		return a.DeliveryID == 3 && a.Number == 1 && a.StatusCode == http.StatusNoContent && a.Error == ""
+	})).Return(nil)
+	mockRepo.On("UpdateDelivery", mock.Anything, delivery).Return(nil)
+
+	processed, err := webhookService.ProcessDue(context.Background(), 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	assert.Equal(t, payload, string(receivedBody))
+	assert.Equal(t, domain.EventOrderCreated, received.Header.Get(service.WebhookEventHeader))
+	assert.Equal(t, "3", received.Header.Get(service.WebhookDeliveryHeader))
+	timestamp := received.Header.Get(service.WebhookTimestampHeader)
+	assert.Equal(t, service.SignWebhookPayload("topsecret", timestamp, []byte(payload)), received.Header.Get(service.WebhookSignatureHeader))
+	assert.Equal(t, domain.WebhookDeliverySucceeded, delivery.Status)
+	assert.NotNil(t, delivery.DeliveredAt)
+	assert.Nil(t, delivery.NextAttemptAt)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProcessDue_Failure_SchedulesRetryWithBackoff(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	mockRepo := new(MockWebhookRepo)
+	webhookService := service.NewWebhookService(mockRepo, service.WithWebhookHTTPClient(receiver.Client()), service.WithWebhookRetryPolicy(service.WebhookRetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+	}))
+
+	delivery := &domain.WebhookDelivery{
+		ID:           3,
+		Payload:      `{}`,
+		Attempts:     2,
+		Status:       domain.WebhookDeliveryRetrying,
+		Subscription: &domain.WebhookSubscription{URL: receiver.URL, Secret: "s", Active: true},
+	}
+	mockRepo.On("LockDueDeliveries", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]*domain.WebhookDelivery{delivery}, nil)
+	mockRepo.On("CreateAttempt", mock.Anything, mock.AnythingOfType("*domain.WebhookAttempt")).Return(nil)
+	mockRepo.On("UpdateDelivery", mock.Anything, delivery).Return(nil)
+
+	before := time.Now()
+	_, err := webhookService.ProcessDue(context.Background(), 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Equal(t, domain.WebhookDeliveryRetrying, delivery.Status)
+	assert.Equal(t, http.StatusInternalServerError, delivery.LastStatusCode)
+	assert.WithinDuration(t, before.Add(4*time.Minute), *delivery.NextAttemptAt, 5*time.Second)
+}
+
+func TestProcessDue_Failure_DeadLettersAfterMaxAttempts(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer receiver.Close()
+
+	mockRepo := new(MockWebhookRepo)
+	webhookService := service.NewWebhookService(mockRepo, service.WithWebhookHTTPClient(receiver.Client()), service.WithWebhookRetryPolicy(service.WebhookRetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+	}))
+
+	delivery := &domain.WebhookDelivery{
+		ID:           3,
+		Payload:      `{}`,
+		Attempts:     2,
+		Status:       domain.WebhookDeliveryRetrying,
+		Subscription: &domain.WebhookSubscription{URL: receiver.URL, Secret: "s", Active: true},
+	}
+	mockRepo.On("LockDueDeliveries", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]*domain.WebhookDelivery{delivery}, nil)
+	mockRepo.On("CreateAttempt", mock.Anything, mock.AnythingOfType("*domain.WebhookAttempt")).Return(nil)
+	mockRepo.On("UpdateDelivery", mock.Anything, delivery).Return(nil)
+
+	_, err := webhookService.ProcessDue(context.Background(), 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.WebhookDeliveryDead, delivery.Status)
+	assert.Nil(t, delivery.NextAttemptAt)
+}
+
+func TestRedeliver_ResetsDeadDelivery(t *testing.T) {
+	mockRepo := new(MockWebhookRepo)
+	webhookService := service.NewWebhookService(mockRepo)
+
+	delivery := &domain.WebhookDelivery{ID: 3, SubscriptionID: 1, UserID: 1, Attempts: 8, Status: domain.WebhookDeliveryDead}
+	mockRepo.On("FindDeliveryByIDAndUserID", mock.Anything, uint(3), uint(1)).Return(delivery, nil)
+	mockRepo.On("UpdateDelivery", mock.Anything, delivery).Return(nil)
+
+	redelivered, err := webhookService.Redeliver(context.Background(), 1, 3, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.WebhookDeliveryPending, redelivered.Status)
+	assert.Equal(t, 8, redelivered.Attempts)
+	assert.Equal(t, 8, redelivered.RetryFrom)
+	assert.NotNil(t, redelivered.NextAttemptAt)
+}
+
+func TestProcessDue_RedeliveredDeliveryContinuesNumbering(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	mockRepo := new(MockWebhookRepo)
+	webhookService := service.NewWebhookService(mockRepo, service.WithWebhookHTTPClient(receiver.Client()), service.WithWebhookRetryPolicy(service.WebhookRetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+	}))
+
+	// Dead after three attempts, then redelivered.
+	delivery := &domain.WebhookDelivery{
+		ID:           3,
+		Payload:      `{}`,
+		Attempts:     3,
+		RetryFrom:    3,
+		Status:       domain.WebhookDeliveryPending,
+		Subscription: &domain.WebhookSubscription{URL: receiver.URL, Secret: "s", Active: true},
+	}
+	mockRepo.On("LockDueDeliveries", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]*domain.WebhookDelivery{delivery}, nil)
+	mockRepo.On("CreateAttempt", mock.Anything, mock.MatchedBy(func(a *domain.WebhookAttempt) bool {
+		return a.Number == 4
+	})).Return(nil)
+	mockRepo.On("UpdateDelivery", mock.Anything, delivery).Return(nil)
+
+	before := time.Now()
+	_, err := webhookService.ProcessDue(context.Background(), 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, delivery.Attempts)
+	assert.Equal(t, domain.WebhookDeliveryRetrying, delivery.Status)
+	assert.WithinDuration(t, before.Add(time.Minute), *delivery.NextAttemptAt, 5*time.Second)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRedeliver_Error_OtherSubscription(t *testing.T) {
+	mockRepo := new(MockWebhookRepo)
+	webhookService := service.NewWebhookService(mockRepo)
+
+	mockRepo.On("FindDeliveryByIDAndUserID", mock.Anything, uint(3), uint(1)).Return(&domain.WebhookDelivery{ID: 3, SubscriptionID: 2}, nil)
+
+	_, err := webhookService.Redeliver(context.Background(), 1, 3, 1)
+
+	assert.Error(t, err)
+	assert.Equal(t, "delivery not found", err.Error())
+}
+
+func TestCreateWebhook_Error_InternalAddress(t *testing.T) {
+	mockRepo := new(MockWebhookRepo)
+	webhookService := service.NewWebhookService(mockRepo, service.WithWebhookResolver(publicResolver))
+
+	for url, want := range map[string]string{
+		"http://127.0.0.1:8080/hooks":         "url must not point to the internal address 127.0.0.1",
+		"http://[::1]/hooks":                  "url must not point to the internal address ::1",
+		"http://0.0.0.0/hooks":                "url must not point to the internal address 0.0.0.0",
+		"https://intranet.example.com/hooks":  "url must not point to the internal address 10.0.0.5",
+		"https://metadata.example.com/latest": "url must not point to the internal address 169.254.169.254",
+		"https://unknown.example.com/hooks":   "url host unknown.example.com could not be resolved",
+	} {
+		_, err := webhookService.CreateSubscription(context.Background(), 1, service.CreateWebhookRequest{
+			URL:    url,
+			Events: []string{domain.EventOrderCreated},
+		})
+		if assert.Error(t, err, url) {
+			assert.Equal(t, want, err.Error(), url)
+		}
+	}
+	mockRepo.AssertNotCalled(t, "CreateSubscription", mock.Anything, mock.Anything)
+}
+
+func TestProcessDue_DefaultClientRefusesInternalAddress(t *testing.T) {
+	called := false
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer receiver.Close()
+
+	mockRepo := new(MockWebhookRepo)
+	webhookService := service.NewWebhookService(mockRepo)
+
+	// The URL passed validation once but now resolves to loopback.
+	delivery := &domain.WebhookDelivery{
+		ID:           3,
+		Payload:      `{}`,
+		Status:       domain.WebhookDeliveryPending,
+		Subscription: &domain.WebhookSubscription{URL: receiver.URL, Secret: "s", Active: true},
+	}
+	mockRepo.On("LockDueDeliveries", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]*domain.WebhookDelivery{delivery}, nil)
+	mockRepo.On("CreateAttempt", mock.Anything, mock.AnythingOfType("*domain.WebhookAttempt")).Return(nil)
+	mockRepo.On("UpdateDelivery", mock.Anything, delivery).Return(nil)
+
+	_, err := webhookService.ProcessDue(context.Background(), 10)
+
+	assert.NoError(t, err)
+	assert.False(t, called)
+	assert.Equal(t, domain.WebhookDeliveryRetrying, delivery.Status)
+	assert.Contains(t, delivery.LastError, "url must not point to the internal address 127.0.0.1")
+}
+
+func TestProcessDue_ContinuesAfterFailedDelivery(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	mockRepo := new(MockWebhookRepo)
+	tx := &countingTransactor{}
+	webhookService := service.NewWebhookService(mockRepo,
+		service.WithWebhookHTTPClient(receiver.Client()),
+		service.WithWebhookTransactor(tx),
+	)
+
+	subscription := &domain.WebhookSubscription{URL: receiver.URL, Secret: "s", Active: true}
+	first := &domain.WebhookDelivery{ID: 3, Payload: `{}`, Status: domain.WebhookDeliveryPending, Subscription: subscription}
+	second := &domain.WebhookDelivery{ID: 4, Payload: `{}`, Status: domain.WebhookDeliveryPending, Subscription: subscription}
+	mockRepo.On("LockDueDeliveries", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]*domain.WebhookDelivery{first, second}, nil)
+	mockRepo.On("CreateAttempt", mock.Anything, mock.MatchedBy(func(a *domain.WebhookAttempt) bool { return a.DeliveryID == 3 })).Return(errors.New("db down"))
+	mockRepo.On("CreateAttempt", mock.Anything, mock.MatchedBy(func(a *domain.WebhookAttempt) bool { return a.DeliveryID == 4 })).Return(nil)
+	mockRepo.On("UpdateDelivery", mock.Anything, mock.Anything).Return(nil)
+
+	before := time.Now()
+	processed, err := webhookService.ProcessDue(context.Background(), 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	assert.Equal(t, 1, tx.calls)
+	// The first delivery keeps its claim and is retried once it runs out.
+	assert.Equal(t, domain.WebhookDeliveryPending, first.Status)
+	assert.True(t, first.NextAttemptAt.After(before.Add(time.Minute)))
+	assert.Equal(t, domain.WebhookDeliverySucceeded, second.Status)
+}